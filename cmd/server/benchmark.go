@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/pkg/server"
+)
+
+// runBenchmark runs the engine benchmark suite with the server's engine configuration and prints
+// the report as JSON. It returns the exit status: 1 when the benchmark fails or the run is
+// unhealthy compared with the baseline.
+func runBenchmark(cfg *server.Config, args []string) int {
+	flags := flag.NewFlagSet("benchmark", flag.ExitOnError)
+	depth := flags.Int("depth", 0, "depth every position is searched to (default 16)")
+	save := flags.Bool("save", false, "keep the run as the baseline in ANALYSIS_BENCHMARK_FILE")
+	flags.Parse(args)
+
+	// Only the engines are needed, so background work is left off
+	cfg.Sync.Players = nil
+	cfg.Analysis.OpeningWarmupLines = 0
+
+	services, closeServices, err := server.NewServices(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to initialize services:", err)
+		return 1
+	}
+	defer closeServices()
+
+	report, err := services.Analysis.RunBenchmark(context.Background(), &models.BenchmarkRequest{
+		Depth:        *depth,
+		SaveBaseline: *save,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Benchmark failed:", err)
+		return 1
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(report)
+	if report.Comparison != nil && !report.Comparison.Healthy {
+		return 1
+	}
+	return 0
+}
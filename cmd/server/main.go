@@ -1,60 +1,139 @@
 package main
 
 import (
+	"context"
 	"log"
+	"os"
+	"time"
 
-	"github.com/pedrampdd/ChessAnalyser/internal/api"
-	"github.com/pedrampdd/ChessAnalyser/internal/config"
-	"github.com/pedrampdd/ChessAnalyser/internal/models"
-	service "github.com/pedrampdd/ChessAnalyser/internal/service"
+	"github.com/pedrampdd/ChessAnalyser/internal/telemetry"
+	"github.com/pedrampdd/ChessAnalyser/pkg/server"
 )
 
 func main() {
 	// Load configuration
-	cfg := config.LoadConfig()
-
-	// Initialize the game analyzer service
-	gameService := service.NewGameAnalyzerService()
-
-	// Initialize the analysis service
-	defaultSettings := models.EngineSettings{
-		Depth:      cfg.Stockfish.DefaultDepth,
-		TimeLimit:  cfg.Stockfish.DefaultTimeLimit,
-		Threads:    cfg.Stockfish.DefaultThreads,
-		HashSize:   cfg.Stockfish.DefaultHashSize,
-		SkillLevel: cfg.Stockfish.DefaultSkillLevel,
-		Contempt:   cfg.Stockfish.DefaultContempt,
-		MultiPV:    1,
+	cfg := server.LoadConfig()
+
+	// "benchmark" runs the engine benchmark suite instead of the server
+	if len(os.Args) > 1 && os.Args[1] == "benchmark" {
+		os.Exit(runBenchmark(cfg, os.Args[2:]))
 	}
 
-	analysisService, err := service.NewAnalysisService(
-		cfg.Stockfish.ExecutablePath,
-		cfg.Stockfish.MaxEngines,
-		defaultSettings,
-	)
+	// Export engine spans when tracing is enabled; the server runs without traces otherwise
+	if cfg.Tracing.Enabled {
+		shutdownTracing, err := telemetry.SetupTracing(context.Background(), cfg.Tracing.ServiceName, cfg.Tracing.SamplePercent)
+		if err != nil {
+			log.Println("Tracing unavailable:", err)
+		} else {
+			defer func() {
+				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+				shutdownTracing(ctx)
+			}()
+		}
+	}
+
+	// Initialize the services and stop their background work on exit
+	services, closeServices, err := server.NewServices(cfg)
 	if err != nil {
-		log.Fatal("Failed to initialize analysis service:", err)
+		log.Fatal("Failed to initialize services:", err)
 	}
-	defer analysisService.Close()
+	defer closeServices()
 
 	// Setup routes
-	router := api.SetupRoutes(gameService, analysisService)
+	srv := server.NewServer(
+		server.WithServices(services),
+		server.WithRouterOptions(server.RouterOptionsFromConfig(cfg)),
+	)
 
 	// Start the server
 	log.Printf("Starting Chess Analyzer API server on %s:%s", cfg.Server.Host, cfg.Server.Port)
-	log.Println("Available endpoints:")
+	log.Println("Available endpoints (also under /api/v1 and /api/v2; unversioned /api is deprecated):")
 	log.Println("  GET /health - Health check")
-	log.Println("  GET /api/game/{gameId} - Get game by ID")
+	log.Println("  GET /metrics - Aggregated analysis resource usage")
+	log.Println("  GET /api/game/{gameId} - Get game by ID, including live games by numeric ID")
 	log.Println("  GET /api/player/{username}/games?year=YYYY&month=MM - Get player's games")
 	log.Println("  GET /api/player/{username}/profile - Get player profile")
 	log.Println("  GET /api/player/{username}/stats - Get player stats")
+	log.Println("  GET /api/player/{username}/heatmaps - Per-square statistics across recent games")
+	log.Println("  GET /api/player/{username}/report - Endgame performance across recent games")
+	log.Println("  GET /api/player/{username}/novelties - Where recent games left opening theory")
+	log.Println("  GET /api/player/{username}/tournaments - Performance in each Chess.com tournament")
 	log.Println("  POST /api/analyze/game - Analyze a chess game")
+	log.Println("  GET /api/analyze/game?url=URL - Fetch and analyze a Chess.com game by URL or ID")
+	log.Println("  GET /api/analyze/jobs/{id} - Get a background game analysis")
 	log.Println("  GET /api/analyze/position?fen=FEN - Analyze a chess position")
+	log.Println("  GET /api/analyze/position/stream?fen=FEN - Stream a cached evaluation, then the deeper result")
+	log.Println("  POST /api/analyze/batch - Analyze many positions or games in one request")
+	log.Println("  GET /api/analyze/position/lines?fen=FEN&multipv=5 - Explore the top engine lines of a position")
+	log.Println("  GET /api/analyze/suggestion?fen=FEN&rating=R - Suggest a move for a player's level")
+	log.Println("  GET /api/analyze/mate?fen=FEN&moves=N - Look for a forced mate within N moves")
+	log.Println("  POST /api/analyze/selfplay - Have the engine play a position out against itself")
+	log.Println("  POST /api/analyze/benchmark - Benchmark the engine and compare with the stored baseline")
 	log.Println("  GET /api/analyze/status - Get engine status")
 	log.Println("  DELETE /api/analyze/cache - Clear analysis cache")
+	log.Println("  GET /api/openings/cache - Opening cache statistics")
+	log.Println("  POST /api/openings/cache/warmup - Pre-analyze the most popular opening lines")
+	log.Println("  GET /api/analysis/{id} - Get a stored analysis")
+	log.Println("  GET /api/analysis/diff?a=ID1&b=ID2 - Compare two analyses of the same game")
+	log.Println("  GET /api/analysis/dataset?format=csv|parquet - Export analyzed positions as an ML dataset")
+	log.Println("  PATCH /api/analysis/{id} - Edit move comments and classifications")
+	log.Println("  POST /api/analysis/{id}/reclassify - Re-derive move classifications with new thresholds or accuracy model")
+	log.Println("  GET /api/analysis/{id}/export?format=json|pgn - Export a stored analysis")
+	log.Println("  GET /api/analysis/{id}/key-moments - Get key moments for a guided review")
+	log.Println("  GET /api/analysis/{id}/position/{ply} - Get the position at one ply of an analysis")
+	log.Println("  POST /api/analysis/{id}/share - Create a public share link")
+	log.Println("  GET /share/{token} - View a shared analysis")
+	log.Println("  POST|GET /api/analysis/{id}/artifacts?format=json|pgn - Export an analysis to the blob store or list its artifacts")
+	log.Println("  GET /api/artifacts/{id}/download - Signed download URL of an artifact")
+	log.Println("  DELETE /api/artifacts/{id} - Delete an artifact")
+	log.Println("  GET /api/analytics/club/{clubId} - Aggregate analytics for a club")
+	log.Println("  GET /api/analytics/country/{iso} - Aggregate analytics for a country")
+	log.Println("  POST /api/screening - Fair play consistency screening across a player's games")
+	log.Println("  GET /api/prepare?opponent=USER&color=white - Build an opening preparation dossier on an opponent")
+	log.Println("  GET|PUT|DELETE /api/preferences - Manage saved user preferences")
+	log.Println("  GET /api/admin/audit - Audit log of game analysis requests (admin API key)")
+	log.Println("  GET /api/sync/status - Archive sync state of configured players")
+	log.Println("  GET /api/sync/notifications - Rating milestones, title changes and streaks of synced players")
+	log.Println("  GET /api/sync/notifications/stream - Stream notifications of synced players (SSE)")
+	log.Println("  POST /api/sync/{username} - Sync a player's archives now")
+	log.Println("  POST /api/sync/{username}/repair - Re-fetch stored games missing their PGN or FEN")
+	log.Println("  GET /api/player/{username}/synced-games - Games stored by archive sync")
+	log.Println("  GET /api/player/{username}/synced-games/source?url=URL - A synced game as Chess.com served it")
+	log.Println("  GET /api/player/{username}/raw-archives - Raw archive snapshots kept by archive sync")
+	log.Println("  POST /api/watch - Watch an ongoing game for evaluation swings")
+	log.Println("  GET /api/watch/{id} - Get a watched game's evaluation updates")
+	log.Println("  GET /api/watch/{id}/stream - Stream evaluation updates (server-sent events)")
+	log.Println("  DELETE /api/watch/{id} - Stop watching a game")
+	log.Println("  POST /api/broadcast - Follow a live event's PGN relay and analyze every board")
+	log.Println("  GET /api/broadcast/{id} - Get a broadcast's boards and evaluation updates")
+	log.Println("  GET /api/broadcast/{id}/stream?board=N - Stream evaluation updates (server-sent events)")
+	log.Println("  DELETE /api/broadcast/{id} - Stop following a broadcast")
+	log.Println("  POST /api/imports - Start a resumable PGN database upload")
+	log.Println("  HEAD|PATCH /api/imports/{id} - Resume or send upload chunks")
+	log.Println("  GET /api/imports/{id} - Upload and parsing progress")
+	log.Println("  GET /api/imports/{id}/games - Games parsed from an import")
+	log.Println("  DELETE /api/imports/{id} - Cancel an import")
+	log.Println("  POST|GET /api/watchlist - Add a player to the watchlist or list watched players")
+	log.Println("  GET /api/watchlist/{id} - Get a watched player's schedule and last report")
+	log.Println("  POST /api/watchlist/{id}/report - Build and deliver a report now")
+	log.Println("  DELETE /api/watchlist/{id} - Remove a player from the watchlist")
+	log.Println("  POST /api/play - Start a game against the engine")
+	log.Println("  GET|DELETE /api/play/{id} - Get or delete a game against the engine")
+	log.Println("  POST /api/play/{id}/moves - Play a move and get the engine's reply")
+	log.Println("  POST /api/play/{id}/resign - Resign a game against the engine")
+	log.Println("  GET /api/play/{id}/pgn - Download a game against the engine as PGN")
+	log.Println("  POST /api/training/puzzles - Turn a stored analysis's errors into training puzzles")
+	log.Println("  GET /api/training/puzzles/due - Puzzles due for review")
+	log.Println("  POST /api/training/puzzles/{id}/attempts - Answer a puzzle and schedule its next review")
+	log.Println("  GET /api/training/stats - Puzzle training statistics")
+	log.Println("  POST|GET /api/collections - Create or list game collections")
+	log.Println("  GET|PUT|DELETE /api/collections/{id} - Get, update or delete a collection")
+	log.Println("  POST|DELETE /api/collections/{id}/items - Add or remove analyses and synced games")
+	log.Println("  GET /api/collections/{id}/report - Aggregate report of a collection's games")
 
 	serverAddr := cfg.Server.Host + ":" + cfg.Server.Port
-	if err := router.Run(serverAddr); err != nil {
+	if err := srv.Run(serverAddr); err != nil {
 		log.Fatal("Failed to start server:", err)
 	}
 }
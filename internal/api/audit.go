@@ -0,0 +1,23 @@
+package api
+
+import (
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuditRequester marks requests with who sent them, by API key fingerprint and client IP, so
+// the game analyses they run are attributed to them in the audit log. The client IP is only read
+// from forwarding headers set by the router's trusted proxies.
+func AuditRequester() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := service.WithRequester(c.Request.Context(), models.Requester{
+			KeyFingerprint: service.KeyFingerprint(c.GetHeader("X-API-Key")),
+			IP:             c.ClientIP(),
+			Endpoint:       c.Request.Method + " " + c.FullPath(),
+		})
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
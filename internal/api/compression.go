@@ -0,0 +1,246 @@
+package api
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Encoder compresses a response body. Flush pushes buffered output to the client so
+// streamed responses keep flowing while compressed.
+type Encoder interface {
+	io.WriteCloser
+	Flush() error
+}
+
+// EncoderFactory creates an encoder writing to w at a compression level
+type EncoderFactory func(w io.Writer, level int) (Encoder, error)
+
+// encoders lists the content codings the server can produce. Further codings can be added
+// with RegisterEncoder.
+var encoders = map[string]EncoderFactory{
+	"zstd": func(w io.Writer, level int) (Encoder, error) {
+		// The window is capped at 8 MB, the most browsers decode (RFC 8878)
+		return zstd.NewWriter(w,
+			zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)),
+			zstd.WithEncoderConcurrency(1),
+			zstd.WithWindowSize(8<<20))
+	},
+	"gzip": func(w io.Writer, level int) (Encoder, error) {
+		return gzip.NewWriterLevel(w, level)
+	},
+	"deflate": func(w io.Writer, level int) (Encoder, error) {
+		return flate.NewWriter(w, level)
+	},
+}
+
+// RegisterEncoder adds a content coding the compression middleware can negotiate
+func RegisterEncoder(name string, factory EncoderFactory) {
+	encoders[name] = factory
+}
+
+// CompressionConfig configures response compression
+type CompressionConfig struct {
+	Enabled   bool
+	Encodings []string // Codings offered, in order of preference
+	Level     int      // Compression level, 1 (fastest) to 9 (smallest)
+	MinSize   int      // Smaller responses are sent uncompressed
+}
+
+// Compression compresses responses with the best coding both the client accepts and the
+// server offers. Bodies under MinSize, event streams, partial content and bodies that are
+// already encoded are passed through untouched.
+func Compression(config CompressionConfig) gin.HandlerFunc {
+	var offered []string
+	for _, name := range config.Encodings {
+		if _, ok := encoders[name]; ok {
+			offered = append(offered, name)
+		}
+	}
+
+	return func(c *gin.Context) {
+		if !config.Enabled || c.Request.Method == http.MethodHead {
+			c.Next()
+			return
+		}
+		encoding := negotiateEncoding(c.GetHeader("Accept-Encoding"), offered)
+		if encoding == "" {
+			c.Next()
+			return
+		}
+
+		writer := &compressWriter{ResponseWriter: c.Writer, encoding: encoding, level: config.Level, minSize: config.MinSize}
+		c.Writer = writer
+		c.Header("Vary", "Accept-Encoding")
+		defer writer.close()
+
+		c.Next()
+	}
+}
+
+// negotiateEncoding picks the offered coding with the highest quality in an Accept-Encoding
+// header, preferring the server's order on ties. It returns "" when nothing offered is acceptable.
+func negotiateEncoding(acceptEncoding string, offered []string) string {
+	quality := make(map[string]float64)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				q = parsed
+			}
+		}
+		quality[name] = q
+	}
+
+	candidates := make([]string, 0, len(offered))
+	for _, name := range offered {
+		q, ok := quality[name]
+		if !ok {
+			q = quality["*"]
+		}
+		if q > 0 {
+			candidates = append(candidates, name)
+		}
+	}
+	if len(candidates) == 0 {
+		return ""
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return codingQuality(quality, candidates[i]) > codingQuality(quality, candidates[j])
+	})
+	return candidates[0]
+}
+
+// codingQuality returns a coding's quality, falling back to the "*" wildcard
+func codingQuality(quality map[string]float64, name string) float64 {
+	if q, ok := quality[name]; ok {
+		return q
+	}
+	return quality["*"]
+}
+
+// compressWriter holds back the start of a response until it knows whether the body is worth
+// compressing, then either compresses everything or passes it through
+type compressWriter struct {
+	gin.ResponseWriter
+	encoding string
+	level    int
+	minSize  int
+	buf      []byte
+	size     int // Uncompressed bytes written by the handler
+	decided  bool
+	encoder  Encoder
+}
+
+func (w *compressWriter) Write(data []byte) (int, error) {
+	w.size += len(data)
+	if !w.decided {
+		w.buf = append(w.buf, data...)
+		if len(w.buf) < w.minSize {
+			return len(data), nil
+		}
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+		return len(data), nil
+	}
+
+	if w.encoder != nil {
+		return w.encoder.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Written reports whether the handler wrote a response, even one still held back
+func (w *compressWriter) Written() bool {
+	return w.size > 0 || w.ResponseWriter.Written()
+}
+
+// Size returns the uncompressed body size
+func (w *compressWriter) Size() int {
+	return w.size
+}
+
+// Flush sends everything written so far. A handler flushing early is streaming a body of
+// unknown length, which is compressed regardless of MinSize.
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		w.decide(true)
+	}
+	if w.encoder != nil {
+		w.encoder.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// decide chooses whether to compress a body large enough to be worth it and writes out
+// the held-back start of the body
+func (w *compressWriter) decide(worthIt bool) error {
+	w.decided = true
+	if worthIt && w.compressible() {
+		encoder, err := encoders[w.encoding](w.ResponseWriter, w.level)
+		if err == nil {
+			w.encoder = encoder
+			w.Header().Set("Content-Encoding", w.encoding)
+			w.Header().Del("Content-Length")
+		}
+	}
+
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	var err error
+	if w.encoder != nil {
+		_, err = w.encoder.Write(buf)
+	} else {
+		_, err = w.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+// compressible reports whether the response may be compressed
+func (w *compressWriter) compressible() bool {
+	header := w.Header()
+	switch {
+	case header.Get("Content-Encoding") != "", header.Get("Content-Range") != "":
+		return false
+	case strings.HasPrefix(header.Get("Content-Type"), "text/event-stream"):
+		return false
+	}
+
+	status := w.Status()
+	return status != http.StatusNoContent && status != http.StatusNotModified && status != http.StatusPartialContent
+}
+
+// close writes out a body too small to compress and finishes the compressed stream
+func (w *compressWriter) close() {
+	if !w.decided {
+		if w.size == 0 {
+			return
+		}
+		w.decide(false)
+	}
+	if w.encoder != nil {
+		w.encoder.Close()
+	}
+}
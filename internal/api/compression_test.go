@@ -0,0 +1,130 @@
+package api
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	offered := []string{"zstd", "gzip", "deflate"}
+	tests := []struct {
+		acceptEncoding string
+		want           string
+	}{
+		{"", ""},
+		{"gzip", "gzip"},
+		{"gzip, deflate, br, zstd", "zstd"}, // Ties go to the server's preference
+		{"GZIP", "gzip"},
+		{"gzip;q=0.5, deflate;q=0.8", "deflate"},
+		{"zstd;q=0, gzip", "gzip"},
+		{"*", "zstd"},
+		{"*;q=0.1, gzip;q=0.5", "gzip"},
+		{"*, zstd;q=0", "gzip"},
+		{"br", ""},
+		{"identity", ""},
+	}
+	for _, tt := range tests {
+		if got := negotiateEncoding(tt.acceptEncoding, offered); got != tt.want {
+			t.Errorf("negotiateEncoding(%q) = %q, want %q", tt.acceptEncoding, got, tt.want)
+		}
+	}
+}
+
+// decode decompresses a response body in a content coding
+func decode(t *testing.T, encoding string, body io.Reader) string {
+	t.Helper()
+	var reader io.Reader
+	switch encoding {
+	case "gzip":
+		gz, err := gzip.NewReader(body)
+		if err != nil {
+			t.Fatalf("Invalid gzip body: %v", err)
+		}
+		reader = gz
+	case "deflate":
+		reader = flate.NewReader(body)
+	case "zstd":
+		zr, err := zstd.NewReader(body)
+		if err != nil {
+			t.Fatalf("Invalid zstd body: %v", err)
+		}
+		defer zr.Close()
+		reader = zr
+	default:
+		reader = body
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("Failed to decode %s body: %v", encoding, err)
+	}
+	return string(data)
+}
+
+func TestCompression(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	large := strings.Repeat("1. e4 e5 2. Nf3 Nc6 3. Bb5 a6 ", 100)
+	moves := make([]models.MoveAnalysis, 2000)
+	for i := range moves {
+		moves[i] = models.MoveAnalysis{Move: "Nf3", MoveNumber: i/2 + 1}
+	}
+	analysis := models.AnalysisResponse{Success: true, Data: &models.GameAnalysis{ID: "a1", Moves: moves}}
+	streamed, _ := json.Marshal(analysis)
+
+	router := gin.New()
+	router.Use(Compression(CompressionConfig{Enabled: true, Encodings: []string{"zstd", "gzip", "deflate"}, Level: 5, MinSize: 1024}))
+	handler := &Handler{streamThreshold: 64 << 10}
+	router.GET("/pgn", func(c *gin.Context) { c.String(http.StatusOK, large) })
+	router.GET("/small", func(c *gin.Context) { c.String(http.StatusOK, "1. e4 *") })
+	router.GET("/events", func(c *gin.Context) {
+		c.Header("Content-Type", "text/event-stream")
+		c.String(http.StatusOK, large)
+	})
+	router.GET("/analysis", func(c *gin.Context) { handler.writeLargeJSON(c, http.StatusOK, analysis) })
+
+	tests := []struct {
+		name           string
+		path           string
+		acceptEncoding string
+		encoding       string
+		body           string
+	}{
+		{"zstd", "/pgn", "gzip, zstd", "zstd", large},
+		{"gzip", "/pgn", "gzip", "gzip", large},
+		{"deflate", "/pgn", "deflate", "deflate", large},
+		{"not accepted", "/pgn", "br", "", large},
+		{"under the minimum size", "/small", "zstd", "", "1. e4 *"},
+		{"event stream", "/events", "zstd", "", large},
+		{"streamed zstd", "/analysis", "zstd", "zstd", string(streamed)},
+		{"streamed gzip", "/analysis", "gzip", "gzip", string(streamed)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if got := w.Header().Get("Content-Encoding"); got != tt.encoding {
+				t.Fatalf("Content-Encoding = %q, want %q", got, tt.encoding)
+			}
+			if tt.encoding != "" && w.Header().Get("Content-Length") != "" {
+				t.Errorf("Expected no Content-Length on a compressed body, got %s", w.Header().Get("Content-Length"))
+			}
+			if body := decode(t, tt.encoding, w.Body); body != tt.body {
+				t.Errorf("Decoded body has %d bytes, want %d", len(body), len(tt.body))
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"strconv"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrorHandler writes the response for errors handlers attach with c.Error, so every endpoint
+// reports the same error type with the same HTTP status
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		err := c.Errors.Last().Err
+
+		var rateLimited *errors.RateLimitedError
+		if errors.As(err, &rateLimited) && rateLimited.RetryAfter > 0 {
+			c.Header("Retry-After", strconv.Itoa(int(rateLimited.RetryAfter.Seconds())))
+		}
+		var uploadOffset *errors.UploadOffsetError
+		if errors.As(err, &uploadOffset) {
+			c.Header("Upload-Offset", strconv.FormatInt(uploadOffset.Expected, 10))
+		}
+
+		c.JSON(errors.HTTPStatus(err), models.APIResponse{
+			Success: false,
+			Error:   err.Error(),
+			Errors:  fieldErrors(err),
+		})
+	}
+}
+
+// fieldErrors lists the invalid fields of a validation error, or nothing for other errors
+func fieldErrors(err error) []models.FieldError {
+	var validations *errors.ValidationErrors
+	var validation *errors.ValidationError
+	var invalid []*errors.ValidationError
+	switch {
+	case errors.As(err, &validations):
+		invalid = validations.Errors
+	case errors.As(err, &validation):
+		invalid = []*errors.ValidationError{validation}
+	}
+
+	var fields []models.FieldError
+	for _, v := range invalid {
+		fields = append(fields, models.FieldError{Field: v.Field, Message: v.Message})
+	}
+	return fields
+}
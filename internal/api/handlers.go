@@ -1,27 +1,63 @@
 package api
 
 import (
+	"crypto/subtle"
+	"fmt"
+	"io"
+	"mime"
+	"net"
 	"net/http"
+	"path"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/pedrampdd/ChessAnalyser/internal/i18n"
 	"github.com/pedrampdd/ChessAnalyser/internal/models"
 	"github.com/pedrampdd/ChessAnalyser/internal/service"
-	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
 
 	"github.com/gin-gonic/gin"
 )
 
 // Handler represents the API handlers
 type Handler struct {
-	gameService     *service.GameAnalyzerService
-	analysisService *service.AnalysisService
+	gameService        *service.GameAnalyzerService
+	analysisService    *service.AnalysisService
+	preferencesService *service.PreferencesService
+	analyticsService   *service.AnalyticsService
+	syncService        *service.SyncService
+	watchService       *service.WatchService
+	broadcastService   *service.BroadcastService
+	importService      *service.ImportService
+	watchlistService   *service.WatchlistService
+	playService        *service.PlayService
+	trainingService    *service.TrainingService
+	collectionService  *service.CollectionService
+	jobService         *service.AnalysisJobService
+	teamService        *service.TeamService
+	streamThreshold    int          // Bytes above which large responses are streamed (0 = never)
+	adminKey           string       // API key of the admin routes (empty = admin routes disabled)
+	publicURL          string       // Scheme and host of links handed out, e.g. "https://chess.example.com" (empty = the request's)
+	trustedProxies     []*net.IPNet // Proxies whose X-Forwarded-Proto and X-Forwarded-Host headers are honoured
 }
 
 // NewHandler creates a new API handler
-func NewHandler(gameService *service.GameAnalyzerService, analysisService *service.AnalysisService) *Handler {
+func NewHandler(services Services) *Handler {
 	return &Handler{
-		gameService:     gameService,
-		analysisService: analysisService,
+		gameService:        services.Game,
+		analysisService:    services.Analysis,
+		preferencesService: services.Preferences,
+		analyticsService:   services.Analytics,
+		syncService:        services.Sync,
+		watchService:       services.Watch,
+		broadcastService:   services.Broadcast,
+		importService:      services.Import,
+		watchlistService:   services.Watchlist,
+		playService:        services.Play,
+		trainingService:    services.Training,
+		collectionService:  services.Collections,
+		jobService:         services.Jobs,
+		teamService:        services.Team,
 	}
 }
 
@@ -31,38 +67,28 @@ func (h *Handler) GetGame(c *gin.Context) {
 
 	gameInfo, err := h.gameService.GetGameByID(gameID)
 	if err != nil {
-		if _, ok := err.(*errors.GameNotFoundError); ok {
-			c.JSON(http.StatusNotFound, models.APIResponse{
-				Success: false,
-				Error:   err.Error(),
-			})
-			return
-		}
-
-		c.JSON(http.StatusInternalServerError, models.APIResponse{
-			Success: false,
-			Error:   err.Error(),
-		})
+		c.Error(err)
 		return
 	}
 
 	gameResponse := models.GameResponse{
-		GameID:      gameInfo.GameID,
-		URL:         gameInfo.URL,
-		FEN:         gameInfo.FEN,
-		PGN:         gameInfo.PGN,
-		TimeControl: gameInfo.TimeControl,
-		Rules:       gameInfo.Rules,
-		WhitePlayer: gameInfo.WhitePlayer,
-		BlackPlayer: gameInfo.BlackPlayer,
-		Result:      gameInfo.Result,
-		ResultCode:  gameInfo.ResultCode,
-		TimeClass:   gameInfo.TimeClass,
-		Rated:       gameInfo.Rated,
-		StartTime:   gameInfo.StartTime,
-		EndTime:     gameInfo.EndTime,
-		Tournament:  gameInfo.Tournament,
-		Match:       gameInfo.Match,
+		GameID:       gameInfo.GameID,
+		URL:          gameInfo.URL,
+		FEN:          gameInfo.FEN,
+		PGN:          gameInfo.PGN,
+		TimeControl:  gameInfo.TimeControl,
+		Rules:        gameInfo.Rules,
+		WhitePlayer:  gameInfo.WhitePlayer,
+		BlackPlayer:  gameInfo.BlackPlayer,
+		Result:       gameInfo.Result,
+		ResultCode:   gameInfo.ResultCode,
+		ResultReason: gameInfo.ResultReason,
+		TimeClass:    gameInfo.TimeClass,
+		Rated:        gameInfo.Rated,
+		StartTime:    gameInfo.StartTime,
+		EndTime:      gameInfo.EndTime,
+		Tournament:   gameInfo.Tournament,
+		Match:        gameInfo.Match,
 	}
 
 	c.JSON(http.StatusOK, models.APIResponse{
@@ -71,7 +97,7 @@ func (h *Handler) GetGame(c *gin.Context) {
 	})
 }
 
-// GetPlayerGames retrieves player's games for a specific month
+// GetPlayerGames retrieves a page of a player's games for a specific month
 func (h *Handler) GetPlayerGames(c *gin.Context) {
 	username := c.Param("username")
 	yearStr := c.Query("year")
@@ -103,16 +129,17 @@ func (h *Handler) GetPlayerGames(c *gin.Context) {
 		return
 	}
 
-	gamesData, err := h.gameService.GetPlayerGames(username, year, month)
+	filter := models.GameFilter{
+		Bots: c.DefaultQuery("bots", models.BotFilterInclude),
+	}
+
+	gamesData, err := h.gameService.GetPlayerGamesPage(username, year, month, filter, pageRequest(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.APIResponse{
-			Success: false,
-			Error:   err.Error(),
-		})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, models.APIResponse{
+	h.writeLargeJSON(c, http.StatusOK, models.APIResponse{
 		Success: true,
 		Data:    gamesData,
 	})
@@ -124,10 +151,7 @@ func (h *Handler) GetPlayerProfile(c *gin.Context) {
 
 	profileData, err := h.gameService.GetPlayerProfile(username)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.APIResponse{
-			Success: false,
-			Error:   err.Error(),
-		})
+		c.Error(err)
 		return
 	}
 
@@ -143,10 +167,7 @@ func (h *Handler) GetPlayerStats(c *gin.Context) {
 
 	statsData, err := h.gameService.GetPlayerStats(username)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.APIResponse{
-			Success: false,
-			Error:   err.Error(),
-		})
+		c.Error(err)
 		return
 	}
 
@@ -176,37 +197,142 @@ func (h *Handler) AnalyzeGame(c *gin.Context) {
 		return
 	}
 
-	// Set default settings if not provided
-	if request.Settings.Depth == 0 {
-		request.Settings.Depth = 15
-	}
-	if request.Settings.TimeLimit == 0 {
-		request.Settings.TimeLimit = 5000
-	}
-	if request.Settings.Threads == 0 {
-		request.Settings.Threads = 4
-	}
-	if request.Settings.HashSize == 0 {
-		request.Settings.HashSize = 128
+	if !h.prepareAnalysisRequest(c, &request) {
+		return
 	}
 
 	// Perform analysis
 	analysis, err := h.analysisService.AnalyzeGame(c.Request.Context(), &request)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.AnalysisResponse{
+		c.Error(err)
+		return
+	}
+
+	h.writeLargeJSON(c, http.StatusOK, models.AnalysisResponse{
+		Success: true,
+		Data:    analysis,
+		Message: "Game analysis completed successfully",
+	})
+}
+
+// NormalizePGN cleans up a malformed PGN and returns it without analyzing it
+func (h *Handler) NormalizePGN(c *gin.Context) {
+	var request models.PGNNormalizeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
 			Success: false,
-			Error:   err.Error(),
+			Error:   "Invalid request format",
+		})
+		return
+	}
+
+	normalization, err := h.analysisService.NormalizePGN(&request)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    normalization,
+	})
+}
+
+// AnalyzeGameByURL fetches a Chess.com game and analyzes it in one GET request, for integrations
+// that can't send a request body. Long games answer 202 with a job to poll.
+func (h *Handler) AnalyzeGameByURL(c *gin.Context) {
+	gameID := c.Query("url")
+	if gameID == "" {
+		gameID = c.Query("game_id")
+	}
+	request := models.AnalysisRequest{
+		Profile:  c.Query("profile"),
+		Mode:     c.Query("mode"),
+		Language: c.Query("language"),
+		Notation: c.Query("notation"),
+	}
+	if !h.prepareAnalysisRequest(c, &request) {
+		return
+	}
+
+	analysis, job, err := h.jobService.AnalyzeGame(c.Request.Context(), gameID, c.Query("username"), request)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if job != nil {
+		c.Header("Location", apiBase(c)+"/analyze/jobs/"+job.ID)
+		c.JSON(http.StatusAccepted, models.APIResponse{
+			Success: true,
+			Data:    job,
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, models.AnalysisResponse{
+	h.writeLargeJSON(c, http.StatusOK, models.AnalysisResponse{
 		Success: true,
 		Data:    analysis,
 		Message: "Game analysis completed successfully",
 	})
 }
 
+// GetAnalysisJob returns the state of a background game analysis
+func (h *Handler) GetAnalysisJob(c *gin.Context) {
+	job, err := h.jobService.GetJob(c.Param("id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    job,
+	})
+}
+
+// ListAnalysisJobs returns a page of the analysis jobs, running and recently finished
+func (h *Handler) ListAnalysisJobs(c *gin.Context) {
+	jobs, err := h.jobService.ListJobs(pageRequest(c))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    jobs,
+	})
+}
+
+// prepareAnalysisRequest fills a game analysis request's omitted fields from the user's saved
+// preferences, the named profile and the defaults. It answers 400 and reports false for an
+// unknown profile.
+func (h *Handler) prepareAnalysisRequest(c *gin.Context, request *models.AnalysisRequest) bool {
+	// Priority analyses skip load shedding, so only operators may ask for them
+	if request.Priority && !h.isAdmin(c) {
+		c.JSON(http.StatusForbidden, models.AnalysisResponse{
+			Success: false,
+			Error:   "Priority analyses require the admin API key in the X-API-Key header",
+		})
+		return false
+	}
+
+	h.preferencesService.ApplyPreferences(userKey(c), request)
+	if err := service.ApplyProfile(&request.Settings, request.Profile); err != nil {
+		c.JSON(http.StatusBadRequest, models.AnalysisResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return false
+	}
+
+	h.analysisService.ApplyDefaultSettings(&request.Settings)
+	if request.Language == "" {
+		request.Language = i18n.FromAcceptLanguage(c.GetHeader("Accept-Language"))
+	}
+	return true
+}
+
 // AnalyzePosition analyzes a single chess position
 func (h *Handler) AnalyzePosition(c *gin.Context) {
 	fen := c.Query("fen")
@@ -218,68 +344,2035 @@ func (h *Handler) AnalyzePosition(c *gin.Context) {
 		return
 	}
 
-	// Parse optional settings from query parameters
+	// Analyze position
+	result, err := h.analysisService.AnalyzePosition(c.Request.Context(), fen, h.positionSettings(c))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    result,
+	})
+}
+
+// StreamPositionAnalysis analyzes a position and streams its evaluations as server-sent events:
+// a cached evaluation at once, then the engine's result when the cached one is too shallow
+func (h *Handler) StreamPositionAnalysis(c *gin.Context) {
+	fen := c.Query("fen")
+	if fen == "" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "FEN parameter is required",
+		})
+		return
+	}
+
+	updates, err := h.analysisService.StreamPositionAnalysis(c.Request.Context(), fen, h.positionSettings(c))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case update, ok := <-updates:
+			if !ok {
+				return false
+			}
+			c.SSEvent("update", update)
+			return !update.Final
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
+
+// positionSettings parses the engine settings of a position analysis from query parameters
+func (h *Handler) positionSettings(c *gin.Context) models.EngineSettings {
 	settings := models.EngineSettings{
-		Depth:     getIntQuery(c, "depth", 15),
-		TimeLimit: getIntQuery(c, "time_limit", 5000),
-		Threads:   getIntQuery(c, "threads", 4),
-		HashSize:  getIntQuery(c, "hash_size", 128),
+		Depth:     getIntQuery(c, "depth", 0),
+		TimeLimit: getIntQuery(c, "time_limit", 0),
+		Threads:   getIntQuery(c, "threads", 0),
+		HashSize:  getIntQuery(c, "hash_size", 0),
 		MultiPV:   getIntQuery(c, "multipv", 1),
+		Variant:   c.Query("variant"),
 	}
+	h.analysisService.ApplyDefaultSettings(&settings)
+	return settings
+}
 
-	// Analyze position
-	result, err := h.analysisService.AnalyzePosition(c.Request.Context(), fen, settings)
+// AnalyzeBatch analyzes several positions or games with one settings block in a single request
+func (h *Handler) AnalyzeBatch(c *gin.Context) {
+	var request models.BatchAnalysisRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid request format",
+		})
+		return
+	}
+	h.analysisService.ApplyDefaultSettings(&request.Settings)
+
+	batch, err := h.analysisService.AnalyzeBatch(c.Request.Context(), &request)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.APIResponse{
+		c.Error(err)
+		return
+	}
+
+	h.writeLargeJSON(c, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    batch,
+	})
+}
+
+// ExploreLines returns the top engine lines of a position for an analysis board
+func (h *Handler) ExploreLines(c *gin.Context) {
+	fen := c.Query("fen")
+	if fen == "" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
 			Success: false,
-			Error:   err.Error(),
+			Error:   "FEN parameter is required",
 		})
 		return
 	}
 
+	settings := models.EngineSettings{
+		Depth:     getIntQuery(c, "depth", 20),
+		TimeLimit: getIntQuery(c, "time_limit", 10000),
+		Threads:   getIntQuery(c, "threads", 0),
+		HashSize:  getIntQuery(c, "hash_size", 0),
+		MultiPV:   getIntQuery(c, "multipv", 5),
+		Variant:   c.Query("variant"),
+	}
+	h.analysisService.ApplyDefaultSettings(&settings)
+
+	lines, err := h.analysisService.ExploreLines(c.Request.Context(), fen, settings)
+	if err == nil {
+		err = service.NotatePositionLines(lines, c.Query("notation"))
+	}
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
 	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
-		Data:    result,
+		Data:    lines,
 	})
 }
 
-// GetEngineStatus returns the status of analysis engines
-func (h *Handler) GetEngineStatus(c *gin.Context) {
-	status := h.analysisService.GetEngineStatus()
+// SelfPlay has the engine play a position out against itself
+func (h *Handler) SelfPlay(c *gin.Context) {
+	var request models.SelfPlayRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid request format",
+		})
+		return
+	}
+
+	h.analysisService.ApplyResourceSettings(&request.Settings)
+
+	game, err := h.analysisService.SelfPlay(c.Request.Context(), &request)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
 	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
-		Data:    status,
+		Data:    game,
 	})
 }
 
-// ClearAnalysisCache clears the analysis cache
-func (h *Handler) ClearAnalysisCache(c *gin.Context) {
-	h.analysisService.ClearCache()
+// RunBenchmark runs the engine benchmark suite and compares it with the stored baseline. Only the
+// admin API key may save the run as the baseline.
+func (h *Handler) RunBenchmark(c *gin.Context) {
+	var request models.BenchmarkRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Error:   "Invalid request format",
+			})
+			return
+		}
+	}
+
+	// Replacing the baseline changes what every later run is judged against
+	if request.SaveBaseline && !h.requireAdmin(c) {
+		return
+	}
+
+	report, err := h.analysisService.RunBenchmark(c.Request.Context(), &request)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
 	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
-		Data: map[string]string{
-			"message": "Analysis cache cleared successfully",
-		},
+		Data:    report,
 	})
 }
 
-// HealthCheck provides a health check endpoint
-func (h *Handler) HealthCheck(c *gin.Context) {
+// FindMate checks whether a position has a forced mate within a number of moves
+func (h *Handler) FindMate(c *gin.Context) {
+	fen := c.Query("fen")
+	if fen == "" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "FEN parameter is required",
+		})
+		return
+	}
+
+	settings := models.EngineSettings{
+		TimeLimit: getIntQuery(c, "time_limit", 0),
+		Threads:   getIntQuery(c, "threads", 0),
+		HashSize:  getIntQuery(c, "hash_size", 0),
+		Variant:   c.Query("variant"),
+	}
+	h.analysisService.ApplyResourceSettings(&settings)
+
+	search, err := h.analysisService.FindMate(c.Request.Context(), fen, getIntQuery(c, "moves", 0), settings)
+	if err == nil {
+		err = service.NotateMateSearch(search, c.Query("notation"))
+	}
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
 	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
-		Data: map[string]string{
-			"status":  "healthy",
-			"service": "chess-analyzer",
-		},
+		Data:    search,
 	})
 }
 
-// getIntQuery gets an integer query parameter with a default value
-func getIntQuery(c *gin.Context, key string, defaultValue int) int {
-	if value := c.Query(key); value != "" {
-		if intValue, err := strconv.Atoi(value); err == nil {
-			return intValue
+// GetAnalysis retrieves a stored analysis by ID, optionally only some of its moves
+func (h *Handler) GetAnalysis(c *gin.Context) {
+	analysisID := c.Param("id")
+
+	filter := models.MoveFilter{
+		FromPly: getIntQuery(c, "from_ply", 0),
+		ToPly:   getIntQuery(c, "to_ply", 0),
+		Only:    c.Query("only"),
+	}
+
+	analysis, err := h.analysisService.GetAnalysis(analysisID)
+	if err == nil {
+		analysis, err = h.analysisService.LocalizeAnalysis(analysis, requestLanguage(c))
+	}
+	if err == nil {
+		analysis, err = h.analysisService.FilterMoves(analysis, filter)
+	}
+	if err == nil {
+		analysis, err = h.analysisService.NotateAnalysis(analysis, c.Query("notation"))
+	}
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	h.writeLargeJSON(c, http.StatusOK, models.AnalysisResponse{
+		Success: true,
+		Data:    analysis,
+	})
+}
+
+// ListAnalyses returns a page of the stored analyses, without their moves
+func (h *Handler) ListAnalyses(c *gin.Context) {
+	analyses, err := h.analysisService.ListAnalyses(pageRequest(c))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	h.writeLargeJSON(c, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    analyses,
+	})
+}
+
+// DiffAnalyses compares two stored analyses of the same game move by move
+func (h *Handler) DiffAnalyses(c *gin.Context) {
+	diff, err := h.analysisService.DiffAnalyses(c.Query("a"), c.Query("b"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    diff,
+	})
+}
+
+// ExportDataset exports the moves of stored analyses as a position dataset for machine learning
+func (h *Handler) ExportDataset(c *gin.Context) {
+	request := models.DatasetRequest{
+		Format: c.Query("format"),
+		Player: c.Query("player"),
+	}
+	if ids := c.Query("ids"); ids != "" {
+		for _, id := range strings.Split(ids, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				request.AnalysisIDs = append(request.AnalysisIDs, id)
+			}
 		}
 	}
-	return defaultValue
+	if limit := c.Query("limit"); limit != "" {
+		request.Limit, _ = strconv.Atoi(limit)
+	}
+	if request.Format == "" {
+		request.Format = service.DatasetFormatCSV
+	}
+
+	dataset, err := h.analysisService.ExportDataset(&request)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	setAttachment(c, "positions."+request.Format)
+	c.Header("X-Dataset-Rows", strconv.Itoa(dataset.Rows))
+	c.Header("X-Dataset-Truncated", strconv.FormatBool(dataset.Truncated))
+	c.Data(http.StatusOK, dataset.ContentType, dataset.Data)
+}
+
+// GetKeyMoments returns the key moments of a stored analysis for a guided review
+func (h *Handler) GetKeyMoments(c *gin.Context) {
+	analysisID := c.Param("id")
+
+	moments, err := h.analysisService.GetKeyMoments(analysisID, requestLanguage(c), c.Query("notation"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    moments,
+	})
+}
+
+// GetCommentary returns rule-based commentary on the key moments of a stored analysis
+func (h *Handler) GetCommentary(c *gin.Context) {
+	commentary, err := h.analysisService.GetCommentary(c.Param("id"), c.Query("tone"), c.Query("notation"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    commentary,
+	})
+}
+
+// GetPlyPosition returns the position at one ply of a stored analysis
+func (h *Handler) GetPlyPosition(c *gin.Context) {
+	ply, err := strconv.Atoi(c.Param("ply"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid ply",
+		})
+		return
+	}
+
+	position, err := h.analysisService.GetPlyPosition(c.Param("id"), ply)
+	if err == nil {
+		err = service.NotatePlyPosition(position, c.Query("notation"))
+	}
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    position,
+	})
+}
+
+// UpdateAnnotations applies user comments and classification overrides to a stored analysis
+func (h *Handler) UpdateAnnotations(c *gin.Context) {
+	analysisID := c.Param("id")
+
+	var patch models.AnnotationPatch
+	if err := c.ShouldBindJSON(&patch); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid request format",
+		})
+		return
+	}
+
+	analysis, err := h.analysisService.UpdateAnnotations(analysisID, &patch)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	h.writeLargeJSON(c, http.StatusOK, models.AnalysisResponse{
+		Success: true,
+		Data:    analysis,
+		Message: "Analysis annotations updated successfully",
+	})
+}
+
+// ReclassifyAnalysis re-derives a stored analysis's move classifications from its evaluations with
+// new thresholds or accuracy model, filling omitted ones from the user's preferences
+func (h *Handler) ReclassifyAnalysis(c *gin.Context) {
+	var request models.ReclassifyRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Error:   "Invalid request format",
+			})
+			return
+		}
+	}
+	h.preferencesService.ApplyReclassifyPreferences(userKey(c), &request)
+
+	analysis, err := h.analysisService.ReclassifyAnalysis(c.Param("id"), &request)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	h.writeLargeJSON(c, http.StatusOK, models.AnalysisResponse{
+		Success: true,
+		Data:    analysis,
+		Message: "Analysis reclassified successfully",
+	})
+}
+
+// ExportAnalysis downloads a stored analysis as JSON or PGN, including user edits
+func (h *Handler) ExportAnalysis(c *gin.Context) {
+	analysisID := c.Param("id")
+
+	format, options := h.exportOptions(c)
+	data, contentType, err := h.analysisService.ExportAnalysis(analysisID, format, options)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	setAttachment(c, fmt.Sprintf("analysis-%s.%s", analysisID, format))
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// exportOptions returns the export format and options of a request, falling back to the user's
+// preferred format and board orientation
+func (h *Handler) exportOptions(c *gin.Context) (string, service.ExportOptions) {
+	format := c.Query("format")
+	options := service.ExportOptions{Notation: c.Query("notation"), Orientation: c.Query("orientation")}
+	if prefs := h.preferencesService.GetPreferences(userKey(c)); prefs != nil {
+		if format == "" {
+			format = prefs.ExportFormat
+		}
+		if options.Orientation == "" {
+			options.Orientation = prefs.BoardOrientation
+		}
+	}
+	if format == "" {
+		format = service.ExportFormatJSON
+	}
+	return format, options
+}
+
+// CreateShareLink creates a read-only public link to a stored analysis
+func (h *Handler) CreateShareLink(c *gin.Context) {
+	analysisID := c.Param("id")
+
+	var request models.ShareRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Error:   "Invalid request format",
+			})
+			return
+		}
+	}
+
+	link, err := h.analysisService.CreateShareLink(analysisID, time.Duration(request.ExpiresIn)*time.Second)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	link.URL = fmt.Sprintf("%s/share/%s", h.baseURL(c), link.Token)
+
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Data:    link,
+	})
+}
+
+// GetSharedAnalysis returns a shared analysis without requiring an API key
+func (h *Handler) GetSharedAnalysis(c *gin.Context) {
+	token := c.Param("token")
+
+	analysis, err := h.analysisService.GetSharedAnalysis(token)
+	if err == nil {
+		analysis, err = h.analysisService.LocalizeAnalysis(analysis, requestLanguage(c))
+	}
+	if err == nil {
+		analysis, err = h.analysisService.NotateAnalysis(analysis, c.Query("notation"))
+	}
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	h.writeLargeJSON(c, http.StatusOK, models.AnalysisResponse{
+		Success: true,
+		Data:    analysis,
+	})
+}
+
+// SuggestMove returns the best move alongside the move a player of the given rating should find
+func (h *Handler) SuggestMove(c *gin.Context) {
+	fen := c.Query("fen")
+	if fen == "" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "FEN parameter is required",
+		})
+		return
+	}
+
+	rating := getIntQuery(c, "rating", 0)
+	if rating == 0 {
+		username := c.Query("username")
+		if username == "" {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Error:   "Rating or username parameter is required",
+			})
+			return
+		}
+
+		var err error
+		rating, err = h.gameService.GetPlayerRating(username, c.DefaultQuery("time_class", "rapid"))
+		if err != nil {
+			c.Error(err)
+			return
+		}
+	}
+
+	settings := models.EngineSettings{
+		Depth:     getIntQuery(c, "depth", 15),
+		TimeLimit: getIntQuery(c, "time_limit", 1000),
+		MultiPV:   1,
+		Variant:   c.Query("variant"),
+	}
+
+	suggestion, err := h.analysisService.SuggestMove(c.Request.Context(), fen, rating, settings)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    suggestion,
+	})
+}
+
+// GetEngineStatus returns the status of analysis engines
+func (h *Handler) GetEngineStatus(c *gin.Context) {
+	status := h.analysisService.GetEngineStatus()
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    status,
+	})
+}
+
+// GetOpeningCacheStats returns the opening cache's size, hit rate and warmup progress
+func (h *Handler) GetOpeningCacheStats(c *gin.Context) {
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    h.analysisService.GetOpeningCacheStats(),
+	})
+}
+
+// WarmOpeningCache starts pre-analyzing the most popular opening lines
+func (h *Handler) WarmOpeningCache(c *gin.Context) {
+	var request models.OpeningWarmupRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Error:   "Invalid request format",
+			})
+			return
+		}
+	}
+	h.analysisService.ApplyDefaultSettings(&request.Settings)
+
+	stats, err := h.analysisService.WarmOpeningCache(&request)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, models.APIResponse{
+		Success: true,
+		Data:    stats,
+	})
+}
+
+// GetSyncStatus returns the archive sync state of the configured players
+func (h *Handler) GetSyncStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    h.syncService.GetSyncStatus(),
+	})
+}
+
+// SyncPlayer syncs a player's archives immediately
+func (h *Handler) SyncPlayer(c *gin.Context) {
+	state, err := h.syncService.SyncPlayer(c.Request.Context(), c.Param("username"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    state,
+	})
+}
+
+// IngestGames starts storing the games of a Chess.com, Lichess, local PGN or TWIC source that
+// weren't stored before. The ingest runs in the background; the job is polled with GetIngest.
+func (h *Handler) IngestGames(c *gin.Context) {
+	var query models.GameSourceQuery
+	if err := c.ShouldBindJSON(&query); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid request format",
+		})
+		return
+	}
+
+	job, err := h.syncService.StartIngest(query)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Header("Location", apiBase(c)+"/ingest/"+job.ID)
+	c.JSON(http.StatusAccepted, models.APIResponse{
+		Success: true,
+		Data:    job,
+	})
+}
+
+// GetIngest returns the state of an ingest job, with its result once it is done
+func (h *Handler) GetIngest(c *gin.Context) {
+	job, err := h.syncService.GetIngest(c.Param("id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    job,
+	})
+}
+
+// GetNewGames returns a player's games finished since the consumer's last poll, or since the
+// end time given as since
+func (h *Handler) GetNewGames(c *gin.Context) {
+	var since *int64
+	if value, ok := c.GetQuery("since"); ok {
+		cursor, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Error:   "Invalid since parameter",
+			})
+			return
+		}
+		since = &cursor
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid limit parameter",
+		})
+		return
+	}
+
+	games, err := h.syncService.GetNewGames(c.Request.Context(), c.Param("username"), c.Query("consumer"), since, limit)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	h.writeLargeJSON(c, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    games,
+	})
+}
+
+// RepairSyncedGames re-fetches a player's stored games that lack a PGN or FEN
+func (h *Handler) RepairSyncedGames(c *gin.Context) {
+	report, err := h.syncService.RepairGames(c.Request.Context(), c.Param("username"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    report,
+	})
+}
+
+// GetSyncNotifications returns a page of recent rating milestone, title and streak notifications
+// of synced players
+func (h *Handler) GetSyncNotifications(c *gin.Context) {
+	notifications, err := h.syncService.GetNotifications(c.Query("username"), pageRequest(c))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    notifications,
+	})
+}
+
+// StreamSyncNotifications streams notifications of synced players as server-sent events
+func (h *Handler) StreamSyncNotifications(c *gin.Context) {
+	notifications, unsubscribe := h.syncService.SubscribeNotifications()
+	defer unsubscribe()
+
+	username := c.Query("username")
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case notification, ok := <-notifications:
+			if !ok {
+				return false
+			}
+			if username == "" || strings.EqualFold(notification.Username, username) {
+				c.SSEvent("notification", notification)
+			}
+			return true
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
+
+// GetSyncedGames returns a page of the games stored for a player by archive sync
+func (h *Handler) GetSyncedGames(c *gin.Context) {
+	games, err := h.syncService.GetSyncedGames(c.Param("username"), pageRequest(c))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	h.writeLargeJSON(c, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    games,
+	})
+}
+
+// ListRawArchives returns a page of the raw archive snapshots kept for a player by archive sync
+func (h *Handler) ListRawArchives(c *gin.Context) {
+	archives, err := h.syncService.ListRawArchives(c.Param("username"), pageRequest(c))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    archives,
+	})
+}
+
+// GetGameSource returns a synced game exactly as it appeared in the raw archive it was fetched from
+func (h *Handler) GetGameSource(c *gin.Context) {
+	source, err := h.syncService.GetGameSource(c.Request.Context(), c.Param("username"), c.Query("url"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    source,
+	})
+}
+
+// GetMetrics returns aggregated analysis resource usage
+func (h *Handler) GetMetrics(c *gin.Context) {
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    h.analysisService.GetMetrics(),
+	})
+}
+
+// ClearAnalysisCache clears the analysis cache
+func (h *Handler) ClearAnalysisCache(c *gin.Context) {
+	h.analysisService.ClearCache()
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data: map[string]string{
+			"message": "Analysis cache cleared successfully",
+		},
+	})
+}
+
+// GetClubAnalytics returns aggregate statistics for a sample of a club's members
+func (h *Handler) GetClubAnalytics(c *gin.Context) {
+	h.groupAnalytics(c, models.GroupTypeClub, c.Param("clubId"))
+}
+
+// GetCountryAnalytics returns aggregate statistics for a sample of a country's players
+func (h *Handler) GetCountryAnalytics(c *gin.Context) {
+	h.groupAnalytics(c, models.GroupTypeCountry, c.Param("iso"))
+}
+
+// groupAnalytics builds a group report from query parameters
+func (h *Handler) groupAnalytics(c *gin.Context, groupType, groupID string) {
+	request := models.GroupAnalyticsRequest{
+		GroupType:      groupType,
+		GroupID:        groupID,
+		SampleSize:     getIntQuery(c, "sample", 0),
+		GamesPerPlayer: getIntQuery(c, "games", 0),
+		Mode:           c.Query("mode"),
+		Settings: models.EngineSettings{
+			Depth:   getIntQuery(c, "depth", 12),
+			MultiPV: 1,
+		},
+	}
+
+	report, err := h.analyticsService.GenerateGroupReport(c.Request.Context(), &request)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    report,
+	})
+}
+
+// GetPlayerHeatmaps returns per-square statistics across a player's recent games
+func (h *Handler) GetPlayerHeatmaps(c *gin.Context) {
+	request := models.HeatmapRequest{
+		Username: c.Param("username"),
+		Games:    getIntQuery(c, "games", 0),
+		Analyze:  getIntQuery(c, "analyze", -1),
+		Settings: models.EngineSettings{
+			Depth:   getIntQuery(c, "depth", 12),
+			MultiPV: 1,
+		},
+	}
+
+	heatmaps, err := h.analyticsService.GetPlayerHeatmaps(c.Request.Context(), &request)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    heatmaps,
+	})
+}
+
+// ScreenPlayer returns engine-match, move-time and performance signals across a player's games
+func (h *Handler) ScreenPlayer(c *gin.Context) {
+	var request models.ScreeningRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid request format",
+		})
+		return
+	}
+
+	report, err := h.analyticsService.ScreenPlayer(c.Request.Context(), &request)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    report,
+	})
+}
+
+// GetPlayerReport returns a player's performance per endgame type and per opening across their recent games
+func (h *Handler) GetPlayerReport(c *gin.Context) {
+	request := models.PlayerReportRequest{
+		Username:       c.Param("username"),
+		Games:          getIntQuery(c, "games", 0),
+		AssessOpenings: c.Query("assess_openings") == "true",
+		Settings: models.EngineSettings{
+			Depth:     getIntQuery(c, "depth", 0),
+			TimeLimit: getIntQuery(c, "time_limit", 0),
+		},
+	}
+
+	report, err := h.analyticsService.GeneratePlayerReport(c.Request.Context(), &request)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    report,
+	})
+}
+
+// GetPlayerNovelties reports where a player's recent games left opening theory
+func (h *Handler) GetPlayerNovelties(c *gin.Context) {
+	request := models.NoveltyRequest{
+		Username: c.Param("username"),
+		Games:    getIntQuery(c, "games", 0),
+		Source:   c.Query("source"),
+		Settings: models.EngineSettings{
+			Depth:     getIntQuery(c, "depth", 0),
+			TimeLimit: getIntQuery(c, "time_limit", 0),
+		},
+	}
+
+	report, err := h.analyticsService.FindNovelties(c.Request.Context(), &request)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    report,
+	})
+}
+
+// GetPlayerTournaments summarizes a player's performance in each of their Chess.com tournaments
+func (h *Handler) GetPlayerTournaments(c *gin.Context) {
+	request := models.TournamentHistoryRequest{
+		Username: c.Param("username"),
+		Games:    getIntQuery(c, "games", 0),
+	}
+
+	history, err := h.analyticsService.GetPlayerTournaments(c.Request.Context(), &request)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    history,
+	})
+}
+
+// PrepareAgainstOpponent builds an opening preparation dossier on an opponent
+func (h *Handler) PrepareAgainstOpponent(c *gin.Context) {
+	request := models.PreparationRequest{
+		Opponent: c.Query("opponent"),
+		Color:    c.Query("color"),
+		Games:    getIntQuery(c, "games", 0),
+		Settings: models.EngineSettings{
+			Depth:     getIntQuery(c, "depth", 0),
+			TimeLimit: getIntQuery(c, "time_limit", 0),
+		},
+	}
+
+	dossier, err := h.analyticsService.PrepareAgainst(c.Request.Context(), &request)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    dossier,
+	})
+}
+
+// PlanTeamMatch starts preparing a board pairing between two clubs with preparation for every
+// board. The plan is made in the background; the job is polled with GetTeamMatchPlan.
+func (h *Handler) PlanTeamMatch(c *gin.Context) {
+	var request models.TeamMatchRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid request format",
+		})
+		return
+	}
+
+	job, err := h.teamService.StartMatchPlan(request)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Header("Location", apiBase(c)+"/team/match-plan/"+job.ID)
+	c.JSON(http.StatusAccepted, models.APIResponse{
+		Success: true,
+		Data:    job,
+	})
+}
+
+// GetTeamMatchPlan returns the state of a match plan job, with the plan once it is ready
+func (h *Handler) GetTeamMatchPlan(c *gin.Context) {
+	job, err := h.teamService.GetMatchPlan(c.Param("id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    job,
+	})
+}
+
+// GetPreferences returns the saved preferences of the requesting user
+func (h *Handler) GetPreferences(c *gin.Context) {
+	user := userKey(c)
+	if user == "" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "X-API-Key header or user parameter is required",
+		})
+		return
+	}
+
+	prefs := h.preferencesService.GetPreferences(user)
+	if prefs == nil {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Error:   "No preferences saved for this user",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    prefs,
+	})
+}
+
+// SavePreferences stores the preferences of the requesting user
+func (h *Handler) SavePreferences(c *gin.Context) {
+	var prefs models.UserPreferences
+	if err := c.ShouldBindJSON(&prefs); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid request format",
+		})
+		return
+	}
+
+	saved, err := h.preferencesService.SavePreferences(userKey(c), &prefs)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    saved,
+	})
+}
+
+// DeletePreferences removes the preferences of the requesting user
+func (h *Handler) DeletePreferences(c *gin.Context) {
+	h.preferencesService.DeletePreferences(userKey(c))
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data: map[string]string{
+			"message": "Preferences deleted successfully",
+		},
+	})
+}
+
+// GetAuditLog returns a page of the audit log of game analyses, filtered by API key fingerprint,
+// IP, game and time. Only the admin API key may read it.
+func (h *Handler) GetAuditLog(c *gin.Context) {
+	if !h.requireAdmin(c) {
+		return
+	}
+
+	query := models.AuditQuery{
+		KeyFingerprint: c.Query("key_fingerprint"),
+		IP:             c.Query("ip"),
+		GameID:         c.Query("game_id"),
+		PageRequest:    pageRequest(c),
+	}
+	// Times were checked by the route's schema
+	if since := c.Query("since"); since != "" {
+		query.Since, _ = time.Parse(time.RFC3339, since)
+	}
+	if until := c.Query("until"); until != "" {
+		query.Until, _ = time.Parse(time.RFC3339, until)
+	}
+
+	entries, err := h.analysisService.QueryAuditLog(query)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    entries,
+	})
+}
+
+// requireAdmin rejects requests without the admin API key, reporting whether the request may go on
+func (h *Handler) requireAdmin(c *gin.Context) bool {
+	if h.adminKey == "" {
+		c.JSON(http.StatusForbidden, models.APIResponse{
+			Success: false,
+			Error:   "Admin routes are disabled; set ADMIN_API_KEY to enable them",
+		})
+		return false
+	}
+	if !h.isAdmin(c) {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Error:   "The admin API key is required in the X-API-Key header",
+		})
+		return false
+	}
+	return true
+}
+
+// isAdmin reports whether a request carries the admin API key
+func (h *Handler) isAdmin(c *gin.Context) bool {
+	return h.adminKey != "" && subtle.ConstantTimeCompare([]byte(c.GetHeader("X-API-Key")), []byte(h.adminKey)) == 1
+}
+
+// HealthCheck provides a health check endpoint
+func (h *Handler) HealthCheck(c *gin.Context) {
+	// The server stays up without a usable engine; report that analysis is off instead of failing
+	analysis := h.analysisService != nil && h.analysisService.EngineAvailable()
+	status := "healthy"
+	if !analysis {
+		status = "degraded"
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data: map[string]interface{}{
+			"status":  status,
+			"service": "chess-analyzer",
+			"capabilities": map[string]bool{
+				"analysis": analysis,
+			},
+		},
+	})
+}
+
+// userKey identifies the requesting user by API key, falling back to the user query parameter
+func userKey(c *gin.Context) string {
+	if apiKey := c.GetHeader("X-API-Key"); apiKey != "" {
+		return apiKey
+	}
+	return c.Query("user")
+}
+
+// baseURL returns the externally visible scheme and host of the server: the configured public URL,
+// or else the request's. Forwarding headers are honoured only from trusted proxies, so callers
+// can't mint links pointing at another host.
+func (h *Handler) baseURL(c *gin.Context) string {
+	if h.publicURL != "" {
+		return h.publicURL
+	}
+
+	scheme, host := "http", c.Request.Host
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if h.fromTrustedProxy(c) {
+		if proto := c.GetHeader("X-Forwarded-Proto"); proto == "http" || proto == "https" {
+			scheme = proto
+		}
+		if forwarded := c.GetHeader("X-Forwarded-Host"); forwarded != "" {
+			host = forwarded
+		}
+	}
+	return fmt.Sprintf("%s://%s", scheme, host)
+}
+
+// fromTrustedProxy reports whether the request's connection comes from a trusted proxy
+func (h *Handler) fromTrustedProxy(c *gin.Context) bool {
+	ip := net.ParseIP(c.RemoteIP())
+	if ip == nil {
+		return false
+	}
+	for _, network := range h.trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTrustedProxies parses IPs and CIDRs of trusted proxies, skipping invalid entries
+func parseTrustedProxies(proxies []string) []*net.IPNet {
+	var networks []*net.IPNet
+	for _, proxy := range proxies {
+		proxy = strings.TrimSpace(proxy)
+		if !strings.Contains(proxy, "/") {
+			if ip := net.ParseIP(proxy); ip != nil {
+				bits := 8 * net.IPv6len
+				if ip.To4() != nil {
+					ip, bits = ip.To4(), 8*net.IPv4len
+				}
+				networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			}
+			continue
+		}
+		if _, network, err := net.ParseCIDR(proxy); err == nil {
+			networks = append(networks, network)
+		}
+	}
+	return networks
+}
+
+// setAttachment marks the response as a file download named filename. The name is sent quoted, with
+// characters outside printable ASCII replaced, so no name can break out of the header.
+func setAttachment(c *gin.Context, filename string) {
+	filename = strings.Map(func(r rune) rune {
+		if r < 0x20 || r > 0x7e {
+			return '_'
+		}
+		return r
+	}, filename)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+}
+
+// requestLanguage returns the language asked for by the lang query parameter, falling back to Accept-Language
+func requestLanguage(c *gin.Context) string {
+	if language := c.Query("lang"); language != "" {
+		return language
+	}
+	return i18n.FromAcceptLanguage(c.GetHeader("Accept-Language"))
+}
+
+// getIntQuery gets an integer query parameter with a default value
+func getIntQuery(c *gin.Context, key string, defaultValue int) int {
+	if value := c.Query(key); value != "" {
+		if intValue, err := strconv.Atoi(value); err == nil {
+			return intValue
+		}
+	}
+	return defaultValue
+}
+
+// pageRequest reads the cursor and limit query parameters of a list request
+func pageRequest(c *gin.Context) models.PageRequest {
+	return models.PageRequest{
+		Cursor: c.Query("cursor"),
+		Limit:  getIntQuery(c, "limit", 0),
+	}
+}
+
+// StartWatch starts polling an ongoing game and analyzing each new position
+func (h *Handler) StartWatch(c *gin.Context) {
+	var request models.WatchRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid request format",
+		})
+		return
+	}
+
+	session, err := h.watchService.StartWatch(&request)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Data:    session,
+	})
+}
+
+// GetWatch returns a watched game's status and evaluation updates
+func (h *Handler) GetWatch(c *gin.Context) {
+	session, err := h.watchService.GetWatch(c.Param("id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    session,
+	})
+}
+
+// StopWatch stops watching a game
+func (h *Handler) StopWatch(c *gin.Context) {
+	session, err := h.watchService.StopWatch(c.Param("id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    session,
+	})
+}
+
+// StreamWatch streams a watched game's evaluation updates as server-sent events.
+// Updates published before the client connected are replayed first.
+func (h *Handler) StreamWatch(c *gin.Context) {
+	id := c.Param("id")
+	backlog, updates, unsubscribe, err := h.watchService.Subscribe(id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	defer unsubscribe()
+
+	for _, update := range backlog {
+		c.SSEvent("update", update)
+	}
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case update, ok := <-updates:
+			if !ok {
+				if session, err := h.watchService.GetWatch(id); err == nil {
+					c.SSEvent("end", gin.H{"status": session.Status})
+				}
+				return false
+			}
+			c.SSEvent("update", update)
+			return true
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
+
+// StartBroadcast starts following a live event's PGN relay and analyzing every board's moves
+func (h *Handler) StartBroadcast(c *gin.Context) {
+	var request models.BroadcastRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid request format",
+		})
+		return
+	}
+
+	session, err := h.broadcastService.StartBroadcast(&request)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Data:    session,
+	})
+}
+
+// GetBroadcast returns a followed broadcast's boards and their evaluation updates
+func (h *Handler) GetBroadcast(c *gin.Context) {
+	session, err := h.broadcastService.GetBroadcast(c.Param("id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    session,
+	})
+}
+
+// StopBroadcast stops following a broadcast
+func (h *Handler) StopBroadcast(c *gin.Context) {
+	session, err := h.broadcastService.StopBroadcast(c.Param("id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    session,
+	})
+}
+
+// StreamBroadcast streams a broadcast's evaluation updates as server-sent events, for one board
+// when the board query parameter is set. Updates published before the client connected are
+// replayed first.
+func (h *Handler) StreamBroadcast(c *gin.Context) {
+	id := c.Param("id")
+	board := 0
+	if value := c.Query("board"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Error:   "Invalid board parameter",
+			})
+			return
+		}
+		board = parsed
+	}
+
+	backlog, updates, unsubscribe, err := h.broadcastService.Subscribe(id, board)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	defer unsubscribe()
+
+	for _, update := range backlog {
+		c.SSEvent("update", update)
+	}
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case update, ok := <-updates:
+			if !ok {
+				if session, err := h.broadcastService.GetBroadcast(id); err == nil {
+					c.SSEvent("end", gin.H{"status": session.Status})
+				}
+				return false
+			}
+			c.SSEvent("update", update)
+			return true
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
+
+// CreateImport starts a resumable PGN database upload
+func (h *Handler) CreateImport(c *gin.Context) {
+	var request models.ImportRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid request format",
+		})
+		return
+	}
+
+	upload, err := h.importService.CreateImport(&request)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Header("Location", apiBase(c)+"/imports/"+upload.ID)
+	c.Header("Upload-Offset", "0")
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Data:    upload,
+	})
+}
+
+// UploadImportChunk appends the request body to an upload at the offset given in the Upload-Offset header.
+// A chunk that doesn't start where the upload left off is rejected with 409 and the current offset.
+func (h *Handler) UploadImportChunk(c *gin.Context) {
+	offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Upload-Offset header is required",
+		})
+		return
+	}
+
+	upload, err := h.importService.AppendChunk(c.Param("id"), offset, c.Request.Body)
+	if upload != nil {
+		c.Header("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	}
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    upload,
+	})
+}
+
+// GetImportOffset reports how many bytes of an upload were received, so a client can resume it
+func (h *Handler) GetImportOffset(c *gin.Context) {
+	upload, err := h.importService.GetImport(c.Param("id"))
+	if err != nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
+
+	c.Header("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	c.Header("Upload-Length", strconv.FormatInt(upload.Size, 10))
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+}
+
+// GetImport returns an import's upload and parsing progress
+func (h *Handler) GetImport(c *gin.Context) {
+	upload, err := h.importService.GetImport(c.Param("id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    upload,
+	})
+}
+
+// GetImportedGames returns a page of the games parsed from an import
+func (h *Handler) GetImportedGames(c *gin.Context) {
+	games, err := h.importService.GetImportedGames(c.Param("id"), pageRequest(c))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	h.writeLargeJSON(c, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    games,
+	})
+}
+
+// DeleteImport cancels an import and removes its data
+func (h *Handler) DeleteImport(c *gin.Context) {
+	if err := h.importService.DeleteImport(c.Param("id")); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    gin.H{"message": "Import deleted"},
+	})
+}
+
+// AddWatchlistEntry adds a player to the watchlist
+func (h *Handler) AddWatchlistEntry(c *gin.Context) {
+	var request models.WatchlistRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid request format",
+		})
+		return
+	}
+
+	entry, err := h.watchlistService.AddEntry(&request)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Data:    entry,
+	})
+}
+
+// ListWatchlist returns a page of the watched players
+func (h *Handler) ListWatchlist(c *gin.Context) {
+	entries, err := h.watchlistService.ListEntries(pageRequest(c))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    entries,
+	})
+}
+
+// GetWatchlistEntry returns a watched player's schedule and last report
+func (h *Handler) GetWatchlistEntry(c *gin.Context) {
+	entry, err := h.watchlistService.GetEntry(c.Param("id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    entry,
+	})
+}
+
+// RunWatchlistReport builds and delivers a watched player's report now
+func (h *Handler) RunWatchlistReport(c *gin.Context) {
+	report, err := h.watchlistService.RunReport(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    report,
+	})
+}
+
+// RemoveWatchlistEntry removes a player from the watchlist
+func (h *Handler) RemoveWatchlistEntry(c *gin.Context) {
+	if err := h.watchlistService.RemoveEntry(c.Param("id")); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    gin.H{"message": "Player removed from watchlist"},
+	})
+}
+
+// CreateArtifact exports a stored analysis to the blob store
+func (h *Handler) CreateArtifact(c *gin.Context) {
+	format, options := h.exportOptions(c)
+	artifact, err := h.analysisService.CreateArtifact(c.Request.Context(), c.Param("id"), format, options)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Data:    artifact,
+	})
+}
+
+// ListArtifacts returns a page of the artifacts exported from a stored analysis
+func (h *Handler) ListArtifacts(c *gin.Context) {
+	artifacts, err := h.analysisService.ListArtifacts(c.Param("id"), pageRequest(c))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    artifacts,
+	})
+}
+
+// GetArtifact returns an artifact's metadata
+func (h *Handler) GetArtifact(c *gin.Context) {
+	artifact, err := h.analysisService.GetArtifact(c.Param("id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    artifact,
+	})
+}
+
+// GetArtifactDownload returns a signed URL downloading an artifact
+func (h *Handler) GetArtifactDownload(c *gin.Context) {
+	download, err := h.analysisService.ArtifactDownload(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if strings.HasPrefix(download.URL, "/") {
+		download.URL = h.baseURL(c) + download.URL
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    download,
+	})
+}
+
+// DeleteArtifact removes an artifact from the blob store
+func (h *Handler) DeleteArtifact(c *gin.Context) {
+	if err := h.analysisService.DeleteArtifact(c.Request.Context(), c.Param("id")); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    gin.H{"message": "Artifact deleted"},
+	})
+}
+
+// ServeBlob serves a blob from the local blob store through a signed URL (no API key required)
+func (h *Handler) ServeBlob(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+
+	body, err := h.analysisService.OpenSignedBlob(c.Request.Context(), key, c.Query("expires"), c.Query("signature"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	defer body.Close()
+
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	setAttachment(c, path.Base(key))
+	c.DataFromReader(http.StatusOK, -1, contentType, body, nil)
+}
+
+// CreatePlayGame starts a game against the engine
+func (h *Handler) CreatePlayGame(c *gin.Context) {
+	var request models.PlayRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid request format",
+		})
+		return
+	}
+
+	h.analysisService.ApplyResourceSettings(&request.Settings)
+
+	session, err := h.playService.CreateGame(c.Request.Context(), &request)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Data:    session,
+	})
+}
+
+// GetPlayGame returns the state of a game against the engine
+func (h *Handler) GetPlayGame(c *gin.Context) {
+	session, err := h.playService.GetGame(c.Param("id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    session,
+	})
+}
+
+// MakePlayMove plays the user's move and returns the game with the engine's reply
+func (h *Handler) MakePlayMove(c *gin.Context) {
+	var request models.PlayMoveRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid request format",
+		})
+		return
+	}
+
+	session, err := h.playService.MakeMove(c.Request.Context(), c.Param("id"), &request)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    session,
+	})
+}
+
+// ResignPlayGame ends a game against the engine as a loss for the user
+func (h *Handler) ResignPlayGame(c *gin.Context) {
+	session, err := h.playService.Resign(c.Param("id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    session,
+	})
+}
+
+// GetPlayGamePGN returns a game against the engine as PGN
+func (h *Handler) GetPlayGamePGN(c *gin.Context) {
+	id := c.Param("id")
+	pgn, err := h.playService.GamePGN(id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	setAttachment(c, fmt.Sprintf("game-%s.pgn", id))
+	c.Data(http.StatusOK, "application/x-chess-pgn", []byte(pgn))
+}
+
+// DeletePlayGame removes a game against the engine
+func (h *Handler) DeletePlayGame(c *gin.Context) {
+	if err := h.playService.DeleteGame(c.Param("id")); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    gin.H{"message": "Game deleted"},
+	})
+}
+
+// ExtractPuzzles turns the requesting user's errors in a stored analysis into training puzzles
+func (h *Handler) ExtractPuzzles(c *gin.Context) {
+	var request models.PuzzleExtractRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid request format",
+		})
+		return
+	}
+
+	puzzles, err := h.trainingService.ExtractPuzzles(userKey(c), &request)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Data:    puzzles,
+	})
+}
+
+// GetDuePuzzles returns a page of the requesting user's puzzles that are due for review
+func (h *Handler) GetDuePuzzles(c *gin.Context) {
+	puzzles, err := h.trainingService.DuePuzzles(userKey(c), pageRequest(c))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    puzzles,
+	})
+}
+
+// SubmitPuzzleAttempt grades an answer to a puzzle and schedules its next review
+func (h *Handler) SubmitPuzzleAttempt(c *gin.Context) {
+	var request models.PuzzleAttemptRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid request format",
+		})
+		return
+	}
+
+	result, err := h.trainingService.SubmitAttempt(userKey(c), c.Param("id"), &request)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    result,
+	})
+}
+
+// GetTrainingStats summarizes the requesting user's puzzle training
+func (h *Handler) GetTrainingStats(c *gin.Context) {
+	stats, err := h.trainingService.Stats(userKey(c))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    stats,
+	})
+}
+
+// CreateCollection creates an empty game collection for the requesting user
+func (h *Handler) CreateCollection(c *gin.Context) {
+	var request models.CollectionRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid request format",
+		})
+		return
+	}
+
+	collection, err := h.collectionService.Create(userKey(c), &request)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Data:    collection,
+	})
+}
+
+// ListCollections returns a page of the requesting user's collections, optionally only those
+// with a tag
+func (h *Handler) ListCollections(c *gin.Context) {
+	collections, err := h.collectionService.List(userKey(c), c.Query("tag"), pageRequest(c))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    collections,
+	})
+}
+
+// GetCollection returns one of the requesting user's collections
+func (h *Handler) GetCollection(c *gin.Context) {
+	collection, err := h.collectionService.Get(userKey(c), c.Param("id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    collection,
+	})
+}
+
+// UpdateCollection renames a collection and replaces its description and tags
+func (h *Handler) UpdateCollection(c *gin.Context) {
+	var request models.CollectionRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid request format",
+		})
+		return
+	}
+
+	collection, err := h.collectionService.Update(userKey(c), c.Param("id"), &request)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    collection,
+	})
+}
+
+// DeleteCollection removes a collection, keeping the analyses and games in it
+func (h *Handler) DeleteCollection(c *gin.Context) {
+	if err := h.collectionService.Delete(userKey(c), c.Param("id")); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    gin.H{"message": "Collection deleted"},
+	})
+}
+
+// AddCollectionItems adds stored analyses and synced games to a collection
+func (h *Handler) AddCollectionItems(c *gin.Context) {
+	var request models.CollectionItemsRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid request format",
+		})
+		return
+	}
+
+	collection, err := h.collectionService.AddItems(userKey(c), c.Param("id"), &request)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    collection,
+	})
+}
+
+// RemoveCollectionItems removes analyses and games from a collection
+func (h *Handler) RemoveCollectionItems(c *gin.Context) {
+	var request models.CollectionItemsRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "Invalid request format",
+		})
+		return
+	}
+
+	collection, err := h.collectionService.RemoveItems(userKey(c), c.Param("id"), &request)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    collection,
+	})
+}
+
+// GetCollectionReport aggregates a collection's games, from a player's point of view when given
+func (h *Handler) GetCollectionReport(c *gin.Context) {
+	report, err := h.collectionService.Report(userKey(c), c.Param("id"), c.Query("player"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    report,
+	})
 }
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestHandler_BaseURL(t *testing.T) {
+	tests := []struct {
+		name       string
+		publicURL  string
+		remoteAddr string
+		want       string
+	}{
+		{"public URL", "https://chess.example.com", "10.0.0.1:1234", "https://chess.example.com"},
+		{"trusted proxy", "", "10.0.0.1:1234", "https://proxied.example.com"},
+		{"untrusted client", "", "203.0.113.7:1234", "http://api.example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &Handler{publicURL: tt.publicURL, trustedProxies: parseTrustedProxies([]string{"10.0.0.0/8"})}
+
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest("POST", "http://api.example.com/api/share", nil)
+			c.Request.RemoteAddr = tt.remoteAddr
+			c.Request.Header.Set("X-Forwarded-Proto", "https")
+			c.Request.Header.Set("X-Forwarded-Host", "proxied.example.com")
+
+			if got := h.baseURL(c); got != tt.want {
+				t.Errorf("baseURL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	networks := parseTrustedProxies([]string{"192.168.1.5", " 10.0.0.0/8", "::1", "not-an-ip"})
+	if len(networks) != 3 {
+		t.Fatalf("parseTrustedProxies() returned %d networks, want 3", len(networks))
+	}
+	if got := networks[0].String(); got != "192.168.1.5/32" {
+		t.Errorf("Single IP network = %s, want 192.168.1.5/32", got)
+	}
+}
@@ -0,0 +1,30 @@
+package api
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pedrampdd/ChessAnalyser/internal/engine"
+)
+
+// EngineQueue marks requests as client requests for the engine pools: they wait in the pools'
+// bounded queues, are rejected with 429 when a queue is full, and report where they waited in
+// the X-Queue-Position and X-Estimated-Wait (milliseconds) headers.
+func EngineQueue() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Reports analyzing several games may wait for engines concurrently
+		var mu sync.Mutex
+		ctx := engine.WithQueue(c.Request.Context(), func(info engine.QueueInfo) {
+			mu.Lock()
+			defer mu.Unlock()
+			if c.Writer.Written() {
+				return
+			}
+			c.Header("X-Queue-Position", strconv.Itoa(info.Position))
+			c.Header("X-Estimated-Wait", strconv.FormatInt(info.EstimatedWait.Milliseconds(), 10))
+		})
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
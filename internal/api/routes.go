@@ -1,20 +1,28 @@
 package api
 
 import (
-	service "github.com/pedrampdd/ChessAnalyser/internal/service"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-// SetupRoutes configures all API routes
-func SetupRoutes(gameService *service.GameAnalyzerService, analysisService *service.AnalysisService) *gin.Engine {
-	r := gin.Default()
+// RouterOptions configures how responses are delivered
+type RouterOptions struct {
+	Compression     CompressionConfig
+	StreamThreshold int       // Bytes above which analyses and game lists are streamed (0 = never)
+	LegacySunset    time.Time // When the unversioned /api routes stop being served (zero = not scheduled)
+	AdminKey        string    // API key of the admin routes (empty = admin routes disabled)
+	TrustedProxies  []string  // IPs and CIDRs of the proxies whose forwarding headers give the client IP (empty = none)
+	PublicURL       string    // Scheme and host of share and download links (empty = taken from the request)
+}
 
-	// Add CORS middleware
-	r.Use(func(c *gin.Context) {
+// cors allows browser clients on other origins to call the API
+func cors() gin.HandlerFunc {
+	return func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type")
+		c.Header("Access-Control-Allow-Methods", "GET, HEAD, POST, PUT, PATCH, DELETE, OPTIONS")
+		c.Header("Access-Control-Allow-Headers", "Content-Type, X-API-Key, Upload-Offset")
+		c.Header("Access-Control-Expose-Headers", "Location, Upload-Offset, Upload-Length")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -22,29 +30,177 @@ func SetupRoutes(gameService *service.GameAnalyzerService, analysisService *serv
 		}
 
 		c.Next()
-	})
-
-	// Initialize handlers
-	handler := NewHandler(gameService, analysisService)
+	}
+}
 
+// registerRoutes registers the built-in routes under every API version, and under the
+// unversioned /api prefix as a deprecated alias of v1. It returns the API route groups.
+func registerRoutes(r *gin.Engine, handler *Handler, legacySunset time.Time) []*gin.RouterGroup {
 	// Health check endpoint
 	r.GET("/health", handler.HealthCheck)
 
-	// API routes
-	api := r.Group("/api")
-	{
-		// Game routes
-		api.GET("/game/:gameId", handler.GetGame)
-		api.GET("/player/:username/games", handler.GetPlayerGames)
-		api.GET("/player/:username/profile", handler.GetPlayerProfile)
-		api.GET("/player/:username/stats", handler.GetPlayerStats)
-
-		// Analysis routes
-		api.POST("/analyze/game", handler.AnalyzeGame)
-		api.GET("/analyze/position", handler.AnalyzePosition)
-		api.GET("/analyze/status", handler.GetEngineStatus)
-		api.DELETE("/analyze/cache", handler.ClearAnalysisCache)
+	// Aggregated analysis resource usage
+	r.GET("/metrics", handler.GetMetrics)
+
+	// Public share links (no API key required)
+	r.GET("/share/:token", handler.GetSharedAnalysis)
+
+	// Signed downloads from the local blob store (no API key required)
+	r.GET("/blobs/*key", handler.ServeBlob)
+
+	var groups []*gin.RouterGroup
+	var shims []responseShim
+	for _, version := range apiVersions {
+		base := "/api/" + version.name
+		shims = append(shims, version.shims...)
+		groups = append(groups, r.Group(base, versioned(base, shims)))
 	}
+	groups = append(groups, r.Group("/api", deprecated("/api", deprecation{Sunset: legacySunset, Successor: "/api/v1"})))
+
+	for _, api := range groups {
+		registerAPIRoutes(api, handler)
+	}
+	return groups
+}
+
+// registerAPIRoutes registers the built-in API routes on a route group
+func registerAPIRoutes(api *gin.RouterGroup, handler *Handler) {
+	// Game routes
+	api.GET("/game/:gameId", handler.GetGame)
+	api.GET("/player/:username/games", handler.GetPlayerGames)
+	api.GET("/player/:username/profile", handler.GetPlayerProfile)
+	api.GET("/player/:username/stats", handler.GetPlayerStats)
+	api.GET("/player/:username/synced-games", handler.GetSyncedGames)
+	api.GET("/player/:username/synced-games/source", handler.GetGameSource)
+	api.GET("/player/:username/raw-archives", handler.ListRawArchives)
+	api.GET("/player/:username/heatmaps", handler.GetPlayerHeatmaps)
+	api.GET("/player/:username/report", handler.GetPlayerReport)
+	api.GET("/player/:username/novelties", handler.GetPlayerNovelties)
+	api.GET("/player/:username/tournaments", handler.GetPlayerTournaments)
+
+	// Analysis routes
+	api.POST("/analyze/game", handler.AnalyzeGame)
+	api.GET("/analyze/game", handler.AnalyzeGameByURL)
+	api.GET("/analyze/jobs", handler.ListAnalysisJobs)
+	api.GET("/analyze/jobs/:id", handler.GetAnalysisJob)
+	api.GET("/analyze/position", handler.AnalyzePosition)
+	api.GET("/analyze/position/stream", handler.StreamPositionAnalysis)
+	api.POST("/analyze/batch", handler.AnalyzeBatch)
+	api.GET("/analyze/position/lines", handler.ExploreLines)
+	api.GET("/analyze/suggestion", handler.SuggestMove)
+	api.GET("/analyze/mate", handler.FindMate)
+	api.POST("/analyze/selfplay", handler.SelfPlay)
+	api.POST("/analyze/benchmark", handler.RunBenchmark)
+	api.GET("/analyze/status", handler.GetEngineStatus)
+	api.DELETE("/analyze/cache", handler.ClearAnalysisCache)
+	api.GET("/openings/cache", handler.GetOpeningCacheStats)
+	api.POST("/openings/cache/warmup", handler.WarmOpeningCache)
+
+	// PGN utility routes
+	api.POST("/pgn/normalize", handler.NormalizePGN)
+
+	// Stored analysis routes
+	api.GET("/analysis", handler.ListAnalyses)
+	api.GET("/analysis/diff", handler.DiffAnalyses)
+	api.GET("/analysis/dataset", handler.ExportDataset)
+	api.GET("/analysis/:id", handler.GetAnalysis)
+	api.PATCH("/analysis/:id", handler.UpdateAnnotations)
+	api.POST("/analysis/:id/reclassify", handler.ReclassifyAnalysis)
+	api.GET("/analysis/:id/key-moments", handler.GetKeyMoments)
+	api.GET("/analysis/:id/commentary", handler.GetCommentary)
+	api.GET("/analysis/:id/position/:ply", handler.GetPlyPosition)
+	api.GET("/analysis/:id/export", handler.ExportAnalysis)
+	api.POST("/analysis/:id/share", handler.CreateShareLink)
+	api.POST("/analysis/:id/artifacts", handler.CreateArtifact)
+	api.GET("/analysis/:id/artifacts", handler.ListArtifacts)
+
+	// Artifact routes
+	api.GET("/artifacts/:id", handler.GetArtifact)
+	api.GET("/artifacts/:id/download", handler.GetArtifactDownload)
+	api.DELETE("/artifacts/:id", handler.DeleteArtifact)
+
+	// Group analytics routes
+	api.GET("/analytics/club/:clubId", handler.GetClubAnalytics)
+	api.GET("/analytics/country/:iso", handler.GetCountryAnalytics)
+
+	// Fair play screening routes (opt-in per request)
+	api.POST("/screening", handler.ScreenPlayer)
+
+	// Opening preparation routes
+	api.GET("/prepare", handler.PrepareAgainstOpponent)
+
+	// Team tools routes
+	api.POST("/team/match-plan", handler.PlanTeamMatch)
+	api.GET("/team/match-plan/:id", handler.GetTeamMatchPlan)
+
+	// Archive sync routes
+	api.GET("/sync/status", handler.GetSyncStatus)
+	api.GET("/sync/notifications", handler.GetSyncNotifications)
+	api.GET("/sync/notifications/stream", handler.StreamSyncNotifications)
+	api.POST("/sync/:username", handler.SyncPlayer)
+	api.POST("/sync/:username/repair", handler.RepairSyncedGames)
+	api.GET("/sync/:username/new", handler.GetNewGames)
+
+	// Game ingestion from any registered source
+	api.POST("/ingest", handler.IngestGames)
+	api.GET("/ingest/:id", handler.GetIngest)
+
+	// Live game watch routes
+	api.POST("/watch", handler.StartWatch)
+	api.GET("/watch/:id", handler.GetWatch)
+	api.GET("/watch/:id/stream", handler.StreamWatch)
+	api.DELETE("/watch/:id", handler.StopWatch)
+
+	// Broadcast relay routes
+	api.POST("/broadcast", handler.StartBroadcast)
+	api.GET("/broadcast/:id", handler.GetBroadcast)
+	api.GET("/broadcast/:id/stream", handler.StreamBroadcast)
+	api.DELETE("/broadcast/:id", handler.StopBroadcast)
+
+	// Resumable PGN import routes
+	api.POST("/imports", handler.CreateImport)
+	api.HEAD("/imports/:id", handler.GetImportOffset)
+	api.PATCH("/imports/:id", handler.UploadImportChunk)
+	api.GET("/imports/:id", handler.GetImport)
+	api.GET("/imports/:id/games", handler.GetImportedGames)
+	api.DELETE("/imports/:id", handler.DeleteImport)
+
+	// Watchlist routes
+	api.POST("/watchlist", handler.AddWatchlistEntry)
+	api.GET("/watchlist", handler.ListWatchlist)
+	api.GET("/watchlist/:id", handler.GetWatchlistEntry)
+	api.POST("/watchlist/:id/report", handler.RunWatchlistReport)
+	api.DELETE("/watchlist/:id", handler.RemoveWatchlistEntry)
+
+	// Play against the engine routes
+	api.POST("/play", handler.CreatePlayGame)
+	api.GET("/play/:id", handler.GetPlayGame)
+	api.POST("/play/:id/moves", handler.MakePlayMove)
+	api.POST("/play/:id/resign", handler.ResignPlayGame)
+	api.GET("/play/:id/pgn", handler.GetPlayGamePGN)
+	api.DELETE("/play/:id", handler.DeletePlayGame)
+
+	// Puzzle training routes
+	api.POST("/training/puzzles", handler.ExtractPuzzles)
+	api.GET("/training/puzzles/due", handler.GetDuePuzzles)
+	api.POST("/training/puzzles/:id/attempts", handler.SubmitPuzzleAttempt)
+	api.GET("/training/stats", handler.GetTrainingStats)
+
+	// Game collection routes
+	api.POST("/collections", handler.CreateCollection)
+	api.GET("/collections", handler.ListCollections)
+	api.GET("/collections/:id", handler.GetCollection)
+	api.PUT("/collections/:id", handler.UpdateCollection)
+	api.DELETE("/collections/:id", handler.DeleteCollection)
+	api.POST("/collections/:id/items", handler.AddCollectionItems)
+	api.DELETE("/collections/:id/items", handler.RemoveCollectionItems)
+	api.GET("/collections/:id/report", handler.GetCollectionReport)
+
+	// User preference routes
+	api.GET("/preferences", handler.GetPreferences)
+	api.PUT("/preferences", handler.SavePreferences)
+	api.DELETE("/preferences", handler.DeletePreferences)
 
-	return r
+	// Admin routes (admin API key required)
+	api.GET("/admin/audit", handler.GetAuditLog)
 }
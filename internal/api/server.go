@@ -0,0 +1,174 @@
+package api
+
+import (
+	"log"
+	"strings"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Services are the services behind the built-in routes
+type Services struct {
+	Game        *service.GameAnalyzerService
+	Analysis    *service.AnalysisService
+	Preferences *service.PreferencesService
+	Analytics   *service.AnalyticsService
+	Sync        *service.SyncService
+	Watch       *service.WatchService
+	Broadcast   *service.BroadcastService
+	Import      *service.ImportService
+	Watchlist   *service.WatchlistService
+	Play        *service.PlayService
+	Training    *service.TrainingService
+	Collections *service.CollectionService
+	Jobs        *service.AnalysisJobService
+	Team        *service.TeamService
+}
+
+// Server builds the API's HTTP handler. Projects embedding the API add their own middleware,
+// routes and services through options instead of editing the built-in routes.
+type Server struct {
+	services   Services
+	options    RouterOptions
+	middleware []gin.HandlerFunc
+	routes     []func(api *gin.RouterGroup)
+	custom     map[string]any
+}
+
+// Option configures a Server
+type Option func(*Server)
+
+// NewServer creates a server configured by the given options
+func NewServer(opts ...Option) *Server {
+	s := &Server{custom: make(map[string]any)}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// WithServices sets the services behind the built-in routes
+func WithServices(services Services) Option {
+	return func(s *Server) {
+		s.services = services
+	}
+}
+
+// WithRouterOptions sets how responses are compressed and streamed
+func WithRouterOptions(options RouterOptions) Option {
+	return func(s *Server) {
+		s.options = options
+	}
+}
+
+// WithMiddleware adds middleware run on every request, in order, after the built-in CORS,
+// compression and error handling middleware. Errors added with c.Error are reported like the
+// built-in handlers' errors.
+func WithMiddleware(middleware ...gin.HandlerFunc) Option {
+	return func(s *Server) {
+		s.middleware = append(s.middleware, middleware...)
+	}
+}
+
+// WithRoutes registers extra routes on every API version's group (/api/v1, /api/v2 and the
+// deprecated /api) once the built-in routes are registered. Registering a route the API
+// already serves panics, as with gin.
+func WithRoutes(register func(api *gin.RouterGroup)) Option {
+	return func(s *Server) {
+		s.routes = append(s.routes, register)
+	}
+}
+
+// WithService makes a custom service available to every handler under name; handlers retrieve
+// it with GetService
+func WithService(name string, svc any) Option {
+	return func(s *Server) {
+		s.custom[name] = svc
+	}
+}
+
+// GetService returns the custom service registered under name, or false if there is none or it
+// isn't a T
+func GetService[T any](c *gin.Context, name string) (T, bool) {
+	value, ok := c.Get(serviceKey(name))
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	svc, ok := value.(T)
+	return svc, ok
+}
+
+// serviceKey is the context key of a custom service
+func serviceKey(name string) string {
+	return "service:" + name
+}
+
+// Handler builds the gin engine serving the built-in routes and the registered extensions
+func (s *Server) Handler() *gin.Engine {
+	r := gin.Default()
+
+	// Client IPs, such as the audit log's, come from forwarding headers only behind trusted proxies
+	if err := r.SetTrustedProxies(s.options.TrustedProxies); err != nil {
+		log.Printf("Invalid trusted proxies, trusting none: %v", err)
+		r.SetTrustedProxies(nil)
+	}
+
+	// Allow cross-origin requests
+	r.Use(cors())
+
+	// Compress responses for clients that accept it
+	r.Use(Compression(s.options.Compression))
+
+	// Report handler errors with a status derived from their type
+	r.Use(ErrorHandler())
+
+	// Shim JSON responses to the requested API version and trim them to the requested fields
+	r.Use(ShapeResponses())
+
+	// Queue requests for busy engines up to a bound and report their place in the queue
+	r.Use(EngineQueue())
+
+	// Attribute the analyses requests run to their API key and IP in the audit log
+	r.Use(AuditRequester())
+
+	// Reject requests whose fields break their route's schema
+	var maxThreads, maxHashSize int
+	if s.services.Analysis != nil {
+		maxThreads, maxHashSize = s.services.Analysis.MaxResources()
+	}
+	r.Use(ValidateRequests(maxThreads, maxHashSize))
+
+	// Expose custom services to handlers
+	if len(s.custom) > 0 {
+		r.Use(func(c *gin.Context) {
+			for name, svc := range s.custom {
+				c.Set(serviceKey(name), svc)
+			}
+			c.Next()
+		})
+	}
+
+	r.Use(s.middleware...)
+
+	handler := NewHandler(s.services)
+	handler.streamThreshold = s.options.StreamThreshold
+	handler.adminKey = s.options.AdminKey
+	handler.publicURL = strings.TrimSuffix(s.options.PublicURL, "/")
+	handler.trustedProxies = parseTrustedProxies(s.options.TrustedProxies)
+
+	for _, api := range registerRoutes(r, handler, s.options.LegacySunset) {
+		for _, register := range s.routes {
+			register(api)
+		}
+	}
+
+	return r
+}
+
+// Run builds the handler and serves it on addr until it fails
+func (s *Server) Run(addr string) error {
+	return s.Handler().Run(addr)
+}
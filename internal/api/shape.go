@@ -0,0 +1,179 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// compactOmittedFields are the heavy fields compact responses leave out: PGN text and engine lines
+var compactOmittedFields = map[string]bool{
+	"pgn":   true,
+	"pv":    true,
+	"lines": true,
+}
+
+// fieldTree is a parsed ?fields= selector. A field without children is selected whole.
+type fieldTree map[string]fieldTree
+
+// responseShape is how a client asked for a large response to be trimmed
+type responseShape struct {
+	fields  fieldTree // nil selects every field
+	compact bool
+}
+
+// ShapeResponses rewrites JSON responses once the handler is done, in a single pass over the
+// body: the shims of the API version serving the request turn the data into the version's schema,
+// then the fields and compact query parameters trim it. Responses that need neither, streams and
+// other content types are written through as they come.
+func ShapeResponses() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shape, err := parseResponseShape(c)
+		if err != nil {
+			c.Error(err)
+			c.Abort()
+			return
+		}
+
+		original := c.Writer
+		writer := &shimWriter{
+			ResponseWriter: original,
+			status:         http.StatusOK,
+			hold:           func() bool { return shape != nil || len(versionShims(c)) > 0 },
+		}
+		c.Writer = writer
+		c.Next()
+		c.Writer = original
+		writer.finish(func(data any) any {
+			for _, shim := range versionShims(c) {
+				shim(data)
+			}
+			if shape != nil {
+				data = shape.apply(data)
+			}
+			return data
+		})
+	}
+}
+
+// parseResponseShape reads the fields and compact query parameters. It returns nil when the
+// response should be sent as is.
+func parseResponseShape(c *gin.Context) (*responseShape, error) {
+	shape := &responseShape{}
+
+	if value := c.Query("compact"); value != "" {
+		compact, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, errors.NewValidationError("compact", "compact must be true or false")
+		}
+		shape.compact = compact
+	}
+
+	if value := c.Query("fields"); value != "" {
+		fields, err := parseFieldTree(value)
+		if err != nil {
+			return nil, err
+		}
+		shape.fields = fields
+	}
+
+	if !shape.compact && shape.fields == nil {
+		return nil, nil
+	}
+	return shape, nil
+}
+
+// parseFieldTree parses a comma-separated list of dotted field paths, e.g. "items.url,total_estimate"
+func parseFieldTree(value string) (fieldTree, error) {
+	tree := fieldTree{}
+	for _, path := range strings.Split(value, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+
+		node := tree
+		names := strings.Split(path, ".")
+		for i, name := range names {
+			if name == "" {
+				return nil, errors.NewValidationError("fields", fmt.Sprintf("invalid field path: %s", path))
+			}
+
+			child, seen := node[name]
+			if seen && len(child) == 0 {
+				break // Already selected whole; a deeper path doesn't narrow it
+			}
+			if i == len(names)-1 {
+				node[name] = fieldTree{}
+				break
+			}
+			if !seen {
+				child = fieldTree{}
+				node[name] = child
+			}
+			node = child
+		}
+	}
+
+	if len(tree) == 0 {
+		return nil, errors.NewValidationError("fields", "at least one field is required")
+	}
+	return tree, nil
+}
+
+// apply trims the data of a response
+func (s *responseShape) apply(data any) any {
+	if s.compact {
+		omitHeavyFields(data)
+	}
+	if s.fields != nil {
+		data = s.fields.selectFrom(data)
+	}
+	return data
+}
+
+// selectFrom keeps only the selected fields of a value. Arrays are transparent: a selector
+// applies to each of their elements.
+func (t fieldTree) selectFrom(value any) any {
+	switch v := value.(type) {
+	case []any:
+		for i := range v {
+			v[i] = t.selectFrom(v[i])
+		}
+	case map[string]any:
+		for key, child := range v {
+			selected, ok := t[key]
+			if !ok {
+				delete(v, key)
+				continue
+			}
+			if len(selected) > 0 {
+				v[key] = selected.selectFrom(child)
+			}
+		}
+	}
+	return value
+}
+
+// omitHeavyFields removes the compact-mode fields at any depth of a value
+func omitHeavyFields(value any) {
+	switch v := value.(type) {
+	case []any:
+		for _, element := range v {
+			omitHeavyFields(element)
+		}
+	case map[string]any:
+		for key, child := range v {
+			if compactOmittedFields[key] {
+				delete(v, key)
+				continue
+			}
+			omitHeavyFields(child)
+		}
+	}
+}
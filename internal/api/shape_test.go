@@ -0,0 +1,142 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestParseFieldTree(t *testing.T) {
+	tests := []struct {
+		value string
+		want  fieldTree
+		valid bool
+	}{
+		{"url", fieldTree{"url": {}}, true},
+		{"items.url, items.white.username ,next_cursor", fieldTree{
+			"items":       {"url": {}, "white": {"username": {}}},
+			"next_cursor": {},
+		}, true},
+		{"items,items.url", fieldTree{"items": {}}, true},
+		{"items.url,items", fieldTree{"items": {}}, true},
+		{"url,,pgn", fieldTree{"url": {}, "pgn": {}}, true},
+		{"white..username", nil, false},
+		{".url", nil, false},
+		{" , ", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseFieldTree(tt.value)
+			if (err == nil) != tt.valid {
+				t.Fatalf("parseFieldTree(%q) error = %v, want valid %t", tt.value, err, tt.valid)
+			}
+			if tt.valid && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseFieldTree(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResponseShape_Apply(t *testing.T) {
+	const data = `{"items": [
+		{"url": "a", "pgn": "1. e4 *", "white": {"username": "alice", "rating": 1500}, "analysis": {"lines": [], "pv": ["e4"], "depth": 20}},
+		{"url": "b", "pgn": "1. d4 *", "white": {"username": "bob", "rating": 1600}}
+	], "next_cursor": "c"}`
+
+	tests := []struct {
+		name  string
+		shape responseShape
+		want  string
+	}{
+		{"compact", responseShape{compact: true}, `{"items": [
+			{"url": "a", "white": {"username": "alice", "rating": 1500}, "analysis": {"depth": 20}},
+			{"url": "b", "white": {"username": "bob", "rating": 1600}}
+		], "next_cursor": "c"}`},
+		{"fields", responseShape{fields: fieldTree{"items": {"url": {}, "white": {"username": {}}}}}, `{"items": [
+			{"url": "a", "white": {"username": "alice"}},
+			{"url": "b", "white": {"username": "bob"}}
+		]}`},
+		{"field selected whole", responseShape{fields: fieldTree{"next_cursor": {}, "items": {"analysis": {}}}}, `{"items": [
+			{"analysis": {"lines": [], "pv": ["e4"], "depth": 20}},
+			{}
+		], "next_cursor": "c"}`},
+		{"compact and fields", responseShape{compact: true, fields: fieldTree{"items": {"pgn": {}, "analysis": {}}}}, `{"items": [
+			{"analysis": {"depth": 20}},
+			{}
+		]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var value, want any
+			json.Unmarshal([]byte(data), &value)
+			json.Unmarshal([]byte(tt.want), &want)
+			if got := tt.shape.apply(value); !reflect.DeepEqual(got, want) {
+				t.Errorf("apply() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestShapeResponses(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	games := []map[string]any{{"id": int64(1) << 60, "pgn": "1. e4 *", "moves": []map[string]any{{"blunder": false, "mistake": false, "inaccuracy": true}}}}
+	register := func(api *gin.RouterGroup, handler *Handler) {
+		api.GET("/games", func(c *gin.Context) {
+			c.JSON(http.StatusOK, models.APIResponse{Success: true, Data: games})
+		})
+		api.GET("/large", func(c *gin.Context) {
+			handler.writeLargeJSON(c, http.StatusOK, models.APIResponse{Success: true, Data: games})
+		})
+		api.GET("/text", func(c *gin.Context) { c.String(http.StatusOK, "pgn") })
+	}
+
+	router := gin.New()
+	router.Use(ErrorHandler(), ShapeResponses())
+	handler := &Handler{streamThreshold: 1}
+	register(router.Group("/api/v1", versioned("/api/v1", nil)), handler)
+	register(router.Group("/api/v2", versioned("/api/v2", apiVersions[1].shims)), handler)
+
+	tests := []struct {
+		name   string
+		path   string
+		status int
+		body   string
+	}{
+		{"unshaped", "/api/v1/games",
+			http.StatusOK, `{"success":true,"data":[{"id":1152921504606846976,"moves":[{"blunder":false,"inaccuracy":true,"mistake":false}],"pgn":"1. e4 *"}]}`},
+		{"compact", "/api/v1/games?compact=true",
+			http.StatusOK, `{"data":[{"id":1152921504606846976,"moves":[{"blunder":false,"inaccuracy":true,"mistake":false}]}],"success":true}`},
+		{"fields", "/api/v1/games?fields=id",
+			http.StatusOK, `{"data":[{"id":1152921504606846976}],"success":true}`},
+		{"large response", "/api/v1/large?fields=pgn",
+			http.StatusOK, `{"data":[{"pgn":"1. e4 *"}],"success":true}`},
+		{"version shims before fields", "/api/v2/games?fields=moves.classification",
+			http.StatusOK, `{"data":[{"moves":[{"classification":"inaccuracy"}]}],"success":true}`},
+		{"other content types", "/api/v1/text?fields=id", http.StatusOK, "pgn"},
+		{"invalid fields", "/api/v1/games?fields=white..username", http.StatusBadRequest, ""},
+		{"invalid compact", "/api/v1/games?compact=yes", http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if w.Code != tt.status {
+				t.Fatalf("GET %s = %d, want %d: %s", tt.path, w.Code, tt.status, w.Body.String())
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			if got := strings.TrimSpace(w.Body.String()); got != tt.body {
+				t.Errorf("GET %s body = %s, want %s", tt.path, got, tt.body)
+			}
+		})
+	}
+}
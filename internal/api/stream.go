@@ -0,0 +1,253 @@
+package api
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"io"
+	"log"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// streamChunkSize is how much of a streamed body is written between flushes
+const streamChunkSize = 32 << 10
+
+// writeLargeJSON writes a response that can grow to several megabytes, such as a full-game
+// analysis. The body is encoded a list element at a time as it is written, so it is never held
+// whole. Bodies up to the handler's stream threshold are sent in one piece with a
+// Content-Length; larger ones are sent with chunked transfer encoding, flushed every 32 KB so
+// the client (and the compressor) can start on the body before all of it has been encoded.
+func (h *Handler) writeLargeJSON(c *gin.Context, status int, value any) {
+	c.Header("Content-Type", "application/json; charset=utf-8")
+	body := &streamedBody{c: c, status: status, threshold: h.streamThreshold}
+	if err := encodeJSON(body, reflect.ValueOf(value)); err != nil {
+		if !body.streaming {
+			c.Error(err)
+			return
+		}
+		log.Printf("Streamed response to %s cut short: %v", c.Request.URL.Path, err)
+		return
+	}
+	body.finish()
+}
+
+// streamedBody holds back a response body until it outgrows the stream threshold, then writes
+// it out in chunks as it is encoded
+type streamedBody struct {
+	c         *gin.Context
+	status    int
+	threshold int // Bodies up to this size are sent whole (0 = always)
+	buf       bytes.Buffer
+	streaming bool
+}
+
+func (b *streamedBody) Write(data []byte) (int, error) {
+	b.buf.Write(data)
+	if !b.streaming {
+		if b.threshold <= 0 || b.buf.Len() <= b.threshold {
+			return len(data), nil
+		}
+		b.streaming = true
+		b.c.Status(b.status)
+	}
+	for b.buf.Len() >= streamChunkSize {
+		if err := b.flush(streamChunkSize); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+// flush writes up to n held bytes to the client and flushes them
+func (b *streamedBody) flush(n int) error {
+	if _, err := b.c.Writer.Write(b.buf.Next(n)); err != nil {
+		return err // Client went away
+	}
+	b.c.Writer.Flush()
+	return nil
+}
+
+// finish sends a body that stayed under the threshold, or the rest of a streamed one
+func (b *streamedBody) finish() {
+	if !b.streaming {
+		b.c.Header("Content-Length", strconv.Itoa(b.buf.Len()))
+		b.c.Status(b.status)
+		b.c.Writer.Write(b.buf.Bytes())
+		return
+	}
+	if b.buf.Len() > 0 {
+		b.flush(b.buf.Len())
+	}
+}
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// encodeJSON writes a value as encoding/json would, descending through its structs, pointers and
+// interfaces down to its lists, whose elements are marshalled and written one at a time
+func encodeJSON(w io.Writer, v reflect.Value) error {
+	if !v.IsValid() {
+		_, err := io.WriteString(w, "null")
+		return err
+	}
+	if marshalsItself(v) {
+		return writeMarshalled(w, v)
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			_, err := io.WriteString(w, "null")
+			return err
+		}
+		return encodeJSON(w, v.Elem())
+	case reflect.Struct:
+		return encodeStruct(w, v)
+	case reflect.Slice:
+		if v.IsNil() || v.Type().Elem().Kind() == reflect.Uint8 {
+			return writeMarshalled(w, v) // null, or bytes as base64
+		}
+		return encodeList(w, v)
+	case reflect.Array:
+		return encodeList(w, v)
+	}
+	return writeMarshalled(w, v)
+}
+
+// marshalsItself reports whether encoding/json leaves a value to its own MarshalJSON or
+// MarshalText, or can't reach the fields of an unexported embedded struct through reflection
+func marshalsItself(v reflect.Value) bool {
+	t := v.Type()
+	for _, marshaler := range []reflect.Type{jsonMarshalerType, textMarshalerType} {
+		if t.Implements(marshaler) || (v.CanAddr() && reflect.PointerTo(t).Implements(marshaler)) {
+			return true
+		}
+	}
+	if t.Kind() == reflect.Struct {
+		for i := 0; i < t.NumField(); i++ {
+			if field := t.Field(i); field.Anonymous && !field.IsExported() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// encodeList writes a list, marshalling its elements one at a time
+func encodeList(w io.Writer, v reflect.Value) error {
+	if _, err := io.WriteString(w, "["); err != nil {
+		return err
+	}
+	for i := 0; i < v.Len(); i++ {
+		if i > 0 {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return err
+			}
+		}
+		if err := writeMarshalled(w, v.Index(i)); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(w, "]")
+	return err
+}
+
+// encodeStruct writes a struct's fields under their JSON names
+func encodeStruct(w io.Writer, v reflect.Value) error {
+	if _, err := io.WriteString(w, "{"); err != nil {
+		return err
+	}
+	first := true
+	if err := encodeFields(w, v, &first); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "}")
+	return err
+}
+
+// encodeFields writes the fields of a struct, following the json tags: "-" fields are skipped,
+// omitempty fields are left out when empty, and untagged embedded structs are flattened
+func encodeFields(w io.Writer, v reflect.Value, first *bool) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		value := v.Field(i)
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+				if value.IsNil() {
+					continue
+				}
+				value = value.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				if err := encodeFields(w, value, first); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if strings.Contains(","+options+",", ",omitempty,") && isEmptyValue(value) {
+			continue
+		}
+
+		key, _ := json.Marshal(name)
+		separator := ","
+		if *first {
+			separator = ""
+			*first = false
+		}
+		if _, err := io.WriteString(w, separator+string(key)+":"); err != nil {
+			return err
+		}
+		if err := encodeJSON(w, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isEmptyValue reports whether omitempty leaves a value out
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Interface, reflect.Pointer:
+		return v.IsZero()
+	}
+	return false
+}
+
+// writeMarshalled marshals a value whole and writes it
+func writeMarshalled(w io.Writer, v reflect.Value) error {
+	if v.CanAddr() && v.Kind() != reflect.Pointer {
+		v = v.Addr() // Methods with pointer receivers apply, as they would for encoding/json
+	}
+	data, err := json.Marshal(v.Interface())
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
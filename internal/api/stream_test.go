@@ -0,0 +1,120 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// rating marshals itself through a pointer receiver
+type rating int
+
+func (r *rating) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]int{"elo": int(*r)})
+}
+
+// Cursor is embedded in streamedPage
+type Cursor struct {
+	Next string `json:"next,omitempty"`
+}
+
+type streamedPage struct {
+	Cursor
+	Ratings  []rating `json:"ratings"`
+	Best     *rating  `json:"best,omitempty"`
+	Raw      []byte   `json:"raw"`
+	Tags     map[string]string
+	Hidden   string `json:"-"`
+	internal string
+}
+
+func TestEncodeJSON(t *testing.T) {
+	best := rating(2800)
+	analysis := &models.GameAnalysis{
+		ID:           "a1",
+		PGN:          `[Event "<Casual>"] 1. e4 *`,
+		AnalysisTime: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Moves: []models.MoveAnalysis{
+			{Move: "e4", MoveNumber: 1, Evaluation: 0.3},
+			{Move: "e5", MoveNumber: 1, Inaccuracy: true},
+		},
+	}
+
+	tests := []struct {
+		name  string
+		value any
+	}{
+		{"analysis", models.AnalysisResponse{Success: true, Data: analysis}},
+		{"page", models.APIResponse{Success: true, Data: models.Page[models.GameInfo]{Items: []models.GameInfo{{URL: "a"}, {URL: "b"}}, NextCursor: "c"}}},
+		{"empty lists", models.APIResponse{Success: true, Data: models.Page[models.GameInfo]{Items: []models.GameInfo{}}}},
+		{"error", models.APIResponse{Error: "not found"}},
+		{"tags and methods", streamedPage{Cursor: Cursor{Next: "n"}, Ratings: []rating{1500, 1600}, Best: &best, Raw: []byte("pgn"), Tags: map[string]string{"b": "1", "a": "2"}, Hidden: "x", internal: "y"}},
+		{"omitted", &streamedPage{}},
+		{"nil", nil},
+		{"array", [2]float64{0.5, -1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want, err := json.Marshal(tt.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got bytes.Buffer
+			if err := encodeJSON(&got, reflect.ValueOf(tt.value)); err != nil {
+				t.Fatalf("encodeJSON() error = %v", err)
+			}
+			if got.String() != string(want) {
+				t.Errorf("encodeJSON() = %s, want %s", got.String(), want)
+			}
+		})
+	}
+}
+
+func TestWriteLargeJSON(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	moves := make([]models.MoveAnalysis, 2000)
+	for i := range moves {
+		moves[i] = models.MoveAnalysis{Move: "Nf3", MoveNumber: i/2 + 1, FEN: "rnbqkbnr/pppppppp/8/8/8/5N2/PPPPPPPP/RNBQKB1R b KQkq - 1 1"}
+	}
+	response := models.AnalysisResponse{Success: true, Data: &models.GameAnalysis{ID: "a1", Moves: moves}}
+	want, _ := json.Marshal(response)
+
+	tests := []struct {
+		name      string
+		threshold int
+		streamed  bool
+	}{
+		{"no threshold", 0, false},
+		{"under the threshold", len(want), false},
+		{"over the threshold", len(want) - 1, true},
+		{"several chunks", 1024, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := &Handler{streamThreshold: tt.threshold}
+			router := gin.New()
+			router.GET("/analysis", func(c *gin.Context) { handler.writeLargeJSON(c, http.StatusCreated, response) })
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/analysis", nil))
+			if w.Code != http.StatusCreated || w.Body.String() != string(want) {
+				t.Fatalf("Expected the analysis with status 201, got %d with %d bytes", w.Code, w.Body.Len())
+			}
+			if streamed := w.Header().Get("Content-Length") == ""; streamed != tt.streamed {
+				t.Errorf("Content-Length = %q, want streamed %t", w.Header().Get("Content-Length"), tt.streamed)
+			}
+			if tt.streamed != w.Flushed {
+				t.Errorf("Flushed = %t, want %t", w.Flushed, tt.streamed)
+			}
+		})
+	}
+}
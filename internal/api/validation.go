@@ -0,0 +1,398 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/service"
+	"github.com/pedrampdd/ChessAnalyser/internal/storage"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Engine setting bounds accepted by the request schemas
+const (
+	maxSearchDepth   = 100
+	maxMultiPV       = 10
+	maxSkillLevel    = 20
+	maxNewGamesLimit = 500
+	maxValidateBody  = 32 << 20 // Larger bodies are left for the handler to reject
+)
+
+// fieldRule is one constraint of a request schema
+type fieldRule struct {
+	field    string           // Query parameter, or dotted JSON path where "[]" applies to every array element
+	required bool             // The field must be present
+	check    func(any) string // Describes what's wrong with a present value, empty when it's valid
+}
+
+// requestSchema declares the query parameters and JSON body fields of a route
+type requestSchema struct {
+	query []fieldRule
+	body  []fieldRule
+}
+
+// resourceLimits are the largest threads and hash size requests may ask for (0 = no bound)
+type resourceLimits struct {
+	threads  int
+	hashSize int
+}
+
+// requestSchemas returns the schemas of the built-in routes, by method and unversioned route path
+func requestSchemas(limits resourceLimits) map[string]requestSchema {
+	return map[string]requestSchema{
+		"POST /api/analyze/game": {body: append(settingsRules("settings.", limits),
+			fieldRule{field: "max_moves", check: intAtLeast(0)},
+			fieldRule{field: "verify_depth", check: intBetween(1, maxSearchDepth)},
+			fieldRule{field: "extend_depth", check: intBetween(1, service.MaxExtendDepth)},
+			fieldRule{field: "player_rating", check: intAtLeast(0)},
+		)},
+		"GET /api/analyze/position":        {query: positionRules(limits)},
+		"GET /api/analyze/position/stream": {query: positionRules(limits)},
+		"GET /api/analyze/position/lines":  {query: positionRules(limits)},
+		"GET /api/analyze/suggestion":      {query: append(positionRules(limits), fieldRule{field: "rating", check: intAtLeast(0)})},
+		"GET /api/analyze/mate":            {query: append(positionRules(limits), fieldRule{field: "moves", check: intAtLeast(0)})},
+		"POST /api/analyze/batch": {body: append(settingsRules("settings.", limits),
+			fieldRule{field: "items[].fen", check: fenSyntax},
+			fieldRule{field: "deadline", check: intAtLeast(0)},
+		)},
+		"POST /api/analyze/selfplay": {body: append(settingsRules("settings.", limits),
+			fieldRule{field: "fen", check: fenSyntax},
+			fieldRule{field: "max_plies", check: intAtLeast(0)},
+		)},
+		"POST /api/analyze/benchmark": {body: []fieldRule{
+			{field: "depth", check: intBetween(1, service.MaxBenchmarkDepth)},
+		}},
+		"POST /api/pgn/normalize": {body: []fieldRule{
+			{field: "pgn", required: true, check: nonEmpty},
+		}},
+		"GET /api/player/:username/games":        {query: pageRules()},
+		"GET /api/player/:username/synced-games": {query: pageRules()},
+		"GET /api/analysis":                      {query: pageRules()},
+		"GET /api/analyze/jobs":                  {query: pageRules()},
+		"GET /api/imports/:id/games":             {query: pageRules()},
+		"GET /api/training/puzzles/due":          {query: pageRules()},
+		"GET /api/player/:username/raw-archives": {query: pageRules()},
+		"GET /api/analysis/:id/artifacts":        {query: pageRules()},
+		"GET /api/sync/notifications":            {query: pageRules()},
+		"GET /api/watchlist":                     {query: pageRules()},
+		"GET /api/collections":                   {query: pageRules()},
+		"GET /api/analysis/dataset": {query: []fieldRule{
+			{field: "format", check: oneOf(service.DatasetFormatCSV, service.DatasetFormatParquet)},
+			{field: "limit", check: intBetween(1, service.MaxDatasetRows)},
+		}},
+		"GET /api/analysis/:id": {query: []fieldRule{
+			{field: "from_ply", check: intAtLeast(0)},
+			{field: "to_ply", check: intAtLeast(0)},
+			{field: "only", check: oneOf("blunders", "mistakes", "key")},
+		}},
+		"POST /api/ingest": {body: []fieldRule{
+			{field: "source", required: true, check: oneOf("chesscom", "lichess", "pgn", "twic")},
+			{field: "max", check: intBetween(1, service.MaxIngestGames)},
+		}},
+		"GET /api/analysis/:id/export": {query: []fieldRule{
+			{field: "format", check: oneOf(service.ExportFormatJSON, service.ExportFormatPGN, service.ExportFormatHTML, service.ExportFormatGIF)},
+			{field: "orientation", check: oneOf("white", "black")},
+		}},
+		"POST /api/analysis/:id/artifacts": {query: []fieldRule{
+			{field: "format", check: oneOf(service.ExportFormatJSON, service.ExportFormatPGN, service.ExportFormatHTML, service.ExportFormatGIF)},
+			{field: "orientation", check: oneOf("white", "black")},
+		}},
+		"GET /api/analysis/:id/commentary": {query: []fieldRule{
+			{field: "tone", check: oneOf("coach", "neutral", "banter")},
+		}},
+		"POST /api/team/match-plan": {body: []fieldRule{
+			{field: "club", required: true, check: nonEmpty},
+			{field: "opponent_club", required: true, check: nonEmpty},
+			{field: "time_class", check: oneOf("daily", "rapid", "blitz", "bullet")},
+			{field: "boards", check: intBetween(1, service.MaxTeamBoards)},
+			{field: "active_days", check: intAtLeast(1)},
+			{field: "prep_games", check: intBetween(1, service.MaxTeamPrepGames)},
+		}},
+		"GET /api/player/:username/report": {query: []fieldRule{
+			{field: "games", check: intAtLeast(1)},
+			{field: "assess_openings", check: oneOf("true", "false")},
+			{field: "depth", check: intBetween(1, maxSearchDepth)},
+			{field: "time_limit", check: intBetween(1, service.MaxDivergenceTimeLimit)},
+		}},
+		"GET /api/player/:username/novelties": {query: []fieldRule{
+			{field: "games", check: intAtLeast(1)},
+			{field: "source", check: oneOf("embedded", "lichess")},
+			{field: "depth", check: intBetween(1, maxSearchDepth)},
+			{field: "time_limit", check: intAtLeast(0)},
+		}},
+		"GET /api/player/:username/tournaments": {query: []fieldRule{
+			{field: "games", check: intAtLeast(1)},
+		}},
+		"GET /api/admin/audit": {query: append(pageRules(),
+			fieldRule{field: "since", check: timestamp},
+			fieldRule{field: "until", check: timestamp},
+		)},
+		"GET /api/sync/:username/new": {query: []fieldRule{
+			{field: "since", check: intAtLeast(0)},
+			{field: "limit", check: intBetween(1, maxNewGamesLimit)},
+		}},
+	}
+}
+
+// settingsRules constrains the engine settings under prefix
+func settingsRules(prefix string, limits resourceLimits) []fieldRule {
+	return []fieldRule{
+		{field: prefix + "depth", check: intBetween(1, maxSearchDepth)},
+		{field: prefix + "time_limit", check: intAtLeast(0)},
+		{field: prefix + "multipv", check: intBetween(1, maxMultiPV)},
+		{field: prefix + "threads", check: intUpTo(1, limits.threads)},
+		{field: prefix + "hash_size", check: intUpTo(1, limits.hashSize)},
+		{field: prefix + "skill_level", check: intBetween(0, maxSkillLevel)},
+	}
+}
+
+// pageRules constrains the page size of a list request; cursors are checked by the list
+func pageRules() []fieldRule {
+	return []fieldRule{{field: "limit", check: intBetween(1, storage.MaxPageSize)}}
+}
+
+// positionRules constrains a position analysis given in query parameters
+func positionRules(limits resourceLimits) []fieldRule {
+	return append([]fieldRule{{field: "fen", required: true, check: fenSyntax}}, settingsRules("", limits)...)
+}
+
+// ValidateRequests checks requests against their route's schema before the handler runs.
+// Invalid requests are rejected with 400 and an error for every invalid field, rather than
+// having their invalid values replaced by defaults. Engine threads and hash above maxThreads and
+// maxHashSize, the engines' configured or auto-tuned sizing, are rejected; 0 leaves them unbounded.
+func ValidateRequests(maxThreads, maxHashSize int) gin.HandlerFunc {
+	schemas := requestSchemas(resourceLimits{threads: maxThreads, hashSize: maxHashSize})
+	return func(c *gin.Context) {
+		schema, ok := schemas[c.Request.Method+" "+unversionedPath(c.FullPath())]
+		if !ok {
+			c.Next()
+			return
+		}
+
+		var invalid []*errors.ValidationError
+		for _, rule := range schema.query {
+			value, present := c.GetQuery(rule.field)
+			invalid = append(invalid, rule.validate(rule.field, value, present)...)
+		}
+		if len(schema.body) > 0 {
+			invalid = append(invalid, validateBody(c, schema.body)...)
+		}
+
+		if len(invalid) > 0 {
+			c.Error(errors.NewValidationErrors(invalid))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// validateBody checks a JSON body against the rules and puts it back for the handler. Bodies that
+// aren't JSON objects are left for the handler to reject.
+func validateBody(c *gin.Context, rules []fieldRule) []*errors.ValidationError {
+	if c.Request.Body == nil {
+		return nil
+	}
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxValidateBody+1))
+	c.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), c.Request.Body))
+	if err != nil || len(body) > maxValidateBody {
+		return nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var document map[string]any
+	if decoder.Decode(&document) != nil {
+		return nil
+	}
+
+	var invalid []*errors.ValidationError
+	for _, rule := range rules {
+		invalid = append(invalid, validatePath(rule, document, rule.field, "")...)
+	}
+	return invalid
+}
+
+// validatePath applies a rule to the value at path, descending into objects and, at "[]", into
+// every element of an array. name is the path walked so far, with array indexes filled in.
+func validatePath(rule fieldRule, value any, path, name string) []*errors.ValidationError {
+	if path == "" {
+		return rule.validate(name, value, true)
+	}
+
+	key, rest, _ := strings.Cut(path, ".")
+	if elements, ok := strings.CutSuffix(key, "[]"); ok {
+		object, _ := value.(map[string]any)
+		array, _ := object[elements].([]any)
+		var invalid []*errors.ValidationError
+		for i, element := range array {
+			invalid = append(invalid, validatePath(rule, element, rest, fmt.Sprintf("%s[%d]", joinField(name, elements), i))...)
+		}
+		return invalid
+	}
+
+	object, _ := value.(map[string]any)
+	child, present := object[key]
+	if !present || child == nil {
+		if rest == "" {
+			return rule.validate(joinField(name, key), nil, false)
+		}
+		return nil
+	}
+	return validatePath(rule, child, rest, joinField(name, key))
+}
+
+// joinField appends a key to a dotted field name
+func joinField(name, key string) string {
+	if name == "" {
+		return key
+	}
+	return name + "." + key
+}
+
+// validate applies the rule to a value found under field
+func (rule fieldRule) validate(field string, value any, present bool) []*errors.ValidationError {
+	if !present {
+		if rule.required {
+			return []*errors.ValidationError{errors.NewValidationError(field, "is required")}
+		}
+		return nil
+	}
+	if message := rule.check(value); message != "" {
+		return []*errors.ValidationError{errors.NewValidationError(field, message)}
+	}
+	return nil
+}
+
+// intValue reads an integer from a query parameter or a JSON number
+func intValue(value any) (int, bool) {
+	var text string
+	switch v := value.(type) {
+	case string:
+		text = v
+	case json.Number:
+		text = v.String()
+	default:
+		return 0, false
+	}
+	n, err := strconv.Atoi(text)
+	return n, err == nil
+}
+
+// intBetween accepts integers from low to high inclusive
+func intBetween(low, high int) func(any) string {
+	return func(value any) string {
+		if n, ok := intValue(value); !ok || n < low || n > high {
+			return fmt.Sprintf("must be an integer between %d and %d", low, high)
+		}
+		return ""
+	}
+}
+
+// intUpTo accepts integers between low and high, or of at least low when high is 0
+func intUpTo(low, high int) func(any) string {
+	if high <= 0 {
+		return intAtLeast(low)
+	}
+	return intBetween(low, high)
+}
+
+// intAtLeast accepts integers of at least low
+func intAtLeast(low int) func(any) string {
+	return func(value any) string {
+		if n, ok := intValue(value); !ok || n < low {
+			return fmt.Sprintf("must be an integer of at least %d", low)
+		}
+		return ""
+	}
+}
+
+// oneOf accepts the given strings
+func oneOf(values ...string) func(any) string {
+	return func(value any) string {
+		if text, ok := value.(string); ok && slices.Contains(values, text) {
+			return ""
+		}
+		return fmt.Sprintf("must be one of %s", strings.Join(values, ", "))
+	}
+}
+
+// timestamp accepts RFC 3339 times, e.g. 2024-05-01T00:00:00Z
+func timestamp(value any) string {
+	if text, ok := value.(string); ok {
+		if _, err := time.Parse(time.RFC3339, text); err == nil {
+			return ""
+		}
+	}
+	return "must be an RFC 3339 time, e.g. 2024-05-01T00:00:00Z"
+}
+
+// nonEmpty accepts strings with something besides white space
+func nonEmpty(value any) string {
+	if text, ok := value.(string); ok && strings.TrimSpace(text) != "" {
+		return ""
+	}
+	return "must be a non-empty string"
+}
+
+// fenSyntax accepts FENs whose placement, side to move, castling and en passant fields are well
+// formed. Placements may carry a crazyhouse pocket, and castling may name Chess960 rook files.
+// Whether the position is legal is left to the analysis.
+func fenSyntax(value any) string {
+	fen, ok := value.(string)
+	if !ok {
+		return "must be a string"
+	}
+	fields := strings.Fields(fen)
+	if len(fields) < 4 {
+		return "must have at least placement, side to move, castling and en passant fields"
+	}
+
+	placement := fields[0]
+	if i := strings.IndexByte(placement, '['); i >= 0 && strings.HasSuffix(placement, "]") {
+		placement = placement[:i]
+	}
+	ranks := strings.Split(placement, "/")
+	if len(ranks) == 9 {
+		ranks = ranks[:8] // Crazyhouse pocket written as a ninth rank
+	}
+	if len(ranks) != 8 {
+		return "placement must have 8 ranks"
+	}
+	for i, rank := range ranks {
+		squares := 0
+		for _, r := range rank {
+			switch {
+			case r >= '1' && r <= '8':
+				squares += int(r - '0')
+			case strings.ContainsRune("pnbrqkPNBRQK", r):
+				squares++
+			case r == '~': // Promoted piece in crazyhouse
+			default:
+				return fmt.Sprintf("rank %d has an invalid character %q", 8-i, r)
+			}
+		}
+		if squares != 8 {
+			return fmt.Sprintf("rank %d must have 8 squares", 8-i)
+		}
+	}
+
+	if fields[1] != "w" && fields[1] != "b" {
+		return "side to move must be w or b"
+	}
+	if fields[2] != "-" && strings.Trim(fields[2], "KQkqABCDEFGHabcdefgh") != "" {
+		return "castling must be - or castling rights such as KQkq"
+	}
+	if ep := fields[3]; ep != "-" && (len(ep) != 2 || ep[0] < 'a' || ep[0] > 'h' || (ep[1] != '3' && ep[1] != '6')) {
+		return "en passant square must be - or a square on the third or sixth rank"
+	}
+	return ""
+}
@@ -0,0 +1,177 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestValidationChecks(t *testing.T) {
+	tests := []struct {
+		name  string
+		check func(any) string
+		value any
+		valid bool
+	}{
+		{"intBetween in range", intBetween(1, 10), "10", true},
+		{"intBetween JSON number", intBetween(1, 10), json.Number("3"), true},
+		{"intBetween below", intBetween(1, 10), "0", false},
+		{"intBetween above", intBetween(1, 10), json.Number("11"), false},
+		{"intBetween not a number", intBetween(1, 10), "ten", false},
+		{"intBetween fraction", intBetween(1, 10), json.Number("2.5"), false},
+		{"intBetween boolean", intBetween(1, 10), true, false},
+		{"intAtLeast bound", intAtLeast(0), "0", true},
+		{"intAtLeast below", intAtLeast(0), "-1", false},
+		{"oneOf listed", oneOf("csv", "parquet"), "parquet", true},
+		{"oneOf unlisted", oneOf("csv", "parquet"), "xlsx", false},
+		{"oneOf case", oneOf("csv", "parquet"), "CSV", false},
+		{"oneOf not a string", oneOf("1"), json.Number("1"), false},
+		{"timestamp RFC 3339", timestamp, "2024-05-01T00:00:00Z", true},
+		{"timestamp date only", timestamp, "2024-05-01", false},
+		{"nonEmpty text", nonEmpty, " pgn ", true},
+		{"nonEmpty blank", nonEmpty, " \n", false},
+		{"nonEmpty not a string", nonEmpty, json.Number("1"), false},
+		{"fenSyntax start", fenSyntax, "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", true},
+		{"fenSyntax without counters", fenSyntax, "8/8/8/8/8/8/8/K6k b - -", true},
+		{"fenSyntax en passant", fenSyntax, "rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq e6 0 2", true},
+		{"fenSyntax missing fields", fenSyntax, "8/8/8/8/8/8/8/K6k w", false},
+		{"fenSyntax seven ranks", fenSyntax, "8/8/8/8/8/8/K6k w - -", false},
+		{"fenSyntax short rank", fenSyntax, "8/8/8/8/8/8/8/K5k w - -", false},
+		{"fenSyntax bad piece", fenSyntax, "8/8/8/8/8/8/8/K5xk w - -", false},
+		{"fenSyntax side to move", fenSyntax, "8/8/8/8/8/8/8/K6k x - -", false},
+		{"fenSyntax castling", fenSyntax, "8/8/8/8/8/8/8/K6k w KZ -", false},
+		{"fenSyntax en passant rank", fenSyntax, "8/8/8/8/8/8/8/K6k w - e4", false},
+		{"fenSyntax not a string", fenSyntax, json.Number("1"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message := tt.check(tt.value)
+			if (message == "") != tt.valid {
+				t.Errorf("check(%v) = %q, want valid %t", tt.value, message, tt.valid)
+			}
+		})
+	}
+}
+
+func TestValidateBody(t *testing.T) {
+	rules := []fieldRule{
+		{field: "pgn", required: true, check: nonEmpty},
+		{field: "settings.depth", check: intBetween(1, maxSearchDepth)},
+		{field: "items[].fen", required: true, check: fenSyntax},
+	}
+	tests := []struct {
+		name string
+		body string
+		want []string // Invalid fields
+	}{
+		{"valid", `{"pgn": "1. e4 *", "settings": {"depth": 20}, "items": [{"fen": "8/8/8/8/8/8/8/K6k w - -"}]}`, nil},
+		{"missing required field", `{"settings": {"depth": 20}}`, []string{"pgn"}},
+		{"null counts as missing", `{"pgn": null}`, []string{"pgn"}},
+		{"nested field", `{"pgn": "1. e4 *", "settings": {"depth": 0}}`, []string{"settings.depth"}},
+		{"missing parent", `{"pgn": "1. e4 *", "settings": null}`, nil},
+		{"array elements", `{"pgn": "1. e4 *", "items": [{"fen": "8/8/8/8/8/8/8/K6k w - -"}, {}, {"fen": "bad"}]}`,
+			[]string{"items[1].fen", "items[2].fen"}},
+		{"every invalid field", `{"pgn": "", "settings": {"depth": "deep"}}`, []string{"pgn", "settings.depth"}},
+		{"not an object", `["pgn"]`, nil},
+		{"not JSON", `pgn`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+
+			var got []string
+			for _, err := range validateBody(c, rules) {
+				got = append(got, err.Field)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Invalid fields = %v, want %v", got, tt.want)
+			}
+
+			// The handler still reads the whole body
+			if body, _ := io.ReadAll(c.Request.Body); string(body) != tt.body {
+				t.Errorf("Body left for the handler = %q, want %q", body, tt.body)
+			}
+		})
+	}
+}
+
+func TestValidateRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(ErrorHandler(), ValidateRequests(2, 64))
+	handled := func(c *gin.Context) { c.String(http.StatusOK, "handled") }
+	router.GET("/api/analyze/position", handled)
+	router.POST("/api/analyze/game", handled)
+	router.GET("/api/sync/:username/new", handled)
+	router.GET("/api/v1/sync/:username/new", handled)
+	router.POST("/api/pgn/normalize", handled)
+	router.GET("/api/unchecked", handled)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+		fields []string
+	}{
+		{"valid query", http.MethodGet, "/api/sync/alice/new?since=0&limit=50", "", http.StatusOK, nil},
+		{"invalid query", http.MethodGet, "/api/sync/alice/new?since=-1&limit=501", "", http.StatusBadRequest, []string{"since", "limit"}},
+		{"versioned route", http.MethodGet, "/api/v1/sync/alice/new?limit=0", "", http.StatusBadRequest, []string{"limit"}},
+		{"valid body", http.MethodPost, "/api/pgn/normalize", `{"pgn": "1. e4 *"}`, http.StatusOK, nil},
+		{"missing body field", http.MethodPost, "/api/pgn/normalize", `{}`, http.StatusBadRequest, []string{"pgn"}},
+		{"route without a schema", http.MethodGet, "/api/unchecked?limit=-1", "", http.StatusOK, nil},
+		{"engine sizing at the limits", http.MethodGet, "/api/analyze/position?fen=8/8/8/8/8/8/8/K6k%20w%20-%20-&threads=2&hash_size=64", "", http.StatusOK, nil},
+		{"engine sizing above the limits", http.MethodGet, "/api/analyze/position?fen=8/8/8/8/8/8/8/K6k%20w%20-%20-&threads=3&hash_size=65", "",
+			http.StatusBadRequest, []string{"threads", "hash_size"}},
+		{"body engine sizing above the limits", http.MethodPost, "/api/analyze/game", `{"settings": {"threads": 64, "hash_size": 1048576}}`,
+			http.StatusBadRequest, []string{"settings.threads", "settings.hash_size"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+			if w.Code != tt.status {
+				t.Fatalf("%s %s = %d, want %d: %s", tt.method, tt.path, w.Code, tt.status, w.Body.String())
+			}
+			if tt.status == http.StatusOK {
+				if w.Body.String() != "handled" {
+					t.Errorf("Expected the handler to run, got %q", w.Body.String())
+				}
+				return
+			}
+
+			var response models.APIResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Invalid error response: %v", err)
+			}
+			var got []string
+			for _, field := range response.Errors {
+				got = append(got, field.Field)
+			}
+			if response.Success || strings.Join(got, ",") != strings.Join(tt.fields, ",") {
+				t.Errorf("Invalid fields = %v, want %v", got, tt.fields)
+			}
+		})
+	}
+}
+
+func TestRequestSchemas(t *testing.T) {
+	// Every rule of the built-in schemas checks something and names its field
+	for route, schema := range requestSchemas(resourceLimits{}) {
+		for _, rule := range append(schema.query, schema.body...) {
+			if rule.field == "" || rule.check == nil {
+				t.Errorf("%s has a rule without a field or check: %+v", route, rule)
+			}
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Context keys of the API version serving a request
+const (
+	apiBaseKey       = "api_base"       // Path prefix of the version
+	responseShimsKey = "response_shims" // Shims turning responses into the version's schema
+)
+
+// responseShim rewrites the data of a JSON response body from the previous API version's
+// schema to its own version's schema, in place
+type responseShim func(data any)
+
+// apiVersion is a versioned route group. Handlers build responses in the first version's
+// schema; each later version lists the shims turning the previous version's responses into
+// its own, so a version's responses pass through the shims of every version up to it.
+type apiVersion struct {
+	name  string // Path segment, e.g. "v1"
+	shims []responseShim
+}
+
+// apiVersions are the served API versions, oldest first
+var apiVersions = []apiVersion{
+	{name: "v1"},
+	{name: "v2", shims: []responseShim{classificationEnum}},
+}
+
+// apiBase returns the path prefix of the API version serving the request, e.g. "/api/v1"
+func apiBase(c *gin.Context) string {
+	if base := c.GetString(apiBaseKey); base != "" {
+		return base
+	}
+	return "/api"
+}
+
+// unversionedPath maps a versioned route path to the unversioned path request schemas are
+// declared under, e.g. "/api/v2/analysis/:id" to "/api/analysis/:id"
+func unversionedPath(path string) string {
+	for _, version := range apiVersions {
+		prefix := "/api/" + version.name
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return "/api" + path[len(prefix):]
+		}
+	}
+	return path
+}
+
+// versioned serves a route group as the given API version. ShapeResponses shims its JSON
+// responses to the version's schema; streams and other content types pass through unchanged.
+func versioned(base string, shims []responseShim) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(apiBaseKey, base)
+		if len(shims) > 0 {
+			c.Set(responseShimsKey, shims)
+		}
+		c.Next()
+	}
+}
+
+// versionShims returns the shims the API version serving the request applies to its responses
+func versionShims(c *gin.Context) []responseShim {
+	shims, _ := c.Value(responseShimsKey).([]responseShim)
+	return shims
+}
+
+// deprecation describes a deprecated route group
+type deprecation struct {
+	Sunset    time.Time // When the routes stop being served (zero = not scheduled)
+	Successor string    // Path prefix replacing the group's, e.g. "/api/v1"
+}
+
+// deprecated marks every response of a route group as deprecated (RFC 9745), announcing the
+// sunset (RFC 8594) and linking the same route in the successor version
+func deprecated(prefix string, deprecation deprecation) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Deprecation", "true")
+		if !deprecation.Sunset.IsZero() {
+			c.Header("Sunset", deprecation.Sunset.UTC().Format(http.TimeFormat))
+		}
+		if deprecation.Successor != "" {
+			successor := deprecation.Successor + strings.TrimPrefix(c.Request.URL.Path, prefix)
+			c.Header("Link", "<"+successor+`>; rel="successor-version"`)
+		}
+		c.Next()
+	}
+}
+
+// shimWriter holds back JSON responses until the handler is done, so their body can be
+// rewritten as a whole
+type shimWriter struct {
+	gin.ResponseWriter
+	hold        func() bool // Whether a JSON body needs rewriting, asked when it is first written
+	status      int
+	buffer      bytes.Buffer
+	buffering   bool
+	passthrough bool
+}
+
+// WriteHeader records the status; it is sent once the body's fate is decided
+func (w *shimWriter) WriteHeader(status int) {
+	if w.passthrough {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	w.status = status
+}
+
+// WriteHeaderNow starts a passthrough response unless the body is being held
+func (w *shimWriter) WriteHeaderNow() {
+	if !w.buffering {
+		w.startPassthrough()
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+// Write holds JSON bodies and passes everything else through
+func (w *shimWriter) Write(data []byte) (int, error) {
+	if !w.buffering && !w.passthrough {
+		if strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") && w.hold() {
+			w.buffering = true
+		} else {
+			w.startPassthrough()
+		}
+	}
+	if w.buffering {
+		return w.buffer.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+// WriteString writes a string like Write
+func (w *shimWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Flush is deferred for held bodies; streams are flushed as usual
+func (w *shimWriter) Flush() {
+	if !w.buffering {
+		w.startPassthrough()
+		w.ResponseWriter.Flush()
+	}
+}
+
+// Status returns the response status
+func (w *shimWriter) Status() int {
+	if w.passthrough {
+		return w.ResponseWriter.Status()
+	}
+	return w.status
+}
+
+// Written reports whether the handler started the response
+func (w *shimWriter) Written() bool {
+	return w.buffering || w.ResponseWriter.Written()
+}
+
+// Size returns the bytes written so far
+func (w *shimWriter) Size() int {
+	if w.buffering {
+		return w.buffer.Len()
+	}
+	return w.ResponseWriter.Size()
+}
+
+// startPassthrough sends the recorded status and writes straight to the client from then on
+func (w *shimWriter) startPassthrough() {
+	if !w.passthrough {
+		w.passthrough = true
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+}
+
+// finish rewrites the data of a held body and sends it. Bodies that aren't a JSON envelope are
+// sent as they are.
+func (w *shimWriter) finish(rewrite func(data any) any) {
+	if !w.buffering {
+		if !w.passthrough && w.status != http.StatusOK {
+			w.ResponseWriter.WriteHeader(w.status) // Status without a body, e.g. 204
+		}
+		return
+	}
+
+	body := w.buffer.Bytes()
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber() // Keep large integers, such as game IDs, exact
+	var response map[string]any
+	if err := decoder.Decode(&response); err == nil {
+		if data, ok := response["data"]; ok {
+			response["data"] = rewrite(data)
+			if rewritten, err := json.Marshal(response); err == nil {
+				body = rewritten
+			}
+		}
+	}
+
+	if w.Header().Get("Content-Length") != "" {
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	w.ResponseWriter.Write(body)
+}
+
+// moveFlags are the boolean classification fields v1 move analyses carry
+var moveFlags = []string{"blunder", "mistake", "inaccuracy", "miss"}
+
+// classificationEnum replaces the classification booleans of move analyses with a single
+// classification (v2): blunder, mistake, inaccuracy, miss or good. A user's override wins.
+func classificationEnum(data any) {
+	switch v := data.(type) {
+	case []any:
+		for _, element := range v {
+			classificationEnum(element)
+		}
+	case map[string]any:
+		if _, isMove := v["blunder"].(bool); isMove {
+			if _, ok := v["mistake"].(bool); ok {
+				classification := "good"
+				if override, _ := v["classification_override"].(string); override != "" {
+					classification = override
+				} else {
+					for _, flag := range moveFlags {
+						if set, _ := v[flag].(bool); set {
+							classification = flag
+							break
+						}
+					}
+				}
+				for _, flag := range moveFlags {
+					delete(v, flag)
+				}
+				delete(v, "classification_override")
+				v["classification"] = classification
+			}
+		}
+		for _, child := range v {
+			classificationEnum(child)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package blob
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLocalStore(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir(), "/blobs", []byte("secret"))
+	if err != nil {
+		t.Fatalf("NewLocalStore() error = %v", err)
+	}
+	ctx := context.Background()
+
+	if err := store.Put(ctx, "analyses/a1.json", []byte(`{"id":"a1"}`), "application/json"); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	body, err := store.Get(ctx, "analyses/a1.json")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	data, _ := io.ReadAll(body)
+	body.Close()
+	if string(data) != `{"id":"a1"}` {
+		t.Errorf("Get() = %q", data)
+	}
+
+	signed, err := store.SignedURL(ctx, "analyses/a1.json", time.Minute)
+	if err != nil {
+		t.Fatalf("SignedURL() error = %v", err)
+	}
+	u, _ := url.Parse(signed)
+	if u.Path != "/blobs/analyses/a1.json" {
+		t.Errorf("Unexpected signed URL path: %s", signed)
+	}
+	key := strings.TrimPrefix(u.Path, "/blobs/")
+	expires, signature := u.Query().Get("expires"), u.Query().Get("signature")
+	if err := store.Verify(key, expires, signature); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+	if err := store.Verify("analyses/a2.json", expires, signature); err == nil {
+		t.Error("Expected the signature to be rejected for another key")
+	}
+
+	store.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	if err := store.Verify(key, expires, signature); err == nil {
+		t.Error("Expected an expired URL to be rejected")
+	}
+
+	if err := store.Delete(ctx, "analyses/a1.json"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := store.Get(ctx, "analyses/a1.json"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound after delete, got %v", err)
+	}
+	if err := store.Put(ctx, "../escape", nil, ""); err == nil {
+		t.Error("Expected keys escaping the store to be rejected")
+	}
+}
+
+func TestNewLocalStore_RequiresDir(t *testing.T) {
+	if _, err := NewLocalStore("", "/blobs", []byte("secret")); err == nil {
+		t.Error("NewLocalStore() without a directory succeeded, want an error")
+	}
+}
@@ -0,0 +1,543 @@
+package board
+
+import "fmt"
+
+// Color is the side a piece belongs to
+type Color uint8
+
+// Piece colors
+const (
+	White Color = iota
+	Black
+)
+
+// Opponent returns the other color
+func (c Color) Opponent() Color {
+	return c ^ 1
+}
+
+// String returns "white" or "black", matching the names used in move analyses
+func (c Color) String() string {
+	if c == White {
+		return "white"
+	}
+	return "black"
+}
+
+// PieceType is the kind of a piece
+type PieceType uint8
+
+// Piece types
+const (
+	NoPieceType PieceType = iota
+	Pawn
+	Knight
+	Bishop
+	Rook
+	Queen
+	King
+)
+
+// pieceLetters maps piece types to their upper-case SAN/FEN letters
+var pieceLetters = [...]byte{' ', 'P', 'N', 'B', 'R', 'Q', 'K'}
+
+// Letter returns the upper-case letter of the piece type (P for pawns)
+func (t PieceType) Letter() byte {
+	return pieceLetters[t]
+}
+
+// String returns the name of the piece type
+func (t PieceType) String() string {
+	return [...]string{"none", "pawn", "knight", "bishop", "rook", "queen", "king"}[t]
+}
+
+// pieceTypeFromLetter parses a piece letter of either case
+func pieceTypeFromLetter(letter byte) PieceType {
+	switch letter {
+	case 'P', 'p':
+		return Pawn
+	case 'N', 'n':
+		return Knight
+	case 'B', 'b':
+		return Bishop
+	case 'R', 'r':
+		return Rook
+	case 'Q', 'q':
+		return Queen
+	case 'K', 'k':
+		return King
+	}
+	return NoPieceType
+}
+
+// Piece is a piece on the board; the zero value is an empty square
+type Piece struct {
+	Type  PieceType
+	Color Color
+}
+
+// IsEmpty reports whether the piece represents an empty square
+func (p Piece) IsEmpty() bool {
+	return p.Type == NoPieceType
+}
+
+// fenLetter returns the FEN letter of the piece (upper case for White)
+func (p Piece) fenLetter() byte {
+	letter := p.Type.Letter()
+	if p.Color == Black {
+		letter += 'a' - 'A'
+	}
+	return letter
+}
+
+// Square is a board square from 0 (a1) to 63 (h8)
+type Square int8
+
+// NoSquare marks the absence of a square, e.g. no en passant target
+const NoSquare Square = -1
+
+// NewSquare creates a square from zero-based file and rank
+func NewSquare(file, rank int) Square {
+	return Square(rank*8 + file)
+}
+
+// ParseSquare parses an algebraic square name such as "e4"
+func ParseSquare(name string) (Square, error) {
+	if len(name) != 2 || name[0] < 'a' || name[0] > 'h' || name[1] < '1' || name[1] > '8' {
+		return NoSquare, fmt.Errorf("invalid square: %q", name)
+	}
+	return NewSquare(int(name[0]-'a'), int(name[1]-'1')), nil
+}
+
+// File returns the zero-based file (0 = a)
+func (s Square) File() int {
+	return int(s) % 8
+}
+
+// Rank returns the zero-based rank (0 = first rank)
+func (s Square) Rank() int {
+	return int(s) / 8
+}
+
+// IsLight reports whether the square is a light square
+func (s Square) IsLight() bool {
+	return (s.File()+s.Rank())%2 == 1
+}
+
+// String returns the algebraic name of the square
+func (s Square) String() string {
+	if s < 0 || s > 63 {
+		return "-"
+	}
+	return string([]byte{byte('a' + s.File()), byte('1' + s.Rank())})
+}
+
+// Castling rights
+const (
+	castleWhiteKing uint8 = 1 << iota
+	castleWhiteQueen
+	castleBlackKing
+	castleBlackQueen
+)
+
+// Board is a chess position. It is a plain value, so copying it copies the position.
+type Board struct {
+	squares   [64]Piece
+	turn      Color
+	castling  uint8
+	epSquare  Square
+	halfmoves int
+	fullmoves int
+}
+
+// StartFEN is the standard starting position
+const StartFEN = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+
+// NewBoard returns the standard starting position
+func NewBoard() *Board {
+	b, _ := FromFEN(StartFEN)
+	return b
+}
+
+// Turn returns the side to move
+func (b *Board) Turn() Color {
+	return b.turn
+}
+
+// FullMoves returns the full move number
+func (b *Board) FullMoves() int {
+	return b.fullmoves
+}
+
+// HalfMoves returns the number of plies since the last capture or pawn move
+func (b *Board) HalfMoves() int {
+	return b.halfmoves
+}
+
+// PieceAt returns the piece on a square
+func (b *Board) PieceAt(s Square) Piece {
+	return b.squares[s]
+}
+
+// KingSquare returns the square of the given side's king, or NoSquare if it has none
+func (b *Board) KingSquare(c Color) Square {
+	for s := Square(0); s < 64; s++ {
+		if p := b.squares[s]; p.Type == King && p.Color == c {
+			return s
+		}
+	}
+	return NoSquare
+}
+
+// InCheck reports whether the side to move is in check
+func (b *Board) InCheck() bool {
+	king := b.KingSquare(b.turn)
+	return king != NoSquare && b.IsAttacked(king, b.turn.Opponent())
+}
+
+// Move is a move on the board
+type Move struct {
+	From      Square
+	To        Square
+	Piece     Piece     // Moving piece
+	Captured  Piece     // Captured piece, empty if none
+	Promotion PieceType // Promotion piece type, NoPieceType if none
+	EnPassant bool
+	Castle    bool
+}
+
+// UCI returns the move in UCI notation, e.g. "e2e4" or "e7e8q"
+func (m Move) UCI() string {
+	uci := m.From.String() + m.To.String()
+	if m.Promotion != NoPieceType {
+		uci += string(m.Promotion.Letter() + 'a' - 'A')
+	}
+	return uci
+}
+
+// IsCapture reports whether the move captures a piece
+func (m Move) IsCapture() bool {
+	return !m.Captured.IsEmpty()
+}
+
+var (
+	knightOffsets   = [8][2]int{{1, 2}, {2, 1}, {2, -1}, {1, -2}, {-1, -2}, {-2, -1}, {-2, 1}, {-1, 2}}
+	kingOffsets     = [8][2]int{{1, 0}, {1, 1}, {0, 1}, {-1, 1}, {-1, 0}, {-1, -1}, {0, -1}, {1, -1}}
+	bishopDirs      = [4][2]int{{1, 1}, {1, -1}, {-1, 1}, {-1, -1}}
+	rookDirs        = [4][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}}
+	promotionPieces = [4]PieceType{Queen, Rook, Bishop, Knight}
+)
+
+// offset returns the square at the given file/rank offset, or false if it is off the board
+func offset(s Square, df, dr int) (Square, bool) {
+	file, rank := s.File()+df, s.Rank()+dr
+	if file < 0 || file > 7 || rank < 0 || rank > 7 {
+		return NoSquare, false
+	}
+	return NewSquare(file, rank), true
+}
+
+// IsAttacked reports whether a square is attacked by the given side
+func (b *Board) IsAttacked(s Square, by Color) bool {
+	// Pawns attack diagonally forward, so look backwards from the target square
+	pawnRank := -1
+	if by == Black {
+		pawnRank = 1
+	}
+	for _, df := range [2]int{-1, 1} {
+		if from, ok := offset(s, df, pawnRank); ok && b.squares[from] == (Piece{Pawn, by}) {
+			return true
+		}
+	}
+
+	for _, o := range knightOffsets {
+		if from, ok := offset(s, o[0], o[1]); ok && b.squares[from] == (Piece{Knight, by}) {
+			return true
+		}
+	}
+
+	for _, o := range kingOffsets {
+		if from, ok := offset(s, o[0], o[1]); ok && b.squares[from] == (Piece{King, by}) {
+			return true
+		}
+	}
+
+	return b.slidingAttack(s, by, bishopDirs[:], Bishop) || b.slidingAttack(s, by, rookDirs[:], Rook)
+}
+
+// Attacks returns the squares attacked by the piece on s, including squares holding pieces of
+// either side. An empty square attacks nothing.
+func (b *Board) Attacks(s Square) []Square {
+	p := b.squares[s]
+	var attacked []Square
+	step := func(offsets [][2]int) {
+		for _, o := range offsets {
+			if to, ok := offset(s, o[0], o[1]); ok {
+				attacked = append(attacked, to)
+			}
+		}
+	}
+	slide := func(dirs [][2]int) {
+		for _, d := range dirs {
+			for to, ok := offset(s, d[0], d[1]); ok; to, ok = offset(to, d[0], d[1]) {
+				attacked = append(attacked, to)
+				if !b.squares[to].IsEmpty() {
+					break
+				}
+			}
+		}
+	}
+
+	switch p.Type {
+	case Pawn:
+		forward := 1
+		if p.Color == Black {
+			forward = -1
+		}
+		step([][2]int{{-1, forward}, {1, forward}})
+	case Knight:
+		step(knightOffsets[:])
+	case King:
+		step(kingOffsets[:])
+	case Bishop:
+		slide(bishopDirs[:])
+	case Rook:
+		slide(rookDirs[:])
+	case Queen:
+		slide(bishopDirs[:])
+		slide(rookDirs[:])
+	}
+	return attacked
+}
+
+// slidingAttack reports whether a bishop-like or rook-like piece (or a queen) attacks the square
+func (b *Board) slidingAttack(s Square, by Color, dirs [][2]int, slider PieceType) bool {
+	for _, d := range dirs {
+		for to, ok := offset(s, d[0], d[1]); ok; to, ok = offset(to, d[0], d[1]) {
+			p := b.squares[to]
+			if p.IsEmpty() {
+				continue
+			}
+			if p.Color == by && (p.Type == slider || p.Type == Queen) {
+				return true
+			}
+			break
+		}
+	}
+	return false
+}
+
+// LegalMoves returns all legal moves for the side to move
+func (b *Board) LegalMoves() []Move {
+	var legal []Move
+	for _, m := range b.pseudoLegalMoves() {
+		next := *b
+		next.apply(m)
+		if king := next.KingSquare(b.turn); king == NoSquare || !next.IsAttacked(king, b.turn.Opponent()) {
+			legal = append(legal, m)
+		}
+	}
+	return legal
+}
+
+// pseudoLegalMoves generates moves without checking whether they leave the king in check
+func (b *Board) pseudoLegalMoves() []Move {
+	var moves []Move
+	for from := Square(0); from < 64; from++ {
+		p := b.squares[from]
+		if p.IsEmpty() || p.Color != b.turn {
+			continue
+		}
+
+		switch p.Type {
+		case Pawn:
+			moves = b.appendPawnMoves(moves, from, p)
+		case Knight:
+			moves = b.appendStepMoves(moves, from, p, knightOffsets[:])
+		case King:
+			moves = b.appendStepMoves(moves, from, p, kingOffsets[:])
+			moves = b.appendCastlingMoves(moves, from, p)
+		case Bishop:
+			moves = b.appendSlidingMoves(moves, from, p, bishopDirs[:])
+		case Rook:
+			moves = b.appendSlidingMoves(moves, from, p, rookDirs[:])
+		case Queen:
+			moves = b.appendSlidingMoves(moves, from, p, bishopDirs[:])
+			moves = b.appendSlidingMoves(moves, from, p, rookDirs[:])
+		}
+	}
+	return moves
+}
+
+// appendPawnMoves adds pawn pushes, captures, en passant and promotions
+func (b *Board) appendPawnMoves(moves []Move, from Square, p Piece) []Move {
+	dir, startRank, lastRank := 1, 1, 7
+	if p.Color == Black {
+		dir, startRank, lastRank = -1, 6, 0
+	}
+
+	add := func(m Move) {
+		if m.To.Rank() == lastRank {
+			for _, promotion := range promotionPieces {
+				m.Promotion = promotion
+				moves = append(moves, m)
+			}
+			return
+		}
+		moves = append(moves, m)
+	}
+
+	if to, ok := offset(from, 0, dir); ok && b.squares[to].IsEmpty() {
+		add(Move{From: from, To: to, Piece: p})
+		if from.Rank() == startRank {
+			if to2, ok := offset(to, 0, dir); ok && b.squares[to2].IsEmpty() {
+				moves = append(moves, Move{From: from, To: to2, Piece: p})
+			}
+		}
+	}
+
+	for _, df := range [2]int{-1, 1} {
+		to, ok := offset(from, df, dir)
+		if !ok {
+			continue
+		}
+		if target := b.squares[to]; !target.IsEmpty() && target.Color != p.Color {
+			add(Move{From: from, To: to, Piece: p, Captured: target})
+		} else if to == b.epSquare {
+			moves = append(moves, Move{From: from, To: to, Piece: p, Captured: Piece{Pawn, p.Color.Opponent()}, EnPassant: true})
+		}
+	}
+
+	return moves
+}
+
+// appendStepMoves adds single-step moves for knights and kings
+func (b *Board) appendStepMoves(moves []Move, from Square, p Piece, offsets [][2]int) []Move {
+	for _, o := range offsets {
+		to, ok := offset(from, o[0], o[1])
+		if !ok {
+			continue
+		}
+		if target := b.squares[to]; target.IsEmpty() || target.Color != p.Color {
+			moves = append(moves, Move{From: from, To: to, Piece: p, Captured: target})
+		}
+	}
+	return moves
+}
+
+// appendSlidingMoves adds moves along the given directions until blocked
+func (b *Board) appendSlidingMoves(moves []Move, from Square, p Piece, dirs [][2]int) []Move {
+	for _, d := range dirs {
+		for to, ok := offset(from, d[0], d[1]); ok; to, ok = offset(to, d[0], d[1]) {
+			target := b.squares[to]
+			if target.IsEmpty() {
+				moves = append(moves, Move{From: from, To: to, Piece: p})
+				continue
+			}
+			if target.Color != p.Color {
+				moves = append(moves, Move{From: from, To: to, Piece: p, Captured: target})
+			}
+			break
+		}
+	}
+	return moves
+}
+
+// appendCastlingMoves adds castling moves that are allowed by the castling rights and the position
+func (b *Board) appendCastlingMoves(moves []Move, from Square, p Piece) []Move {
+	rank, kingSide, queenSide := 0, castleWhiteKing, castleWhiteQueen
+	if p.Color == Black {
+		rank, kingSide, queenSide = 7, castleBlackKing, castleBlackQueen
+	}
+	if from != NewSquare(4, rank) {
+		return moves
+	}
+
+	opponent := p.Color.Opponent()
+	if b.IsAttacked(from, opponent) {
+		return moves
+	}
+
+	if b.castling&kingSide != 0 &&
+		b.squares[NewSquare(5, rank)].IsEmpty() && b.squares[NewSquare(6, rank)].IsEmpty() &&
+		b.squares[NewSquare(7, rank)] == (Piece{Rook, p.Color}) &&
+		!b.IsAttacked(NewSquare(5, rank), opponent) && !b.IsAttacked(NewSquare(6, rank), opponent) {
+		moves = append(moves, Move{From: from, To: NewSquare(6, rank), Piece: p, Castle: true})
+	}
+
+	if b.castling&queenSide != 0 &&
+		b.squares[NewSquare(3, rank)].IsEmpty() && b.squares[NewSquare(2, rank)].IsEmpty() && b.squares[NewSquare(1, rank)].IsEmpty() &&
+		b.squares[NewSquare(0, rank)] == (Piece{Rook, p.Color}) &&
+		!b.IsAttacked(NewSquare(3, rank), opponent) && !b.IsAttacked(NewSquare(2, rank), opponent) {
+		moves = append(moves, Move{From: from, To: NewSquare(2, rank), Piece: p, Castle: true})
+	}
+
+	return moves
+}
+
+// Apply plays a move on the board. The move must come from LegalMoves, ParseSAN or ParseUCI.
+func (b *Board) Apply(m Move) {
+	b.apply(m)
+}
+
+// apply updates the position for a move without any legality checks
+func (b *Board) apply(m Move) {
+	b.squares[m.From] = Piece{}
+	if m.EnPassant {
+		b.squares[NewSquare(m.To.File(), m.From.Rank())] = Piece{}
+	}
+
+	placed := m.Piece
+	if m.Promotion != NoPieceType {
+		placed.Type = m.Promotion
+	}
+	b.squares[m.To] = placed
+
+	if m.Castle {
+		rank := m.From.Rank()
+		if m.To.File() == 6 {
+			b.squares[NewSquare(7, rank)] = Piece{}
+			b.squares[NewSquare(5, rank)] = Piece{Rook, m.Piece.Color}
+		} else {
+			b.squares[NewSquare(0, rank)] = Piece{}
+			b.squares[NewSquare(3, rank)] = Piece{Rook, m.Piece.Color}
+		}
+	}
+
+	// Moving the king or a rook, or capturing a rook, loses the matching castling rights
+	b.castling &^= castlingMask(m.From) | castlingMask(m.To)
+
+	b.epSquare = NoSquare
+	if m.Piece.Type == Pawn && (m.To.Rank()-m.From.Rank() == 2 || m.From.Rank()-m.To.Rank() == 2) {
+		b.epSquare = NewSquare(m.From.File(), (m.From.Rank()+m.To.Rank())/2)
+	}
+
+	if m.Piece.Type == Pawn || m.IsCapture() {
+		b.halfmoves = 0
+	} else {
+		b.halfmoves++
+	}
+	if b.turn == Black {
+		b.fullmoves++
+	}
+	b.turn = b.turn.Opponent()
+}
+
+// castlingMask returns the castling rights lost when a piece moves from or to the square
+func castlingMask(s Square) uint8 {
+	switch s {
+	case NewSquare(4, 0):
+		return castleWhiteKing | castleWhiteQueen
+	case NewSquare(7, 0):
+		return castleWhiteKing
+	case NewSquare(0, 0):
+		return castleWhiteQueen
+	case NewSquare(4, 7):
+		return castleBlackKing | castleBlackQueen
+	case NewSquare(7, 7):
+		return castleBlackKing
+	case NewSquare(0, 7):
+		return castleBlackQueen
+	}
+	return 0
+}
@@ -0,0 +1,250 @@
+package board
+
+import (
+	"strings"
+	"testing"
+)
+
+// perft counts leaf nodes of the legal move tree to a given depth
+func perft(b *Board, depth int) int {
+	if depth == 0 {
+		return 1
+	}
+	nodes := 0
+	for _, m := range b.LegalMoves() {
+		next := *b
+		next.Apply(m)
+		nodes += perft(&next, depth-1)
+	}
+	return nodes
+}
+
+func TestPerft(t *testing.T) {
+	tests := []struct {
+		name  string
+		fen   string
+		depth int
+		want  int
+	}{
+		{"start position", StartFEN, 3, 8902},
+		{"kiwipete", "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1", 2, 2039},
+		{"en passant and promotions", "8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1", 3, 2812},
+		{"position 4", "r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1", 2, 264},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := FromFEN(tt.fen)
+			if err != nil {
+				t.Fatalf("FromFEN() error = %v", err)
+			}
+			if got := perft(b, tt.depth); got != tt.want {
+				t.Errorf("perft(%d) = %d, want %d", tt.depth, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFENRoundTrip(t *testing.T) {
+	for _, fen := range []string{
+		StartFEN,
+		"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+		"rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3",
+	} {
+		b, err := FromFEN(fen)
+		if err != nil {
+			t.Fatalf("FromFEN(%q) error = %v", fen, err)
+		}
+		if got := b.FEN(); got != fen {
+			t.Errorf("FEN() = %q, want %q", got, fen)
+		}
+	}
+
+	for _, fen := range []string{"", "8/8/8 w - - 0 1", "rnbqkbnr/pppppppp/9/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", StartFEN[:len(StartFEN)-12] + " x KQkq - 0 1"} {
+		if _, err := FromFEN(fen); err == nil {
+			t.Errorf("FromFEN(%q) expected an error", fen)
+		}
+	}
+}
+
+func TestSAN(t *testing.T) {
+	b := NewBoard()
+	moves := []string{"e4", "e5", "Nf3", "Nc6", "Bc4", "Nf6", "Ng5", "d5", "exd5", "Nxd5", "Nxf7", "Kxf7", "Qf3+", "Ke6", "Nc3", "Nb4", "O-O"}
+
+	for _, san := range moves {
+		m, err := b.ParseSAN(san)
+		if err != nil {
+			t.Fatalf("ParseSAN(%q) error = %v", san, err)
+		}
+		if got := b.SAN(m); got != san {
+			t.Errorf("SAN() = %q, want %q", got, san)
+		}
+		b.Apply(m)
+	}
+
+	want := "r1bq1b1r/ppp3pp/4k3/3np3/1nB5/2N2Q2/PPPP1PPP/R1B2RK1 b - - 5 9"
+	if got := b.FEN(); got != want {
+		t.Errorf("FEN() = %q, want %q", got, want)
+	}
+}
+
+func TestParseSANDisambiguationAndPromotion(t *testing.T) {
+	b, err := FromFEN("4k3/1P6/8/8/8/8/4K3/R6R w - - 0 1")
+	if err != nil {
+		t.Fatalf("FromFEN() error = %v", err)
+	}
+
+	if _, err := b.ParseSAN("Rd1"); err == nil {
+		t.Error("ParseSAN(Rd1) expected an ambiguity error")
+	}
+
+	m, err := b.ParseSAN("Rhd1")
+	if err != nil || m.From.String() != "h1" {
+		t.Errorf("ParseSAN(Rhd1) = %v, %v", m, err)
+	}
+	if got := b.SAN(m); got != "Rhd1" {
+		t.Errorf("SAN() = %q, want Rhd1", got)
+	}
+
+	m, err = b.ParseSAN("b8=N")
+	if err != nil || m.Promotion != Knight || m.UCI() != "b7b8n" {
+		t.Errorf("ParseSAN(b8=N) = %v, %v", m, err)
+	}
+
+	if got := b.SAN(mustUCI(t, b, "b7b8q")); got != "b8=Q+" {
+		t.Errorf("SAN(b7b8q) = %q, want b8=Q+", got)
+	}
+
+	castling, err := FromFEN("4k3/8/8/8/8/8/8/R3K2R w KQ - 0 1")
+	if err != nil {
+		t.Fatalf("FromFEN() error = %v", err)
+	}
+	if got := castling.SAN(mustUCI(t, castling, "e1c1")); got != "O-O-O" {
+		t.Errorf("SAN(e1c1) = %q, want O-O-O", got)
+	}
+}
+
+func TestCheckmate(t *testing.T) {
+	b := NewBoard()
+	for _, san := range []string{"f3", "e5", "g4"} {
+		b.Apply(mustSAN(t, b, san))
+	}
+
+	m := mustSAN(t, b, "Qh4#")
+	if got := b.SAN(m); got != "Qh4#" {
+		t.Errorf("SAN() = %q, want Qh4#", got)
+	}
+	b.Apply(m)
+
+	if !b.InCheck() || len(b.LegalMoves()) != 0 {
+		t.Error("Expected White to be checkmated")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		fen     string
+		wantErr bool
+	}{
+		{"start position", StartFEN, false},
+		{"black king missing", "8/8/8/8/8/8/8/4K3 w - - 0 1", true},
+		{"two white kings", "4k3/8/8/8/8/8/8/3KK3 w - - 0 1", true},
+		{"pawn on back rank", "4k2P/8/8/8/8/8/8/4K3 w - - 0 1", true},
+		{"nine pawns", "4k3/8/8/8/8/P7/PPPPPPPP/4K3 w - - 0 1", true},
+		{"rook next to the king", "4k3/8/8/8/8/8/8/4KR2 w - - 0 1", false},
+		{"side not to move in check", "4k3/4R3/8/8/8/8/8/4K3 w - - 0 1", true},
+	}
+
+	for _, tt := range tests {
+		b, err := FromFEN(tt.fen)
+		if err != nil {
+			t.Fatalf("FromFEN(%q) error = %v", tt.fen, err)
+		}
+		if err := b.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestInsufficientMaterial(t *testing.T) {
+	tests := []struct {
+		name string
+		fen  string
+		want bool
+	}{
+		{"bare kings", "4k3/8/8/8/8/8/8/4K3 w - - 0 1", true},
+		{"lone knight", "4k3/8/8/8/8/8/8/4KN2 w - - 0 1", true},
+		{"same colored bishops", "2b1k3/8/8/8/8/8/8/4KB2 w - - 0 1", true},
+		{"opposite colored bishops", "4k3/8/8/8/8/8/8/2B1KB2 w - - 0 1", false},
+		{"two knights", "4k3/8/8/8/8/8/8/3NKN2 w - - 0 1", false},
+		{"pawn", "4k3/8/8/8/8/8/4P3/4K3 w - - 0 1", false},
+	}
+
+	for _, tt := range tests {
+		b, err := FromFEN(tt.fen)
+		if err != nil {
+			t.Fatalf("FromFEN(%q) error = %v", tt.fen, err)
+		}
+		if got := b.InsufficientMaterial(); got != tt.want {
+			t.Errorf("%s: InsufficientMaterial() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAttacks(t *testing.T) {
+	// White: Kg1, Rd1, Nc7, Pe4; Black: Ke8, Qd5, Ra8
+	b, err := FromFEN("r3k3/2N5/8/3q4/4P3/8/8/3R2K1 w - - 0 1")
+	if err != nil {
+		t.Fatalf("FromFEN() error = %v", err)
+	}
+
+	tests := []struct {
+		square string
+		want   string
+	}{
+		{"c7", "a8 a6 b5 d5 e6 e8"},             // Knight forking king, queen and rook
+		{"e4", "d5 f5"},                         // Pawns attack diagonally forward
+		{"d1", "e1 f1 g1 c1 b1 a1 d2 d3 d4 d5"}, // Rays stop at the first piece of either side
+		{"e6", ""},                              // Empty square
+	}
+
+	for _, tt := range tests {
+		square, _ := ParseSquare(tt.square)
+		got := make(map[string]bool)
+		for _, s := range b.Attacks(square) {
+			got[s.String()] = true
+		}
+		want := make(map[string]bool)
+		for _, s := range strings.Fields(tt.want) {
+			want[s] = true
+		}
+		if len(got) != len(want) {
+			t.Errorf("Attacks(%s) = %v, want %v", tt.square, got, want)
+			continue
+		}
+		for s := range want {
+			if !got[s] {
+				t.Errorf("Attacks(%s) = %v, missing %s", tt.square, got, s)
+			}
+		}
+	}
+}
+
+func mustSAN(t *testing.T, b *Board, san string) Move {
+	t.Helper()
+	m, err := b.ParseSAN(san)
+	if err != nil {
+		t.Fatalf("ParseSAN(%q) error = %v", san, err)
+	}
+	return m
+}
+
+func mustUCI(t *testing.T, b *Board, uci string) Move {
+	t.Helper()
+	m, err := b.ParseUCI(uci)
+	if err != nil {
+		t.Fatalf("ParseUCI(%q) error = %v", uci, err)
+	}
+	return m
+}
@@ -0,0 +1,29 @@
+package board
+
+// InsufficientMaterial reports whether neither side can possibly checkmate: bare kings, a single
+// minor piece, or only bishops that all stand on squares of the same color
+func (b *Board) InsufficientMaterial() bool {
+	var minors, knights int
+	var lightBishops, darkBishops bool
+	for s := Square(0); s < 64; s++ {
+		switch b.squares[s].Type {
+		case Pawn, Rook, Queen:
+			return false
+		case Knight:
+			minors++
+			knights++
+		case Bishop:
+			minors++
+			if s.IsLight() {
+				lightBishops = true
+			} else {
+				darkBishops = true
+			}
+		}
+	}
+
+	if minors <= 1 {
+		return true
+	}
+	return knights == 0 && !(lightBishops && darkBishops)
+}
@@ -0,0 +1,149 @@
+package board
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// FromFEN parses a position in Forsyth-Edwards Notation
+func FromFEN(fen string) (*Board, error) {
+	fields := strings.Fields(fen)
+	if len(fields) < 4 {
+		return nil, fmt.Errorf("invalid FEN %q: expected at least 4 fields", fen)
+	}
+
+	b := &Board{epSquare: NoSquare, fullmoves: 1}
+
+	ranks := strings.Split(fields[0], "/")
+	if len(ranks) != 8 {
+		return nil, fmt.Errorf("invalid FEN %q: expected 8 ranks", fen)
+	}
+	for i, row := range ranks {
+		rank, file := 7-i, 0
+		for j := 0; j < len(row); j++ {
+			c := row[j]
+			if c >= '1' && c <= '8' {
+				file += int(c - '0')
+				continue
+			}
+
+			pieceType := pieceTypeFromLetter(c)
+			if pieceType == NoPieceType || file > 7 {
+				return nil, fmt.Errorf("invalid FEN %q: bad rank %q", fen, row)
+			}
+			color := White
+			if c >= 'a' {
+				color = Black
+			}
+			b.squares[NewSquare(file, rank)] = Piece{pieceType, color}
+			file++
+		}
+		if file != 8 {
+			return nil, fmt.Errorf("invalid FEN %q: rank %q does not have 8 files", fen, row)
+		}
+	}
+
+	switch fields[1] {
+	case "w":
+		b.turn = White
+	case "b":
+		b.turn = Black
+	default:
+		return nil, fmt.Errorf("invalid FEN %q: bad side to move %q", fen, fields[1])
+	}
+
+	if fields[2] != "-" {
+		for _, c := range fields[2] {
+			switch c {
+			case 'K':
+				b.castling |= castleWhiteKing
+			case 'Q':
+				b.castling |= castleWhiteQueen
+			case 'k':
+				b.castling |= castleBlackKing
+			case 'q':
+				b.castling |= castleBlackQueen
+			default:
+				return nil, fmt.Errorf("invalid FEN %q: bad castling rights %q", fen, fields[2])
+			}
+		}
+	}
+
+	if fields[3] != "-" {
+		ep, err := ParseSquare(fields[3])
+		if err != nil {
+			return nil, fmt.Errorf("invalid FEN %q: %w", fen, err)
+		}
+		b.epSquare = ep
+	}
+
+	if len(fields) > 4 {
+		halfmoves, err := strconv.Atoi(fields[4])
+		if err != nil || halfmoves < 0 {
+			return nil, fmt.Errorf("invalid FEN %q: bad halfmove clock %q", fen, fields[4])
+		}
+		b.halfmoves = halfmoves
+	}
+	if len(fields) > 5 {
+		fullmoves, err := strconv.Atoi(fields[5])
+		if err != nil || fullmoves < 1 {
+			return nil, fmt.Errorf("invalid FEN %q: bad fullmove number %q", fen, fields[5])
+		}
+		b.fullmoves = fullmoves
+	}
+
+	return b, nil
+}
+
+// FEN returns the position in Forsyth-Edwards Notation
+func (b *Board) FEN() string {
+	var sb strings.Builder
+
+	for rank := 7; rank >= 0; rank-- {
+		empty := 0
+		for file := 0; file < 8; file++ {
+			p := b.squares[NewSquare(file, rank)]
+			if p.IsEmpty() {
+				empty++
+				continue
+			}
+			if empty > 0 {
+				sb.WriteByte(byte('0' + empty))
+				empty = 0
+			}
+			sb.WriteByte(p.fenLetter())
+		}
+		if empty > 0 {
+			sb.WriteByte(byte('0' + empty))
+		}
+		if rank > 0 {
+			sb.WriteByte('/')
+		}
+	}
+
+	sb.WriteByte(' ')
+	if b.turn == White {
+		sb.WriteByte('w')
+	} else {
+		sb.WriteByte('b')
+	}
+
+	sb.WriteByte(' ')
+	castling := ""
+	for _, r := range []struct {
+		flag   uint8
+		letter string
+	}{{castleWhiteKing, "K"}, {castleWhiteQueen, "Q"}, {castleBlackKing, "k"}, {castleBlackQueen, "q"}} {
+		if b.castling&r.flag != 0 {
+			castling += r.letter
+		}
+	}
+	if castling == "" {
+		castling = "-"
+	}
+	sb.WriteString(castling)
+
+	fmt.Fprintf(&sb, " %s %d %d", b.epSquare, b.halfmoves, b.fullmoves)
+	return sb.String()
+}
@@ -0,0 +1,164 @@
+package board
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseSAN finds the legal move described by a move in Standard Algebraic Notation
+func (b *Board) ParseSAN(san string) (Move, error) {
+	clean := strings.TrimRight(strings.TrimSpace(san), "+#!?")
+
+	switch clean {
+	case "O-O", "0-0":
+		return b.findCastle(6, san)
+	case "O-O-O", "0-0-0":
+		return b.findCastle(2, san)
+	}
+
+	// Promotion: "e8=Q" or "e8Q"
+	promotion := NoPieceType
+	if idx := strings.IndexByte(clean, '='); idx != -1 && idx+1 < len(clean) {
+		promotion = pieceTypeFromLetter(clean[idx+1])
+		clean = clean[:idx]
+	} else if n := len(clean); n > 2 && strings.IndexByte("QRBN", clean[n-1]) != -1 && clean[n-2] >= '1' && clean[n-2] <= '8' {
+		promotion = pieceTypeFromLetter(clean[n-1])
+		clean = clean[:n-1]
+	}
+
+	pieceType := Pawn
+	if clean != "" && strings.IndexByte("KQRBN", clean[0]) != -1 {
+		pieceType = pieceTypeFromLetter(clean[0])
+		clean = clean[1:]
+	}
+
+	clean = strings.ReplaceAll(clean, "x", "")
+	clean = strings.ReplaceAll(clean, "-", "")
+	if len(clean) < 2 {
+		return Move{}, fmt.Errorf("invalid SAN move: %q", san)
+	}
+
+	to, err := ParseSquare(clean[len(clean)-2:])
+	if err != nil {
+		return Move{}, fmt.Errorf("invalid SAN move %q: %w", san, err)
+	}
+
+	// Remaining characters disambiguate the origin by file and/or rank
+	fromFile, fromRank := -1, -1
+	for _, c := range clean[:len(clean)-2] {
+		switch {
+		case c >= 'a' && c <= 'h':
+			fromFile = int(c - 'a')
+		case c >= '1' && c <= '8':
+			fromRank = int(c - '1')
+		default:
+			return Move{}, fmt.Errorf("invalid SAN move: %q", san)
+		}
+	}
+
+	var match *Move
+	for _, m := range b.LegalMoves() {
+		if m.Piece.Type != pieceType || m.To != to || m.Promotion != promotion || m.Castle {
+			continue
+		}
+		if (fromFile != -1 && m.From.File() != fromFile) || (fromRank != -1 && m.From.Rank() != fromRank) {
+			continue
+		}
+		if match != nil {
+			return Move{}, fmt.Errorf("ambiguous SAN move: %q", san)
+		}
+		found := m
+		match = &found
+	}
+
+	if match == nil {
+		return Move{}, fmt.Errorf("illegal move %q in position %s", san, b.FEN())
+	}
+	return *match, nil
+}
+
+// findCastle finds the legal castling move landing the king on the given file
+func (b *Board) findCastle(kingFile int, san string) (Move, error) {
+	for _, m := range b.LegalMoves() {
+		if m.Castle && m.To.File() == kingFile {
+			return m, nil
+		}
+	}
+	return Move{}, fmt.Errorf("illegal move %q in position %s", san, b.FEN())
+}
+
+// ParseUCI finds the legal move described by a move in UCI notation, e.g. "e2e4" or "e7e8q"
+func (b *Board) ParseUCI(uci string) (Move, error) {
+	for _, m := range b.LegalMoves() {
+		if m.UCI() == uci {
+			return m, nil
+		}
+	}
+	return Move{}, fmt.Errorf("illegal move %q in position %s", uci, b.FEN())
+}
+
+// SAN returns a legal move in Standard Algebraic Notation, including check and mate markers
+func (b *Board) SAN(m Move) string {
+	var san string
+
+	switch {
+	case m.Castle && m.To.File() == 6:
+		san = "O-O"
+	case m.Castle:
+		san = "O-O-O"
+	case m.Piece.Type == Pawn:
+		if m.IsCapture() {
+			san = string(rune('a'+m.From.File())) + "x"
+		}
+		san += m.To.String()
+		if m.Promotion != NoPieceType {
+			san += "=" + string(m.Promotion.Letter())
+		}
+	default:
+		san = string(m.Piece.Type.Letter()) + b.disambiguation(m)
+		if m.IsCapture() {
+			san += "x"
+		}
+		san += m.To.String()
+	}
+
+	next := *b
+	next.apply(m)
+	if next.InCheck() {
+		if len(next.LegalMoves()) == 0 {
+			san += "#"
+		} else {
+			san += "+"
+		}
+	}
+
+	return san
+}
+
+// disambiguation returns the origin file and/or rank needed to tell a piece move apart
+func (b *Board) disambiguation(m Move) string {
+	sameFile, sameRank, ambiguous := false, false, false
+	for _, other := range b.LegalMoves() {
+		if other.Piece != m.Piece || other.To != m.To || other.From == m.From {
+			continue
+		}
+		ambiguous = true
+		if other.From.File() == m.From.File() {
+			sameFile = true
+		}
+		if other.From.Rank() == m.From.Rank() {
+			sameRank = true
+		}
+	}
+
+	switch {
+	case !ambiguous:
+		return ""
+	case !sameFile:
+		return string(rune('a' + m.From.File()))
+	case !sameRank:
+		return string(rune('1' + m.From.Rank()))
+	default:
+		return m.From.String()
+	}
+}
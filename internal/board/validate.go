@@ -0,0 +1,36 @@
+package board
+
+import "fmt"
+
+// Validate reports impossible positions: a side without exactly one king, pawns on the first or
+// last rank, more than eight pawns per side, or the side not to move in check
+func (b *Board) Validate() error {
+	var kings, pawns [2]int
+	for s := Square(0); s < 64; s++ {
+		p := b.squares[s]
+		switch p.Type {
+		case King:
+			kings[p.Color]++
+		case Pawn:
+			pawns[p.Color]++
+			if rank := s.Rank(); rank == 0 || rank == 7 {
+				return fmt.Errorf("pawn on %s", s)
+			}
+		}
+	}
+
+	for _, c := range []Color{White, Black} {
+		if kings[c] != 1 {
+			return fmt.Errorf("%s has %d kings", c, kings[c])
+		}
+		if pawns[c] > 8 {
+			return fmt.Errorf("%s has %d pawns", c, pawns[c])
+		}
+	}
+
+	waiting := b.turn.Opponent()
+	if b.IsAttacked(b.KingSquare(waiting), b.turn) {
+		return fmt.Errorf("%s is in check but it is %s's turn", waiting, b.turn)
+	}
+	return nil
+}
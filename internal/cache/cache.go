@@ -0,0 +1,169 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Cache is a concurrency-safe key-value cache bounded in size and entry age. When full, the
+// least recently used entry is evicted; entries older than the TTL are treated as missing.
+type Cache[K comparable, V any] struct {
+	maxSize int           // 0 disables caching
+	ttl     time.Duration // 0 keeps entries until evicted
+	now     func() time.Time
+	mu      sync.Mutex
+	entries map[K]*list.Element
+	order   *list.List // Most recently used first
+}
+
+// entry is a cached value and when it expires
+type entry[K comparable, V any] struct {
+	key       K
+	value     V
+	expiresAt time.Time
+}
+
+// New creates a cache holding at most maxSize entries for at most ttl each.
+// A maxSize of 0 or less disables caching; a ttl of 0 or less never expires entries.
+func New[K comparable, V any](maxSize int, ttl time.Duration) *Cache[K, V] {
+	if maxSize < 0 {
+		maxSize = 0
+	}
+	if ttl < 0 {
+		ttl = 0
+	}
+	return &Cache[K, V]{
+		maxSize: maxSize,
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[K]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// Get returns the cached value for key, and whether it was found and not expired
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var zero V
+	element, ok := c.entries[key]
+	if !ok {
+		return zero, false
+	}
+	e := element.Value.(*entry[K, V])
+	if c.expired(e) {
+		c.remove(element)
+		return zero, false
+	}
+
+	c.order.MoveToFront(element)
+	return e.value, true
+}
+
+// Set caches a value, evicting expired entries and then the least recently used one when full
+func (c *Cache[K, V]) Set(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.maxSize == 0 {
+		return
+	}
+
+	var expiresAt time.Time
+	if c.ttl > 0 {
+		expiresAt = c.now().Add(c.ttl)
+	}
+
+	if element, ok := c.entries[key]; ok {
+		e := element.Value.(*entry[K, V])
+		e.value, e.expiresAt = value, expiresAt
+		c.order.MoveToFront(element)
+		return
+	}
+
+	if len(c.entries) >= c.maxSize {
+		c.removeExpired()
+	}
+	for len(c.entries) >= c.maxSize {
+		c.remove(c.order.Back())
+	}
+
+	c.entries[key] = c.order.PushFront(&entry[K, V]{key: key, value: value, expiresAt: expiresAt})
+}
+
+// Delete removes a key from the cache
+func (c *Cache[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		c.remove(element)
+	}
+}
+
+// Update replaces the values for which fn returns a new value and true, keeping their
+// expiry and recency. fn is called with the cache locked and must not use the cache.
+func (c *Cache[K, V]) Update(fn func(key K, value V) (V, bool)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, element := range c.entries {
+		e := element.Value.(*entry[K, V])
+		if value, ok := fn(key, e.value); ok {
+			e.value = value
+		}
+	}
+}
+
+// Len returns the number of cached entries, including expired ones not yet evicted
+func (c *Cache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// MaxSize returns the most entries the cache holds
+func (c *Cache[K, V]) MaxSize() int {
+	return c.maxSize
+}
+
+// TTL returns how long entries are kept, 0 for no limit
+func (c *Cache[K, V]) TTL() time.Duration {
+	return c.ttl
+}
+
+// Clear removes every entry
+func (c *Cache[K, V]) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[K]*list.Element)
+	c.order.Init()
+}
+
+// expired reports whether an entry has outlived the TTL. Callers hold c.mu.
+func (c *Cache[K, V]) expired(e *entry[K, V]) bool {
+	return !e.expiresAt.IsZero() && !c.now().Before(e.expiresAt)
+}
+
+// removeExpired evicts every expired entry. Callers hold c.mu.
+func (c *Cache[K, V]) removeExpired() {
+	if c.ttl == 0 {
+		return
+	}
+	for element := c.order.Back(); element != nil; {
+		prev := element.Prev()
+		if c.expired(element.Value.(*entry[K, V])) {
+			c.remove(element)
+		}
+		element = prev
+	}
+}
+
+// remove evicts an entry. Callers hold c.mu.
+func (c *Cache[K, V]) remove(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.entries, element.Value.(*entry[K, V]).key)
+}
@@ -0,0 +1,112 @@
+package cache
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	c := New[string, int](2, 0)
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Get("a") // b is now the least recently used
+	c.Set("c", 3)
+
+	if _, ok := c.Get("b"); ok {
+		t.Error("Expected b to be evicted")
+	}
+	if v, ok := c.Get("a"); !ok || v != 1 {
+		t.Errorf("Get(a) = %d, %v, want 1, true", v, ok)
+	}
+	if v, ok := c.Get("c"); !ok || v != 3 {
+		t.Errorf("Get(c) = %d, %v, want 3, true", v, ok)
+	}
+	if c.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", c.Len())
+	}
+}
+
+func TestCache_TTL(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := New[string, int](2, time.Minute)
+	c.now = func() time.Time { return now }
+
+	c.Set("a", 1)
+	now = now.Add(30 * time.Second)
+	c.Set("b", 2)
+	if _, ok := c.Get("a"); !ok {
+		t.Error("Expected a to be cached before it expires")
+	}
+
+	now = now.Add(45 * time.Second)
+	if _, ok := c.Get("a"); ok {
+		t.Error("Expected a to expire after a minute")
+	}
+
+	// A full cache drops expired entries before evicting live ones
+	c.Set("c", 3)
+	now = now.Add(30 * time.Second)
+	c.Set("d", 4)
+	if _, ok := c.Get("c"); !ok {
+		t.Error("Expected c to survive while the expired b made room")
+	}
+}
+
+func TestCache_Disabled(t *testing.T) {
+	c := New[string, int](0, time.Minute)
+	c.Set("a", 1)
+	if _, ok := c.Get("a"); ok || c.Len() != 0 {
+		t.Error("Expected a cache without room to store nothing")
+	}
+}
+
+func TestCache_UpdateDeleteClear(t *testing.T) {
+	c := New[string, int](10, 0)
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Set("c", 3)
+
+	c.Update(func(key string, value int) (int, bool) {
+		return value * 10, value%2 == 1
+	})
+	if v, _ := c.Get("a"); v != 10 {
+		t.Errorf("Get(a) = %d, want 10", v)
+	}
+	if v, _ := c.Get("b"); v != 2 {
+		t.Errorf("Get(b) = %d, want 2", v)
+	}
+
+	c.Delete("a")
+	if _, ok := c.Get("a"); ok {
+		t.Error("Expected a to be deleted")
+	}
+
+	c.Clear()
+	if c.Len() != 0 {
+		t.Errorf("Len() after Clear() = %d, want 0", c.Len())
+	}
+}
+
+func TestCache_Concurrent(t *testing.T) {
+	c := New[string, int](50, time.Minute)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				key := fmt.Sprintf("%d-%d", i, j%80)
+				c.Set(key, j)
+				c.Get(key)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if c.Len() > 50 {
+		t.Errorf("Len() = %d, want at most 50", c.Len())
+	}
+}
@@ -0,0 +1,286 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ErrStopStream can be returned by a stream callback to stop decoding without an error
+var ErrStopStream = errors.New("stop stream")
+
+// ErrArchiveNotFound is returned for a month Chess.com has no archive of, e.g. one before the
+// player's account was created
+var ErrArchiveNotFound = errors.New("archive not found")
+
+// ArchivePlayer is a player entry of a monthly archive game
+type ArchivePlayer struct {
+	ID       string  `json:"@id"`
+	Username string  `json:"username"`
+	URL      string  `json:"url"`
+	Avatar   string  `json:"avatar"`
+	Country  string  `json:"country"`
+	Title    string  `json:"title"`
+	Rating   float64 `json:"rating"`
+	Result   string  `json:"result"`
+	PlayerID *int    `json:"player_id"`
+}
+
+// UnmarshalJSON accepts a player object or, as some archive entries have, the player's profile URL
+func (p *ArchivePlayer) UnmarshalJSON(data []byte) error {
+	var id string
+	if err := json.Unmarshal(data, &id); err == nil {
+		*p = profilePlayer(id)
+		return nil
+	}
+
+	type plain ArchivePlayer // Without the method, to avoid recursing
+	return json.Unmarshal(data, (*plain)(p))
+}
+
+// ArchiveGame is a game of a monthly archive
+type ArchiveGame struct {
+	URL         string        `json:"url"`
+	FEN         string        `json:"fen"`
+	PGN         string        `json:"pgn"`
+	TimeControl string        `json:"time_control"`
+	Rules       string        `json:"rules"`
+	White       ArchivePlayer `json:"white"`
+	Black       ArchivePlayer `json:"black"`
+	Result      string        `json:"result"`
+	ResultCode  string        `json:"result_code"`
+	TimeClass   string        `json:"time_class"`
+	Rated       bool          `json:"rated"`
+	StartTime   int64         `json:"start_time"`
+	EndTime     int64         `json:"end_time"`
+	Tournament  string        `json:"tournament"`
+	Match       string        `json:"match"`
+
+	// Players lists every seat of four-player and team games such as bughouse, where White and
+	// Black only name one board
+	Players []ArchivePlayer `json:"players"`
+
+	// Malformed is set on games whose fields didn't have the expected types. Fields that could be
+	// read are kept, the rest are left empty.
+	Malformed bool `json:"-"`
+}
+
+// StreamPlayerGames decodes a monthly archive one game at a time, calling fn for each game.
+// Only the current game is held in memory, so very large archives can be paged through cheaply.
+func (api *ChessComAPI) StreamPlayerGames(username string, year, month int, fn func(*ArchiveGame) error) error {
+	return api.StreamPlayerGamesContext(context.Background(), username, year, month, fn)
+}
+
+// StreamPlayerGamesContext is StreamPlayerGames with a context that aborts the download, also
+// while the archive is being decoded
+func (api *ChessComAPI) StreamPlayerGamesContext(ctx context.Context, username string, year, month int, fn func(*ArchiveGame) error) error {
+	url := fmt.Sprintf("%s/player/%s/games/%d/%02d", api.BaseURL, username, year, month)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("User-Agent", api.UserAgent)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := api.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w: %v", ErrArchiveNotFound, statusError(resp))
+	}
+	if resp.StatusCode != http.StatusOK {
+		return statusError(resp)
+	}
+
+	return DecodeGameStream(resp.Body, fn)
+}
+
+// OpenPlayerGamesPGN opens the PGN download of a monthly archive, all games of the month in one
+// multi-game PGN. It's much smaller than the JSON archive, so bulk imports should prefer it.
+// The caller must close the returned body.
+func (api *ChessComAPI) OpenPlayerGamesPGN(username string, year, month int) (io.ReadCloser, error) {
+	url := fmt.Sprintf("%s/player/%s/games/%d/%02d/pgn", api.BaseURL, username, year, month)
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("User-Agent", api.UserAgent)
+	req.Header.Set("Accept", "application/x-chess-pgn")
+
+	resp, err := api.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, statusError(resp)
+	}
+
+	return resp.Body, nil
+}
+
+// DecodeGameStream decodes the "games" array of an archive response incrementally. Games of
+// formats the typed model doesn't fit, such as bughouse, are decoded leniently and marked
+// malformed rather than failing the archive; entries that aren't objects are skipped.
+func DecodeGameStream(r io.Reader, fn func(*ArchiveGame) error) error {
+	decoder := json.NewDecoder(r)
+
+	if err := expectDelim(decoder, '{'); err != nil {
+		return err
+	}
+
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+
+		if key, _ := token.(string); key != "games" {
+			// Skip values of any other top-level key
+			var skip json.RawMessage
+			if err := decoder.Decode(&skip); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := expectDelim(decoder, '['); err != nil {
+			return err
+		}
+		for decoder.More() {
+			var raw json.RawMessage
+			if err := decoder.Decode(&raw); err != nil {
+				return err
+			}
+			game, ok := decodeArchiveGame(raw)
+			if !ok {
+				continue
+			}
+			if err := fn(game); err != nil {
+				if err == ErrStopStream {
+					return nil
+				}
+				return err
+			}
+		}
+		if err := expectDelim(decoder, ']'); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// decodeArchiveGame decodes one archive entry, falling back to reading field by field when the
+// entry doesn't fit ArchiveGame. It reports false for entries that aren't objects.
+func decodeArchiveGame(raw json.RawMessage) (*ArchiveGame, bool) {
+	var game ArchiveGame
+	if err := json.Unmarshal(raw, &game); err == nil {
+		return &game, true
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal(raw, &fields); err != nil || fields == nil {
+		return nil, false
+	}
+	game = ArchiveGame{
+		URL:         lenientString(fields["url"]),
+		FEN:         lenientString(fields["fen"]),
+		PGN:         lenientString(fields["pgn"]),
+		TimeControl: lenientString(fields["time_control"]),
+		Rules:       lenientString(fields["rules"]),
+		White:       lenientPlayer(fields["white"]),
+		Black:       lenientPlayer(fields["black"]),
+		Result:      lenientString(fields["result"]),
+		ResultCode:  lenientString(fields["result_code"]),
+		TimeClass:   lenientString(fields["time_class"]),
+		Rated:       fields["rated"] == true,
+		StartTime:   int64(lenientNumber(fields["start_time"])),
+		EndTime:     int64(lenientNumber(fields["end_time"])),
+		Tournament:  lenientString(fields["tournament"]),
+		Match:       lenientString(fields["match"]),
+		Malformed:   true,
+	}
+	if players, ok := fields["players"].([]any); ok {
+		for _, player := range players {
+			game.Players = append(game.Players, lenientPlayer(player))
+		}
+	}
+	return &game, true
+}
+
+// lenientPlayer reads a player object or profile URL, keeping the fields of the expected types
+func lenientPlayer(value any) ArchivePlayer {
+	if id, ok := value.(string); ok {
+		return profilePlayer(id)
+	}
+	fields, _ := value.(map[string]any)
+	player := ArchivePlayer{
+		ID:       lenientString(fields["@id"]),
+		Username: lenientString(fields["username"]),
+		URL:      lenientString(fields["url"]),
+		Avatar:   lenientString(fields["avatar"]),
+		Country:  lenientString(fields["country"]),
+		Title:    lenientString(fields["title"]),
+		Rating:   lenientNumber(fields["rating"]),
+		Result:   lenientString(fields["result"]),
+	}
+	if id, ok := fields["player_id"].(float64); ok {
+		playerID := int(id)
+		player.PlayerID = &playerID
+	}
+	return player
+}
+
+// profilePlayer is a player known only by their profile URL, which ends with the username
+func profilePlayer(id string) ArchivePlayer {
+	return ArchivePlayer{ID: id, Username: id[strings.LastIndex(id, "/")+1:]}
+}
+
+// lenientString returns a string value, or numbers formatted, and "" for anything else
+func lenientString(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return ""
+}
+
+// lenientNumber returns a number value, or a string holding one, and 0 for anything else
+func lenientNumber(value any) float64 {
+	switch v := value.(type) {
+	case float64:
+		return v
+	case string:
+		n, _ := strconv.ParseFloat(v, 64)
+		return n
+	}
+	return 0
+}
+
+// expectDelim reads the next token and checks that it is the given delimiter
+func expectDelim(decoder *json.Decoder, delim json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if d, ok := token.(json.Delim); !ok || d != delim {
+		return fmt.Errorf("unexpected token %v, expected %v", token, delim)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package client
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDecodeGameStream(t *testing.T) {
+	body := `{
+		"meta": {"ignored": [1, 2, 3]},
+		"games": [
+			{"url": "https://www.chess.com/game/live/1", "white": {"username": "alice", "rating": 1500, "player_id": 7}, "start_time": 1700000000},
+			{"url": "https://www.chess.com/game/live/2", "black": {"username": "bob", "title": "BOT"}},
+			{"url": "https://www.chess.com/game/live/3"}
+		]
+	}`
+
+	var urls []string
+	err := DecodeGameStream(strings.NewReader(body), func(game *ArchiveGame) error {
+		urls = append(urls, game.URL)
+		if game.URL == "https://www.chess.com/game/live/1" {
+			if game.White.Username != "alice" || game.White.Rating != 1500 || game.White.PlayerID == nil || *game.White.PlayerID != 7 {
+				t.Errorf("Unexpected white player: %+v", game.White)
+			}
+		}
+		if len(urls) == 2 {
+			return ErrStopStream
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("DecodeGameStream() error = %v", err)
+	}
+
+	if len(urls) != 2 {
+		t.Errorf("Expected decoding to stop after 2 games, got %v", urls)
+	}
+}
+
+func TestDecodeGameStream_InvalidJSON(t *testing.T) {
+	err := DecodeGameStream(strings.NewReader(`{"games": [{"url": }]}`), func(*ArchiveGame) error { return nil })
+	if err == nil {
+		t.Error("Expected an error for invalid JSON")
+	}
+}
+
+func TestDecodeGameStream_OtherFormats(t *testing.T) {
+	body := `{"games": [
+		{"url": "https://www.chess.com/game/live/1", "rules": "bughouse", "white": "https://api.chess.com/pub/player/alice", "black": {"username": "bob"},
+		 "players": [{"username": "alice"}, {"username": "bob"}, {"username": "carol"}, {"username": "dave"}]},
+		{"url": "https://www.chess.com/game/live/2", "rules": "bughouse", "start_time": "1700000000", "white": {"username": "erin", "rating": "1500"}, "black": ["frank"]},
+		"not a game",
+		{"url": "https://www.chess.com/game/live/3", "rules": "chess"}
+	]}`
+
+	var games []*ArchiveGame
+	err := DecodeGameStream(strings.NewReader(body), func(game *ArchiveGame) error {
+		games = append(games, game)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("DecodeGameStream() error = %v", err)
+	}
+	if len(games) != 3 {
+		t.Fatalf("Expected 3 games, got %d", len(games))
+	}
+
+	if game := games[0]; game.Malformed || game.White.Username != "alice" || game.White.ID != "https://api.chess.com/pub/player/alice" || len(game.Players) != 4 {
+		t.Errorf("Unexpected bughouse game: %+v", game)
+	}
+	if game := games[1]; !game.Malformed || game.StartTime != 1700000000 || game.White.Rating != 1500 || game.Black.Username != "" || game.Rules != "bughouse" {
+		t.Errorf("Unexpected malformed game: %+v", game)
+	}
+	if game := games[2]; game.Malformed || game.Rules != "chess" {
+		t.Errorf("Unexpected standard game: %+v", game)
+	}
+}
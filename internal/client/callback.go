@@ -0,0 +1,61 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrGameNotFound is returned when Chess.com has no record of a game
+var ErrGameNotFound = errors.New("game not found")
+
+// CallbackGame is a single game as served by Chess.com's website callback endpoints. Its moves
+// are TCN-encoded; see DecodeTCN.
+type CallbackGame struct {
+	ID             int64          `json:"id"`
+	MoveList       string         `json:"moveList"`
+	MoveTimestamps string         `json:"moveTimestamps"` // Clock after each ply in tenths of a second, comma-separated
+	PGNHeaders     map[string]any `json:"pgnHeaders"`
+	IsFinished     bool           `json:"isFinished"`
+	IsRated        bool           `json:"isRated"`
+	EndTime        int64          `json:"endTime"` // Unix time, set once the game is finished
+}
+
+// GetCallbackGame retrieves a live or daily game by its numeric ID
+func (api *ChessComAPI) GetCallbackGame(gameType, gameID string) (*CallbackGame, error) {
+	url := fmt.Sprintf("%s/%s/game/%s", api.CallbackURL, gameType, gameID)
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("User-Agent", api.UserAgent)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := api.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrGameNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError(resp)
+	}
+
+	var result struct {
+		Game *CallbackGame `json:"game"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	if result.Game == nil {
+		return nil, ErrGameNotFound
+	}
+
+	return result.Game, nil
+}
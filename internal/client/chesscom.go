@@ -1,23 +1,34 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
 	"time"
+
+	apperrors "github.com/pedrampdd/ChessAnalyser/pkg/errors"
 )
 
+// ErrNotModified is returned by conditional requests when the resource hasn't changed
+var ErrNotModified = errors.New("resource not modified")
+
 // ChessComAPI represents the Chess.com API client
 type ChessComAPI struct {
-	BaseURL    string
-	HTTPClient *http.Client
-	UserAgent  string
+	BaseURL     string
+	CallbackURL string // Website endpoints serving single games, e.g. ones missing from the archives' PGN
+	HTTPClient  *http.Client
+	UserAgent   string
 }
 
 // NewChessComAPI creates a new Chess.com API client
 func NewChessComAPI() *ChessComAPI {
 	return &ChessComAPI{
-		BaseURL: "https://api.chess.com/pub",
+		BaseURL:     "https://api.chess.com/pub",
+		CallbackURL: "https://www.chess.com/callback",
 		HTTPClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
@@ -25,6 +36,21 @@ func NewChessComAPI() *ChessComAPI {
 	}
 }
 
+// statusError describes an unsuccessful response. Rate-limited responses become a
+// RateLimitedError carrying the Retry-After delay when Chess.com sends one.
+func statusError(resp *http.Response) error {
+	err := fmt.Errorf("API request failed with status: %d", resp.StatusCode)
+	if resp.StatusCode != http.StatusTooManyRequests {
+		return err
+	}
+
+	var retryAfter time.Duration
+	if seconds, convErr := strconv.Atoi(resp.Header.Get("Retry-After")); convErr == nil && seconds > 0 {
+		retryAfter = time.Duration(seconds) * time.Second
+	}
+	return apperrors.NewRateLimitedError(retryAfter, err)
+}
+
 // GetPlayerProfile retrieves player profile information
 func (api *ChessComAPI) GetPlayerProfile(username string) (map[string]interface{}, error) {
 	url := fmt.Sprintf("%s/player/%s", api.BaseURL, username)
@@ -44,7 +70,7 @@ func (api *ChessComAPI) GetPlayerProfile(username string) (map[string]interface{
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API request failed with status: %d", resp.StatusCode)
+		return nil, statusError(resp)
 	}
 
 	var result map[string]interface{}
@@ -74,7 +100,7 @@ func (api *ChessComAPI) GetPlayerGames(username string, year, month int) (map[st
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API request failed with status: %d", resp.StatusCode)
+		return nil, statusError(resp)
 	}
 
 	var result map[string]interface{}
@@ -85,6 +111,75 @@ func (api *ChessComAPI) GetPlayerGames(username string, year, month int) (map[st
 	return result, nil
 }
 
+// ArchiveResponse is a monthly archive exactly as Chess.com served it
+type ArchiveResponse struct {
+	URL       string
+	Body      []byte // Raw JSON
+	ETag      string
+	FetchedAt time.Time
+}
+
+// FetchPlayerArchive retrieves the raw JSON of a monthly archive unless it still matches etag,
+// in which case it returns ErrNotModified
+func (api *ChessComAPI) FetchPlayerArchive(username string, year, month int, etag string) (*ArchiveResponse, error) {
+	url := fmt.Sprintf("%s/player/%s/games/%d/%02d", api.BaseURL, username, year, month)
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("User-Agent", api.UserAgent)
+	req.Header.Set("Accept", "application/json")
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	resp, err := api.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, ErrNotModified
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %v", ErrArchiveNotFound, statusError(resp))
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError(resp)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ArchiveResponse{
+		URL:       url,
+		Body:      body,
+		ETag:      resp.Header.Get("ETag"),
+		FetchedAt: time.Now(),
+	}, nil
+}
+
+// GetPlayerGamesIfModified retrieves a monthly archive unless it still matches etag.
+// It returns the decoded archive along with its raw response, or ErrNotModified if nothing changed.
+func (api *ChessComAPI) GetPlayerGamesIfModified(username string, year, month int, etag string) (map[string]interface{}, *ArchiveResponse, error) {
+	archive, err := api.FetchPlayerArchive(username, year, month, etag)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(archive.Body, &result); err != nil {
+		return nil, nil, err
+	}
+
+	return result, archive, nil
+}
+
 // GetPlayerStats retrieves player's statistics
 func (api *ChessComAPI) GetPlayerStats(username string) (map[string]interface{}, error) {
 	url := fmt.Sprintf("%s/player/%s/stats", api.BaseURL, username)
@@ -104,7 +199,7 @@ func (api *ChessComAPI) GetPlayerStats(username string) (map[string]interface{},
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API request failed with status: %d", resp.StatusCode)
+		return nil, statusError(resp)
 	}
 
 	var result map[string]interface{}
@@ -115,10 +210,45 @@ func (api *ChessComAPI) GetPlayerStats(username string) (map[string]interface{},
 	return result, nil
 }
 
-func (api *ChessComAPI) GetGameByID(gameID string) (map[string]interface{}, error) {
-	url := fmt.Sprintf("%s/game/live/%s", api.BaseURL, gameID)
+// GetGameByID retrieves a live game by its numeric ID. The public API has no single-game
+// endpoint, so this goes through the website's callback endpoint.
+func (api *ChessComAPI) GetGameByID(gameID string) (*CallbackGame, error) {
+	return api.GetCallbackGame("live", gameID)
+}
 
-	req, err := http.NewRequest("GET", url, nil)
+// GetPlayerCurrentGames retrieves a player's ongoing daily games
+func (api *ChessComAPI) GetPlayerCurrentGames(username string) (map[string]interface{}, error) {
+	return api.getJSON(fmt.Sprintf("%s/player/%s/games", api.BaseURL, username))
+}
+
+// GetPlayerArchives retrieves the list of monthly archive URLs for a player
+func (api *ChessComAPI) GetPlayerArchives(username string) (map[string]interface{}, error) {
+	return api.GetPlayerArchivesContext(context.Background(), username)
+}
+
+// GetPlayerArchivesContext is GetPlayerArchives with a context that aborts the request
+func (api *ChessComAPI) GetPlayerArchivesContext(ctx context.Context, username string) (map[string]interface{}, error) {
+	return api.getJSONContext(ctx, fmt.Sprintf("%s/player/%s/games/archives", api.BaseURL, username))
+}
+
+// GetClubMembers retrieves the members of a club, grouped by activity
+func (api *ChessComAPI) GetClubMembers(clubID string) (map[string]interface{}, error) {
+	return api.getJSON(fmt.Sprintf("%s/club/%s/members", api.BaseURL, clubID))
+}
+
+// GetCountryPlayers retrieves the usernames of players registered in a country
+func (api *ChessComAPI) GetCountryPlayers(countryCode string) (map[string]interface{}, error) {
+	return api.getJSON(fmt.Sprintf("%s/country/%s/players", api.BaseURL, countryCode))
+}
+
+// getJSON performs a GET request and decodes the JSON response
+func (api *ChessComAPI) getJSON(url string) (map[string]interface{}, error) {
+	return api.getJSONContext(context.Background(), url)
+}
+
+// getJSONContext is getJSON with a context that aborts the request
+func (api *ChessComAPI) getJSONContext(ctx context.Context, url string) (map[string]interface{}, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -133,7 +263,7 @@ func (api *ChessComAPI) GetGameByID(gameID string) (map[string]interface{}, erro
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API request failed with status: %d", resp.StatusCode)
+		return nil, statusError(resp)
 	}
 
 	var result map[string]interface{}
@@ -0,0 +1,217 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// LichessAPI represents the Lichess API client
+type LichessAPI struct {
+	BaseURL     string
+	ExplorerURL string // Opening explorer, served from its own host
+	Token       string // Personal API token, raising the export rate (empty = anonymous)
+	HTTPClient  *http.Client
+	UserAgent   string
+
+	// ExportIdleTimeout bounds the wait for the next game of an export (0 = one minute). Exports
+	// stream for as long as the player has games, so they aren't bound by the client's timeout.
+	ExportIdleTimeout time.Duration
+}
+
+// defaultExportIdleTimeout is the export idle timeout of clients that don't set one
+const defaultExportIdleTimeout = time.Minute
+
+// errExportStalled ends an export that sent nothing for the idle timeout
+var errExportStalled = errors.New("export stalled")
+
+// NewLichessAPI creates a new Lichess API client
+func NewLichessAPI() *LichessAPI {
+	return &LichessAPI{
+		BaseURL:     "https://lichess.org",
+		ExplorerURL: "https://explorer.lichess.ovh",
+		HTTPClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+		UserAgent:         "ChessAnalyzer/1.0",
+		ExportIdleTimeout: defaultExportIdleTimeout,
+	}
+}
+
+// LichessPlayer is a player entry of an exported Lichess game
+type LichessPlayer struct {
+	User *struct {
+		Name  string `json:"name"`
+		ID    string `json:"id"`
+		Title string `json:"title"`
+	} `json:"user"`
+	Rating  int `json:"rating"`
+	AILevel int `json:"aiLevel"` // Set for games against Lichess's computer opponent
+}
+
+// LichessGame is an exported Lichess game
+type LichessGame struct {
+	ID         string `json:"id"`
+	Rated      bool   `json:"rated"`
+	Variant    string `json:"variant"`
+	Speed      string `json:"speed"`
+	CreatedAt  int64  `json:"createdAt"`  // Milliseconds since the epoch
+	LastMoveAt int64  `json:"lastMoveAt"` // Milliseconds since the epoch
+	Status     string `json:"status"`
+	Winner     string `json:"winner"` // white or black, empty for draws and unfinished games
+	Players    struct {
+		White LichessPlayer `json:"white"`
+		Black LichessPlayer `json:"black"`
+	} `json:"players"`
+	Clock *struct {
+		Initial   int `json:"initial"`   // Seconds
+		Increment int `json:"increment"` // Seconds
+	} `json:"clock"`
+	DaysPerTurn int    `json:"daysPerTurn"` // Correspondence games
+	Tournament  string `json:"tournament"`
+	PGN         string `json:"pgn"`
+}
+
+// LichessExport selects the games of a player export
+type LichessExport struct {
+	Since time.Time // Games started from then on (zero = any)
+	Until time.Time // Games started before then (zero = any)
+	Max   int       // Most games to export, newest first (0 = all)
+}
+
+// StreamUserGames exports a player's games one at a time, newest first, calling fn for each
+// game. The export is newline-delimited JSON, so only the current game is held in memory. It
+// fails when the response or the next game takes longer than the export idle timeout.
+func (api *LichessAPI) StreamUserGames(ctx context.Context, username string, export LichessExport, fn func(*LichessGame) error) (err error) {
+	query := url.Values{"pgnInJson": {"true"}, "clocks": {"true"}, "evals": {"true"}}
+	if !export.Since.IsZero() {
+		query.Set("since", strconv.FormatInt(export.Since.UnixMilli(), 10))
+	}
+	if !export.Until.IsZero() {
+		query.Set("until", strconv.FormatInt(export.Until.UnixMilli(), 10))
+	}
+	if export.Max > 0 {
+		query.Set("max", strconv.Itoa(export.Max))
+	}
+	endpoint := fmt.Sprintf("%s/api/games/user/%s?%s", api.BaseURL, url.PathEscape(username), query.Encode())
+
+	idleTimeout := api.ExportIdleTimeout
+	if idleTimeout <= 0 {
+		idleTimeout = defaultExportIdleTimeout
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	idle := time.AfterFunc(idleTimeout, func() { cancel(errExportStalled) })
+	defer idle.Stop()
+	defer func() {
+		if err != nil && context.Cause(ctx) == errExportStalled {
+			err = fmt.Errorf("%w: nothing received for %s", errExportStalled, idleTimeout)
+		}
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("User-Agent", api.UserAgent)
+	req.Header.Set("Accept", "application/x-ndjson")
+	if api.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+api.Token)
+	}
+
+	exportClient := *api.HTTPClient
+	exportClient.Timeout = 0
+	resp, err := exportClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return statusError(resp)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 16<<20) // Long correspondence games with clocks and evals
+	for scanner.Scan() {
+		idle.Reset(idleTimeout)
+		if len(scanner.Bytes()) == 0 {
+			continue // Keep-alive
+		}
+		var game LichessGame
+		if err := json.Unmarshal(scanner.Bytes(), &game); err != nil {
+			return fmt.Errorf("failed to decode game: %w", err)
+		}
+		if err := fn(&game); err != nil {
+			if err == ErrStopStream {
+				return nil
+			}
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// LichessExplorerMove is a move played from an opening explorer position and its results
+type LichessExplorerMove struct {
+	UCI   string `json:"uci"`
+	SAN   string `json:"san"`
+	White int    `json:"white"` // Games White won after the move
+	Draws int    `json:"draws"`
+	Black int    `json:"black"`
+}
+
+// Games returns the number of games the move was played in
+func (m LichessExplorerMove) Games() int {
+	return m.White + m.Draws + m.Black
+}
+
+// LichessExplorerPosition is an opening explorer position: the results of the games that reached
+// it and the moves played from it, most played first
+type LichessExplorerPosition struct {
+	White int                   `json:"white"`
+	Draws int                   `json:"draws"`
+	Black int                   `json:"black"`
+	Moves []LichessExplorerMove `json:"moves"`
+}
+
+// GetMastersPosition looks a position up in the opening explorer's database of over-the-board
+// master games
+func (api *LichessAPI) GetMastersPosition(ctx context.Context, fen string) (*LichessExplorerPosition, error) {
+	query := url.Values{"fen": {fen}, "moves": {"30"}, "topGames": {"0"}}
+	endpoint := fmt.Sprintf("%s/masters?%s", api.ExplorerURL, query.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("User-Agent", api.UserAgent)
+	req.Header.Set("Accept", "application/json")
+	if api.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+api.Token)
+	}
+
+	resp, err := api.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError(resp)
+	}
+
+	var position LichessExplorerPosition
+	if err := json.NewDecoder(resp.Body).Decode(&position); err != nil {
+		return nil, fmt.Errorf("failed to decode explorer position: %w", err)
+	}
+	return &position, nil
+}
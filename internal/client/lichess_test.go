@@ -0,0 +1,35 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLichessAPI_StreamUserGamesStalls(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"id":"abc12345"}`)
+		w.(http.Flusher).Flush()
+		<-release // Sends nothing more
+	}))
+	defer server.Close()
+	defer close(release)
+
+	api := NewLichessAPI()
+	api.BaseURL = server.URL
+	api.ExportIdleTimeout = 50 * time.Millisecond
+
+	var games int
+	err := api.StreamUserGames(context.Background(), "alice", LichessExport{}, func(*LichessGame) error {
+		games++
+		return nil
+	})
+	if games != 1 || !errors.Is(err, errExportStalled) {
+		t.Errorf("Expected the export to stop after 1 game as stalled, got %d games and %v", games, err)
+	}
+}
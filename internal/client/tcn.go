@@ -0,0 +1,58 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+)
+
+// tcnAlphabet maps TCN characters to squares (0-63, a1 = 0), promotion targets (64-75) and drops
+const tcnAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789!?{~}(^)[_]@#$,./&-*++="
+
+// tcnPromotions lists promotion pieces in TCN order
+const tcnPromotions = "qnrbkp"
+
+// DecodeTCN decodes a Chess.com TCN move list into UCI moves. Every move takes two characters:
+// the origin square and either the target square or a promotion code, which encodes the piece
+// and whether the pawn moved straight or captured to the left or right.
+func DecodeTCN(moveList string) ([]string, error) {
+	if len(moveList)%2 != 0 {
+		return nil, fmt.Errorf("TCN move list has odd length %d", len(moveList))
+	}
+
+	moves := make([]string, 0, len(moveList)/2)
+	for i := 0; i < len(moveList); i += 2 {
+		from := strings.IndexByte(tcnAlphabet, moveList[i])
+		to := strings.IndexByte(tcnAlphabet, moveList[i+1])
+		if from < 0 || to < 0 {
+			return nil, fmt.Errorf("invalid TCN move %q", moveList[i:i+2])
+		}
+		if from > 63 {
+			return nil, fmt.Errorf("TCN move %q is a piece drop", moveList[i:i+2])
+		}
+
+		promotion := ""
+		if to > 63 {
+			if to > 75 {
+				return nil, fmt.Errorf("invalid TCN move %q", moveList[i:i+2])
+			}
+			promotion = string(tcnPromotions[(to-64)/3])
+			direction := 8 // White promotes towards the eighth rank
+			if from < 16 {
+				direction = -8
+			}
+			to = from + direction + (to-64)%3 - 1
+			if to < 0 || to > 63 {
+				return nil, fmt.Errorf("invalid TCN promotion %q", moveList[i:i+2])
+			}
+		}
+
+		moves = append(moves, tcnSquare(from)+tcnSquare(to)+promotion)
+	}
+
+	return moves, nil
+}
+
+// tcnSquare names a square index
+func tcnSquare(index int) string {
+	return string(rune('a'+index%8)) + string(rune('1'+index/8))
+}
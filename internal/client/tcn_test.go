@@ -0,0 +1,34 @@
+package client
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDecodeTCN(t *testing.T) {
+	tests := []struct {
+		name     string
+		moveList string
+		want     []string
+		wantErr  bool
+	}{
+		{"opening", "mC0K", []string{"e2e4", "e7e5"}, false},
+		{"promotion", "W~", []string{"a7a8q"}, false},
+		{"capture promotion", "j(", []string{"b2a1n"}, false},
+		{"empty", "", []string{}, false},
+		{"odd length", "mC0", nil, true},
+		{"invalid character", "m\"", nil, true},
+		{"drop", "=C", nil, true},
+	}
+
+	for _, tt := range tests {
+		got, err := DecodeTCN(tt.moveList)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: DecodeTCN() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: DecodeTCN() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// PlayerTournament is a tournament a player took part in or is registered for. Only finished
+// tournaments carry the player's results.
+type PlayerTournament struct {
+	URL           string `json:"url"`    // Tournament page on the website
+	ID            string `json:"@id"`    // Tournament in the public API, as in the tournament field of archive games
+	Status        string `json:"status"` // The player's standing, e.g. winner, eliminated, withdrew or registered
+	Wins          int    `json:"wins"`
+	Losses        int    `json:"losses"`
+	Draws         int    `json:"draws"`
+	PointsAwarded int    `json:"points_awarded"`
+	Placement     int    `json:"placement"`
+	TotalPlayers  int    `json:"total_players"`
+	TimeClass     string `json:"time_class"`
+	Type          string `json:"type"` // swiss or arena
+}
+
+// PlayerTournaments is a player's tournament history
+type PlayerTournaments struct {
+	Finished   []PlayerTournament `json:"finished"`
+	InProgress []PlayerTournament `json:"in_progress"`
+	Registered []PlayerTournament `json:"registered"`
+}
+
+// GetPlayerTournaments retrieves the tournaments a player finished, is playing or registered for
+func (api *ChessComAPI) GetPlayerTournaments(username string) (*PlayerTournaments, error) {
+	url := fmt.Sprintf("%s/player/%s/tournaments", api.BaseURL, username)
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("User-Agent", api.UserAgent)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := api.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError(resp)
+	}
+
+	var result PlayerTournaments
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
@@ -0,0 +1,94 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// ClientOptions configures how the Chess.com client reaches the API
+type ClientOptions struct {
+	BaseURL             string
+	UserAgent           string
+	Timeout             time.Duration
+	ProxyURL            string // http://, https:// or socks5:// proxy (empty = HTTP_PROXY/HTTPS_PROXY/NO_PROXY)
+	CABundle            string // PEM file of extra CAs trusted alongside the system roots, e.g. a TLS-inspecting proxy's
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	MaxConnsPerHost     int // 0 = unlimited
+	IdleConnTimeout     time.Duration
+}
+
+// NewChessComAPIWithOptions creates a Chess.com API client with a transport built from options.
+// Unset options keep the defaults of NewChessComAPI.
+func NewChessComAPIWithOptions(options ClientOptions) (*ChessComAPI, error) {
+	transport, err := NewTransport(options)
+	if err != nil {
+		return nil, err
+	}
+
+	api := NewChessComAPI()
+	api.HTTPClient.Transport = transport
+	if options.BaseURL != "" {
+		api.BaseURL = options.BaseURL
+	}
+	if options.UserAgent != "" {
+		api.UserAgent = options.UserAgent
+	}
+	if options.Timeout > 0 {
+		api.HTTPClient.Timeout = options.Timeout
+	}
+	return api, nil
+}
+
+// NewTransport builds an HTTP transport that routes through the configured proxy, trusts the
+// extra CAs and sizes its connection pool as requested
+func NewTransport(options ClientOptions) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if options.ProxyURL != "" {
+		proxy, err := url.Parse(options.ProxyURL)
+		if err != nil || proxy.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL %q", options.ProxyURL)
+		}
+		switch proxy.Scheme {
+		case "http", "https", "socks5":
+		default:
+			return nil, fmt.Errorf("unsupported proxy scheme %q", proxy.Scheme)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	if options.CABundle != "" {
+		pem, err := os.ReadFile(options.CABundle)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+		roots, err := x509.SystemCertPool()
+		if err != nil {
+			roots = x509.NewCertPool()
+		}
+		if !roots.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", options.CABundle)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12}
+	}
+
+	if options.MaxIdleConns > 0 {
+		transport.MaxIdleConns = options.MaxIdleConns
+	}
+	if options.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = options.MaxIdleConnsPerHost
+	}
+	if options.MaxConnsPerHost > 0 {
+		transport.MaxConnsPerHost = options.MaxConnsPerHost
+	}
+	if options.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = options.IdleConnTimeout
+	}
+	return transport, nil
+}
@@ -0,0 +1,89 @@
+package client
+
+import (
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNewChessComAPIWithOptions_Proxy(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+		fmt.Fprint(w, `{"username": "alice"}`)
+	}))
+	defer proxy.Close()
+
+	api, err := NewChessComAPIWithOptions(ClientOptions{
+		BaseURL:  "http://api.chess.test/pub",
+		ProxyURL: proxy.URL,
+		Timeout:  5 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("NewChessComAPIWithOptions() error = %v", err)
+	}
+
+	profile, err := api.GetPlayerProfile("alice")
+	if err != nil {
+		t.Fatalf("GetPlayerProfile() error = %v", err)
+	}
+	if proxied != "http://api.chess.test/pub/player/alice" || profile["username"] != "alice" {
+		t.Errorf("Expected the request to go through the proxy, got %q and %v", proxied, profile)
+	}
+}
+
+func TestNewChessComAPIWithOptions_CABundle(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"username": "alice"}`)
+	}))
+	defer server.Close()
+
+	// Without the bundle the test server's self-signed certificate is rejected
+	api, err := NewChessComAPIWithOptions(ClientOptions{BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("NewChessComAPIWithOptions() error = %v", err)
+	}
+	if _, err := api.GetPlayerProfile("alice"); err == nil {
+		t.Error("Expected an untrusted certificate to be rejected")
+	}
+
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	certificate := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(bundle, certificate, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	api, err = NewChessComAPIWithOptions(ClientOptions{BaseURL: server.URL, CABundle: bundle})
+	if err != nil {
+		t.Fatalf("NewChessComAPIWithOptions() error = %v", err)
+	}
+	if _, err := api.GetPlayerProfile("alice"); err != nil {
+		t.Errorf("Expected the bundled CA to be trusted, got %v", err)
+	}
+}
+
+func TestNewTransport(t *testing.T) {
+	transport, err := NewTransport(ClientOptions{MaxIdleConnsPerHost: 32, MaxConnsPerHost: 8, IdleConnTimeout: time.Minute})
+	if err != nil {
+		t.Fatalf("NewTransport() error = %v", err)
+	}
+	if transport.MaxIdleConnsPerHost != 32 || transport.MaxConnsPerHost != 8 || transport.IdleConnTimeout != time.Minute {
+		t.Errorf("Unexpected pool settings: %d idle, %d max, %v", transport.MaxIdleConnsPerHost, transport.MaxConnsPerHost, transport.IdleConnTimeout)
+	}
+
+	invalid := []ClientOptions{
+		{ProxyURL: "ftp://proxy:21"},
+		{ProxyURL: "not a url"},
+		{CABundle: filepath.Join(t.TempDir(), "missing.pem")},
+	}
+	for _, options := range invalid {
+		if _, err := NewTransport(options); err == nil {
+			t.Errorf("Expected %+v to be rejected", options)
+		}
+	}
+}
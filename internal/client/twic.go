@@ -0,0 +1,78 @@
+package client
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+// maxTWICDownload bounds a TWIC issue download. Issues are a few megabytes zipped.
+const maxTWICDownload = 64 << 20
+
+// TWICClient downloads issues of The Week in Chess
+type TWICClient struct {
+	BaseURL    string
+	HTTPClient *http.Client
+	UserAgent  string
+}
+
+// NewTWICClient creates a new TWIC client
+func NewTWICClient() *TWICClient {
+	return &TWICClient{
+		BaseURL: "https://theweekinchess.com/zips",
+		HTTPClient: &http.Client{
+			Timeout: 2 * time.Minute,
+		},
+		UserAgent: "ChessAnalyzer/1.0",
+	}
+}
+
+// OpenIssuePGN downloads a TWIC issue and opens the multi-game PGN inside its zip.
+// The caller must close the returned reader.
+func (api *TWICClient) OpenIssuePGN(ctx context.Context, issue int) (io.ReadCloser, error) {
+	url := fmt.Sprintf("%s/twic%dg.zip", api.BaseURL, issue)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("User-Agent", api.UserAgent)
+	req.Header.Set("Accept", "application/zip")
+
+	resp, err := api.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError(resp)
+	}
+
+	// Zip archives are read from their end, so the download is buffered
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxTWICDownload+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > maxTWICDownload {
+		return nil, fmt.Errorf("TWIC issue %d exceeds %d bytes", issue, maxTWICDownload)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid TWIC issue %d: %w", issue, err)
+	}
+	for _, file := range archive.File {
+		if strings.EqualFold(path.Ext(file.Name), ".pgn") {
+			return file.Open()
+		}
+	}
+	return nil, fmt.Errorf("TWIC issue %d contains no PGN", issue)
+}
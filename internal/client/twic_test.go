@@ -0,0 +1,44 @@
+package client
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTWICClient_OpenIssuePGN(t *testing.T) {
+	var archive bytes.Buffer
+	writer := zip.NewWriter(&archive)
+	file, _ := writer.Create("twic1550.pgn")
+	io.WriteString(file, "[White \"A\"]\n[Black \"B\"]\n\n1. e4 *\n")
+	writer.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/twic1550g.zip" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(archive.Bytes())
+	}))
+	defer server.Close()
+
+	api := NewTWICClient()
+	api.BaseURL = server.URL
+
+	body, err := api.OpenIssuePGN(context.Background(), 1550)
+	if err != nil {
+		t.Fatalf("OpenIssuePGN() error = %v", err)
+	}
+	defer body.Close()
+	if pgn, _ := io.ReadAll(body); !bytes.Contains(pgn, []byte("1. e4 *")) {
+		t.Errorf("Unexpected PGN: %q", pgn)
+	}
+
+	if _, err := api.OpenIssuePGN(context.Background(), 1); err == nil {
+		t.Error("Expected an error for a missing issue")
+	}
+}
@@ -2,40 +2,117 @@ package config
 
 import (
 	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // Config holds all configuration for the application
 type Config struct {
-	Server    ServerConfig
-	ChessAPI  ChessAPIConfig
-	Stockfish StockfishConfig
-	Analysis  AnalysisConfig
+	Server      ServerConfig
+	ChessAPI    ChessAPIConfig
+	Stockfish   StockfishConfig
+	EnginePools []EnginePoolConfig
+	Maia        MaiaConfig
+	Analysis    AnalysisConfig
+	Sync        SyncConfig
+	Import      ImportConfig
+	Sources     SourcesConfig
+	Tracing     TracingConfig
+	Mail        MailConfig
+	Telegram    TelegramConfig
+	Blob        BlobConfig
 }
 
 // ServerConfig holds server configuration
 type ServerConfig struct {
-	Port string
-	Host string
+	Port                 string
+	Host                 string
+	Compression          bool      // Compress responses for clients that accept it
+	CompressionEncodings []string  // Content codings offered, in order of preference
+	CompressionLevel     int       // 1 (fastest) to 9 (smallest)
+	CompressionMinSize   int       // Smaller responses are sent uncompressed, in bytes
+	StreamThreshold      int       // Analyses and game lists larger than this are streamed, in bytes (0 = never)
+	LegacyAPISunset      time.Time // When the unversioned /api routes stop being served (zero = not scheduled)
+	AdminAPIKey          string    // API key of the admin routes, such as the audit log (empty = admin routes disabled)
+	TrustedProxies       []string  // IPs and CIDRs of reverse proxies trusted to report the client IP (empty = none)
+	PublicURL            string    // Scheme and host of share and download links, e.g. https://chess.example.com (empty = the request's)
+	AllowPrivateWebhooks bool      // User-supplied webhooks may target loopback, private and link-local addresses
 }
 
 // ChessAPIConfig holds Chess.com API configuration
 type ChessAPIConfig struct {
-	BaseURL   string
-	UserAgent string
-	Timeout   int
+	BaseURL             string
+	UserAgent           string
+	Timeout             int
+	ProxyURL            string // http://, https:// or socks5:// proxy (empty = HTTP_PROXY/HTTPS_PROXY)
+	CABundle            string // PEM file of extra trusted CAs
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	MaxConnsPerHost     int     // 0 = unlimited
+	IdleConnTimeout     int     // in seconds
+	GameCacheSize       int     // Games looked up by ID kept in memory (0 disables the cache)
+	GameCacheTTL        int     // in minutes
+	ArchiveWorkers      int     // Monthly archives fetched at the same time for player reports
+	RequestsPerSecond   float64 // Archive requests per second to each host (0 = unlimited)
+	ArchiveWalkTimeout  int     // Seconds a report may spend walking a player's archives (0 = no limit)
 }
 
 // StockfishConfig holds Stockfish engine configuration
 type StockfishConfig struct {
 	ExecutablePath    string
+	AutoTune          bool // Derive MaxEngines, DefaultThreads and DefaultHashSize left unset (0) from host resources
 	MaxEngines        int
+	MaxQueue          int // Requests allowed to wait for an engine per pool (0 = unbounded)
+	ShedQueueLength   int // Queue length from which non-priority analyses are reduced (0 = never)
+	ShedMultiPV       int // Lines searched by reduced analyses (0 = unchanged)
+	ShedDepth         int // Depth of reduced analyses (0 = unchanged)
 	DefaultDepth      int
 	DefaultTimeLimit  int
 	DefaultThreads    int
 	DefaultHashSize   int
 	DefaultSkillLevel int
 	DefaultContempt   int
+	EvalFile          string // NNUE network file (empty = engine default)
+	DownloadEvalFile  bool   // Download the engine's default network if EvalFile is missing
+
+	// Scale-to-zero deployments, which shouldn't keep idle engines running or wait long for one to start
+	Lazy        bool // Start engines on first use rather than at startup
+	IdleTimeout int  // Seconds a lazily started engine may sit idle before it's stopped (0 = never)
+	ColdStart   bool // Default to a single thread and a small hash, so engines start fast
+
+	// Resource limits of engine processes, so a busy pool doesn't starve the API server (Linux only)
+	Nice        int    // Scheduling niceness of engines, 0-19 (0 = unchanged)
+	CPUs        string // CPU list engines may run on, e.g. "2-3" (empty = any)
+	MaxMemoryMB int    // Address space limit of each engine process (0 = unlimited)
+	Cgroup      string // cgroup v2 directory engines are moved into (empty = none)
+	CPUQuota    int    // Percent of one CPU the engines in Cgroup may use together (0 = unlimited)
+
+	// Restrictions engine processes run under, for deployments configuring engines they don't trust (Linux only)
+	SandboxUser      string   // Account engines run as (empty = the server's)
+	SandboxNoNetwork bool     // Engines get a network namespace without interfaces
+	SandboxReadOnly  bool     // Engines can't write files and read only libraries, their directory and NNUE networks
+	SandboxReadPaths []string // Further paths read-only engines may read
+	SandboxSeccomp   bool     // Engines can't open sockets or administer the host
+	SandboxAppArmor  string   // AppArmor profile engines are confined to (empty = none)
+}
+
+// EnginePoolConfig holds configuration for an additional engine pool, e.g. Fairy-Stockfish for variants
+type EnginePoolConfig struct {
+	Name           string
+	ExecutablePath string
+	Size           int      // Engines in the pool
+	Variants       []string // Variants routed to the pool
+	Profiles       []string // Analysis profiles routed to the pool
+}
+
+// MaiaConfig holds configuration for the optional Maia (Lc0) human move model
+type MaiaConfig struct {
+	Enabled        bool
+	ExecutablePath string // Lc0 executable
+	WeightsDir     string // Directory containing maia-1100.pb.gz ... maia-1900.pb.gz
+	Engines        int    // Lc0 processes in the Maia pool
 }
 
 // AnalysisConfig holds analysis service configuration
@@ -45,29 +122,183 @@ type AnalysisConfig struct {
 	MaxMovesPerGame    int
 	EnableCaching      bool
 	ConcurrentAnalysis bool
+	AccuracyModel      string // Accuracy model used when a request doesn't choose one
+	BatchMaxItems      int    // Positions and games a batch analysis request may hold
+	SyncMaxPlies       int    // Longest game GET /api/analyze/game analyzes within the request (0 = always a job)
+	MaxRunningJobs     int    // Games GET /api/analyze/game analyzes at once (0 = unbounded)
+	EvalCacheSize      int    // Position evaluations kept for position analysis (0 disables)
+	StreamMinPlies     int    // Games with at least this many plies to analyze stream their moves to the store (0 disables)
+	StreamWindow       int    // Analyzed moves a streamed analysis holds before writing them out
+
+	OpeningCachePlies        int    // Plies from the start of each game kept in the opening cache (0 disables it)
+	OpeningCacheMaxPositions int    // Engine results the opening cache holds at most
+	OpeningCacheFile         string // File the opening cache is persisted to (empty = memory only)
+	OpeningWarmupLines       int    // Popular ECO lines pre-analyzed at startup (0 = none)
+
+	BenchmarkFile string // File the engine benchmark baseline is persisted to (empty = memory only)
+
+	AuditMaxEntries int    // Game analyses the audit log keeps, dropping the oldest (0 disables the audit log)
+	AuditFile       string // File the audit log is appended to, so it survives restarts (empty = memory only)
+}
+
+// SyncConfig holds archive sync configuration
+type SyncConfig struct {
+	Players             []string // Chess.com usernames to keep in sync (empty disables syncing)
+	Interval            int      // in minutes
+	AutoAnalyze         bool     // Analyze newly synced games automatically
+	RawArchives         bool     // Keep raw archive responses in the blob store
+	RawArchiveRetention int      // in days; older snapshots no game traces back to are deleted (0 keeps all)
+
+	NotifyWebhook   string // URL player status notifications are posted to (empty = none)
+	NotifyChannels  string // More notification channels as comma-separated type:target pairs
+	RatingMilestone int    // Notify when a rating crosses a multiple of this (0 disables)
+	StreakLength    int    // Notify on win/loss streaks of this length and its multiples (0 disables)
+	BlunderCheck    bool   // Scan just-finished games and notify about the player's worst blunder
+}
+
+// ImportConfig holds resumable PGN import configuration
+type ImportConfig struct {
+	Dir     string // Directory holding uploaded PGN databases
+	MaxSize int64  // Largest accepted upload in bytes
+}
+
+// SourcesConfig holds the game sources games can be ingested from besides Chess.com
+type SourcesConfig struct {
+	LichessURL   string
+	LichessToken string // Personal API token, raising Lichess's export rate (empty = anonymous)
+	ExplorerURL  string // Lichess opening explorer, the lichess reference of novelty searches
+	TWICURL      string // Where TWIC issue zips are downloaded from
+	PGNDir       string // Directory of local PGN files (empty disables the pgn source)
+}
+
+// TracingConfig holds OpenTelemetry tracing configuration
+type TracingConfig struct {
+	Enabled       bool
+	ServiceName   string
+	SamplePercent int // Share of traces recorded, 0-100
+}
+
+// MailConfig holds the SMTP server used to email reports and notifications
+type MailConfig struct {
+	Host     string // Empty disables email delivery
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+// TelegramConfig holds the bot that sends reports and notifications to Telegram chats
+type TelegramConfig struct {
+	BotToken string // Empty disables Telegram delivery
+	APIURL   string
+}
+
+// BlobConfig holds the blob store used for exported artifacts
+type BlobConfig struct {
+	Backend    string // local, s3 or gcs
+	Dir        string // Directory of the local backend; required by it
+	SigningKey string // Key signing local download URLs (random when empty)
+	Bucket     string
+	Region     string
+	Endpoint   string // S3-compatible endpoint (empty = AWS)
+	AccessKey  string
+	SecretKey  string
+	PathStyle  bool
+	URLExpiry  int // Signed URL lifetime in minutes
 }
 
 // LoadConfig loads configuration from environment variables with defaults
 func LoadConfig() *Config {
+	// Auto-tuning derives the engine pool size, threads and hash that aren't set explicitly
+	autoTune := getEnvAsBool("STOCKFISH_AUTO_TUNE", false)
+	engineDefault := func(value int) int {
+		if autoTune {
+			return 0
+		}
+		return value
+	}
+	// A cold start favours engines that start fast over engines that search fast
+	coldStart := getEnvAsBool("STOCKFISH_COLD_START", false)
+	searchDefault := func(value, cold int) int {
+		if coldStart {
+			return cold
+		}
+		return engineDefault(value)
+	}
+
+	// STOCKFISH_SANDBOX turns on the sandbox restrictions that need no further configuration
+	sandbox := getEnvAsBool("STOCKFISH_SANDBOX", false)
+
 	return &Config{
 		Server: ServerConfig{
-			Port: getEnv("SERVER_PORT", "8080"),
-			Host: getEnv("SERVER_HOST", "0.0.0.0"),
+			Port:                 getEnv("SERVER_PORT", "8080"),
+			Host:                 getEnv("SERVER_HOST", "0.0.0.0"),
+			Compression:          getEnvAsBool("SERVER_COMPRESSION", true),
+			CompressionEncodings: getEnvAsListWithDefault("SERVER_COMPRESSION_ENCODINGS", []string{"zstd", "gzip", "deflate"}),
+			CompressionLevel:     getEnvAsInt("SERVER_COMPRESSION_LEVEL", 5),
+			CompressionMinSize:   getEnvAsInt("SERVER_COMPRESSION_MIN_SIZE", 1024),
+			StreamThreshold:      getEnvAsInt("SERVER_STREAM_THRESHOLD", 1<<20), // 1 MB
+			LegacyAPISunset:      getEnvAsTime("SERVER_LEGACY_API_SUNSET"),
+			AdminAPIKey:          getEnv("ADMIN_API_KEY", ""),
+			TrustedProxies:       getEnvAsList("TRUSTED_PROXIES"),
+			PublicURL:            getEnv("SERVER_PUBLIC_URL", ""),
+			AllowPrivateWebhooks: getEnvAsBool("WEBHOOK_ALLOW_PRIVATE_TARGETS", false),
 		},
 		ChessAPI: ChessAPIConfig{
-			BaseURL:   getEnv("CHESS_API_BASE_URL", "https://api.chess.com/pub"),
-			UserAgent: getEnv("CHESS_API_USER_AGENT", "ChessAnalyzer/1.0"),
-			Timeout:   getEnvAsInt("CHESS_API_TIMEOUT", 30),
+			BaseURL:             getEnv("CHESS_API_BASE_URL", "https://api.chess.com/pub"),
+			UserAgent:           getEnv("CHESS_API_USER_AGENT", "ChessAnalyzer/1.0"),
+			Timeout:             getEnvAsInt("CHESS_API_TIMEOUT", 30),
+			ProxyURL:            getEnv("CHESS_API_PROXY", ""),
+			CABundle:            getEnv("CHESS_API_CA_BUNDLE", ""),
+			MaxIdleConns:        getEnvAsInt("CHESS_API_MAX_IDLE_CONNS", 100),
+			MaxIdleConnsPerHost: getEnvAsInt("CHESS_API_MAX_IDLE_CONNS_PER_HOST", 10),
+			MaxConnsPerHost:     getEnvAsInt("CHESS_API_MAX_CONNS_PER_HOST", 0),
+			IdleConnTimeout:     getEnvAsInt("CHESS_API_IDLE_CONN_TIMEOUT", 90),
+			GameCacheSize:       getEnvAsInt("CHESS_API_GAME_CACHE_SIZE", 500),
+			GameCacheTTL:        getEnvAsInt("CHESS_API_GAME_CACHE_TTL", 60),
+			ArchiveWorkers:      getEnvAsInt("CHESS_API_ARCHIVE_WORKERS", 4),
+			RequestsPerSecond:   getEnvAsFloat("CHESS_API_REQUESTS_PER_SECOND", 10),
+			ArchiveWalkTimeout:  getEnvAsInt("CHESS_API_ARCHIVE_WALK_TIMEOUT", 60),
 		},
 		Stockfish: StockfishConfig{
 			ExecutablePath:    getEnv("STOCKFISH_PATH", "./stockfish/stockfish"),
-			MaxEngines:        getEnvAsInt("STOCKFISH_MAX_ENGINES", 4),
+			AutoTune:          autoTune,
+			MaxEngines:        getEnvAsInt("STOCKFISH_MAX_ENGINES", engineDefault(4)),
+			MaxQueue:          getEnvAsInt("STOCKFISH_MAX_QUEUE", 32),
+			Lazy:              getEnvAsBool("STOCKFISH_LAZY", false),
+			IdleTimeout:       getEnvAsInt("STOCKFISH_IDLE_TIMEOUT", 300), // 5 minutes
+			ColdStart:         coldStart,
+			ShedQueueLength:   getEnvAsInt("STOCKFISH_SHED_QUEUE_LENGTH", 0),
+			ShedMultiPV:       getEnvAsInt("STOCKFISH_SHED_MULTIPV", 1),
+			ShedDepth:         getEnvAsInt("STOCKFISH_SHED_DEPTH", 12),
 			DefaultDepth:      getEnvAsInt("STOCKFISH_DEFAULT_DEPTH", 15),
 			DefaultTimeLimit:  getEnvAsInt("STOCKFISH_DEFAULT_TIME_LIMIT", 5000), // 5 seconds
-			DefaultThreads:    getEnvAsInt("STOCKFISH_DEFAULT_THREADS", 4),
-			DefaultHashSize:   getEnvAsInt("STOCKFISH_DEFAULT_HASH_SIZE", 128), // 128 MB
+			DefaultThreads:    getEnvAsInt("STOCKFISH_DEFAULT_THREADS", searchDefault(4, 1)),
+			DefaultHashSize:   getEnvAsInt("STOCKFISH_DEFAULT_HASH_SIZE", searchDefault(128, 16)), // 128 MB (16 MB on a cold start)
 			DefaultSkillLevel: getEnvAsInt("STOCKFISH_DEFAULT_SKILL_LEVEL", 20),
 			DefaultContempt:   getEnvAsInt("STOCKFISH_DEFAULT_CONTEMPT", 0),
+			EvalFile:          getEnv("STOCKFISH_EVAL_FILE", ""),
+			DownloadEvalFile:  getEnvAsBool("STOCKFISH_DOWNLOAD_EVAL_FILE", false),
+
+			Nice:        getEnvAsInt("STOCKFISH_NICE", 0),
+			CPUs:        getEnv("STOCKFISH_CPUS", ""),
+			MaxMemoryMB: getEnvAsInt("STOCKFISH_MAX_MEMORY_MB", 0),
+			Cgroup:      getEnv("STOCKFISH_CGROUP", ""),
+			CPUQuota:    getEnvAsInt("STOCKFISH_CPU_QUOTA", 0),
+
+			SandboxUser:      getEnv("STOCKFISH_SANDBOX_USER", ""),
+			SandboxNoNetwork: getEnvAsBool("STOCKFISH_SANDBOX_NO_NETWORK", sandbox),
+			SandboxReadOnly:  getEnvAsBool("STOCKFISH_SANDBOX_READ_ONLY", sandbox),
+			SandboxReadPaths: getEnvAsList("STOCKFISH_SANDBOX_READ_PATHS"),
+			SandboxSeccomp:   getEnvAsBool("STOCKFISH_SANDBOX_SECCOMP", sandbox),
+			SandboxAppArmor:  getEnv("STOCKFISH_SANDBOX_APPARMOR", ""),
+		},
+		EnginePools: loadEnginePools(),
+		Maia: MaiaConfig{
+			Enabled:        getEnvAsBool("MAIA_ENABLED", false),
+			ExecutablePath: getEnv("MAIA_LC0_PATH", "./lc0/lc0"),
+			WeightsDir:     getEnv("MAIA_WEIGHTS_DIR", "./maia"),
+			Engines:        getEnvAsInt("MAIA_ENGINES", 1),
 		},
 		Analysis: AnalysisConfig{
 			MaxCacheSize:       getEnvAsInt("ANALYSIS_MAX_CACHE_SIZE", 1000),
@@ -75,8 +306,112 @@ func LoadConfig() *Config {
 			MaxMovesPerGame:    getEnvAsInt("ANALYSIS_MAX_MOVES_PER_GAME", 100),
 			EnableCaching:      getEnvAsBool("ANALYSIS_ENABLE_CACHING", true),
 			ConcurrentAnalysis: getEnvAsBool("ANALYSIS_CONCURRENT", true),
+			AccuracyModel:      getEnv("ANALYSIS_ACCURACY_MODEL", "legacy"),
+			BatchMaxItems:      getEnvAsInt("ANALYSIS_BATCH_MAX_ITEMS", 50),
+			SyncMaxPlies:       getEnvAsInt("ANALYSIS_SYNC_MAX_PLIES", 80),
+			MaxRunningJobs:     getEnvAsInt("ANALYSIS_MAX_RUNNING_JOBS", 4),
+			EvalCacheSize:      getEnvAsInt("ANALYSIS_EVAL_CACHE_SIZE", 10000),
+			StreamMinPlies:     getEnvAsInt("ANALYSIS_STREAM_MIN_PLIES", 400),
+			StreamWindow:       getEnvAsInt("ANALYSIS_STREAM_WINDOW", 32),
+
+			OpeningCachePlies:        getEnvAsInt("ANALYSIS_OPENING_CACHE_PLIES", 14),
+			OpeningCacheMaxPositions: getEnvAsInt("ANALYSIS_OPENING_CACHE_MAX_POSITIONS", 100000),
+			OpeningCacheFile:         getEnv("ANALYSIS_OPENING_CACHE_FILE", ""),
+			BenchmarkFile:            getEnv("ANALYSIS_BENCHMARK_FILE", ""),
+			OpeningWarmupLines:       getEnvAsInt("ANALYSIS_OPENING_WARMUP_LINES", 0),
+
+			AuditMaxEntries: getEnvAsInt("ANALYSIS_AUDIT_MAX_ENTRIES", 10000),
+			AuditFile:       getEnv("ANALYSIS_AUDIT_FILE", ""),
+		},
+		Sync: SyncConfig{
+			Players:             getEnvAsList("SYNC_PLAYERS"),
+			Interval:            getEnvAsInt("SYNC_INTERVAL", 30), // 30 minutes
+			AutoAnalyze:         getEnvAsBool("SYNC_AUTO_ANALYZE", false),
+			RawArchives:         getEnvAsBool("SYNC_RAW_ARCHIVES", true),
+			RawArchiveRetention: getEnvAsInt("SYNC_RAW_ARCHIVE_RETENTION_DAYS", 90),
+
+			NotifyWebhook:   getEnv("SYNC_NOTIFY_WEBHOOK", ""),
+			NotifyChannels:  getEnv("SYNC_NOTIFY_CHANNELS", ""),
+			RatingMilestone: getEnvAsInt("SYNC_RATING_MILESTONE", 100),
+			StreakLength:    getEnvAsInt("SYNC_STREAK_LENGTH", 5),
+			BlunderCheck:    getEnvAsBool("SYNC_BLUNDER_CHECK", false),
+		},
+		Import: ImportConfig{
+			Dir:     getEnv("IMPORT_DIR", filepath.Join(os.TempDir(), "chess-analyzer-imports")),
+			MaxSize: int64(getEnvAsInt("IMPORT_MAX_SIZE_MB", 1024)) << 20, // 1 GB
+		},
+		Sources: SourcesConfig{
+			LichessURL:   getEnv("LICHESS_API_URL", "https://lichess.org"),
+			LichessToken: getEnv("LICHESS_API_TOKEN", ""),
+			ExplorerURL:  getEnv("LICHESS_EXPLORER_URL", "https://explorer.lichess.ovh"),
+			TWICURL:      getEnv("TWIC_URL", "https://theweekinchess.com/zips"),
+			PGNDir:       getEnv("GAME_SOURCE_PGN_DIR", ""),
+		},
+		Tracing: TracingConfig{
+			Enabled:       getEnvAsBool("TRACING_ENABLED", false),
+			ServiceName:   getEnv("TRACING_SERVICE_NAME", "chess-analyzer"),
+			SamplePercent: getEnvAsInt("TRACING_SAMPLE_PERCENT", 100),
 		},
+		Mail: MailConfig{
+			Host:     getEnv("SMTP_HOST", ""),
+			Port:     getEnvAsInt("SMTP_PORT", 587),
+			Username: getEnv("SMTP_USERNAME", ""),
+			Password: getEnv("SMTP_PASSWORD", ""),
+			From:     getEnv("SMTP_FROM", "chess-analyzer@localhost"),
+		},
+		Telegram: TelegramConfig{
+			BotToken: getEnv("TELEGRAM_BOT_TOKEN", ""),
+			APIURL:   getEnv("TELEGRAM_API_URL", "https://api.telegram.org"),
+		},
+		Blob: BlobConfig{
+			Backend:    getEnv("BLOB_BACKEND", "local"),
+			Dir:        getEnv("BLOB_DIR", ""),
+			SigningKey: getEnv("BLOB_SIGNING_KEY", ""),
+			Bucket:     getEnv("BLOB_BUCKET", ""),
+			Region:     getEnv("BLOB_REGION", "us-east-1"),
+			Endpoint:   getEnv("BLOB_ENDPOINT", ""),
+			AccessKey:  getEnv("BLOB_ACCESS_KEY", ""),
+			SecretKey:  getEnv("BLOB_SECRET_KEY", ""),
+			PathStyle:  getEnvAsBool("BLOB_PATH_STYLE", false),
+			URLExpiry:  getEnvAsInt("BLOB_URL_EXPIRY", 15), // 15 minutes
+		},
+	}
+}
+
+// loadEnginePools reads the pools named in ENGINE_POOLS. Each pool is configured with
+// ENGINE_POOL_<NAME>_PATH, _SIZE, _VARIANTS and _PROFILES.
+func loadEnginePools() []EnginePoolConfig {
+	var pools []EnginePoolConfig
+	for _, name := range getEnvAsList("ENGINE_POOLS") {
+		prefix := "ENGINE_POOL_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		pools = append(pools, EnginePoolConfig{
+			Name:           name,
+			ExecutablePath: getEnv(prefix+"PATH", "./stockfish/stockfish"),
+			Size:           getEnvAsInt(prefix+"SIZE", 1),
+			Variants:       getEnvAsList(prefix + "VARIANTS"),
+			Profiles:       getEnvAsList(prefix + "PROFILES"),
+		})
 	}
+	return pools
+}
+
+// getEnvAsList gets a comma-separated environment variable as a list
+func getEnvAsList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// getEnvAsListWithDefault gets a comma-separated environment variable as a list with a default value
+func getEnvAsListWithDefault(key string, defaultValue []string) []string {
+	if values := getEnvAsList(key); len(values) > 0 {
+		return values
+	}
+	return defaultValue
 }
 
 // getEnv gets an environment variable with a default value
@@ -97,6 +432,28 @@ func getEnvAsInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+// getEnvAsFloat gets an environment variable as float with a default value
+func getEnvAsFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
+// getEnvAsTime gets an environment variable as an RFC 3339 time or a date, zero when unset or invalid
+func getEnvAsTime(key string) time.Time {
+	value := os.Getenv(key)
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t
+	}
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t
+	}
+	return time.Time{}
+}
+
 // getEnvAsBool gets an environment variable as boolean with a default value
 func getEnvAsBool(key string, defaultValue bool) bool {
 	if value := os.Getenv(key); value != "" {
@@ -0,0 +1,89 @@
+package engine
+
+import (
+	"runtime"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+)
+
+const (
+	maxAutoThreads  = 4    // Threads per engine beyond which more engines serve requests better
+	minAutoHashMB   = 16   // Smallest hash table auto-tuning gives an engine
+	maxAutoHashMB   = 2048 // Largest hash table auto-tuning gives an engine
+	unknownMemHash  = 128  // Hash table of each engine when the host memory is unknown
+	hashMemoryShare = 2    // Hash tables take at most 1/hashMemoryShare of the memory
+)
+
+// HostResources are the CPUs and memory the host offers engine processes
+type HostResources struct {
+	CPUs     int
+	MemoryMB int // 0 when unknown
+}
+
+// DetectHostResources measures the CPUs and memory available to the server process. On Linux
+// the CPU affinity, cgroup CPU quota and cgroup memory limit are taken into account.
+func DetectHostResources() HostResources {
+	cpus := runtime.NumCPU()
+	if quota := cgroupCPULimit(); quota > 0 && quota < cpus {
+		cpus = quota
+	}
+	return HostResources{CPUs: cpus, MemoryMB: hostMemoryMB()}
+}
+
+// AutoTune sizes the default engine pool for the host. Non-zero values in requested are kept and
+// the rest are derived around them, so that engines × threads doesn't exceed the CPUs left after
+// one is reserved for the API server, and the hash tables take at most half of the memory.
+func AutoTune(host HostResources, requested models.EngineTuning) models.EngineTuning {
+	tuning := models.EngineTuning{
+		CPUs:       host.CPUs,
+		MemoryMB:   host.MemoryMB,
+		MaxEngines: requested.MaxEngines,
+		Threads:    requested.Threads,
+		HashSize:   requested.HashSize,
+	}
+
+	cores := max(host.CPUs, 1)
+	if cores > 2 {
+		cores--
+	}
+	switch {
+	case tuning.MaxEngines > 0 && tuning.Threads > 0:
+	case tuning.MaxEngines > 0:
+		tuning.Threads = max(cores/tuning.MaxEngines, 1)
+	case tuning.Threads > 0:
+		tuning.MaxEngines = max(cores/tuning.Threads, 1)
+	default:
+		tuning.Threads = min(max(cores/4, 1), maxAutoThreads)
+		tuning.MaxEngines = max(cores/tuning.Threads, 1)
+	}
+
+	if tuning.HashSize == 0 {
+		tuning.HashSize = unknownMemHash
+		if host.MemoryMB > 0 {
+			tuning.HashSize = min(max(floorPowerOfTwo(host.MemoryMB/hashMemoryShare/tuning.MaxEngines), minAutoHashMB), maxAutoHashMB)
+		}
+	}
+
+	if requested.MaxEngines > 0 {
+		tuning.Overridden = append(tuning.Overridden, "max_engines")
+	}
+	if requested.Threads > 0 {
+		tuning.Overridden = append(tuning.Overridden, "threads")
+	}
+	if requested.HashSize > 0 {
+		tuning.Overridden = append(tuning.Overridden, "hash_size")
+	}
+	return tuning
+}
+
+// floorPowerOfTwo returns the largest power of two not above n, or 0 when n is below 1
+func floorPowerOfTwo(n int) int {
+	if n < 1 {
+		return 0
+	}
+	power := 1
+	for power*2 <= n {
+		power *= 2
+	}
+	return power
+}
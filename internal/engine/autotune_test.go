@@ -0,0 +1,71 @@
+package engine
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+)
+
+func TestAutoTune(t *testing.T) {
+	tests := []struct {
+		name      string
+		host      HostResources
+		requested models.EngineTuning
+		want      models.EngineTuning
+	}{
+		{
+			name: "single core host",
+			host: HostResources{CPUs: 1, MemoryMB: 512},
+			want: models.EngineTuning{CPUs: 1, MemoryMB: 512, MaxEngines: 1, Threads: 1, HashSize: 256},
+		},
+		{
+			name: "eight cores keep one for the server",
+			host: HostResources{CPUs: 8, MemoryMB: 16000},
+			want: models.EngineTuning{CPUs: 8, MemoryMB: 16000, MaxEngines: 7, Threads: 1, HashSize: 1024},
+		},
+		{
+			name: "large host runs several threads per engine",
+			host: HostResources{CPUs: 32, MemoryMB: 64000},
+			want: models.EngineTuning{CPUs: 32, MemoryMB: 64000, MaxEngines: 7, Threads: 4, HashSize: 2048},
+		},
+		{
+			name:      "configured engines get the remaining cores as threads",
+			host:      HostResources{CPUs: 16, MemoryMB: 4000},
+			requested: models.EngineTuning{MaxEngines: 2},
+			want: models.EngineTuning{CPUs: 16, MemoryMB: 4000, MaxEngines: 2, Threads: 7, HashSize: 512,
+				Overridden: []string{"max_engines"}},
+		},
+		{
+			name:      "configured threads and hash are kept",
+			host:      HostResources{CPUs: 4},
+			requested: models.EngineTuning{Threads: 2, HashSize: 64},
+			want: models.EngineTuning{CPUs: 4, MaxEngines: 1, Threads: 2, HashSize: 64,
+				Overridden: []string{"threads", "hash_size"}},
+		},
+		{
+			name: "little memory gets the smallest hash",
+			host: HostResources{CPUs: 2, MemoryMB: 40},
+			want: models.EngineTuning{CPUs: 2, MemoryMB: 40, MaxEngines: 2, Threads: 1, HashSize: minAutoHashMB},
+		},
+		{
+			name: "unknown resources",
+			host: HostResources{},
+			want: models.EngineTuning{MaxEngines: 1, Threads: 1, HashSize: unknownMemHash},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AutoTune(tt.host, tt.requested); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("AutoTune() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectHostResources(t *testing.T) {
+	if host := DetectHostResources(); host.CPUs < 1 || host.MemoryMB < 0 {
+		t.Errorf("DetectHostResources() = %+v", host)
+	}
+}
@@ -0,0 +1,70 @@
+package engine
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Evaluation scales
+const (
+	// EvalScaleNormalized is the scale of Stockfish 15.1 and later, where +1.00 means a 50% chance
+	// to win. Evaluations are reported on this scale whatever engine produced them.
+	EvalScaleNormalized = "normalized"
+	// EvalScaleLegacy is the scale of older Stockfish versions and engines derived from them, where
+	// +1.00 is the endgame value of a pawn
+	EvalScaleLegacy = "legacy"
+)
+
+// legacyFactor converts legacy centipawns to the normalized scale: legacy scores are internal
+// units divided by the endgame pawn value (208), normalized ones divided by 361
+const legacyFactor = 208.0 / 361.0
+
+// stockfishVersionRegex matches release versions in engine names, e.g. "Stockfish 15.1"
+var stockfishVersionRegex = regexp.MustCompile(`^Stockfish (\d+)(?:\.(\d+))?\b`)
+
+// EvalScale is how an engine's centipawn scores relate to the normalized scale
+type EvalScale struct {
+	Name   string  // EvalScaleNormalized or EvalScaleLegacy
+	Factor float64 // Multiplies the engine's scores to put them on the normalized scale
+}
+
+// EvalScaleFor returns the evaluation scale of an engine from the name it reports. Stockfish
+// development builds are named after their date, as "Stockfish DDMMYY" or "Stockfish dev-YYYYMMDD";
+// the scale changed in July 2022. Fairy-Stockfish branched off before the change. Engines that
+// can't be identified are taken as normalized.
+func EvalScaleFor(engineName string) EvalScale {
+	normalized := EvalScale{Name: EvalScaleNormalized, Factor: 1}
+	legacy := EvalScale{Name: EvalScaleLegacy, Factor: legacyFactor}
+
+	if strings.HasPrefix(engineName, "Fairy-Stockfish") {
+		return legacy
+	}
+
+	matches := stockfishVersionRegex.FindStringSubmatch(engineName)
+	if matches == nil {
+		return normalized
+	}
+	if len(matches[1]) == 6 {
+		// A dated development build: compare year and month
+		date, _ := strconv.Atoi(matches[1][4:6] + matches[1][2:4])
+		if date < 2207 {
+			return legacy
+		}
+		return normalized
+	}
+	major, _ := strconv.Atoi(matches[1])
+	minor, _ := strconv.Atoi(matches[2])
+	if major < 15 || (major == 15 && minor < 1) {
+		return legacy
+	}
+	return normalized
+}
+
+// normalize converts centipawns on the engine's scale to pawns on the normalized scale
+func (s EvalScale) normalize(centipawns float64) float64 {
+	if s.Factor == 0 {
+		return centipawns / 100.0
+	}
+	return centipawns * s.Factor / 100.0
+}
@@ -0,0 +1,57 @@
+package engine
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+)
+
+func TestEvalScaleFor(t *testing.T) {
+	tests := []struct {
+		engine string
+		want   string
+	}{
+		{"Stockfish 14.1", EvalScaleLegacy},
+		{"Stockfish 15", EvalScaleLegacy},
+		{"Stockfish 15.1", EvalScaleNormalized},
+		{"Stockfish 16", EvalScaleNormalized},
+		{"Stockfish 120522", EvalScaleLegacy},     // 12 May 2022
+		{"Stockfish 050822", EvalScaleNormalized}, // 5 August 2022
+		{"Stockfish dev-20240101-a1b2c3d4", EvalScaleNormalized},
+		{"Fairy-Stockfish 14.0.1 LB", EvalScaleLegacy},
+		{"SomeEngine 1.0", EvalScaleNormalized},
+	}
+	for _, tt := range tests {
+		if got := EvalScaleFor(tt.engine); got.Name != tt.want {
+			t.Errorf("EvalScaleFor(%q) = %s, want %s", tt.engine, got.Name, tt.want)
+		}
+	}
+}
+
+func TestStockfishEngine_AnalyzePositionNormalizesLegacyScores(t *testing.T) {
+	output := `info depth 10 multipv 1 score cp 361 nodes 900 pv e2e4
+info depth 10 multipv 2 score mate 2 nodes 900 pv d1h5
+bestmove e2e4
+`
+	engine, _ := newFakeEngine(output, models.EngineSettings{MultiPV: 2})
+	engine.evalScale = EvalScaleFor("Stockfish 14")
+
+	fen := "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+	result, err := engine.AnalyzePosition(context.Background(), fen, models.EngineSettings{Depth: 10, MultiPV: 2})
+	if err != nil {
+		t.Fatalf("AnalyzePosition() error = %v", err)
+	}
+
+	// 361 legacy centipawns are 2.08 pawns on the normalized scale; mate scores aren't scaled
+	if math.Abs(result.Evaluation-2.08) > 1e-9 || math.Abs(result.Lines[0].Evaluation-2.08) > 1e-9 {
+		t.Errorf("Evaluation = %v, want 2.08", result.Evaluation)
+	}
+	if result.LineEvaluations[1] != 998 {
+		t.Errorf("Mate line evaluation = %v, want 998", result.LineEvaluations[1])
+	}
+	if result.EvalScale != EvalScaleLegacy {
+		t.Errorf("EvalScale = %q, want %q", result.EvalScale, EvalScaleLegacy)
+	}
+}
@@ -0,0 +1,156 @@
+package engine
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+)
+
+// probeHashMB is the hash of the engine a lazy pool starts to check the engine, which never searches
+const probeHashMB = 1
+
+// NewLazyEnginePool creates an engine pool that keeps no engine running until one is needed, for
+// deployments that scale to zero. Engines are started by Acquire, up to maxEngines, and an engine
+// left idle in the pool for idleTimeout is shut down (0 keeps started engines running). One engine
+// is started and stopped right away, so a broken engine fails at startup as it would in an eager
+// pool, and so the pool knows its engine version before any request.
+func NewLazyEnginePool(maxEngines int, executablePath string, settings models.EngineSettings, sandbox models.EngineSandbox,
+	idleTimeout time.Duration) (*EnginePool, error) {
+	probeSettings := settings
+	probeSettings.Threads, probeSettings.HashSize = 1, probeHashMB
+	probe, err := NewSandboxedStockfishEngine(executablePath, probeSettings, sandbox)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create engine 0: %w", err)
+	}
+	version, evalScale := probe.GetVersion(), probe.GetEvalScale().Name
+	probe.Close()
+
+	pool := &EnginePool{
+		Engines:        make([]*StockfishEngine, 0, maxEngines),
+		Available:      make(chan *StockfishEngine, maxEngines),
+		Version:        version,
+		EvalScale:      evalScale,
+		maxEngines:     maxEngines,
+		settings:       settings,
+		sandbox:        sandbox,
+		lazy:           true,
+		executablePath: executablePath,
+		idleTimeout:    idleTimeout,
+		stop:           make(chan struct{}),
+	}
+	if idleTimeout > 0 {
+		go pool.reapIdle()
+	}
+	return pool, nil
+}
+
+// Size returns how many engines the pool runs at most
+func (p *EnginePool) Size() int {
+	return p.maxEngines
+}
+
+// Running returns how many engine processes the pool has started
+func (p *EnginePool) Running() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return len(p.Engines)
+}
+
+// Idle returns how many engines a request can take without waiting: those free in the pool and,
+// in a lazy pool, those not started yet
+func (p *EnginePool) Idle() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	idle := len(p.Available)
+	if p.lazy {
+		idle += p.maxEngines - len(p.Engines) - p.starting
+	}
+	return idle
+}
+
+// startEngine starts an engine for a lazy pool with room for one, applying the pool's resource
+// limits. It returns nil without an error when the pool isn't lazy or all its engines are running.
+func (p *EnginePool) startEngine() (*StockfishEngine, error) {
+	p.mu.Lock()
+	if !p.lazy || p.closed || len(p.Engines)+p.starting >= p.maxEngines {
+		p.mu.Unlock()
+		return nil, nil
+	}
+	p.starting++
+	settings, sandbox, limits := p.settings, p.sandbox, p.limits
+	p.mu.Unlock()
+
+	engine, err := NewSandboxedStockfishEngine(p.executablePath, settings, sandbox)
+	if err == nil && !limits.IsZero() && engine.cmd.Process != nil {
+		if err = applyLimits(engine.cmd.Process.Pid, limits); err != nil {
+			engine.Close()
+			err = fmt.Errorf("failed to limit engine: %w", err)
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.starting--
+	if err != nil {
+		return nil, err
+	}
+	if p.closed {
+		engine.Close()
+		return nil, fmt.Errorf("engine pool is closed")
+	}
+
+	p.queueMu.Lock()
+	p.Engines = append(p.Engines, engine)
+	p.queueMu.Unlock()
+	return engine, nil
+}
+
+// reapIdle periodically shuts down the engines of a lazy pool that have been idle for longer than
+// the idle timeout, until the pool is closed
+func (p *EnginePool) reapIdle() {
+	ticker := time.NewTicker(p.idleTimeout / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			p.stopIdle()
+		case <-p.stop:
+			return
+		}
+	}
+}
+
+// stopIdle shuts down the engines waiting in the pool that were returned more than the idle
+// timeout ago. Engines in use are left alone.
+func (p *EnginePool) stopIdle() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return
+	}
+
+	for waiting := len(p.Available); waiting > 0; waiting-- {
+		var engine *StockfishEngine
+		select {
+		case engine = <-p.Available:
+		default:
+			return
+		}
+
+		p.queueMu.Lock()
+		idle := time.Since(engine.returnedAt) >= p.idleTimeout
+		if idle {
+			p.Engines = slices.DeleteFunc(p.Engines, func(e *StockfishEngine) bool { return e == engine })
+		}
+		p.queueMu.Unlock()
+
+		if idle {
+			engine.Close()
+		} else {
+			p.Available <- engine
+		}
+	}
+}
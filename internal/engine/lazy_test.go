@@ -0,0 +1,38 @@
+package engine
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEnginePool_StopIdle(t *testing.T) {
+	stale, recent := &StockfishEngine{}, &StockfishEngine{}
+	pool := &EnginePool{
+		Engines:     []*StockfishEngine{stale, recent},
+		Available:   make(chan *StockfishEngine, 4),
+		maxEngines:  4,
+		lazy:        true,
+		idleTimeout: time.Minute,
+	}
+	stale.returnedAt = time.Now().Add(-2 * time.Minute)
+	recent.returnedAt = time.Now()
+	pool.Available <- stale
+	pool.Available <- recent
+
+	// Engines not started yet can be taken without waiting too
+	if size, idle, running := pool.Size(), pool.Idle(), pool.Running(); size != 4 || idle != 4 || running != 2 {
+		t.Errorf("Size(), Idle(), Running() = %d, %d, %d, want 4, 4, 2", size, idle, running)
+	}
+
+	// Only the engine idle for longer than the timeout is stopped
+	pool.stopIdle()
+	if len(pool.Engines) != 1 || pool.Engines[0] != recent {
+		t.Fatalf("Engines after stopIdle() = %v, want only the recently returned engine", pool.Engines)
+	}
+	if engine := <-pool.Available; engine != recent || len(pool.Available) != 0 {
+		t.Errorf("Available after stopIdle() = %v and %d more, want only the recently returned engine", engine, len(pool.Available))
+	}
+	if idle := pool.Idle(); idle != 3 {
+		t.Errorf("Idle() with the remaining engine in use = %d, want 3", idle)
+	}
+}
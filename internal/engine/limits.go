@@ -0,0 +1,105 @@
+package engine
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+)
+
+// maxCPUs is the highest CPU number accepted in a CPU list
+const maxCPUs = 1024
+
+// ValidateLimits checks that resource limits are within the ranges the kernel accepts
+func ValidateLimits(limits models.EngineLimits) error {
+	if limits.Nice < 0 || limits.Nice > 19 {
+		return fmt.Errorf("nice level %d must be between 0 and 19", limits.Nice)
+	}
+	for _, cpu := range limits.CPUs {
+		if cpu < 0 || cpu >= maxCPUs {
+			return fmt.Errorf("CPU %d must be between 0 and %d", cpu, maxCPUs-1)
+		}
+	}
+	if limits.MaxMemoryMB < 0 {
+		return fmt.Errorf("max memory %d MB can't be negative", limits.MaxMemoryMB)
+	}
+	if limits.CPUQuota < 0 {
+		return fmt.Errorf("CPU quota %d%% can't be negative", limits.CPUQuota)
+	}
+	if limits.CPUQuota > 0 && limits.Cgroup == "" {
+		return fmt.Errorf("a CPU quota needs a cgroup to apply to")
+	}
+	return nil
+}
+
+// SetResourceLimits restricts the CPU and memory every engine of the pool may use, so engines
+// searching at full speed leave room for the rest of the host. Limits only ever get stricter:
+// processes can't regain a lower nice level or more memory without privileges.
+func (p *EnginePool) SetResourceLimits(limits models.EngineLimits) error {
+	if err := ValidateLimits(limits); err != nil {
+		return err
+	}
+	if limits.IsZero() {
+		return nil
+	}
+
+	if limits.Cgroup != "" {
+		if err := prepareCgroup(limits.Cgroup, limits.CPUQuota); err != nil {
+			return fmt.Errorf("failed to prepare cgroup %s: %w", limits.Cgroup, err)
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for i, engine := range p.Engines {
+		if engine.cmd == nil || engine.cmd.Process == nil {
+			continue
+		}
+		if err := applyLimits(engine.cmd.Process.Pid, limits); err != nil {
+			return fmt.Errorf("failed to limit engine %d: %w", i, err)
+		}
+	}
+
+	p.limits = limits
+	return nil
+}
+
+// ResourceLimits returns the limits applied to the pool's engines
+func (p *EnginePool) ResourceLimits() models.EngineLimits {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.limits
+}
+
+// ParseCPUList parses a Linux style CPU list such as "0-3,6" into CPU numbers
+func ParseCPUList(list string) ([]int, error) {
+	var cpus []int
+	for _, part := range strings.Split(list, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		first, last, isRange := strings.Cut(part, "-")
+		start, err := strconv.Atoi(strings.TrimSpace(first))
+		if err != nil {
+			return nil, fmt.Errorf("invalid CPU %q", part)
+		}
+		end := start
+		if isRange {
+			if end, err = strconv.Atoi(strings.TrimSpace(last)); err != nil || end < start {
+				return nil, fmt.Errorf("invalid CPU range %q", part)
+			}
+		}
+		if start < 0 || end >= maxCPUs {
+			return nil, fmt.Errorf("CPU range %q must be between 0 and %d", part, maxCPUs-1)
+		}
+
+		for cpu := start; cpu <= end; cpu++ {
+			cpus = append(cpus, cpu)
+		}
+	}
+	return cpus, nil
+}
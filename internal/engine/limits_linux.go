@@ -0,0 +1,140 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+)
+
+// cpuPeriod is the cgroup CPU accounting period in microseconds
+const cpuPeriod = 100000
+
+// applyLimits applies resource limits to a running engine process. Niceness and affinity are set
+// on every thread of the process; threads the engine starts later inherit them.
+func applyLimits(pid int, limits models.EngineLimits) error {
+	if limits.Cgroup != "" {
+		procs := filepath.Join(limits.Cgroup, "cgroup.procs")
+		if err := os.WriteFile(procs, []byte(strconv.Itoa(pid)), 0o644); err != nil {
+			return fmt.Errorf("failed to move process into cgroup: %w", err)
+		}
+	}
+
+	if limits.MaxMemoryMB > 0 {
+		size := uint64(limits.MaxMemoryMB) << 20
+		limit := syscall.Rlimit{Cur: size, Max: size}
+		if _, _, errno := syscall.RawSyscall6(syscall.SYS_PRLIMIT64, uintptr(pid), syscall.RLIMIT_AS,
+			uintptr(unsafe.Pointer(&limit)), 0, 0, 0); errno != 0 {
+			return fmt.Errorf("failed to limit memory: %w", errno)
+		}
+	}
+
+	if limits.Nice == 0 && len(limits.CPUs) == 0 {
+		return nil
+	}
+	threads, err := os.ReadDir(fmt.Sprintf("/proc/%d/task", pid))
+	if err != nil {
+		return fmt.Errorf("failed to list engine threads: %w", err)
+	}
+	for _, thread := range threads {
+		tid, err := strconv.Atoi(thread.Name())
+		if err != nil {
+			continue
+		}
+		if limits.Nice != 0 {
+			if err := syscall.Setpriority(syscall.PRIO_PROCESS, tid, limits.Nice); err != nil {
+				return fmt.Errorf("failed to set nice level: %w", err)
+			}
+		}
+		if len(limits.CPUs) > 0 {
+			if err := setAffinity(tid, limits.CPUs); err != nil {
+				return fmt.Errorf("failed to set CPU affinity: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// setAffinity pins a thread to the given CPUs
+func setAffinity(tid int, cpus []int) error {
+	var mask [maxCPUs / 64]uint64
+	for _, cpu := range cpus {
+		mask[cpu/64] |= 1 << (cpu % 64)
+	}
+	if _, _, errno := syscall.RawSyscall(syscall.SYS_SCHED_SETAFFINITY, uintptr(tid),
+		unsafe.Sizeof(mask), uintptr(unsafe.Pointer(&mask))); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// prepareCgroup creates a cgroup v2 directory for the engines and caps their combined CPU time.
+// The parent cgroup must be delegated to the server's user.
+func prepareCgroup(dir string, cpuQuota int) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	if cpuQuota == 0 {
+		return nil
+	}
+
+	// The cpu controller may already be enabled; a failure here shows up writing cpu.max
+	subtree := filepath.Join(filepath.Dir(dir), "cgroup.subtree_control")
+	_ = os.WriteFile(subtree, []byte("+cpu"), 0o644)
+
+	quota := fmt.Sprintf("%d %d", cpuQuota*cpuPeriod/100, cpuPeriod)
+	if err := os.WriteFile(filepath.Join(dir, "cpu.max"), []byte(quota), 0o644); err != nil {
+		return fmt.Errorf("failed to set CPU quota: %w", err)
+	}
+	return nil
+}
+
+// cgroupRoot is where the server's cgroup v2 controllers are mounted
+const cgroupRoot = "/sys/fs/cgroup"
+
+// cgroupCPULimit returns how many CPUs the server's cgroup CPU quota allows, or 0 when unlimited
+func cgroupCPULimit() int {
+	data, err := os.ReadFile(filepath.Join(cgroupRoot, "cpu.max"))
+	if err != nil {
+		return 0
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) != 2 || fields[0] == "max" {
+		return 0
+	}
+	quota, err1 := strconv.Atoi(fields[0])
+	period, err2 := strconv.Atoi(fields[1])
+	if err1 != nil || err2 != nil || period <= 0 {
+		return 0
+	}
+	return max(quota/period, 1)
+}
+
+// hostMemoryMB returns the memory available to the server: the available memory of the host,
+// capped by the cgroup memory limit. It returns 0 when neither can be read.
+func hostMemoryMB() int {
+	memory := 0
+	if data, err := os.ReadFile("/proc/meminfo"); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) >= 2 && fields[0] == "MemAvailable:" {
+				if kb, err := strconv.Atoi(fields[1]); err == nil {
+					memory = kb >> 10
+				}
+			}
+		}
+	}
+	if data, err := os.ReadFile(filepath.Join(cgroupRoot, "memory.max")); err == nil {
+		if limit, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64); err == nil {
+			if limitMB := int(limit >> 20); memory == 0 || limitMB < memory {
+				memory = limitMB
+			}
+		}
+	}
+	return memory
+}
@@ -0,0 +1,29 @@
+//go:build !linux
+
+package engine
+
+import (
+	"fmt"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+)
+
+// applyLimits is only implemented on Linux
+func applyLimits(pid int, limits models.EngineLimits) error {
+	return fmt.Errorf("engine resource limits are only supported on Linux")
+}
+
+// prepareCgroup is only implemented on Linux
+func prepareCgroup(dir string, cpuQuota int) error {
+	return fmt.Errorf("cgroups are only supported on Linux")
+}
+
+// cgroupCPULimit is only implemented on Linux; other hosts report no CPU quota
+func cgroupCPULimit() int {
+	return 0
+}
+
+// hostMemoryMB is only implemented on Linux; other hosts report unknown memory
+func hostMemoryMB() int {
+	return 0
+}
@@ -0,0 +1,100 @@
+package engine
+
+import (
+	"os"
+	"os/exec"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+)
+
+func TestParseCPUList(t *testing.T) {
+	tests := []struct {
+		list    string
+		want    []int
+		wantErr bool
+	}{
+		{"", nil, false},
+		{"2", []int{2}, false},
+		{"0-2, 6", []int{0, 1, 2, 6}, false},
+		{"3-1", nil, true},
+		{"a", nil, true},
+		{"0-5000", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseCPUList(tt.list)
+		if (err != nil) != tt.wantErr || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseCPUList(%q) = %v, %v; want %v, error %v", tt.list, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateLimits(t *testing.T) {
+	invalid := []models.EngineLimits{
+		{Nice: 20},
+		{Nice: -1},
+		{CPUs: []int{maxCPUs}},
+		{MaxMemoryMB: -1},
+		{CPUQuota: 200}, // No cgroup to apply it to
+	}
+	for _, limits := range invalid {
+		if err := ValidateLimits(limits); err == nil {
+			t.Errorf("ValidateLimits(%+v) accepted invalid limits", limits)
+		}
+	}
+
+	if err := ValidateLimits(models.EngineLimits{Nice: 10, CPUs: []int{0}, MaxMemoryMB: 512, Cgroup: "/sys/fs/cgroup/engines", CPUQuota: 200}); err != nil {
+		t.Errorf("ValidateLimits() rejected valid limits: %v", err)
+	}
+}
+
+func TestEnginePool_SetResourceLimits(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("resource limits are only supported on Linux")
+	}
+
+	cmd := exec.Command("sleep", "30")
+	if err := cmd.Start(); err != nil {
+		t.Skipf("can't start a process to limit: %v", err)
+	}
+	defer cmd.Process.Kill()
+
+	pool := &EnginePool{Engines: []*StockfishEngine{{cmd: cmd}}}
+	limits := models.EngineLimits{Nice: 7, CPUs: []int{0}, MaxMemoryMB: 256}
+	if err := pool.SetResourceLimits(limits); err != nil {
+		t.Fatalf("SetResourceLimits() error = %v", err)
+	}
+	if got := pool.ResourceLimits(); !reflect.DeepEqual(got, limits) {
+		t.Errorf("ResourceLimits() = %+v, want %+v", got, limits)
+	}
+
+	// Field 19 of /proc/<pid>/stat is the niceness
+	stat, err := os.ReadFile("/proc/" + strconv.Itoa(cmd.Process.Pid) + "/stat")
+	if err != nil {
+		t.Fatalf("failed to read process stat: %v", err)
+	}
+	fields := strings.Fields(string(stat)[strings.LastIndex(string(stat), ")")+2:])
+	if fields[16] != "7" {
+		t.Errorf("Engine niceness = %s, want 7", fields[16])
+	}
+
+	status, err := os.ReadFile("/proc/" + strconv.Itoa(cmd.Process.Pid) + "/status")
+	if err != nil {
+		t.Fatalf("failed to read process status: %v", err)
+	}
+	if !strings.Contains(string(status), "Cpus_allowed_list:\t0\n") {
+		t.Errorf("Expected the engine to be pinned to CPU 0, got:\n%s", status)
+	}
+
+	procLimits, err := os.ReadFile("/proc/" + strconv.Itoa(cmd.Process.Pid) + "/limits")
+	if err != nil {
+		t.Fatalf("failed to read process limits: %v", err)
+	}
+	if !strings.Contains(string(procLimits), strconv.Itoa(256<<20)) {
+		t.Errorf("Expected a 256 MB address space limit, got:\n%s", procLimits)
+	}
+}
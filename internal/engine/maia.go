@@ -0,0 +1,311 @@
+package engine
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Maia networks are published for ratings 1100 to 1900 in steps of 100
+const (
+	maiaMinRating  = 1100
+	maiaMaxRating  = 1900
+	maiaRatingStep = 100
+)
+
+// policyLineRegex matches Lc0 verbose move stats, e.g. "info string e2e4  (322 ) N: ... (P: 34.56%) ..."
+var policyLineRegex = regexp.MustCompile(`^info string ([a-h][1-8][a-h][1-8][qrbn]?)\s.*\(P:\s*([\d.]+)%\)`)
+
+// MaiaEngine represents an Lc0 process running Maia weights, used to predict human moves
+type MaiaEngine struct {
+	cmd        *exec.Cmd
+	stdin      io.WriteCloser
+	stdout     io.ReadCloser
+	scanner    *bufio.Scanner
+	mu         sync.Mutex
+	weightsDir string
+	weights    string
+}
+
+// NewMaiaEngine starts an Lc0 process that loads Maia weights from weightsDir, under the sandbox's restrictions
+func NewMaiaEngine(executablePath, weightsDir string, sandbox models.EngineSandbox) (*MaiaEngine, error) {
+	if _, err := os.Stat(weightsDir); err != nil {
+		return nil, fmt.Errorf("maia weights directory is not accessible: %w", err)
+	}
+
+	cmd := exec.Command(executablePath)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stdin pipe: %w", err)
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stdout pipe: %w", err)
+	}
+
+	if err := startEngine(cmd, sandbox); err != nil {
+		return nil, fmt.Errorf("failed to start Lc0: %w", err)
+	}
+
+	engine := &MaiaEngine{
+		cmd:        cmd,
+		stdin:      stdin,
+		stdout:     stdout,
+		scanner:    bufio.NewScanner(stdout),
+		weightsDir: weightsDir,
+	}
+
+	if err := engine.initialize(); err != nil {
+		engine.Close()
+		return nil, fmt.Errorf("failed to initialize Maia engine: %w", err)
+	}
+
+	return engine, nil
+}
+
+// initialize performs the UCI handshake and enables per-move policy output
+func (m *MaiaEngine) initialize() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.sendCommand("uci"); err != nil {
+		return err
+	}
+	if err := m.waitFor("uciok"); err != nil {
+		return err
+	}
+
+	if err := m.sendCommand("setoption name VerboseMoveStats value true"); err != nil {
+		return err
+	}
+
+	return m.loadWeights(maiaMinRating)
+}
+
+// loadWeights switches to the Maia network closest to the given rating.
+// The caller must hold the engine lock.
+func (m *MaiaEngine) loadWeights(rating int) error {
+	weights := filepath.Join(m.weightsDir, fmt.Sprintf("maia-%d.pb.gz", MaiaRating(rating)))
+	if weights == m.weights {
+		return nil
+	}
+
+	if _, err := os.Stat(weights); err != nil {
+		return fmt.Errorf("maia weights not found: %w", err)
+	}
+
+	if err := m.sendCommand(fmt.Sprintf("setoption name WeightsFile value %s", weights)); err != nil {
+		return err
+	}
+	if err := m.sendCommand("isready"); err != nil {
+		return err
+	}
+	if err := m.waitFor("readyok"); err != nil {
+		return err
+	}
+
+	m.weights = weights
+	return nil
+}
+
+// MovePolicy returns the probability (0-1) of each legal move being played by a human of the given rating
+func (m *MaiaEngine) MovePolicy(ctx context.Context, fen string, rating int) (map[string]float64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.loadWeights(rating); err != nil {
+		return nil, err
+	}
+
+	ctx, span := startSearchSpan(ctx, "maia.policy", fen, "go nodes 1")
+	span.SetAttributes(attribute.Int("maia.rating", MaiaRating(rating)))
+	started := time.Now()
+	policy, err := m.policy(ctx, fen)
+	span.SetAttributes(attribute.Int("maia.moves", len(policy)))
+	endSearchSpan(span, started, nil, err)
+	return policy, err
+}
+
+// policy runs a single-node search and collects the policy of every legal move
+func (m *MaiaEngine) policy(ctx context.Context, fen string) (map[string]float64, error) {
+	if err := m.sendCommand(fmt.Sprintf("position fen %s", fen)); err != nil {
+		return nil, err
+	}
+
+	// Maia is meant to be used without search: a single node exposes the raw policy
+	if err := m.sendCommand("go nodes 1"); err != nil {
+		return nil, err
+	}
+
+	policy := make(map[string]float64)
+	timeout := time.After(10 * time.Second)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timeout:
+			return nil, errors.NewTimeoutError("maia policy", nil)
+		default:
+			if !m.scanner.Scan() {
+				return nil, fmt.Errorf("scanner error during maia evaluation")
+			}
+
+			line := strings.TrimSpace(m.scanner.Text())
+			if _, ok := parseBestMove(line); ok {
+				return policy, nil
+			}
+
+			if matches := policyLineRegex.FindStringSubmatch(line); matches != nil {
+				if p, err := strconv.ParseFloat(matches[2], 64); err == nil {
+					policy[matches[1]] = p / 100
+				}
+			}
+		}
+	}
+}
+
+// MaiaRating rounds a rating to the nearest available Maia network
+func MaiaRating(rating int) int {
+	rounded := int(math.Round(float64(rating)/maiaRatingStep)) * maiaRatingStep
+	if rounded < maiaMinRating {
+		return maiaMinRating
+	}
+	if rounded > maiaMaxRating {
+		return maiaMaxRating
+	}
+	return rounded
+}
+
+// sendCommand sends a command to the engine
+func (m *MaiaEngine) sendCommand(command string) error {
+	_, err := fmt.Fprintf(m.stdin, "%s\n", command)
+	return err
+}
+
+// waitFor waits for a specific response from the engine
+func (m *MaiaEngine) waitFor(expected string) error {
+	timeout := time.After(30 * time.Second)
+
+	for {
+		select {
+		case <-timeout:
+			return errors.NewTimeoutError("waiting for "+expected, nil)
+		default:
+			if !m.scanner.Scan() {
+				return fmt.Errorf("scanner error while waiting for: %s", expected)
+			}
+			if strings.Contains(m.scanner.Text(), expected) {
+				return nil
+			}
+		}
+	}
+}
+
+// Close shuts down the engine
+func (m *MaiaEngine) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.stdin != nil {
+		m.stdin.Close()
+	}
+	if m.stdout != nil {
+		m.stdout.Close()
+	}
+
+	if m.cmd != nil && m.cmd.Process != nil {
+		return m.cmd.Process.Kill()
+	}
+
+	return nil
+}
+
+// MaiaPool manages a pool of Maia engines; each analysis holds one engine while it runs
+type MaiaPool struct {
+	Engines   []*MaiaEngine
+	Available chan *MaiaEngine
+}
+
+// NewMaiaPool starts size Maia engines that load their weights from weightsDir
+func NewMaiaPool(size int, executablePath, weightsDir string, sandbox models.EngineSandbox) (*MaiaPool, error) {
+	if size <= 0 {
+		size = 1
+	}
+
+	pool := &MaiaPool{
+		Engines:   make([]*MaiaEngine, 0, size),
+		Available: make(chan *MaiaEngine, size),
+	}
+
+	for i := 0; i < size; i++ {
+		engine, err := NewMaiaEngine(executablePath, weightsDir, sandbox)
+		if err != nil {
+			// Clean up any created engines
+			pool.Close()
+			return nil, fmt.Errorf("failed to create Maia engine %d: %w", i, err)
+		}
+		pool.Engines = append(pool.Engines, engine)
+		pool.Available <- engine
+	}
+
+	return pool, nil
+}
+
+// Acquire takes an engine from the pool, waiting until one is free or ctx is done
+func (p *MaiaPool) Acquire(ctx context.Context) (*MaiaEngine, error) {
+	select {
+	case engine := <-p.Available:
+		return engine, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// ReturnEngine returns an engine to the pool
+func (p *MaiaPool) ReturnEngine(engine *MaiaEngine) {
+	p.Available <- engine
+}
+
+// Size returns how many engines the pool runs
+func (p *MaiaPool) Size() int {
+	return len(p.Engines)
+}
+
+// Idle returns how many engines are free to be acquired
+func (p *MaiaPool) Idle() int {
+	return len(p.Available)
+}
+
+// Close shuts down all engines in the pool
+func (p *MaiaPool) Close() error {
+	var errs []error
+	for _, engine := range p.Engines {
+		if err := engine.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("errors closing Maia engines: %v", errs)
+	}
+
+	return nil
+}
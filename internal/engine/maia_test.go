@@ -0,0 +1,93 @@
+package engine
+
+import (
+	"bufio"
+	"context"
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMaiaRating(t *testing.T) {
+	tests := []struct {
+		rating int
+		want   int
+	}{
+		{800, 1100},
+		{1100, 1100},
+		{1449, 1400},
+		{1450, 1500},
+		{1900, 1900},
+		{2400, 1900},
+	}
+
+	for _, tt := range tests {
+		if got := MaiaRating(tt.rating); got != tt.want {
+			t.Errorf("MaiaRating(%d) = %d, want %d", tt.rating, got, tt.want)
+		}
+	}
+}
+
+func TestMaiaEngine_MovePolicy(t *testing.T) {
+	output := strings.Join([]string{
+		"info string g1f3  (1351) N:       0 (+ 0) (P: 12.40%) (WL:  -.-----) (D: -.---) (M:  -.-) (Q: -0.03) (V:  -.----)",
+		"info string e2e4  (322 ) N:       1 (+ 0) (P: 48.25%) (WL:  -.-----) (D: -.---) (M:  -.-) (Q: -0.03) (V:  -.----)",
+		"info string e7e8q (100 ) N:       0 (+ 0) (P:  0.50%) (WL:  -.-----) (D: -.---) (M:  -.-) (Q: -0.03) (V:  -.----)",
+		"info string node  ( 20) N:       1 (+ 0) (P: 100.0%) (WL:  -.-----) (D: -.---) (M:  -.-) (Q: -0.03) (V:  -.----)",
+		"bestmove e2e4",
+	}, "\n") + "\n"
+
+	stdin := &nopWriteCloser{}
+	maia := &MaiaEngine{
+		stdin:      stdin,
+		scanner:    bufio.NewScanner(strings.NewReader(output)),
+		weightsDir: "maia",
+		weights:    filepath.Join("maia", "maia-1500.pb.gz"),
+	}
+
+	policy, err := maia.MovePolicy(context.Background(), "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", 1530)
+	if err != nil {
+		t.Fatalf("MovePolicy() error = %v", err)
+	}
+
+	want := map[string]float64{"g1f3": 0.124, "e2e4": 0.4825, "e7e8q": 0.005}
+	if len(policy) != len(want) {
+		t.Fatalf("MovePolicy() = %v, want %v", policy, want)
+	}
+	for move, p := range want {
+		if math.Abs(policy[move]-p) > 1e-9 {
+			t.Errorf("policy[%s] = %v, want %v", move, policy[move], p)
+		}
+	}
+
+	if sent := stdin.String(); strings.Contains(sent, "WeightsFile") || !strings.Contains(sent, "go nodes 1") {
+		t.Errorf("Unexpected commands sent: %q", sent)
+	}
+}
+
+func TestMaiaPool_Acquire(t *testing.T) {
+	maia := &MaiaEngine{}
+	pool := &MaiaPool{Engines: []*MaiaEngine{maia}, Available: make(chan *MaiaEngine, 1)}
+	pool.Available <- maia
+
+	got, err := pool.Acquire(context.Background())
+	if err != nil || got != maia {
+		t.Fatalf("Acquire() = %v, %v, want the pool's engine", got, err)
+	}
+	if pool.Size() != 1 || pool.Idle() != 0 {
+		t.Errorf("Size() = %d, Idle() = %d after Acquire, want 1 and 0", pool.Size(), pool.Idle())
+	}
+
+	// With every engine taken, Acquire waits until the context is done
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := pool.Acquire(ctx); err != context.Canceled {
+		t.Errorf("Acquire() on a busy pool error = %v, want %v", err, context.Canceled)
+	}
+
+	pool.ReturnEngine(got)
+	if pool.Idle() != 1 {
+		t.Errorf("Idle() = %d after ReturnEngine, want 1", pool.Idle())
+	}
+}
@@ -0,0 +1,120 @@
+package engine
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+)
+
+// networkDownloadURL is where official Stockfish NNUE networks are published
+const networkDownloadURL = "https://tests.stockfishchess.org/api/nn/"
+
+// ValidateEvalFile checks that an NNUE evaluation file exists and looks like a network
+func ValidateEvalFile(path string) error {
+	if !strings.HasSuffix(path, ".nnue") {
+		return fmt.Errorf("eval file %s must have a .nnue extension", path)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("eval file %s is not accessible: %w", path, err)
+	}
+	if !info.Mode().IsRegular() || info.Size() == 0 {
+		return fmt.Errorf("eval file %s is not a valid network file", path)
+	}
+
+	return nil
+}
+
+// PrepareEvalFile validates the configured NNUE file. If the file is missing and download is
+// enabled, the default network of the configured Stockfish binary is downloaded to that path. The
+// binary is run under the sandbox to read the network's name.
+func PrepareEvalFile(executablePath, evalFile string, download bool, sandbox models.EngineSandbox) error {
+	if evalFile == "" {
+		return nil
+	}
+
+	err := ValidateEvalFile(evalFile)
+	if err == nil || !download {
+		return err
+	}
+	if _, statErr := os.Stat(evalFile); !os.IsNotExist(statErr) {
+		return err
+	}
+
+	name, err := DefaultNetworkName(executablePath, sandbox)
+	if err != nil {
+		return fmt.Errorf("failed to determine default network: %w", err)
+	}
+
+	if err := downloadNetwork(name, evalFile); err != nil {
+		return fmt.Errorf("failed to download network %s: %w", name, err)
+	}
+
+	return ValidateEvalFile(evalFile)
+}
+
+// DefaultNetworkName starts the engine briefly to read the name of its built-in default network
+func DefaultNetworkName(executablePath string, sandbox models.EngineSandbox) (string, error) {
+	engine, err := NewSandboxedStockfishEngine(executablePath, defaultProbeSettings(), sandbox)
+	if err != nil {
+		return "", err
+	}
+	defer engine.Close()
+
+	name := engine.GetNetworkName()
+	if name == "" || name == "<empty>" {
+		return "", fmt.Errorf("engine does not report a default EvalFile")
+	}
+	return name, nil
+}
+
+// defaultProbeSettings returns minimal settings for short-lived engine probes
+func defaultProbeSettings() models.EngineSettings {
+	return models.EngineSettings{
+		Threads:    1,
+		HashSize:   16,
+		SkillLevel: 20,
+	}
+}
+
+// downloadNetwork downloads a published network to the given path
+func downloadNetwork(name, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	client := &http.Client{Timeout: 5 * time.Minute}
+	resp, err := client.Get(networkDownloadURL + name)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("download failed with status: %d", resp.StatusCode)
+	}
+
+	// Write to a temporary file first so a failed download never leaves a truncated network
+	tmp, err := os.CreateTemp(filepath.Dir(path), name+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, resp.Body); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,74 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+)
+
+func TestValidateEvalFile(t *testing.T) {
+	dir := t.TempDir()
+
+	valid := filepath.Join(dir, "nn-test.nnue")
+	if err := os.WriteFile(valid, []byte("network"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	empty := filepath.Join(dir, "nn-empty.nnue")
+	if err := os.WriteFile(empty, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		wantErr bool
+	}{
+		{"Valid network", valid, false},
+		{"Empty network", empty, true},
+		{"Missing network", filepath.Join(dir, "nn-missing.nnue"), true},
+		{"Wrong extension", filepath.Join(dir, "network.bin"), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateEvalFile(tt.path); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateEvalFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// No configured file means the engine default is used
+	if err := PrepareEvalFile("stockfish", "", true, models.EngineSandbox{}); err != nil {
+		t.Errorf("PrepareEvalFile() with no file error = %v", err)
+	}
+}
+
+func TestStockfishEngine_ReadUCIHeader(t *testing.T) {
+	output := `Stockfish 16 by the Stockfish developers (see AUTHORS file)
+id name Stockfish 16
+id author the Stockfish developers (see AUTHORS file)
+
+option name Threads type spin default 1 min 1 max 1024
+option name EvalFile type string default nn-5af11540bbfe.nnue
+uciok
+`
+	engine, _ := newFakeEngine(output, models.EngineSettings{})
+
+	if err := engine.readUCIHeader(); err != nil {
+		t.Fatalf("readUCIHeader() error = %v", err)
+	}
+	if got := engine.GetVersion(); got != "Stockfish 16" {
+		t.Errorf("GetVersion() = %v, want Stockfish 16", got)
+	}
+	if got := engine.GetNetworkName(); got != "nn-5af11540bbfe.nnue" {
+		t.Errorf("GetNetworkName() = %v, want nn-5af11540bbfe.nnue", got)
+	}
+
+	// A configured network takes precedence over the default
+	engine.settings.EvalFile = "/networks/nn-custom.nnue"
+	if got := engine.GetNetworkName(); got != "nn-custom.nnue" {
+		t.Errorf("GetNetworkName() = %v, want nn-custom.nnue", got)
+	}
+}
@@ -0,0 +1,135 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+// holdWeight is how much each finished use of an engine moves the average hold time
+const holdWeight = 0.2
+
+// QueueInfo is where a request waits for an engine and roughly how long it will wait
+type QueueInfo struct {
+	Position      int           // 1 for the next request to get an engine
+	EstimatedWait time.Duration // 0 until the pool has timed a few searches
+}
+
+// queueObserverKey is the context key of a request's queue observer
+type queueObserverKey struct{}
+
+// WithQueue marks a context as belonging to a client request. Such requests wait for an engine
+// in the pool's bounded queue and are rejected when it is full; observe is called with the
+// request's place in the queue when it has to wait. Work without the mark, such as background
+// syncs, waits as long as it takes.
+func WithQueue(ctx context.Context, observe func(QueueInfo)) context.Context {
+	return context.WithValue(ctx, queueObserverKey{}, observe)
+}
+
+// SetMaxQueue bounds how many client requests wait for an engine; 0 means no bound
+func (p *EnginePool) SetMaxQueue(maxQueue int) {
+	p.queueMu.Lock()
+	defer p.queueMu.Unlock()
+	p.maxQueue = maxQueue
+}
+
+// Acquire takes an engine from the pool, waiting until one is free or ctx is done. Client
+// requests (see WithQueue) are rejected with a RateLimitedError when the queue is full.
+func (p *EnginePool) Acquire(ctx context.Context) (*StockfishEngine, error) {
+	select {
+	case engine := <-p.Available:
+		p.acquired(engine)
+		return engine, nil
+	default:
+	}
+
+	// A lazy pool with room for another engine starts one rather than queueing the request
+	if engine, err := p.startEngine(); engine != nil || err != nil {
+		if err != nil {
+			return nil, err
+		}
+		p.acquired(engine)
+		return engine, nil
+	}
+
+	observe, bounded := ctx.Value(queueObserverKey{}).(func(QueueInfo))
+
+	p.queueMu.Lock()
+	if bounded && p.maxQueue > 0 && p.waiting >= p.maxQueue {
+		retryAfter := p.estimateWait(p.waiting + 1)
+		p.queueMu.Unlock()
+		return nil, errors.NewRateLimitedError(retryAfter, fmt.Errorf("analysis queue is full (%d requests waiting)", p.maxQueue))
+	}
+	p.waiting++
+	info := QueueInfo{Position: p.waiting, EstimatedWait: p.estimateWait(p.waiting)}
+	p.queueMu.Unlock()
+
+	defer func() {
+		p.queueMu.Lock()
+		p.waiting--
+		p.queueMu.Unlock()
+	}()
+
+	if bounded && observe != nil {
+		observe(info)
+	}
+
+	select {
+	case engine := <-p.Available:
+		p.acquired(engine)
+		return engine, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// ReturnEngine returns an engine to the pool
+func (p *EnginePool) ReturnEngine(engine *StockfishEngine) {
+	p.queueMu.Lock()
+	if !engine.acquiredAt.IsZero() {
+		held := time.Since(engine.acquiredAt)
+		if p.avgHold == 0 {
+			p.avgHold = held
+		} else {
+			p.avgHold = time.Duration(float64(p.avgHold)*(1-holdWeight) + float64(held)*holdWeight)
+		}
+		engine.acquiredAt = time.Time{}
+	}
+	engine.returnedAt = time.Now()
+	p.queueMu.Unlock()
+
+	p.Available <- engine
+}
+
+// QueueStatus reports how many client requests wait for an engine, the bound, and how long a
+// new request would wait
+func (p *EnginePool) QueueStatus() (waiting, maxQueue int, estimatedWait time.Duration) {
+	p.queueMu.Lock()
+	defer p.queueMu.Unlock()
+	estimatedWait = 0
+	if len(p.Available) == 0 {
+		estimatedWait = p.estimateWait(p.waiting + 1)
+	}
+	return p.waiting, p.maxQueue, estimatedWait
+}
+
+// acquired records when an engine was taken, to time how long engines are held
+func (p *EnginePool) acquired(engine *StockfishEngine) {
+	p.queueMu.Lock()
+	engine.acquiredAt = time.Now()
+	p.queueMu.Unlock()
+}
+
+// estimateWait estimates how long the request at a queue position waits: every engine frees up
+// once per average hold, so each full round of engines ahead of it costs one average hold.
+// The caller holds queueMu.
+func (p *EnginePool) estimateWait(position int) time.Duration {
+	engines := len(p.Engines)
+	if engines == 0 || p.avgHold == 0 {
+		return 0
+	}
+	rounds := (position + engines - 1) / engines
+	return time.Duration(rounds) * p.avgHold
+}
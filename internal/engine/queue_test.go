@@ -0,0 +1,56 @@
+package engine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+func TestEnginePool_Acquire(t *testing.T) {
+	busy := &StockfishEngine{}
+	pool := &EnginePool{Engines: []*StockfishEngine{busy}, Available: make(chan *StockfishEngine, 1)}
+	pool.SetMaxQueue(1)
+	pool.avgHold = 2 * time.Second
+
+	// The only engine is taken straight away
+	pool.Available <- busy
+	engine, err := pool.Acquire(context.Background())
+	if err != nil || engine != busy {
+		t.Fatalf("Acquire() = %v, %v, want the idle engine", engine, err)
+	}
+
+	// The next client request waits first in the queue
+	positions := make(chan QueueInfo, 1)
+	acquired := make(chan error, 1)
+	go func() {
+		ctx := WithQueue(context.Background(), func(info QueueInfo) { positions <- info })
+		_, err := pool.Acquire(ctx)
+		acquired <- err
+	}()
+	info := <-positions
+	if info.Position != 1 || info.EstimatedWait != 2*time.Second {
+		t.Errorf("Queue info = %+v, want position 1 waiting 2s", info)
+	}
+
+	// A client request beyond the bound is turned away; background work still waits
+	_, err = pool.Acquire(WithQueue(context.Background(), nil))
+	var rateLimited *errors.RateLimitedError
+	if !errors.As(err, &rateLimited) || rateLimited.RetryAfter != 4*time.Second {
+		t.Errorf("Acquire() on a full queue error = %v, want a rate limit retrying after 4s", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := pool.Acquire(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Background Acquire() error = %v, want it to wait until its deadline", err)
+	}
+
+	pool.ReturnEngine(engine)
+	if err := <-acquired; err != nil {
+		t.Errorf("Queued Acquire() error = %v", err)
+	}
+	if waiting, maxQueue, _ := pool.QueueStatus(); waiting != 0 || maxQueue != 1 {
+		t.Errorf("QueueStatus() = %d waiting of %d, want 0 of 1", waiting, maxQueue)
+	}
+}
@@ -0,0 +1,58 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+)
+
+// ValidateSandbox checks that a sandbox's user and read paths exist. Whether the host supports the
+// restrictions shows when the first engine starts.
+func ValidateSandbox(sandbox models.EngineSandbox) error {
+	if sandbox.User != "" {
+		if _, err := user.Lookup(sandbox.User); err != nil {
+			return fmt.Errorf("unknown sandbox user %s: %w", sandbox.User, err)
+		}
+	}
+	for _, path := range sandbox.ReadPaths {
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("sandbox read path is not accessible: %w", err)
+		}
+	}
+	return nil
+}
+
+// Sandbox returns the restrictions the pool's engines were started with
+func (p *EnginePool) Sandbox() models.EngineSandbox {
+	return p.sandbox
+}
+
+// startEngine starts an engine process under the sandbox's restrictions
+func startEngine(cmd *exec.Cmd, sandbox models.EngineSandbox) error {
+	if sandbox.IsZero() {
+		return cmd.Start()
+	}
+	return startSandboxed(cmd, sandbox)
+}
+
+// readablePaths returns the paths a read-only engine may read: the directory of its executable,
+// the configured read paths, and the system paths a dynamically linked engine needs to load and
+// detect the CPUs. Paths that don't exist are left out.
+func readablePaths(executable string, sandbox models.EngineSandbox) []string {
+	paths := []string{filepath.Dir(executable)}
+	paths = append(paths, sandbox.ReadPaths...)
+	paths = append(paths, "/lib", "/lib32", "/lib64", "/usr/lib", "/usr/lib32", "/usr/lib64",
+		"/etc/ld.so.cache", "/proc/cpuinfo", "/sys/devices/system/cpu", "/sys/devices/system/node")
+
+	var readable []string
+	for _, path := range paths {
+		if resolved, err := filepath.EvalSymlinks(path); err == nil {
+			readable = append(readable, resolved)
+		}
+	}
+	return readable
+}
@@ -0,0 +1,251 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+)
+
+// Kernel interfaces the sandbox uses that the syscall package doesn't define
+const (
+	prSetNoNewPrivs = 38
+	prSetSeccomp    = 22
+	oPath           = 0x200000
+
+	sysLandlockCreateRuleset = 444
+	sysLandlockAddRule       = 445
+	sysLandlockRestrictSelf  = 446
+	landlockRulesetVersion   = 1 << 0
+	landlockRulePathBeneath  = 1
+
+	seccompModeFilter = 2
+	seccompRetAllow   = 0x7fff0000
+	seccompRetErrno   = 0x00050000
+	seccompRetKill    = 0x80000000 // Kills the whole process
+	seccompMaxSyscall = 0x40000000 // Numbers from here on are the x32 ABI's on amd64, and unused elsewhere
+)
+
+// Landlock filesystem access rights
+const (
+	landlockExecute    = 1 << 0
+	landlockWriteFile  = 1 << 1
+	landlockReadFile   = 1 << 2
+	landlockReadDir    = 1 << 3
+	landlockAccessABI1 = 1<<13 - 1 // Everything up to MAKE_SYM
+	landlockRefer      = 1 << 13   // ABI 2
+	landlockTruncate   = 1 << 14   // ABI 3
+)
+
+// startSandboxed starts an engine process with the sandbox's restrictions. The user and network
+// namespace are set up while the process is created. Landlock, seccomp and AppArmor confine the
+// thread that applies them and the processes it starts, so they are applied on a thread of its
+// own that starts the engine and then exits, leaving the server unconfined.
+func startSandboxed(cmd *exec.Cmd, sandbox models.EngineSandbox) error {
+	attr := &syscall.SysProcAttr{}
+	if sandbox.User != "" {
+		credential, err := lookupCredential(sandbox.User)
+		if err != nil {
+			return err
+		}
+		attr.Credential = credential
+	}
+	if sandbox.NoNetwork {
+		attr.Cloneflags |= syscall.CLONE_NEWNET
+		if os.Geteuid() != 0 {
+			// Without privileges, a network namespace can only be created inside a user namespace
+			attr.Cloneflags |= syscall.CLONE_NEWUSER
+			attr.UidMappings = []syscall.SysProcIDMap{{ContainerID: os.Geteuid(), HostID: os.Geteuid(), Size: 1}}
+			attr.GidMappings = []syscall.SysProcIDMap{{ContainerID: os.Getegid(), HostID: os.Getegid(), Size: 1}}
+		}
+	}
+	cmd.SysProcAttr = attr
+
+	if !sandbox.ReadOnly && !sandbox.Seccomp && sandbox.AppArmor == "" {
+		return cmd.Start()
+	}
+
+	started := make(chan error, 1)
+	go func() {
+		// Never unlocked, so the confined thread exits with the goroutine instead of running other goroutines
+		runtime.LockOSThread()
+		if err := confineThread(cmd.Path, sandbox); err != nil {
+			started <- err
+			return
+		}
+		started <- cmd.Start()
+	}()
+	return <-started
+}
+
+// lookupCredential returns the IDs of the account engines run as, without supplementary groups
+func lookupCredential(name string) (*syscall.Credential, error) {
+	account, err := user.Lookup(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown sandbox user %s: %w", name, err)
+	}
+	uid, err1 := strconv.ParseUint(account.Uid, 10, 32)
+	gid, err2 := strconv.ParseUint(account.Gid, 10, 32)
+	if err1 != nil || err2 != nil {
+		return nil, fmt.Errorf("sandbox user %s has no numeric IDs", name)
+	}
+	return &syscall.Credential{Uid: uint32(uid), Gid: uint32(gid)}, nil
+}
+
+// confineThread applies the sandbox's AppArmor profile, Landlock ruleset and seccomp filter to the
+// calling thread, which must be locked to its goroutine
+func confineThread(executable string, sandbox models.EngineSandbox) error {
+	if sandbox.AppArmor != "" {
+		if err := setAppArmorExec(sandbox.AppArmor); err != nil {
+			return fmt.Errorf("failed to apply AppArmor profile %s: %w", sandbox.AppArmor, err)
+		}
+	}
+	if !sandbox.ReadOnly && !sandbox.Seccomp {
+		return nil
+	}
+
+	// Lets processes without privileges restrict themselves, and keeps the engine from regaining privileges
+	if _, _, errno := syscall.RawSyscall(syscall.SYS_PRCTL, prSetNoNewPrivs, 1, 0); errno != 0 {
+		return fmt.Errorf("failed to set no_new_privs: %w", errno)
+	}
+	if sandbox.ReadOnly {
+		if err := restrictFilesystem(readablePaths(executable, sandbox)); err != nil {
+			return fmt.Errorf("failed to make the filesystem read-only: %w", err)
+		}
+	}
+	if sandbox.Seccomp {
+		if err := installSeccompFilter(); err != nil {
+			return fmt.Errorf("failed to install seccomp filter: %w", err)
+		}
+	}
+	return nil
+}
+
+// setAppArmorExec confines the next program the thread executes to an AppArmor profile
+func setAppArmorExec(profile string) error {
+	enabled, err := os.ReadFile("/sys/module/apparmor/parameters/enabled")
+	if err != nil || strings.TrimSpace(string(enabled)) != "Y" {
+		return fmt.Errorf("AppArmor is not enabled")
+	}
+	command := []byte("exec " + profile)
+	if err := os.WriteFile("/proc/thread-self/attr/apparmor/exec", command, 0); err == nil {
+		return nil
+	}
+	return os.WriteFile("/proc/thread-self/attr/exec", command, 0)
+}
+
+// landlockPathBeneath is struct landlock_path_beneath_attr; the kernel reads its packed 12 bytes
+type landlockPathBeneath struct {
+	allowedAccess uint64
+	parentFd      int32
+}
+
+// restrictFilesystem denies the thread every filesystem access Landlock handles except reading
+// and executing the given paths, and writing to /dev/null
+func restrictFilesystem(readable []string) error {
+	abi, _, errno := syscall.RawSyscall(sysLandlockCreateRuleset, 0, 0, landlockRulesetVersion)
+	if errno != 0 {
+		return fmt.Errorf("Landlock is not supported: %w", errno)
+	}
+	handled := uint64(landlockAccessABI1)
+	if abi >= 2 {
+		handled |= landlockRefer
+	}
+	if abi >= 3 {
+		handled |= landlockTruncate
+	}
+
+	rulesetAttr := handled // struct landlock_ruleset_attr holding only handled_access_fs
+	ruleset, _, errno := syscall.RawSyscall(sysLandlockCreateRuleset,
+		uintptr(unsafe.Pointer(&rulesetAttr)), unsafe.Sizeof(rulesetAttr), 0)
+	if errno != 0 {
+		return fmt.Errorf("failed to create ruleset: %w", errno)
+	}
+	defer syscall.Close(int(ruleset))
+
+	for _, path := range readable {
+		if err := allowAccess(int(ruleset), path, landlockExecute|landlockReadFile|landlockReadDir); err != nil {
+			return fmt.Errorf("failed to allow reading %s: %w", path, err)
+		}
+	}
+	// Standard streams the engine doesn't use are connected to /dev/null
+	if err := allowAccess(int(ruleset), os.DevNull, landlockReadFile|landlockWriteFile); err != nil {
+		return fmt.Errorf("failed to allow %s: %w", os.DevNull, err)
+	}
+
+	if _, _, errno := syscall.RawSyscall(sysLandlockRestrictSelf, ruleset, 0, 0); errno != 0 {
+		return fmt.Errorf("failed to restrict thread: %w", errno)
+	}
+	return nil
+}
+
+// allowAccess adds a rule allowing access to path and, for directories, everything beneath it.
+// Directory rights are dropped for files, which Landlock rejects them for.
+func allowAccess(ruleset int, path string, access uint64) error {
+	fd, err := syscall.Open(path, oPath|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return err
+	}
+	defer syscall.Close(fd)
+
+	var stat syscall.Stat_t
+	if err := syscall.Fstat(fd, &stat); err != nil {
+		return err
+	}
+	if stat.Mode&syscall.S_IFMT != syscall.S_IFDIR {
+		access &^= landlockReadDir
+	}
+
+	rule := landlockPathBeneath{allowedAccess: access, parentFd: int32(fd)}
+	if _, _, errno := syscall.RawSyscall6(sysLandlockAddRule, uintptr(ruleset), landlockRulePathBeneath,
+		uintptr(unsafe.Pointer(&rule)), 0, 0, 0); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// installSeccompFilter makes the system calls in deniedSyscalls fail with EPERM for the thread.
+// Calls through another architecture's ABI, which could bypass the numbers, kill the process.
+func installSeccompFilter() error {
+	if seccompArch == 0 {
+		return fmt.Errorf("seccomp filtering is not supported on %s", runtime.GOARCH)
+	}
+
+	const (
+		loadWord = syscall.BPF_LD | syscall.BPF_W | syscall.BPF_ABS
+		jumpEq   = syscall.BPF_JMP | syscall.BPF_JEQ | syscall.BPF_K
+		jumpGe   = syscall.BPF_JMP | syscall.BPF_JGE | syscall.BPF_K
+		ret      = syscall.BPF_RET | syscall.BPF_K
+	)
+	// struct seccomp_data starts with the system call number, followed by the architecture
+	program := []syscall.SockFilter{
+		{Code: loadWord, K: 4},
+		{Code: jumpEq, Jt: 1, K: seccompArch},
+		{Code: ret, K: seccompRetKill},
+		{Code: loadWord, K: 0},
+		{Code: jumpGe, Jt: uint8(len(deniedSyscalls) + 1), K: seccompMaxSyscall},
+	}
+	for i, nr := range deniedSyscalls {
+		// Jump over the remaining comparisons and the allow to the deny
+		program = append(program, syscall.SockFilter{Code: jumpEq, Jt: uint8(len(deniedSyscalls) - i), K: nr})
+	}
+	program = append(program,
+		syscall.SockFilter{Code: ret, K: seccompRetAllow},
+		syscall.SockFilter{Code: ret, K: seccompRetErrno | uint32(syscall.EPERM)},
+	)
+
+	filter := syscall.SockFprog{Len: uint16(len(program)), Filter: &program[0]}
+	_, _, errno := syscall.RawSyscall(syscall.SYS_PRCTL, prSetSeccomp, seccompModeFilter, uintptr(unsafe.Pointer(&filter)))
+	runtime.KeepAlive(program)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,15 @@
+//go:build !linux
+
+package engine
+
+import (
+	"fmt"
+	"os/exec"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+)
+
+// startSandboxed is only implemented on Linux
+func startSandboxed(cmd *exec.Cmd, sandbox models.EngineSandbox) error {
+	return fmt.Errorf("engine sandboxing is only supported on Linux")
+}
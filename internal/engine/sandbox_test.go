@@ -0,0 +1,91 @@
+package engine
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+)
+
+func TestValidateSandbox(t *testing.T) {
+	invalid := []models.EngineSandbox{
+		{User: "no-such-sandbox-user"},
+		{ReadOnly: true, ReadPaths: []string{filepath.Join(t.TempDir(), "missing.nnue")}},
+	}
+	for _, sandbox := range invalid {
+		if err := ValidateSandbox(sandbox); err == nil {
+			t.Errorf("ValidateSandbox(%+v) accepted an invalid sandbox", sandbox)
+		}
+	}
+
+	if err := ValidateSandbox(models.EngineSandbox{NoNetwork: true, ReadOnly: true, ReadPaths: []string{t.TempDir()}, Seccomp: true}); err != nil {
+		t.Errorf("ValidateSandbox() rejected a valid sandbox: %v", err)
+	}
+}
+
+func TestStartEngine_Sandbox(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("engine sandboxing is only supported on Linux")
+	}
+	shell, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("no shell to sandbox")
+	}
+
+	// The shell writes a file and reads a file it may read
+	dir := t.TempDir()
+	readable := filepath.Join(dir, "network.nnue")
+	if err := os.WriteFile(readable, []byte("net"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	written := filepath.Join(dir, "written")
+	script := "echo x > " + written + "; cat " + readable
+
+	sandbox := models.EngineSandbox{ReadOnly: true, ReadPaths: []string{readable}, Seccomp: true}
+	cmd := exec.Command(shell, "-c", script)
+	var output strings.Builder
+	cmd.Stdout = &output
+	if err := startEngine(cmd, sandbox); err != nil {
+		t.Skipf("sandbox unsupported here: %v", err)
+	}
+	cmd.Wait()
+
+	if _, err := os.Stat(written); err == nil {
+		t.Error("sandboxed process wrote a file")
+	}
+	if !strings.Contains(output.String(), "net") {
+		t.Errorf("sandboxed process couldn't read its read path, output %q", output.String())
+	}
+
+	// The server itself stays unconfined
+	if err := os.WriteFile(written, nil, 0o644); err != nil {
+		t.Errorf("sandboxing confined the server: %v", err)
+	}
+}
+
+func TestStartEngine_NoNetwork(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("engine sandboxing is only supported on Linux")
+	}
+
+	// A fresh network namespace has only a loopback interface, and it's down
+	cmd := exec.Command("cat", "/proc/net/dev")
+	var output strings.Builder
+	cmd.Stdout = &output
+	if err := startEngine(cmd, models.EngineSandbox{NoNetwork: true}); err != nil {
+		t.Skipf("network namespaces unsupported here: %v", err)
+	}
+	if err := cmd.Wait(); err != nil {
+		t.Fatalf("sandboxed process failed: %v", err)
+	}
+
+	for _, line := range strings.Split(output.String(), "\n") {
+		if name, _, ok := strings.Cut(line, ":"); ok && strings.TrimSpace(name) != "lo" {
+			t.Errorf("sandboxed process sees interface %s", strings.TrimSpace(name))
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package engine
+
+// seccompArch is AUDIT_ARCH_X86_64, the architecture seccomp filters check system calls against
+const seccompArch = 0xc000003e
+
+// deniedSyscalls are the system calls sandboxed engines can't make: opening sockets, tracing
+// other processes, and administering mounts, namespaces, kernel modules and keys
+var deniedSyscalls = []uint32{
+	41,  // socket
+	101, // ptrace
+	155, // pivot_root
+	161, // chroot
+	165, // mount
+	166, // umount2
+	167, // swapon
+	168, // swapoff
+	169, // reboot
+	175, // init_module
+	176, // delete_module
+	246, // kexec_load
+	248, // add_key
+	249, // request_key
+	250, // keyctl
+	272, // unshare
+	298, // perf_event_open
+	308, // setns
+	310, // process_vm_readv
+	311, // process_vm_writev
+	313, // finit_module
+	320, // kexec_file_load
+	321, // bpf
+	323, // userfaultfd
+	428, // open_tree
+	429, // move_mount
+	430, // fsopen
+	432, // fsmount
+}
@@ -0,0 +1,37 @@
+package engine
+
+// seccompArch is AUDIT_ARCH_AARCH64, the architecture seccomp filters check system calls against
+const seccompArch = 0xc00000b7
+
+// deniedSyscalls are the system calls sandboxed engines can't make: opening sockets, tracing
+// other processes, and administering mounts, namespaces, kernel modules and keys
+var deniedSyscalls = []uint32{
+	39,  // umount2
+	40,  // mount
+	41,  // pivot_root
+	51,  // chroot
+	97,  // unshare
+	104, // kexec_load
+	105, // init_module
+	106, // delete_module
+	117, // ptrace
+	142, // reboot
+	198, // socket
+	217, // add_key
+	218, // request_key
+	219, // keyctl
+	224, // swapon
+	225, // swapoff
+	241, // perf_event_open
+	268, // setns
+	270, // process_vm_readv
+	271, // process_vm_writev
+	273, // finit_module
+	280, // bpf
+	282, // userfaultfd
+	294, // kexec_file_load
+	428, // open_tree
+	429, // move_mount
+	430, // fsopen
+	432, // fsmount
+}
@@ -0,0 +1,9 @@
+//go:build linux && !amd64 && !arm64
+
+package engine
+
+// seccompArch is unset where the sandbox has no system call table; seccomp filtering fails there
+const seccompArch = 0
+
+// deniedSyscalls is empty where the sandbox has no system call table
+var deniedSyscalls []uint32
@@ -5,16 +5,27 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"math"
 	"os/exec"
-	"regexp"
-	"strconv"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/pedrampdd/ChessAnalyser/internal/board"
 	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// ScoreVersion identifies how search results are scored. Version 2 reports every evaluation,
+// line evaluation, WDL and score bound from White's point of view, where UCI scores them from
+// the side to move. Caches of search results key or tag them with it, so results scored
+// another way are never served.
+const ScoreVersion = 2
+
 // StockfishEngine represents a Stockfish chess engine instance
 type StockfishEngine struct {
 	cmd         *exec.Cmd
@@ -27,19 +38,48 @@ type StockfishEngine struct {
 	isAnalyzing bool
 	settings    models.EngineSettings
 	version     string
+	defaultNet  string
+	showWDL     bool      // Engine supports UCI_ShowWDL; searches then report win/draw/loss odds
+	evalScale   EvalScale // Scale of the engine's scores, converted to the normalized scale when parsed
+	skillLevel  int       // Skill level the engine was configured with, restored after weakened searches
+	contempt    int       // Contempt the engine was configured with, restored after searches that set their own
+	acquiredAt  time.Time // When the engine was taken from its pool (guarded by the pool's queueMu)
+	returnedAt  time.Time // When the engine was last returned to its pool (guarded by the pool's queueMu)
 }
 
 // EnginePool manages multiple Stockfish engine instances
 type EnginePool struct {
-	Engines    []*StockfishEngine
+	Engines    []*StockfishEngine // Running engines; changed with both mu and queueMu held
 	Available  chan *StockfishEngine
+	Version    string // Engine version reported by the pool's engines
+	EvalScale  string // Native evaluation scale of the pool's engines
 	mu         sync.RWMutex
 	maxEngines int
 	settings   models.EngineSettings
+	limits     models.EngineLimits  // Resource limits applied to the engine processes
+	sandbox    models.EngineSandbox // Restrictions the engine processes were started with
+
+	// Lazy pools start engines on first use and stop those idle for idleTimeout
+	lazy           bool
+	executablePath string
+	idleTimeout    time.Duration
+	starting       int           // Engines being started
+	closed         bool          // Close was called; no engine is started or stopped any more
+	stop           chan struct{} // Closed by Close to end the idle engine reaper
+
+	queueMu  sync.Mutex
+	maxQueue int           // Client requests allowed to wait for an engine (0 = unbounded)
+	waiting  int           // Requests waiting for an engine
+	avgHold  time.Duration // Moving average of how long an engine is held
 }
 
 // NewStockfishEngine creates a new Stockfish engine instance
 func NewStockfishEngine(executablePath string, settings models.EngineSettings) (*StockfishEngine, error) {
+	return NewSandboxedStockfishEngine(executablePath, settings, models.EngineSandbox{})
+}
+
+// NewSandboxedStockfishEngine creates a Stockfish engine instance whose process runs under the sandbox's restrictions
+func NewSandboxedStockfishEngine(executablePath string, settings models.EngineSettings, sandbox models.EngineSandbox) (*StockfishEngine, error) {
 	cmd := exec.Command(executablePath)
 
 	stdin, err := cmd.StdinPipe()
@@ -57,17 +97,25 @@ func NewStockfishEngine(executablePath string, settings models.EngineSettings) (
 		return nil, fmt.Errorf("failed to create stderr pipe: %w", err)
 	}
 
-	if err := cmd.Start(); err != nil {
+	if err := startEngine(cmd, sandbox); err != nil {
 		return nil, fmt.Errorf("failed to start Stockfish: %w", err)
 	}
 
+	contempt := 0
+	if settings.Contempt != nil {
+		contempt = *settings.Contempt
+	}
+	settings.Contempt = &contempt
+
 	engine := &StockfishEngine{
-		cmd:      cmd,
-		stdin:    stdin,
-		stdout:   stdout,
-		stderr:   stderr,
-		scanner:  bufio.NewScanner(stdout),
-		settings: settings,
+		cmd:        cmd,
+		stdin:      stdin,
+		stdout:     stdout,
+		stderr:     stderr,
+		scanner:    bufio.NewScanner(stdout),
+		settings:   settings,
+		skillLevel: settings.SkillLevel,
+		contempt:   contempt,
 	}
 
 	// Initialize the engine
@@ -89,11 +137,13 @@ func (e *StockfishEngine) initialize() error {
 		return err
 	}
 
-	// Wait for uciok
-	if err := e.waitForResponse("uciok"); err != nil {
+	// Read engine identification and options until uciok
+	if err := e.readUCIHeader(); err != nil {
 		return err
 	}
 
+	e.evalScale = EvalScaleFor(e.version)
+
 	// Set engine options
 	if err := e.configureEngine(); err != nil {
 		return err
@@ -118,7 +168,16 @@ func (e *StockfishEngine) configureEngine() error {
 		fmt.Sprintf("setoption name Threads value %d", e.settings.Threads),
 		fmt.Sprintf("setoption name Hash value %d", e.settings.HashSize),
 		fmt.Sprintf("setoption name Skill Level value %d", e.settings.SkillLevel),
-		fmt.Sprintf("setoption name Contempt value %d", e.settings.Contempt),
+		fmt.Sprintf("setoption name Contempt value %d", *e.settings.Contempt),
+	}
+	if e.settings.MultiPV > 0 {
+		commands = append(commands, fmt.Sprintf("setoption name MultiPV value %d", e.settings.MultiPV))
+	}
+	if e.settings.EvalFile != "" {
+		commands = append(commands, fmt.Sprintf("setoption name EvalFile value %s", e.settings.EvalFile))
+	}
+	if e.showWDL {
+		commands = append(commands, "setoption name UCI_ShowWDL value true")
 	}
 
 	for _, cmd := range commands {
@@ -130,12 +189,117 @@ func (e *StockfishEngine) configureEngine() error {
 	return nil
 }
 
+// applySettings sends setoption commands for the options that differ from the
+// engine's current configuration. Zero values keep the current option value.
+// The caller must hold the engine lock.
+func (e *StockfishEngine) applySettings(settings models.EngineSettings) error {
+	var commands []string
+
+	if settings.Threads > 0 && settings.Threads != e.settings.Threads {
+		commands = append(commands, fmt.Sprintf("setoption name Threads value %d", settings.Threads))
+		e.settings.Threads = settings.Threads
+	}
+	if settings.HashSize > 0 && settings.HashSize != e.settings.HashSize {
+		commands = append(commands, fmt.Sprintf("setoption name Hash value %d", settings.HashSize))
+		e.settings.HashSize = settings.HashSize
+	}
+	// Searches without a skill level run at the configured one, so a weakened search never leaks into the next one
+	skillLevel := settings.SkillLevel
+	if skillLevel <= 0 {
+		skillLevel = e.skillLevel
+	}
+	if skillLevel > 0 && skillLevel != e.settings.SkillLevel {
+		commands = append(commands, fmt.Sprintf("setoption name Skill Level value %d", skillLevel))
+		e.settings.SkillLevel = skillLevel
+	}
+	// Likewise for contempt, which may be set to 0 on purpose
+	contempt := e.contempt
+	if settings.Contempt != nil {
+		contempt = *settings.Contempt
+	}
+	if e.settings.Contempt == nil || contempt != *e.settings.Contempt {
+		commands = append(commands, fmt.Sprintf("setoption name Contempt value %d", contempt))
+		e.settings.Contempt = &contempt
+	}
+
+	// Strength limiting is applied exactly as requested so a limited search never leaks into the next one
+	if settings.LimitStrength != e.settings.LimitStrength {
+		commands = append(commands, fmt.Sprintf("setoption name UCI_LimitStrength value %t", settings.LimitStrength))
+		e.settings.LimitStrength = settings.LimitStrength
+	}
+	if settings.LimitStrength && settings.Elo > 0 && settings.Elo != e.settings.Elo {
+		commands = append(commands, fmt.Sprintf("setoption name UCI_Elo value %d", settings.Elo))
+		e.settings.Elo = settings.Elo
+	}
+
+	// Only variant engines know UCI_Variant, and only variant requests are routed to them
+	if variant := uciVariant(settings.Variant); variant != uciVariant(e.settings.Variant) {
+		commands = append(commands, fmt.Sprintf("setoption name UCI_Variant value %s", variant))
+	}
+	e.settings.Variant = settings.Variant
+
+	multiPV := settings.MultiPV
+	if multiPV < 1 {
+		multiPV = 1
+	}
+	if multiPV != e.settings.MultiPV {
+		commands = append(commands, fmt.Sprintf("setoption name MultiPV value %d", multiPV))
+		e.settings.MultiPV = multiPV
+	}
+
+	if len(commands) == 0 {
+		return nil
+	}
+
+	for _, cmd := range commands {
+		if err := e.sendCommand(cmd); err != nil {
+			return err
+		}
+	}
+
+	// Options such as Hash are applied asynchronously; wait until the engine is ready again
+	if err := e.sendCommand("isready"); err != nil {
+		return err
+	}
+	return e.waitForResponse("readyok")
+}
+
 // sendCommand sends a command to the engine
 func (e *StockfishEngine) sendCommand(command string) error {
 	_, err := fmt.Fprintf(e.stdin, "%s\n", command)
 	return err
 }
 
+// readUCIHeader reads the engine's response to "uci", recording its name and default network
+func (e *StockfishEngine) readUCIHeader() error {
+	timeout := time.After(10 * time.Second)
+
+	for {
+		select {
+		case <-timeout:
+			return errors.NewTimeoutError("waiting for uciok", nil)
+		default:
+			if !e.scanner.Scan() {
+				return fmt.Errorf("scanner error while waiting for: uciok")
+			}
+
+			line := strings.TrimSpace(e.scanner.Text())
+			switch {
+			case strings.EqualFold(line, "uciok"):
+				return nil
+			case strings.HasPrefix(line, "id name "):
+				e.version = strings.TrimPrefix(line, "id name ")
+			case strings.HasPrefix(line, "option name UCI_ShowWDL "):
+				e.showWDL = true
+			case strings.HasPrefix(line, "option name EvalFile "):
+				if idx := strings.Index(line, " default "); idx != -1 {
+					e.defaultNet = strings.TrimSpace(line[idx+len(" default "):])
+				}
+			}
+		}
+	}
+}
+
 // waitForResponse waits for a specific response from the engine
 func (e *StockfishEngine) waitForResponse(expected string) error {
 	timeout := time.After(10 * time.Second)
@@ -143,11 +307,11 @@ func (e *StockfishEngine) waitForResponse(expected string) error {
 	for {
 		select {
 		case <-timeout:
-			return fmt.Errorf("timeout waiting for response: %s", expected)
+			return errors.NewTimeoutError("waiting for "+expected, nil)
 		default:
 			if e.scanner.Scan() {
 				line := strings.TrimSpace(e.scanner.Text())
-				if strings.Contains(line, expected) {
+				if strings.Contains(strings.ToLower(line), strings.ToLower(expected)) {
 					return nil
 				}
 			} else {
@@ -157,49 +321,259 @@ func (e *StockfishEngine) waitForResponse(expected string) error {
 	}
 }
 
+// EarlyStop configures stopping a search once the evaluation has stabilized
+type EarlyStop struct {
+	MinDepth  int     // Depth before which the search is never stopped
+	Window    int     // Number of consecutive depths whose evaluations must agree
+	Tolerance float64 // Maximum evaluation spread within the window, in pawns
+}
+
+// Elo range supported by Stockfish's UCI_Elo option
+const (
+	MinElo = 1320
+	MaxElo = 3190
+)
+
+// ClampElo limits a rating to the range the engine can play at
+func ClampElo(rating int) int {
+	if rating < MinElo {
+		return MinElo
+	}
+	if rating > MaxElo {
+		return MaxElo
+	}
+	return rating
+}
+
+// DefaultEarlyStop returns the early-stop settings used for scan mode
+func DefaultEarlyStop() EarlyStop {
+	return EarlyStop{
+		MinDepth:  8,
+		Window:    3,
+		Tolerance: 0.15,
+	}
+}
+
 // AnalyzePosition analyzes a chess position
 func (e *StockfishEngine) AnalyzePosition(ctx context.Context, fen string, settings models.EngineSettings) (*models.AnalysisResult, error) {
+	return e.analyze(ctx, fen, settings, searchCommand(settings), nil)
+}
+
+// ExtendPosition searches a position to the settings' depth like AnalyzePosition without a time
+// limit, but gives up deepening after maxTime milliseconds with "go depth N movetime T", so a
+// deep extension can't hold the engine indefinitely
+func (e *StockfishEngine) ExtendPosition(ctx context.Context, fen string, settings models.EngineSettings, maxTime int) (*models.AnalysisResult, error) {
+	return e.analyze(ctx, fen, settings, fmt.Sprintf("go depth %d movetime %d", settings.Depth, maxTime), nil)
+}
+
+// SearchMate looks for a forced mate in at most moves moves with "go mate N", giving up after the
+// settings' time limit. It returns the search result, evaluated from the side to move, and the
+// mate distance in moves: positive if the side to move mates, negative if it gets mated, 0 if
+// no mate was found.
+func (e *StockfishEngine) SearchMate(ctx context.Context, fen string, moves int, settings models.EngineSettings) (*models.AnalysisResult, int, error) {
+	if IsStandardVariant(settings.Variant) {
+		if err := validatePosition(fen); err != nil {
+			return nil, 0, err
+		}
+	}
+
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
 	if !e.isReady {
-		return nil, fmt.Errorf("engine is not ready")
+		return nil, 0, errors.NewEngineUnavailableError("stockfish", nil)
 	}
 
 	e.isAnalyzing = true
 	defer func() { e.isAnalyzing = false }()
 
-	// Set position
-	if err := e.sendCommand(fmt.Sprintf("position fen %s", fen)); err != nil {
-		return nil, err
+	settings.MultiPV = 1
+	if err := e.applySettings(settings); err != nil {
+		return nil, 0, fmt.Errorf("failed to apply engine settings: %w", err)
 	}
 
-	// Start analysis
-	analysisCmd := fmt.Sprintf("go depth %d", settings.Depth)
+	command := fmt.Sprintf("go mate %d", moves)
 	if settings.TimeLimit > 0 {
-		analysisCmd = fmt.Sprintf("go movetime %d", settings.TimeLimit)
+		command += fmt.Sprintf(" movetime %d", settings.TimeLimit)
 	}
-	if settings.MultiPV > 1 {
-		analysisCmd += fmt.Sprintf(" multipv %d", settings.MultiPV)
+
+	ctx, span := startSearchSpan(ctx, "engine.mate_search", fen, command)
+	started := time.Now()
+	result, err := e.search(ctx, fen, command, 1, nil)
+	endSearchSpan(span, started, result, err)
+	if err != nil {
+		return nil, 0, err
 	}
 
-	if err := e.sendCommand(analysisCmd); err != nil {
-		return nil, err
+	return result, mateDistance(result.Evaluation), nil
+}
+
+// mateDistance recovers the mate distance in moves from an evaluation with mate scores mapped to ±1000
+func mateDistance(evaluation float64) int {
+	switch {
+	case evaluation >= 900:
+		return int(math.Round(1000 - evaluation))
+	case evaluation <= -900:
+		return -int(math.Round(1000 + evaluation))
+	}
+	return 0
+}
+
+// ScanPosition evaluates a position with "go depth N" but stops the search as soon as
+// the evaluation is stable, instead of waiting for the full depth to complete
+func (e *StockfishEngine) ScanPosition(ctx context.Context, fen string, settings models.EngineSettings, earlyStop EarlyStop) (*models.AnalysisResult, error) {
+	settings.TimeLimit = 0
+	return e.analyze(ctx, fen, settings, searchCommand(settings), &earlyStop)
+}
+
+// searchCommand returns the go command of a search: a fixed search time when the settings have a
+// time limit, a fixed depth otherwise
+func searchCommand(settings models.EngineSettings) string {
+	if settings.TimeLimit > 0 {
+		return fmt.Sprintf("go movetime %d", settings.TimeLimit)
+	}
+	return fmt.Sprintf("go depth %d", settings.Depth)
+}
+
+// analyze runs a search command on a position, optionally stopping early once the evaluation is stable
+func (e *StockfishEngine) analyze(ctx context.Context, fen string, settings models.EngineSettings, analysisCmd string, earlyStop *EarlyStop) (*models.AnalysisResult, error) {
+	if IsStandardVariant(settings.Variant) {
+		if err := validatePosition(fen); err != nil {
+			return nil, err
+		}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if !e.isReady {
+		return nil, errors.NewEngineUnavailableError("stockfish", nil)
 	}
 
-	// Parse analysis results
-	result, err := e.parseAnalysisOutput(ctx, settings.MultiPV)
+	e.isAnalyzing = true
+	defer func() { e.isAnalyzing = false }()
+
+	// Reconfigure the engine for this request's options
+	if err := e.applySettings(settings); err != nil {
+		return nil, fmt.Errorf("failed to apply engine settings: %w", err)
+	}
+
+	// Trace the go/stop cycle of this position
+	ctx, span := startSearchSpan(ctx, "engine.search", fen, analysisCmd)
+	span.SetAttributes(attribute.Int("engine.multipv", settings.MultiPV), attribute.Bool("engine.early_stop", earlyStop != nil))
+	started := time.Now()
+	result, err := e.search(ctx, fen, analysisCmd, settings.MultiPV, earlyStop)
+	endSearchSpan(span, started, result, err)
 	if err != nil {
 		return nil, err
 	}
 
+	// UCI scores are from the side to move; report them from White's point of view
+	if blackToMove(fen) {
+		flipPointOfView(result)
+	}
+
 	return result, nil
 }
 
+// search sets up the position, runs the search command and parses the engine's output
+func (e *StockfishEngine) search(ctx context.Context, fen, command string, multiPV int, earlyStop *EarlyStop) (*models.AnalysisResult, error) {
+	if err := e.sendCommand(fmt.Sprintf("position fen %s", fen)); err != nil {
+		return nil, err
+	}
+	if err := e.sendCommand(command); err != nil {
+		return nil, err
+	}
+	return e.parseAnalysisOutput(ctx, multiPV, earlyStop)
+}
+
+// replayableVariants are the variants besides standard chess that can be analyzed end to end:
+// their moves follow the standard rules, so their games replay on the standard board and their
+// positions are plain FENs. Only their win conditions differ, which a variant engine such as
+// Fairy-Stockfish knows.
+var replayableVariants = map[string]bool{
+	"kingofthehill": true,
+	"racingkings":   true,
+}
+
+// uciVariant maps a Chess.com or PGN variant name to the UCI_Variant value understood by Fairy-Stockfish
+func uciVariant(variant string) string {
+	name := strings.NewReplacer(" ", "", "-", "", "_", "").Replace(strings.ToLower(variant))
+	switch name {
+	case "", "chess", "standard":
+		return "chess"
+	}
+	return name
+}
+
+// IsStandardVariant reports whether Stockfish itself can analyze the variant
+func IsStandardVariant(variant string) bool {
+	return uciVariant(variant) == "chess"
+}
+
+// SameVariant reports whether two variant names, e.g. "King of the Hill" and "kingofthehill", name the same variant
+func SameVariant(a, b string) bool {
+	return uciVariant(a) == uciVariant(b)
+}
+
+// IsSupportedVariant reports whether games of the variant can be analyzed: standard chess, or a
+// variant whose moves follow the standard rules. Variants with other moves, such as Chess960
+// castling or crazyhouse drops, can't be replayed.
+func IsSupportedVariant(variant string) bool {
+	return IsStandardVariant(variant) || replayableVariants[uciVariant(variant)]
+}
+
+// validatePosition rejects FENs of impossible positions before they reach the engine.
+// Stockfish's behavior on them is undefined and has hung engines in the pool.
+func validatePosition(fen string) error {
+	b, err := board.FromFEN(fen)
+	if err != nil {
+		return errors.NewValidationError("fen", err.Error())
+	}
+	if err := b.Validate(); err != nil {
+		return errors.NewValidationError("fen", fmt.Sprintf("impossible position: %v", err))
+	}
+	return nil
+}
+
+// flipPointOfView turns a search result scored for Black into one scored for White: evaluations
+// are negated, WDL odds swapped and score bounds reversed
+func flipPointOfView(result *models.AnalysisResult) {
+	result.Evaluation = -result.Evaluation
+	for i := range result.LineEvaluations {
+		result.LineEvaluations[i] = -result.LineEvaluations[i]
+	}
+	for i := range result.Lines {
+		result.Lines[i].Evaluation = -result.Lines[i].Evaluation
+		if wdl := result.Lines[i].WDL; wdl != nil {
+			flipped := wdl.Flip()
+			result.Lines[i].WDL = &flipped
+		}
+	}
+	if result.WDL != nil {
+		flipped := result.WDL.Flip()
+		result.WDL = &flipped
+	}
+	switch result.ScoreBound {
+	case models.ScoreLowerBound:
+		result.ScoreBound = models.ScoreUpperBound
+	case models.ScoreUpperBound:
+		result.ScoreBound = models.ScoreLowerBound
+	}
+}
+
+// blackToMove reports whether the FEN has Black to move
+func blackToMove(fen string) bool {
+	fields := strings.Fields(fen)
+	return len(fields) > 1 && fields[1] == "b"
+}
+
 // parseAnalysisOutput parses the engine's analysis output
-func (e *StockfishEngine) parseAnalysisOutput(ctx context.Context, multiPV int) (*models.AnalysisResult, error) {
+func (e *StockfishEngine) parseAnalysisOutput(ctx context.Context, multiPV int, earlyStop *EarlyStop) (*models.AnalysisResult, error) {
 	var result models.AnalysisResult
 	var pvLines []string
+	var evalHistory []float64
+	stopped := false
 
 	timeout := time.After(30 * time.Second)
 
@@ -208,127 +582,133 @@ func (e *StockfishEngine) parseAnalysisOutput(ctx context.Context, multiPV int)
 		case <-ctx.Done():
 			return nil, ctx.Err()
 		case <-timeout:
-			return nil, fmt.Errorf("analysis timeout")
+			return nil, errors.NewTimeoutError("analysis", nil)
 		default:
 			if e.scanner.Scan() {
 				line := strings.TrimSpace(e.scanner.Text())
 
-				if strings.HasPrefix(line, "bestmove") {
+				if bestMove, ok := parseBestMove(line); ok {
 					// Analysis complete
-					parts := strings.Fields(line)
-					if len(parts) >= 2 {
-						result.BestMove = parts[1]
-					}
+					result.BestMove = bestMove
 					result.PrincipalVariation = pvLines
+					result.EvalScale = e.evalScale.Name
 					return &result, nil
 				}
 
-				// Parse info lines
-				if strings.HasPrefix(line, "info") {
-					if err := e.parseInfoLine(line, &result, &pvLines); err != nil {
-						continue // Continue parsing even if one line fails
+				// Parse info lines, skipping messages and anything else the engine prints
+				info, ok := parseUCIInfo(line)
+				if !ok {
+					continue
+				}
+				e.applyInfo(info, &result, &pvLines)
+
+				// Stop the search once the evaluation is stable; the engine still
+				// answers with bestmove, which keeps the protocol in sync
+				if earlyStop != nil && !stopped && info.exactMainLine() {
+					evalHistory = append(evalHistory, result.Evaluation)
+					if result.Depth >= earlyStop.MinDepth && isStable(evalHistory, earlyStop) {
+						if err := e.sendCommand("stop"); err != nil {
+							return nil, err
+						}
+						stopped = true
+						trace.SpanFromContext(ctx).AddEvent("stop", trace.WithAttributes(attribute.Int("engine.depth", result.Depth)))
 					}
 				}
 			} else {
-				return nil, fmt.Errorf("scanner error during analysis")
+				return nil, errors.NewEngineUnavailableError("stockfish", fmt.Errorf("engine output ended during analysis"))
 			}
 		}
 	}
 }
 
-// parseInfoLine parses a single info line from Stockfish
-func (e *StockfishEngine) parseInfoLine(line string, result *models.AnalysisResult, pvLines *[]string) error {
-	// Extract depth
-	if depth := extractInt(line, "depth"); depth > 0 {
-		result.Depth = depth
+// applyInfo records a parsed info line in the search result. Currmove progress lines only update
+// the search statistics. Bound scores from a failed aspiration search aren't final either, so
+// they're only recorded, and flagged, while the search has no exact score to report yet.
+func (e *StockfishEngine) applyInfo(info uciInfo, result *models.AnalysisResult, pvLines *[]string) {
+	if info.nodes > 0 {
+		result.Nodes = info.nodes
 	}
-
-	// Extract nodes
-	if nodes := extractInt64(line, "nodes"); nodes > 0 {
-		result.Nodes = nodes
+	if info.time > 0 {
+		result.Time = info.time
 	}
-
-	// Extract time
-	if time := extractInt64(line, "time"); time > 0 {
-		result.Time = time
+	if info.hashFull > 0 {
+		result.HashFull = info.hashFull
 	}
-
-	// Extract evaluation
-	if eval := extractFloat(line, "score cp"); eval != 0 {
-		result.Evaluation = eval / 100.0 // Convert centipawns to pawns
-	} else if mate := extractInt(line, "score mate"); mate != 0 {
-		// Handle mate scores
-		if mate > 0 {
-			result.Evaluation = 1000.0 - float64(mate)
-		} else {
-			result.Evaluation = -1000.0 - float64(mate)
+	if info.currMove != "" {
+		return
+	}
+	if info.bound != "" {
+		if info.multiPV > 1 || (result.Depth > 0 && result.ScoreBound == "") {
+			return
 		}
+		result.ScoreBound = info.bound
+	} else if info.hasScore && info.multiPV <= 1 {
+		result.ScoreBound = ""
 	}
 
-	// Extract principal variation
-	if strings.Contains(line, "pv") {
-		pv := extractPV(line)
-		if len(pv) > 0 {
-			*pvLines = pv
-		}
+	if info.depth > 0 {
+		result.Depth = info.depth
 	}
 
-	return nil
-}
+	// With Multi-PV only the first line is the position's evaluation
+	mainLine := info.multiPV <= 1
+	if mainLine && info.hasScore {
+		result.Evaluation = info.evaluation(e.evalScale)
+	}
 
-// extractInt extracts an integer value from a string
-func extractInt(line, key string) int {
-	re := regexp.MustCompile(fmt.Sprintf(`%s\s+(\d+)`, key))
-	matches := re.FindStringSubmatch(line)
-	if len(matches) > 1 {
-		if val, err := strconv.Atoi(matches[1]); err == nil {
-			return val
-		}
+	// Extract the win/draw/loss odds reported with UCI_ShowWDL
+	if mainLine && info.wdl != nil {
+		result.WDL = info.wdl
 	}
-	return 0
-}
 
-// extractInt64 extracts an int64 value from a string
-func extractInt64(line, key string) int64 {
-	re := regexp.MustCompile(fmt.Sprintf(`%s\s+(\d+)`, key))
-	matches := re.FindStringSubmatch(line)
-	if len(matches) > 1 {
-		if val, err := strconv.ParseInt(matches[1], 10, 64); err == nil {
-			return val
+	// Keep the score of every Multi-PV line of the latest depth
+	if info.multiPV > 0 && info.hasScore {
+		for len(result.LineEvaluations) < info.multiPV {
+			result.LineEvaluations = append(result.LineEvaluations, 0)
 		}
-	}
-	return 0
-}
+		result.LineEvaluations[info.multiPV-1] = info.evaluation(e.evalScale)
 
-// extractFloat extracts a float value from a string
-func extractFloat(line, key string) float64 {
-	re := regexp.MustCompile(fmt.Sprintf(`%s\s+(-?\d+)`, key))
-	matches := re.FindStringSubmatch(line)
-	if len(matches) > 1 {
-		if val, err := strconv.ParseFloat(matches[1], 64); err == nil {
-			return val
+		for len(result.Lines) < info.multiPV {
+			result.Lines = append(result.Lines, models.PVLine{})
+		}
+		if len(info.pv) > 0 {
+			result.Lines[info.multiPV-1] = models.PVLine{Moves: info.pv, Evaluation: info.evaluation(e.evalScale), Depth: info.depth, WDL: info.wdl}
 		}
 	}
-	return 0
+
+	if mainLine && len(info.pv) > 0 {
+		*pvLines = info.pv
+	}
 }
 
-// extractPV extracts the principal variation from a line
-func extractPV(line string) []string {
-	parts := strings.Fields(line)
-	var pv []string
-	inPV := false
+// isStable reports whether the last evaluations agree within the early-stop tolerance
+func isStable(history []float64, earlyStop *EarlyStop) bool {
+	window := earlyStop.Window
+	if window < 2 {
+		window = 2
+	}
+	if len(history) < window {
+		return false
+	}
 
-	for _, part := range parts {
-		if part == "pv" {
-			inPV = true
-			continue
+	recent := history[len(history)-window:]
+	min, max := recent[0], recent[0]
+	for _, eval := range recent[1:] {
+		if eval < min {
+			min = eval
 		}
-		if inPV {
-			pv = append(pv, part)
+		if eval > max {
+			max = eval
 		}
 	}
+	return max-min <= earlyStop.Tolerance
+}
 
-	return pv
+// GetSettings returns the options currently applied to the engine
+func (e *StockfishEngine) GetSettings() models.EngineSettings {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.settings
 }
 
 // GetVersion returns the engine version
@@ -338,6 +718,59 @@ func (e *StockfishEngine) GetVersion() string {
 	return e.version
 }
 
+// GetEvalScale returns the scale of the engine's own scores
+func (e *StockfishEngine) GetEvalScale() EvalScale {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.evalScale
+}
+
+// GetNetworkName returns the name of the NNUE network used by the engine
+func (e *StockfishEngine) GetNetworkName() string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if e.settings.EvalFile != "" {
+		return filepath.Base(e.settings.EvalFile)
+	}
+	return e.defaultNet
+}
+
+// EffectiveSettings returns the settings a search with the given ones actually runs with: options
+// left at zero keep the engine's current value, skill level and contempt left unset run at the
+// configured ones, a time limit replaces the depth limit, MultiPV is
+// at least 1, a limited strength is clamped to the supported Elo range and the network is the one
+// loaded
+func (e *StockfishEngine) EffectiveSettings(settings models.EngineSettings) models.EngineSettings {
+	e.mu.RLock()
+	current, configuredSkill, configuredContempt := e.settings, e.skillLevel, e.contempt
+	e.mu.RUnlock()
+
+	if settings.Threads <= 0 {
+		settings.Threads = current.Threads
+	}
+	if settings.HashSize <= 0 {
+		settings.HashSize = current.HashSize
+	}
+	if settings.SkillLevel <= 0 {
+		settings.SkillLevel = configuredSkill
+	}
+	if settings.Contempt == nil {
+		settings.Contempt = &configuredContempt
+	}
+	if settings.TimeLimit > 0 {
+		settings.Depth = 0
+	}
+	settings.MultiPV = max(settings.MultiPV, 1)
+	if settings.LimitStrength {
+		settings.Elo = ClampElo(settings.Elo)
+	} else {
+		settings.Elo = 0
+	}
+	settings.EvalFile = e.GetNetworkName()
+	return settings
+}
+
 // IsReady returns whether the engine is ready
 func (e *StockfishEngine) IsReady() bool {
 	e.mu.RLock()
@@ -376,16 +809,22 @@ func (e *StockfishEngine) Close() error {
 
 // NewEnginePool creates a new engine pool
 func NewEnginePool(maxEngines int, executablePath string, settings models.EngineSettings) (*EnginePool, error) {
+	return NewSandboxedEnginePool(maxEngines, executablePath, settings, models.EngineSandbox{})
+}
+
+// NewSandboxedEnginePool creates an engine pool whose engine processes run under the sandbox's restrictions
+func NewSandboxedEnginePool(maxEngines int, executablePath string, settings models.EngineSettings, sandbox models.EngineSandbox) (*EnginePool, error) {
 	pool := &EnginePool{
 		Engines:    make([]*StockfishEngine, 0, maxEngines),
 		Available:  make(chan *StockfishEngine, maxEngines),
 		maxEngines: maxEngines,
 		settings:   settings,
+		sandbox:    sandbox,
 	}
 
 	// Create initial engines
 	for i := 0; i < maxEngines; i++ {
-		engine, err := NewStockfishEngine(executablePath, settings)
+		engine, err := NewSandboxedStockfishEngine(executablePath, settings, sandbox)
 		if err != nil {
 			// Clean up any created engines
 			pool.Close()
@@ -395,17 +834,12 @@ func NewEnginePool(maxEngines int, executablePath string, settings models.Engine
 		pool.Available <- engine
 	}
 
-	return pool, nil
-}
-
-// GetEngine gets an available engine from the pool
-func (p *EnginePool) GetEngine() *StockfishEngine {
-	return <-p.Available
-}
+	if len(pool.Engines) > 0 {
+		pool.Version = pool.Engines[0].GetVersion()
+		pool.EvalScale = pool.Engines[0].GetEvalScale().Name
+	}
 
-// ReturnEngine returns an engine to the pool
-func (p *EnginePool) ReturnEngine(engine *StockfishEngine) {
-	p.Available <- engine
+	return pool, nil
 }
 
 // Close shuts down all Engines in the pool
@@ -413,6 +847,11 @@ func (p *EnginePool) Close() error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
+	if p.stop != nil && !p.closed {
+		close(p.stop)
+	}
+	p.closed = true
+
 	var errs []error
 	for _, engine := range p.Engines {
 		if err := engine.Close(); err != nil {
@@ -428,3 +867,12 @@ func (p *EnginePool) Close() error {
 
 	return nil
 }
+
+// PoolConfig describes an additional engine pool and the requests routed to it
+type PoolConfig struct {
+	Name           string
+	ExecutablePath string
+	Size           int
+	Variants       []string // Variants routed to the pool, e.g. "crazyhouse"
+	Profiles       []string // Analysis profiles routed to the pool, e.g. "deep"
+}
@@ -0,0 +1,499 @@
+package engine
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// nopWriteCloser records commands sent to a fake engine
+type nopWriteCloser struct {
+	bytes.Buffer
+}
+
+func (w *nopWriteCloser) Close() error { return nil }
+
+// newFakeEngine creates an engine that reads the given output instead of a real process
+func newFakeEngine(output string, settings models.EngineSettings) (*StockfishEngine, *nopWriteCloser) {
+	stdin := &nopWriteCloser{}
+	contempt := 0
+	if settings.Contempt != nil {
+		contempt = *settings.Contempt
+	}
+	settings.Contempt = &contempt
+	return &StockfishEngine{
+		stdin:    stdin,
+		scanner:  bufio.NewScanner(strings.NewReader(output)),
+		settings: settings,
+		contempt: contempt,
+		isReady:  true,
+	}, stdin
+}
+
+func TestStockfishEngine_ApplySettings(t *testing.T) {
+	engine, stdin := newFakeEngine("readyok\n", models.EngineSettings{Threads: 4, HashSize: 128, SkillLevel: 20, MultiPV: 1})
+
+	err := engine.applySettings(models.EngineSettings{Threads: 2, HashSize: 128, MultiPV: 3})
+	if err != nil {
+		t.Fatalf("applySettings() error = %v", err)
+	}
+
+	sent := stdin.String()
+	for _, want := range []string{"setoption name Threads value 2", "setoption name MultiPV value 3", "isready"} {
+		if !strings.Contains(sent, want) {
+			t.Errorf("Expected %q to be sent, got %q", want, sent)
+		}
+	}
+	for _, unwanted := range []string{"Hash", "Skill Level", "Contempt"} {
+		if strings.Contains(sent, unwanted) {
+			t.Errorf("Expected unchanged option %q not to be sent, got %q", unwanted, sent)
+		}
+	}
+
+	if got := engine.GetSettings(); got.Threads != 2 || got.MultiPV != 3 {
+		t.Errorf("GetSettings() = %+v, want Threads 2 and MultiPV 3", got)
+	}
+
+	// Applying the same settings again sends nothing
+	stdin.Reset()
+	if err := engine.applySettings(models.EngineSettings{Threads: 2, MultiPV: 3}); err != nil {
+		t.Fatalf("applySettings() error = %v", err)
+	}
+	if stdin.Len() != 0 {
+		t.Errorf("Expected no commands for unchanged settings, got %q", stdin.String())
+	}
+}
+
+func TestStockfishEngine_EffectiveSettings(t *testing.T) {
+	engine, _ := newFakeEngine("", models.EngineSettings{Threads: 2, HashSize: 256, SkillLevel: 20, MultiPV: 1, EvalFile: "/nets/nn-custom.nnue"})
+	engine.skillLevel = 20
+
+	got := engine.EffectiveSettings(models.EngineSettings{Depth: 18, TimeLimit: 3000, LimitStrength: true, Elo: 900, Variant: "kingofthehill"})
+	want := models.EngineSettings{TimeLimit: 3000, MultiPV: 1, Threads: 2, HashSize: 256, SkillLevel: 20,
+		EvalFile: "nn-custom.nnue", LimitStrength: true, Elo: MinElo, Variant: "kingofthehill"}
+	if got.Contempt == nil || *got.Contempt != 0 {
+		t.Errorf("Expected the configured contempt 0, got %v", got.Contempt)
+	}
+	if got.Contempt = nil; got != want {
+		t.Errorf("EffectiveSettings() = %+v, want %+v", got, want)
+	}
+
+	if got := engine.EffectiveSettings(models.EngineSettings{Depth: 12, Threads: 8, MultiPV: 3, Elo: 2000}); got.Depth != 12 || got.Threads != 8 || got.MultiPV != 3 || got.Elo != 0 {
+		t.Errorf("Expected explicit settings to be kept and an unused Elo dropped, got %+v", got)
+	}
+}
+
+func TestStockfishEngine_ScanPositionStopsEarly(t *testing.T) {
+	output := `info depth 1 seldepth 1 multipv 1 score cp 40 nodes 20 pv e2e4
+info depth 2 seldepth 2 multipv 1 score cp 10 nodes 80 pv d2d4
+info depth 3 seldepth 3 multipv 1 score cp 30 nodes 200 pv e2e4
+info depth 4 seldepth 4 multipv 1 score cp 31 nodes 500 pv e2e4
+info depth 5 seldepth 5 multipv 1 score cp 32 nodes 900 pv e2e4 e7e5
+info depth 6 seldepth 6 multipv 1 score cp 30 nodes 1500 pv e2e4 e7e5
+bestmove e2e4 ponder e7e5
+`
+	engine, stdin := newFakeEngine(output, models.EngineSettings{MultiPV: 1})
+
+	result, err := engine.ScanPosition(context.Background(), "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", models.EngineSettings{Depth: 20, TimeLimit: 5000}, EarlyStop{
+		MinDepth:  4,
+		Window:    3,
+		Tolerance: 0.05,
+	})
+	if err != nil {
+		t.Fatalf("ScanPosition() error = %v", err)
+	}
+
+	sent := stdin.String()
+	if !strings.Contains(sent, "go depth 20") {
+		t.Errorf("Expected a depth search ignoring the time limit, got %q", sent)
+	}
+	if strings.Count(sent, "stop") != 1 {
+		t.Errorf("Expected exactly one stop command, got %q", sent)
+	}
+	if result.BestMove != "e2e4" {
+		t.Errorf("BestMove = %v, want e2e4", result.BestMove)
+	}
+}
+
+func TestStockfishEngine_ApplySettingsRestoresSkillLevel(t *testing.T) {
+	engine, stdin := newFakeEngine("readyok\nreadyok\n", models.EngineSettings{SkillLevel: 20, MultiPV: 1})
+	engine.skillLevel = 20
+
+	if err := engine.applySettings(models.EngineSettings{SkillLevel: 3}); err != nil {
+		t.Fatalf("applySettings() error = %v", err)
+	}
+	if !strings.Contains(stdin.String(), "setoption name Skill Level value 3") {
+		t.Errorf("Expected the skill level to be lowered, got %q", stdin.String())
+	}
+
+	stdin.Reset()
+	if err := engine.applySettings(models.EngineSettings{}); err != nil {
+		t.Fatalf("applySettings() error = %v", err)
+	}
+	if !strings.Contains(stdin.String(), "setoption name Skill Level value 20") {
+		t.Errorf("Expected the configured skill level to be restored, got %q", stdin.String())
+	}
+}
+
+func TestStockfishEngine_ApplySettingsContempt(t *testing.T) {
+	configured, zero, aggressive := 20, 0, 50
+	engine, stdin := newFakeEngine("readyok\nreadyok\nreadyok\n", models.EngineSettings{Contempt: &configured, MultiPV: 1})
+
+	tests := []struct {
+		name     string
+		contempt *int
+		want     string
+	}{
+		{"explicit zero", &zero, "setoption name Contempt value 0"},
+		{"unset restores the configured contempt", nil, "setoption name Contempt value 20"},
+		{"explicit value", &aggressive, "setoption name Contempt value 50"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdin.Reset()
+			if err := engine.applySettings(models.EngineSettings{Contempt: tt.contempt}); err != nil {
+				t.Fatalf("applySettings() error = %v", err)
+			}
+			if !strings.Contains(stdin.String(), tt.want) {
+				t.Errorf("Expected %q to be sent, got %q", tt.want, stdin.String())
+			}
+		})
+	}
+}
+
+func TestStockfishEngine_AnalyzePositionLineEvaluations(t *testing.T) {
+	output := `info depth 10 seldepth 12 multipv 1 score cp 25 nodes 900 pv e7e5
+info depth 10 seldepth 12 multipv 2 score cp -150 nodes 900 pv f7f6
+info depth 11 seldepth 13 multipv 1 score mate 3 nodes 1500 pv d8h4
+info depth 11 seldepth 13 multipv 2 score cp 0 nodes 1500 pv e7e5
+bestmove d8h4
+`
+	engine, _ := newFakeEngine(output, models.EngineSettings{MultiPV: 2})
+
+	fen := "rnbqkbnr/pppppppp/8/8/5PP1/8/PPPPP2P/RNBQKBNR b KQkq - 0 2"
+	result, err := engine.AnalyzePosition(context.Background(), fen, models.EngineSettings{Depth: 11, MultiPV: 2})
+	if err != nil {
+		t.Fatalf("AnalyzePosition() error = %v", err)
+	}
+
+	// Black is to move, so the scores are reported from White's point of view
+	if len(result.LineEvaluations) != 2 || result.LineEvaluations[0] != -997 || result.LineEvaluations[1] != 0 {
+		t.Errorf("LineEvaluations = %v, want [-997 0]", result.LineEvaluations)
+	}
+	if result.Evaluation != -997 || len(result.PrincipalVariation) != 1 || result.PrincipalVariation[0] != "d8h4" {
+		t.Errorf("Expected the first line to be the evaluation and PV, got %v %v", result.Evaluation, result.PrincipalVariation)
+	}
+	if len(result.Lines) != 2 || result.Lines[1].Moves[0] != "e7e5" || result.Lines[1].Depth != 11 || result.Lines[0].Evaluation != -997 {
+		t.Errorf("Lines = %+v, want the depth 11 lines", result.Lines)
+	}
+}
+
+func TestStockfishEngine_AnalyzePositionWhitePointOfView(t *testing.T) {
+	tests := []struct {
+		name      string
+		fen       string
+		output    string
+		wantEval  float64
+		wantBound string
+	}{
+		{
+			name:      "white to move",
+			fen:       "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+			output:    "info depth 12 multipv 1 score cp 50 lowerbound nodes 900 pv e2e4\nbestmove e2e4\n",
+			wantEval:  0.5,
+			wantBound: models.ScoreLowerBound,
+		},
+		{
+			name:      "black to move",
+			fen:       "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1",
+			output:    "info depth 12 multipv 1 score cp 50 lowerbound nodes 900 pv c7c5\nbestmove c7c5\n",
+			wantEval:  -0.5,
+			wantBound: models.ScoreUpperBound,
+		},
+		{
+			name:     "black mates",
+			fen:      "rnbqkbnr/pppppppp/8/8/5PP1/8/PPPPP2P/RNBQKBNR b KQkq - 0 2",
+			output:   "info depth 12 multipv 1 score mate 1 nodes 900 pv d8h4\nbestmove d8h4\n",
+			wantEval: -999,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine, _ := newFakeEngine(tt.output, models.EngineSettings{MultiPV: 1})
+			result, err := engine.AnalyzePosition(context.Background(), tt.fen, models.EngineSettings{Depth: 12, MultiPV: 1})
+			if err != nil {
+				t.Fatalf("AnalyzePosition() error = %v", err)
+			}
+			if result.Evaluation != tt.wantEval || result.LineEvaluations[0] != tt.wantEval || result.Lines[0].Evaluation != tt.wantEval {
+				t.Errorf("Expected every evaluation to be %v for White, got %+v", tt.wantEval, result)
+			}
+			if result.ScoreBound != tt.wantBound {
+				t.Errorf("ScoreBound = %q, want %q", result.ScoreBound, tt.wantBound)
+			}
+		})
+	}
+}
+
+func TestStockfishEngine_AnalyzePositionWDL(t *testing.T) {
+	output := `info depth 10 seldepth 12 multipv 1 score cp 40 wdl 120 850 30 nodes 900 pv e7e5
+info depth 10 seldepth 12 multipv 2 score cp -90 wdl 10 700 290 nodes 900 pv f7f6
+bestmove e7e5
+`
+	engine, _ := newFakeEngine(output, models.EngineSettings{MultiPV: 2})
+
+	fen := "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1"
+	result, err := engine.AnalyzePosition(context.Background(), fen, models.EngineSettings{Depth: 10, MultiPV: 2})
+	if err != nil {
+		t.Fatalf("AnalyzePosition() error = %v", err)
+	}
+
+	// Black is to move, so the odds are reported from White's point of view
+	if result.WDL == nil || *result.WDL != (models.WDL{Win: 30, Draw: 850, Loss: 120}) {
+		t.Errorf("WDL = %+v, want 30/850/120", result.WDL)
+	}
+	if len(result.Lines) != 2 || result.Lines[1].WDL == nil || *result.Lines[1].WDL != (models.WDL{Win: 290, Draw: 700, Loss: 10}) {
+		t.Errorf("Lines = %+v, want the second line's odds flipped", result.Lines)
+	}
+}
+
+func TestStockfishEngine_ShowWDL(t *testing.T) {
+	output := `id name Stockfish 16
+option name UCI_ShowWDL type check default false
+uciok
+`
+	engine, stdin := newFakeEngine(output, models.EngineSettings{Threads: 1, HashSize: 16})
+	if err := engine.readUCIHeader(); err != nil {
+		t.Fatalf("readUCIHeader() error = %v", err)
+	}
+	if err := engine.configureEngine(); err != nil {
+		t.Fatalf("configureEngine() error = %v", err)
+	}
+	if !strings.Contains(stdin.String(), "setoption name UCI_ShowWDL value true") {
+		t.Errorf("Expected UCI_ShowWDL to be enabled, sent %q", stdin.String())
+	}
+
+	// Engines without the option aren't sent it
+	engine, stdin = newFakeEngine("id name Old Engine\nuciok\n", models.EngineSettings{Threads: 1, HashSize: 16})
+	engine.readUCIHeader()
+	engine.configureEngine()
+	if strings.Contains(stdin.String(), "UCI_ShowWDL") {
+		t.Errorf("Expected UCI_ShowWDL not to be sent, sent %q", stdin.String())
+	}
+}
+
+func TestStockfishEngine_SearchMate(t *testing.T) {
+	output := `info depth 1 seldepth 1 score cp 150 nodes 20 pv d1h5
+info depth 3 seldepth 3 score mate 2 nodes 800 pv d1h5 g7g6 h5g6
+bestmove d1h5
+`
+	engine, stdin := newFakeEngine(output, models.EngineSettings{MultiPV: 1})
+
+	fen := "rnbqkbnr/ppppp2p/8/5pp1/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 0 3"
+	result, mateIn, err := engine.SearchMate(context.Background(), fen, 2, models.EngineSettings{TimeLimit: 1000, MultiPV: 1})
+	if err != nil {
+		t.Fatalf("SearchMate() error = %v", err)
+	}
+	if !strings.Contains(stdin.String(), "go mate 2 movetime 1000") {
+		t.Errorf("Expected a mate search to be sent, got %q", stdin.String())
+	}
+	if mateIn != 2 || result.BestMove != "d1h5" || result.Depth != 3 {
+		t.Errorf("SearchMate() = %+v, mate in %d", result, mateIn)
+	}
+}
+
+func TestMateDistance(t *testing.T) {
+	tests := []struct {
+		evaluation float64
+		want       int
+	}{
+		{997, 3},
+		{-998, -2},
+		{4.5, 0},
+	}
+
+	for _, tt := range tests {
+		if got := mateDistance(tt.evaluation); got != tt.want {
+			t.Errorf("mateDistance(%v) = %d, want %d", tt.evaluation, got, tt.want)
+		}
+	}
+}
+
+func TestStockfishEngine_AnalyzePositionRejectsImpossibleFEN(t *testing.T) {
+	engine, stdin := newFakeEngine("", models.EngineSettings{MultiPV: 1})
+
+	for _, fen := range []string{
+		"startpos",
+		"8/8/8/8/8/8/8/4K3 w - - 0 1",     // Black king missing
+		"4k3/4R3/8/8/8/8/8/4K3 w - - 0 1", // Black in check with White to move
+		"4k2P/8/8/8/8/8/8/4K3 w - - 0 1",  // Pawn on the last rank
+	} {
+		var validation *errors.ValidationError
+		if _, err := engine.AnalyzePosition(context.Background(), fen, models.EngineSettings{Depth: 10, MultiPV: 1}); !errors.As(err, &validation) {
+			t.Errorf("AnalyzePosition(%q) error = %v, want a validation error", fen, err)
+		}
+	}
+	if stdin.Len() != 0 {
+		t.Errorf("Expected nothing to be sent to the engine, got %q", stdin.String())
+	}
+}
+
+func TestIsStable(t *testing.T) {
+	earlyStop := &EarlyStop{Window: 3, Tolerance: 0.1}
+
+	if isStable([]float64{0.3, 0.35}, earlyStop) {
+		t.Error("Expected history shorter than the window to be unstable")
+	}
+	if !isStable([]float64{1.0, 0.3, 0.35, 0.32}, earlyStop) {
+		t.Error("Expected the last three evaluations to be stable")
+	}
+	if isStable([]float64{0.3, 0.5, 0.35}, earlyStop) {
+		t.Error("Expected a spread above the tolerance to be unstable")
+	}
+}
+
+func TestStockfishEngine_ApplySettingsLimitStrength(t *testing.T) {
+	engine, stdin := newFakeEngine("readyok\nreadyok\n", models.EngineSettings{MultiPV: 1})
+
+	if err := engine.applySettings(models.EngineSettings{LimitStrength: true, Elo: ClampElo(900)}); err != nil {
+		t.Fatalf("applySettings() error = %v", err)
+	}
+	sent := stdin.String()
+	if !strings.Contains(sent, "setoption name UCI_LimitStrength value true") || !strings.Contains(sent, "setoption name UCI_Elo value 1320") {
+		t.Errorf("Expected strength limiting to be enabled at the minimum Elo, got %q", sent)
+	}
+
+	// A regular search afterwards switches strength limiting off again
+	stdin.Reset()
+	if err := engine.applySettings(models.EngineSettings{}); err != nil {
+		t.Fatalf("applySettings() error = %v", err)
+	}
+	if !strings.Contains(stdin.String(), "setoption name UCI_LimitStrength value false") {
+		t.Errorf("Expected strength limiting to be disabled, got %q", stdin.String())
+	}
+}
+
+func TestClampElo(t *testing.T) {
+	tests := map[int]int{800: MinElo, 1500: 1500, 3500: MaxElo}
+	for rating, want := range tests {
+		if got := ClampElo(rating); got != want {
+			t.Errorf("ClampElo(%d) = %d, want %d", rating, got, want)
+		}
+	}
+}
+
+func TestStockfishEngine_ApplySettingsVariant(t *testing.T) {
+	engine, stdin := newFakeEngine("readyok\nreadyok\nreadyok\n", models.EngineSettings{MultiPV: 1})
+
+	// Standard chess never selects a variant, which plain Stockfish doesn't know
+	if err := engine.applySettings(models.EngineSettings{Variant: "Standard", MultiPV: 2}); err != nil {
+		t.Fatalf("applySettings() error = %v", err)
+	}
+	if sent := stdin.String(); strings.Contains(sent, "UCI_Variant") {
+		t.Errorf("Expected no variant for standard chess, got %q", sent)
+	}
+
+	stdin.Reset()
+	if err := engine.applySettings(models.EngineSettings{Variant: "King of the Hill"}); err != nil {
+		t.Fatalf("applySettings() error = %v", err)
+	}
+	if sent := stdin.String(); !strings.Contains(sent, "setoption name UCI_Variant value kingofthehill") {
+		t.Errorf("Expected the variant to be selected, got %q", sent)
+	}
+
+	stdin.Reset()
+	if err := engine.applySettings(models.EngineSettings{}); err != nil {
+		t.Fatalf("applySettings() error = %v", err)
+	}
+	if sent := stdin.String(); !strings.Contains(sent, "setoption name UCI_Variant value chess") {
+		t.Errorf("Expected the engine to be switched back to standard chess, got %q", sent)
+	}
+}
+
+func TestIsStandardVariant(t *testing.T) {
+	for variant, want := range map[string]bool{"": true, "Standard": true, "chess960": false, "crazyhouse": false, "kingofthehill": false} {
+		if got := IsStandardVariant(variant); got != want {
+			t.Errorf("IsStandardVariant(%q) = %v, want %v", variant, got, want)
+		}
+	}
+}
+
+func TestIsSupportedVariant(t *testing.T) {
+	tests := map[string]bool{
+		"":                 true,
+		"chess":            true,
+		"King of the Hill": true,
+		"kingofthehill":    true,
+		"Racing Kings":     true,
+		"chess960":         false,
+		"crazyhouse":       false,
+		"Three-check":      false,
+		"bughouse":         false,
+	}
+	for variant, want := range tests {
+		if got := IsSupportedVariant(variant); got != want {
+			t.Errorf("IsSupportedVariant(%q) = %v, want %v", variant, got, want)
+		}
+	}
+}
+
+func TestStockfishEngine_AnalyzePositionTracesSearch(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+
+	output := `info depth 12 seldepth 16 multipv 1 score cp 35 nodes 48000 time 120 pv e2e4
+bestmove e2e4
+`
+	engine, _ := newFakeEngine(output, models.EngineSettings{MultiPV: 1})
+
+	fen := "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+	if _, err := engine.AnalyzePosition(context.Background(), fen, models.EngineSettings{Depth: 12, MultiPV: 1}); err != nil {
+		t.Fatalf("AnalyzePosition() error = %v", err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 || spans[0].Name() != "engine.search" {
+		t.Fatalf("Expected one engine.search span, got %d", len(spans))
+	}
+
+	attributes := make(map[string]interface{})
+	for _, kv := range spans[0].Attributes() {
+		attributes[string(kv.Key)] = kv.Value.AsInterface()
+	}
+	if attributes["engine.depth"] != int64(12) || attributes["engine.nodes"] != int64(48000) ||
+		attributes["engine.fen_hash"] != fenHash(fen) || attributes["engine.command"] != "go depth 12" {
+		t.Errorf("Unexpected span attributes: %v", attributes)
+	}
+	if _, ok := attributes["engine.elapsed_ms"]; !ok {
+		t.Error("Expected the elapsed time to be recorded")
+	}
+}
+
+func TestStockfishEngine_ExtendPositionBoundsTime(t *testing.T) {
+	output := `info depth 18 seldepth 24 multipv 1 score cp 120 nodes 90000 pv g1f3
+bestmove g1f3
+`
+	engine, stdin := newFakeEngine(output, models.EngineSettings{MultiPV: 1})
+
+	result, err := engine.ExtendPosition(context.Background(), "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1", models.EngineSettings{Depth: 20}, 5000)
+	if err != nil {
+		t.Fatalf("ExtendPosition() error = %v", err)
+	}
+	if sent := stdin.String(); !strings.Contains(sent, "go depth 20 movetime 5000") {
+		t.Errorf("Expected a depth search bounded in time, got %q", sent)
+	}
+	if result.Depth != 18 {
+		t.Errorf("Depth = %v, want the depth reached before the time ran out", result.Depth)
+	}
+}
@@ -0,0 +1,51 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer creates a span for every UCI go/stop cycle. It is a no-op until a tracer provider is installed.
+var tracer = otel.Tracer("github.com/pedrampdd/ChessAnalyser/internal/engine")
+
+// startSearchSpan starts the span of one search. Positions are identified by a hash of the FEN,
+// so traces can group slow positions without carrying full game positions.
+func startSearchSpan(ctx context.Context, name, fen, command string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, trace.WithAttributes(
+		attribute.String("engine.fen_hash", fenHash(fen)),
+		attribute.String("engine.command", command),
+	))
+}
+
+// endSearchSpan records the outcome of a search and ends its span
+func endSearchSpan(span trace.Span, started time.Time, result *models.AnalysisResult, err error) {
+	span.SetAttributes(attribute.Int64("engine.elapsed_ms", time.Since(started).Milliseconds()))
+	if result != nil {
+		span.SetAttributes(
+			attribute.Int("engine.depth", result.Depth),
+			attribute.Int64("engine.nodes", result.Nodes),
+			attribute.Int64("engine.search_time_ms", result.Time),
+		)
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// fenHash returns a short stable hash of a position
+func fenHash(fen string) string {
+	h := fnv.New64a()
+	h.Write([]byte(fen))
+	return fmt.Sprintf("%016x", h.Sum64())
+}
@@ -0,0 +1,135 @@
+package engine
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+)
+
+// uciInfoKeywords are the tokens that start a field of an info line. The moves of pv, refutation
+// and currline fields run until the next keyword, so trailing fields some engines print after the
+// principal variation aren't taken for moves.
+var uciInfoKeywords = map[string]bool{
+	"depth": true, "seldepth": true, "time": true, "nodes": true, "pv": true, "multipv": true,
+	"score": true, "cp": true, "mate": true, "lowerbound": true, "upperbound": true, "wdl": true,
+	"currmove": true, "currmovenumber": true, "hashfull": true, "nps": true, "tbhits": true,
+	"sbhits": true, "cpuload": true, "string": true, "refutation": true, "currline": true,
+}
+
+// uciInfo is one parsed info line of engine output
+type uciInfo struct {
+	depth    int
+	multiPV  int // 0 when the engine didn't say
+	nodes    int64
+	time     int64
+	hashFull int
+	hasScore bool
+	mate     bool    // The score is a mate distance rather than centipawns
+	score    float64 // Centipawns, or moves to mate (negative when the side to move is mated)
+	bound    string  // "lowerbound" or "upperbound" when the score is only a bound on the real one
+	wdl      *models.WDL
+	pv       []string
+	currMove string // Set on progress lines reporting the root move being searched
+}
+
+// parseUCIInfo parses an info line, tolerating fields in any order, keyword case and unknown
+// tokens. It reports false for other lines and for "info string" messages, which are free text.
+func parseUCIInfo(line string) (uciInfo, bool) {
+	var info uciInfo
+	fields := strings.Fields(line)
+	if len(fields) < 2 || !strings.EqualFold(fields[0], "info") || strings.EqualFold(fields[1], "string") {
+		return info, false
+	}
+
+	i := 1
+	next := func() string {
+		if i+1 >= len(fields) {
+			return ""
+		}
+		i++
+		return fields[i]
+	}
+	nextInt := func() int64 {
+		value, _ := strconv.ParseInt(next(), 10, 64)
+		return value
+	}
+	moves := func() []string {
+		var list []string
+		for i+1 < len(fields) && !uciInfoKeywords[strings.ToLower(fields[i+1])] {
+			i++
+			list = append(list, fields[i])
+		}
+		return list
+	}
+
+	for ; i < len(fields); i++ {
+		switch strings.ToLower(fields[i]) {
+		case "depth":
+			info.depth = int(nextInt())
+		case "multipv":
+			info.multiPV = int(nextInt())
+		case "nodes":
+			info.nodes = nextInt()
+		case "time":
+			info.time = nextInt()
+		case "hashfull":
+			info.hashFull = int(nextInt())
+		case "score":
+			// Some engines print a fractional centipawn score
+			kind := strings.ToLower(next())
+			if value, err := strconv.ParseFloat(next(), 64); err == nil && (kind == "cp" || kind == "mate") {
+				info.hasScore = true
+				info.mate = kind == "mate"
+				info.score = value
+			}
+		case "lowerbound", "upperbound":
+			info.bound = strings.ToLower(fields[i])
+		case "wdl":
+			win, draw, loss := nextInt(), nextInt(), nextInt()
+			info.wdl = &models.WDL{Win: int(win), Draw: int(draw), Loss: int(loss)}
+		case "currmove":
+			info.currMove = next()
+		case "pv":
+			info.pv = moves()
+		case "refutation", "currline":
+			moves()
+		case "string":
+			// The rest of the line is a message
+			return info, true
+		case "seldepth", "currmovenumber", "nps", "tbhits", "sbhits", "cpuload":
+			next()
+		}
+	}
+	return info, true
+}
+
+// exactMainLine reports whether the line carries the exact score of the first principal variation
+func (info uciInfo) exactMainLine() bool {
+	return info.hasScore && info.bound == "" && info.multiPV <= 1
+}
+
+// evaluation returns the line's score in normalized pawns, with mate scores mapped to ±1000
+func (info uciInfo) evaluation(scale EvalScale) float64 {
+	if !info.mate {
+		return scale.normalize(info.score)
+	}
+	if info.score > 0 {
+		return 1000.0 - info.score
+	}
+	// "mate 0" means the side to move is already mated
+	return -1000.0 - info.score
+}
+
+// parseBestMove returns the move of a bestmove line, empty when the engine has none to play,
+// and reports false for other lines
+func parseBestMove(line string) (string, bool) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 || !strings.EqualFold(fields[0], "bestmove") {
+		return "", false
+	}
+	if len(fields) < 2 || fields[1] == "(none)" || fields[1] == "0000" {
+		return "", true
+	}
+	return fields[1], true
+}
@@ -0,0 +1,189 @@
+package engine
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+)
+
+// Transcripts captured from different engines, trimmed to a few lines each
+func TestStockfishEngine_AnalyzePositionTranscripts(t *testing.T) {
+	const (
+		startFEN   = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+		afterE4FEN = "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1"
+		matedFEN   = "rnb1kbnr/pppp1ppp/8/4p3/6Pq/5P2/PPPPP2P/RNBQKBNR w KQkq - 1 3"
+	)
+
+	tests := []struct {
+		name      string
+		fen       string
+		multiPV   int
+		output    string
+		wantEval  float64
+		wantDepth int
+		wantBest  string
+		wantPV    []string
+		wantNodes int64
+		wantLines int
+		wantBound string
+	}{
+		{
+			name: "Stockfish with aspiration bounds and currmove lines",
+			fen:  startFEN,
+			output: `info string NNUE evaluation using nn-5af11540bbfe.nnue enabled
+info depth 1 seldepth 1 multipv 1 score cp 18 nodes 20 nps 20000 hashfull 0 tbhits 0 time 1 pv e2e4
+info depth 12 seldepth 16 multipv 1 score cp 31 nodes 16000 nps 800000 hashfull 5 tbhits 0 time 20 pv e2e4 e7e5 g1f3
+info depth 13 seldepth 18 multipv 1 score cp 52 lowerbound nodes 19000 nps 791666 hashfull 6 tbhits 0 time 24 pv d2d4
+info depth 13 currmove g1f3 currmovenumber 2
+info depth 13 seldepth 18 multipv 1 score cp 35 nodes 24000 nps 800000 hashfull 7 tbhits 0 time 30 pv e2e4 c7c5
+info depth 14 seldepth 19 multipv 1 score cp 10 upperbound nodes 30000 nps 810810 hashfull 8 tbhits 0 time 37 pv e2e4
+bestmove e2e4 ponder c7c5
+`,
+			wantEval:  0.35,
+			wantDepth: 13,
+			wantBest:  "e2e4",
+			wantPV:    []string{"e2e4", "c7c5"},
+			wantNodes: 30000,
+		},
+		{
+			name:    "Lc0 with multipv after the score and verbose move stats",
+			fen:     startFEN,
+			multiPV: 2,
+			output: `info depth 6 seldepth 14 time 1203 nodes 804 score cp 22 wdl 95 830 75 hashfull 2 nps 668 tbhits 0 multipv 1 pv d2d4 g8f6 c2c4
+info depth 6 seldepth 13 time 1203 nodes 804 score cp 9 wdl 80 830 90 hashfull 2 nps 668 tbhits 0 multipv 2 pv e2e4 e7e5
+info string d2d4  (293 ) N:     412 (+ 0) (P: 21.17%) (WL:  0.03) (D: 0.830) (M: 122.0) (Q:  0.02) (U: 0.02) (S:  0.04) (V:  0.0600)
+bestmove d2d4
+`,
+			wantEval:  0.22,
+			wantDepth: 6,
+			wantBest:  "d2d4",
+			wantPV:    []string{"d2d4", "g8f6", "c2c4"},
+			wantNodes: 804,
+			wantLines: 2,
+		},
+		{
+			name: "mixed-case keywords with seldepth first and a negative mate",
+			fen:  afterE4FEN,
+			output: `Info SelDepth 30 Depth 18 Score Mate -3 Nodes 5000 PV e7e5 d1h5
+BestMove e7e5
+`,
+			wantEval:  997, // Black to move gets mated, so White mates
+			wantDepth: 18,
+			wantBest:  "e7e5",
+			wantPV:    []string{"e7e5", "d1h5"},
+			wantNodes: 5000,
+		},
+		{
+			name: "fractional score and fields after the principal variation",
+			fen:  startFEN,
+			output: `info depth 8 score cp 40 time 30 nodes 700 pv d2d4
+info depth 9 score cp 12.5 time 50 nodes 900 pv g1f3 d7d5 string book move
+bestmove g1f3
+`,
+			wantEval:  0.125,
+			wantDepth: 9,
+			wantBest:  "g1f3",
+			wantPV:    []string{"g1f3", "d7d5"},
+			wantNodes: 900,
+		},
+		{
+			name: "a later zero score replaces the previous depth's",
+			fen:  startFEN,
+			output: `info depth 10 score cp 40 nodes 1000 pv e2e4
+info depth 11 score cp 0 nodes 2000 pv d2d4
+bestmove d2d4
+`,
+			wantEval:  0,
+			wantDepth: 11,
+			wantBest:  "d2d4",
+			wantPV:    []string{"d2d4"},
+			wantNodes: 2000,
+		},
+		{
+			name: "a fail-high after the last exact score is ignored",
+			fen:  startFEN,
+			output: `info depth 1 seldepth 1 multipv 1 score cp 18 nodes 20 time 1 pv e2e4
+info depth 2 seldepth 2 multipv 1 score cp 60 lowerbound nodes 45 time 1 pv d2d4
+bestmove d2d4
+`,
+			wantEval:  0.18,
+			wantDepth: 1,
+			wantBest:  "d2d4",
+			wantPV:    []string{"e2e4"},
+			wantNodes: 45,
+		},
+		{
+			name: "a search cut short with only a bound is flagged",
+			fen:  afterE4FEN,
+			output: `info depth 1 seldepth 1 multipv 1 score cp 45 lowerbound nodes 30 time 1 pv e7e5
+bestmove e7e5
+`,
+			wantEval:  -0.45,
+			wantDepth: 1,
+			wantBest:  "e7e5",
+			wantPV:    []string{"e7e5"},
+			wantNodes: 30,
+			wantBound: models.ScoreUpperBound, // At least +0.45 for Black is at most -0.45 for White
+		},
+		{
+			name: "Stockfish in a mated position",
+			fen:  matedFEN,
+			output: `info depth 0 score mate 0
+bestmove (none)
+`,
+			wantEval: -1000,
+			wantBest: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			multiPV := tt.multiPV
+			if multiPV == 0 {
+				multiPV = 1
+			}
+			engine, _ := newFakeEngine(tt.output, models.EngineSettings{MultiPV: multiPV})
+
+			result, err := engine.AnalyzePosition(context.Background(), tt.fen, models.EngineSettings{Depth: 20, MultiPV: multiPV})
+			if err != nil {
+				t.Fatalf("AnalyzePosition() error = %v", err)
+			}
+			if math.Abs(result.Evaluation-tt.wantEval) > 1e-9 {
+				t.Errorf("Evaluation = %v, want %v", result.Evaluation, tt.wantEval)
+			}
+			if result.Depth != tt.wantDepth || result.BestMove != tt.wantBest || result.Nodes != tt.wantNodes {
+				t.Errorf("Depth, BestMove, Nodes = %d, %q, %d, want %d, %q, %d",
+					result.Depth, result.BestMove, result.Nodes, tt.wantDepth, tt.wantBest, tt.wantNodes)
+			}
+			if !reflect.DeepEqual(result.PrincipalVariation, tt.wantPV) {
+				t.Errorf("PrincipalVariation = %v, want %v", result.PrincipalVariation, tt.wantPV)
+			}
+			if result.ScoreBound != tt.wantBound {
+				t.Errorf("ScoreBound = %q, want %q", result.ScoreBound, tt.wantBound)
+			}
+			if tt.wantLines > 0 && len(result.Lines) != tt.wantLines {
+				t.Errorf("Lines = %+v, want %d lines", result.Lines, tt.wantLines)
+			}
+		})
+	}
+}
+
+func TestParseUCIInfo(t *testing.T) {
+	if _, ok := parseUCIInfo("info string Available processors: 0-7"); ok {
+		t.Error("Expected info string lines to be skipped")
+	}
+	if _, ok := parseUCIInfo("readyok"); ok {
+		t.Error("Expected non-info lines to be skipped")
+	}
+
+	info, ok := parseUCIInfo("info depth 20 multipv 2 score cp -15 upperbound wdl 10 600 390 refutation e2e4 e7e5 nodes 10 pv d2d4")
+	if !ok || info.multiPV != 2 || info.bound != "upperbound" || info.score != -15 || info.nodes != 10 || len(info.pv) != 1 {
+		t.Errorf("parseUCIInfo() = %+v", info)
+	}
+	if info.exactMainLine() {
+		t.Error("A bound on the second line isn't the main line's exact score")
+	}
+}
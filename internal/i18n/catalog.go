@@ -0,0 +1,61 @@
+package i18n
+
+// catalog holds the message formats of every supported language, keyed by language code and message key
+var catalog = map[string]map[string]string{
+	"en": {
+		RecommendBlunders:       "Consider spending more time on tactical calculations to reduce blunders",
+		RecommendMistakes:       "Focus on positional understanding to minimize mistakes",
+		RecommendAccuracy:       "Overall game accuracy could be improved with more careful move selection",
+		RecommendOpening:        "Study opening theory to improve early game play",
+		RecommendFindableMisses: "%d opponent errors went unpunished although players at this level usually find the refutation; practice tactics that follow opponent mistakes",
+		RecommendMisses:         "Look for tactical chances after your opponent's mistakes instead of continuing with your plan",
+
+		MomentMissedWinBest: "%s let a winning position slip (%s was best)",
+		MomentMissedWin:     "%s let a winning position slip",
+		MomentTactic:        "%s is a nice tactic, gaining %.1f pawns",
+		MomentBestMove:      "%s was the best move in the position",
+		MomentTurningPoint:  "The game turned after %s: the evaluation swung from %+.1f to %+.1f",
+	},
+	"es": {
+		RecommendBlunders:       "Dedica más tiempo al cálculo táctico para reducir los errores graves",
+		RecommendMistakes:       "Céntrate en la comprensión posicional para minimizar los errores",
+		RecommendAccuracy:       "La precisión general de la partida mejoraría eligiendo las jugadas con más cuidado",
+		RecommendOpening:        "Estudia teoría de aperturas para mejorar el comienzo de la partida",
+		RecommendFindableMisses: "%d errores del rival quedaron sin castigo aunque los jugadores de este nivel suelen encontrar la refutación; practica la táctica que sigue a los errores del rival",
+		RecommendMisses:         "Busca oportunidades tácticas tras los errores de tu rival en lugar de seguir con tu plan",
+
+		MomentMissedWinBest: "%s dejó escapar una posición ganadora (lo mejor era %s)",
+		MomentMissedWin:     "%s dejó escapar una posición ganadora",
+		MomentTactic:        "%s es una bonita táctica que gana %.1f peones",
+		MomentBestMove:      "%s fue la mejor jugada de la posición",
+		MomentTurningPoint:  "La partida cambió tras %s: la evaluación pasó de %+.1f a %+.1f",
+	},
+	"de": {
+		RecommendBlunders:       "Nimm dir mehr Zeit für taktische Berechnungen, um grobe Fehler zu vermeiden",
+		RecommendMistakes:       "Konzentriere dich auf das Positionsverständnis, um Fehler zu minimieren",
+		RecommendAccuracy:       "Die Genauigkeit der Partie ließe sich durch eine sorgfältigere Zugwahl verbessern",
+		RecommendOpening:        "Studiere Eröffnungstheorie, um das frühe Spiel zu verbessern",
+		RecommendFindableMisses: "%d Fehler des Gegners blieben ungestraft, obwohl Spieler dieser Stärke die Widerlegung meist finden; übe Taktiken, die auf gegnerische Fehler folgen",
+		RecommendMisses:         "Suche nach Fehlern deines Gegners nach taktischen Chancen, statt einfach deinen Plan fortzusetzen",
+
+		MomentMissedWinBest: "%s ließ eine Gewinnstellung entgleiten (%s war am besten)",
+		MomentMissedWin:     "%s ließ eine Gewinnstellung entgleiten",
+		MomentTactic:        "%s ist eine schöne Taktik, die %.1f Bauern gewinnt",
+		MomentBestMove:      "%s war der beste Zug in der Stellung",
+		MomentTurningPoint:  "Nach %s kippte die Partie: Die Bewertung ging von %+.1f auf %+.1f",
+	},
+	"fr": {
+		RecommendBlunders:       "Prenez plus de temps pour le calcul tactique afin de réduire les gaffes",
+		RecommendMistakes:       "Concentrez-vous sur la compréhension positionnelle pour limiter les erreurs",
+		RecommendAccuracy:       "La précision de la partie pourrait être améliorée en choisissant les coups avec plus de soin",
+		RecommendOpening:        "Étudiez la théorie des ouvertures pour améliorer le début de partie",
+		RecommendFindableMisses: "%d erreurs de l'adversaire sont restées impunies alors que les joueurs de ce niveau trouvent généralement la réfutation ; entraînez-vous aux tactiques qui suivent les erreurs adverses",
+		RecommendMisses:         "Cherchez des occasions tactiques après les erreurs de votre adversaire au lieu de poursuivre votre plan",
+
+		MomentMissedWinBest: "%s a laissé échapper une position gagnante (%s était le meilleur coup)",
+		MomentMissedWin:     "%s a laissé échapper une position gagnante",
+		MomentTactic:        "%s est une jolie combinaison qui gagne %.1f pions",
+		MomentBestMove:      "%s était le meilleur coup de la position",
+		MomentTurningPoint:  "La partie a basculé après %s : l'évaluation est passée de %+.1f à %+.1f",
+	},
+}
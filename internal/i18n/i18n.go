@@ -0,0 +1,82 @@
+// Package i18n translates the text generated by the analyzer, such as recommendations and key moment descriptions.
+package i18n
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DefaultLanguage is used when a request doesn't ask for a language
+const DefaultLanguage = "en"
+
+// Message keys
+const (
+	RecommendBlunders       = "recommend.blunders"
+	RecommendMistakes       = "recommend.mistakes"
+	RecommendAccuracy       = "recommend.accuracy"
+	RecommendOpening        = "recommend.opening"
+	RecommendFindableMisses = "recommend.findable_misses" // Args: number of misses
+	RecommendMisses         = "recommend.misses"
+
+	MomentMissedWinBest = "moment.missed_win_best" // Args: move label, best move
+	MomentMissedWin     = "moment.missed_win"      // Args: move label
+	MomentTactic        = "moment.tactic"          // Args: move label, pawns gained
+	MomentBestMove      = "moment.best_move"       // Args: move label
+	MomentTurningPoint  = "moment.turning_point"   // Args: move label, evaluation before, evaluation after
+)
+
+// Normalize maps a language tag such as "de-AT" or "FR" to a supported language code.
+// An empty tag selects the default language.
+func Normalize(tag string) (string, bool) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if tag == "" {
+		return DefaultLanguage, true
+	}
+	if _, ok := catalog[tag]; ok {
+		return tag, true
+	}
+	if base, _, found := strings.Cut(strings.ReplaceAll(tag, "_", "-"), "-"); found {
+		if _, ok := catalog[base]; ok {
+			return base, true
+		}
+	}
+	return "", false
+}
+
+// FromAcceptLanguage returns the first supported language of an Accept-Language header, or the default language.
+// Quality values are ignored; browsers already list languages in order of preference.
+func FromAcceptLanguage(header string) string {
+	for _, part := range strings.Split(header, ",") {
+		tag, _, _ := strings.Cut(part, ";")
+		if tag = strings.TrimSpace(tag); tag == "" || tag == "*" {
+			continue
+		}
+		if language, ok := Normalize(tag); ok {
+			return language
+		}
+	}
+	return DefaultLanguage
+}
+
+// Languages returns the supported language codes, sorted
+func Languages() []string {
+	languages := make([]string, 0, len(catalog))
+	for language := range catalog {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// Translate formats a message in the given language, falling back to English for unknown languages or keys
+func Translate(language, key string, args ...any) string {
+	format, ok := catalog[language][key]
+	if !ok {
+		format, ok = catalog[DefaultLanguage][key]
+	}
+	if !ok {
+		return key
+	}
+	return fmt.Sprintf(format, args...)
+}
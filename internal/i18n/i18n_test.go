@@ -0,0 +1,115 @@
+package i18n
+
+import (
+	"regexp"
+	"testing"
+)
+
+// verbRegex matches fmt verbs, ignoring flags and precision
+var verbRegex = regexp.MustCompile(`%[+#0 -]*[0-9.]*[a-zA-Z]`)
+
+func TestCatalogComplete(t *testing.T) {
+	for language, messages := range catalog {
+		if len(messages) != len(catalog[DefaultLanguage]) {
+			t.Errorf("%s has %d messages, want %d", language, len(messages), len(catalog[DefaultLanguage]))
+		}
+		for key, english := range catalog[DefaultLanguage] {
+			format, ok := messages[key]
+			if !ok {
+				t.Errorf("%s is missing %s", language, key)
+				continue
+			}
+			want, got := verbRegex.FindAllString(english, -1), verbRegex.FindAllString(format, -1)
+			if len(want) != len(got) {
+				t.Errorf("%s %s has verbs %v, want %v", language, key, got, want)
+				continue
+			}
+			for i := range want {
+				if want[i] != got[i] {
+					t.Errorf("%s %s has verbs %v, want %v", language, key, got, want)
+					break
+				}
+			}
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want string
+		ok   bool
+	}{
+		{"", DefaultLanguage, true},
+		{"de", "de", true},
+		{"FR", "fr", true},
+		{"es-MX", "es", true},
+		{"de_AT", "de", true},
+		{"ja", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := Normalize(tt.tag)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Normalize(%q) = %q, %v, want %q, %v", tt.tag, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestFromAcceptLanguage(t *testing.T) {
+	if got := FromAcceptLanguage("ja-JP, fr-CH;q=0.9, en;q=0.8"); got != "fr" {
+		t.Errorf("FromAcceptLanguage() = %q, want fr", got)
+	}
+	if got := FromAcceptLanguage("ja, *;q=0.5"); got != DefaultLanguage {
+		t.Errorf("FromAcceptLanguage() = %q, want %s", got, DefaultLanguage)
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	if got := Translate("de", MomentBestMove, "12. Nf3"); got != "12. Nf3 war der beste Zug in der Stellung" {
+		t.Errorf("Translate() = %q", got)
+	}
+	if got := Translate("xx", RecommendOpening); got != catalog[DefaultLanguage][RecommendOpening] {
+		t.Errorf("Expected unknown languages to fall back to English, got %q", got)
+	}
+}
+
+func TestFormatSAN(t *testing.T) {
+	tests := []struct {
+		notation string
+		san      string
+		want     string
+	}{
+		{"", "Nxe5+", "Nxe5+"},
+		{"en", "Nxe5+", "Nxe5+"},
+		{"figurine", "Qxf7#", "♕xf7#"},
+		{"figurine", "exd8=Q+", "exd8=♕+"},
+		{"de", "Nbd7", "Sbd7"},
+		{"de-AT", "Bxc6", "Lxc6"},
+		{"FR", "Kxe2", "Rxe2"},
+		{"es", "Rad1", "Tad1"},
+		{"es", "O-O-O", "O-O-O"},
+		{"de", "e8=N", "e8=S"},
+	}
+
+	for _, tt := range tests {
+		notation, ok := NormalizeNotation(tt.notation)
+		if !ok {
+			t.Errorf("NormalizeNotation(%q) is unsupported", tt.notation)
+			continue
+		}
+		if got := FormatSAN(notation, tt.san); got != tt.want {
+			t.Errorf("FormatSAN(%q, %q) = %q, want %q", notation, tt.san, got, tt.want)
+		}
+	}
+
+	if _, ok := NormalizeNotation("ja"); ok {
+		t.Error("Expected ja to be unsupported")
+	}
+	// Every language has its piece letters
+	for language := range catalog {
+		if letters := pieceLetters[language]; len(letters) != len(pieceLetters[DefaultLanguage]) {
+			t.Errorf("%s piece letters = %q", language, letters)
+		}
+	}
+}
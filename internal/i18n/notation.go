@@ -0,0 +1,90 @@
+package i18n
+
+import (
+	"sort"
+	"strings"
+)
+
+// Display notations for moves, besides the languages of pieceLetters
+const (
+	NotationSAN      = "san"      // Standard algebraic notation with English piece letters
+	NotationFigurine = "figurine" // Unicode piece figurines instead of letters
+)
+
+// pieceLetters are the letters of the king, queen, rook, bishop and knight in each language
+var pieceLetters = map[string]string{
+	"en": "KQRBN",
+	"es": "RDTAC",
+	"de": "KDTLS",
+	"fr": "RDTFC",
+}
+
+// figurines replace the piece letters of SAN in figurine notation. The same figurines are used for
+// both sides, as in printed notation.
+var figurines = strings.NewReplacer("K", "♔", "Q", "♕", "R", "♖", "B", "♗", "N", "♘")
+
+// NormalizeNotation maps a requested notation to a supported one: san, figurine, or a language
+// code for its piece letters, given as a language tag such as "de" or "de-AT". An empty notation
+// and English select san.
+func NormalizeNotation(notation string) (string, bool) {
+	notation = strings.ToLower(strings.TrimSpace(notation))
+	switch notation {
+	case "", NotationSAN:
+		return NotationSAN, true
+	case NotationFigurine:
+		return notation, true
+	}
+	language, ok := Normalize(notation)
+	if !ok {
+		return "", false
+	}
+	if language == DefaultLanguage {
+		return NotationSAN, true
+	}
+	return language, true
+}
+
+// Notations returns the supported notations, sorted
+func Notations() []string {
+	notations := []string{NotationSAN, NotationFigurine}
+	for language := range pieceLetters {
+		if language != DefaultLanguage {
+			notations = append(notations, language)
+		}
+	}
+	sort.Strings(notations)
+	return notations
+}
+
+// FormatSAN rewrites a move in SAN in a normalized notation. Squares, captures, checks and castling
+// are written the same in every notation; only the piece letters change.
+func FormatSAN(notation, san string) string {
+	switch notation {
+	case "", NotationSAN:
+		return san
+	case NotationFigurine:
+		return figurines.Replace(san)
+	}
+	letters, ok := pieceLetters[notation]
+	if !ok {
+		return san
+	}
+	return strings.Map(func(r rune) rune {
+		if i := strings.IndexRune(pieceLetters[DefaultLanguage], r); i >= 0 {
+			return rune(letters[i])
+		}
+		return r
+	}, san)
+}
+
+// FormatLine rewrites a line of moves in SAN in a normalized notation
+func FormatLine(notation string, line []string) []string {
+	if notation == "" || notation == NotationSAN || line == nil {
+		return line
+	}
+	formatted := make([]string, len(line))
+	for i, san := range line {
+		formatted[i] = FormatSAN(notation, san)
+	}
+	return formatted
+}
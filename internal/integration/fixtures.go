@@ -0,0 +1,133 @@
+package integration
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Hosts that recording fetches missing fixtures from, by the path prefix they are served under
+var upstreams = map[string]string{
+	"/pub":      "https://api.chess.com",
+	"/callback": "https://www.chess.com",
+}
+
+// FixtureServer replays recorded Chess.com responses. The published-data API is served under
+// /pub and the website's callback endpoints under /callback, so a client's BaseURL is
+// URL+"/pub" and its CallbackURL URL+"/callback".
+//
+// A request is answered with the file at its path below Dir with ".json" appended, or ".pgn"
+// for PGN downloads, e.g. testdata/pub/player/alice/games/2024/01.json for an archive. Usernames
+// are case-insensitive on Chess.com, so paths under /pub are looked up in lower case. Requests
+// without a fixture get Chess.com's 404 response, unless the server is recording.
+type FixtureServer struct {
+	*httptest.Server
+	Dir    string
+	Record bool // Fetch requests without a fixture from Chess.com and save the responses
+
+	mu       sync.Mutex
+	requests []string // Paths requested, in order
+}
+
+// NewFixtureServer starts a server replaying the fixtures in dir
+func NewFixtureServer(dir string) *FixtureServer {
+	f := &FixtureServer{Dir: dir}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.serve))
+	return f
+}
+
+// Requests returns the paths requested so far, in order
+func (f *FixtureServer) Requests() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.requests...)
+}
+
+// serve answers a request with its fixture, recording it first if needed
+func (f *FixtureServer) serve(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	f.requests = append(f.requests, r.URL.Path)
+	f.mu.Unlock()
+
+	file, contentType := f.fixturePath(r.URL.Path)
+	body, err := os.ReadFile(file)
+	if os.IsNotExist(err) && f.Record {
+		body, err = f.record(r.URL.Path, file)
+	}
+	if os.IsNotExist(err) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprintf(w, `{"code":0,"message":"Data provider not found for key \"%s\"."}`, r.URL.Path)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Archives are fetched conditionally, so fixtures carry a stable ETag
+	etag := fmt.Sprintf(`"%x"`, sha1.Sum(body))
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Write(body)
+}
+
+// fixturePath returns the file holding the response to a request path and its content type
+func (f *FixtureServer) fixturePath(urlPath string) (string, string) {
+	clean := path.Clean("/" + urlPath)
+	if strings.HasPrefix(clean, "/pub/") {
+		clean = strings.ToLower(clean)
+	}
+	if path.Base(clean) == "pgn" {
+		return filepath.Join(f.Dir, filepath.FromSlash(clean)+".pgn"), "application/x-chess-pgn"
+	}
+	return filepath.Join(f.Dir, filepath.FromSlash(clean)+".json"), "application/json"
+}
+
+// record fetches a response from Chess.com and saves it as the fixture at file. Responses other
+// than 200 aren't saved and are reported as a missing fixture.
+func (f *FixtureServer) record(urlPath, file string) ([]byte, error) {
+	var upstream string
+	for prefix, host := range upstreams {
+		if strings.HasPrefix(urlPath, prefix+"/") {
+			upstream = host + urlPath
+		}
+	}
+	if upstream == "" {
+		return nil, os.ErrNotExist
+	}
+
+	req, err := http.NewRequest(http.MethodGet, upstream, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "ChessAnalyzer/1.0 (fixture recording)")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, os.ErrNotExist
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return nil, err
+	}
+	return body, os.WriteFile(file, body, 0o644)
+}
@@ -0,0 +1,150 @@
+// Package integration runs the service end to end without network access or Stockfish: a
+// FixtureServer replays recorded Chess.com responses, and the test binary itself stands in for
+// the engine, answering UCI through RunMockEngine.
+//
+// A package using the harness hands its TestMain to Main:
+//
+//	func TestMain(m *testing.M) { integration.Main(m) }
+//
+// Fixtures are recorded by running the tests with CHESSANALYSER_RECORD_FIXTURES=1, which fetches
+// the requests without a fixture from Chess.com and saves them under testdata.
+package integration
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/client"
+	"github.com/pedrampdd/ChessAnalyser/internal/config"
+	"github.com/pedrampdd/ChessAnalyser/pkg/server"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Environment variables of the harness
+const (
+	mockEngineEnv = "CHESSANALYSER_MOCK_ENGINE"     // Set in engine processes started by the harness
+	recordEnv     = "CHESSANALYSER_RECORD_FIXTURES" // Record missing fixtures from Chess.com
+)
+
+// AdminKey is the admin API key of the harness' server
+const AdminKey = "harness-admin-key"
+
+// Main runs the tests of m, or the mock engine when the process was started as one
+func Main(m *testing.M) {
+	if os.Getenv(mockEngineEnv) != "" {
+		if err := RunMockEngine(os.Stdin, os.Stdout); err != nil {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// Harness is a fully wired server whose Chess.com client talks to a FixtureServer and whose
+// engines run the mock engine
+type Harness struct {
+	Fixtures *FixtureServer
+	Config   *config.Config
+	Services server.Services
+	Handler  http.Handler
+}
+
+// New starts a harness replaying the fixtures in testdata. Everything it starts is stopped when
+// the test ends. The calling package's TestMain must run Main.
+func New(t testing.TB) *Harness {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	fixtures := NewFixtureServer("testdata")
+	fixtures.Record = os.Getenv(recordEnv) != ""
+	t.Cleanup(fixtures.Close)
+
+	// Engines are started as the test binary, which runs the mock engine when it sees the variable
+	t.Setenv(mockEngineEnv, "1")
+	executable, err := os.Executable()
+	if err != nil {
+		t.Fatalf("Failed to locate the test binary: %v", err)
+	}
+
+	// Start from the defaults, and keep the environment from reaching anything outside the test
+	cfg := config.LoadConfig()
+	cfg.ChessAPI.BaseURL = fixtures.URL + "/pub"
+	cfg.ChessAPI.ProxyURL, cfg.ChessAPI.CABundle = "", ""
+	cfg.Stockfish = config.StockfishConfig{
+		ExecutablePath:    executable,
+		MaxEngines:        2,
+		MaxQueue:          32,
+		DefaultDepth:      12,
+		DefaultTimeLimit:  5000,
+		DefaultThreads:    1,
+		DefaultHashSize:   16,
+		DefaultSkillLevel: 20,
+	}
+	cfg.EnginePools = nil
+	cfg.Maia = config.MaiaConfig{}
+	cfg.Sync = config.SyncConfig{Interval: 30}
+	cfg.Analysis.OpeningCacheFile, cfg.Analysis.OpeningWarmupLines = "", 0
+	cfg.Analysis.BenchmarkFile = filepath.Join(t.TempDir(), "benchmark.json")
+	cfg.Import.Dir = t.TempDir()
+	cfg.Blob = config.BlobConfig{Backend: "local", Dir: t.TempDir(), URLExpiry: 15}
+	cfg.Sources.LichessURL, cfg.Sources.TWICURL, cfg.Sources.PGNDir = fixtures.URL+"/lichess", fixtures.URL+"/twic", ""
+	cfg.Tracing.Enabled = false
+	cfg.Server.AdminAPIKey = AdminKey
+
+	services, closeServices, err := server.NewServices(cfg)
+	if err != nil {
+		t.Fatalf("Failed to start the services: %v", err)
+	}
+	t.Cleanup(closeServices)
+
+	// Single games come from the website's callback endpoints, which the configuration can't move
+	chessAPI, err := client.NewChessComAPIWithOptions(client.ClientOptions{
+		BaseURL: cfg.ChessAPI.BaseURL,
+		Timeout: 10 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create the Chess.com client: %v", err)
+	}
+	chessAPI.CallbackURL = fixtures.URL + "/callback"
+	services.Game.SetChessAPI(chessAPI)
+
+	srv := server.NewServer(server.WithServices(services), server.WithRouterOptions(server.RouterOptionsFromConfig(cfg)))
+	return &Harness{Fixtures: fixtures, Config: cfg, Services: services, Handler: srv.Handler()}
+}
+
+// Do sends a request to the server and returns the recorded response. A non-nil body is sent
+// as JSON.
+func (h *Harness) Do(method, path string, body []byte) *httptest.ResponseRecorder {
+	return h.DoWithHeaders(method, path, body, nil)
+}
+
+// DoAdmin sends a request with the admin API key
+func (h *Harness) DoAdmin(method, path string, body []byte) *httptest.ResponseRecorder {
+	return h.DoWithHeaders(method, path, body, map[string]string{"X-API-Key": AdminKey})
+}
+
+// DoWithHeaders sends a request with extra headers and returns the recorded response
+func (h *Harness) DoWithHeaders(method, path string, body []byte, headers map[string]string) *httptest.ResponseRecorder {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	w := httptest.NewRecorder()
+	h.Handler.ServeHTTP(w, req)
+	return w
+}
@@ -0,0 +1,246 @@
+package integration
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/board"
+)
+
+// MockEngineName is the name the mock engine reports to "uci". It reads as a Stockfish release
+// with normalized scores.
+const MockEngineName = "Stockfish 16 (mock)"
+
+// mockDepth is the depth reported for searches not limited by depth
+const mockDepth = 10
+
+// pieceValues are the centipawn values the mock engine counts material with
+var pieceValues = map[board.PieceType]int{
+	board.Pawn: 100, board.Knight: 300, board.Bishop: 300, board.Rook: 500, board.Queen: 900,
+}
+
+// mockLine is a move with its score for the side to move: mate in plies when mate isn't 0
+// (negative when the side to move gets mated), centipawns otherwise
+type mockLine struct {
+	move string
+	cp   int
+	mate int
+}
+
+// rank orders lines from best to worst: mates soonest first, then material, then getting mated last
+func (l mockLine) rank() int {
+	switch {
+	case l.mate > 0:
+		return 1_000_000 - l.mate
+	case l.mate < 0:
+		return -1_000_000 - l.mate
+	}
+	return l.cp
+}
+
+// RunMockEngine speaks enough UCI on in and out to stand in for Stockfish in tests. It answers
+// searches instantly and deterministically: every legal move is scored by the material balance
+// after the opponent's best capture in reply, and mates in one are found. That's shallow, but
+// enough for a hung piece or a missed mate to come out as a blunder, and the same game always
+// analyzes the same way. It returns when in is exhausted or on "quit".
+func RunMockEngine(in io.Reader, out io.Writer) error {
+	w := bufio.NewWriter(out)
+	position := board.NewBoard()
+	multiPV := 1
+
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "uci":
+			fmt.Fprintf(w, "id name %s\n", MockEngineName)
+			fmt.Fprintln(w, "id author the ChessAnalyser developers")
+			fmt.Fprintln(w, "option name Threads type spin default 1 min 1 max 1024")
+			fmt.Fprintln(w, "option name Hash type spin default 16 min 1 max 33554432")
+			fmt.Fprintln(w, "option name MultiPV type spin default 1 min 1 max 500")
+			fmt.Fprintln(w, "option name Skill Level type spin default 20 min 0 max 20")
+			fmt.Fprintln(w, "uciok")
+		case "isready":
+			fmt.Fprintln(w, "readyok")
+		case "setoption":
+			// setoption name MultiPV value N
+			if len(fields) == 5 && strings.EqualFold(fields[2], "MultiPV") {
+				if n, err := strconv.Atoi(fields[4]); err == nil && n > 0 {
+					multiPV = n
+				}
+			}
+		case "position":
+			b, err := parsePosition(fields[1:])
+			if err != nil {
+				fmt.Fprintf(w, "info string %v\n", err)
+				continue
+			}
+			position = b
+		case "go":
+			search(w, position, searchDepth(fields[1:]), multiPV)
+		case "quit":
+			return w.Flush()
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// parsePosition sets up the board of a "position" command: "startpos" or "fen <fen>", optionally
+// followed by "moves" and UCI moves
+func parsePosition(args []string) (*board.Board, error) {
+	var b *board.Board
+	var rest []string
+	switch {
+	case len(args) > 0 && args[0] == "startpos":
+		b, rest = board.NewBoard(), args[1:]
+	case len(args) > 0 && args[0] == "fen":
+		end := 1
+		for end < len(args) && args[end] != "moves" {
+			end++
+		}
+		parsed, err := board.FromFEN(strings.Join(args[1:end], " "))
+		if err != nil {
+			return nil, err
+		}
+		b, rest = parsed, args[end:]
+	default:
+		return nil, fmt.Errorf("unsupported position command: %s", strings.Join(args, " "))
+	}
+
+	if len(rest) > 0 && rest[0] == "moves" {
+		for _, uci := range rest[1:] {
+			m, err := b.ParseUCI(uci)
+			if err != nil {
+				return nil, err
+			}
+			b.Apply(m)
+		}
+	}
+	return b, nil
+}
+
+// searchDepth returns the depth a "go" command asks for, or mockDepth
+func searchDepth(args []string) int {
+	for i := 0; i+1 < len(args); i++ {
+		if args[i] == "depth" {
+			if depth, err := strconv.Atoi(args[i+1]); err == nil && depth > 0 {
+				return depth
+			}
+		}
+	}
+	return mockDepth
+}
+
+// search writes the info lines and bestmove of a search of b
+func search(w io.Writer, b *board.Board, depth, multiPV int) {
+	lines := scoreMoves(b)
+	if len(lines) == 0 {
+		// Checkmate or stalemate: nothing to play
+		if b.InCheck() {
+			fmt.Fprintln(w, "info depth 0 score mate 0")
+		} else {
+			fmt.Fprintln(w, "info depth 0 score cp 0")
+		}
+		fmt.Fprintln(w, "bestmove (none)")
+		return
+	}
+
+	nodes := 1000 * depth * len(lines)
+	for i, line := range lines {
+		if i == multiPV {
+			break
+		}
+		score := fmt.Sprintf("cp %d", line.cp)
+		if line.mate != 0 {
+			// UCI counts mates in moves, not plies
+			moves := (line.mate + 1) / 2
+			if line.mate < 0 {
+				moves = (line.mate - 1) / 2
+			}
+			score = fmt.Sprintf("mate %d", moves)
+		}
+		fmt.Fprintf(w, "info depth %d seldepth %d multipv %d score %s nodes %d nps 1000000 time %d pv %s\n",
+			depth, depth, i+1, score, nodes, nodes/1000, line.move)
+	}
+	fmt.Fprintf(w, "bestmove %s\n", lines[0].move)
+}
+
+// scoreMoves scores every legal move of b for the side to move, best first. Moves that score
+// the same keep the order the board generated them in.
+func scoreMoves(b *board.Board) []mockLine {
+	mover := b.Turn()
+	var lines []mockLine
+	for _, m := range b.LegalMoves() {
+		next := *b
+		next.Apply(m)
+		line := mockLine{move: m.UCI()}
+
+		replies := next.LegalMoves()
+		switch {
+		case len(replies) == 0 && next.InCheck():
+			line.mate = 1
+		case len(replies) == 0:
+			line.cp = 0
+		default:
+			bestCapture := 0
+			for _, reply := range replies {
+				if mates(&next, reply) {
+					line.mate = -2
+					break
+				}
+				if gain := captureValue(reply); gain > bestCapture {
+					bestCapture = gain
+				}
+			}
+			line.cp = material(&next, mover) - bestCapture
+		}
+		lines = append(lines, line)
+	}
+
+	sort.SliceStable(lines, func(i, j int) bool { return lines[i].rank() > lines[j].rank() })
+	return lines
+}
+
+// mates reports whether playing m on b checkmates the opponent
+func mates(b *board.Board, m board.Move) bool {
+	next := *b
+	next.Apply(m)
+	return next.InCheck() && len(next.LegalMoves()) == 0
+}
+
+// captureValue returns the material a move wins: the captured piece and any promotion
+func captureValue(m board.Move) int {
+	gain := pieceValues[m.Captured.Type]
+	if m.EnPassant {
+		gain = pieceValues[board.Pawn]
+	}
+	if m.Promotion != board.NoPieceType {
+		gain += pieceValues[m.Promotion] - pieceValues[board.Pawn]
+	}
+	return gain
+}
+
+// material returns the material balance of b from side's point of view
+func material(b *board.Board, side board.Color) int {
+	balance := 0
+	for s := board.Square(0); s < 64; s++ {
+		p := b.PieceAt(s)
+		if p.Color == side {
+			balance += pieceValues[p.Type]
+		} else {
+			balance -= pieceValues[p.Type]
+		}
+	}
+	return balance
+}
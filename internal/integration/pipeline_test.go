@@ -0,0 +1,244 @@
+package integration
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+)
+
+func TestMain(m *testing.M) { Main(m) }
+
+// decodeData decodes the data of a successful API response
+func decodeData[T any](t *testing.T, body []byte) T {
+	t.Helper()
+	var response struct {
+		Success bool `json:"success"`
+		Data    T    `json:"data"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil || !response.Success {
+		t.Fatalf("Unexpected response %s (%v)", body, err)
+	}
+	return response.Data
+}
+
+func TestPipeline_AnalyzeArchivedGames(t *testing.T) {
+	h := New(t)
+
+	w := h.Do(http.MethodGet, "/api/v1/player/PipelineTester/games?year=2024&month=1", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET games = %d %s", w.Code, w.Body.String())
+	}
+	games := decodeData[models.Page[*models.GameInfo]](t, w.Body.Bytes()).Items
+	if len(games) != 3 {
+		t.Fatalf("Expected the 3 games of January, got %d", len(games))
+	}
+
+	// Every game runs through the mock engine; each ends in a different way
+	tests := []struct {
+		reason    models.ResultReason
+		blunder   string // Move expected to be flagged as a blunder, empty for none
+		lostOnTop string
+	}{
+		{reason: models.ResultReasonCheckmate, blunder: "Nf6"},
+		{reason: models.ResultReasonResignation, blunder: "Qxc5"},
+		{reason: models.ResultReasonTimeout, blunder: "Qh4", lostOnTop: "white"},
+	}
+	for i, tt := range tests {
+		game := games[i]
+		if game.ResultReason != tt.reason {
+			t.Errorf("Game %s result reason = %q, want %q", game.URL, game.ResultReason, tt.reason)
+		}
+
+		// The legacy accuracy model scores the position reached, which flags every move of a lost
+		// game; the centipawn-loss model flags just the moves that threw the game away
+		body, _ := json.Marshal(map[string]any{"game_id": game.GameID, "pgn": game.PGN, "include_moves": true, "accuracy_model": "cpl"})
+		w := h.Do(http.MethodPost, "/api/v1/analyze/game", body)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Analyzing %s = %d %s", game.URL, w.Code, w.Body.String())
+		}
+		analysis := decodeData[models.GameAnalysis](t, w.Body.Bytes())
+
+		if analysis.EngineVersion != MockEngineName || len(analysis.Moves) == 0 {
+			t.Errorf("Analysis of %s by %q has %d moves", game.URL, analysis.EngineVersion, len(analysis.Moves))
+		}
+		var blunders []string
+		for _, move := range analysis.Moves {
+			if move.Blunder {
+				blunders = append(blunders, move.Move)
+			}
+		}
+		if len(blunders) != 1 || blunders[0] != tt.blunder {
+			t.Errorf("Blunders in %s = %v, want [%s]", game.URL, blunders, tt.blunder)
+		}
+		if analysis.Summary.ResultReason != tt.reason || analysis.Summary.LostOnTimeWinning != tt.lostOnTop {
+			t.Errorf("Summary of %s = %q lost on time winning %q, want %q and %q", game.URL,
+				analysis.Summary.ResultReason, analysis.Summary.LostOnTimeWinning, tt.reason, tt.lostOnTop)
+		}
+	}
+}
+
+func TestPipeline_MissingFixture(t *testing.T) {
+	h := New(t)
+
+	w := h.Do(http.MethodGet, "/api/v1/player/nobody/games?year=2024&month=1", nil)
+	if w.Code == http.StatusOK {
+		t.Errorf("GET games of a player without fixtures = %d, want an error", w.Code)
+	}
+	if requests := h.Fixtures.Requests(); len(requests) != 1 || requests[0] != "/pub/player/nobody/games/2024/01" {
+		t.Errorf("Fixture requests = %v, want the archive of January", requests)
+	}
+}
+
+func TestRunMockEngine(t *testing.T) {
+	commands := strings.Join([]string{
+		"uci",
+		"setoption name MultiPV value 2",
+		"isready",
+		// Black has just played 3...Nf6, allowing Qxf7#
+		"position startpos moves e2e4 e7e5 f1c4 b8c6 d1h5 g8f6",
+		"go depth 8",
+		// Black to move is mated
+		"position startpos moves e2e4 e7e5 f1c4 b8c6 d1h5 g8f6 h5f7",
+		"go movetime 100",
+		"quit",
+	}, "\n")
+
+	var out strings.Builder
+	if err := RunMockEngine(strings.NewReader(commands), &out); err != nil {
+		t.Fatalf("RunMockEngine() error = %v", err)
+	}
+
+	for _, want := range []string{
+		"id name " + MockEngineName,
+		"uciok",
+		"readyok",
+		"info depth 8 seldepth 8 multipv 1 score mate 1 ",
+		"multipv 2 score cp",
+		"bestmove h5f7",
+		"info depth 0 score mate 0\nbestmove (none)",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Mock engine output is missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestPipeline_Tournaments(t *testing.T) {
+	h := New(t)
+
+	// Analyze one of the two arena games; the player won both
+	w := h.Do(http.MethodGet, "/api/v1/player/PipelineTester/games?year=2024&month=1", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET games = %d %s", w.Code, w.Body.String())
+	}
+	game := decodeData[models.Page[*models.GameInfo]](t, w.Body.Bytes()).Items[0]
+	body, _ := json.Marshal(map[string]any{"pgn": game.PGN, "accuracy_model": "cpl"})
+	if w := h.Do(http.MethodPost, "/api/v1/analyze/game", body); w.Code != http.StatusOK {
+		t.Fatalf("Analyzing %s = %d %s", game.URL, w.Code, w.Body.String())
+	}
+
+	w = h.Do(http.MethodGet, "/api/v1/player/PipelineTester/tournaments", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET tournaments = %d %s", w.Code, w.Body.String())
+	}
+	history := decodeData[models.TournamentHistory](t, w.Body.Bytes())
+	if history.Games != 4 || history.AnalyzedGames != 1 || len(history.Tournaments) != 4 {
+		t.Fatalf("Unexpected history: %d games, %d analyzed, %d tournaments", history.Games, history.AnalyzedGames, len(history.Tournaments))
+	}
+	var states []string
+	for _, tournament := range history.Tournaments {
+		states = append(states, tournament.State)
+	}
+	if strings.Join(states, ",") != "in_progress,finished,finished,registered" {
+		t.Errorf("Tournament states = %v", states)
+	}
+
+	arena := history.Tournaments[1]
+	if arena.Type != "arena" || arena.Placement != 3 || arena.Games != 2 || arena.Score != 100 {
+		t.Errorf("Unexpected arena results: %+v", arena)
+	}
+	// Black's blunder was the opponent's, not the player's
+	if arena.AnalyzedGames != 1 || arena.Accuracy == nil || arena.Blunders != 0 || len(arena.AnalysisIDs) != 1 {
+		t.Errorf("Unexpected arena analysis summary: %+v", arena)
+	}
+	if swiss := history.Tournaments[2]; swiss.Games != 0 || swiss.Accuracy != nil {
+		t.Errorf("Expected no recent games in the swiss, got %+v", swiss)
+	}
+}
+
+func TestPipeline_Benchmark(t *testing.T) {
+	h := New(t)
+
+	// Only the admin may replace the baseline
+	w := h.Do(http.MethodPost, "/api/v1/analyze/benchmark", []byte(`{"depth": 4, "save_baseline": true}`))
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("POST benchmark without the admin key = %d %s, want 401", w.Code, w.Body.String())
+	}
+	if w = h.Do(http.MethodPost, "/api/v1/analyze/benchmark", []byte(`{"depth": 40}`)); w.Code != http.StatusBadRequest {
+		t.Errorf("POST benchmark deeper than the cap = %d, want 400", w.Code)
+	}
+
+	// The first run becomes the baseline the second is compared with
+	w = h.DoAdmin(http.MethodPost, "/api/v1/analyze/benchmark", []byte(`{"depth": 4, "save_baseline": true}`))
+	if w.Code != http.StatusOK {
+		t.Fatalf("POST benchmark = %d %s", w.Code, w.Body.String())
+	}
+	first := decodeData[models.BenchmarkReport](t, w.Body.Bytes())
+	// The mock engine sees one move ahead, which is too shallow for the knight fork
+	if first.Summary.EngineVersion != MockEngineName || first.Summary.Solved != first.Summary.Positions-1 ||
+		first.Summary.Threads != h.Config.Stockfish.DefaultThreads || first.Summary.HashSize != h.Config.Stockfish.DefaultHashSize ||
+		first.Summary.NodesPerSecond == 0 || !first.BaselineSaved || first.Comparison != nil {
+		t.Fatalf("Unexpected first run: %+v", first.Summary)
+	}
+
+	w = h.Do(http.MethodPost, "/api/v1/analyze/benchmark", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("POST benchmark = %d %s", w.Code, w.Body.String())
+	}
+	second := decodeData[models.BenchmarkReport](t, w.Body.Bytes())
+	if second.Baseline == nil || second.Baseline.RanAt.IsZero() || second.BaselineSaved {
+		t.Fatalf("Expected the second run to be compared with the saved baseline, got %+v", second)
+	}
+	// Only the depth differs from the baseline
+	if c := second.Comparison; c == nil || !c.Healthy || c.SolvedDiff != 0 || len(c.Problems) != 1 {
+		t.Errorf("Unexpected comparison: %+v", second.Comparison)
+	}
+}
+
+func TestPipeline_StreamedAnalysis(t *testing.T) {
+	h := New(t)
+	h.Services.Analysis.SetStreamingOptions(6, 2)
+
+	w := h.Do(http.MethodGet, "/api/v1/player/PipelineTester/games?year=2024&month=1", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET games = %d %s", w.Code, w.Body.String())
+	}
+	game := decodeData[models.Page[*models.GameInfo]](t, w.Body.Bytes()).Items[0]
+
+	// A long game's analysis is answered, and cached, without its moves
+	body, _ := json.Marshal(map[string]any{"pgn": game.PGN})
+	var analysis models.GameAnalysis
+	for i := 0; i < 2; i++ {
+		w = h.Do(http.MethodPost, "/api/v1/analyze/game", body)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Analyzing %s = %d %s", game.URL, w.Code, w.Body.String())
+		}
+		analysis = decodeData[models.GameAnalysis](t, w.Body.Bytes())
+		if analysis.StreamedMoves < 6 || len(analysis.Moves) != 0 {
+			t.Fatalf("Expected a streamed analysis without moves, got %d streamed and %d moves", analysis.StreamedMoves, len(analysis.Moves))
+		}
+	}
+
+	// Its moves are read from the store in ranges
+	w = h.Do(http.MethodGet, "/api/v1/analysis/"+analysis.ID+"?from_ply=3&to_ply=6", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET analysis = %d %s", w.Code, w.Body.String())
+	}
+	moves := decodeData[models.GameAnalysis](t, w.Body.Bytes()).Moves
+	if len(moves) != 4 || moves[0].MoveNumber != 3 || moves[3].MoveNumber != 6 {
+		t.Errorf("Expected plies 3 to 6, got %+v", moves)
+	}
+}
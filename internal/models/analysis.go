@@ -4,21 +4,61 @@ import "time"
 
 // AnalysisResult represents the result of a chess position analysis
 type AnalysisResult struct {
-	Position           string   `json:"position"`    // FEN position
-	MoveNumber         int      `json:"move_number"` // Move number in the game
-	BestMove           string   `json:"best_move"`   // Best move found by engine
-	Evaluation         float64  `json:"evaluation"`  // Centipawn evaluation
-	Depth              int      `json:"depth"`       // Search depth reached
-	Nodes              int64    `json:"nodes"`       // Number of nodes searched
-	Time               int64    `json:"time"`        // Analysis time in milliseconds
-	PrincipalVariation []string `json:"pv"`          // Principal variation (best line)
-	MultiPV            int      `json:"multipv"`     // Multi-PV line number
+	Position           string    `json:"position"`                   // FEN position
+	MoveNumber         int       `json:"move_number"`                // Move number in the game
+	BestMove           string    `json:"best_move"`                  // Best move found by engine
+	Evaluation         float64   `json:"evaluation"`                 // Evaluation from White's point of view
+	Depth              int       `json:"depth"`                      // Search depth reached
+	Nodes              int64     `json:"nodes"`                      // Number of nodes searched
+	Time               int64     `json:"time"`                       // Analysis time in milliseconds
+	PrincipalVariation []string  `json:"pv"`                         // Principal variation (best line)
+	MultiPV            int       `json:"multipv"`                    // Multi-PV line number
+	HashFull           int       `json:"hashfull"`                   // Hash table usage in permille
+	LineEvaluations    []float64 `json:"line_evaluations,omitempty"` // Evaluation of each Multi-PV line from White's point of view, best first
+	Lines              []PVLine  `json:"lines,omitempty"`            // Each Multi-PV line, best first
+	WDL                *WDL      `json:"wdl,omitempty"`              // Win/draw/loss odds, when the engine reports them
+	EvalScale          string    `json:"eval_scale,omitempty"`       // Engine's native scale; evaluations are converted to the normalized scale
+	ScoreBound         string    `json:"score_bound,omitempty"`      // Set when the search ended with only a bound on the evaluation
+}
+
+// Score bounds of an evaluation, from White's point of view
+const (
+	ScoreLowerBound = "lowerbound" // The real evaluation is at least this good for White
+	ScoreUpperBound = "upperbound" // The real evaluation is at most this good for White
+)
+
+// PVLine is one Multi-PV line reported by the engine
+type PVLine struct {
+	Moves      []string `json:"moves"`         // Line in UCI notation
+	Evaluation float64  `json:"evaluation"`    // Evaluation from White's point of view
+	Depth      int      `json:"depth"`         // Depth the line was last reported at
+	WDL        *WDL     `json:"wdl,omitempty"` // Win/draw/loss odds of the line
+}
+
+// WDL is the engine's estimate of the game's outcome from White's point of view, in permille.
+// Stockfish derives it from the evaluation and the material left, so a +1 in a queen endgame
+// wins more often than a +1 in a rook endgame.
+type WDL struct {
+	Win  int `json:"win"`
+	Draw int `json:"draw"`
+	Loss int `json:"loss"`
+}
+
+// Flip returns the odds from the other side's point of view
+func (w WDL) Flip() WDL {
+	return WDL{Win: w.Loss, Draw: w.Draw, Loss: w.Win}
+}
+
+// ExpectedScore returns the points White is expected to score, from 0 to 1
+func (w WDL) ExpectedScore() float64 {
+	return (float64(w.Win) + float64(w.Draw)/2) / 1000
 }
 
 // MoveAnalysis represents analysis for a specific move
 type MoveAnalysis struct {
 	Move         string            `json:"move"`         // Move in algebraic notation
 	MoveNumber   int               `json:"move_number"`  // Move number
+	FEN          string            `json:"fen"`          // Position after the move
 	Evaluation   float64           `json:"evaluation"`   // Position evaluation after move
 	Accuracy     float64           `json:"accuracy"`     // Move accuracy percentage
 	Blunder      bool              `json:"blunder"`      // True if move is a blunder
@@ -26,6 +66,94 @@ type MoveAnalysis struct {
 	Inaccuracy   bool              `json:"inaccuracy"`   // True if move is an inaccuracy
 	BestMove     string            `json:"best_move"`    // Best move in this position
 	Alternatives []MoveAlternative `json:"alternatives"` // Alternative moves
+
+	HumanMove              string   `json:"human_move,omitempty"`              // Move a player of the requested rating should find
+	HumanProbability       float64  `json:"human_probability,omitempty"`       // Chance a human of the requested rating finds the best move (Maia)
+	Miss                   bool     `json:"miss,omitempty"`                    // Failed to punish the opponent's error with a findable move
+	Verified               bool     `json:"verified,omitempty"`                // Re-checked at a higher depth
+	Unverified             bool     `json:"unverified,omitempty"`              // Evaluation is a PGN annotation without a depth, so it may be shallower than requested
+	Extended               bool     `json:"extended,omitempty"`                // Re-searched deeper because the evaluation swung sharply
+	ScoreBound             string   `json:"score_bound,omitempty"`             // The evaluation is only a bound, so the move isn't classified
+	BestLine               []string `json:"best_line,omitempty"`               // Engine's principal variation from this position
+	UserComment            string   `json:"user_comment,omitempty"`            // Comment added by the user
+	ClassificationOverride string   `json:"classification_override,omitempty"` // Classification set by the user
+
+	Practical *PracticalAssessment `json:"practical,omitempty"` // Evaluation weighed with the clocks (practical mode)
+
+	WDL *WDL `json:"wdl,omitempty"` // Engine's win/draw/loss odds after the move, from White's point of view
+
+	ExpectedPoints     float64 `json:"expected_points"`      // White's expected points after the move: win probability plus half the draw probability
+	ExpectedPointsLost float64 `json:"expected_points_lost"` // Expected points the mover gave away with the move
+
+	Source string `json:"source,omitempty"` // Where the evaluation came from: engine, or pgn for an [%eval] annotation
+
+	Board *BoardAnnotations `json:"board,omitempty"` // Arrows and highlights for key moments: errors, misses and forced mates
+}
+
+// BoardAnnotations are the arrows and square highlights a board UI draws for a move. Squares are
+// named in algebraic notation, e.g. "e4".
+type BoardAnnotations struct {
+	Arrows     []BoardArrow      `json:"arrows"`
+	Highlights []SquareHighlight `json:"highlights"`
+}
+
+// BoardArrow is an arrow from one square to another
+type BoardArrow struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Kind string `json:"kind"`
+}
+
+// SquareHighlight is a highlighted square
+type SquareHighlight struct {
+	Square string `json:"square"`
+	Kind   string `json:"kind"`
+}
+
+// Kinds of board arrows and highlights
+const (
+	ArrowPlayed = "played" // The move played
+	ArrowBest   = "best"   // Engine's best move in the position the move was played in
+	ArrowThreat = "threat" // Engine's best reply after the move
+
+	HighlightThreatened = "threatened" // Mover's piece that is attacked and not adequately defended after the move
+	HighlightMatedKing  = "mated_king" // King facing a forced mate
+	HighlightMatingNet  = "mating_net" // Square next to the mated king that it can't flee to
+)
+
+// Sources of a move's evaluation
+const (
+	EvalSourceEngine = "engine"
+	EvalSourcePGN    = "pgn"
+)
+
+// Ways an analysis treats evaluations annotated in the PGN
+const (
+	InlineEvalsUse    = "use"    // Annotated plies deep enough for the request aren't searched again
+	InlineEvalsIgnore = "ignore" // Every ply is searched
+)
+
+// Time pressure and practical risk levels
+const (
+	TimePressureNone     = "none"
+	TimePressureLow      = "low"
+	TimePressureCritical = "critical"
+
+	RiskLow    = "low"
+	RiskMedium = "medium"
+	RiskHigh   = "high"
+)
+
+// PracticalAssessment weighs the engine evaluation after a move with both players' remaining time
+type PracticalAssessment struct {
+	MoverClock          float64 `json:"mover_clock"`          // Seconds left for the player who moved
+	OpponentClock       float64 `json:"opponent_clock"`       // Seconds left for the player to move next
+	MoverPressure       string  `json:"mover_pressure"`       // none/low/critical
+	OpponentPressure    string  `json:"opponent_pressure"`    // none/low/critical
+	MutualTimeTrouble   bool    `json:"mutual_time_trouble"`  // Both players are short of time
+	Sharpness           float64 `json:"sharpness"`            // Pawns lost by the reply's second-best move
+	PracticalEvaluation float64 `json:"practical_evaluation"` // Evaluation shifted against the side that must find a hard move short of time
+	Risk                string  `json:"risk"`                 // low/medium/high
 }
 
 // MoveAlternative represents an alternative move suggestion
@@ -37,26 +165,75 @@ type MoveAlternative struct {
 
 // GameAnalysis represents complete analysis of a chess game
 type GameAnalysis struct {
+	ID             string          `json:"id"`              // Stored analysis ID
 	GameID         string          `json:"game_id"`         // Original game ID
 	PGN            string          `json:"pgn"`             // Original PGN
 	AnalysisTime   time.Time       `json:"analysis_time"`   // When analysis was performed
 	EngineVersion  string          `json:"engine_version"`  // Stockfish version used
+	EvalScale      string          `json:"eval_scale"`      // Engine's native evaluation scale; evaluations are normalized
 	EngineSettings EngineSettings  `json:"engine_settings"` // Analysis settings
 	Moves          []MoveAnalysis  `json:"moves"`           // Analysis for each move
 	GameEvaluation float64         `json:"game_evaluation"` // Overall game evaluation
 	Accuracy       GameAccuracy    `json:"accuracy"`        // Overall accuracy metrics
 	Summary        AnalysisSummary `json:"summary"`         // Analysis summary
+	Cost           *AnalysisCost   `json:"cost,omitempty"`  // Resources used to produce the analysis
+	Language       string          `json:"language"`        // Language of recommendations and descriptions
+	Momentum       []MomentumPoint `json:"momentum"`        // Expected points after every analyzed ply
+
+	// StreamedMoves counts the moves of a long game written to the store as they were analyzed.
+	// Such analyses are returned without their moves, which are read from the store in ranges.
+	StreamedMoves int `json:"streamed_moves,omitempty"`
+
+	ReducedQuality *ReducedQuality `json:"reduced_quality,omitempty"` // Set when the searches were cut back under load
+}
+
+// ReducedQuality records that an analysis ran with fewer lines or less depth than requested
+// because the engines were busy. Such analyses aren't cached and are worth analyzing again.
+type ReducedQuality struct {
+	Reason                string `json:"reason"`
+	QueueLength           int    `json:"queue_length"`            // Requests waiting for an engine when the analysis started
+	RequestedMultiPV      int    `json:"requested_multipv"`       // Lines the analysis would have searched
+	RequestedDepth        int    `json:"requested_depth"`         // Depth the analysis would have searched (0 = engine default)
+	RequestedTimeLimit    int    `json:"requested_time_limit"`    // Milliseconds per move the analysis would have searched (0 = none)
+	EligibleForReanalysis bool   `json:"eligible_for_reanalysis"` // Always true; analyze the game again once the load drops
+}
+
+// AnalysisListing describes a stored analysis in analysis lists, without its moves
+type AnalysisListing struct {
+	ID             string          `json:"id"`
+	GameID         string          `json:"game_id"`
+	AnalysisTime   time.Time       `json:"analysis_time"`
+	EngineVersion  string          `json:"engine_version"`
+	EngineSettings EngineSettings  `json:"engine_settings"`
+	Accuracy       GameAccuracy    `json:"accuracy"`
+	TotalMoves     int             `json:"total_moves"`
+	ReducedQuality *ReducedQuality `json:"reduced_quality,omitempty"`
+}
+
+// MomentumPoint tracks the expected points of a game after one ply
+type MomentumPoint struct {
+	Ply            int     `json:"ply"`
+	ExpectedPoints float64 `json:"expected_points"` // White's expected points
+	WhiteLost      float64 `json:"white_lost"`      // Expected points White gave away so far
+	BlackLost      float64 `json:"black_lost"`      // Expected points Black gave away so far
 }
 
 // EngineSettings represents Stockfish engine configuration
 type EngineSettings struct {
-	Depth      int `json:"depth"`       // Search depth
-	TimeLimit  int `json:"time_limit"`  // Time limit in milliseconds
-	MultiPV    int `json:"multipv"`     // Number of principal variations
-	Threads    int `json:"threads"`     // Number of threads
-	HashSize   int `json:"hash_size"`   // Hash table size in MB
-	SkillLevel int `json:"skill_level"` // Skill level (0-20)
-	Contempt   int `json:"contempt"`    // Contempt factor
+	Depth      int  `json:"depth"`              // Search depth
+	TimeLimit  int  `json:"time_limit"`         // Time limit in milliseconds
+	MultiPV    int  `json:"multipv"`            // Number of principal variations
+	Threads    int  `json:"threads"`            // Number of threads
+	HashSize   int  `json:"hash_size"`          // Hash table size in MB
+	SkillLevel int  `json:"skill_level"`        // Skill level (0-20)
+	Contempt   *int `json:"contempt,omitempty"` // Contempt factor; unset runs at the engine's configured one
+
+	EvalFile string `json:"eval_file,omitempty"` // NNUE network file (set from server configuration)
+
+	LimitStrength bool `json:"limit_strength,omitempty"` // Play at a limited Elo (UCI_LimitStrength)
+	Elo           int  `json:"elo,omitempty"`            // Target Elo when strength is limited (UCI_Elo)
+
+	Variant string `json:"variant,omitempty"` // Chess variant, e.g. kingofthehill (routes to a matching engine pool)
 }
 
 // GameAccuracy represents accuracy metrics for the entire game
@@ -70,26 +247,324 @@ type GameAccuracy struct {
 	BrilliantMoves  int     `json:"brilliant_moves"`  // Number of brilliant moves
 	GreatMoves      int     `json:"great_moves"`      // Number of great moves
 	BestMoves       int     `json:"best_moves"`       // Number of best moves
+
+	Model string `json:"model"` // Accuracy model that scored the moves
+
+	WhiteExpectedPointsLost float64 `json:"white_expected_points_lost"` // Expected points White gave away over the game
+	BlackExpectedPointsLost float64 `json:"black_expected_points_lost"` // Expected points Black gave away over the game
+}
+
+// Recommendation codes
+const (
+	RecommendationBlunders       = "blunders"
+	RecommendationMistakes       = "mistakes"
+	RecommendationAccuracy       = "accuracy"
+	RecommendationOpening        = "opening"
+	RecommendationFindableMisses = "findable_misses"
+	RecommendationMisses         = "misses"
+)
+
+// Recommendation categories
+const (
+	CategoryTactics    = "tactics"
+	CategoryPositional = "positional"
+	CategoryAccuracy   = "accuracy"
+	CategoryOpening    = "opening"
+)
+
+// Recommendation severities
+const (
+	SeverityLow    = "low"
+	SeverityMedium = "medium"
+	SeverityHigh   = "high"
+)
+
+// Recommendation is an improvement suggestion. Code, category and params are stable for clients
+// that localize, filter or link recommendations themselves; text is rendered in the analysis language.
+type Recommendation struct {
+	Code     string                 `json:"code"`             // e.g. "blunders"
+	Category string                 `json:"category"`         // tactics, positional, accuracy or opening
+	Severity string                 `json:"severity"`         // low, medium or high
+	Params   map[string]interface{} `json:"params,omitempty"` // Values the text is built from
+	Text     string                 `json:"text"`
 }
 
 // AnalysisSummary provides a high-level summary of the analysis
 type AnalysisSummary struct {
-	TotalMoves      int      `json:"total_moves"`     // Total number of moves analyzed
-	AnalysisDepth   int      `json:"analysis_depth"`  // Average analysis depth
-	TotalTime       int64    `json:"total_time"`      // Total analysis time in ms
-	NodesSearched   int64    `json:"nodes_searched"`  // Total nodes searched
-	GamePhase       string   `json:"game_phase"`      // Opening/Middlegame/Endgame
-	Complexity      string   `json:"complexity"`      // Low/Medium/High complexity
-	Recommendations []string `json:"recommendations"` // Analysis recommendations
+	TotalMoves      int              `json:"total_moves"`     // Total number of moves analyzed
+	AnalysisDepth   int              `json:"analysis_depth"`  // Average analysis depth
+	TotalTime       int64            `json:"total_time"`      // Total analysis time in ms
+	NodesSearched   int64            `json:"nodes_searched"`  // Total nodes searched
+	GamePhase       string           `json:"game_phase"`      // Opening/Middlegame/Endgame
+	Complexity      string           `json:"complexity"`      // Low/Medium/High complexity
+	Recommendations []Recommendation `json:"recommendations"` // Analysis recommendations
+
+	VerifiedMoves     int `json:"verified_moves,omitempty"`     // Flagged moves re-checked at a higher depth
+	ReclassifiedMoves int `json:"reclassified_moves,omitempty"` // Verified moves whose classification changed
+	ExtendedMoves     int `json:"extended_moves,omitempty"`     // Plies re-searched deeper because the evaluation swung sharply
+	Misses            int `json:"misses,omitempty"`             // Moves classified as a miss
+	UnverifiedMoves   int `json:"unverified_moves,omitempty"`   // Moves evaluated from PGN annotations without a depth
+
+	EndgameType  string `json:"endgame_type,omitempty"`  // Endgame the game was decided in, if it reached one
+	EndgameStart int    `json:"endgame_start,omitempty"` // Ply at which the endgame began
+
+	RiskyMoves int `json:"risky_moves,omitempty"` // Positions assessed as high risk in practical mode
+
+	ResultReason      ResultReason `json:"result_reason,omitempty"`        // How the game ended, from the PGN's Termination tag
+	LostOnTimeWinning string       `json:"lost_on_time_winning,omitempty"` // Side that lost on time in a winning final position
+}
+
+// Endgame types, by the material left besides kings and pawns
+const (
+	EndgamePawn            = "pawn"             // Kings and pawns only
+	EndgameKnight          = "knight"           // Knights only
+	EndgameBishop          = "bishop"           // Bishops on the same color
+	EndgameOppositeBishops = "opposite_bishops" // One bishop each, on opposite colors
+	EndgameMinorPiece      = "minor_piece"      // Other combinations of minor pieces
+	EndgameRook            = "rook"             // Rooks only
+	EndgameRookMinor       = "rook_minor"       // Rooks and minor pieces
+	EndgameQueen           = "queen"            // Queens only
+	EndgameQueenPiece      = "queen_piece"      // Queens with other pieces
+)
+
+// Key moment types used in guided game reviews
+const (
+	KeyMomentBestMove     = "best_move"
+	KeyMomentMissedWin    = "missed_win"
+	KeyMomentTurningPoint = "turning_point"
+	KeyMomentTactic       = "tactic"
+)
+
+// KeyMoment represents a notable position selected for a guided game review
+type KeyMoment struct {
+	Type        string  `json:"type"`        // best_move/missed_win/turning_point/tactic
+	MoveNumber  int     `json:"move_number"` // Ply of the move in the analysis
+	Color       string  `json:"color"`       // Side that played the move
+	Move        string  `json:"move"`        // Move played
+	BestMove    string  `json:"best_move"`   // Engine's best move in the position
+	Evaluation  float64 `json:"evaluation"`  // Evaluation after the move
+	FEN         string  `json:"fen"`         // Position after the move
+	Description string  `json:"description"` // One-line description of the moment
+}
+
+// Commentary tones
+const (
+	CommentaryToneCoach   = "coach"   // Explains what to learn from each moment
+	CommentaryToneNeutral = "neutral" // States what happened
+	CommentaryToneBanter  = "banter"  // Light-hearted, like a streamer's chat
+)
+
+// Motifs detected in commented moves
+const (
+	MotifCheck     = "check"
+	MotifCheckmate = "checkmate"
+	MotifCapture   = "capture"
+	MotifPromotion = "promotion"
+	MotifCastling  = "castling"
+	MotifFork      = "fork"      // The moved piece attacks two or more valuable pieces
+	MotifSacrifice = "sacrifice" // A piece is left en prise without losing ground
+	MotifHanging   = "hanging"   // A piece is left en prise and the position suffers
+)
+
+// Commentary is generated prose about a game's key moments
+type Commentary struct {
+	AnalysisID string                `json:"analysis_id"`
+	Tone       string                `json:"tone"`
+	Paragraphs []CommentaryParagraph `json:"paragraphs"`
+}
+
+// CommentaryParagraph comments on one key moment
+type CommentaryParagraph struct {
+	MoveNumber int      `json:"move_number"` // Ply of the move in the analysis
+	Color      string   `json:"color"`
+	Move       string   `json:"move"`
+	Moment     string   `json:"moment"`           // Key moment type
+	Motifs     []string `json:"motifs,omitempty"` // Motifs detected in the move
+	Text       string   `json:"text"`
+}
+
+// MoveSuggestion pairs the objectively best move with a move suited to the player's level
+type MoveSuggestion struct {
+	FEN            string   `json:"fen"`             // Position analyzed
+	Rating         int      `json:"rating"`          // Player rating the suggestion targets
+	Elo            int      `json:"elo"`             // Engine Elo used (clamped to the engine's range)
+	BestMove       string   `json:"best_move"`       // Objectively best move
+	BestEvaluation float64  `json:"best_evaluation"` // Evaluation of the best move
+	BestLine       []string `json:"best_line"`       // Principal variation of the best move
+	HumanMove      string   `json:"human_move"`      // Move a player of this level should find
+}
+
+// Mate search outcomes
+const (
+	MateStatusFound   = "mate"    // Forced mate within the requested number of moves
+	MateStatusNone    = "no_mate" // The search went deep enough to rule out a mate within the requested moves
+	MateStatusUnknown = "unknown" // The time limit ran out before the search went deep enough
+)
+
+// MateSearch is the answer to whether a position has a forced mate within a number of moves
+type MateSearch struct {
+	FEN     string   `json:"fen"`
+	Moves   int      `json:"moves"`             // Mate searched for, in moves
+	Status  string   `json:"status"`            // mate, no_mate or unknown
+	MateIn  int      `json:"mate_in,omitempty"` // Moves to mate when one was found
+	Line    []string `json:"line,omitempty"`    // Mating line in UCI notation
+	LineSAN []string `json:"line_san,omitempty"`
+	Depth   int      `json:"depth"` // Plies searched
+	Nodes   int64    `json:"nodes"`
+	Time    int64    `json:"time"` // Search time in milliseconds
+}
+
+// PositionLines is a position and its best continuations, one level deep: each line starts
+// with a candidate move from the root position
+type PositionLines struct {
+	FEN        string         `json:"fen"`
+	Evaluation float64        `json:"evaluation"` // Evaluation of the best line
+	Depth      int            `json:"depth"`      // Depth reached by the search
+	Lines      []PositionLine `json:"lines"`      // Candidate moves, best first
+}
+
+// PositionLine is a candidate move and the engine's continuation after it
+type PositionLine struct {
+	Rank            int      `json:"rank"`                       // 1 for the best line
+	Move            string   `json:"move"`                       // Candidate move in UCI notation
+	MoveSAN         string   `json:"move_san,omitempty"`         // Candidate move in SAN (standard chess only)
+	FEN             string   `json:"fen,omitempty"`              // Position after the candidate move
+	Evaluation      float64  `json:"evaluation"`                 // Evaluation from White's point of view
+	Depth           int      `json:"depth"`                      // Depth the line was searched to
+	Continuation    []string `json:"continuation"`               // Moves after the candidate move in UCI notation
+	ContinuationSAN []string `json:"continuation_san,omitempty"` // Continuation in SAN
+}
+
+// Move filters of stored analysis retrieval
+const (
+	MoveFilterBlunders = "blunders"
+	MoveFilterMistakes = "mistakes"
+	MoveFilterKey      = "key" // Moves picked as key moments
+)
+
+// MoveFilter selects the moves of a stored analysis a client fetches; the zero value selects all
+type MoveFilter struct {
+	FromPly int    `json:"from_ply"` // First ply, 0 = from the start
+	ToPly   int    `json:"to_ply"`   // Last ply, 0 = to the end
+	Only    string `json:"only"`     // blunders | mistakes | key, empty for every move
+}
+
+// IsZero reports whether the filter selects every move
+func (f MoveFilter) IsZero() bool {
+	return f == MoveFilter{}
+}
+
+// PlyPosition is the position at one ply of a stored analysis, for stepping through a game move by move
+type PlyPosition struct {
+	AnalysisID     string   `json:"analysis_id"`
+	Ply            int      `json:"ply"`                     // 0 is the starting position
+	TotalPlies     int      `json:"total_plies"`             // Plies in the game
+	FEN            string   `json:"fen"`                     // Position at this ply
+	Move           string   `json:"move,omitempty"`          // Move that led to the position, in SAN
+	Color          string   `json:"color,omitempty"`         // Side that played the move
+	Analyzed       bool     `json:"analyzed"`                // False for the starting position and plies the engine skipped
+	Evaluation     float64  `json:"evaluation"`              // Evaluation from White's point of view
+	BestMove       string   `json:"best_move,omitempty"`     // Best move in the position
+	BestLine       []string `json:"best_line,omitempty"`     // Principal variation in UCI notation
+	BestLineSAN    []string `json:"best_line_san,omitempty"` // Principal variation in SAN
+	Classification string   `json:"classification,omitempty"`
+	Comment        string   `json:"comment,omitempty"` // User comment
+}
+
+// Move classifications a user can assign when editing an analysis
+var MoveClassifications = []string{
+	"brilliant", "great", "best", "excellent", "good", "book",
+	"inaccuracy", "mistake", "blunder", "miss", "practical_decision",
+}
+
+// MoveAnnotationEdit represents a user edit of a single analyzed move.
+// Nil fields are left unchanged, empty strings clear the existing value.
+type MoveAnnotationEdit struct {
+	MoveNumber     int     `json:"move_number"`              // Ply of the move to edit
+	Comment        *string `json:"comment,omitempty"`        // User comment
+	Classification *string `json:"classification,omitempty"` // Classification override
+}
+
+// AnnotationPatch represents a set of user edits to a stored analysis
+type AnnotationPatch struct {
+	Moves []MoveAnnotationEdit `json:"moves"`
+}
+
+// ReclassifyRequest re-derives the accuracy and classification of a stored analysis's moves from
+// their evaluations. Omitted fields fall back to the user's preferences, then to the defaults.
+type ReclassifyRequest struct {
+	Thresholds    *ClassificationThresholds `json:"thresholds,omitempty"`
+	AccuracyModel string                    `json:"accuracy_model,omitempty"`
+}
+
+// Analysis modes
+const (
+	AnalysisModeFull = "full" // Search every position to the requested depth or time
+	AnalysisModeScan = "scan" // Evaluation-only pass that stops each search once the eval is stable
+)
+
+// Built-in accuracy models
+const (
+	AccuracyModelLegacy     = "legacy"      // Scores the evaluation reached (default)
+	AccuracyModelCPL        = "cpl"         // Lichess-style centipawn loss curve
+	AccuracyModelWinPercent = "win_percent" // Chess.com-like drop in winning chances
+	AccuracyModelLinear     = "linear"      // Fixed deduction per pawn lost
+)
+
+// PGN validation modes
+const (
+	PGNValidationLenient  = "lenient"  // Only parseable movetext is required
+	PGNValidationStrict   = "strict"   // The Seven Tag Roster headers are also required
+	PGNValidationSanitize = "sanitize" // Common export defects are fixed, then validated leniently
+)
+
+// PGN fixes applied by the sanitizer
+const (
+	PGNFixLineEndings  = "line_endings"  // CRLF or CR line endings converted to LF
+	PGNFixSmartQuotes  = "smart_quotes"  // Typographic quotes in tags replaced with ASCII quotes
+	PGNFixTagSeparator = "tag_separator" // Blank line added between the tags and the movetext
+	PGNFixMoveNumbers  = "move_numbers"  // Move numbers split from moves, redundant "1..." removed
+	PGNFixCastling     = "castling"      // Castling written with zeros, such as 0-0
+	PGNFixComments     = "comments"      // Rest-of-line or unterminated comments rewritten as braces
+)
+
+// PGNNormalizeRequest asks for a PGN to be sanitized without analyzing it
+type PGNNormalizeRequest struct {
+	PGN string `json:"pgn"`
+}
+
+// PGNNormalization is a sanitized PGN with the fixes that were applied to it
+type PGNNormalization struct {
+	PGN   string   `json:"pgn"`
+	Fixes []string `json:"fixes"`           // PGN fixes, in the order they were first applied
+	Valid bool     `json:"valid"`           // The sanitized PGN passes lenient validation
+	Error string   `json:"error,omitempty"` // Why the sanitized PGN is still invalid
 }
 
 // AnalysisRequest represents a request for game analysis
 type AnalysisRequest struct {
-	GameID       string         `json:"game_id"`       // Game identifier
-	PGN          string         `json:"pgn"`           // PGN to analyze
-	Settings     EngineSettings `json:"settings"`      // Analysis settings
-	IncludeMoves bool           `json:"include_moves"` // Include move-by-move analysis
-	MaxMoves     int            `json:"max_moves"`     // Maximum moves to analyze (0 = all)
+	GameID       string                    `json:"game_id"`                 // Game identifier
+	PGN          string                    `json:"pgn"`                     // PGN to analyze
+	Settings     EngineSettings            `json:"settings"`                // Analysis settings
+	Mode         string                    `json:"mode,omitempty"`          // full (default) or scan
+	Profile      string                    `json:"profile,omitempty"`       // Named analysis profile
+	Thresholds   *ClassificationThresholds `json:"thresholds,omitempty"`    // Move classification thresholds
+	Verify       bool                      `json:"verify,omitempty"`        // Re-check flagged moves at a higher depth
+	VerifyDepth  int                       `json:"verify_depth,omitempty"`  // Depth of the verification pass
+	AutoExtend   bool                      `json:"auto_extend,omitempty"`   // Re-search plies whose evaluation swings sharply deeper before classifying them
+	ExtendDepth  int                       `json:"extend_depth,omitempty"`  // Depth of the extended search
+	ExtendSwing  float64                   `json:"extend_swing,omitempty"`  // Evaluation swing in pawns that triggers an extension
+	PlayerRating int                       `json:"player_rating,omitempty"` // Suggest level-appropriate moves for this rating
+	IncludeMoves bool                      `json:"include_moves"`           // Include move-by-move analysis
+	MaxMoves     int                       `json:"max_moves"`               // Maximum moves to analyze (0 = all)
+	Language     string                    `json:"language,omitempty"`      // Language of generated text (default: en)
+	Notation     string                    `json:"notation,omitempty"`      // Display notation of moves: san (default), figurine or a language code
+	Validation   string                    `json:"validation,omitempty"`    // PGN validation mode: lenient (default), strict or sanitize
+	Practical    bool                      `json:"practical,omitempty"`     // Weigh evaluations with the clocks from time annotations
+	InlineEvals  string                    `json:"inline_evals,omitempty"`  // use (default) or ignore evaluations annotated in the PGN
+	Priority     bool                      `json:"priority,omitempty"`      // Never reduce the searches under load (admin API key only)
+
+	AccuracyModel string `json:"accuracy_model,omitempty"` // legacy (default), cpl, win_percent or linear
 }
 
 // AnalysisResponse represents the response for an analysis request
@@ -0,0 +1,129 @@
+package models
+
+import "time"
+
+// Group types supported by the analytics service
+const (
+	GroupTypeClub    = "club"
+	GroupTypeCountry = "country"
+)
+
+// GroupAnalyticsRequest represents a request for club or country analytics
+type GroupAnalyticsRequest struct {
+	GroupType      string         `json:"group_type"`       // club/country
+	GroupID        string         `json:"group_id"`         // Club URL ID or country ISO code
+	SampleSize     int            `json:"sample_size"`      // Number of members to sample
+	GamesPerPlayer int            `json:"games_per_player"` // Recent games analyzed per member
+	Mode           string         `json:"mode"`             // Analysis mode used for each game
+	Settings       EngineSettings `json:"settings"`         // Engine settings used for each game
+}
+
+// GroupReport holds aggregate statistics for a sampled club or country
+type GroupReport struct {
+	GroupType       string           `json:"group_type"`
+	GroupID         string           `json:"group_id"`
+	GeneratedAt     time.Time        `json:"generated_at"`
+	PlayersSampled  int              `json:"players_sampled"`
+	GamesAnalyzed   int              `json:"games_analyzed"`
+	AverageAccuracy float64          `json:"average_accuracy"`
+	Openings        []OpeningStat    `json:"openings"`
+	RatingBands     []RatingBandStat `json:"rating_bands"`
+	FailedPlayers   []string         `json:"failed_players,omitempty"`  // Members whose games could not be fetched
+	FailedArchives  []ArchiveFailure `json:"failed_archives,omitempty"` // Months skipped for the other members
+	Archives        []ArchiveStatus  `json:"archives,omitempty"`        // Every month walked for the members and how fetching it went
+}
+
+// OpeningStat holds how often an opening was played and how accurately
+type OpeningStat struct {
+	Name            string  `json:"name"`
+	Games           int     `json:"games"`
+	Share           float64 `json:"share"` // Percentage of analyzed games
+	AverageAccuracy float64 `json:"average_accuracy"`
+}
+
+// RatingBandStat holds accuracy and blunder statistics for a rating band
+type RatingBandStat struct {
+	Band            string  `json:"band"` // e.g. "1200-1399"
+	MinRating       int     `json:"min_rating"`
+	MaxRating       int     `json:"max_rating"`
+	Games           int     `json:"games"`
+	Moves           int     `json:"moves"`
+	Blunders        int     `json:"blunders"`
+	BlunderRate     float64 `json:"blunder_rate"` // Blunders per 100 moves
+	AverageAccuracy float64 `json:"average_accuracy"`
+}
+
+// PlayerReport holds a player's performance across their recent games
+type PlayerReport struct {
+	Username     string        `json:"username"`
+	GeneratedAt  time.Time     `json:"generated_at"`
+	Games        int           `json:"games"`         // Games included
+	EndgameGames int           `json:"endgame_games"` // Games that reached an endgame
+	Endgames     []EndgameStat `json:"endgames"`      // Performance per endgame type, most played first
+
+	Openings           []OpeningDivergence `json:"openings"`                      // Results per opening and color against the engine's assessment, most played first
+	OpeningsIncomplete bool                `json:"openings_incomplete,omitempty"` // The assessment stopped at the position cap or the deadline
+
+	AnalyzedGames int            `json:"analyzed_games"`       // Games included that have a stored analysis
+	BestGame      *GameHighlight `json:"best_game,omitempty"`  // Analyzed game with the highest performance
+	WorstGame     *GameHighlight `json:"worst_game,omitempty"` // Lowest performance; omitted with fewer than two analyzed games
+
+	FailedArchives []ArchiveFailure `json:"failed_archives,omitempty"` // Months that couldn't be fetched
+	Archives       []ArchiveStatus  `json:"archives,omitempty"`        // Every month walked and how fetching it went
+}
+
+// GameHighlight is an analyzed game a player report nominates as the player's best or worst
+type GameHighlight struct {
+	URL            string      `json:"url"`
+	AnalysisID     string      `json:"analysis_id"`
+	Opponent       string      `json:"opponent"`
+	OpponentRating int         `json:"opponent_rating,omitempty"`
+	Color          string      `json:"color"`
+	Result         string      `json:"result"` // win, draw or loss
+	Accuracy       float64     `json:"accuracy"`
+	Performance    float64     `json:"performance"` // Accuracy adjusted for the opponent's rating
+	KeyMoments     []KeyMoment `json:"key_moments"`
+}
+
+// EndgameStat holds a player's results in one endgame type
+type EndgameStat struct {
+	Type   string  `json:"type"` // One of the Endgame* types
+	Games  int     `json:"games"`
+	Wins   int     `json:"wins"`
+	Draws  int     `json:"draws"`
+	Losses int     `json:"losses"`
+	Score  float64 `json:"score"` // Percentage of points scored
+}
+
+// Verdicts comparing a player's results in an opening with the engine's assessment of it
+const (
+	OpeningUnderperforming = "underperforming" // The player scores worse than the positions they reach are worth
+	OpeningOverperforming  = "overperforming"  // The player scores better than the positions they reach are worth
+	OpeningAsExpected      = "as_expected"
+)
+
+// OpeningDivergence compares a player's practical results in an opening with the engine's
+// assessment of the positions they reach once it's over
+type OpeningDivergence struct {
+	Name   string  `json:"name"`
+	Color  string  `json:"color"` // The player's color
+	Games  int     `json:"games"`
+	Wins   int     `json:"wins"`
+	Draws  int     `json:"draws"`
+	Losses int     `json:"losses"`
+	Score  float64 `json:"score"` // Percentage of points scored
+
+	EvaluatedGames    int      `json:"evaluated_games"`              // Games whose position after the opening the engine assessed
+	AverageEvaluation *float64 `json:"average_evaluation,omitempty"` // Of those positions, in pawns from the player's point of view
+	ExpectedScore     *float64 `json:"expected_score,omitempty"`     // Percentage of points those positions are worth
+	Divergence        *float64 `json:"divergence,omitempty"`         // Points scored in the evaluated games minus the expected score, in percentage points
+	Verdict           string   `json:"verdict,omitempty"`            // One of the Opening* verdicts, once enough games were evaluated
+}
+
+// PlayerReportRequest selects the games used for a player report
+type PlayerReportRequest struct {
+	Username       string         `json:"username"`
+	Games          int            `json:"games"`           // Recent games to include
+	AssessOpenings bool           `json:"assess_openings"` // Have the engine assess the positions reached after the openings
+	Settings       EngineSettings `json:"settings"`        // Engine settings for assessing the positions reached after the opening
+}
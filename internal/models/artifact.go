@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Artifact describes an exported analysis kept in the blob store. Only this metadata is stored
+// with the analyses; the content lives in the blob store under Key.
+type Artifact struct {
+	ID          string    `json:"id"`
+	AnalysisID  string    `json:"analysis_id"`
+	Format      string    `json:"format"` // Export format, e.g. json or pgn
+	Key         string    `json:"key"`    // Blob store key
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"` // Bytes
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ArtifactDownload is a time-limited link to an artifact's content
+type ArtifactDownload struct {
+	ArtifactID string    `json:"artifact_id"`
+	URL        string    `json:"url"` // Signed URL; no API key is needed to download it
+	ExpiresAt  time.Time `json:"expires_at"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// Outcomes of an audited analysis request
+const (
+	AuditStatusCompleted = "completed" // Analyzed by the engine
+	AuditStatusCached    = "cached"    // Served from the analysis cache without using the engine
+	AuditStatusFailed    = "failed"
+)
+
+// Requester identifies who sent a request, for the audit log
+type Requester struct {
+	KeyFingerprint string // Fingerprint of the X-API-Key header, empty for anonymous requests
+	IP             string // Client IP
+	Endpoint       string // Method and route, e.g. POST /api/v1/analyze/game
+}
+
+// AuditEntry records one game analysis: who asked for it, with what settings, and what it cost
+type AuditEntry struct {
+	ID             string         `json:"id"`
+	Time           time.Time      `json:"time"`                      // When the analysis was requested
+	KeyFingerprint string         `json:"key_fingerprint,omitempty"` // Of the API key; empty for anonymous requests and the service's own analyses
+	IP             string         `json:"ip,omitempty"`              // Empty for the service's own analyses, e.g. of synced games
+	Endpoint       string         `json:"endpoint,omitempty"`        // Method and route the analysis was requested through
+	GameID         string         `json:"game_id,omitempty"`         // Game ID of the request, or the game link of its PGN
+	AnalysisID     string         `json:"analysis_id,omitempty"`     // Analysis produced or served from the cache
+	Settings       EngineSettings `json:"settings"`
+	Mode           string         `json:"mode,omitempty"`
+	Profile        string         `json:"profile,omitempty"`
+	Status         string         `json:"status"`          // One of the AuditStatus* outcomes
+	Error          string         `json:"error,omitempty"` // Why the analysis failed
+	EngineTime     int64          `json:"engine_time"`     // Engine search time consumed, in milliseconds
+	WallClock      int64          `json:"wall_clock"`      // Elapsed time of the request, in milliseconds
+}
+
+// AuditQuery filters the audit log. Empty fields match every entry.
+type AuditQuery struct {
+	KeyFingerprint string
+	IP             string
+	GameID         string
+	Since          time.Time // Entries from then on (zero = any)
+	Until          time.Time // Entries before then (zero = any)
+	PageRequest
+}
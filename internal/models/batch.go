@@ -0,0 +1,34 @@
+package models
+
+// BatchAnalysisRequest analyzes several positions or games in one request, all with the same settings
+type BatchAnalysisRequest struct {
+	Items    []BatchItem    `json:"items"`
+	Settings EngineSettings `json:"settings"`
+	Deadline int            `json:"deadline"` // Milliseconds for the whole batch (0 = no deadline)
+}
+
+// BatchItem is one position or game of a batch; exactly one of FEN and PGN is set
+type BatchItem struct {
+	ID  string `json:"id,omitempty"` // Client's label, echoed in the result
+	FEN string `json:"fen,omitempty"`
+	PGN string `json:"pgn,omitempty"`
+}
+
+// BatchItemResult is the outcome of one batch item
+type BatchItemResult struct {
+	Index    int             `json:"index"` // Position of the item in the request
+	ID       string          `json:"id,omitempty"`
+	Position *AnalysisResult `json:"position,omitempty"` // Set for FEN items
+	Game     *GameAnalysis   `json:"game,omitempty"`     // Set for PGN items
+	Error    string          `json:"error,omitempty"`
+	TimedOut bool            `json:"timed_out,omitempty"` // The deadline passed before the item finished
+}
+
+// BatchAnalysis holds the results of a batch in request order
+type BatchAnalysis struct {
+	Results   []BatchItemResult `json:"results"`
+	Succeeded int               `json:"succeeded"`
+	Failed    int               `json:"failed"`
+	TimedOut  bool              `json:"timed_out"` // The deadline passed before every item finished
+	ElapsedMs int64             `json:"elapsed_ms"`
+}
@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// BenchmarkRequest asks for the engine benchmark suite to be run
+type BenchmarkRequest struct {
+	Depth        int  `json:"depth"`         // Depth every position is searched to
+	SaveBaseline bool `json:"save_baseline"` // Keep the run as the baseline later runs are compared with
+}
+
+// BenchmarkSummary is the outcome of a benchmark run
+type BenchmarkSummary struct {
+	EngineVersion  string    `json:"engine_version"`
+	Host           string    `json:"host"`
+	Depth          int       `json:"depth"`
+	Threads        int       `json:"threads"`
+	HashSize       int       `json:"hash_size"`
+	Positions      int       `json:"positions"`
+	Solved         int       `json:"solved"` // Positions where the engine found a solution
+	Nodes          int64     `json:"nodes"`
+	Time           int64     `json:"time"` // Search time in milliseconds
+	NodesPerSecond int64     `json:"nodes_per_second"`
+	RanAt          time.Time `json:"ran_at"`
+}
+
+// BenchmarkPosition is the engine's result on one position of the suite
+type BenchmarkPosition struct {
+	ID        string   `json:"id"`
+	FEN       string   `json:"fen"`
+	Solutions []string `json:"solutions"` // Moves that solve the position, in UCI
+	BestMove  string   `json:"best_move"` // Move the engine chose
+	Solved    bool     `json:"solved"`
+	Depth     int      `json:"depth"`
+	Nodes     int64    `json:"nodes"`
+	Time      int64    `json:"time"` // Milliseconds
+}
+
+// BenchmarkComparison compares a run with the stored baseline
+type BenchmarkComparison struct {
+	SpeedRatio float64  `json:"speed_ratio"` // Nodes per second relative to the baseline
+	SolvedDiff int      `json:"solved_diff"` // Positions solved minus the baseline's
+	Healthy    bool     `json:"healthy"`
+	Problems   []string `json:"problems,omitempty"` // Why the run isn't healthy, or isn't comparable
+}
+
+// BenchmarkReport is a benchmark run, per position and compared with the baseline when there is one
+type BenchmarkReport struct {
+	Summary       BenchmarkSummary     `json:"summary"`
+	Positions     []BenchmarkPosition  `json:"positions"`
+	Baseline      *BenchmarkSummary    `json:"baseline,omitempty"`
+	Comparison    *BenchmarkComparison `json:"comparison,omitempty"`
+	BaselineSaved bool                 `json:"baseline_saved"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// BroadcastRequest starts following the games of a live event relayed as PGN
+type BroadcastRequest struct {
+	URL          string         `json:"url"`           // Lichess broadcast round URL, or any URL serving the round's PGN
+	PollInterval int            `json:"poll_interval"` // Seconds between polls
+	Settings     EngineSettings `json:"settings"`      // Engine settings used for each position
+}
+
+// BroadcastSession is the state of a followed broadcast. Its status is one of the watch statuses;
+// a broadcast finishes once every board has a result and all its moves are analyzed.
+type BroadcastSession struct {
+	ID           string           `json:"id"`
+	URL          string           `json:"url"`
+	PGNURL       string           `json:"pgn_url"` // Where the round's PGN is polled from
+	Status       string           `json:"status"`
+	PollInterval int              `json:"poll_interval"`
+	StartedAt    time.Time        `json:"started_at"`
+	LastPoll     time.Time        `json:"last_poll,omitempty"`
+	LastMoveAt   time.Time        `json:"last_move_at,omitempty"`
+	LastError    string           `json:"last_error,omitempty"`
+	Boards       []BroadcastBoard `json:"boards"`
+}
+
+// BroadcastBoard is one game of a broadcast with the evaluation of each position so far
+type BroadcastBoard struct {
+	Board      int          `json:"board"` // Position of the game in the relay's PGN, from 1
+	White      string       `json:"white"`
+	Black      string       `json:"black"`
+	Result     string       `json:"result"`     // "*" while the game is in progress
+	Ply        int          `json:"ply"`        // Plies evaluated so far
+	Evaluation float64      `json:"evaluation"` // Evaluation of the latest position, pawns from White's point of view
+	BestMove   string       `json:"best_move,omitempty"`
+	Updates    []EvalUpdate `json:"updates"`
+}
+
+// BroadcastUpdate is the evaluation of one position of a broadcast board
+type BroadcastUpdate struct {
+	Board int `json:"board"`
+	EvalUpdate
+}
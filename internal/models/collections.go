@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// CollectionRequest creates a collection, or renames and retags one
+type CollectionRequest struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+// CollectionItemsRequest adds stored analyses and synced games to a collection, or removes them
+type CollectionItemsRequest struct {
+	AnalysisIDs []string `json:"analysis_ids,omitempty"`
+	GameURLs    []string `json:"game_urls,omitempty"` // Games synced with the archive sync
+}
+
+// Collection is a named group of a user's stored analyses and synced games,
+// e.g. "My French Defense losses" or "Club championship 2024"
+type Collection struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Tags        []string  `json:"tags"`
+	AnalysisIDs []string  `json:"analysis_ids"`
+	GameURLs    []string  `json:"game_urls"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// CollectionReport aggregates the games of a collection. With a player, results, accuracy and
+// errors are the player's; otherwise results are White's and accuracy and errors cover both sides.
+type CollectionReport struct {
+	CollectionID    string        `json:"collection_id"`
+	Name            string        `json:"name"`
+	Player          string        `json:"player,omitempty"`
+	Games           int           `json:"games"`          // Items still stored
+	AnalyzedGames   int           `json:"analyzed_games"` // Items that are analyses
+	Wins            int           `json:"wins"`
+	Draws           int           `json:"draws"`
+	Losses          int           `json:"losses"`
+	AverageAccuracy float64       `json:"average_accuracy"`
+	Blunders        int           `json:"blunders"`
+	Mistakes        int           `json:"mistakes"`
+	Inaccuracies    int           `json:"inaccuracies"`
+	Openings        []OpeningStat `json:"openings"`
+	Missing         []string      `json:"missing,omitempty"` // Items that are no longer stored
+	GeneratedAt     time.Time     `json:"generated_at"`
+}
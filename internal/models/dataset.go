@@ -0,0 +1,17 @@
+package models
+
+// DatasetRequest selects the analyzed moves exported as a position dataset
+type DatasetRequest struct {
+	Format      string   // csv (default) or parquet
+	Player      string   // Only this player's moves (matched against the White and Black tags)
+	AnalysisIDs []string // Only these stored analyses (empty = all)
+	Limit       int      // Rows exported at most (0 = the most allowed)
+}
+
+// Dataset is an encoded position dataset
+type Dataset struct {
+	Data        []byte
+	ContentType string
+	Rows        int
+	Truncated   bool // Rows were left out to stay within the limit
+}
@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// AnalysisDiff compares two stored analyses of the same game, e.g. depth 12 against depth 24
+type AnalysisDiff struct {
+	A       AnalysisDiffSide `json:"a"`
+	B       AnalysisDiffSide `json:"b"`
+	Summary DiffSummary      `json:"summary"`
+	Moves   []MoveDiff       `json:"moves"` // Moves analyzed in both, by ply
+}
+
+// AnalysisDiffSide identifies one of the compared analyses
+type AnalysisDiffSide struct {
+	ID             string         `json:"id"`
+	AnalysisTime   time.Time      `json:"analysis_time"`
+	EngineVersion  string         `json:"engine_version"`
+	EvalScale      string         `json:"eval_scale"` // Native scale of the engine; both sides' evaluations are normalized
+	EngineSettings EngineSettings `json:"engine_settings"`
+	Accuracy       GameAccuracy   `json:"accuracy"`
+	Moves          int            `json:"moves"` // Moves analyzed
+}
+
+// DiffSummary aggregates the per-move differences
+type DiffSummary struct {
+	ComparedMoves          int     `json:"compared_moves"`
+	ClassificationChanges  int     `json:"classification_changes"`
+	BestMoveDisagreements  int     `json:"best_move_disagreements"`
+	AverageEvalDelta       float64 `json:"average_eval_delta"` // Mean absolute evaluation difference in pawns
+	MaxEvalDelta           float64 `json:"max_eval_delta"`     // Largest absolute evaluation difference in pawns
+	MaxEvalDeltaMoveNumber int     `json:"max_eval_delta_move_number,omitempty"`
+}
+
+// MoveDiff is the difference between two analyses of one move. Deltas are B minus A.
+type MoveDiff struct {
+	MoveNumber            int     `json:"move_number"` // Ply of the move
+	Move                  string  `json:"move"`
+	EvaluationA           float64 `json:"evaluation_a"`
+	EvaluationB           float64 `json:"evaluation_b"`
+	EvalDelta             float64 `json:"eval_delta"`
+	ClassificationA       string  `json:"classification_a"`
+	ClassificationB       string  `json:"classification_b"`
+	ClassificationChanged bool    `json:"classification_changed"`
+	BestMoveA             string  `json:"best_move_a"`
+	BestMoveB             string  `json:"best_move_b"`
+	BestMoveDisagreement  bool    `json:"best_move_disagreement"`
+}
@@ -0,0 +1,20 @@
+package models
+
+// EvalCacheStats reports the use of the position evaluation cache
+type EvalCacheStats struct {
+	Positions    int     `json:"positions"`     // Cached evaluations
+	MaxPositions int     `json:"max_positions"` // Cached evaluations kept at most (0 = disabled)
+	Hits         int64   `json:"hits"`          // Lookups answered at the requested depth
+	Upgrades     int64   `json:"upgrades"`      // Lookups that found a shallower evaluation to search deeper
+	Misses       int64   `json:"misses"`
+	HitRate      float64 `json:"hit_rate"`
+}
+
+// PositionEvalUpdate is one evaluation streamed while a position is analyzed: a cached
+// evaluation shown at once, then the engine's result at the requested depth
+type PositionEvalUpdate struct {
+	Result *AnalysisResult `json:"result,omitempty"`
+	Cached bool            `json:"cached"`          // Served from the evaluation cache
+	Final  bool            `json:"final"`           // No deeper evaluation follows
+	Error  string          `json:"error,omitempty"` // Why the deeper search failed
+}
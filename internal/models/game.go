@@ -10,8 +10,29 @@ type Player struct {
 	Avatar   string `json:"avatar,omitempty"`
 	Country  string `json:"country,omitempty"`
 	Title    string `json:"title,omitempty"`
+	Rating   int    `json:"rating,omitempty"`
+	IsBot    bool   `json:"is_bot,omitempty"`
+	Result   string `json:"result,omitempty"` // Chess.com result code of the player, such as win or timeout
 }
 
+// ResultReason is how a game ended
+type ResultReason string
+
+// Result reasons, parsed from Chess.com result codes or a PGN Termination tag
+const (
+	ResultReasonCheckmate             ResultReason = "checkmate"
+	ResultReasonResignation           ResultReason = "resignation"
+	ResultReasonTimeout               ResultReason = "timeout"   // Lost on time
+	ResultReasonAbandoned             ResultReason = "abandoned" // Left the game or disconnected
+	ResultReasonAgreement             ResultReason = "agreement"
+	ResultReasonRepetition            ResultReason = "threefold_repetition"
+	ResultReasonStalemate             ResultReason = "stalemate"
+	ResultReasonInsufficientMaterial  ResultReason = "insufficient_material"
+	ResultReasonFiftyMoveRule         ResultReason = "fifty_move_rule"
+	ResultReasonTimeoutVsInsufficient ResultReason = "timeout_vs_insufficient_material" // Drawn: the flag fell, but the opponent couldn't mate
+	ResultReasonVariant               ResultReason = "variant"                          // A variant's own win condition, such as three checks
+)
+
 // GameMove represents a single move in a chess game
 type GameMove struct {
 	MoveNumber    int    `json:"move_number"`
@@ -40,31 +61,81 @@ type GameInfo struct {
 	Moves       []GameMove `json:"moves,omitempty"`
 	Tournament  string     `json:"tournament,omitempty"`
 	Match       string     `json:"match,omitempty"`
+	Players     []Player   `json:"players,omitempty"` // Every seat of four-player and team games such as bughouse
+
+	ResultReason       ResultReason `json:"result_reason,omitempty"`       // How the game ended, when known
+	UnanalyzableReason string       `json:"unanalyzable_reason,omitempty"` // Why the game can't be analyzed, set on fetch or by the repair job
+}
+
+// ArchiveFailure is a monthly archive that couldn't be fetched for a report
+type ArchiveFailure struct {
+	Username string `json:"username"`
+	Year     int    `json:"year"`
+	Month    int    `json:"month"`
+	Error    string `json:"error"`
+}
+
+// Fetch statuses of the monthly archives walked for a report
+const (
+	ArchiveStatusFetched   = "fetched"   // The month's games were fetched
+	ArchiveStatusNotFound  = "not_found" // Chess.com has no archive for the month, e.g. before the account was created
+	ArchiveStatusFailed    = "failed"    // The month couldn't be fetched
+	ArchiveStatusCancelled = "cancelled" // The walk was cancelled or timed out before the month was fetched
+)
+
+// ArchiveStatus is how fetching a monthly archive went while walking a player's archives
+type ArchiveStatus struct {
+	Username string `json:"username"`
+	Year     int    `json:"year"`
+	Month    int    `json:"month"`
+	Status   string `json:"status"`
+	Games    int    `json:"games"` // Games kept from the month
+	Error    string `json:"error,omitempty"`
+}
+
+// Bot filter modes for player game listings
+const (
+	BotFilterInclude = "include" // Keep all games
+	BotFilterExclude = "exclude" // Drop games against bots/computers
+	BotFilterOnly    = "only"    // Keep only games against bots/computers
+)
+
+// GameFilter holds filters applied to player game listings
+type GameFilter struct {
+	Bots string `json:"bots,omitempty"` // include/exclude/only
 }
 
 // APIResponse represents a standard API response
 type APIResponse struct {
-	Success bool        `json:"success"`
-	Data    interface{} `json:"data,omitempty"`
-	Error   string      `json:"error,omitempty"`
+	Success bool         `json:"success"`
+	Data    interface{}  `json:"data,omitempty"`
+	Error   string       `json:"error,omitempty"`
+	Errors  []FieldError `json:"errors,omitempty"` // Every invalid field, when the request failed validation
+}
+
+// FieldError describes why one field of a request is invalid
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
 }
 
 // GameResponse represents the response structure for game data
 type GameResponse struct {
-	GameID      string     `json:"game_id"`
-	URL         string     `json:"url"`
-	FEN         string     `json:"fen"`
-	PGN         string     `json:"pgn"`
-	TimeControl string     `json:"time_control"`
-	Rules       string     `json:"rules"`
-	WhitePlayer Player     `json:"white_player"`
-	BlackPlayer Player     `json:"black_player"`
-	Result      string     `json:"result"`
-	ResultCode  string     `json:"result_code"`
-	TimeClass   string     `json:"time_class"`
-	Rated       bool       `json:"rated"`
-	StartTime   time.Time  `json:"start_time"`
-	EndTime     *time.Time `json:"end_time,omitempty"`
-	Tournament  string     `json:"tournament,omitempty"`
-	Match       string     `json:"match,omitempty"`
+	GameID       string       `json:"game_id"`
+	URL          string       `json:"url"`
+	FEN          string       `json:"fen"`
+	PGN          string       `json:"pgn"`
+	TimeControl  string       `json:"time_control"`
+	Rules        string       `json:"rules"`
+	WhitePlayer  Player       `json:"white_player"`
+	BlackPlayer  Player       `json:"black_player"`
+	Result       string       `json:"result"`
+	ResultCode   string       `json:"result_code"`
+	ResultReason ResultReason `json:"result_reason,omitempty"`
+	TimeClass    string       `json:"time_class"`
+	Rated        bool         `json:"rated"`
+	StartTime    time.Time    `json:"start_time"`
+	EndTime      *time.Time   `json:"end_time,omitempty"`
+	Tournament   string       `json:"tournament,omitempty"`
+	Match        string       `json:"match,omitempty"`
 }
@@ -0,0 +1,39 @@
+package models
+
+// SquareStat holds a player's statistics for one board square
+type SquareStat struct {
+	Square      string  `json:"square"`                  // Square name, e.g. "e4"
+	Moves       int     `json:"moves"`                   // Moves ending on the square
+	Captures    int     `json:"captures"`                // Captures made on the square
+	Blunders    int     `json:"blunders"`                // Blunders ending on the square (analyzed games only)
+	Mistakes    int     `json:"mistakes"`                // Mistakes ending on the square (analyzed games only)
+	AvgMoveTime float64 `json:"avg_move_time,omitempty"` // Average seconds spent on moves to the square
+}
+
+// PatternStat counts a recurring move pattern
+type PatternStat struct {
+	Pattern string `json:"pattern"` // e.g. "Nb1-d2-f1" for a maneuver or "f5" for a pawn break
+	Color   string `json:"color"`   // Side the player had when playing it
+	Count   int    `json:"count"`
+}
+
+// PlayerHeatmaps holds visualization-oriented per-square statistics across a player's games
+type PlayerHeatmaps struct {
+	Username      string        `json:"username"`
+	Games         int           `json:"games"`          // Games included
+	AnalyzedGames int           `json:"analyzed_games"` // Games analyzed for blunder and mistake squares
+	Squares       []SquareStat  `json:"squares"`        // All 64 squares, a1 to h8
+	Maneuvers     []PatternStat `json:"maneuvers"`      // Most frequent multi-move piece maneuvers
+	PawnBreaks    []PatternStat `json:"pawn_breaks"`    // Most frequent pawn breaks
+
+	FailedArchives []ArchiveFailure `json:"failed_archives,omitempty"` // Months that couldn't be fetched
+	Archives       []ArchiveStatus  `json:"archives,omitempty"`        // Every month walked and how fetching it went
+}
+
+// HeatmapRequest selects the games used for a player's heatmaps
+type HeatmapRequest struct {
+	Username string         `json:"username"`
+	Games    int            `json:"games"`   // Recent games to include
+	Analyze  int            `json:"analyze"` // How many of them to analyze for blunder squares
+	Settings EngineSettings `json:"settings"`
+}
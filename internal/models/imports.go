@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// Import statuses
+const (
+	ImportStatusUploading   = "uploading"   // Waiting for more chunks
+	ImportStatusDownloading = "downloading" // Downloading a Chess.com archive in the background
+	ImportStatusParsing     = "parsing"     // Upload complete, games are being parsed in the background
+	ImportStatusCompleted   = "completed"
+	ImportStatusFailed      = "failed"
+)
+
+// ImportRequest creates a resumable upload of a PGN database, or imports a Chess.com monthly
+// archive when ChessCom is set
+type ImportRequest struct {
+	Filename string           `json:"filename"`
+	Size     int64            `json:"size"` // Total upload size in bytes
+	ChessCom *ChessComArchive `json:"chesscom,omitempty"`
+}
+
+// ChessComArchive names a player's monthly Chess.com archive
+type ChessComArchive struct {
+	Username string `json:"username"`
+	Year     int    `json:"year"`
+	Month    int    `json:"month"`
+}
+
+// ImportUpload is the state of a resumable PGN upload and of the parsing that follows it
+type ImportUpload struct {
+	ID          string     `json:"id"` // Upload token used to send chunks
+	Filename    string     `json:"filename,omitempty"`
+	Status      string     `json:"status"`
+	Size        int64      `json:"size"`
+	Offset      int64      `json:"offset"`       // Bytes received so far; the next chunk starts here
+	ParsedBytes int64      `json:"parsed_bytes"` // Bytes of the upload parsed so far
+	Progress    float64    `json:"progress"`     // Parsing progress from 0 to 1
+	Games       int        `json:"games"`        // Games parsed successfully
+	FailedGames int        `json:"failed_games"` // Games that could not be parsed
+	Error       string     `json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// ImportedGame is one game found in an imported PGN database
+type ImportedGame struct {
+	Index  int    `json:"index"` // Position of the game in the database, starting at 0
+	White  string `json:"white,omitempty"`
+	Black  string `json:"black,omitempty"`
+	Result string `json:"result,omitempty"`
+	Date   string `json:"date,omitempty"`
+	Event  string `json:"event,omitempty"`
+	Moves  int    `json:"moves"`
+	PGN    string `json:"pgn"`
+	Error  string `json:"error,omitempty"` // Why the game could not be parsed
+}
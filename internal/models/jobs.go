@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// Analysis job statuses
+const (
+	JobStatusRunning   = "running"
+	JobStatusCompleted = "completed"
+	JobStatusFailed    = "failed"
+)
+
+// AnalysisJob is a game analysis running in the background, for games too long to analyze
+// within the request that asked for it
+type AnalysisJob struct {
+	ID          string     `json:"id"`
+	GameURL     string     `json:"game_url"`
+	Plies       int        `json:"plies"`                 // Plies of the game being analyzed
+	Status      string     `json:"status"`                // running, completed or failed
+	AnalysisID  string     `json:"analysis_id,omitempty"` // Stored analysis, once completed
+	Error       string     `json:"error,omitempty"`       // Why the analysis failed
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"` // When the job completed or failed
+}
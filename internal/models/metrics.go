@@ -0,0 +1,100 @@
+package models
+
+// AnalysisCost records the resources used by a single game analysis
+type AnalysisCost struct {
+	EngineTime   int64   `json:"engine_time"`   // Engine search time in milliseconds
+	WallClock    int64   `json:"wall_clock"`    // Elapsed time in milliseconds
+	Nodes        int64   `json:"nodes"`         // Nodes searched
+	Positions    int     `json:"positions"`     // Positions sent to the engine
+	PeakHashMB   float64 `json:"peak_hash_mb"`  // Peak hash table usage reported by the engine
+	CacheHits    int     `json:"cache_hits"`    // Times the analysis was served from the cache
+	EngineErrors int     `json:"engine_errors"` // Positions the engine failed to analyze
+	OpeningHits  int     `json:"opening_hits"`  // Positions served from the opening cache
+	InlineEvals  int     `json:"inline_evals"`  // Positions evaluated by the PGN's [%eval] annotations
+}
+
+// MetricsSnapshot aggregates analysis costs for capacity planning
+type MetricsSnapshot struct {
+	Analyses         int64   `json:"analyses"`           // Analyses computed by the engine
+	CacheHits        int64   `json:"cache_hits"`         // Requests served from the cache
+	CacheHitRate     float64 `json:"cache_hit_rate"`     // Share of requests served from the cache
+	TotalEngineTime  int64   `json:"total_engine_time"`  // Engine search time in milliseconds
+	TotalWallClock   int64   `json:"total_wall_clock"`   // Elapsed analysis time in milliseconds
+	TotalNodes       int64   `json:"total_nodes"`        // Nodes searched
+	TotalPositions   int64   `json:"total_positions"`    // Positions sent to the engine
+	AverageWallClock float64 `json:"average_wall_clock"` // Mean elapsed time per analysis in milliseconds
+	PeakHashMB       float64 `json:"peak_hash_mb"`       // Highest hash usage seen in any analysis
+	TotalEngines     int     `json:"total_engines"`      // Engines across all pools
+	AvailableEngines int     `json:"available_engines"`  // Idle engines across all pools
+}
+
+// EnginePoolStatus reports the capacity and health of one engine pool
+type EnginePoolStatus struct {
+	Name             string         `json:"name"`
+	Engine           string         `json:"engine"`               // Engine version, e.g. "Stockfish 16"
+	EvalScale        string         `json:"eval_scale,omitempty"` // Native evaluation scale of the engine (normalized or legacy)
+	Variants         []string       `json:"variants,omitempty"`   // Variants routed to the pool
+	Profiles         []string       `json:"profiles,omitempty"`   // Analysis profiles routed to the pool
+	TotalEngines     int            `json:"total_engines"`
+	AvailableEngines int            `json:"available_engines"` // Engines free to take, including those a lazy pool hasn't started
+	RunningEngines   int            `json:"running_engines"`   // Engine processes started; below total_engines in a lazy pool
+	Queued           int            `json:"queued"`            // Requests waiting for an engine
+	MaxQueue         int            `json:"max_queue"`         // Requests allowed to wait (0 = unbounded)
+	EstimatedWaitMS  int64          `json:"estimated_wait_ms"` // How long a new request would wait
+	Healthy          bool           `json:"healthy"`
+	Error            string         `json:"error,omitempty"`   // Why the pool could not start
+	Limits           *EngineLimits  `json:"limits,omitempty"`  // Resource limits applied to the pool's engine processes
+	Sandbox          *EngineSandbox `json:"sandbox,omitempty"` // Restrictions the pool's engine processes run under
+}
+
+// EngineLimits bounds the host resources engine processes may use
+type EngineLimits struct {
+	Nice        int    `json:"nice,omitempty"`          // Scheduling niceness, 1 (slightly lower) to 19 (lowest)
+	CPUs        []int  `json:"cpus,omitempty"`          // CPUs engines may run on (empty = any)
+	MaxMemoryMB int    `json:"max_memory_mb,omitempty"` // Address space limit of each engine process
+	Cgroup      string `json:"cgroup,omitempty"`        // cgroup v2 directory engines are moved into
+	CPUQuota    int    `json:"cpu_quota,omitempty"`     // Percent of one CPU all engines in the cgroup share
+}
+
+// IsZero reports whether no limit is set
+func (l EngineLimits) IsZero() bool {
+	return l.Nice == 0 && len(l.CPUs) == 0 && l.MaxMemoryMB == 0 && l.Cgroup == "" && l.CPUQuota == 0
+}
+
+// EngineSandbox restricts what engine processes may do, for deployments running engines they don't trust
+type EngineSandbox struct {
+	User      string   `json:"user,omitempty"`       // Account engines run as; switching needs root
+	NoNetwork bool     `json:"no_network,omitempty"` // Engines run in a network namespace of their own, without interfaces
+	ReadOnly  bool     `json:"read_only,omitempty"`  // Engines can't write files, and read only system libraries, their own directory and ReadPaths
+	ReadPaths []string `json:"read_paths,omitempty"` // Further paths read-only engines may read, e.g. the NNUE network
+	Seccomp   bool     `json:"seccomp,omitempty"`    // Engines can't open sockets or use system calls that administer the host
+	AppArmor  string   `json:"apparmor,omitempty"`   // AppArmor profile engines are confined to (empty = none)
+}
+
+// IsZero reports whether engines run unrestricted
+func (s EngineSandbox) IsZero() bool {
+	return s.User == "" && !s.NoNetwork && !s.ReadOnly && !s.Seccomp && s.AppArmor == ""
+}
+
+// LoadShedding caps the searches of non-priority analyses while the engine queue is long, so a
+// busy server keeps answering instead of rejecting requests
+type LoadShedding struct {
+	QueueLength int `json:"queue_length"` // Requests waiting for an engine from which analyses are reduced (0 = never)
+	MaxMultiPV  int `json:"max_multipv"`  // Lines searched by reduced analyses (0 = unchanged)
+	MaxDepth    int `json:"max_depth"`    // Depth of reduced analyses (0 = unchanged)
+}
+
+// Enabled reports whether analyses are ever reduced
+func (l LoadShedding) Enabled() bool {
+	return l.QueueLength > 0 && (l.MaxMultiPV > 0 || l.MaxDepth > 0)
+}
+
+// EngineTuning records how the default engine pool was sized for the host
+type EngineTuning struct {
+	CPUs       int      `json:"cpus"`                 // CPUs available to engines
+	MemoryMB   int      `json:"memory_mb"`            // Memory available to engines (0 = unknown)
+	MaxEngines int      `json:"max_engines"`          // Engines in the default pool
+	Threads    int      `json:"threads"`              // Search threads of each engine
+	HashSize   int      `json:"hash_size"`            // Hash table of each engine in MB
+	Overridden []string `json:"overridden,omitempty"` // Settings taken from the configuration instead of derived
+}
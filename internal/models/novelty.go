@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// NoveltyRequest asks where a player's recent games left opening theory
+type NoveltyRequest struct {
+	Username string         `json:"username"`
+	Games    int            `json:"games"`    // Recent games to scan
+	Source   string         `json:"source"`   // Reference database: embedded or lichess
+	Settings EngineSettings `json:"settings"` // Engine settings used to evaluate the novelties
+}
+
+// NoveltyReport lists the first move of each game the reference database has never seen, and
+// how the player fared with the novelties they played
+type NoveltyReport struct {
+	Username          string        `json:"username"`
+	Source            string        `json:"source"`
+	GeneratedAt       time.Time     `json:"generated_at"`
+	PlayerNovelties   int           `json:"player_novelties"`   // Games where the player left theory first
+	OpponentNovelties int           `json:"opponent_novelties"` // Games where the opponent did
+	AveragePly        float64       `json:"average_ply"`        // Average ply of the player's novelties
+	AverageEvaluation float64       `json:"average_evaluation"` // Average evaluation after the player's novelties, from their point of view
+	Games             []GameNovelty `json:"games"`              // Scanned games, newest first
+
+	FailedArchives []ArchiveFailure `json:"failed_archives,omitempty"` // Months that couldn't be fetched
+	Archives       []ArchiveStatus  `json:"archives,omitempty"`        // Every month walked and how fetching it went
+}
+
+// GameNovelty is a scanned game and its novelty, if it left the reference database in the opening
+type GameNovelty struct {
+	URL         string   `json:"url"`
+	White       string   `json:"white"`
+	Black       string   `json:"black"`
+	PlayerColor string   `json:"player_color"`
+	Opening     string   `json:"opening"`
+	Novelty     *Novelty `json:"novelty,omitempty"` // Nil when the game stayed in theory as far as it was followed
+}
+
+// Novelty is the first move of a game that no reference game played in its position
+type Novelty struct {
+	Ply            int      `json:"ply"` // 1 for White's first move
+	MoveNumber     int      `json:"move_number"`
+	Color          string   `json:"color"`
+	Move           string   `json:"move"` // SAN
+	ByPlayer       bool     `json:"by_player"`
+	FENBefore      string   `json:"fen_before"`
+	FEN            string   `json:"fen"`                  // Position after the novelty
+	ReferenceGames int      `json:"reference_games"`      // Reference games that reached the position before it
+	ReferenceMove  string   `json:"reference_move"`       // Move the reference games played most in that position
+	Evaluation     *float64 `json:"evaluation,omitempty"` // Engine evaluation after the novelty, from White's point of view
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// OpeningCacheStats describes the opening prefix cache, which serves the engine results of the
+// first plies of games from earlier analyses
+type OpeningCacheStats struct {
+	MaxPlies     int            `json:"max_plies"`     // Plies from the start cached per game (0 = disabled)
+	Positions    int            `json:"positions"`     // Cached engine results
+	MaxPositions int            `json:"max_positions"` // Cached results kept at most
+	Hits         int64          `json:"hits"`
+	Misses       int64          `json:"misses"`
+	HitRate      float64        `json:"hit_rate"`
+	File         string         `json:"file,omitempty"` // File the cache is persisted to
+	Warmup       *OpeningWarmup `json:"warmup,omitempty"`
+}
+
+// OpeningWarmup is the progress of pre-analyzing popular opening lines
+type OpeningWarmup struct {
+	Lines      int        `json:"lines"`     // ECO lines being warmed
+	Positions  int        `json:"positions"` // Distinct positions in those lines
+	Analyzed   int        `json:"analyzed"`  // Positions done so far, including ones already cached
+	Running    bool       `json:"running"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// OpeningWarmupRequest asks for the most popular ECO lines to be pre-analyzed
+type OpeningWarmupRequest struct {
+	Lines    int            `json:"lines"`    // Most popular lines to warm (default 20)
+	Settings EngineSettings `json:"settings"` // Engine settings of the analyses the results will serve
+}
@@ -0,0 +1,16 @@
+package models
+
+// PageRequest asks for one page of a list
+type PageRequest struct {
+	Cursor string `json:"cursor,omitempty"` // next_cursor or prev_cursor of a previous page, empty for the first page
+	Limit  int    `json:"limit,omitempty"`  // Items per page, 0 for the list's default
+}
+
+// Page is one page of a list; every list endpoint answers with it. Cursors are opaque and only
+// valid for the list that issued them.
+type Page[T any] struct {
+	Items         []T    `json:"items"`
+	NextCursor    string `json:"next_cursor,omitempty"` // Set when later items exist
+	PrevCursor    string `json:"prev_cursor,omitempty"` // Set when earlier items exist
+	TotalEstimate int    `json:"total_estimate"`        // Items in the whole list when the page was read
+}
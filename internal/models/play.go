@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// Play session statuses
+const (
+	PlayStatusActive   = "active"
+	PlayStatusFinished = "finished"
+)
+
+// PlayRequest starts a game against the engine
+type PlayRequest struct {
+	Color      string         `json:"color"`       // Color the user plays: white or black
+	SkillLevel int            `json:"skill_level"` // Engine skill level, 1-20
+	Elo        int            `json:"elo"`         // Engine strength as an Elo rating; overrides skill_level
+	FEN        string         `json:"fen"`         // Starting position, the initial position by default
+	ShowEval   bool           `json:"show_eval"`   // Report the engine's evaluation with each reply
+	Settings   EngineSettings `json:"settings"`    // Depth and time limit of the engine's moves
+}
+
+// PlayMoveRequest is a move played by the user
+type PlayMoveRequest struct {
+	Move string `json:"move"` // SAN or UCI notation
+}
+
+// PlaySession is a game between a user and the engine
+type PlaySession struct {
+	ID          string    `json:"id"`
+	Color       string    `json:"color"` // Color the user plays
+	SkillLevel  int       `json:"skill_level,omitempty"`
+	Elo         int       `json:"elo,omitempty"`
+	StartFEN    string    `json:"start_fen"`
+	FEN         string    `json:"fen"`   // Current position
+	Moves       []string  `json:"moves"` // Moves played so far in SAN
+	Status      string    `json:"status"`
+	Result      string    `json:"result"`                // 1-0, 0-1, 1/2-1/2 or * while the game is on
+	Termination string    `json:"termination,omitempty"` // Why the game ended
+	EngineMove  string    `json:"engine_move,omitempty"` // Engine's last reply in SAN
+	Evaluation  *float64  `json:"evaluation,omitempty"`  // Engine's evaluation before its last reply, from White's point of view (show_eval only)
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
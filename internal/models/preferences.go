@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// ClassificationThresholds holds the accuracy limits used to classify moves
+type ClassificationThresholds struct {
+	Blunder    float64 `json:"blunder"`    // Accuracy below which a move is a blunder
+	Mistake    float64 `json:"mistake"`    // Accuracy below which a move is a mistake
+	Inaccuracy float64 `json:"inaccuracy"` // Accuracy below which a move is an inaccuracy
+}
+
+// DefaultClassificationThresholds returns the built-in classification thresholds
+func DefaultClassificationThresholds() ClassificationThresholds {
+	return ClassificationThresholds{
+		Blunder:    50,
+		Mistake:    80,
+		Inaccuracy: 90,
+	}
+}
+
+// UserPreferences holds saved per-user defaults applied to requests
+type UserPreferences struct {
+	User             string                    `json:"user"`                        // API key or username
+	DefaultProfile   string                    `json:"default_profile,omitempty"`   // Analysis profile name
+	ExportFormat     string                    `json:"export_format,omitempty"`     // pgn/json/html/gif
+	BoardOrientation string                    `json:"board_orientation,omitempty"` // white/black
+	Thresholds       *ClassificationThresholds `json:"thresholds,omitempty"`        // Move classification thresholds
+	AccuracyModel    string                    `json:"accuracy_model,omitempty"`    // Accuracy model that scores moves
+	UpdatedAt        time.Time                 `json:"updated_at"`                  // Last update time
+}
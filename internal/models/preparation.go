@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// PreparationRequest asks for a dossier on an opponent's openings
+type PreparationRequest struct {
+	Opponent string         `json:"opponent"`
+	Color    string         `json:"color"`    // Color the requester will play: white or black
+	Games    int            `json:"games"`    // Recent opponent games to scan
+	Settings EngineSettings `json:"settings"` // Engine settings used to search for antidotes
+}
+
+// PreparationDossier summarizes how an opponent handles the openings of the requested color
+type PreparationDossier struct {
+	Opponent      string            `json:"opponent"`
+	Color         string            `json:"color"`          // Color the requester will play
+	OpponentColor string            `json:"opponent_color"` // Color the opponent will play
+	GeneratedAt   time.Time         `json:"generated_at"`
+	Games         int               `json:"games"` // Opponent games with that color
+	Wins          int               `json:"wins"`  // Opponent's results
+	Draws         int               `json:"draws"`
+	Losses        int               `json:"losses"`
+	Score         float64           `json:"score"`    // Opponent's score percentage
+	Openings      []PreparedOpening `json:"openings"` // Most played first
+
+	FailedArchives []ArchiveFailure `json:"failed_archives,omitempty"` // Months that couldn't be fetched
+	Archives       []ArchiveStatus  `json:"archives,omitempty"`        // Every month walked and how fetching it went
+}
+
+// PreparedOpening is an opening the opponent plays, their results in it and the engine's suggested antidotes
+type PreparedOpening struct {
+	Name      string         `json:"name"`
+	Games     int            `json:"games"`
+	Share     float64        `json:"share"` // Percentage of the opponent's games with that color
+	Wins      int            `json:"wins"`
+	Draws     int            `json:"draws"`
+	Losses    int            `json:"losses"`
+	Score     float64        `json:"score"`               // Opponent's score percentage
+	Line      []string       `json:"line"`                // Opponent's most common line in SAN, ending with their move
+	LineGames int            `json:"line_games"`          // Games that followed the line
+	FEN       string         `json:"fen"`                 // Position after the line, with the requester to move
+	Antidotes []PositionLine `json:"antidotes,omitempty"` // Engine's best replies in that position
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// RawArchive describes a monthly archive response kept byte for byte in the blob store, so games
+// can be traced back to exactly what Chess.com served even after they are edited or removed.
+// Every fetch that returned new content is kept as its own snapshot.
+type RawArchive struct {
+	Key       string    `json:"key"` // Blob store key
+	Username  string    `json:"username"`
+	Year      int       `json:"year"`
+	Month     int       `json:"month"`
+	URL       string    `json:"url"` // Chess.com endpoint it was fetched from
+	ETag      string    `json:"etag,omitempty"`
+	FetchedAt time.Time `json:"fetched_at"`
+	Size      int64     `json:"size"`  // Bytes
+	Games     int       `json:"games"` // Games in the archive
+
+	GameURLs []string `json:"-"` // URLs of the games in the archive
+}
+
+// GameSource is a stored game's entry in the most recent raw archive that contained it
+type GameSource struct {
+	GameURL string          `json:"game_url"`
+	Archive *RawArchive     `json:"archive"`
+	Game    json.RawMessage `json:"game"` // The game's JSON exactly as served
+}
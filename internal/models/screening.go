@@ -0,0 +1,60 @@
+package models
+
+import "time"
+
+// ScreeningRequest selects the games of a player to screen for engine-like consistency
+type ScreeningRequest struct {
+	Username string   `json:"username"`         // Player screened
+	PGNs     []string `json:"pgns,omitempty"`   // Games to screen, e.g. a tournament's (default: the player's recent games)
+	Games    int      `json:"games,omitempty"`  // Recent games to screen when no PGNs are given
+	Depths   []int    `json:"depths,omitempty"` // Engine depths the player's moves are compared at
+
+	AcknowledgeDisclaimer bool `json:"acknowledge_disclaimer"` // Required: the report is a screening signal, not evidence
+}
+
+// ScreeningReport bundles statistical signals organizers use to decide whether a player's
+// games deserve a closer look. None of them proves or disproves engine use.
+type ScreeningReport struct {
+	Username     string            `json:"username"`
+	GeneratedAt  time.Time         `json:"generated_at"`
+	Disclaimer   string            `json:"disclaimer"`
+	Games        int               `json:"games"`         // Games the player was found in
+	EngineMatch  []EngineMatchStat `json:"engine_match"`  // One entry per requested depth, shallowest first
+	MoveTimes    *MoveTimeStat     `json:"move_times"`    // Nil when no game had a clock for every move
+	Performance  *PerformanceStat  `json:"performance"`   // Nil when no decided game had both ratings
+	Observations []string          `json:"observations"`  // Signals outside the usual range, for a human to review
+	SkippedGames int               `json:"skipped_games"` // Games that couldn't be parsed or didn't include the player
+
+	FailedArchives []ArchiveFailure `json:"failed_archives,omitempty"` // Months that couldn't be fetched
+	Archives       []ArchiveStatus  `json:"archives,omitempty"`        // Every month walked and how fetching it went
+}
+
+// EngineMatchStat compares a player's moves with the engine's first choice at one depth.
+// Opening moves and moves in already decided positions are left out.
+type EngineMatchStat struct {
+	Depth                int     `json:"depth"`
+	Games                int     `json:"games"`                  // Games analyzed at this depth
+	Moves                int     `json:"moves"`                  // Moves compared
+	Matches              int     `json:"matches"`                // Moves equal to the engine's first choice
+	MatchRate            float64 `json:"match_rate"`             // Percentage of moves matched
+	AverageCentipawnLoss float64 `json:"average_centipawn_loss"` // Over the compared moves
+}
+
+// MoveTimeStat describes how evenly a player spent their clock, in seconds per move
+type MoveTimeStat struct {
+	Moves     int     `json:"moves"`
+	Mean      float64 `json:"mean"`
+	StdDev    float64 `json:"std_dev"`
+	Variation float64 `json:"variation"` // Standard deviation over the mean; low values mean uniform move times
+}
+
+// PerformanceStat compares a player's results with what their rating predicts
+type PerformanceStat struct {
+	Games                 int     `json:"games"`                   // Decided games with both ratings known
+	Score                 float64 `json:"score"`                   // Points scored
+	ExpectedScore         float64 `json:"expected_score"`          // Points predicted by the Elo formula
+	AverageRating         int     `json:"average_rating"`          // Player's average rating in those games
+	AverageOpponentRating int     `json:"average_opponent_rating"` // Opponents' average rating
+	PerformanceRating     int     `json:"performance_rating"`      // Linear performance rating
+	RatingDeviation       int     `json:"rating_deviation"`        // Performance rating minus average rating
+}
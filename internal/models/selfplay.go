@@ -0,0 +1,40 @@
+package models
+
+// Reasons a game played on the server ended
+const (
+	TerminationCheckmate            = "checkmate"
+	TerminationStalemate            = "stalemate"
+	TerminationFiftyMoveRule        = "fifty_move_rule"
+	TerminationThreefoldRepetition  = "threefold_repetition"
+	TerminationInsufficientMaterial = "insufficient_material"
+	TerminationMaxPlies             = "max_plies" // Stopped at the ply limit; the game is unfinished
+	TerminationResignation          = "resignation"
+)
+
+// SelfPlaySide limits the engine's search for one side
+type SelfPlaySide struct {
+	Depth     int `json:"depth"`
+	TimeLimit int `json:"time_limit"` // Milliseconds per move
+}
+
+// SelfPlayRequest asks the engine to play a position out against itself
+type SelfPlayRequest struct {
+	FEN      string         `json:"fen"`
+	White    SelfPlaySide   `json:"white"`
+	Black    SelfPlaySide   `json:"black"`
+	MaxPlies int            `json:"max_plies"`
+	Settings EngineSettings `json:"settings"` // Threads and hash size shared by both sides
+	Notation string         `json:"notation"` // Display notation of the moves: san (default), figurine or a language code
+}
+
+// SelfPlayGame is the continuation the engine played
+type SelfPlayGame struct {
+	FEN         string    `json:"fen"`         // Starting position
+	Moves       []string  `json:"moves"`       // Continuation in the requested notation
+	UCIMoves    []string  `json:"uci_moves"`   // Continuation in UCI notation
+	Evaluations []float64 `json:"evaluations"` // Evaluation before each move, from White's point of view
+	Result      string    `json:"result"`      // 1-0, 0-1, 1/2-1/2 or * when stopped at the ply limit
+	Termination string    `json:"termination"` // Why the game ended
+	FinalFEN    string    `json:"final_fen"`
+	PGN         string    `json:"pgn"`
+}
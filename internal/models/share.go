@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// ShareLink represents a read-only public link to a stored analysis
+type ShareLink struct {
+	Token      string     `json:"token"`                // Public token used in the share URL
+	AnalysisID string     `json:"analysis_id"`          // Shared analysis
+	URL        string     `json:"url,omitempty"`        // Public URL of the shared analysis
+	CreatedAt  time.Time  `json:"created_at"`           // When the link was created
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"` // Optional expiry time
+}
+
+// Expired reports whether the link has expired at the given time
+func (l *ShareLink) Expired(now time.Time) bool {
+	return l.ExpiresAt != nil && now.After(*l.ExpiresAt)
+}
+
+// ShareRequest represents a request to create a share link
+type ShareRequest struct {
+	ExpiresIn int `json:"expires_in"` // Link lifetime in seconds (0 = never expires)
+}
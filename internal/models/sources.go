@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// Game sources games can be ingested from
+const (
+	GameSourceChessCom = "chesscom" // Chess.com monthly archives
+	GameSourceLichess  = "lichess"  // Lichess game exports
+	GameSourcePGN      = "pgn"      // PGN files in the configured local directory
+	GameSourceTWIC     = "twic"     // Issues of The Week in Chess
+)
+
+// GameSourceQuery selects the games to ingest from a source
+type GameSourceQuery struct {
+	Source     string    `json:"source"`               // chesscom, lichess, pgn or twic
+	Player     string    `json:"player,omitempty"`     // Player whose games are fetched; required for online sources
+	Collection string    `json:"collection,omitempty"` // PGN file or subdirectory (pgn), or issue number (twic)
+	Since      time.Time `json:"since,omitempty"`      // Games started from then on (zero = any)
+	Until      time.Time `json:"until,omitempty"`      // Games started before then (zero = any)
+	Max        int       `json:"max,omitempty"`        // Most games to ingest
+}
+
+// IngestJob is an ingest running in the background. Sources can hold thousands of games, so
+// ingests are jobs clients poll.
+type IngestJob struct {
+	ID          string        `json:"id"`
+	Source      string        `json:"source"`
+	Player      string        `json:"player,omitempty"`
+	Collection  string        `json:"collection,omitempty"`
+	Status      string        `json:"status"`           // running, completed or failed
+	Result      *IngestResult `json:"result,omitempty"` // What was ingested, once completed
+	Error       string        `json:"error,omitempty"`  // Why the ingest failed
+	CreatedAt   time.Time     `json:"created_at"`
+	CompletedAt *time.Time    `json:"completed_at,omitempty"` // When the job completed or failed
+}
+
+// IngestResult reports the games ingested from a source
+type IngestResult struct {
+	Source         string   `json:"source"`
+	Fetched        int      `json:"fetched"`             // Games read from the source
+	Added          int      `json:"added"`               // Games not stored before
+	AnalysesQueued int      `json:"analyses_queued"`     // New games queued for automatic analysis
+	Skipped        int      `json:"skipped"`             // Games that couldn't be read
+	Errors         []string `json:"errors,omitempty"`    // Why games were skipped, first few only
+	Truncated      bool     `json:"truncated,omitempty"` // The source had more games than max
+}
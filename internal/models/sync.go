@@ -0,0 +1,94 @@
+package models
+
+import "time"
+
+// SyncState tracks the incremental archive sync of a player
+type SyncState struct {
+	Username       string            `json:"username"`
+	ArchiveETags   map[string]string `json:"-"`                    // ETag per "YYYY/MM" archive
+	LastArchive    string            `json:"last_archive"`         // Most recent archive synced ("YYYY/MM")
+	LastSync       time.Time         `json:"last_sync"`            // When the player was last synced
+	GamesSynced    int               `json:"games_synced"`         // Games stored for the player
+	AnalysesQueued int               `json:"analyses_queued"`      // Games queued for automatic analysis
+	GamesRepaired  int               `json:"games_repaired"`       // Games whose missing PGN was re-fetched
+	Unanalyzable   int               `json:"unanalyzable"`         // Games marked unanalyzable
+	LastError      string            `json:"last_error,omitempty"` // Error from the last sync, if any
+	Ratings        map[string]int    `json:"ratings,omitempty"`    // Latest rating per time class, from rated synced games
+	Title          string            `json:"title,omitempty"`      // Title the player last played under
+	Streak         int               `json:"streak"`               // Consecutive wins (positive) or losses (negative)
+}
+
+// NewGames are the games a player finished after a cursor, oldest first
+type NewGames struct {
+	Username string      `json:"username"`
+	Consumer string      `json:"consumer,omitempty"` // Integrator the stored cursor belongs to
+	Since    int64       `json:"since"`              // Cursor the games are newer than, in Unix seconds (0 = first poll)
+	Cursor   int64       `json:"cursor"`             // End time of the last game returned, to poll from next
+	HasMore  bool        `json:"has_more"`           // More new games remain after the returned ones
+	Games    []*GameInfo `json:"games"`
+}
+
+// Player status notification types
+const (
+	NotificationRatingMilestone = "rating_milestone" // A rating crossed a multiple of the milestone step
+	NotificationTitleChange     = "title_change"
+	NotificationWinStreak       = "win_streak"
+	NotificationLossStreak      = "loss_streak"
+	NotificationBlunderCheck    = "blunder_check" // A just-finished game in which the player blundered
+)
+
+// PlayerNotification reports a change in a synced player's status, detected from their new games
+type PlayerNotification struct {
+	ID             string    `json:"id"`
+	Type           string    `json:"type"`
+	Username       string    `json:"username"`
+	Message        string    `json:"message"`
+	GameURL        string    `json:"game_url"`                  // Game that brought the change
+	TimeClass      string    `json:"time_class,omitempty"`      // Rating milestones
+	Rating         int       `json:"rating,omitempty"`          // Rating milestones: rating after the game
+	PreviousRating int       `json:"previous_rating,omitempty"` // Rating milestones
+	Milestone      int       `json:"milestone,omitempty"`       // Rating milestones: the multiple reached or dropped below
+	Title          string    `json:"title,omitempty"`           // Title changes: the new title (empty when dropped)
+	PreviousTitle  string    `json:"previous_title,omitempty"`  // Title changes
+	Streak         int       `json:"streak,omitempty"`          // Streaks: games won or lost in a row
+	AnalysisID     string    `json:"analysis_id,omitempty"`     // Blunder checks: the scan of the game
+	MoveNumber     int       `json:"move_number,omitempty"`     // Blunder checks: move number of the worst blunder
+	Move           string    `json:"move,omitempty"`            // Blunder checks: the worst blunder in SAN
+	Refutation     []string  `json:"refutation,omitempty"`      // Blunder checks: the engine's punishing line in SAN
+	Blunders       int       `json:"blunders,omitempty"`        // Blunder checks: blunders the player made in the game
+	Time           time.Time `json:"time"`
+}
+
+// Reasons a synced game can't be analyzed
+const (
+	UnanalyzableNoMoves      = "no_moves"            // Aborted before a move was played
+	UnanalyzableVariant      = "unsupported_variant" // Not standard chess
+	UnanalyzableNotFound     = "not_found"           // Chess.com no longer serves the game
+	UnanalyzableInvalidMoves = "invalid_moves"       // The re-fetched moves don't replay
+	UnanalyzableUnrecognized = "unrecognized_url"    // The game URL carries no game ID
+	UnanalyzableMalformed    = "malformed"           // The archive entry didn't have the expected shape
+)
+
+// Outcomes of a game repair
+const (
+	RepairStatusRepaired     = "repaired"
+	RepairStatusUnanalyzable = "unanalyzable"
+	RepairStatusFailed       = "failed" // Re-fetching failed; the game is retried by the next repair
+)
+
+// RepairReport summarizes a repair run over a player's stored games with a missing PGN or FEN
+type RepairReport struct {
+	Username     string         `json:"username"`
+	Checked      int            `json:"checked"`
+	Repaired     int            `json:"repaired"`
+	Unanalyzable int            `json:"unanalyzable"`
+	Failed       int            `json:"failed"`
+	Games        []RepairedGame `json:"games"`
+}
+
+// RepairedGame is the outcome of repairing one game
+type RepairedGame struct {
+	URL    string `json:"url"`
+	Status string `json:"status"`           // repaired, unanalyzable or failed
+	Reason string `json:"reason,omitempty"` // Unanalyzable reason or fetch error
+}
@@ -0,0 +1,66 @@
+package models
+
+import "time"
+
+// TeamMatchRequest asks for a board pairing between two Chess.com clubs
+type TeamMatchRequest struct {
+	Club         string `json:"club"`          // Club ID (URL name) of the requester's club
+	OpponentClub string `json:"opponent_club"` // Club ID of the opponent club
+	TimeClass    string `json:"time_class"`    // Rating used for pairing: daily, rapid, blitz or bullet (default: daily)
+	Boards       int    `json:"boards"`        // Boards to pair (default: as many as both rosters allow)
+	ActiveDays   int    `json:"active_days"`   // Members without a rated game in this many days are paired last (default: 30)
+	PrepGames    int    `json:"prep_games"`    // Recent games of each opponent scanned for openings (default: 30)
+}
+
+// TeamMatchJob is a match plan being prepared in the background. Looking up both rosters and
+// every opponent's recent games takes a few hundred Chess.com requests, so plans are made as jobs
+// clients poll.
+type TeamMatchJob struct {
+	ID           string         `json:"id"`
+	Club         string         `json:"club"`
+	OpponentClub string         `json:"opponent_club"`
+	Status       string         `json:"status"`          // running, completed or failed
+	Plan         *TeamMatchPlan `json:"plan,omitempty"`  // The plan, once completed
+	Error        string         `json:"error,omitempty"` // Why planning failed
+	CreatedAt    time.Time      `json:"created_at"`
+	CompletedAt  *time.Time     `json:"completed_at,omitempty"` // When the job completed or failed
+}
+
+// TeamMatchPlan is a suggested board pairing for a club match
+type TeamMatchPlan struct {
+	Club         string       `json:"club"`
+	OpponentClub string       `json:"opponent_club"`
+	TimeClass    string       `json:"time_class"`
+	GeneratedAt  time.Time    `json:"generated_at"`
+	Boards       []TeamBoard  `json:"boards"`
+	Bench        []TeamMember `json:"bench"`               // Eligible members of the club left without a board
+	Unrated      []string     `json:"unrated"`             // Members of either club without a rating in the time class
+	Failed       []string     `json:"failed,omitempty"`    // Members whose ratings couldn't be fetched
+	Truncated    bool         `json:"truncated,omitempty"` // A roster was larger than the members looked up
+}
+
+// TeamMember is a club member's rating and recent activity
+type TeamMember struct {
+	Username string     `json:"username"`
+	Rating   int        `json:"rating"`
+	LastGame *time.Time `json:"last_game,omitempty"` // Last rated game in the time class
+	Active   bool       `json:"active"`              // Played a rated game within the active window
+}
+
+// TeamBoard is one board of a suggested pairing. Club matches are played with both colors,
+// so the opponent's openings are summarized for each.
+type TeamBoard struct {
+	Board       int              `json:"board"`
+	Player      TeamMember       `json:"player"`
+	Opponent    TeamMember       `json:"opponent"`
+	RatingDiff  int              `json:"rating_diff"` // Player's rating minus the opponent's
+	Preparation BoardPreparation `json:"preparation"`
+}
+
+// BoardPreparation summarizes the openings an opponent played in their recent games
+type BoardPreparation struct {
+	Games   int               `json:"games"`           // Recent games scanned
+	AsWhite []PreparedOpening `json:"as_white"`        // Opponent's most played openings with White
+	AsBlack []PreparedOpening `json:"as_black"`        // Opponent's most played openings with Black
+	Error   string            `json:"error,omitempty"` // Why the games couldn't be fetched
+}
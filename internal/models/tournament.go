@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// Tournament states in a player's tournament history
+const (
+	TournamentFinished   = "finished"
+	TournamentInProgress = "in_progress"
+	TournamentRegistered = "registered"
+)
+
+// TournamentHistoryRequest asks for a player's tournament history
+type TournamentHistoryRequest struct {
+	Username string `json:"username"`
+	Games    int    `json:"games"` // Recent games searched for tournament games
+}
+
+// TournamentHistory is a player's Chess.com tournaments and how they played in each
+type TournamentHistory struct {
+	Username      string                  `json:"username"`
+	GeneratedAt   time.Time               `json:"generated_at"`
+	Games         int                     `json:"games"`          // Recent games searched
+	AnalyzedGames int                     `json:"analyzed_games"` // Tournament games with a stored analysis
+	Tournaments   []TournamentPerformance `json:"tournaments"`    // In progress first, then finished, then registered
+
+	FailedArchives []ArchiveFailure `json:"failed_archives,omitempty"` // Months that couldn't be fetched
+	Archives       []ArchiveStatus  `json:"archives,omitempty"`        // Every month walked and how fetching it went
+}
+
+// TournamentPerformance is a player's results in a tournament, as reported by Chess.com, and a
+// summary of their games in it that were among the games searched
+type TournamentPerformance struct {
+	URL           string `json:"url"`
+	ID            string `json:"id"`     // Tournament in the Chess.com public API
+	State         string `json:"state"`  // finished, in_progress or registered
+	Status        string `json:"status"` // The player's standing, e.g. winner or eliminated
+	Type          string `json:"type,omitempty"`
+	TimeClass     string `json:"time_class,omitempty"`
+	Wins          int    `json:"wins"`
+	Losses        int    `json:"losses"`
+	Draws         int    `json:"draws"`
+	PointsAwarded int    `json:"points_awarded"`
+	Placement     int    `json:"placement,omitempty"`
+	TotalPlayers  int    `json:"total_players,omitempty"`
+
+	Games                 int     `json:"games"`                   // Games found among the recent games
+	Score                 float64 `json:"score"`                   // Points scored in those games, in percent
+	AverageOpponentRating int     `json:"average_opponent_rating"` // Of those games
+	PerformanceRating     int     `json:"performance_rating"`      // Average opponent rating + 400 × (wins − losses) / games
+
+	AnalyzedGames int      `json:"analyzed_games"`     // Games with a stored analysis
+	Accuracy      *float64 `json:"accuracy,omitempty"` // The player's average accuracy in the analyzed games
+	Blunders      int      `json:"blunders"`           // The player's, in the analyzed games
+	Mistakes      int      `json:"mistakes"`
+	Inaccuracies  int      `json:"inaccuracies"`
+	AnalysisIDs   []string `json:"analysis_ids,omitempty"`
+}
@@ -0,0 +1,70 @@
+package models
+
+import "time"
+
+// Training puzzle themes, after the move the user played in the game
+const (
+	PuzzleThemeBlunder = "blunder"
+	PuzzleThemeMistake = "mistake"
+)
+
+// PuzzleExtractRequest turns the errors of a stored analysis into training puzzles
+type PuzzleExtractRequest struct {
+	AnalysisID string `json:"analysis_id"`
+	Color      string `json:"color"` // Side the user played: white or black (empty = both)
+}
+
+// Puzzle is a position from one of the user's games where they went wrong. The solution is
+// revealed by attempting it.
+type Puzzle struct {
+	ID             string       `json:"id"`
+	AnalysisID     string       `json:"analysis_id"`
+	Ply            int          `json:"ply"`             // Ply of the move played in the game
+	Color          string       `json:"color"`           // Side to move
+	FEN            string       `json:"fen"`             // Position to solve
+	PlayedMove     string       `json:"played_move"`     // Move played in the game, in SAN
+	EvaluationLoss float64      `json:"evaluation_loss"` // Pawns the played move gave away
+	Theme          string       `json:"theme"`           // blunder or mistake
+	Review         PuzzleReview `json:"review"`
+	CreatedAt      time.Time    `json:"created_at"`
+}
+
+// PuzzleReview is the spaced-repetition schedule of a puzzle
+type PuzzleReview struct {
+	EaseFactor    float64    `json:"ease_factor"`   // Growth of the interval after each successful review
+	IntervalDays  int        `json:"interval_days"` // Days between the last review and the next
+	Repetitions   int        `json:"repetitions"`   // Successful reviews in a row
+	DueAt         time.Time  `json:"due_at"`
+	Attempts      int        `json:"attempts"`
+	Solved        int        `json:"solved"`
+	LastAttemptAt *time.Time `json:"last_attempt_at,omitempty"`
+}
+
+// PuzzleAttemptRequest is the user's answer to a puzzle
+type PuzzleAttemptRequest struct {
+	Move     string `json:"move"`      // SAN or UCI notation
+	HintUsed bool   `json:"hint_used"` // A correct answer found with a hint is scheduled sooner
+}
+
+// PuzzleAttemptResult grades an attempt and reveals the solution
+type PuzzleAttemptResult struct {
+	Correct     bool    `json:"correct"`
+	Move        string  `json:"move"`         // Attempted move in SAN
+	Solution    string  `json:"solution"`     // Engine's best move in UCI notation
+	SolutionSAN string  `json:"solution_san"` // Engine's best move in SAN
+	Quality     int     `json:"quality"`      // Recall quality the review was graded with, 0-5
+	Puzzle      *Puzzle `json:"puzzle"`       // Puzzle with its updated schedule
+}
+
+// TrainingStats summarizes a user's puzzle training
+type TrainingStats struct {
+	Puzzles         int     `json:"puzzles"`
+	New             int     `json:"new"`      // Never attempted
+	Learning        int     `json:"learning"` // Attempted, reviewed less than 21 days apart
+	Mastered        int     `json:"mastered"` // Reviewed 21 or more days apart
+	Due             int     `json:"due"`
+	Attempts        int     `json:"attempts"`
+	CorrectAttempts int     `json:"correct_attempts"`
+	SolveRate       float64 `json:"solve_rate"` // Percentage of correct attempts
+	Streak          int     `json:"streak"`     // Correct attempts in a row, most recent first
+}
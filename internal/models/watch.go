@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// Watch statuses
+const (
+	WatchStatusWatching = "watching"
+	WatchStatusFinished = "finished" // The game ended and every position was analyzed
+	WatchStatusStopped  = "stopped"  // Stopped by the client or after a long time without moves
+	WatchStatusFailed   = "failed"   // Polling kept failing
+)
+
+// WatchRequest starts watching an ongoing Chess.com game
+type WatchRequest struct {
+	URL            string         `json:"url"`             // Chess.com daily or live game URL
+	Username       string         `json:"username"`        // One of the players, used to look the game up
+	WebhookURL     string         `json:"webhook_url"`     // Receives swing alerts (optional)
+	SwingThreshold float64        `json:"swing_threshold"` // Evaluation change in pawns that raises an alert
+	PollInterval   int            `json:"poll_interval"`   // Seconds between polls
+	Settings       EngineSettings `json:"settings"`        // Engine settings used for each position
+}
+
+// WatchSession is the state of a watched game
+type WatchSession struct {
+	ID             string       `json:"id"`
+	URL            string       `json:"url"`
+	Username       string       `json:"username"`
+	Status         string       `json:"status"`
+	SwingThreshold float64      `json:"swing_threshold"`
+	PollInterval   int          `json:"poll_interval"`
+	StartedAt      time.Time    `json:"started_at"`
+	LastPoll       time.Time    `json:"last_poll,omitempty"`
+	LastMoveAt     time.Time    `json:"last_move_at,omitempty"` // When a new move was last seen
+	Alerts         int          `json:"alerts"`
+	LastError      string       `json:"last_error,omitempty"`
+	Updates        []EvalUpdate `json:"updates"`
+}
+
+// EvalUpdate is the evaluation of one position of a watched game
+type EvalUpdate struct {
+	Ply        int       `json:"ply"`        // Half-move number (1 = White's first move)
+	Move       string    `json:"move"`       // Move that led to the position
+	FEN        string    `json:"fen"`        // Position after the move
+	Evaluation float64   `json:"evaluation"` // Pawns from White's point of view
+	BestMove   string    `json:"best_move"`  // Best reply in the position
+	Depth      int       `json:"depth"`
+	Swing      float64   `json:"swing"` // Evaluation change caused by the move, capped at ±10 per side
+	Alert      bool      `json:"alert"` // True if the swing reached the threshold on a move played while watching
+	Time       time.Time `json:"time"`
+}
+
+// WatchAlert is the webhook payload sent for a swing alert
+type WatchAlert struct {
+	WatchID string     `json:"watch_id"`
+	URL     string     `json:"url"`
+	Update  EvalUpdate `json:"update"`
+}
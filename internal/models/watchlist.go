@@ -0,0 +1,84 @@
+package models
+
+import "time"
+
+// Watchlist report schedules
+const (
+	ScheduleDaily  = "daily"
+	ScheduleWeekly = "weekly"
+)
+
+// Notification delivery channels
+const (
+	DeliveryWebhook  = "webhook"  // JSON report posted to a URL
+	DeliveryEmail    = "email"    // Plain-text report sent over SMTP
+	DeliverySlack    = "slack"    // Message posted to a Slack incoming webhook
+	DeliveryDiscord  = "discord"  // Message posted to a Discord webhook
+	DeliveryTelegram = "telegram" // Message sent to a Telegram chat by the configured bot
+)
+
+// NotificationChannel is one destination reports and notifications are delivered to
+type NotificationChannel struct {
+	Type   string `json:"type"`   // webhook, email, slack, discord or telegram
+	Target string `json:"target"` // Webhook URL, email address or Telegram chat ID
+}
+
+// WatchlistRequest adds a player to the watchlist
+type WatchlistRequest struct {
+	Username string                `json:"username"`
+	Schedule string                `json:"schedule"` // daily or weekly
+	Delivery string                `json:"delivery"` // Channel type, see NotificationChannel
+	Target   string                `json:"target"`   // Webhook URL, email address or Telegram chat ID
+	Channels []NotificationChannel `json:"channels"` // More channels the report is delivered to (optional)
+	Settings EngineSettings        `json:"settings"` // Engine settings used to analyze new games
+}
+
+// WatchlistEntry is a watched player and the schedule their reports are delivered on
+type WatchlistEntry struct {
+	ID           string                `json:"id"`
+	Username     string                `json:"username"`
+	Schedule     string                `json:"schedule"`
+	Delivery     string                `json:"delivery"`
+	Target       string                `json:"target"`
+	Channels     []NotificationChannel `json:"channels"` // Every channel reports go to, including delivery and target
+	CreatedAt    time.Time             `json:"created_at"`
+	NextReportAt time.Time             `json:"next_report_at"`
+	ReportsSent  int                   `json:"reports_sent"`
+	LastError    string                `json:"last_error,omitempty"` // Error from the last report, if any
+	LastReport   *WatchlistReport      `json:"last_report,omitempty"`
+}
+
+// WatchlistReport summarizes the games a watched player finished during a report period
+type WatchlistReport struct {
+	EntryID         string          `json:"entry_id"`
+	Username        string          `json:"username"`
+	Schedule        string          `json:"schedule"`
+	From            time.Time       `json:"from"` // Games that ended after this time are included
+	To              time.Time       `json:"to"`
+	Games           int             `json:"games"`
+	Wins            int             `json:"wins"`
+	Draws           int             `json:"draws"`
+	Losses          int             `json:"losses"`
+	AnalyzedGames   int             `json:"analyzed_games"`
+	AverageAccuracy float64         `json:"average_accuracy"` // Player's accuracy across analyzed games
+	Blunders        int             `json:"blunders"`         // Player's moves only
+	Mistakes        int             `json:"mistakes"`
+	Inaccuracies    int             `json:"inaccuracies"`
+	GameSummaries   []WatchlistGame `json:"game_summaries"`
+	GeneratedAt     time.Time       `json:"generated_at"`
+}
+
+// WatchlistGame is one game in a watchlist report
+type WatchlistGame struct {
+	URL          string    `json:"url"`
+	Opponent     string    `json:"opponent"`
+	Color        string    `json:"color"`
+	Result       string    `json:"result"` // win, draw or loss
+	TimeClass    string    `json:"time_class"`
+	EndTime      time.Time `json:"end_time"`
+	AnalysisID   string    `json:"analysis_id,omitempty"` // Stored analysis, when the game was analyzed
+	Accuracy     float64   `json:"accuracy,omitempty"`
+	Blunders     int       `json:"blunders"`
+	Mistakes     int       `json:"mistakes"`
+	Inaccuracies int       `json:"inaccuracies"`
+}
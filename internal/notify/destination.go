@@ -0,0 +1,100 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// nonPublicPrefixes are address ranges a delivery to a user-supplied URL may not reach, besides
+// the loopback, private, link-local (where cloud metadata services live), multicast and
+// unspecified addresses the net/netip predicates already rule out
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),      // "This" network
+	netip.MustParsePrefix("100.64.0.0/10"),  // Carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),   // IETF protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"),  // Benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),    // Reserved
+	netip.MustParsePrefix("64:ff9b::/96"),   // NAT64, which can map to any IPv4 address
+	netip.MustParsePrefix("64:ff9b:1::/48"), // Local-use NAT64
+	netip.MustParsePrefix("2001::/32"),      // Teredo, which embeds an IPv4 address
+	netip.MustParsePrefix("2002::/16"),      // 6to4, which embeds an IPv4 address
+	netip.MustParsePrefix("fec0::/10"),      // Deprecated site-local
+}
+
+// PublicAddress reports whether an address is one a delivery to a user-supplied URL may reach
+func PublicAddress(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	// Global unicast rules out loopback, link-local, multicast, unspecified and broadcast addresses
+	if !addr.IsValid() || !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckDestination rejects a user-supplied delivery URL that isn't http or https, or whose host
+// is or resolves to an address that isn't public, such as loopback, private networks or the cloud
+// metadata service. The error describes the problem for the user. Hosts that don't resolve yet
+// pass: deliveries to them fail, and PublicClient checks what they resolve to later.
+func CheckDestination(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("must be an http or https URL")
+	}
+
+	host := u.Hostname()
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if !PublicAddress(addr) {
+			return fmt.Errorf("must not point to a private, loopback or link-local address")
+		}
+		return nil
+	}
+	addrs, _ := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	for _, addr := range addrs {
+		if !PublicAddress(addr) {
+			return fmt.Errorf("host %s resolves to a private, loopback or link-local address", host)
+		}
+	}
+	return nil
+}
+
+// PublicClient returns an HTTP client for user-supplied destinations. It checks the address of
+// every connection it opens, so a host that passed CheckDestination can't be rebound to an
+// internal address later, nor redirect to one. Proxies are not used, since they would be
+// connected to instead of the destination.
+func PublicClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !PublicAddress(addrPort.Addr()) {
+				return fmt.Errorf("refusing to connect to non-public address %s", addrPort.Addr())
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			Proxy:                 nil,
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   timeout,
+			ResponseHeaderTimeout: timeout,
+			MaxIdleConns:          10,
+			IdleConnTimeout:       90 * time.Second,
+		},
+	}
+}
@@ -0,0 +1,298 @@
+// Package notify delivers reports and notifications over the channels users pick: JSON webhooks,
+// email, Slack, Discord and Telegram.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+// Delivery settings
+const (
+	defaultTimeout     = 5 * time.Second
+	defaultTelegramURL = "https://api.telegram.org"
+	maxDiscordLength   = 2000 // Discord rejects longer message content
+	maxTelegramLength  = 4096
+)
+
+// Message is something to deliver. Text channels send the subject and text; webhooks post the payload as JSON.
+type Message struct {
+	Subject string
+	Text    string
+	Payload any
+}
+
+// Channel delivers messages to one destination
+type Channel interface {
+	Send(ctx context.Context, message Message) error
+}
+
+// MailSettings configures the SMTP server used for email delivery
+type MailSettings struct {
+	Host     string // Empty disables email delivery
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+// Settings configures the channels a Notifier can build
+type Settings struct {
+	Mail          MailSettings
+	TelegramToken string // Bot token; empty disables Telegram delivery
+	TelegramURL   string // Bot API base URL (empty = api.telegram.org)
+
+	// AllowPrivateTargets lets webhook, Slack and Discord targets be loopback, private and
+	// link-local addresses. By default they must be public, so users can't make the server reach
+	// internal services.
+	AllowPrivateTargets bool
+}
+
+// Notifier builds channels from their configuration and delivers messages to them
+type Notifier struct {
+	settings      Settings
+	httpClient    *http.Client // Reaches the configured Telegram bot API
+	webhookClient *http.Client // Reaches user-supplied webhook targets
+	sendMail      func(addr string, auth smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// New creates a notifier with the given settings
+func New(settings Settings) *Notifier {
+	if settings.TelegramURL == "" {
+		settings.TelegramURL = defaultTelegramURL
+	}
+	webhookClient := PublicClient(defaultTimeout)
+	if settings.AllowPrivateTargets {
+		webhookClient = &http.Client{Timeout: defaultTimeout}
+	}
+	return &Notifier{
+		settings:      settings,
+		httpClient:    &http.Client{Timeout: defaultTimeout},
+		webhookClient: webhookClient,
+		sendMail:      smtp.SendMail,
+	}
+}
+
+// Validate checks a channel configuration without sending anything
+func (n *Notifier) Validate(config models.NotificationChannel) error {
+	_, err := n.Channel(config)
+	return err
+}
+
+// Channel builds the channel a configuration describes
+func (n *Notifier) Channel(config models.NotificationChannel) (Channel, error) {
+	switch config.Type {
+	case models.DeliveryWebhook, models.DeliverySlack, models.DeliveryDiscord:
+		if err := n.checkTarget(config.Target); err != nil {
+			return nil, errors.NewValidationError("target", err.Error())
+		}
+		return &webhookChannel{notifier: n, kind: config.Type, url: config.Target}, nil
+	case models.DeliveryEmail:
+		if n.settings.Mail.Host == "" {
+			return nil, errors.NewValidationError("delivery", "email delivery is not configured")
+		}
+		if _, err := mail.ParseAddress(config.Target); err != nil {
+			return nil, errors.NewValidationError("target", "must be an email address")
+		}
+		return &emailChannel{notifier: n, to: config.Target}, nil
+	case models.DeliveryTelegram:
+		if n.settings.TelegramToken == "" {
+			return nil, errors.NewValidationError("delivery", "Telegram delivery is not configured")
+		}
+		if config.Target == "" {
+			return nil, errors.NewValidationError("target", "must be a Telegram chat ID")
+		}
+		return &telegramChannel{notifier: n, chatID: config.Target}, nil
+	default:
+		return nil, errors.NewValidationError("delivery", fmt.Sprintf("unknown delivery: %s", config.Type))
+	}
+}
+
+// Send delivers a message to every channel, returning the failures joined together
+func (n *Notifier) Send(ctx context.Context, configs []models.NotificationChannel, message Message) error {
+	var failures []error
+	for _, config := range configs {
+		channel, err := n.Channel(config)
+		if err == nil {
+			err = channel.Send(ctx, message)
+		}
+		if err != nil {
+			failures = append(failures, fmt.Errorf("%s: %w", config.Type, err))
+		}
+	}
+	return stderrors.Join(failures...)
+}
+
+// WebhookChannel returns the channel of a webhook URL: Discord's own message format for Discord
+// webhooks, which accept nothing else, and the JSON payload for others
+func WebhookChannel(webhookURL string) models.NotificationChannel {
+	if u, err := url.Parse(webhookURL); err == nil && strings.HasPrefix(u.Path, "/api/webhooks/") &&
+		(u.Hostname() == "discord.com" || u.Hostname() == "discordapp.com") {
+		return models.NotificationChannel{Type: models.DeliveryDiscord, Target: webhookURL}
+	}
+	return models.NotificationChannel{Type: models.DeliveryWebhook, Target: webhookURL}
+}
+
+// ParseChannels parses a comma-separated list of type:target channels, e.g.
+// "slack:https://hooks.slack.com/services/...,telegram:12345"
+func ParseChannels(list string) ([]models.NotificationChannel, error) {
+	var channels []models.NotificationChannel
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		kind, target, ok := strings.Cut(entry, ":")
+		if !ok || target == "" {
+			return nil, fmt.Errorf("invalid notification channel %q, want type:target", entry)
+		}
+		channels = append(channels, models.NotificationChannel{Type: strings.ToLower(kind), Target: target})
+	}
+	return channels, nil
+}
+
+// webhookChannel posts to an incoming webhook: the payload as JSON for plain webhooks, or the
+// message text in Slack's or Discord's format
+type webhookChannel struct {
+	notifier *Notifier
+	kind     string
+	url      string
+}
+
+func (c *webhookChannel) Send(ctx context.Context, message Message) error {
+	var body any
+	switch c.kind {
+	case models.DeliverySlack:
+		body = map[string]string{"text": messageText(message)}
+	case models.DeliveryDiscord:
+		body = map[string]string{"content": truncate(messageText(message), maxDiscordLength)}
+	default:
+		body = message.Payload
+	}
+	return c.notifier.postJSON(ctx, c.notifier.webhookClient, c.url, body)
+}
+
+// telegramChannel sends the message text to a chat through the configured bot
+type telegramChannel struct {
+	notifier *Notifier
+	chatID   string
+}
+
+func (c *telegramChannel) Send(ctx context.Context, message Message) error {
+	endpoint := fmt.Sprintf("%s/bot%s/sendMessage", c.notifier.settings.TelegramURL, c.notifier.settings.TelegramToken)
+	return c.notifier.postJSON(ctx, c.notifier.httpClient, endpoint, map[string]any{
+		"chat_id":                  c.chatID,
+		"text":                     truncate(messageText(message), maxTelegramLength),
+		"disable_web_page_preview": true,
+	})
+}
+
+// emailChannel emails the message as plain text through the configured SMTP server. Messages
+// without a subject use their first line.
+type emailChannel struct {
+	notifier *Notifier
+	to       string
+}
+
+func (c *emailChannel) Send(ctx context.Context, message Message) error {
+	if message.Subject == "" {
+		message.Subject, _, _ = strings.Cut(message.Text, "\n")
+	}
+
+	settings := c.notifier.settings.Mail
+	var auth smtp.Auth
+	if settings.Username != "" {
+		auth = smtp.PlainAuth("", settings.Username, settings.Password, settings.Host)
+	}
+
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s",
+		settings.From, c.to, message.Subject, strings.ReplaceAll(message.Text, "\n", "\r\n"))
+	addr := fmt.Sprintf("%s:%d", settings.Host, settings.Port)
+	return c.notifier.sendMail(addr, auth, settings.From, []string{c.to}, []byte(msg))
+}
+
+// checkTarget rejects webhook targets that aren't http or https URLs and, unless private targets
+// are allowed, those whose host resolves to an address that isn't public
+func (n *Notifier) checkTarget(target string) error {
+	if n.settings.AllowPrivateTargets {
+		if u, err := url.Parse(target); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("must be an http or https URL")
+		}
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	return CheckDestination(ctx, target)
+}
+
+// postJSON posts a JSON body with client, treating any non-2xx response as a failure
+func (n *Notifier) postJSON(ctx context.Context, client *http.Client, endpoint string, body any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return redactToken(err, n.settings.TelegramToken)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("delivery returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// messageText joins a message's subject and text for chat channels
+func messageText(message Message) string {
+	switch {
+	case message.Subject == "":
+		return message.Text
+	case message.Text == "":
+		return message.Subject
+	}
+	return message.Subject + "\n\n" + message.Text
+}
+
+// truncate shortens text to at most limit bytes without splitting a character
+func truncate(text string, limit int) string {
+	if len(text) <= limit {
+		return text
+	}
+	const ellipsis = "…"
+	cut := limit - len(ellipsis)
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	return text[:cut] + ellipsis
+}
+
+// redactToken keeps the Telegram bot token out of errors, which include the request URL
+func redactToken(err error, token string) error {
+	if token == "" || !strings.Contains(err.Error(), token) {
+		return err
+	}
+	return stderrors.New(strings.ReplaceAll(err.Error(), token, "<token>"))
+}
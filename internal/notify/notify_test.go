@@ -0,0 +1,126 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"strings"
+	"testing"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+)
+
+func TestNotifier_Send(t *testing.T) {
+	bodies := make(map[string]map[string]any)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		bodies[r.URL.Path] = body
+		if r.URL.Path == "/down" {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+
+	var mailed string
+	notifier := New(Settings{
+		Mail:                MailSettings{Host: "smtp.example.com", Port: 25, From: "reports@example.com"},
+		TelegramToken:       "secret",
+		TelegramURL:         server.URL,
+		AllowPrivateTargets: true, // The test server listens on loopback
+	})
+	notifier.sendMail = func(addr string, auth smtp.Auth, from string, to []string, msg []byte) error {
+		mailed = string(msg)
+		return nil
+	}
+
+	message := Message{Subject: "Report", Text: "3 games", Payload: map[string]int{"games": 3}}
+	err := notifier.Send(context.Background(), []models.NotificationChannel{
+		{Type: models.DeliveryWebhook, Target: server.URL + "/hook"},
+		{Type: models.DeliverySlack, Target: server.URL + "/slack"},
+		{Type: models.DeliveryDiscord, Target: server.URL + "/discord"},
+		{Type: models.DeliveryTelegram, Target: "42"},
+		{Type: models.DeliveryEmail, Target: "alice@example.com"},
+	}, message)
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	if bodies["/hook"]["games"] != 3.0 {
+		t.Errorf("Webhook body = %v, want the payload", bodies["/hook"])
+	}
+	if bodies["/slack"]["text"] != "Report\n\n3 games" || bodies["/discord"]["content"] != "Report\n\n3 games" {
+		t.Errorf("Slack body = %v, Discord body = %v", bodies["/slack"], bodies["/discord"])
+	}
+	if telegram := bodies["/botsecret/sendMessage"]; telegram["chat_id"] != "42" || telegram["text"] != "Report\n\n3 games" {
+		t.Errorf("Telegram body = %v", telegram)
+	}
+	if !strings.Contains(mailed, "Subject: Report\r\n") || !strings.HasSuffix(mailed, "3 games") {
+		t.Errorf("Unexpected email: %q", mailed)
+	}
+
+	// Failures are reported per channel without stopping the others
+	err = notifier.Send(context.Background(), []models.NotificationChannel{
+		{Type: models.DeliveryWebhook, Target: server.URL + "/down"},
+		{Type: models.DeliverySlack, Target: server.URL + "/slack2"},
+	}, message)
+	if err == nil || !strings.Contains(err.Error(), "status 502") || bodies["/slack2"] == nil {
+		t.Errorf("Send() error = %v, slack2 body = %v", err, bodies["/slack2"])
+	}
+}
+
+func TestNotifier_Validate(t *testing.T) {
+	notifier := New(Settings{})
+
+	tests := []struct {
+		channel models.NotificationChannel
+		wantErr bool
+	}{
+		{models.NotificationChannel{Type: models.DeliverySlack, Target: "https://hooks.slack.com/services/T/B/x"}, false},
+		{models.NotificationChannel{Type: models.DeliveryDiscord, Target: "discord.com/api/webhooks/1/x"}, true},
+		{models.NotificationChannel{Type: models.DeliveryWebhook, Target: "http://127.0.0.1:9000/hook"}, true},
+		{models.NotificationChannel{Type: models.DeliverySlack, Target: "http://169.254.169.254/latest/meta-data/"}, true},
+		{models.NotificationChannel{Type: models.DeliveryDiscord, Target: "http://[::1]/api/webhooks/1/x"}, true},
+		{models.NotificationChannel{Type: models.DeliveryEmail, Target: "alice@example.com"}, true}, // No SMTP server
+		{models.NotificationChannel{Type: models.DeliveryTelegram, Target: "42"}, true},             // No bot token
+		{models.NotificationChannel{Type: "sms", Target: "555"}, true},
+	}
+	for _, tt := range tests {
+		if err := notifier.Validate(tt.channel); (err != nil) != tt.wantErr {
+			t.Errorf("Validate(%+v) error = %v, wantErr %v", tt.channel, err, tt.wantErr)
+		}
+	}
+
+	// Deployments whose webhooks run on the internal network may allow them
+	private := models.NotificationChannel{Type: models.DeliveryWebhook, Target: "http://10.0.0.5/hook"}
+	if err := New(Settings{AllowPrivateTargets: true}).Validate(private); err != nil {
+		t.Errorf("Validate(%+v) with private targets allowed error = %v", private, err)
+	}
+}
+
+func TestWebhookChannel(t *testing.T) {
+	if got := WebhookChannel("https://discord.com/api/webhooks/123/token"); got.Type != models.DeliveryDiscord {
+		t.Errorf("Discord webhook type = %s", got.Type)
+	}
+	if got := WebhookChannel("https://example.com/hooks/chess"); got.Type != models.DeliveryWebhook {
+		t.Errorf("Plain webhook type = %s", got.Type)
+	}
+}
+
+func TestParseChannels(t *testing.T) {
+	channels, err := ParseChannels("slack:https://hooks.slack.com/services/T/B/x, Telegram:42")
+	if err != nil || len(channels) != 2 || channels[0].Target != "https://hooks.slack.com/services/T/B/x" || channels[1].Type != models.DeliveryTelegram {
+		t.Errorf("ParseChannels() = %+v, %v", channels, err)
+	}
+	if _, err := ParseChannels("telegram"); err == nil {
+		t.Error("Expected a channel without a target to be rejected")
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	if got := truncate("ééé", 5); got != "é…" {
+		t.Errorf("truncate() = %q", got)
+	}
+}
@@ -0,0 +1,270 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// magic starts and ends every Parquet file
+const magic = "PAR1"
+
+// Type is the type of a column's values
+type Type int
+
+// Column types
+const (
+	Int32  Type = iota // int32 values
+	Int64              // int64 values
+	Double             // float64 values
+	String             // UTF-8 string values
+)
+
+// Parquet physical types, encodings and schema constants
+const (
+	physicalInt32     = 1
+	physicalInt64     = 2
+	physicalDouble    = 5
+	physicalByteArray = 6
+
+	encodingPlain = 0
+	encodingRLE   = 3
+
+	repetitionRequired = 0
+	repetitionOptional = 1
+
+	convertedUTF8 = 0
+	pageTypeData  = 0
+	codecNone     = 0
+)
+
+// Column describes one column of a file
+type Column struct {
+	Name     string
+	Type     Type
+	Optional bool // Rows may leave the value out (nil)
+}
+
+// Writer writes rows as a Parquet file with a single row group. Rows are kept in memory until
+// Close, since a Parquet file describes its columns in a footer after the data.
+type Writer struct {
+	out     io.Writer
+	columns []Column
+	values  []bytes.Buffer // PLAIN encoded values of each column
+	defined [][]bool       // Which rows of each optional column have a value
+	rows    int
+}
+
+// NewWriter creates a writer of a file with the given columns
+func NewWriter(out io.Writer, columns []Column) *Writer {
+	return &Writer{
+		out:     out,
+		columns: columns,
+		values:  make([]bytes.Buffer, len(columns)),
+		defined: make([][]bool, len(columns)),
+	}
+}
+
+// Write adds a row holding one value per column, in column order. Values must match their
+// column's type (int32, int64, float64 or string); optional columns accept nil.
+func (w *Writer) Write(row []interface{}) error {
+	if len(row) != len(w.columns) {
+		return fmt.Errorf("row has %d values, want %d", len(row), len(w.columns))
+	}
+
+	for i, value := range row {
+		column := w.columns[i]
+		if value == nil {
+			if !column.Optional {
+				return fmt.Errorf("column %s is required", column.Name)
+			}
+			w.defined[i] = append(w.defined[i], false)
+			continue
+		}
+		if err := w.encode(i, value); err != nil {
+			return err
+		}
+		if column.Optional {
+			w.defined[i] = append(w.defined[i], true)
+		}
+	}
+	w.rows++
+	return nil
+}
+
+// encode appends a value to a column in PLAIN encoding
+func (w *Writer) encode(i int, value interface{}) error {
+	column := w.columns[i]
+	buf := &w.values[i]
+
+	switch column.Type {
+	case Int32:
+		v, ok := value.(int32)
+		if !ok {
+			return fmt.Errorf("column %s: %T is not an int32", column.Name, value)
+		}
+		binary.Write(buf, binary.LittleEndian, v)
+	case Int64:
+		v, ok := value.(int64)
+		if !ok {
+			return fmt.Errorf("column %s: %T is not an int64", column.Name, value)
+		}
+		binary.Write(buf, binary.LittleEndian, v)
+	case Double:
+		v, ok := value.(float64)
+		if !ok {
+			return fmt.Errorf("column %s: %T is not a float64", column.Name, value)
+		}
+		binary.Write(buf, binary.LittleEndian, math.Float64bits(v))
+	case String:
+		v, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("column %s: %T is not a string", column.Name, value)
+		}
+		binary.Write(buf, binary.LittleEndian, uint32(len(v)))
+		buf.WriteString(v)
+	default:
+		return fmt.Errorf("column %s has an unknown type", column.Name)
+	}
+	return nil
+}
+
+// Close writes the file: every column as one uncompressed data page, followed by the footer
+func (w *Writer) Close() error {
+	var file bytes.Buffer
+	file.WriteString(magic)
+
+	chunks := make([]columnChunk, len(w.columns))
+	for i, column := range w.columns {
+		page := w.values[i].Bytes()
+		if column.Optional {
+			page = append(definitionLevels(w.defined[i]), page...)
+		}
+
+		header := &compactWriter{}
+		header.begin()
+		header.i32(1, pageTypeData)
+		header.i32(2, int32(len(page)))
+		header.i32(3, int32(len(page)))
+		header.structField(5)
+		header.i32(1, int32(w.rows))
+		header.i32(2, encodingPlain)
+		header.i32(3, encodingRLE)
+		header.i32(4, encodingRLE)
+		header.end()
+		header.end()
+
+		chunks[i] = columnChunk{offset: int64(file.Len()), size: int64(header.buf.Len() + len(page))}
+		file.Write(header.buf.Bytes())
+		file.Write(page)
+	}
+
+	footer := w.footer(chunks)
+	file.Write(footer)
+	binary.Write(&file, binary.LittleEndian, uint32(len(footer)))
+	file.WriteString(magic)
+
+	_, err := w.out.Write(file.Bytes())
+	return err
+}
+
+// columnChunk is where a column's data page was written
+type columnChunk struct {
+	offset int64
+	size   int64
+}
+
+// footer encodes the file metadata: the schema and the single row group
+func (w *Writer) footer(chunks []columnChunk) []byte {
+	meta := &compactWriter{}
+	meta.begin()
+	meta.i32(1, 1) // Format version
+
+	meta.listHeader(2, compactStruct, len(w.columns)+1)
+	meta.begin()
+	meta.string(4, "schema")
+	meta.i32(5, int32(len(w.columns)))
+	meta.end()
+	for _, column := range w.columns {
+		meta.begin()
+		meta.i32(1, physicalType(column.Type))
+		repetition := int32(repetitionRequired)
+		if column.Optional {
+			repetition = repetitionOptional
+		}
+		meta.i32(3, repetition)
+		meta.string(4, column.Name)
+		if column.Type == String {
+			meta.i32(6, convertedUTF8)
+		}
+		meta.end()
+	}
+
+	meta.i64(3, int64(w.rows))
+
+	var totalSize int64
+	for _, chunk := range chunks {
+		totalSize += chunk.size
+	}
+	meta.listHeader(4, compactStruct, 1)
+	meta.begin()
+	meta.listHeader(1, compactStruct, len(w.columns))
+	for i, column := range w.columns {
+		meta.begin()
+		meta.i64(2, chunks[i].offset)
+		meta.structField(3)
+		meta.i32(1, physicalType(column.Type))
+		meta.i32List(2, []int32{encodingPlain, encodingRLE})
+		meta.stringList(3, []string{column.Name})
+		meta.i32(4, codecNone)
+		meta.i64(5, int64(w.rows))
+		meta.i64(6, chunks[i].size)
+		meta.i64(7, chunks[i].size)
+		meta.i64(9, chunks[i].offset)
+		meta.end()
+		meta.end()
+	}
+	meta.i64(2, totalSize)
+	meta.i64(3, int64(w.rows))
+	meta.end()
+
+	meta.string(6, "ChessAnalyser")
+	meta.end()
+	return meta.buf.Bytes()
+}
+
+// physicalType maps a column type to its Parquet physical type
+func physicalType(t Type) int32 {
+	switch t {
+	case Int32:
+		return physicalInt32
+	case Int64:
+		return physicalInt64
+	case Double:
+		return physicalDouble
+	}
+	return physicalByteArray
+}
+
+// definitionLevels encodes whether each value is present as a length-prefixed run of
+// bit-packed levels (RLE/bit-packing hybrid with a bit width of 1)
+func definitionLevels(defined []bool) []byte {
+	groups := (len(defined) + 7) / 8
+	var runs bytes.Buffer
+	var scratch [binary.MaxVarintLen64]byte
+	runs.Write(scratch[:binary.PutUvarint(scratch[:], uint64(groups)<<1|1)])
+
+	packed := make([]byte, groups)
+	for i, ok := range defined {
+		if ok {
+			packed[i/8] |= 1 << (i % 8)
+		}
+	}
+	runs.Write(packed)
+
+	levels := make([]byte, 4, 4+runs.Len())
+	binary.LittleEndian.PutUint32(levels, uint32(runs.Len()))
+	return append(levels, runs.Bytes()...)
+}
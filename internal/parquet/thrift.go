@@ -0,0 +1,112 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// Thrift compact protocol field types
+const (
+	compactI32    = 5
+	compactI64    = 6
+	compactBinary = 8
+	compactList   = 9
+	compactStruct = 12
+)
+
+// compactWriter encodes Parquet metadata with the Thrift compact protocol. Only the types the
+// file footer and page headers use are supported.
+type compactWriter struct {
+	buf    bytes.Buffer
+	last   int16   // ID of the last field written in the current struct
+	nested []int16 // Last field IDs of the enclosing structs
+}
+
+// fieldHeader writes a field's type and ID, as a delta from the previous field when it fits
+func (w *compactWriter) fieldHeader(id int16, fieldType byte) {
+	if delta := id - w.last; delta > 0 && delta <= 15 {
+		w.buf.WriteByte(byte(delta)<<4 | fieldType)
+	} else {
+		w.buf.WriteByte(fieldType)
+		w.varint(int64(id))
+	}
+	w.last = id
+}
+
+// i32 writes an i32 field
+func (w *compactWriter) i32(id int16, value int32) {
+	w.fieldHeader(id, compactI32)
+	w.varint(int64(value))
+}
+
+// i64 writes an i64 field
+func (w *compactWriter) i64(id int16, value int64) {
+	w.fieldHeader(id, compactI64)
+	w.varint(value)
+}
+
+// string writes a binary field
+func (w *compactWriter) string(id int16, value string) {
+	w.fieldHeader(id, compactBinary)
+	w.uvarint(uint64(len(value)))
+	w.buf.WriteString(value)
+}
+
+// listHeader starts a list field of size elements
+func (w *compactWriter) listHeader(id int16, elemType byte, size int) {
+	w.fieldHeader(id, compactList)
+	if size < 15 {
+		w.buf.WriteByte(byte(size)<<4 | elemType)
+	} else {
+		w.buf.WriteByte(0xF0 | elemType)
+		w.uvarint(uint64(size))
+	}
+}
+
+// i32List writes a list of i32 values
+func (w *compactWriter) i32List(id int16, values []int32) {
+	w.listHeader(id, compactI32, len(values))
+	for _, value := range values {
+		w.varint(int64(value))
+	}
+}
+
+// stringList writes a list of binary values
+func (w *compactWriter) stringList(id int16, values []string) {
+	w.listHeader(id, compactBinary, len(values))
+	for _, value := range values {
+		w.uvarint(uint64(len(value)))
+		w.buf.WriteString(value)
+	}
+}
+
+// structField starts a struct field; close it with end
+func (w *compactWriter) structField(id int16) {
+	w.fieldHeader(id, compactStruct)
+	w.begin()
+}
+
+// begin starts a struct, including list elements and the top-level struct
+func (w *compactWriter) begin() {
+	w.nested = append(w.nested, w.last)
+	w.last = 0
+}
+
+// end closes the current struct
+func (w *compactWriter) end() {
+	w.buf.WriteByte(0) // STOP
+	w.last = w.nested[len(w.nested)-1]
+	w.nested = w.nested[:len(w.nested)-1]
+}
+
+// varint writes a zigzag encoded varint
+func (w *compactWriter) varint(value int64) {
+	w.uvarint(uint64(value<<1) ^ uint64(value>>63))
+}
+
+// uvarint writes an unsigned varint
+func (w *compactWriter) uvarint(value uint64) {
+	var scratch [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(scratch[:], value)
+	w.buf.Write(scratch[:n])
+}
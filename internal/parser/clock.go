@@ -0,0 +1,197 @@
+package parser
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MoveTime is the time information recorded for one ply of the main line. Exporters annotate
+// moves differently: Chess.com and Lichess record the clock ([%clk]), ChessBase the elapsed time
+// ([%emt]), and Chess.com's timestamped downloads the elapsed time in tenths ([%timestamp]).
+type MoveTime struct {
+	Ply        int           // 1 for White's first move
+	Clock      time.Duration // Mover's remaining time after the move
+	Elapsed    time.Duration // Time the mover spent on the move
+	HasClock   bool
+	HasElapsed bool
+}
+
+// Time annotations in move comments. Clocks and elapsed times are H:MM:SS with optional
+// fractional seconds; the hours may be left out.
+var (
+	clockRegex     = regexp.MustCompile(`\[%clk\s+((?:\d+:)?\d{1,2}:\d{1,2}(?:\.\d+)?)\]`)
+	elapsedRegex   = regexp.MustCompile(`\[%emt\s+((?:\d+:)?\d{1,2}:\d{1,2}(?:\.\d+)?)\]`)
+	timestampRegex = regexp.MustCompile(`\[%timestamp\s+(\d+)\]`)
+)
+
+// movePrefixRegex matches move numbers written before a move, e.g. "12." or "12..."
+var movePrefixRegex = regexp.MustCompile(`^\d+\.+`)
+
+// ExtractClocks returns the remaining clock time recorded after each ply, in order.
+// Chess.com records one [%clk] annotation per move, so the result lines up with the moves
+// when every move is annotated.
+func (p *PGNParser) ExtractClocks(pgn string) []time.Duration {
+	var clocks []time.Duration
+	for _, match := range clockRegex.FindAllStringSubmatch(pgn, -1) {
+		if clock, ok := parseClockValue(match[1]); ok {
+			clocks = append(clocks, clock)
+		}
+	}
+	return clocks
+}
+
+// ExtractMoveTimes returns the time annotations of every main-line ply, one entry per ply.
+// Annotations are read from the comments following each move; variations are skipped.
+// Missing values can be derived with FillMoveTimes.
+func (p *PGNParser) ExtractMoveTimes(pgn string) []MoveTime {
+	comments := mainLineComments(pgn)
+	times := make([]MoveTime, len(comments))
+	for i, comment := range comments {
+		times[i].Ply = i + 1
+		applyTimeAnnotations(&times[i], comment)
+	}
+	return times
+}
+
+// mainLineComments returns the comments following each main-line ply, one entry per ply with
+// all of the ply's comments joined. Variations and the comments inside them are skipped.
+func mainLineComments(pgn string) []string {
+	_, movetext := splitPGN(pgn)
+
+	var comments []string
+	depth := 0 // Variation nesting
+	annotate := func(comment string) {
+		if depth == 0 && len(comments) > 0 {
+			comments[len(comments)-1] += comment
+		}
+	}
+
+	var token strings.Builder
+	flush := func() {
+		word := token.String()
+		token.Reset()
+		if depth > 0 || word == "" {
+			return
+		}
+
+		word = movePrefixRegex.ReplaceAllString(word, "")
+		switch {
+		case word == "", word == "1-0", word == "0-1", word == "1/2-1/2", word == "*", strings.HasPrefix(word, "$"):
+			return
+		}
+		comments = append(comments, "")
+	}
+
+	for i := 0; i < len(movetext); i++ {
+		switch c := movetext[i]; c {
+		case '{':
+			flush()
+			end := strings.IndexByte(movetext[i:], '}')
+			if end == -1 {
+				end = len(movetext) - i
+			}
+			annotate(movetext[i : i+end])
+			i += end
+		case ';':
+			flush()
+			end := strings.IndexByte(movetext[i:], '\n')
+			if end == -1 {
+				end = len(movetext) - i
+			}
+			annotate(movetext[i : i+end])
+			i += end
+		case '(':
+			flush()
+			depth++
+		case ')':
+			flush()
+			if depth > 0 {
+				depth--
+			}
+		case ' ', '\t', '\n', '\r':
+			flush()
+		default:
+			token.WriteByte(c)
+		}
+	}
+	flush()
+
+	return comments
+}
+
+// applyTimeAnnotations reads the time annotations of a comment into a ply's move time
+func applyTimeAnnotations(moveTime *MoveTime, comment string) {
+	if match := clockRegex.FindStringSubmatch(comment); match != nil {
+		if clock, ok := parseClockValue(match[1]); ok {
+			moveTime.Clock, moveTime.HasClock = clock, true
+		}
+	}
+	if match := elapsedRegex.FindStringSubmatch(comment); match != nil {
+		if elapsed, ok := parseClockValue(match[1]); ok {
+			moveTime.Elapsed, moveTime.HasElapsed = elapsed, true
+		}
+	} else if match := timestampRegex.FindStringSubmatch(comment); match != nil {
+		if tenths, err := strconv.Atoi(match[1]); err == nil {
+			moveTime.Elapsed, moveTime.HasElapsed = time.Duration(tenths)*100*time.Millisecond, true
+		}
+	}
+}
+
+// parseClockValue parses an H:MM:SS or MM:SS time with optional fractional seconds
+func parseClockValue(value string) (time.Duration, bool) {
+	parts := strings.Split(value, ":")
+	if len(parts) == 2 {
+		parts = append([]string{"0"}, parts...)
+	}
+	if len(parts) != 3 {
+		return 0, false
+	}
+
+	hours, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, false
+	}
+	minutes, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, false
+	}
+	seconds, err := strconv.ParseFloat(parts[2], 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute +
+		time.Duration(seconds*float64(time.Second)), true
+}
+
+// FillMoveTimes derives the clocks and elapsed times a PGN left out from the ones it recorded,
+// given the game's base time and increment: a move took the mover's previous clock plus the
+// increment minus their new clock. Plies alternate between the two sides, starting with the side
+// that moved first. A base of 0 means the starting clocks are unknown.
+func FillMoveTimes(times []MoveTime, base, increment time.Duration) {
+	var previous [2]time.Duration
+	var known [2]bool
+	if base > 0 {
+		previous, known = [2]time.Duration{base, base}, [2]bool{true, true}
+	}
+
+	for i := range times {
+		side := i % 2
+		t := &times[i]
+
+		switch {
+		case t.HasClock && !t.HasElapsed && known[side]:
+			if elapsed := previous[side] - t.Clock + increment; elapsed >= 0 {
+				t.Elapsed, t.HasElapsed = elapsed, true
+			}
+		case !t.HasClock && t.HasElapsed && known[side]:
+			if clock := previous[side] - t.Elapsed + increment; clock >= 0 {
+				t.Clock, t.HasClock = clock, true
+			}
+		}
+
+		previous[side], known[side] = t.Clock, t.HasClock
+	}
+}
@@ -0,0 +1,69 @@
+package parser
+
+import (
+	"regexp"
+	"strconv"
+)
+
+// MoveEval is the engine evaluation recorded for one ply of the main line. Lichess records it
+// after server analysis as [%eval 0.17] or [%eval #-3]; some tools add the search depth, as in
+// [%eval 0.17,23].
+type MoveEval struct {
+	Ply        int     // 1 for White's first move
+	Evaluation float64 // Pawns from White's point of view, with mates mapped to ±1000 less the moves to mate
+	Mate       int     // Moves to mate, negative when Black mates (0 = no mate)
+	Depth      int     // Search depth, 0 when not recorded
+	HasEval    bool
+}
+
+// evalRegex matches an evaluation annotation: an optional mate sign, the score, and an optional depth
+var evalRegex = regexp.MustCompile(`\[%eval\s+(#?)([+-]?\d+(?:\.\d+)?)(?:\s*,\s*(\d+))?\s*\]`)
+
+// ExtractMoveEvals returns the evaluation annotations of every main-line ply, one entry per ply.
+// Annotations are read from the comments following each move; variations are skipped.
+func (p *PGNParser) ExtractMoveEvals(pgn string) []MoveEval {
+	comments := mainLineComments(pgn)
+	evals := make([]MoveEval, len(comments))
+	for i, comment := range comments {
+		evals[i].Ply = i + 1
+		applyEvalAnnotation(&evals[i], comment)
+	}
+	return evals
+}
+
+// HasMoveEvals reports whether a PGN carries any evaluation annotation
+func (p *PGNParser) HasMoveEvals(pgn string) bool {
+	return evalRegex.MatchString(pgn)
+}
+
+// applyEvalAnnotation reads the evaluation annotation of a comment into a ply's evaluation
+func applyEvalAnnotation(eval *MoveEval, comment string) {
+	match := evalRegex.FindStringSubmatch(comment)
+	if match == nil {
+		return
+	}
+
+	if match[1] == "#" {
+		mate, err := strconv.Atoi(match[2])
+		if err != nil || mate == 0 {
+			return
+		}
+		eval.Mate = mate
+		if mate > 0 {
+			eval.Evaluation = 1000 - float64(mate)
+		} else {
+			eval.Evaluation = -1000 - float64(mate)
+		}
+	} else {
+		score, err := strconv.ParseFloat(match[2], 64)
+		if err != nil {
+			return
+		}
+		eval.Evaluation = score
+	}
+
+	if match[3] != "" {
+		eval.Depth, _ = strconv.Atoi(match[3])
+	}
+	eval.HasEval = true
+}
@@ -1,12 +1,16 @@
 package parser
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
+	"io"
 	"regexp"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/pedrampdd/ChessAnalyser/internal/board"
 	"github.com/pedrampdd/ChessAnalyser/internal/models"
 )
 
@@ -51,13 +55,13 @@ func (p *PGNParser) ParsePGN(pgn string) (*ParsedGame, error) {
 	}
 
 	// Split PGN into headers and moves
-	parts := strings.Split(pgn, "\n\n")
-	if len(parts) < 2 {
+	headerSection, movetext := splitPGN(pgn)
+	if movetext == "" {
 		return nil, fmt.Errorf("invalid PGN format: missing moves section")
 	}
 
-	headers := p.parseHeaders(parts[0])
-	moves, result, err := p.parseMoves(parts[1])
+	headers := p.parseHeaders(headerSection)
+	moves, result, err := p.parseMoves(movetext)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse moves: %w", err)
 	}
@@ -92,7 +96,6 @@ func (p *PGNParser) parseHeaders(headerSection string) map[string]string {
 
 // parseMoves extracts moves from the moves section
 func (p *PGNParser) parseMoves(movesSection string) ([]ParsedMove, string, error) {
-	var moves []ParsedMove
 	var result string
 
 	// Clean up the moves section
@@ -108,20 +111,15 @@ func (p *PGNParser) parseMoves(movesSection string) ([]ParsedMove, string, error
 		}
 	}
 
-	// Parse individual moves
+	// Comments are removed line by line, since a ; comment runs to the end of its line. The lines
+	// are then read as one, so a move wrapped onto the next line keeps its move number.
 	lines := strings.Split(movesSection, "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
-		}
-
-		// Parse moves in this line
-		lineMoves, err := p.parseMoveLine(line)
-		if err != nil {
-			continue // Skip invalid lines
-		}
-		moves = append(moves, lineMoves...)
+	for i, line := range lines {
+		lines[i] = p.removeComments(line)
+	}
+	moves, err := p.parseMoveLine(strings.Join(lines, " "))
+	if err != nil {
+		return nil, "", err
 	}
 
 	return moves, result, nil
@@ -142,9 +140,12 @@ func (p *PGNParser) parseMoveLine(line string) ([]ParsedMove, error) {
 	for _, part := range parts {
 		// Check if this is a move number
 		if strings.HasSuffix(part, ".") {
-			if num, err := strconv.Atoi(strings.TrimSuffix(part, ".")); err == nil {
+			if num, err := strconv.Atoi(strings.TrimRight(part, ".")); err == nil {
 				currentMoveNumber = num
 				moveIndex = 0 // Reset move index for new move number
+				if strings.HasSuffix(part, "...") {
+					moveIndex = 1 // Black's move, e.g. in a game set up with Black to move
+				}
 			}
 			continue
 		}
@@ -209,14 +210,26 @@ func (p *PGNParser) determineGamePhase(moveCount int) string {
 	}
 }
 
-// ExtractPositions extracts FEN positions for each move
+// ExtractPositions replays the game and sets the FEN of the position after each move, and the
+// side that played it. Games set up from a position (FEN tag) are replayed from that position,
+// which may have Black to move first.
 func (p *PGNParser) ExtractPositions(game *ParsedGame) error {
-	// For now, generate basic FEN positions
-	// In a real implementation, you'd use a chess library to generate proper FEN strings
+	b := board.NewBoard()
+	if fen, ok := game.Headers["fen"]; ok && fen != "" {
+		var err error
+		if b, err = board.FromFEN(fen); err != nil {
+			return fmt.Errorf("invalid FEN tag: %w", err)
+		}
+	}
+
 	for i := range game.Moves {
-		// Generate a simple FEN based on move number
-		// This is a placeholder - real implementation would parse moves and update position
-		game.Moves[i].FEN = fmt.Sprintf("rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - %d %d", i, (i/2)+1)
+		move, err := b.ParseSAN(game.Moves[i].Move)
+		if err != nil {
+			return fmt.Errorf("move %d (%s): %w", game.Moves[i].MoveNumber, game.Moves[i].Move, err)
+		}
+		game.Moves[i].Color = b.Turn().String()
+		b.Apply(move)
+		game.Moves[i].FEN = b.FEN()
 	}
 	return nil
 }
@@ -252,6 +265,8 @@ func (p *PGNParser) ConvertToGameInfo(parsedGame *ParsedGame) *models.GameInfo {
 		gameInfo.Rules = rules
 	}
 
+	gameInfo.ResultReason = TerminationReason(parsedGame.Headers["termination"])
+
 	// Convert players
 	if white, ok := parsedGame.Headers["white"]; ok {
 		gameInfo.WhitePlayer = models.Player{Username: white}
@@ -280,35 +295,162 @@ func (p *PGNParser) ConvertToGameInfo(parsedGame *ParsedGame) *models.GameInfo {
 	return gameInfo
 }
 
-// ValidatePGN validates if a PGN string is well-formed
+// terminationReasons maps phrases of Termination tags to result reasons. Timeout vs insufficient
+// material is listed before both of the phrases it contains.
+var terminationReasons = []struct {
+	phrase string
+	reason models.ResultReason
+}{
+	{"timeout vs insufficient material", models.ResultReasonTimeoutVsInsufficient},
+	{"checkmate", models.ResultReasonCheckmate},
+	{"resignation", models.ResultReasonResignation},
+	{"on time", models.ResultReasonTimeout},
+	{"time forfeit", models.ResultReasonTimeout},
+	{"abandon", models.ResultReasonAbandoned},
+	{"agreement", models.ResultReasonAgreement},
+	{"repetition", models.ResultReasonRepetition},
+	{"stalemate", models.ResultReasonStalemate},
+	{"insufficient material", models.ResultReasonInsufficientMaterial},
+	{"50-move rule", models.ResultReasonFiftyMoveRule},
+}
+
+// TerminationReason parses a Termination tag, such as Chess.com's "Hikaru won on time" or
+// Lichess's "Time forfeit", into a result reason. Unrecognized tags, and Lichess's "Normal",
+// have no reason.
+func TerminationReason(termination string) models.ResultReason {
+	termination = strings.ToLower(termination)
+	for _, t := range terminationReasons {
+		if strings.Contains(termination, t.phrase) {
+			return t.reason
+		}
+	}
+	return ""
+}
+
+// ErrUnknownValidationMode is returned by ValidatePGNMode for a mode it doesn't know
+var ErrUnknownValidationMode = errors.New("unknown validation mode")
+
+// ValidatePGN validates a PGN in strict mode, requiring the Seven Tag Roster headers and movetext
 func (p *PGNParser) ValidatePGN(pgn string) error {
+	return p.ValidatePGNMode(pgn, models.PGNValidationStrict)
+}
+
+// ValidatePGNMode validates a PGN in the given mode. Lenient mode, the default, only requires
+// movetext with at least one parseable move, since exported PGNs often lack tags such as Round.
+// Strict mode also requires the Seven Tag Roster headers. Sanitize mode validates leniently after
+// SanitizePGN has fixed the PGN.
+func (p *PGNParser) ValidatePGNMode(pgn, mode string) error {
+	switch mode {
+	case "", models.PGNValidationLenient, models.PGNValidationStrict:
+	case models.PGNValidationSanitize:
+		pgn, _ = SanitizePGN(pgn)
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownValidationMode, mode)
+	}
 	if strings.TrimSpace(pgn) == "" {
 		return fmt.Errorf("empty PGN")
 	}
 
-	// Check for required headers
-	headers := p.parseHeaders(strings.Split(pgn, "\n\n")[0])
-	requiredHeaders := []string{"event", "site", "date", "round", "white", "black", "result"}
-
-	for _, header := range requiredHeaders {
-		if _, exists := headers[header]; !exists {
-			return fmt.Errorf("missing required header: %s", header)
+	headerSection, movetext := splitPGN(pgn)
+	if mode == models.PGNValidationStrict {
+		headers := p.parseHeaders(headerSection)
+		for _, tag := range sevenTagRoster {
+			if _, exists := headers[strings.ToLower(tag)]; !exists {
+				return fmt.Errorf("missing required header: %s", strings.ToLower(tag))
+			}
 		}
 	}
 
-	// Check for moves section
-	parts := strings.Split(pgn, "\n\n")
-	if len(parts) < 2 {
+	if movetext == "" {
 		return fmt.Errorf("missing moves section")
 	}
+	moves, _, err := p.parseMoves(movetext)
+	if err != nil {
+		return fmt.Errorf("unparseable movetext: %w", err)
+	}
+	if len(moves) == 0 {
+		return fmt.Errorf("no moves found in movetext")
+	}
+	return nil
+}
 
-	// Basic move validation
-	movesSection := parts[1]
-	if strings.TrimSpace(movesSection) == "" {
-		return fmt.Errorf("empty moves section")
+// splitPGN separates the leading tag pairs from the movetext of the first game.
+// The blank line after the tags is optional, and a PGN without tags is all movetext.
+func splitPGN(pgn string) (string, string) {
+	lines := strings.Split(strings.ReplaceAll(strings.TrimSpace(pgn), "\r\n", "\n"), "\n")
+
+	i := 0
+	for i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), "[") {
+		i++
 	}
 
-	return nil
+	movetext := strings.TrimSpace(strings.Join(lines[i:], "\n"))
+	if end := strings.Index(movetext, "\n\n["); end != -1 {
+		// Later games of a multi-game PGN are ignored
+		movetext = strings.TrimSpace(movetext[:end])
+	}
+
+	return strings.Join(lines[:i], "\n"), movetext
+}
+
+// sevenTagRoster lists the mandatory PGN tags in their required order
+var sevenTagRoster = []string{"Event", "Site", "Date", "Round", "White", "Black", "Result"}
+
+// FormatPGN renders a parsed game back to PGN, including move comments and NAGs.
+// Tags are written with their original names when the source PGN is available.
+func (p *PGNParser) FormatPGN(game *ParsedGame) string {
+	var sb strings.Builder
+
+	written := make(map[string]bool)
+	writeTag := func(name, value string) {
+		sb.WriteString(fmt.Sprintf("[%s \"%s\"]\n", name, value))
+		written[strings.ToLower(name)] = true
+	}
+
+	for _, name := range sevenTagRoster {
+		value, ok := game.Headers[strings.ToLower(name)]
+		if !ok {
+			value = "?"
+		}
+		writeTag(name, value)
+	}
+	if game.PGN != "" {
+		for _, match := range p.gameRegex.FindAllStringSubmatch(strings.Split(game.PGN, "\n\n")[0], -1) {
+			if !written[strings.ToLower(match[1])] {
+				writeTag(match[1], match[2])
+			}
+		}
+	}
+	sb.WriteString("\n")
+
+	var tokens []string
+	needNumber := true
+	for _, move := range game.Moves {
+		if move.Color == "white" {
+			tokens = append(tokens, fmt.Sprintf("%d.", move.MoveNumber))
+		} else if needNumber {
+			tokens = append(tokens, fmt.Sprintf("%d...", move.MoveNumber))
+		}
+		tokens = append(tokens, move.Move)
+		needNumber = false
+
+		if move.NAG != "" {
+			tokens = append(tokens, move.NAG)
+		}
+		if move.Comment != "" {
+			tokens = append(tokens, "{"+strings.ReplaceAll(move.Comment, "}", ")")+"}")
+			needNumber = true
+		}
+	}
+
+	result := game.Result
+	if result == "" {
+		result = "*"
+	}
+	tokens = append(tokens, result)
+
+	writeMovetext(&sb, tokens)
+	return sb.String()
 }
 
 // GetMoveAtPosition returns the move at a specific position number
@@ -332,3 +474,67 @@ func (p *PGNParser) IsValidMove(move string) bool {
 	moveRegex := regexp.MustCompile(`^[KQRBN]?[a-h]?[1-8]?x?[a-h][1-8](?:=[QRBN])?[+#]?$|^O-O(-O)?[+#]?$`)
 	return moveRegex.MatchString(move)
 }
+
+// SplitGames reads a multi-game PGN database and calls fn with each game's text and its byte
+// offset in the stream. A game ends with its termination marker or where the tag pairs of the
+// next game begin, so movetext without tags after a finished game is a game of its own. The whole
+// database never has to be held in memory.
+func SplitGames(r io.Reader, fn func(offset int64, pgn string) error) error {
+	reader := bufio.NewReader(r)
+
+	var game strings.Builder
+	var start, offset int64
+	inMovetext, ended := false, false
+
+	flush := func() error {
+		text := game.String()
+		game.Reset()
+		if strings.TrimSpace(text) == "" {
+			return nil
+		}
+		return fn(start, text)
+	}
+
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			trimmed := strings.TrimSpace(line)
+			tag := strings.HasPrefix(trimmed, "[")
+			if trimmed != "" && (ended || (tag && inMovetext)) {
+				if err := flush(); err != nil {
+					return err
+				}
+				start, inMovetext, ended = offset, false, false
+			}
+			switch {
+			case trimmed != "" && !tag:
+				inMovetext = true
+				ended = endsGame(trimmed)
+			case trimmed == "" && game.Len() == 0:
+				// Skip blank lines between games
+				start = offset + int64(len(line))
+			}
+			if game.Len() > 0 || trimmed != "" {
+				game.WriteString(line)
+			}
+			offset += int64(len(line))
+		}
+
+		if err == io.EOF {
+			return flush()
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// endsGame reports whether a line of movetext ends with a game termination marker
+func endsGame(line string) bool {
+	fields := strings.Fields(line)
+	switch fields[len(fields)-1] {
+	case "1-0", "0-1", "1/2-1/2", "*":
+		return true
+	}
+	return false
+}
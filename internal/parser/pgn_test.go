@@ -1,7 +1,12 @@
 package parser
 
 import (
+	"errors"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
 )
 
 func TestPGNParser_ParsePGN(t *testing.T) {
@@ -111,6 +116,133 @@ func TestPGNParser_ValidatePGN(t *testing.T) {
 	}
 }
 
+func TestPGNParser_ValidatePGNMode(t *testing.T) {
+	parser := NewPGNParser()
+
+	noRound := "[Event \"Live Chess\"]\n[Site \"Chess.com\"]\n[White \"a\"]\n[Black \"b\"]\n[Result \"1-0\"]\n\n1. e4 e5 1-0"
+	tests := []struct {
+		name    string
+		pgn     string
+		mode    string
+		wantErr bool
+	}{
+		{"strict without Round", noRound, models.PGNValidationStrict, true},
+		{"strict with unparseable movetext", "[Event \"a\"]\n[Site \"b\"]\n[Date \"c\"]\n[Round \"1\"]\n[White \"d\"]\n[Black \"e\"]\n[Result \"*\"]\n\n1.e4 e5 *", models.PGNValidationStrict, true},
+		{"lenient without Round", noRound, models.PGNValidationLenient, false},
+		{"default is lenient", noRound, "", false},
+		{"movetext only", "1. d4 d5 2. c4 *", models.PGNValidationLenient, false},
+		{"tags without blank line", "[Event \"Test\"]\r\n1. e4 e5 *", models.PGNValidationLenient, false},
+		{"tags only", "[Event \"Test\"]\n[Result \"*\"]", models.PGNValidationLenient, true},
+		{"no moves", "[Event \"Test\"]\n\n*", models.PGNValidationLenient, true},
+		{"glued move numbers", "1.e4 e5 2.Nf3 *", models.PGNValidationLenient, true},
+		{"glued move numbers sanitized", "1.e4 e5 2.Nf3 *", models.PGNValidationSanitize, false},
+		{"unknown mode", noRound, "sloppy", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := parser.ValidatePGNMode(tt.pgn, tt.mode); (err != nil) != tt.wantErr {
+				t.Errorf("ValidatePGNMode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+	if err := parser.ValidatePGNMode(noRound, "sloppy"); !errors.Is(err, ErrUnknownValidationMode) {
+		t.Errorf("Expected ErrUnknownValidationMode for an unknown mode, got %v", err)
+	}
+
+	game, err := parser.ParsePGN("1. d4 d5 2. c4 *")
+	if err != nil || len(game.Moves) != 3 || game.Result != "*" {
+		t.Errorf("Expected a PGN without tags to parse, got %+v, %v", game, err)
+	}
+
+	// A move wrapped onto the next line keeps its number and color
+	game, err = parser.ParsePGN("1. d4 d5 2. c4\ne6 ; Queen's Gambit Declined\n3. Nc3 *")
+	if err != nil || len(game.Moves) != 5 {
+		t.Fatalf("Expected wrapped movetext to parse, got %+v, %v", game, err)
+	}
+	if move := game.Moves[3]; move.Move != "e6" || move.MoveNumber != 2 || move.Color != "black" {
+		t.Errorf("Unexpected wrapped move: %+v", move)
+	}
+}
+
+func TestTerminationReason(t *testing.T) {
+	tests := []struct {
+		termination string
+		want        models.ResultReason
+	}{
+		{"Hikaru won on time", models.ResultReasonTimeout},
+		{"Time forfeit", models.ResultReasonTimeout},
+		{"MagnusCarlsen won by resignation", models.ResultReasonResignation},
+		{"Hikaru won - game abandoned", models.ResultReasonAbandoned},
+		{"Game drawn by timeout vs insufficient material", models.ResultReasonTimeoutVsInsufficient},
+		{"Game drawn by insufficient material", models.ResultReasonInsufficientMaterial},
+		{"Game drawn by 50-move rule", models.ResultReasonFiftyMoveRule},
+		{"Normal", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := TerminationReason(tt.termination); got != tt.want {
+			t.Errorf("TerminationReason(%q) = %q, want %q", tt.termination, got, tt.want)
+		}
+	}
+}
+
+func TestSanitizePGN(t *testing.T) {
+	pgn := "\ufeff[Event “Club Night”]\r\n[White ‘Ann’]\r\n[Result \"1-0\"]\r\n1.e4 e5 2.Nf3 2...Nc6 3.Bc4 { Giuoco\r\n} 3...Bc5 4.0-0 Nf6; Two knights?\r\n5.d3 1-0"
+
+	sanitized, fixes := SanitizePGN(pgn)
+	expected := "[Event \"Club Night\"]\n[White 'Ann']\n[Result \"1-0\"]\n\n" +
+		"1. e4 e5 2. Nf3 Nc6 3. Bc4 {Giuoco} 3... Bc5 4. O-O Nf6 {Two knights?} 5. d3 1-0\n"
+	if sanitized != expected {
+		t.Errorf("SanitizePGN() =\n%s\nwant\n%s", sanitized, expected)
+	}
+
+	wantFixes := []string{models.PGNFixLineEndings, models.PGNFixSmartQuotes, models.PGNFixTagSeparator,
+		models.PGNFixMoveNumbers, models.PGNFixCastling, models.PGNFixComments}
+	if strings.Join(fixes, ",") != strings.Join(wantFixes, ",") {
+		t.Errorf("SanitizePGN() fixes = %v, want %v", fixes, wantFixes)
+	}
+
+	game, err := NewPGNParser().ParsePGN(sanitized)
+	if err != nil || len(game.Moves) != 9 || game.Headers["event"] != "Club Night" || game.Moves[6].Move != "O-O" {
+		t.Errorf("Expected the sanitized PGN to parse, got %+v, %v", game, err)
+	}
+
+	// A well-formed PGN needs no fixes, and a game starting with Black keeps its move number
+	if sanitized, fixes = SanitizePGN("1... e5 2. Nf3 *"); sanitized != "1... e5 2. Nf3 *\n" || len(fixes) != 0 {
+		t.Errorf("SanitizePGN() = %q, %v", sanitized, fixes)
+	}
+}
+
+func TestPGNParser_ExtractPositions(t *testing.T) {
+	parser := NewPGNParser()
+
+	// A game set up with Black to move starts with a Black ply
+	game, err := parser.ParsePGN(`[SetUp "1"]
+[FEN "6k1/8/8/8/8/8/5r2/6K1 b - - 0 40"]
+
+40... Rf1+ 41. Kxf1 *`)
+	if err != nil {
+		t.Fatalf("ParsePGN() error = %v", err)
+	}
+	if err := parser.ExtractPositions(game); err != nil {
+		t.Fatalf("ExtractPositions() error = %v", err)
+	}
+
+	want := []struct{ color, fen string }{
+		{"black", "6k1/8/8/8/8/8/8/5rK1 w - - 1 41"},
+		{"white", "6k1/8/8/8/8/8/8/5K2 b - - 0 41"},
+	}
+	if len(game.Moves) != len(want) {
+		t.Fatalf("Expected %d moves, got %+v", len(want), game.Moves)
+	}
+	for i, w := range want {
+		if game.Moves[i].Color != w.color || game.Moves[i].FEN != w.fen {
+			t.Errorf("Move %d = %s after %s, want %s after %s", i, game.Moves[i].Color, game.Moves[i].FEN, w.color, w.fen)
+		}
+	}
+}
+
 func TestPGNParser_IsValidMove(t *testing.T) {
 	parser := NewPGNParser()
 
@@ -242,3 +374,194 @@ func TestPGNParser_ConvertToGameInfo(t *testing.T) {
 		t.Error("Expected moves to be converted")
 	}
 }
+
+func TestPGNParser_FormatPGN(t *testing.T) {
+	parser := NewPGNParser()
+
+	testPGN := `[Event "Test Game"]
+[Site "Test Site"]
+[Date "2023.01.01"]
+[Round "1"]
+[White "TestWhite"]
+[Black "TestBlack"]
+[Result "1-0"]
+[TimeControl "600"]
+
+1. e4 e5 2. Nf3 Nc6 1-0`
+
+	game, err := parser.ParsePGN(testPGN)
+	if err != nil {
+		t.Fatalf("Failed to parse PGN: %v", err)
+	}
+	game.Moves[1].Comment = "Symmetrical"
+
+	formatted := parser.FormatPGN(game)
+
+	expected := `[Event "Test Game"]
+[Site "Test Site"]
+[Date "2023.01.01"]
+[Round "1"]
+[White "TestWhite"]
+[Black "TestBlack"]
+[Result "1-0"]
+[TimeControl "600"]
+
+1. e4 e5 {Symmetrical} 2. Nf3 Nc6 1-0
+`
+	if formatted != expected {
+		t.Errorf("FormatPGN() =\n%s\nwant\n%s", formatted, expected)
+	}
+
+	// The formatted PGN must parse back to the same moves
+	reparsed, err := parser.ParsePGN(formatted)
+	if err != nil {
+		t.Fatalf("Failed to parse formatted PGN: %v", err)
+	}
+	if len(reparsed.Moves) != len(game.Moves) {
+		t.Errorf("Expected %d moves after round trip, got %d", len(game.Moves), len(reparsed.Moves))
+	}
+}
+
+func TestPGNParser_ExtractClocks(t *testing.T) {
+	parser := NewPGNParser()
+
+	clocks := parser.ExtractClocks(`1. e4 {[%clk 0:02:59.9]} 1... e5 {[%clk 1:00:05]} 2. Nf3 {[%clk 0:2:58]} *`)
+	want := []time.Duration{179*time.Second + 900*time.Millisecond, time.Hour + 5*time.Second, 178 * time.Second}
+
+	if len(clocks) != len(want) {
+		t.Fatalf("ExtractClocks() = %v, want %v", clocks, want)
+	}
+	for i := range want {
+		if clocks[i] != want[i] {
+			t.Errorf("clock %d = %v, want %v", i, clocks[i], want[i])
+		}
+	}
+}
+
+func TestSplitGames(t *testing.T) {
+	database := "\n[Event \"One\"]\n[Result \"1-0\"]\n\n1. e4 e5\n2. Qh5 Nc6 1-0\n\n\n" +
+		"[Event \"Two\"]\n[Result \"0-1\"]\n1. f3 e5 2. g4 Qh4# 0-1\n" +
+		"1. d4 d5 *\n"
+
+	type split struct {
+		offset int64
+		pgn    string
+	}
+	var games []split
+	err := SplitGames(strings.NewReader(database), func(offset int64, pgn string) error {
+		games = append(games, split{offset, pgn})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("SplitGames() error = %v", err)
+	}
+
+	if len(games) != 3 {
+		t.Fatalf("Expected 3 games, got %d: %+v", len(games), games)
+	}
+	for i, game := range games {
+		if got := database[game.offset : game.offset+int64(len(game.pgn))]; got != game.pgn {
+			t.Errorf("Game %d offset %d does not locate its text: %q", i, game.offset, got)
+		}
+	}
+	if !strings.HasPrefix(games[0].pgn, "[Event \"One\"]") || !strings.HasPrefix(games[1].pgn, "[Event \"Two\"]") {
+		t.Errorf("Unexpected games: %+v", games)
+	}
+	if strings.Contains(games[1].pgn, "1. d4 d5") || games[2].pgn != "1. d4 d5 *\n" {
+		t.Errorf("Expected movetext without tags after a finished game to be a game of its own, got %+v", games)
+	}
+}
+
+func TestPGNParser_ExtractMoveTimes(t *testing.T) {
+	parser := NewPGNParser()
+
+	pgn := `[Event "Mixed"]
+
+1. e4 {[%clk 0:03:00] [%emt 0:00:02]} 1... e5 {[%timestamp 35]} (1... c5 {[%clk 0:01:00]} 2. Nf3) 2. Nf3 {[%clk 2:58]} ; [%emt 0:00:04.5]
+2... Nc6 {no time} 3.Bb5 {[%emt 1:05]} 1-0`
+
+	times := parser.ExtractMoveTimes(pgn)
+	if len(times) != 5 {
+		t.Fatalf("Expected 5 main-line plies, got %d: %+v", len(times), times)
+	}
+
+	want := []MoveTime{
+		{Ply: 1, Clock: 3 * time.Minute, Elapsed: 2 * time.Second, HasClock: true, HasElapsed: true},
+		{Ply: 2, Elapsed: 3500 * time.Millisecond, HasElapsed: true},
+		{Ply: 3, Clock: 178 * time.Second, Elapsed: 4500 * time.Millisecond, HasClock: true, HasElapsed: true},
+		{Ply: 4},
+		{Ply: 5, Elapsed: 65 * time.Second, HasElapsed: true},
+	}
+	for i := range want {
+		if times[i] != want[i] {
+			t.Errorf("ply %d = %+v, want %+v", i+1, times[i], want[i])
+		}
+	}
+}
+
+func TestPGNParser_ExtractMoveEvals(t *testing.T) {
+	parser := NewPGNParser()
+
+	pgn := `[Event "Annotated"]
+
+1. e4 {[%eval 0.17] [%clk 0:03:00]} 1... e5 {[%eval 0.2,24]} (1... c5 {[%eval 0.3]}) 2. Qh5 {[%eval -0.45]}
+2... Nc6 3. Bc4 {no eval} 3... Nf6 {[%eval #1]} 4. Qxf7# {[%eval #-0]} 1-0`
+
+	if !parser.HasMoveEvals(pgn) {
+		t.Error("HasMoveEvals() = false for an annotated PGN")
+	}
+	evals := parser.ExtractMoveEvals(pgn)
+	if len(evals) != 7 {
+		t.Fatalf("Expected 7 main-line plies, got %d: %+v", len(evals), evals)
+	}
+
+	want := []MoveEval{
+		{Ply: 1, Evaluation: 0.17, HasEval: true},
+		{Ply: 2, Evaluation: 0.2, Depth: 24, HasEval: true},
+		{Ply: 3, Evaluation: -0.45, HasEval: true},
+		{Ply: 4},
+		{Ply: 5},
+		{Ply: 6, Evaluation: 999, Mate: 1, HasEval: true},
+		{Ply: 7},
+	}
+	for i := range want {
+		if evals[i] != want[i] {
+			t.Errorf("ply %d = %+v, want %+v", i+1, evals[i], want[i])
+		}
+	}
+
+	if parser.HasMoveEvals("1. e4 {[%clk 0:03:00]} e5 1-0") {
+		t.Error("HasMoveEvals() = true for a PGN without evaluations")
+	}
+}
+
+func TestFillMoveTimes(t *testing.T) {
+	times := []MoveTime{
+		{Ply: 1, Clock: 178 * time.Second, HasClock: true},
+		{Ply: 2, Elapsed: 5 * time.Second, HasElapsed: true},
+		{Ply: 3, Elapsed: 10 * time.Second, HasElapsed: true},
+		{Ply: 4},
+		{Ply: 5, Clock: 160 * time.Second, HasClock: true},
+		{Ply: 6, Clock: 150 * time.Second, HasClock: true},
+	}
+	FillMoveTimes(times, 3*time.Minute, 2*time.Second)
+
+	// White: 180 + 2 - 178 = 4s spent; 178 + 2 - 10 = 170s left; 170 + 2 - 160 = 12s spent
+	if !times[0].HasElapsed || times[0].Elapsed != 4*time.Second {
+		t.Errorf("ply 1 = %+v, want 4s elapsed", times[0])
+	}
+	if !times[2].HasClock || times[2].Clock != 170*time.Second {
+		t.Errorf("ply 3 = %+v, want 170s left", times[2])
+	}
+	if !times[4].HasElapsed || times[4].Elapsed != 12*time.Second {
+		t.Errorf("ply 5 = %+v, want 12s elapsed", times[4])
+	}
+
+	// Black: 180 + 2 - 5 = 177s left, then nothing is known about ply 4, so ply 6 can't be derived
+	if !times[1].HasClock || times[1].Clock != 177*time.Second {
+		t.Errorf("ply 2 = %+v, want 177s left", times[1])
+	}
+	if times[3].HasClock || times[3].HasElapsed || times[5].HasElapsed {
+		t.Errorf("Expected no times after a ply without any, got %+v and %+v", times[3], times[5])
+	}
+}
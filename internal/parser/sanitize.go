@@ -0,0 +1,181 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+)
+
+// smartQuotes replaces the typographic quotes word processors and chat apps put in tag values
+var smartQuotes = strings.NewReplacer(
+	"“", `"`, "”", `"`, "„", `"`, "‟", `"`, "«", `"`, "»", `"`,
+	"‘", "'", "’", "'", "‚", "'", "‛", "'",
+)
+
+var (
+	moveNumberToken = regexp.MustCompile(`^(\d+)(\.+)(.*)$`)
+	zeroCastling    = regexp.MustCompile(`^0-0(-0)?([+#!?]*)$`)
+)
+
+// SanitizePGN fixes the defects of PGNs commonly exported by apps: CRLF line endings, smart
+// quotes in tags, a missing blank line after the tags, move numbers glued to moves or repeated
+// for Black as "1...", castling written with zeros and rest-of-line comments. The movetext is
+// rewritten one space apart and wrapped at 80 columns. Only the first game of a database is kept.
+// It returns the sanitized PGN and the fixes that were applied, in the order they were first made.
+func SanitizePGN(pgn string) (string, []string) {
+	var fixes []string
+	fixed := func(fix string) {
+		for _, existing := range fixes {
+			if existing == fix {
+				return
+			}
+		}
+		fixes = append(fixes, fix)
+	}
+
+	text := strings.TrimPrefix(pgn, "\ufeff")
+	if strings.Contains(text, "\r") {
+		text = strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\n"), "\r", "\n")
+		fixed(models.PGNFixLineEndings)
+	}
+
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	var tags []string
+	i := 0
+	for ; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if !strings.HasPrefix(line, "[") {
+			break
+		}
+		if cleaned := smartQuotes.Replace(line); cleaned != line {
+			line = cleaned
+			fixed(models.PGNFixSmartQuotes)
+		}
+		tags = append(tags, line)
+	}
+	if len(tags) > 0 && i < len(lines) && strings.TrimSpace(lines[i]) != "" {
+		fixed(models.PGNFixTagSeparator)
+	}
+
+	movetext := strings.TrimSpace(strings.Join(lines[i:], "\n"))
+	if end := strings.Index(movetext, "\n\n["); end != -1 {
+		movetext = movetext[:end]
+	}
+	tokens := sanitizeMovetext(movetext, fixed)
+
+	var sb strings.Builder
+	for _, tag := range tags {
+		sb.WriteString(tag)
+		sb.WriteString("\n")
+	}
+	if len(tags) > 0 {
+		sb.WriteString("\n")
+	}
+	writeMovetext(&sb, tokens)
+	return sb.String(), fixes
+}
+
+// sanitizeMovetext splits movetext into normalized tokens. Comments and variations are kept
+// whole, with their whitespace collapsed.
+func sanitizeMovetext(movetext string, fixed func(string)) []string {
+	var tokens []string
+	afterMove := false // The previous token was a move, so a Black move number is redundant
+
+	runes := []rune(movetext)
+	for i := 0; i < len(runes); {
+		switch r := runes[i]; {
+		case r == ' ' || r == '\t' || r == '\n':
+			i++
+		case r == '{':
+			end := i + 1
+			for end < len(runes) && runes[end] != '}' {
+				end++
+			}
+			comment := strings.Join(strings.Fields(string(runes[i+1:end])), " ")
+			if end == len(runes) {
+				fixed(models.PGNFixComments)
+			}
+			tokens = append(tokens, "{"+comment+"}")
+			i = end + 1
+			afterMove = false
+		case r == ';':
+			end := i + 1
+			for end < len(runes) && runes[end] != '\n' {
+				end++
+			}
+			comment := strings.TrimSpace(strings.ReplaceAll(string(runes[i+1:end]), "}", ")"))
+			tokens = append(tokens, "{"+comment+"}")
+			fixed(models.PGNFixComments)
+			i = end
+			afterMove = false
+		case r == '(':
+			depth, end := 0, i
+			for ; end < len(runes); end++ {
+				if runes[end] == '(' {
+					depth++
+				} else if runes[end] == ')' {
+					depth--
+					if depth == 0 {
+						break
+					}
+				}
+			}
+			if end == len(runes) {
+				end--
+			}
+			tokens = append(tokens, strings.Join(strings.Fields(string(runes[i:end+1])), " "))
+			i = end + 1
+			afterMove = false
+		default:
+			end := i
+			for end < len(runes) && !strings.ContainsRune(" \t\n{};(", runes[end]) {
+				end++
+			}
+			word := strings.ReplaceAll(string(runes[i:end]), "…", "...")
+			i = end
+
+			if match := moveNumberToken.FindStringSubmatch(word); match != nil {
+				if match[2] == "." {
+					tokens = append(tokens, match[1]+".")
+				} else if afterMove {
+					fixed(models.PGNFixMoveNumbers)
+				} else {
+					tokens = append(tokens, match[1]+"...")
+				}
+				if match[3] == "" {
+					continue
+				}
+				word = match[3]
+				fixed(models.PGNFixMoveNumbers)
+			}
+
+			if match := zeroCastling.FindStringSubmatch(word); match != nil {
+				word = "O-O" + strings.ReplaceAll(match[1], "0", "O") + match[2]
+				fixed(models.PGNFixCastling)
+			}
+			tokens = append(tokens, word)
+			afterMove = true
+		}
+	}
+	return tokens
+}
+
+// writeMovetext writes movetext tokens wrapped at 80 columns, as recommended by the PGN standard
+func writeMovetext(sb *strings.Builder, tokens []string) {
+	lineLength := 0
+	for i, token := range tokens {
+		if i > 0 {
+			if lineLength+1+len(token) > 80 {
+				sb.WriteString("\n")
+				lineLength = 0
+			} else {
+				sb.WriteString(" ")
+				lineLength++
+			}
+		}
+		sb.WriteString(token)
+		lineLength += len(token)
+	}
+	sb.WriteString("\n")
+}
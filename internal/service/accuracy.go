@@ -0,0 +1,157 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+// Accuracy model parameters
+const (
+	accuracyEvalCap     = 10.0  // Evaluations are capped at ±10 pawns so mates don't dominate the loss
+	cplCurveScale       = 300.0 // Centipawn loss at which the CPL curve falls to 37%
+	linearPointsPerPawn = 10.0  // Accuracy the linear model deducts per pawn lost
+)
+
+// MoveEvaluation is what an accuracy model sees of a move: the evaluations in pawns before and
+// after it, from the mover's point of view
+type MoveEvaluation struct {
+	Before float64
+	After  float64
+	Color  string // white or black
+}
+
+// Loss returns the pawns the move gave away, with both evaluations capped
+func (m MoveEvaluation) Loss() float64 {
+	return math.Max(capEvaluation(m.Before)-capEvaluation(m.After), 0)
+}
+
+// AccuracyModel scores a move from 0 (worst) to 100 (best)
+type AccuracyModel interface {
+	MoveAccuracy(move MoveEvaluation) float64
+}
+
+// AccuracyFunc adapts a function to AccuracyModel
+type AccuracyFunc func(move MoveEvaluation) float64
+
+// MoveAccuracy calls f
+func (f AccuracyFunc) MoveAccuracy(move MoveEvaluation) float64 {
+	return f(move)
+}
+
+// accuracyModels holds the models requests can choose from, by name
+var accuracyModels = map[string]AccuracyModel{
+	models.AccuracyModelLegacy:     AccuracyFunc(legacyAccuracy),
+	models.AccuracyModelCPL:        AccuracyFunc(cplAccuracy),
+	models.AccuracyModelWinPercent: AccuracyFunc(winPercentAccuracy),
+	models.AccuracyModelLinear:     AccuracyFunc(linearAccuracy),
+}
+
+// RegisterAccuracyModel makes a model selectable by name, e.g. to compare a research formula
+// with the built-in ones on the same games. Register models before serving requests.
+func RegisterAccuracyModel(name string, model AccuracyModel) {
+	accuracyModels[name] = model
+}
+
+// AccuracyModelNames lists the selectable models, sorted
+func AccuracyModelNames() []string {
+	names := make([]string, 0, len(accuracyModels))
+	for name := range accuracyModels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetAccuracyModel sets the model used when a request doesn't choose one
+func (s *AnalysisService) SetAccuracyModel(name string) error {
+	if _, ok := accuracyModels[name]; !ok {
+		return unknownAccuracyModelError(name)
+	}
+	s.accuracyModel = name
+	return nil
+}
+
+// requestAccuracyModel resolves the accuracy model of a request: the one it names, the
+// service default, or the legacy model
+func (s *AnalysisService) requestAccuracyModel(request *models.AnalysisRequest) (string, AccuracyModel, error) {
+	name := request.AccuracyModel
+	if name == "" {
+		name = s.accuracyModel
+	}
+	if name == "" {
+		name = models.AccuracyModelLegacy
+	}
+
+	model, ok := accuracyModels[name]
+	if !ok {
+		return "", nil, unknownAccuracyModelError(name)
+	}
+	return name, model, nil
+}
+
+// unknownAccuracyModelError reports an accuracy model that isn't registered
+func unknownAccuracyModelError(name string) error {
+	return errors.NewValidationError("accuracy_model", fmt.Sprintf("unknown accuracy model %q, must be one of: %s",
+		name, strings.Join(AccuracyModelNames(), ", ")))
+}
+
+// moveEvaluation builds a model's view of a move from White-relative evaluations
+func moveEvaluation(before, after float64, color string) MoveEvaluation {
+	if color == "black" {
+		before, after = -before, -after
+	}
+	return MoveEvaluation{Before: before, After: after, Color: color}
+}
+
+// legacyAccuracy scores the position reached rather than the move: evaluations favouring White
+// cost accuracy slowly, evaluations favouring Black quickly, whoever moved
+func legacyAccuracy(move MoveEvaluation) float64 {
+	evaluation := move.After
+	if move.Color == "black" {
+		evaluation = -evaluation
+	}
+
+	if evaluation >= 0 {
+		return 100.0 - (evaluation * 10) // Penalize positive evaluations less
+	}
+	return 100.0 + (evaluation * 15) // Penalize negative evaluations more
+}
+
+// cplAccuracy maps the centipawn loss onto a decaying curve, in the spirit of Lichess's
+// average centipawn loss: 50cp keeps 85%, 100cp 72% and 300cp 37%
+func cplAccuracy(move MoveEvaluation) float64 {
+	return 100 * math.Exp(-move.Loss()*100/cplCurveScale)
+}
+
+// winPercentAccuracy compares winning chances before and after the move, as Chess.com and
+// Lichess do, so the same centipawn loss matters less in decided positions
+func winPercentAccuracy(move MoveEvaluation) float64 {
+	drop := math.Max(winPercent(move.Before)-winPercent(move.After), 0)
+	return clampAccuracy(103.1668*math.Exp(-0.04354*drop) - 3.1669)
+}
+
+// linearAccuracy deducts a fixed amount per pawn lost
+func linearAccuracy(move MoveEvaluation) float64 {
+	return clampAccuracy(100 - move.Loss()*linearPointsPerPawn)
+}
+
+// winPercent converts an evaluation in pawns to the mover's winning chances, 0-100
+func winPercent(evaluation float64) float64 {
+	centipawns := capEvaluation(evaluation) * 100
+	return 50 + 50*(2/(1+math.Exp(-0.00368208*centipawns))-1)
+}
+
+// capEvaluation limits an evaluation to ±accuracyEvalCap pawns
+func capEvaluation(evaluation float64) float64 {
+	return math.Max(-accuracyEvalCap, math.Min(accuracyEvalCap, evaluation))
+}
+
+// clampAccuracy limits an accuracy to 0-100
+func clampAccuracy(accuracy float64) float64 {
+	return math.Max(0, math.Min(100, accuracy))
+}
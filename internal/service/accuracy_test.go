@@ -0,0 +1,93 @@
+package service
+
+import (
+	"math"
+	"testing"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+func TestAccuracyModels(t *testing.T) {
+	tests := []struct {
+		name      string
+		model     string
+		before    float64
+		after     float64
+		color     string
+		want      float64
+		tolerance float64
+	}{
+		{"legacy white edge", models.AccuracyModelLegacy, 0, 0.5, "white", 95, 1e-9},
+		{"legacy black edge", models.AccuracyModelLegacy, 0, -0.5, "black", 92.5, 1e-9},
+		{"cpl best move", models.AccuracyModelCPL, 0.3, 0.3, "white", 100, 1e-9},
+		{"cpl one pawn", models.AccuracyModelCPL, 0.5, -0.5, "white", 71.65, 0.01},
+		{"cpl black loss", models.AccuracyModelCPL, -0.5, 0.5, "black", 71.65, 0.01},
+		{"cpl improvement", models.AccuracyModelCPL, 0, 1, "white", 100, 1e-9},
+		{"linear two pawns", models.AccuracyModelLinear, 1, -1, "white", 80, 1e-9},
+		{"linear floor", models.AccuracyModelLinear, 10, -10, "white", 0, 1e-9},
+		{"linear mates capped", models.AccuracyModelLinear, 999, 9, "white", 90, 1e-9},
+		{"win percent best move", models.AccuracyModelWinPercent, 0, 0, "white", 100, 0.01},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := accuracyModels[tt.model].MoveAccuracy(moveEvaluation(tt.before, tt.after, tt.color))
+			if math.Abs(got-tt.want) > tt.tolerance {
+				t.Errorf("%s accuracy = %.3f, want %.3f", tt.model, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWinPercentAccuracyDecidedPositions(t *testing.T) {
+	level := winPercentAccuracy(moveEvaluation(0, -1, "white"))
+	decided := winPercentAccuracy(moveEvaluation(7, 6, "white"))
+	if decided <= level {
+		t.Errorf("Expected losing a pawn in a won position (%.1f) to cost less than in a level one (%.1f)", decided, level)
+	}
+}
+
+func TestRequestAccuracyModel(t *testing.T) {
+	s := &AnalysisService{}
+
+	name, _, err := s.requestAccuracyModel(&models.AnalysisRequest{})
+	if err != nil || name != models.AccuracyModelLegacy {
+		t.Errorf("Expected the legacy model by default, got %q (%v)", name, err)
+	}
+
+	if err := s.SetAccuracyModel(models.AccuracyModelCPL); err != nil {
+		t.Fatalf("SetAccuracyModel failed: %v", err)
+	}
+	name, _, _ = s.requestAccuracyModel(&models.AnalysisRequest{})
+	if name != models.AccuracyModelCPL {
+		t.Errorf("Expected the service default, got %q", name)
+	}
+	name, _, _ = s.requestAccuracyModel(&models.AnalysisRequest{AccuracyModel: models.AccuracyModelLinear})
+	if name != models.AccuracyModelLinear {
+		t.Errorf("Expected the request's model, got %q", name)
+	}
+
+	_, _, err = s.requestAccuracyModel(&models.AnalysisRequest{AccuracyModel: "elo"})
+	var validationErr *errors.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Errorf("Expected a validation error for an unknown model, got %v", err)
+	}
+	if err := s.SetAccuracyModel("elo"); err == nil {
+		t.Error("Expected SetAccuracyModel to reject an unknown model")
+	}
+}
+
+func TestRegisterAccuracyModel(t *testing.T) {
+	RegisterAccuracyModel("perfect", AccuracyFunc(func(MoveEvaluation) float64 { return 100 }))
+	defer delete(accuracyModels, "perfect")
+
+	s := &AnalysisService{}
+	name, model, err := s.requestAccuracyModel(&models.AnalysisRequest{AccuracyModel: "perfect"})
+	if err != nil || name != "perfect" {
+		t.Fatalf("Expected the registered model, got %q (%v)", name, err)
+	}
+	if got := model.MoveAccuracy(MoveEvaluation{Before: 5, After: -5}); got != 100 {
+		t.Errorf("Registered model accuracy = %.1f, want 100", got)
+	}
+}
@@ -2,85 +2,337 @@ package service
 
 import (
 	"context"
+	stderrors "errors"
 	"fmt"
-	"sync"
+	"log"
+	"math"
+	"strings"
 	"time"
 
+	"github.com/pedrampdd/ChessAnalyser/internal/blob"
+	"github.com/pedrampdd/ChessAnalyser/internal/board"
+	"github.com/pedrampdd/ChessAnalyser/internal/cache"
 	"github.com/pedrampdd/ChessAnalyser/internal/engine"
+	"github.com/pedrampdd/ChessAnalyser/internal/i18n"
 	"github.com/pedrampdd/ChessAnalyser/internal/models"
 	"github.com/pedrampdd/ChessAnalyser/internal/parser"
+	"github.com/pedrampdd/ChessAnalyser/internal/storage"
 	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
 )
 
+// defaultVerifyDepthIncrease is how much deeper flagged moves are re-searched when no verify depth is given
+const defaultVerifyDepthIncrease = 6
+
+// Auto-extension defaults: plies whose evaluation swings more than defaultExtendSwing pawns are
+// re-searched defaultExtendDepthIncrease plies deeper than the depth they reached. Extensions
+// never go deeper than MaxExtendDepth, and give up deepening after extendTimeLimit.
+const (
+	defaultExtendSwing         = 2.0
+	defaultExtendDepthIncrease = 4
+	MaxExtendDepth             = 30
+	extendTimeLimit            = 5000 // Milliseconds
+)
+
+// Analysis cache defaults, until SetCacheOptions applies the configured ones
+const (
+	defaultAnalysisCacheSize = 1000
+	defaultAnalysisCacheTTL  = time.Hour
+)
+
+// Streaming defaults, until SetStreamingOptions applies the configured ones
+const (
+	defaultStreamPlies  = 400 // 200 moves
+	defaultStreamWindow = 32
+)
+
 // AnalysisService provides chess game analysis using Stockfish engine
 type AnalysisService struct {
 	enginePool      *engine.EnginePool
+	engineErr       error                // Why the default pool could not start; analysis needing it fails with it
+	partitions      []*enginePartition   // Additional pools routed by variant or profile
+	queueLimit      int                  // Client requests allowed to wait for an engine per pool
+	loadShedding    models.LoadShedding  // How non-priority analyses are reduced while the queue is long
+	batchLimit      int                  // Items a batch analysis request may hold
+	engineLimits    models.EngineLimits  // Resource limits of the engine processes of every pool
+	engineSandbox   models.EngineSandbox // Restrictions the engine processes of every pool start with
+	engineTuning    *models.EngineTuning // How auto-tuning sized the default pool (nil when not auto-tuned)
+	lazyEngines     bool                 // Pools start engines on first use
+	engineIdle      time.Duration        // How long an engine of a lazy pool may sit idle before it's stopped
+	humanModel      *engine.MaiaPool     // Optional Maia engines for human move probabilities
+	blobs           blob.Store           // Holds exported artifacts; their metadata stays in store
+	artifactURLLife time.Duration        // Lifetime of signed artifact download URLs
 	pgnParser       *parser.PGNParser
-	cache           map[string]*models.GameAnalysis
-	cacheMutex      sync.RWMutex
+	store           *storage.MemoryStore
+	cache           *cache.Cache[string, *models.GameAnalysis]
+	openings        *openingCache // Engine results of the first plies of games, by move sequence
+	evals           *evalCache    // Deepest engine result of analyzed positions
+	benchmarks      benchmarkBaseline
 	defaultSettings models.EngineSettings
-	maxCacheSize    int
+	accuracyModel   string // Accuracy model used when a request doesn't choose one
+	streamPlies     int    // Games with at least this many plies to analyze stream their moves to the store (0 disables)
+	streamWindow    int    // Analyzed moves a streamed analysis holds before writing them out
+	audit           auditLog
+	metrics         analysisMetrics
 }
 
 // NewAnalysisService creates a new analysis service
 func NewAnalysisService(executablePath string, maxEngines int, defaultSettings models.EngineSettings) (*AnalysisService, error) {
-	enginePool, err := engine.NewEnginePool(maxEngines, executablePath, defaultSettings)
+	return NewSandboxedAnalysisService(executablePath, maxEngines, defaultSettings, models.EngineSandbox{})
+}
+
+// NewSandboxedAnalysisService creates an analysis service whose engines, including those of pools
+// added later, run under the sandbox's restrictions
+func NewSandboxedAnalysisService(executablePath string, maxEngines int, defaultSettings models.EngineSettings, sandbox models.EngineSandbox) (*AnalysisService, error) {
+	if err := engine.ValidateSandbox(sandbox); err != nil {
+		return nil, errors.NewValidationError("sandbox", err.Error())
+	}
+	enginePool, err := engine.NewSandboxedEnginePool(maxEngines, executablePath, defaultSettings, sandbox)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create engine pool: %w", err)
 	}
+	return newAnalysisService(enginePool, defaultSettings, sandbox), nil
+}
 
+// NewLazyAnalysisService creates an analysis service for scale-to-zero deployments. Its engine
+// pools, including those added later, start engines on first use and stop engines left idle for
+// idleTimeout (0 keeps them running once started).
+func NewLazyAnalysisService(executablePath string, maxEngines int, defaultSettings models.EngineSettings, sandbox models.EngineSandbox,
+	idleTimeout time.Duration) (*AnalysisService, error) {
+	if err := engine.ValidateSandbox(sandbox); err != nil {
+		return nil, errors.NewValidationError("sandbox", err.Error())
+	}
+	enginePool, err := engine.NewLazyEnginePool(maxEngines, executablePath, defaultSettings, sandbox, idleTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create engine pool: %w", err)
+	}
+
+	service := newAnalysisService(enginePool, defaultSettings, sandbox)
+	service.SetLazyEngines(idleTimeout)
+	return service, nil
+}
+
+// newAnalysisService creates an analysis service around a started default engine pool
+func newAnalysisService(enginePool *engine.EnginePool, defaultSettings models.EngineSettings, sandbox models.EngineSandbox) *AnalysisService {
 	return &AnalysisService{
 		enginePool:      enginePool,
+		engineSandbox:   sandbox,
+		pgnParser:       parser.NewPGNParser(),
+		store:           storage.NewMemoryStore(),
+		cache:           cache.New[string, *models.GameAnalysis](defaultAnalysisCacheSize, defaultAnalysisCacheTTL),
+		openings:        newOpeningCache(defaultOpeningCachePlies, defaultOpeningCachePositions),
+		evals:           newEvalCache(defaultEvalCachePositions),
+		defaultSettings: defaultSettings,
+		streamPlies:     defaultStreamPlies,
+		streamWindow:    defaultStreamWindow,
+	}
+}
+
+// SetStore makes the service keep its analyses in a store shared with the other services, so
+// collections, synced games and preferences see the same analyses. Call it before the service
+// stores anything.
+func (s *AnalysisService) SetStore(store *storage.MemoryStore) {
+	s.store = store
+}
+
+// NewUnavailableAnalysisService creates an analysis service without a working default engine
+// pool, for servers that start even though Stockfish isn't usable. Requests needing the engine
+// fail with an EngineUnavailableError carrying reason; stored analyses, additional engine pools
+// and everything else keep working.
+func NewUnavailableAnalysisService(defaultSettings models.EngineSettings, reason error) *AnalysisService {
+	return &AnalysisService{
+		engineErr:       reason,
 		pgnParser:       parser.NewPGNParser(),
-		cache:           make(map[string]*models.GameAnalysis),
+		store:           storage.NewMemoryStore(),
+		cache:           cache.New[string, *models.GameAnalysis](defaultAnalysisCacheSize, defaultAnalysisCacheTTL),
+		openings:        newOpeningCache(defaultOpeningCachePlies, defaultOpeningCachePositions),
+		evals:           newEvalCache(defaultEvalCachePositions),
 		defaultSettings: defaultSettings,
-		maxCacheSize:    1000, // Maximum cached analyses
-	}, nil
+		streamPlies:     defaultStreamPlies,
+		streamWindow:    defaultStreamWindow,
+	}
 }
 
-// AnalyzeGame analyzes a complete chess game
+// AnalyzeGame analyzes a complete chess game, recording the request in the audit log
 func (s *AnalysisService) AnalyzeGame(ctx context.Context, request *models.AnalysisRequest) (*models.GameAnalysis, error) {
+	started := time.Now()
+	analysis, cached, err := s.analyzeGame(ctx, request)
+	s.recordAudit(ctx, request, analysis, cached, err, started)
+	return analysis, err
+}
+
+// analyzeGame analyzes a complete chess game and reports whether it was served from the cache
+func (s *AnalysisService) analyzeGame(ctx context.Context, request *models.AnalysisRequest) (*models.GameAnalysis, bool, error) {
+	if _, ok := i18n.Normalize(request.Language); !ok {
+		return nil, false, unsupportedLanguageError()
+	}
+	if _, err := displayNotation(request.Notation); err != nil {
+		return nil, false, err
+	}
+
+	// Validate PGN before the cache lookup so strict requests can't be served a leniently validated game
+	if err := s.pgnParser.ValidatePGNMode(request.PGN, request.Validation); err != nil {
+		if stderrors.Is(err, parser.ErrUnknownValidationMode) {
+			return nil, false, errors.NewValidationError("validation", err.Error())
+		}
+		return nil, false, errors.NewValidationError("pgn", err.Error())
+	}
+	if request.Validation == models.PGNValidationSanitize {
+		// The sanitized PGN is analyzed and stored, and is what the cache is keyed on
+		sanitized := *request
+		sanitized.PGN, _ = parser.SanitizePGN(request.PGN)
+		request = &sanitized
+	}
+
 	// Check cache first
 	cacheKey := s.generateCacheKey(request)
-	if cached := s.getFromCache(cacheKey); cached != nil {
-		return cached, nil
+	if cached, ok := s.cache.Get(cacheKey); ok {
+		s.recordCacheHit(cached)
+		presented, err := s.presentAnalysis(cached, request)
+		return presented, true, err
 	}
 
-	// Validate PGN
-	if err := s.pgnParser.ValidatePGN(request.PGN); err != nil {
-		return nil, errors.NewValidationError("pgn", err.Error())
+	switch request.Mode {
+	case "", models.AnalysisModeFull, models.AnalysisModeScan:
+	default:
+		return nil, false, errors.NewValidationError("mode", fmt.Sprintf("unknown analysis mode: %s", request.Mode))
+	}
+	switch request.InlineEvals {
+	case "", models.InlineEvalsUse, models.InlineEvalsIgnore:
+	default:
+		return nil, false, errors.NewValidationError("inline_evals", fmt.Sprintf("unknown inline evals mode: %s", request.InlineEvals))
+	}
+	if _, _, err := s.requestAccuracyModel(request); err != nil {
+		return nil, false, err
+	}
+	if request.ExtendSwing < 0 {
+		return nil, false, errors.NewValidationError("extend_swing", "extend swing can't be negative")
+	}
+	if request.ExtendDepth > MaxExtendDepth {
+		return nil, false, errors.NewValidationError("extend_depth", fmt.Sprintf("extend depth can't exceed %d", MaxExtendDepth))
+	}
+	if err := validateThresholds(request.Thresholds); err != nil {
+		return nil, false, err
 	}
 
 	// Parse PGN
 	parsedGame, err := s.pgnParser.ParsePGN(request.PGN)
 	if err != nil {
-		return nil, errors.NewValidationError("pgn", fmt.Sprintf("failed to parse PGN: %v", err))
+		return nil, false, errors.NewValidationError("pgn", fmt.Sprintf("failed to parse PGN: %v", err))
+	}
+
+	// Variants whose moves don't follow the standard rules can't be replayed
+	variant := request.Settings.Variant
+	if variant == "" {
+		variant = parsedGame.Headers["variant"]
+	}
+	if !engine.IsSupportedVariant(variant) {
+		return nil, false, errors.NewUnsupportedVariantError(variant)
 	}
 
 	// Extract positions
 	if err := s.pgnParser.ExtractPositions(parsedGame); err != nil {
-		return nil, errors.NewAPIError("failed to extract positions", err)
+		return nil, false, errors.NewValidationError("pgn", fmt.Sprintf("failed to replay moves: %v", err))
 	}
 
 	// Perform analysis
-	analysis, err := s.performGameAnalysis(ctx, parsedGame, request.Settings, request.MaxMoves)
+	analysis, err := s.performGameAnalysis(ctx, parsedGame, request)
 	if err != nil {
-		return nil, errors.NewAPIError("analysis failed", err)
+		switch err.(type) {
+		case *errors.ValidationError, *errors.APIError, *errors.UnsupportedVariantError,
+			*errors.EngineUnavailableError, *errors.TimeoutError:
+			return nil, false, err
+		}
+		return nil, false, errors.NewAPIError("analysis failed", err)
 	}
 
-	// Cache the result
-	s.addToCache(cacheKey, analysis)
+	// Store the result so it can be retrieved by ID later
+	if _, err := s.store.SaveAnalysis(analysis); err != nil {
+		// The streamed moves of an analysis that can't be retrieved would never be released
+		if analysis.StreamedMoves > 0 {
+			s.store.DeleteAnalysisMoves(analysis.ID)
+		}
+		return nil, false, errors.NewStorageError("store analysis", err)
+	}
+	// Cache the result, unless it was cut back under load and a later request should get the full analysis
+	if analysis.ReducedQuality == nil {
+		s.cache.Set(cacheKey, analysis)
+	}
 
-	return analysis, nil
+	presented, err := s.presentAnalysis(analysis, request)
+	return presented, false, err
+}
+
+// presentAnalysis returns an analysis in the language and notation a request asked for
+func (s *AnalysisService) presentAnalysis(analysis *models.GameAnalysis, request *models.AnalysisRequest) (*models.GameAnalysis, error) {
+	localized, err := s.LocalizeAnalysis(analysis, request.Language)
+	if err != nil {
+		return nil, err
+	}
+	return s.NotateAnalysis(localized, request.Notation)
 }
 
 // performGameAnalysis performs the actual game analysis
-func (s *AnalysisService) performGameAnalysis(ctx context.Context, game *parser.ParsedGame, settings models.EngineSettings, maxMoves int) (*models.GameAnalysis, error) {
+func (s *AnalysisService) performGameAnalysis(ctx context.Context, game *parser.ParsedGame, request *models.AnalysisRequest) (*models.GameAnalysis, error) {
 	startTime := time.Now()
+	settings := request.Settings
+	maxMoves := request.MaxMoves
+	thresholds := requestThresholds(request)
+	modelName, accuracyModel, err := s.requestAccuracyModel(request)
+	if err != nil {
+		return nil, err
+	}
+	scan := request.Mode == models.AnalysisModeScan
+	if scan {
+		// Scan mode only needs the main line evaluation
+		settings.MultiPV = 1
+	}
+	if settings.Variant == "" {
+		settings.Variant = game.Headers["variant"]
+	}
 
-	// Get engine from pool
-	stockfishEngine := s.enginePool.GetEngine()
-	defer s.enginePool.ReturnEngine(stockfishEngine)
+	// Practical mode needs the clocks and the second-best reply of every position
+	var clocks []time.Duration
+	var baseTime time.Duration
+	practical := false
+	if request.Practical {
+		clocks, baseTime, practical = gameClocks(s.pgnParser, game)
+		if settings.MultiPV < practicalMultiPV {
+			settings.MultiPV = practicalMultiPV
+		}
+	}
+
+	// Get engine from the pool the game is routed to
+	pool, err := s.poolFor(settings.Variant, request.Profile)
+	if err != nil {
+		return nil, err
+	}
+
+	// Non-priority analyses search less while the engines are busy
+	var reduced *models.ReducedQuality
+	if !request.Priority {
+		minMultiPV := 1
+		if practical {
+			minMultiPV = practicalMultiPV
+		}
+		reduced = s.shedLoad(pool, &settings, minMultiPV)
+	}
+
+	stockfishEngine, err := pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer pool.ReturnEngine(stockfishEngine)
+
+	// Human probabilities use a Maia engine held for the rest of the analysis
+	human := s.newHumanSession()
+	defer human.release()
+
+	// Record the settings the searches run with rather than the ones requested. Scans search to
+	// depth whatever the time limit.
+	effective := settings
+	if scan {
+		effective.TimeLimit = 0
+	}
 
 	// Initialize analysis result
 	analysis := &models.GameAnalysis{
@@ -88,10 +340,13 @@ func (s *AnalysisService) performGameAnalysis(ctx context.Context, game *parser.
 		PGN:            game.PGN,
 		AnalysisTime:   startTime,
 		EngineVersion:  stockfishEngine.GetVersion(),
-		EngineSettings: settings,
+		EvalScale:      stockfishEngine.GetEvalScale().Name,
+		EngineSettings: stockfishEngine.EffectiveSettings(effective),
 		Moves:          make([]models.MoveAnalysis, 0, len(game.Moves)),
-		Accuracy:       models.GameAccuracy{},
+		Accuracy:       models.GameAccuracy{Model: modelName},
 		Summary:        models.AnalysisSummary{},
+		Language:       i18n.DefaultLanguage,
+		ReducedQuality: reduced,
 	}
 
 	// Determine how many moves to analyze
@@ -100,31 +355,207 @@ func (s *AnalysisService) performGameAnalysis(ctx context.Context, game *parser.
 		movesToAnalyze = maxMoves
 	}
 
+	// Long games write their moves to the store a window at a time, so the analysis never holds
+	// more than a window of them. Their statistics are tallied as the moves complete, and the
+	// analysis is returned without its moves, which are read from the store in ranges.
+	streamed := s.streamPlies > 0 && movesToAnalyze >= s.streamPlies
+	completed := false
+	if streamed {
+		id, err := storage.NewID()
+		if err != nil {
+			return nil, errors.NewStorageError("store analysis", err)
+		}
+		analysis.ID = id
+		analysis.Moves = make([]models.MoveAnalysis, 0, max(s.streamWindow, 1))
+		defer func() {
+			if !completed {
+				s.store.DeleteAnalysisMoves(id)
+			}
+		}()
+	}
+
+	// Positions shared with other games' openings are served from the opening cache
+	var openingMoves []string
+	var openingKey string
+	if line := openingLine(game, settings.Variant); line != nil {
+		openingMoves, openingKey = line, openingSettingsKey(settings, scan)
+	}
+
+	// Evaluations annotated in the PGN, e.g. by Lichess, stand in for searches they're deep enough
+	// for. They don't record the second-best reply practical mode needs, and are only trusted when
+	// they line up with the moves.
+	var inlineEvals []parser.MoveEval
+	if request.InlineEvals != models.InlineEvalsIgnore && !practical && s.pgnParser.HasMoveEvals(game.PGN) {
+		if evals := s.pgnParser.ExtractMoveEvals(game.PGN); len(evals) == len(game.Moves) {
+			inlineEvals = evals
+		}
+	}
+
+	// Board annotations are drawn on standard boards only
+	standardBoard := engine.IsStandardVariant(settings.Variant)
+	startFEN := board.StartFEN
+	if fen := game.Headers["fen"]; fen != "" {
+		startFEN = fen
+	}
+
 	// Analyze each move
+	var openingHits, inlineHits int
 	var totalNodes int64
 	var totalTime int64
 	var whiteBlunders, blackBlunders int
 	var whiteMistakes, blackMistakes int
 	var whiteInaccuracies, blackInaccuracies int
 	var whiteBestMoves, blackBestMoves int
+	var peakHashFull, engineErrors int
+	var accuracy accuracyTally
+	points := newExpectedPointsTally()
+	var last models.MoveAnalysis // Latest analyzed ply, kept when a streamed window is written out
+	hasLast := false
 
 	for i := 0; i < movesToAnalyze; i++ {
+		if ctx.Err() != nil {
+			return nil, errors.NewTimeoutError("analyze game", ctx.Err())
+		}
 		move := game.Moves[i]
 
 		// Analyze the position after this move
-		result, err := stockfishEngine.AnalyzePosition(ctx, move.FEN, settings)
-		if err != nil {
-			// Continue with next move if analysis fails
-			continue
+		var result *models.AnalysisResult
+		var err error
+		cached, annotated := false, false
+		if openingMoves != nil {
+			result, cached = s.openings.get(openingMoves[:i+1], openingKey)
+		}
+		if !cached && i < len(inlineEvals) && inlineEvalUsable(inlineEvals[i], settings) {
+			result, annotated = inlineEvalResult(move.FEN, inlineEvals[i]), true
+		}
+		if !cached && !annotated {
+			if scan {
+				result, err = stockfishEngine.ScanPosition(ctx, move.FEN, settings, engine.DefaultEarlyStop())
+			} else {
+				result, err = stockfishEngine.AnalyzePosition(ctx, move.FEN, settings)
+			}
+			if err != nil {
+				// Continue with next move if analysis fails
+				engineErrors++
+				continue
+			}
+			if result.ScoreBound != "" {
+				result = s.resolveScoreBound(ctx, stockfishEngine, move.FEN, settings, result)
+			}
+			if openingMoves != nil && result.ScoreBound == "" {
+				s.openings.put(openingMoves[:i+1], openingKey, result)
+			}
+		}
+		if result.HashFull > peakHashFull {
+			peakHashFull = result.HashFull
+		}
+
+		// The previous ply's evaluation is the evaluation before this move; the game starts level
+		var prev *models.MoveAnalysis
+		if hasLast && last.MoveNumber == i {
+			prev = &last
+		}
+		before := result.Evaluation // Unknown after a skipped ply, so no loss is charged
+		if prev != nil {
+			before = prev.Evaluation
+		} else if i == 0 {
+			before = 0
+		}
+
+		// A sharp swing found at a shallow depth is often a horizon effect, so the ply is searched
+		// deeper before it's classified
+		extended := false
+		if request.AutoExtend && !annotated {
+			result, extended = extendSwing(ctx, stockfishEngine.ExtendPosition, move.FEN, settings, result, before, request)
 		}
 
 		// Create move analysis
-		moveAnalysis := s.createMoveAnalysis(move, result, i+1)
+		moveAnalysis := s.createMoveAnalysis(move, result, i+1, before, accuracyModel, thresholds)
+
+		// Re-check flagged moves at a higher depth to filter out shallow-search noise
+		if request.Verify && (moveAnalysis.Blunder || moveAnalysis.Mistake) {
+			verified, err := s.verifyMove(ctx, stockfishEngine.AnalyzePosition, move, moveAnalysis, i+1, before, accuracyModel, settings, request.VerifyDepth, thresholds)
+			if err == nil {
+				analysis.Summary.VerifiedMoves++
+				if verified.Blunder != moveAnalysis.Blunder || verified.Mistake != moveAnalysis.Mistake {
+					analysis.Summary.ReclassifiedMoves++
+				}
+				moveAnalysis = verified
+			}
+		}
+		moveAnalysis.Source = models.EvalSourceEngine
+		if annotated && !moveAnalysis.Verified {
+			moveAnalysis.Source = models.EvalSourcePGN
+			// An annotation without a depth isn't known to be as deep as the requested search
+			if inlineEvals[i].Depth == 0 {
+				moveAnalysis.Unverified = true
+				analysis.Summary.UnverifiedMoves++
+			}
+		}
+		if extended {
+			moveAnalysis.Extended = true
+			analysis.Summary.ExtendedMoves++
+		}
+
+		// Weigh missed opportunities by how likely a human would have found the best move
+		if prev != nil && prev.BestMove != "" {
+			probability, err := human.probability(ctx, game.Moves[i-1].FEN, prev.BestMove, moverRating(request.PlayerRating, game.Headers, move.Color))
+			if err == nil {
+				moveAnalysis.HumanProbability = probability
+			}
+			if isMiss(prev, &moveAnalysis, probability, err == nil) {
+				moveAnalysis.Miss = true
+				analysis.Summary.Misses++
+			}
+		}
+
+		// Weigh the evaluation with both players' clocks
+		if practical {
+			moveAnalysis.Practical = assessPractical(moveAnalysis, result, i+1, clocks, baseTime)
+			if moveAnalysis.Practical.Risk == models.RiskHigh {
+				analysis.Summary.RiskyMoves++
+			}
+		}
+
+		// Suggest the move a player of the requested rating should find
+		if request.PlayerRating > 0 {
+			if humanMove, _, err := s.humanMove(ctx, stockfishEngine, move.FEN, request.PlayerRating, settings); err == nil {
+				moveAnalysis.HumanMove = humanMove
+			}
+		}
+
+		// Draw the key moments for board UIs
+		if standardBoard {
+			beforeFEN, bestMove := startFEN, ""
+			if i > 0 {
+				beforeFEN = game.Moves[i-1].FEN
+			}
+			if prev != nil {
+				bestMove = prev.BestMove
+			}
+			moveAnalysis.Board = annotateBoard(beforeFEN, &moveAnalysis, bestMove)
+		}
+
+		points.add(analysis, &moveAnalysis)
+		accuracy.add(&moveAnalysis)
+		last, hasLast = moveAnalysis, true
 		analysis.Moves = append(analysis.Moves, moveAnalysis)
+		if streamed && len(analysis.Moves) >= s.streamWindow {
+			s.store.AppendAnalysisMoves(analysis.ID, analysis.Moves)
+			analysis.StreamedMoves += len(analysis.Moves)
+			analysis.Moves = analysis.Moves[:0]
+		}
 
-		// Update statistics
-		totalNodes += result.Nodes
-		totalTime += result.Time
+		// Update statistics; cached and annotated positions cost no engine time
+		switch {
+		case cached:
+			openingHits++
+		case annotated:
+			inlineHits++
+		default:
+			totalNodes += result.Nodes
+			totalTime += result.Time
+		}
 
 		// Count move quality
 		if move.Color == "white" {
@@ -150,23 +581,72 @@ func (s *AnalysisService) performGameAnalysis(ctx context.Context, game *parser.
 		}
 	}
 
+	if streamed {
+		s.store.AppendAnalysisMoves(analysis.ID, analysis.Moves)
+		analysis.StreamedMoves += len(analysis.Moves)
+		analysis.Moves = nil
+	}
+
+	analysis.Summary.EndgameType, analysis.Summary.EndgameStart = classifyGameEndgame(game.Moves)
+
+	// The final evaluation is only known when every move was analyzed
+	analysis.Summary.ResultReason = parser.TerminationReason(game.Headers["termination"])
+	if hasLast && movesToAnalyze == len(game.Moves) {
+		analysis.Summary.LostOnTimeWinning = lostOnTimeWinning(analysis.Summary.ResultReason, game.Result, last.Evaluation)
+	}
+
 	// Calculate final statistics
-	s.calculateGameStatistics(analysis, totalNodes, totalTime,
+	s.calculateGameStatistics(analysis, accuracy, totalNodes, totalTime,
 		whiteBlunders, blackBlunders, whiteMistakes, blackMistakes,
 		whiteInaccuracies, blackInaccuracies, whiteBestMoves, blackBestMoves)
 
+	analysis.Cost = &models.AnalysisCost{
+		EngineTime:   totalTime,
+		WallClock:    time.Since(startTime).Milliseconds(),
+		Nodes:        totalNodes,
+		Positions:    movesToAnalyze - openingHits - inlineHits,
+		PeakHashMB:   hashUsageMB(peakHashFull, stockfishEngine.GetSettings().HashSize),
+		EngineErrors: engineErrors,
+		OpeningHits:  openingHits,
+		InlineEvals:  inlineHits,
+	}
+	s.metrics.recordAnalysis(analysis.Cost)
+
+	completed = true
 	return analysis, nil
 }
 
-// createMoveAnalysis creates a MoveAnalysis from a ParsedMove and AnalysisResult
-func (s *AnalysisService) createMoveAnalysis(move parser.ParsedMove, result *models.AnalysisResult, moveNumber int) models.MoveAnalysis {
-	// Calculate move accuracy based on evaluation
-	accuracy := s.calculateMoveAccuracy(result.Evaluation)
+// inlineEvalUsable reports whether an annotated evaluation can stand in for the requested search:
+// annotations with a depth must be at least as deep as the requested depth. Annotations without
+// one are used, and their moves are marked unverified.
+func inlineEvalUsable(eval parser.MoveEval, settings models.EngineSettings) bool {
+	return eval.HasEval && (eval.Depth == 0 || eval.Depth >= settings.Depth)
+}
 
-	// Determine move quality
-	blunder := accuracy < 50
-	mistake := accuracy >= 50 && accuracy < 80
-	inaccuracy := accuracy >= 80 && accuracy < 90
+// inlineEvalResult turns an annotated evaluation into the result of a search of the position
+func inlineEvalResult(fen string, eval parser.MoveEval) *models.AnalysisResult {
+	return &models.AnalysisResult{
+		Position:   fen,
+		MoveNumber: eval.Ply,
+		Evaluation: eval.Evaluation,
+		Depth:      eval.Depth,
+	}
+}
+
+// createMoveAnalysis creates a MoveAnalysis from a ParsedMove and AnalysisResult,
+// given the White-relative evaluation before the move
+func (s *AnalysisService) createMoveAnalysis(move parser.ParsedMove, result *models.AnalysisResult, moveNumber int,
+	before float64, accuracyModel AccuracyModel, thresholds models.ClassificationThresholds) models.MoveAnalysis {
+	// Score the move with the requested accuracy model
+	accuracy := accuracyModel.MoveAccuracy(moveEvaluation(before, result.Evaluation, moveColor(move.FEN, moveNumber)))
+
+	// Determine move quality; a bound says too little about the loss to classify the move
+	blunder := accuracy < thresholds.Blunder
+	mistake := accuracy >= thresholds.Blunder && accuracy < thresholds.Mistake
+	inaccuracy := accuracy >= thresholds.Mistake && accuracy < thresholds.Inaccuracy
+	if result.ScoreBound != "" {
+		blunder, mistake, inaccuracy = false, false, false
+	}
 
 	// Get alternative moves (simplified for now)
 	alternatives := make([]models.MoveAlternative, 0)
@@ -182,54 +662,160 @@ func (s *AnalysisService) createMoveAnalysis(move parser.ParsedMove, result *mod
 	return models.MoveAnalysis{
 		Move:         move.Move,
 		MoveNumber:   moveNumber,
+		FEN:          move.FEN,
 		Evaluation:   result.Evaluation,
 		Accuracy:     accuracy,
 		Blunder:      blunder,
 		Mistake:      mistake,
 		Inaccuracy:   inaccuracy,
 		BestMove:     result.BestMove,
+		BestLine:     result.PrincipalVariation,
 		Alternatives: alternatives,
+		WDL:          result.WDL,
+		ScoreBound:   result.ScoreBound,
 	}
 }
 
-// calculateMoveAccuracy calculates the accuracy percentage for a move
-func (s *AnalysisService) calculateMoveAccuracy(evaluation float64) float64 {
-	// This is a simplified accuracy calculation
-	// In practice, you'd compare against the best move evaluation
-	if evaluation >= 0 {
-		return 100.0 - (evaluation * 10) // Penalize positive evaluations less
-	} else {
-		return 100.0 + (evaluation * 15) // Penalize negative evaluations more
+// resolveScoreBound re-searches a position whose search ended with only a bound on the
+// evaluation, as fast time-limited searches can after a fail-high or fail-low. The depth reached
+// is searched to completion, which always ends with an exact score; the bound is kept if that fails.
+func (s *AnalysisService) resolveScoreBound(ctx context.Context, stockfishEngine *engine.StockfishEngine, fen string,
+	settings models.EngineSettings, bounded *models.AnalysisResult) *models.AnalysisResult {
+	exactSettings := settings
+	exactSettings.Depth = max(bounded.Depth, 1)
+	exactSettings.TimeLimit = 0
+
+	result, err := stockfishEngine.AnalyzePosition(ctx, fen, exactSettings)
+	if err != nil || result.ScoreBound != "" {
+		return bounded
+	}
+	result.Nodes += bounded.Nodes
+	result.Time += bounded.Time
+	return result
+}
+
+// extendSearch searches a position to the settings' depth, giving up deepening after maxTime milliseconds
+type extendSearch func(ctx context.Context, fen string, settings models.EngineSettings, maxTime int) (*models.AnalysisResult, error)
+
+// extendSwing re-searches a ply deeper when its evaluation swung more than the request allows from
+// the previous ply's, and reports whether the deeper result replaced the original. Forced mates
+// and bounds are left as they are, and so are plies already searched to the extension depth.
+func extendSwing(ctx context.Context, search extendSearch, fen string, settings models.EngineSettings,
+	result *models.AnalysisResult, before float64, request *models.AnalysisRequest) (*models.AnalysisResult, bool) {
+	swing := request.ExtendSwing
+	if swing == 0 {
+		swing = defaultExtendSwing
+	}
+	if result.ScoreBound != "" || math.Abs(result.Evaluation-before) <= swing ||
+		math.Abs(before) >= mateEvaluation || math.Abs(result.Evaluation) >= mateEvaluation {
+		return result, false
+	}
+
+	depth := request.ExtendDepth
+	if depth == 0 {
+		depth = result.Depth + defaultExtendDepthIncrease
+	}
+	depth = min(depth, MaxExtendDepth)
+	if result.Depth >= depth {
+		return result, false
+	}
+
+	// Search to the depth rather than for the analysis' time limit, which the shallow search
+	// already ran into, but within a time bound of its own
+	extendedSettings := settings
+	extendedSettings.Depth = depth
+	extendedSettings.TimeLimit = 0
+	extended, err := search(ctx, fen, extendedSettings, extendTimeLimit)
+	if err != nil || extended.ScoreBound != "" {
+		return result, false
+	}
+	extended.Nodes += result.Nodes
+	extended.Time += result.Time
+	return extended, true
+}
+
+// verifySearch searches a position to the settings' depth
+type verifySearch func(ctx context.Context, fen string, settings models.EngineSettings) (*models.AnalysisResult, error)
+
+// verifyMove re-evaluates a flagged move at a higher depth and classifies it again. The original
+// analysis is returned with an error when the move leads to an illegal position or can't be
+// searched, or when the deeper search only bounds the evaluation, which is too ambiguous to
+// overturn a classification.
+func (s *AnalysisService) verifyMove(ctx context.Context, search verifySearch, move parser.ParsedMove,
+	original models.MoveAnalysis, moveNumber int, before float64, accuracyModel AccuracyModel, settings models.EngineSettings,
+	verifyDepth int, thresholds models.ClassificationThresholds) (models.MoveAnalysis, error) {
+
+	// A position the move can't have reached is never sent to the engine
+	position, err := board.FromFEN(move.FEN)
+	if err == nil {
+		err = position.Validate()
+	}
+	if err != nil {
+		return original, errors.NewValidationError("fen", fmt.Sprintf("move %s: %v", move.Move, err))
 	}
+	if verifyDepth <= settings.Depth {
+		verifyDepth = settings.Depth + defaultVerifyDepthIncrease
+	}
+
+	// Search to a fixed depth so the verification isn't cut short by the time limit
+	verifySettings := settings
+	verifySettings.Depth = verifyDepth
+	verifySettings.TimeLimit = 0
+
+	result, err := search(ctx, move.FEN, verifySettings)
+	if err != nil {
+		return original, err
+	}
+	if result.ScoreBound != "" {
+		return original, fmt.Errorf("verification of %s ended with only a %s bound", move.Move, result.ScoreBound)
+	}
+
+	verified := s.createMoveAnalysis(move, result, moveNumber, before, accuracyModel, thresholds)
+	verified.Verified = true
+	return verified, nil
 }
 
-// calculateGameStatistics calculates overall game statistics
-func (s *AnalysisService) calculateGameStatistics(analysis *models.GameAnalysis, totalNodes, totalTime int64,
+// accuracyTally sums move accuracies by color, so game accuracy can be computed without holding
+// every move
+type accuracyTally struct {
+	whiteMoves, blackMoves int
+	whiteSum, blackSum     float64
+}
+
+// tallyAccuracy sums the accuracies of a list of moves
+func tallyAccuracy(moves []models.MoveAnalysis) accuracyTally {
+	var tally accuracyTally
+	for i := range moves {
+		tally.add(&moves[i])
+	}
+	return tally
+}
+
+// add counts one analyzed move
+func (t *accuracyTally) add(move *models.MoveAnalysis) {
+	if moveColor(move.FEN, move.MoveNumber) == "white" {
+		t.whiteMoves++
+		t.whiteSum += move.Accuracy
+	} else { // Black moves
+		t.blackMoves++
+		t.blackSum += move.Accuracy
+	}
+}
+
+// calculateGameStatistics calculates overall game statistics from the tallied move accuracies.
+// Expected points are applied separately, as the moves are analyzed.
+func (s *AnalysisService) calculateGameStatistics(analysis *models.GameAnalysis, tally accuracyTally, totalNodes, totalTime int64,
 	whiteBlunders, blackBlunders, whiteMistakes, blackMistakes, whiteInaccuracies, blackInaccuracies, whiteBestMoves, blackBestMoves int) {
 
-	totalMoves := len(analysis.Moves)
+	totalMoves := tally.whiteMoves + tally.blackMoves
 	if totalMoves == 0 {
 		return
 	}
 
 	// Calculate accuracies
-	whiteMoves := 0
-	blackMoves := 0
-	var whiteAccuracySum, blackAccuracySum float64
-
-	for _, move := range analysis.Moves {
-		if move.MoveNumber%2 == 1 { // White moves
-			whiteMoves++
-			whiteAccuracySum += move.Accuracy
-		} else { // Black moves
-			blackMoves++
-			blackAccuracySum += move.Accuracy
-		}
-	}
-
-	analysis.Accuracy.WhiteAccuracy = whiteAccuracySum / float64(whiteMoves)
-	analysis.Accuracy.BlackAccuracy = blackAccuracySum / float64(blackMoves)
-	analysis.Accuracy.AverageAccuracy = (whiteAccuracySum + blackAccuracySum) / float64(totalMoves)
+	analysis.Accuracy.WhiteAccuracy = tally.whiteSum / float64(tally.whiteMoves)
+	analysis.Accuracy.BlackAccuracy = tally.blackSum / float64(tally.blackMoves)
+	analysis.Accuracy.AverageAccuracy = (tally.whiteSum + tally.blackSum) / float64(totalMoves)
 	analysis.Accuracy.Blunders = whiteBlunders + blackBlunders
 	analysis.Accuracy.Mistakes = whiteMistakes + blackMistakes
 	analysis.Accuracy.Inaccuracies = whiteInaccuracies + blackInaccuracies
@@ -244,6 +830,28 @@ func (s *AnalysisService) calculateGameStatistics(analysis *models.GameAnalysis,
 	analysis.Summary.Recommendations = s.generateRecommendations(analysis)
 }
 
+// lostOnTimeWinning returns the side that lost on time although the final evaluation, from
+// White's point of view, was winning for it, or "" when the game didn't end that way
+func lostOnTimeWinning(reason models.ResultReason, result string, finalEval float64) string {
+	if reason != models.ResultReasonTimeout {
+		return ""
+	}
+
+	var loser string
+	switch result {
+	case "1-0":
+		loser = "black"
+	case "0-1":
+		loser = "white"
+	default:
+		return ""
+	}
+	if moverEval(finalEval, loser) < winningAdvantage {
+		return ""
+	}
+	return loser
+}
+
 // determineGamePhase determines the game phase based on move count
 func (s *AnalysisService) determineGamePhase(moveCount int) string {
 	if moveCount <= 20 {
@@ -266,88 +874,318 @@ func (s *AnalysisService) determineComplexity(accuracy float64) string {
 	}
 }
 
-// generateRecommendations generates analysis recommendations
-func (s *AnalysisService) generateRecommendations(analysis *models.GameAnalysis) []string {
-	var recommendations []string
+// generateRecommendations generates analysis recommendations in the analysis language
+func (s *AnalysisService) generateRecommendations(analysis *models.GameAnalysis) []models.Recommendation {
+	var recommendations []models.Recommendation
+	language := analysis.Language
+	accuracy := analysis.Accuracy.AverageAccuracy
 
 	if analysis.Accuracy.Blunders > 5 {
-		recommendations = append(recommendations, "Consider spending more time on tactical calculations to reduce blunders")
+		recommendations = append(recommendations, models.Recommendation{
+			Code:     models.RecommendationBlunders,
+			Category: models.CategoryTactics,
+			Severity: models.SeverityHigh,
+			Params:   map[string]interface{}{"blunders": analysis.Accuracy.Blunders},
+			Text:     i18n.Translate(language, i18n.RecommendBlunders),
+		})
 	}
 
 	if analysis.Accuracy.Mistakes > 10 {
-		recommendations = append(recommendations, "Focus on positional understanding to minimize mistakes")
+		recommendations = append(recommendations, models.Recommendation{
+			Code:     models.RecommendationMistakes,
+			Category: models.CategoryPositional,
+			Severity: models.SeverityMedium,
+			Params:   map[string]interface{}{"mistakes": analysis.Accuracy.Mistakes},
+			Text:     i18n.Translate(language, i18n.RecommendMistakes),
+		})
+	}
+
+	if accuracy < 80 {
+		recommendations = append(recommendations, models.Recommendation{
+			Code:     models.RecommendationAccuracy,
+			Category: models.CategoryAccuracy,
+			Severity: models.SeverityMedium,
+			Params:   map[string]interface{}{"accuracy": accuracy},
+			Text:     i18n.Translate(language, i18n.RecommendAccuracy),
+		})
 	}
 
-	if analysis.Accuracy.AverageAccuracy < 80 {
-		recommendations = append(recommendations, "Overall game accuracy could be improved with more careful move selection")
+	if analysis.Summary.GamePhase == "opening" && accuracy < 85 {
+		recommendations = append(recommendations, models.Recommendation{
+			Code:     models.RecommendationOpening,
+			Category: models.CategoryOpening,
+			Severity: models.SeverityLow,
+			Params:   map[string]interface{}{"accuracy": accuracy},
+			Text:     i18n.Translate(language, i18n.RecommendOpening),
+		})
 	}
 
-	if analysis.Summary.GamePhase == "opening" && analysis.Accuracy.AverageAccuracy < 85 {
-		recommendations = append(recommendations, "Study opening theory to improve early game play")
+	if findable := countFindableMisses(analysis); findable >= 2 {
+		recommendations = append(recommendations, models.Recommendation{
+			Code:     models.RecommendationFindableMisses,
+			Category: models.CategoryTactics,
+			Severity: models.SeverityMedium,
+			Params:   map[string]interface{}{"misses": findable},
+			Text:     i18n.Translate(language, i18n.RecommendFindableMisses, findable),
+		})
+	} else if analysis.Summary.Misses >= 3 {
+		recommendations = append(recommendations, models.Recommendation{
+			Code:     models.RecommendationMisses,
+			Category: models.CategoryTactics,
+			Severity: models.SeverityLow,
+			Params:   map[string]interface{}{"misses": analysis.Summary.Misses},
+			Text:     i18n.Translate(language, i18n.RecommendMisses),
+		})
 	}
 
 	return recommendations
 }
 
+// LocalizeAnalysis returns a copy of an analysis with its generated text in the given language.
+// Analyses are cached and stored in English, so any language can be served without re-analyzing.
+func (s *AnalysisService) LocalizeAnalysis(analysis *models.GameAnalysis, language string) (*models.GameAnalysis, error) {
+	language, ok := i18n.Normalize(language)
+	if !ok {
+		return nil, unsupportedLanguageError()
+	}
+	if language == analysis.Language {
+		return analysis, nil
+	}
+
+	localized := *analysis
+	localized.Language = language
+	localized.Summary.Recommendations = s.generateRecommendations(&localized)
+	return &localized, nil
+}
+
+// unsupportedLanguageError lists the supported languages
+func unsupportedLanguageError() error {
+	return errors.NewValidationError("language", fmt.Sprintf("unsupported language; supported languages: %s", strings.Join(i18n.Languages(), ", ")))
+}
+
 // generateCacheKey generates a cache key for the analysis request
 func (s *AnalysisService) generateCacheKey(request *models.AnalysisRequest) string {
-	return fmt.Sprintf("%s_%d_%d_%d",
+	model, _, _ := s.requestAccuracyModel(request)
+	return fmt.Sprintf("%s_%s_%s_%s_%s_%d_%d_%d_%d_%d_%s_%t_%d_%s_%d_%v_%t_%d_%t_%d_%g_%t_%s",
 		request.PGN,
+		request.Mode,
+		request.InlineEvals,
+		request.Profile,
+		request.Settings.Variant,
+		request.PlayerRating,
 		request.Settings.Depth,
 		request.Settings.TimeLimit,
-		request.MaxMoves)
+		request.Settings.MultiPV,
+		request.Settings.SkillLevel,
+		contemptKey(request.Settings.Contempt),
+		request.Settings.LimitStrength,
+		request.Settings.Elo,
+		request.Settings.EvalFile,
+		request.MaxMoves,
+		requestThresholds(request),
+		request.Verify,
+		request.VerifyDepth,
+		request.AutoExtend,
+		request.ExtendDepth,
+		request.ExtendSwing,
+		request.Practical,
+		model)
 }
 
-// getFromCache retrieves analysis from cache
-func (s *AnalysisService) getFromCache(key string) *models.GameAnalysis {
-	s.cacheMutex.RLock()
-	defer s.cacheMutex.RUnlock()
-	return s.cache[key]
+// ApplyDefaultSettings fills the engine settings a request left out, taking threads and hash
+// from the service's defaults so auto-tuned engines keep their sizing
+func (s *AnalysisService) ApplyDefaultSettings(settings *models.EngineSettings) {
+	s.ApplyResourceSettings(settings)
+	ApplyDefaultSettings(settings)
 }
 
-// addToCache adds analysis to cache
-func (s *AnalysisService) addToCache(key string, analysis *models.GameAnalysis) {
-	s.cacheMutex.Lock()
-	defer s.cacheMutex.Unlock()
+// ApplyResourceSettings fills the threads and hash a request left out from the service's defaults,
+// leaving the search limits to the caller. Requests may lower the threads and hash but never raise
+// them above that sizing, since the engines are shared.
+func (s *AnalysisService) ApplyResourceSettings(settings *models.EngineSettings) {
+	maxThreads, maxHashSize := s.MaxResources()
+	if settings.Threads == 0 || (maxThreads > 0 && settings.Threads > maxThreads) {
+		settings.Threads = maxThreads
+	}
+	if settings.HashSize == 0 || (maxHashSize > 0 && settings.HashSize > maxHashSize) {
+		settings.HashSize = maxHashSize
+	}
+	if settings.Threads == 0 {
+		settings.Threads = 4
+	}
+	if settings.HashSize == 0 {
+		settings.HashSize = 128
+	}
+}
 
-	// Simple cache eviction if cache is full
-	if len(s.cache) >= s.maxCacheSize {
-		// Remove oldest entry (simplified)
-		for k := range s.cache {
-			delete(s.cache, k)
-			break
-		}
+// MaxResources returns the largest threads and hash size (MB) a request may use: the engines'
+// configured or auto-tuned sizing. 0 means unbounded.
+func (s *AnalysisService) MaxResources() (threads, hashSize int) {
+	return s.defaultSettings.Threads, s.defaultSettings.HashSize
+}
+
+// ApplyDefaultSettings fills the engine settings a game analysis request left out
+func ApplyDefaultSettings(settings *models.EngineSettings) {
+	if settings.Depth == 0 {
+		settings.Depth = 15
+	}
+	if settings.TimeLimit == 0 {
+		settings.TimeLimit = 5000
+	}
+	if settings.Threads == 0 {
+		settings.Threads = 4
+	}
+	if settings.HashSize == 0 {
+		settings.HashSize = 128
+	}
+}
+
+// requestThresholds returns the classification thresholds of a request, or the defaults
+func requestThresholds(request *models.AnalysisRequest) models.ClassificationThresholds {
+	if request.Thresholds != nil {
+		return *request.Thresholds
+	}
+	return models.DefaultClassificationThresholds()
+}
+
+// NormalizePGN sanitizes a PGN without analyzing it, reporting the fixes and whether the
+// sanitized PGN can be analyzed
+func (s *AnalysisService) NormalizePGN(request *models.PGNNormalizeRequest) (*models.PGNNormalization, error) {
+	if strings.TrimSpace(request.PGN) == "" {
+		return nil, errors.NewValidationError("pgn", "PGN is required")
 	}
 
-	s.cache[key] = analysis
+	pgn, fixes := parser.SanitizePGN(request.PGN)
+	if fixes == nil {
+		fixes = []string{}
+	}
+	normalization := &models.PGNNormalization{PGN: pgn, Fixes: fixes, Valid: true}
+	if err := s.pgnParser.ValidatePGNMode(pgn, models.PGNValidationLenient); err != nil {
+		normalization.Valid = false
+		normalization.Error = err.Error()
+	}
+	return normalization, nil
+}
+
+// analyzeGameMoves analyzes a game like AnalyzeGame for callers that go through every move: the
+// moves of a long game, which AnalyzeGame leaves in the store, are read back
+func (s *AnalysisService) analyzeGameMoves(ctx context.Context, request *models.AnalysisRequest) (*models.GameAnalysis, error) {
+	analysis, err := s.AnalyzeGame(ctx, request)
+	if err != nil || analysis.StreamedMoves == 0 {
+		return analysis, err
+	}
+	return s.GetAnalysis(analysis.ID)
+}
+
+// GetAnalysis retrieves a stored analysis by ID
+func (s *AnalysisService) GetAnalysis(analysisID string) (*models.GameAnalysis, error) {
+	return s.store.GetAnalysis(analysisID)
+}
+
+// ListAnalyses returns a page of the stored analyses, oldest first, without their moves
+func (s *AnalysisService) ListAnalyses(request models.PageRequest) (*models.Page[models.AnalysisListing], error) {
+	page, err := s.store.QueryAnalyses(request)
+	if err != nil {
+		return nil, err
+	}
+
+	listings := &models.Page[models.AnalysisListing]{
+		Items:         make([]models.AnalysisListing, 0, len(page.Items)),
+		NextCursor:    page.NextCursor,
+		PrevCursor:    page.PrevCursor,
+		TotalEstimate: page.TotalEstimate,
+	}
+	for _, analysis := range page.Items {
+		listings.Items = append(listings.Items, models.AnalysisListing{
+			ID:             analysis.ID,
+			GameID:         analysis.GameID,
+			AnalysisTime:   analysis.AnalysisTime,
+			EngineVersion:  analysis.EngineVersion,
+			EngineSettings: analysis.EngineSettings,
+			Accuracy:       analysis.Accuracy,
+			TotalMoves:     analysis.Summary.TotalMoves,
+			ReducedQuality: analysis.ReducedQuality,
+		})
+	}
+	return listings, nil
 }
 
 // AnalyzePosition analyzes a single chess position
 func (s *AnalysisService) AnalyzePosition(ctx context.Context, fen string, settings models.EngineSettings) (*models.AnalysisResult, error) {
-	stockfishEngine := s.enginePool.GetEngine()
-	defer s.enginePool.ReturnEngine(stockfishEngine)
+	pool, err := s.poolFor(settings.Variant, "")
+	if err != nil {
+		return nil, err
+	}
 
-	return stockfishEngine.AnalyzePosition(ctx, fen, settings)
+	// Serve positions already searched at least as deep from the evaluation cache
+	key := evalCacheKey(fen, pool.Version, settings)
+	if cached, deepEnough := s.evals.lookup(key, settings.Depth); deepEnough {
+		return cached, nil
+	}
+	return s.searchPosition(ctx, pool, key, fen, settings)
 }
 
 // GetEngineStatus returns the status of engines in the pool
 func (s *AnalysisService) GetEngineStatus() map[string]interface{} {
-	return map[string]interface{}{
-		"total_engines":     len(s.enginePool.Engines),
-		"available_engines": len(s.enginePool.Available),
-		"cache_size":        len(s.cache),
-		"max_cache_size":    s.maxCacheSize,
+	status := map[string]interface{}{
+		"total_engines":      0,
+		"available_engines":  0,
+		"analysis_available": s.EngineAvailable(),
+		"pools":              s.enginePoolStatuses(),
+		"cache_size":         s.cache.Len(),
+		"max_cache_size":     s.cache.MaxSize(),
+		"opening_cache":      s.openings.stats(),
+		"eval_cache":         s.evals.stats(),
+	}
+	if s.loadShedding.Enabled() {
+		status["load_shedding"] = s.loadShedding
+	}
+	if s.enginePool != nil {
+		status["total_engines"] = s.enginePool.Size()
+		status["available_engines"] = s.enginePool.Idle()
+		status["running_engines"] = s.enginePool.Running()
+	}
+	if s.engineErr != nil {
+		status["engine_error"] = s.engineErr.Error()
+	}
+	if s.engineTuning != nil {
+		status["auto_tune"] = s.engineTuning
 	}
+	return status
 }
 
-// ClearCache clears the analysis cache
+// ClearCache clears the analysis and position evaluation caches
 func (s *AnalysisService) ClearCache() {
-	s.cacheMutex.Lock()
-	defer s.cacheMutex.Unlock()
-	s.cache = make(map[string]*models.GameAnalysis)
+	s.cache.Clear()
+	s.evals.results.Clear()
+}
+
+// SetCacheOptions replaces the analysis cache with one holding at most maxSize analyses for at
+// most ttl each. A maxSize of 0 disables caching; a ttl of 0 keeps analyses until evicted.
+func (s *AnalysisService) SetCacheOptions(maxSize int, ttl time.Duration) {
+	s.cache = cache.New[string, *models.GameAnalysis](maxSize, ttl)
+}
+
+// SetStreamingOptions sets how long games are analyzed: games with at least minPlies plies to
+// analyze write their moves to the store window moves at a time instead of holding them all.
+// A minPlies of 0 keeps every analysis in memory.
+func (s *AnalysisService) SetStreamingOptions(minPlies, window int) {
+	s.streamPlies = minPlies
+	s.streamWindow = max(window, 1)
 }
 
 // Close shuts down the analysis service
 func (s *AnalysisService) Close() error {
+	if s.humanModel != nil {
+		s.humanModel.Close()
+	}
+	s.openings.stop()
+	if err := s.openings.save(); err != nil {
+		log.Printf("Failed to save opening cache: %v", err)
+	}
+	s.closePartitions()
+	if s.enginePool == nil {
+		return nil
+	}
 	return s.enginePool.Close()
 }
@@ -7,6 +7,7 @@ import (
 
 	"github.com/pedrampdd/ChessAnalyser/internal/models"
 	"github.com/pedrampdd/ChessAnalyser/internal/service"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
 )
 
 func TestAnalysisService_AnalyzeGame(t *testing.T) {
@@ -148,3 +149,81 @@ func TestAnalysisService_ClearCache(t *testing.T) {
 		t.Errorf("Expected cache_size to be 0 after clear, got: %v", status["cache_size"])
 	}
 }
+
+func TestAnalysisService_AnalyzeGameRejectsNegativeSwing(t *testing.T) {
+	analysisService := service.NewUnavailableAnalysisService(models.EngineSettings{}, nil)
+
+	_, err := analysisService.AnalyzeGame(context.Background(), &models.AnalysisRequest{
+		PGN:         "1. e4 e5 *",
+		AutoExtend:  true,
+		ExtendSwing: -1,
+	})
+	var validation *errors.ValidationError
+	if !errors.As(err, &validation) || validation.Field != "extend_swing" {
+		t.Errorf("Expected a validation error for extend_swing, got %v", err)
+	}
+}
+
+func TestAnalysisService_AnalyzeGameRejectsDeepExtension(t *testing.T) {
+	analysisService := service.NewUnavailableAnalysisService(models.EngineSettings{}, nil)
+
+	_, err := analysisService.AnalyzeGame(context.Background(), &models.AnalysisRequest{
+		PGN:         "1. e4 e5 *",
+		AutoExtend:  true,
+		ExtendDepth: service.MaxExtendDepth + 1,
+	})
+	var validation *errors.ValidationError
+	if !errors.As(err, &validation) || validation.Field != "extend_depth" {
+		t.Errorf("Expected a validation error for extend_depth, got %v", err)
+	}
+}
+
+func TestAnalysisService_AnalyzeGameRejectsUnorderedThresholds(t *testing.T) {
+	analysisService := service.NewUnavailableAnalysisService(models.EngineSettings{}, nil)
+
+	_, err := analysisService.AnalyzeGame(context.Background(), &models.AnalysisRequest{
+		PGN:        "1. e4 e5 *",
+		Thresholds: &models.ClassificationThresholds{Blunder: 90, Mistake: 80, Inaccuracy: 70},
+	})
+	var validation *errors.ValidationError
+	if !errors.As(err, &validation) || validation.Field != "thresholds" {
+		t.Errorf("Expected a validation error for thresholds, got %v", err)
+	}
+}
+
+func TestAnalysisService_ApplyDefaultSettingsCapsResources(t *testing.T) {
+	analysisService := service.NewUnavailableAnalysisService(models.EngineSettings{Threads: 2, HashSize: 64}, nil)
+
+	tests := []struct {
+		name                      string
+		threads, hashSize         int
+		wantThreads, wantHashSize int
+	}{
+		{"defaults", 0, 0, 2, 64},
+		{"lower", 1, 16, 1, 16},
+		{"above the engines' sizing", 64, 1000000, 2, 64},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := models.EngineSettings{Threads: tt.threads, HashSize: tt.hashSize}
+			analysisService.ApplyDefaultSettings(&settings)
+			if settings.Threads != tt.wantThreads || settings.HashSize != tt.wantHashSize {
+				t.Errorf("Threads, hash = %d, %d, want %d, %d", settings.Threads, settings.HashSize, tt.wantThreads, tt.wantHashSize)
+			}
+		})
+	}
+}
+
+func TestAnalysisService_ApplyResourceSettingsKeepsSearchLimits(t *testing.T) {
+	analysisService := service.NewUnavailableAnalysisService(models.EngineSettings{Threads: 2, HashSize: 64}, nil)
+
+	settings := models.EngineSettings{Threads: 8, HashSize: 256}
+	analysisService.ApplyResourceSettings(&settings)
+	if settings.Threads != 2 || settings.HashSize != 64 {
+		t.Errorf("Threads, hash = %d, %d, want 2, 64", settings.Threads, settings.HashSize)
+	}
+	// Mate searches, self-play and play games choose their own depth and time
+	if settings.Depth != 0 || settings.TimeLimit != 0 {
+		t.Errorf("Depth, time limit = %d, %d, want them left unset", settings.Depth, settings.TimeLimit)
+	}
+}
@@ -0,0 +1,311 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/internal/parser"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+// Analytics sampling limits
+const (
+	defaultAnalyticsSampleSize = 10
+	maxAnalyticsSampleSize     = 50
+	defaultGamesPerPlayer      = 5
+	maxGamesPerPlayer          = 20
+	analyticsWorkers           = 2
+	ratingBandWidth            = 200
+)
+
+// AnalyticsService produces aggregate reports for players, clubs and countries
+type AnalyticsService struct {
+	gameService     *GameAnalyzerService
+	analysisService *AnalysisService
+	pgnParser       *parser.PGNParser
+
+	referencesMu sync.RWMutex
+	references   map[string]OpeningReference // Reference databases for novelty searches, by name
+}
+
+// NewAnalyticsService creates a new analytics service
+func NewAnalyticsService(gameService *GameAnalyzerService, analysisService *AnalysisService) *AnalyticsService {
+	return &AnalyticsService{
+		gameService:     gameService,
+		analysisService: analysisService,
+		pgnParser:       parser.NewPGNParser(),
+		references:      map[string]OpeningReference{ReferenceEmbedded: NewEmbeddedReference()},
+	}
+}
+
+// sampledGame is a member's game selected for analysis
+type sampledGame struct {
+	username string
+	game     *models.GameInfo
+}
+
+// groupAggregate accumulates statistics while games are analyzed
+type groupAggregate struct {
+	mu            sync.Mutex
+	games         int
+	accuracySum   float64
+	openings      map[string]*models.OpeningStat
+	openingAccSum map[string]float64
+	bands         map[int]*models.RatingBandStat
+	bandAccSum    map[int]float64
+}
+
+// GenerateGroupReport samples member game archives of a club or country and aggregates their analyses
+func (s *AnalyticsService) GenerateGroupReport(ctx context.Context, request *models.GroupAnalyticsRequest) (*models.GroupReport, error) {
+	if err := normalizeGroupRequest(request); err != nil {
+		return nil, err
+	}
+
+	var members []string
+	var err error
+	if request.GroupType == models.GroupTypeClub {
+		members, err = s.gameService.GetClubMembers(request.GroupID)
+	} else {
+		members, err = s.gameService.GetCountryPlayers(request.GroupID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.GroupReport{
+		GroupType:   request.GroupType,
+		GroupID:     request.GroupID,
+		GeneratedAt: time.Now(),
+	}
+
+	sample := sampleMembers(members, request.SampleSize)
+	report.PlayersSampled = len(sample)
+
+	var games []sampledGame
+	for _, username := range sample {
+		recent, archives, err := s.gameService.GetRecentGames(ctx, username, request.GamesPerPlayer)
+		report.Archives = append(report.Archives, archives...)
+		if err != nil {
+			report.FailedPlayers = append(report.FailedPlayers, username)
+			continue
+		}
+		report.FailedArchives = append(report.FailedArchives, failedArchives(archives)...)
+		for _, game := range recent {
+			games = append(games, sampledGame{username: username, game: game})
+		}
+	}
+
+	agg := &groupAggregate{
+		openings:      make(map[string]*models.OpeningStat),
+		openingAccSum: make(map[string]float64),
+		bands:         make(map[int]*models.RatingBandStat),
+		bandAccSum:    make(map[int]float64),
+	}
+
+	// Analyze the sampled games across a few workers so the engine pool is shared fairly
+	jobs := make(chan sampledGame)
+	var wg sync.WaitGroup
+	for i := 0; i < analyticsWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for sg := range jobs {
+				s.analyzeSampledGame(ctx, sg, request, agg)
+			}
+		}()
+	}
+
+	for _, sg := range games {
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- sg
+	}
+	close(jobs)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	agg.fill(report)
+	return report, nil
+}
+
+// normalizeGroupRequest validates a group analytics request and applies defaults
+func normalizeGroupRequest(request *models.GroupAnalyticsRequest) error {
+	switch request.GroupType {
+	case models.GroupTypeClub, models.GroupTypeCountry:
+	default:
+		return errors.NewValidationError("group_type", fmt.Sprintf("must be one of: %s, %s", models.GroupTypeClub, models.GroupTypeCountry))
+	}
+
+	if request.GroupID == "" {
+		return errors.NewValidationError("group_id", "a club ID or country code is required")
+	}
+
+	if request.SampleSize <= 0 {
+		request.SampleSize = defaultAnalyticsSampleSize
+	}
+	if request.SampleSize > maxAnalyticsSampleSize {
+		request.SampleSize = maxAnalyticsSampleSize
+	}
+	if request.GamesPerPlayer <= 0 {
+		request.GamesPerPlayer = defaultGamesPerPlayer
+	}
+	if request.GamesPerPlayer > maxGamesPerPlayer {
+		request.GamesPerPlayer = maxGamesPerPlayer
+	}
+	if request.Mode == "" {
+		request.Mode = models.AnalysisModeScan
+	}
+
+	return nil
+}
+
+// sampleMembers picks up to n distinct members at random
+func sampleMembers(members []string, n int) []string {
+	seen := make(map[string]bool, len(members))
+	unique := make([]string, 0, len(members))
+	for _, member := range members {
+		if !seen[member] {
+			seen[member] = true
+			unique = append(unique, member)
+		}
+	}
+
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	rng.Shuffle(len(unique), func(i, j int) {
+		unique[i], unique[j] = unique[j], unique[i]
+	})
+
+	if len(unique) > n {
+		unique = unique[:n]
+	}
+	return unique
+}
+
+// analyzeSampledGame analyzes one game and adds the member's side to the aggregate
+func (s *AnalyticsService) analyzeSampledGame(ctx context.Context, sg sampledGame, request *models.GroupAnalyticsRequest, agg *groupAggregate) {
+	analysis, err := s.analysisService.analyzeGameMoves(ctx, &models.AnalysisRequest{
+		PGN:      sg.game.PGN,
+		Settings: request.Settings,
+		Mode:     request.Mode,
+	})
+	if err != nil || len(analysis.Moves) == 0 {
+		return
+	}
+
+	color := "white"
+	rating := sg.game.WhitePlayer.Rating
+	accuracy := analysis.Accuracy.WhiteAccuracy
+	if strings.EqualFold(sg.game.BlackPlayer.Username, sg.username) {
+		color = "black"
+		rating = sg.game.BlackPlayer.Rating
+		accuracy = analysis.Accuracy.BlackAccuracy
+	}
+
+	moves, blunders := 0, 0
+	for _, move := range analysis.Moves {
+		if moveColor(move.FEN, move.MoveNumber) != color {
+			continue
+		}
+		moves++
+		if move.Blunder {
+			blunders++
+		}
+	}
+
+	opening := s.openingName(sg.game.PGN)
+
+	agg.mu.Lock()
+	defer agg.mu.Unlock()
+
+	agg.games++
+	agg.accuracySum += accuracy
+
+	stat, ok := agg.openings[opening]
+	if !ok {
+		stat = &models.OpeningStat{Name: opening}
+		agg.openings[opening] = stat
+	}
+	stat.Games++
+	agg.openingAccSum[opening] += accuracy
+
+	bandStart := rating / ratingBandWidth * ratingBandWidth
+	band, ok := agg.bands[bandStart]
+	if !ok {
+		band = &models.RatingBandStat{
+			Band:      fmt.Sprintf("%d-%d", bandStart, bandStart+ratingBandWidth-1),
+			MinRating: bandStart,
+			MaxRating: bandStart + ratingBandWidth - 1,
+		}
+		agg.bands[bandStart] = band
+	}
+	band.Games++
+	band.Moves += moves
+	band.Blunders += blunders
+	agg.bandAccSum[bandStart] += accuracy
+}
+
+// openingName derives a readable opening name from a game's PGN headers
+func (s *AnalyticsService) openingName(pgn string) string {
+	game, err := s.analysisService.pgnParser.ParsePGN(pgn)
+	if err != nil {
+		return "Unknown"
+	}
+	return openingFromHeaders(game.Headers)
+}
+
+// openingFromHeaders derives a readable opening name from parsed PGN headers
+func openingFromHeaders(headers map[string]string) string {
+	if url := headers["ecourl"]; url != "" {
+		name := url[strings.LastIndex(url, "/")+1:]
+		return strings.ReplaceAll(name, "-", " ")
+	}
+	if opening := headers["opening"]; opening != "" {
+		return opening
+	}
+	if eco := headers["eco"]; eco != "" {
+		return eco
+	}
+	return "Unknown"
+}
+
+// fill computes the final averages and writes them to the report
+func (agg *groupAggregate) fill(report *models.GroupReport) {
+	report.GamesAnalyzed = agg.games
+	if agg.games == 0 {
+		return
+	}
+	report.AverageAccuracy = agg.accuracySum / float64(agg.games)
+
+	for name, stat := range agg.openings {
+		stat.Share = float64(stat.Games) / float64(agg.games) * 100
+		stat.AverageAccuracy = agg.openingAccSum[name] / float64(stat.Games)
+		report.Openings = append(report.Openings, *stat)
+	}
+	sort.Slice(report.Openings, func(i, j int) bool {
+		if report.Openings[i].Games != report.Openings[j].Games {
+			return report.Openings[i].Games > report.Openings[j].Games
+		}
+		return report.Openings[i].Name < report.Openings[j].Name
+	})
+
+	for start, band := range agg.bands {
+		band.AverageAccuracy = agg.bandAccSum[start] / float64(band.Games)
+		if band.Moves > 0 {
+			band.BlunderRate = float64(band.Blunders) / float64(band.Moves) * 100
+		}
+		report.RatingBands = append(report.RatingBands, *band)
+	}
+	sort.Slice(report.RatingBands, func(i, j int) bool {
+		return report.RatingBands[i].MinRating < report.RatingBands[j].MinRating
+	})
+}
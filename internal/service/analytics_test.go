@@ -0,0 +1,86 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+)
+
+func TestNormalizeGroupRequest(t *testing.T) {
+	request := &models.GroupAnalyticsRequest{GroupType: models.GroupTypeClub, GroupID: "chess-com-developer-community", SampleSize: 500}
+	if err := normalizeGroupRequest(request); err != nil {
+		t.Fatalf("normalizeGroupRequest() error = %v", err)
+	}
+	if request.SampleSize != maxAnalyticsSampleSize {
+		t.Errorf("SampleSize = %d, want %d", request.SampleSize, maxAnalyticsSampleSize)
+	}
+	if request.GamesPerPlayer != defaultGamesPerPlayer {
+		t.Errorf("GamesPerPlayer = %d, want %d", request.GamesPerPlayer, defaultGamesPerPlayer)
+	}
+	if request.Mode != models.AnalysisModeScan {
+		t.Errorf("Mode = %s, want %s", request.Mode, models.AnalysisModeScan)
+	}
+
+	if err := normalizeGroupRequest(&models.GroupAnalyticsRequest{GroupType: "league", GroupID: "x"}); err == nil {
+		t.Error("Expected an error for an unknown group type")
+	}
+	if err := normalizeGroupRequest(&models.GroupAnalyticsRequest{GroupType: models.GroupTypeCountry}); err == nil {
+		t.Error("Expected an error for a missing group ID")
+	}
+}
+
+func TestSampleMembers(t *testing.T) {
+	members := []string{"a", "b", "c", "a", "d"}
+
+	sample := sampleMembers(members, 3)
+	if len(sample) != 3 {
+		t.Fatalf("Expected 3 sampled members, got %d", len(sample))
+	}
+
+	seen := make(map[string]bool)
+	for _, m := range sample {
+		if seen[m] {
+			t.Errorf("Member %s sampled twice", m)
+		}
+		seen[m] = true
+	}
+
+	if got := sampleMembers(members, 10); len(got) != 4 {
+		t.Errorf("Expected all 4 unique members, got %v", got)
+	}
+}
+
+func TestParseArchiveURL(t *testing.T) {
+	year, month, ok := parseArchiveURL("https://api.chess.com/pub/player/hikaru/games/2024/03")
+	if !ok || year != 2024 || month != 3 {
+		t.Errorf("parseArchiveURL() = %d, %d, %v, want 2024, 3, true", year, month, ok)
+	}
+
+	if _, _, ok := parseArchiveURL("https://api.chess.com/pub/player/hikaru"); ok {
+		t.Error("Expected an invalid archive URL to be rejected")
+	}
+}
+
+func TestGroupAggregateFill(t *testing.T) {
+	agg := &groupAggregate{
+		games:         2,
+		accuracySum:   170,
+		openings:      map[string]*models.OpeningStat{"Italian Game": {Name: "Italian Game", Games: 2}},
+		openingAccSum: map[string]float64{"Italian Game": 170},
+		bands:         map[int]*models.RatingBandStat{1200: {Band: "1200-1399", MinRating: 1200, Games: 2, Moves: 50, Blunders: 3}},
+		bandAccSum:    map[int]float64{1200: 170},
+	}
+
+	report := &models.GroupReport{}
+	agg.fill(report)
+
+	if report.AverageAccuracy != 85 {
+		t.Errorf("AverageAccuracy = %v, want 85", report.AverageAccuracy)
+	}
+	if len(report.Openings) != 1 || report.Openings[0].Share != 100 {
+		t.Errorf("Unexpected openings: %+v", report.Openings)
+	}
+	if len(report.RatingBands) != 1 || report.RatingBands[0].BlunderRate != 6 {
+		t.Errorf("Unexpected rating bands: %+v", report.RatingBands)
+	}
+}
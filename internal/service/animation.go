@@ -0,0 +1,214 @@
+package service
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/board"
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+// GIF export settings
+const (
+	gifSquareSize   = 40  // Pixels per board square
+	gifSpriteScale  = 3   // Pixels per sprite cell
+	gifMoveDelay    = 100 // Hundredths of a second each position is shown
+	gifFlaggedDelay = 200 // Positions after a flagged move are shown longer
+	gifFinalDelay   = 400
+)
+
+// Palette indexes of the GIF export
+const (
+	gifLight uint8 = iota
+	gifDark
+	gifLightHighlight
+	gifDarkHighlight
+	gifWhitePiece
+	gifBlackPiece
+)
+
+// gifPalette holds the colors of board squares, highlighted squares and pieces
+var gifPalette = color.Palette{
+	gifLight:          color.RGBA{0xee, 0xee, 0xd2, 0xff},
+	gifDark:           color.RGBA{0x76, 0x96, 0x56, 0xff},
+	gifLightHighlight: color.RGBA{0xf6, 0xf6, 0x69, 0xff},
+	gifDarkHighlight:  color.RGBA{0xba, 0xca, 0x2b, 0xff},
+	gifWhitePiece:     color.RGBA{0xff, 0xff, 0xff, 0xff},
+	gifBlackPiece:     color.RGBA{0x00, 0x00, 0x00, 0xff},
+}
+
+// pieceSprites are 12x12 piece silhouettes: '#' is the outline, 'o' the body in the piece's color
+var pieceSprites = [7][12]string{
+	board.Pawn: {
+		"............",
+		"............",
+		".....##.....",
+		"....#oo#....",
+		"....#oo#....",
+		".....##.....",
+		"....#oo#....",
+		"....#oo#....",
+		"...#oooo#...",
+		"..#oooooo#..",
+		"..########..",
+		"............",
+	},
+	board.Knight: {
+		"............",
+		".....##.....",
+		"....#oo##...",
+		"...#oooooo#.",
+		"..#oo#oooo#.",
+		"..####ooo#..",
+		".....#ooo#..",
+		"....#oooo#..",
+		"...#ooooo#..",
+		"..#oooooo#..",
+		"..########..",
+		"............",
+	},
+	board.Bishop: {
+		".....##.....",
+		"....#oo#....",
+		"...#oo#o#...",
+		"...#o#oo#...",
+		"...#oooo#...",
+		"....#oo#....",
+		".....##.....",
+		"....#oo#....",
+		"...#oooo#...",
+		"..#oooooo#..",
+		"..########..",
+		"............",
+	},
+	board.Rook: {
+		"............",
+		"..##.##.##..",
+		"..#o#oo#o#..",
+		"..#oooooo#..",
+		"...#oooo#...",
+		"...#oooo#...",
+		"...#oooo#...",
+		"...#oooo#...",
+		"..#oooooo#..",
+		"..#oooooo#..",
+		"..########..",
+		"............",
+	},
+	board.Queen: {
+		"............",
+		".#...##...#.",
+		".#o#.##.#o#.",
+		".#oo#oo#oo#.",
+		".#oooooooo#.",
+		"..#oooooo#..",
+		"..#oooooo#..",
+		"...#oooo#...",
+		"...#oooo#...",
+		"..#oooooo#..",
+		"..########..",
+		"............",
+	},
+	board.King: {
+		".....##.....",
+		"....####....",
+		".....##.....",
+		"...######...",
+		"..#oooooo#..",
+		"..#oooooo#..",
+		"...#oooo#...",
+		"...#oooo#...",
+		"...#oooo#...",
+		"..#oooooo#..",
+		"..########..",
+		"............",
+	},
+}
+
+// exportGIF renders an analysis as an animated GIF of the game, one frame per position, drawn
+// from the orientation's side. The squares of the last move are highlighted and positions after
+// flagged moves are shown longer.
+func (s *AnalysisService) exportGIF(analysis *models.GameAnalysis, orientation string) ([]byte, error) {
+	_, start, moves, err := s.replayGame(analysis)
+	if err != nil {
+		return nil, err
+	}
+
+	animation := &gif.GIF{}
+	addFrame := func(position board.Board, last *board.Move, delay int) {
+		animation.Image = append(animation.Image, drawGIFBoard(position, last, orientation))
+		animation.Delay = append(animation.Delay, delay)
+	}
+
+	addFrame(start, nil, gifMoveDelay)
+	for i := range moves {
+		delay := gifMoveDelay
+		if moves[i].Analysis != nil && engineClassification(*moves[i].Analysis) != "" {
+			delay = gifFlaggedDelay
+		}
+		addFrame(moves[i].Board, &moves[i].Move, delay)
+	}
+	animation.Delay[len(animation.Delay)-1] = gifFinalDelay
+
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, animation); err != nil {
+		return nil, errors.NewAPIError("failed to encode GIF", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// drawGIFBoard draws a position as a GIF frame, highlighting the squares of the last move if any
+func drawGIFBoard(position board.Board, last *board.Move, orientation string) *image.Paletted {
+	frame := image.NewPaletted(image.Rect(0, 0, 8*gifSquareSize, 8*gifSquareSize), gifPalette)
+	spriteOffset := (gifSquareSize - 12*gifSpriteScale) / 2
+
+	for row, squares := range displaySquares(orientation) {
+		for col, square := range squares {
+			background := gifDark
+			if square.IsLight() {
+				background = gifLight
+			}
+			if last != nil && (square == last.From || square == last.To) {
+				// Highlighted squares keep their shade: the highlight colors follow the plain ones
+				background += gifLightHighlight
+			}
+			x0, y0 := col*gifSquareSize, row*gifSquareSize
+			fillRect(frame, x0, y0, gifSquareSize, gifSquareSize, background)
+
+			piece := position.PieceAt(square)
+			if piece.IsEmpty() {
+				continue
+			}
+			body := gifWhitePiece
+			if piece.Color == board.Black {
+				body = gifBlackPiece
+			}
+			for y, line := range pieceSprites[piece.Type] {
+				for x, cell := range line {
+					index := body
+					switch cell {
+					case '#':
+						index = gifBlackPiece
+					case 'o':
+					default:
+						continue
+					}
+					fillRect(frame, x0+spriteOffset+x*gifSpriteScale, y0+spriteOffset+y*gifSpriteScale, gifSpriteScale, gifSpriteScale, index)
+				}
+			}
+		}
+	}
+	return frame
+}
+
+// fillRect fills a rectangle of a frame with a palette color
+func fillRect(frame *image.Paletted, x0, y0, width, height int, index uint8) {
+	for y := y0; y < y0+height; y++ {
+		for x := x0; x < x0+width; x++ {
+			frame.SetColorIndex(x, y, index)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+// UpdateAnnotations applies user comments and classification overrides to a stored analysis
+func (s *AnalysisService) UpdateAnnotations(analysisID string, patch *models.AnnotationPatch) (*models.GameAnalysis, error) {
+	if len(patch.Moves) == 0 {
+		return nil, errors.NewValidationError("moves", "at least one move edit is required")
+	}
+
+	for _, edit := range patch.Moves {
+		if edit.Classification != nil && *edit.Classification != "" && !isValidClassification(*edit.Classification) {
+			return nil, errors.NewValidationError("classification", fmt.Sprintf("unknown classification: %s", *edit.Classification))
+		}
+	}
+
+	updated, err := s.store.UpdateAnalysis(analysisID, func(analysis *models.GameAnalysis) error {
+		for _, edit := range patch.Moves {
+			idx := findMoveIndex(analysis.Moves, edit.MoveNumber)
+			if idx == -1 {
+				return errors.NewValidationError("move_number", fmt.Sprintf("move %d is not part of the analysis", edit.MoveNumber))
+			}
+
+			move := &analysis.Moves[idx]
+			if edit.Comment != nil {
+				move.UserComment = *edit.Comment
+			}
+			if edit.Classification != nil {
+				move.ClassificationOverride = *edit.Classification
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.replaceCachedAnalysis(updated)
+	return updated, nil
+}
+
+// replaceCachedAnalysis swaps cached copies of an analysis for its updated version
+func (s *AnalysisService) replaceCachedAnalysis(analysis *models.GameAnalysis) {
+	s.cache.Update(func(_ string, cached *models.GameAnalysis) (*models.GameAnalysis, bool) {
+		return analysis, cached.ID == analysis.ID
+	})
+}
+
+// findMoveIndex returns the index of the move with the given ply, or -1
+func findMoveIndex(moves []models.MoveAnalysis, moveNumber int) int {
+	for i, move := range moves {
+		if move.MoveNumber == moveNumber {
+			return i
+		}
+	}
+	return -1
+}
+
+// isValidClassification reports whether a classification can be assigned by users
+func isValidClassification(classification string) bool {
+	for _, c := range models.MoveClassifications {
+		if c == classification {
+			return true
+		}
+	}
+	return false
+}
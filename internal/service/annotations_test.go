@@ -0,0 +1,143 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/cache"
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/internal/parser"
+	"github.com/pedrampdd/ChessAnalyser/internal/storage"
+)
+
+const annotationsTestPGN = `[Event "Test Game"]
+[Site "Test Site"]
+[Date "2023.01.01"]
+[Round "1"]
+[White "TestWhite"]
+[Black "TestBlack"]
+[Result "1-0"]
+
+1. e4 e5 2. Nf3 Nc6 1-0`
+
+// newTestAnalysisService creates an analysis service without an engine pool
+func newTestAnalysisService() *AnalysisService {
+	return &AnalysisService{
+		pgnParser: parser.NewPGNParser(),
+		store:     storage.NewMemoryStore(),
+		cache:     cache.New[string, *models.GameAnalysis](10, 0),
+	}
+}
+
+func TestAnalysisService_UpdateAnnotations(t *testing.T) {
+	service := newTestAnalysisService()
+
+	original := &models.GameAnalysis{
+		PGN: annotationsTestPGN,
+		Moves: []models.MoveAnalysis{
+			{Move: "e4", MoveNumber: 1, Evaluation: 0.3},
+			{Move: "e5", MoveNumber: 2, Evaluation: 0.25},
+			{Move: "Nf3", MoveNumber: 3, Evaluation: -0.5, Accuracy: 62.5, Mistake: true},
+			{Move: "Nc6", MoveNumber: 4, Evaluation: 998},
+		},
+	}
+	id, err := service.store.SaveAnalysis(original)
+	if err != nil {
+		t.Fatalf("SaveAnalysis() error = %v", err)
+	}
+
+	comment := "Solid developing move"
+	classification := "practical_decision"
+	updated, err := service.UpdateAnnotations(id, &models.AnnotationPatch{
+		Moves: []models.MoveAnnotationEdit{
+			{MoveNumber: 3, Comment: &comment, Classification: &classification},
+		},
+	})
+	if err != nil {
+		t.Fatalf("UpdateAnnotations() error = %v", err)
+	}
+
+	if updated.Moves[2].UserComment != comment || updated.Moves[2].ClassificationOverride != classification {
+		t.Errorf("Expected move 3 to be annotated, got %+v", updated.Moves[2])
+	}
+	if original.Moves[2].UserComment != "" {
+		t.Error("Expected the previous version of the analysis to be left untouched")
+	}
+
+	// Edits are merged with the engine's annotations in PGN exports
+	data, contentType, err := service.ExportAnalysis(id, ExportFormatPGN, ExportOptions{})
+	if err != nil {
+		t.Fatalf("ExportAnalysis() error = %v", err)
+	}
+	if contentType != "application/x-chess-pgn" {
+		t.Errorf("contentType = %v, want application/x-chess-pgn", contentType)
+	}
+	for _, want := range []string{
+		"1. e4 {[%eval 0.30]}",
+		"2. Nf3 $2 {[%eval -0.50] Engine: mistake (accuracy 62.5%). User: [%class practical_decision] Solid developing move}",
+		"2... Nc6 {[%eval #2]}",
+	} {
+		if !strings.Contains(strings.ReplaceAll(string(data), "\n", " "), want) {
+			t.Errorf("Expected %q in exported PGN, got:\n%s", want, data)
+		}
+	}
+
+	// Boards seen from Black are recorded with the tags
+	data, _, err = service.ExportAnalysis(id, ExportFormatPGN, ExportOptions{Orientation: OrientationBlack})
+	if err != nil {
+		t.Fatalf("ExportAnalysis() error = %v", err)
+	}
+	if tags, _, _ := strings.Cut(string(data), "\n\n"); !strings.HasSuffix(tags, "[Orientation \"black\"]") {
+		t.Errorf("Expected an Orientation tag after the tags, got:\n%s", data)
+	}
+	if _, _, err := service.ExportAnalysis(id, ExportFormatPGN, ExportOptions{Orientation: "sideways"}); err == nil {
+		t.Error("Expected an error for an unknown orientation")
+	}
+
+	// Invalid edits are rejected
+	unknown := "superb"
+	if _, err := service.UpdateAnnotations(id, &models.AnnotationPatch{
+		Moves: []models.MoveAnnotationEdit{{MoveNumber: 1, Classification: &unknown}},
+	}); err == nil {
+		t.Error("Expected an error for an unknown classification")
+	}
+	if _, err := service.UpdateAnnotations(id, &models.AnnotationPatch{
+		Moves: []models.MoveAnnotationEdit{{MoveNumber: 99, Comment: &comment}},
+	}); err == nil {
+		t.Error("Expected an error for a move outside the analysis")
+	}
+}
+
+func TestAnalysisService_UpdateAnnotationsStreamed(t *testing.T) {
+	service := newTestAnalysisService()
+
+	// A streamed analysis writes its moves a window at a time and is saved without them
+	id := "streamed"
+	service.store.AppendAnalysisMoves(id, []models.MoveAnalysis{{Move: "e4", MoveNumber: 1}, {Move: "e5", MoveNumber: 2}})
+	service.store.AppendAnalysisMoves(id, []models.MoveAnalysis{{Move: "Nf3", MoveNumber: 3}, {Move: "Nc6", MoveNumber: 4}})
+	if _, err := service.store.SaveAnalysis(&models.GameAnalysis{ID: id, PGN: annotationsTestPGN, StreamedMoves: 4}); err != nil {
+		t.Fatalf("SaveAnalysis() error = %v", err)
+	}
+
+	stored, err := service.GetAnalysis(id)
+	if err != nil {
+		t.Fatalf("GetAnalysis() error = %v", err)
+	}
+	if len(stored.Moves) != 4 || stored.Moves[3].Move != "Nc6" {
+		t.Fatalf("Expected the streamed moves to be read back, got %+v", stored.Moves)
+	}
+
+	comment := "Principled"
+	if _, err := service.UpdateAnnotations(id, &models.AnnotationPatch{
+		Moves: []models.MoveAnnotationEdit{{MoveNumber: 4, Comment: &comment}},
+	}); err != nil {
+		t.Fatalf("UpdateAnnotations() error = %v", err)
+	}
+	updated, _ := service.GetAnalysis(id)
+	if len(updated.Moves) != 4 || updated.Moves[3].UserComment != comment {
+		t.Errorf("Expected the edit to be stored with the moves, got %+v", updated.Moves)
+	}
+	if stored.Moves[3].UserComment != "" {
+		t.Error("Expected the previous version of the analysis to be left untouched")
+	}
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/client"
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+// Archive fetch settings
+const (
+	defaultArchiveWorkers = 4
+	maxArchiveWorkers     = 16
+)
+
+// ArchiveFetchOptions configures how monthly archives are fetched for player reports
+type ArchiveFetchOptions struct {
+	Workers           int           // Archives fetched at the same time (0 = default)
+	RequestsPerSecond float64       // Requests per second to each host (0 = unlimited)
+	WalkTimeout       time.Duration // Longest walk through a player's archives for a report (0 = no limit)
+}
+
+// SetArchiveFetchOptions sets the concurrency and rate limit of archive fetches
+func (s *GameAnalyzerService) SetArchiveFetchOptions(options ArchiveFetchOptions) {
+	workers := options.Workers
+	if workers <= 0 {
+		workers = defaultArchiveWorkers
+	}
+	if workers > maxArchiveWorkers {
+		workers = maxArchiveWorkers
+	}
+	s.archiveWorkers = workers
+	s.archiveWalkTimeout = options.WalkTimeout
+
+	s.limiter = nil
+	if options.RequestsPerSecond > 0 {
+		s.limiter = newHostLimiter(time.Duration(float64(time.Second) / options.RequestsPerSecond))
+	}
+}
+
+// archiveResult is one fetched month of a player's games
+type archiveResult struct {
+	month [2]int
+	games []*models.GameInfo
+	err   error
+}
+
+// fetchArchives fetches months of a player's games with a bounded number of workers, respecting
+// the host's rate limit. Results come back in the order of months; a month that failed carries
+// its error and doesn't stop the others. Once the context is done, downloads in flight are
+// aborted and the remaining months carry the context's error.
+func (s *GameAnalyzerService) fetchArchives(ctx context.Context, username string, months [][2]int, filter models.GameFilter) []archiveResult {
+	results := make([]archiveResult, len(months))
+	workers := s.archiveWorkers
+	if workers <= 0 {
+		workers = defaultArchiveWorkers
+	}
+
+	host := ""
+	if u, err := url.Parse(s.chessAPI.BaseURL); err == nil {
+		host = u.Host
+	}
+
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for i, month := range months {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, month [2]int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if ctx.Err() == nil && s.limiter != nil {
+				s.limiter.wait(host)
+			}
+			if err := ctx.Err(); err != nil {
+				results[i] = archiveResult{month: month, err: err}
+				return
+			}
+			games, err := s.playerGames(ctx, username, month[0], month[1], filter)
+			results[i] = archiveResult{month: month, games: games, err: err}
+		}(i, month)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// archiveStatus describes how fetching a month went
+func archiveStatus(username string, result archiveResult) models.ArchiveStatus {
+	status := models.ArchiveStatus{
+		Username: username,
+		Year:     result.month[0],
+		Month:    result.month[1],
+		Status:   models.ArchiveStatusFetched,
+	}
+	switch {
+	case result.err == nil:
+		return status
+	case errors.Is(result.err, context.Canceled), errors.Is(result.err, context.DeadlineExceeded):
+		status.Status = models.ArchiveStatusCancelled
+	case isArchiveNotFound(result.err):
+		status.Status = models.ArchiveStatusNotFound
+	default:
+		status.Status = models.ArchiveStatusFailed
+	}
+	status.Error = result.err.Error()
+	return status
+}
+
+// isArchiveNotFound reports whether err is a month Chess.com has no archive of. The player has no
+// games there, so walks treat it as an empty month.
+func isArchiveNotFound(err error) bool {
+	return errors.Is(err, client.ErrArchiveNotFound)
+}
+
+// failedArchives lists the months of a walk that failed or were cancelled. Months without an
+// archive aren't failures: the player simply has no games there.
+func failedArchives(statuses []models.ArchiveStatus) []models.ArchiveFailure {
+	var failed []models.ArchiveFailure
+	for _, status := range statuses {
+		if status.Status == models.ArchiveStatusFailed || status.Status == models.ArchiveStatusCancelled {
+			failed = append(failed, models.ArchiveFailure{
+				Username: status.Username,
+				Year:     status.Year,
+				Month:    status.Month,
+				Error:    status.Error,
+			})
+		}
+	}
+	return failed
+}
+
+// hostLimiter spaces out requests to each host by a fixed interval
+type hostLimiter struct {
+	interval time.Duration
+	mu       sync.Mutex
+	next     map[string]time.Time // Earliest time of the next request to each host
+}
+
+// newHostLimiter creates a limiter allowing one request per interval to each host
+func newHostLimiter(interval time.Duration) *hostLimiter {
+	return &hostLimiter{
+		interval: interval,
+		next:     make(map[string]time.Time),
+	}
+}
+
+// wait blocks until a request to host may be sent, reserving its slot
+func (l *hostLimiter) wait(host string) {
+	l.mu.Lock()
+	now := time.Now()
+	slot := l.next[host]
+	if slot.Before(now) {
+		slot = now
+	}
+	l.next[host] = slot.Add(l.interval)
+	l.mu.Unlock()
+
+	time.Sleep(time.Until(slot))
+}
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+// newTestArchiveServer serves a player with archives from January to June 2024, one game per month.
+// Months listed in failing return an error; a month suffixed with :404 isn't found, and one
+// suffixed with :hang never responds.
+func newTestArchiveServer(t *testing.T, failing ...string) (*httptest.Server, *int) {
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/player/alice/games/archives" {
+			var urls []string
+			for month := 1; month <= 6; month++ {
+				urls = append(urls, fmt.Sprintf(`"https://api.chess.com/pub/player/alice/games/2024/%02d"`, month))
+			}
+			fmt.Fprintf(w, `{"archives": [%s]}`, strings.Join(urls, ","))
+			return
+		}
+
+		mu.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+		}()
+		time.Sleep(10 * time.Millisecond)
+
+		month := strings.TrimPrefix(r.URL.Path, "/player/alice/games/2024/")
+		for _, f := range failing {
+			switch f {
+			case month:
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			case month + ":404":
+				w.WriteHeader(http.StatusNotFound)
+				return
+			case month + ":hang":
+				<-r.Context().Done()
+				return
+			}
+		}
+		fmt.Fprintf(w, `{"games": [{"url": "https://www.chess.com/game/live/%s", "rules": "chess", "pgn": "1. e4 e5 *"}]}`, month)
+	}))
+	t.Cleanup(server.Close)
+	return server, &maxInFlight
+}
+
+func TestGetRecentGames_Concurrent(t *testing.T) {
+	server, maxInFlight := newTestArchiveServer(t, "05")
+
+	service := NewGameAnalyzerService()
+	service.chessAPI.BaseURL = server.URL
+	service.SetArchiveFetchOptions(ArchiveFetchOptions{Workers: 3})
+
+	games, archives, err := service.GetRecentGames(context.Background(), "alice", 4)
+	if err != nil {
+		t.Fatalf("GetRecentGames() error = %v", err)
+	}
+
+	var urls []string
+	for _, game := range games {
+		urls = append(urls, game.URL[strings.LastIndex(game.URL, "/")+1:])
+	}
+	if strings.Join(urls, ",") != "06,04,03,02" {
+		t.Errorf("Expected the newest games without May's, got %v", urls)
+	}
+	failed := failedArchives(archives)
+	if len(failed) != 1 || failed[0].Year != 2024 || failed[0].Month != 5 || failed[0].Username != "alice" || failed[0].Error == "" {
+		t.Errorf("Expected May to be reported as failed, got %+v", failed)
+	}
+	if len(archives) != 5 || archives[1].Status != models.ArchiveStatusFailed || archives[0].Status != models.ArchiveStatusFetched || archives[0].Games != 1 {
+		t.Errorf("Expected the status of the five months walked, got %+v", archives)
+	}
+	if *maxInFlight > 3 || *maxInFlight < 2 {
+		t.Errorf("Expected up to 3 archives in flight, got %d", *maxInFlight)
+	}
+}
+
+func TestGetRecentGames_AllMonthsFail(t *testing.T) {
+	server, _ := newTestArchiveServer(t, "01", "02", "03", "04", "05", "06")
+
+	service := NewGameAnalyzerService()
+	service.chessAPI.BaseURL = server.URL
+
+	games, archives, err := service.GetRecentGames(context.Background(), "alice", 10)
+	if err == nil || games != nil {
+		t.Errorf("Expected an error when no month could be fetched, got %d games", len(games))
+	}
+	if failed := failedArchives(archives); len(failed) != 6 {
+		t.Errorf("Expected every month to be reported, got %d", len(failed))
+	}
+}
+
+func TestGetRecentGames_MonthsNotFound(t *testing.T) {
+	// The account was created in March, but its archive list still starts in January
+	server, _ := newTestArchiveServer(t, "01:404", "02:404")
+
+	service := NewGameAnalyzerService()
+	service.chessAPI.BaseURL = server.URL
+
+	games, archives, err := service.GetRecentGames(context.Background(), "alice", 10)
+	if err != nil {
+		t.Fatalf("GetRecentGames() error = %v", err)
+	}
+	if len(games) != 4 {
+		t.Errorf("Expected the games of March to June, got %d", len(games))
+	}
+	if failed := failedArchives(archives); len(failed) != 0 {
+		t.Errorf("Expected missing months not to be failures, got %+v", failed)
+	}
+	if len(archives) != 6 || archives[4].Status != models.ArchiveStatusNotFound || archives[5].Status != models.ArchiveStatusNotFound {
+		t.Errorf("Expected January and February to be reported as not found, got %+v", archives)
+	}
+}
+
+func TestGetRecentGames_WalkTimeout(t *testing.T) {
+	server, _ := newTestArchiveServer(t, "04:hang")
+
+	service := NewGameAnalyzerService()
+	service.chessAPI.BaseURL = server.URL
+	service.SetArchiveFetchOptions(ArchiveFetchOptions{Workers: 1, WalkTimeout: 200 * time.Millisecond})
+
+	games, archives, err := service.GetRecentGames(context.Background(), "alice", 10)
+	if err != nil {
+		t.Fatalf("GetRecentGames() error = %v", err)
+	}
+	if len(games) != 2 {
+		t.Errorf("Expected the games of June and May before the walk timed out, got %d", len(games))
+	}
+	if len(archives) != 6 || archives[2].Status != models.ArchiveStatusCancelled || archives[2].Month != 4 {
+		t.Errorf("Expected April to be reported as cancelled, got %+v", archives)
+	}
+	if last := archives[5]; last.Status != models.ArchiveStatusCancelled || last.Month != 1 {
+		t.Errorf("Expected the months never reached to be reported as cancelled, got %+v", archives)
+	}
+	if failed := failedArchives(archives); len(failed) != 4 {
+		t.Errorf("Expected the cancelled months to be listed as failed, got %+v", failed)
+	}
+}
+
+func TestGetRecentGames_WalkTimeoutBeforeAnyMonth(t *testing.T) {
+	server, _ := newTestArchiveServer(t, "06:hang")
+
+	service := NewGameAnalyzerService()
+	service.chessAPI.BaseURL = server.URL
+	service.SetArchiveFetchOptions(ArchiveFetchOptions{Workers: 1, WalkTimeout: 100 * time.Millisecond})
+
+	games, archives, err := service.GetRecentGames(context.Background(), "alice", 10)
+	var timeout *errors.TimeoutError
+	if !errors.As(err, &timeout) || games != nil {
+		t.Fatalf("GetRecentGames() = %d games, error %v, want a timeout", len(games), err)
+	}
+	if failed := failedArchives(archives); len(failed) != 6 {
+		t.Errorf("Expected every month to be reported as cancelled, got %+v", archives)
+	}
+
+	// The archive list itself honours the deadline
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := service.GetPlayerArchives(ctx, "alice"); err == nil {
+		t.Error("GetPlayerArchives() ignored a cancelled context")
+	}
+}
+
+func TestHostLimiter(t *testing.T) {
+	limiter := newHostLimiter(20 * time.Millisecond)
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		limiter.wait("api.chess.com")
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("Expected three requests to take at least 40ms, took %v", elapsed)
+	}
+
+	// Hosts are limited separately
+	start = time.Now()
+	limiter.wait("example.com")
+	if elapsed := time.Since(start); elapsed > 10*time.Millisecond {
+		t.Errorf("Expected the first request to another host to go through, waited %v", elapsed)
+	}
+}
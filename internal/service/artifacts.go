@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/blob"
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/internal/storage"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+// defaultArtifactURLLife is how long signed download URLs stay valid when no lifetime is configured
+const defaultArtifactURLLife = 15 * time.Minute
+
+// SetBlobStore plugs in the blob store holding exported artifacts
+func (s *AnalysisService) SetBlobStore(store blob.Store, urlLife time.Duration) {
+	if urlLife <= 0 {
+		urlLife = defaultArtifactURLLife
+	}
+	s.blobs = store
+	s.artifactURLLife = urlLife
+}
+
+// CreateArtifact exports a stored analysis in the given format and keeps it in the blob store
+func (s *AnalysisService) CreateArtifact(ctx context.Context, analysisID, format string, options ExportOptions) (*models.Artifact, error) {
+	if s.blobs == nil {
+		return nil, errors.NewStorageError("create artifact", fmt.Errorf("no blob store configured"))
+	}
+	if format == "" {
+		format = ExportFormatJSON
+	}
+
+	data, contentType, err := s.ExportAnalysis(analysisID, format, options)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := storage.NewID()
+	if err != nil {
+		return nil, err
+	}
+
+	artifact := &models.Artifact{
+		ID:          id,
+		AnalysisID:  analysisID,
+		Format:      format,
+		Key:         fmt.Sprintf("analyses/%s/%s.%s", analysisID, id, format),
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		CreatedAt:   time.Now(),
+	}
+	if err := s.blobs.Put(ctx, artifact.Key, data, contentType); err != nil {
+		return nil, errors.NewStorageError("upload artifact", err)
+	}
+
+	s.store.SaveArtifact(artifact)
+	return artifact, nil
+}
+
+// ListArtifacts returns a page of the artifacts created from a stored analysis, oldest first
+func (s *AnalysisService) ListArtifacts(analysisID string, request models.PageRequest) (*models.Page[*models.Artifact], error) {
+	if _, err := s.GetAnalysis(analysisID); err != nil {
+		return nil, err
+	}
+	return storage.Paginate(storage.ListArtifacts, s.store.ListArtifacts(analysisID), func(artifact *models.Artifact) string {
+		return storage.TimeKey(artifact.CreatedAt, artifact.ID)
+	}, request)
+}
+
+// GetArtifact returns an artifact's metadata
+func (s *AnalysisService) GetArtifact(id string) (*models.Artifact, error) {
+	return s.store.GetArtifact(id)
+}
+
+// ArtifactDownload returns a signed URL downloading an artifact's content
+func (s *AnalysisService) ArtifactDownload(ctx context.Context, id string) (*models.ArtifactDownload, error) {
+	artifact, err := s.store.GetArtifact(id)
+	if err != nil {
+		return nil, err
+	}
+	if s.blobs == nil {
+		return nil, errors.NewStorageError("sign artifact URL", fmt.Errorf("no blob store configured"))
+	}
+
+	expiresAt := time.Now().Add(s.artifactURLLife)
+	signedURL, err := s.blobs.SignedURL(ctx, artifact.Key, s.artifactURLLife)
+	if err != nil {
+		return nil, errors.NewStorageError("sign artifact URL", err)
+	}
+
+	return &models.ArtifactDownload{ArtifactID: id, URL: signedURL, ExpiresAt: expiresAt}, nil
+}
+
+// DeleteArtifact removes an artifact's content and metadata
+func (s *AnalysisService) DeleteArtifact(ctx context.Context, id string) error {
+	artifact, err := s.store.GetArtifact(id)
+	if err != nil {
+		return err
+	}
+	if s.blobs != nil {
+		if err := s.blobs.Delete(ctx, artifact.Key); err != nil {
+			return errors.NewStorageError("delete artifact", err)
+		}
+	}
+
+	s.store.DeleteArtifact(id)
+	return nil
+}
+
+// OpenSignedBlob opens a blob from the local blob store after checking its signed URL parameters.
+// Blobs in S3 or GCS are downloaded from the bucket directly and never served this way.
+func (s *AnalysisService) OpenSignedBlob(ctx context.Context, key, expires, signature string) (io.ReadCloser, error) {
+	local, ok := s.blobs.(*blob.LocalStore)
+	if !ok {
+		return nil, errors.NewArtifactNotFoundError(key)
+	}
+	// Expired or forged links are reported like missing blobs, as with expired share links
+	if err := local.Verify(key, expires, signature); err != nil {
+		return nil, errors.NewArtifactNotFoundError(key)
+	}
+
+	body, err := local.Get(ctx, key)
+	if err == blob.ErrNotFound {
+		return nil, errors.NewArtifactNotFoundError(key)
+	}
+	if err != nil {
+		return nil, errors.NewStorageError("read artifact", err)
+	}
+	return body, nil
+}
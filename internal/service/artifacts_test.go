@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/blob"
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+func TestAnalysisService_Artifacts(t *testing.T) {
+	service := newTestAnalysisService()
+	blobs, err := blob.NewLocalStore(t.TempDir(), "/blobs", []byte("secret"))
+	if err != nil {
+		t.Fatalf("NewLocalStore() error = %v", err)
+	}
+	service.SetBlobStore(blobs, time.Minute)
+	ctx := context.Background()
+
+	id, err := service.store.SaveAnalysis(&models.GameAnalysis{PGN: annotationsTestPGN})
+	if err != nil {
+		t.Fatalf("SaveAnalysis() error = %v", err)
+	}
+
+	artifact, err := service.CreateArtifact(ctx, id, ExportFormatPGN, ExportOptions{})
+	if err != nil {
+		t.Fatalf("CreateArtifact() error = %v", err)
+	}
+	if artifact.ContentType != "application/x-chess-pgn" || artifact.Size == 0 || !strings.HasSuffix(artifact.Key, ".pgn") {
+		t.Errorf("Unexpected artifact: %+v", artifact)
+	}
+
+	if artifacts, _ := service.ListArtifacts(id, models.PageRequest{}); len(artifacts.Items) != 1 || artifacts.Items[0].ID != artifact.ID {
+		t.Errorf("Expected the artifact to be listed, got %v", artifacts)
+	}
+
+	download, err := service.ArtifactDownload(ctx, artifact.ID)
+	if err != nil {
+		t.Fatalf("ArtifactDownload() error = %v", err)
+	}
+	u, _ := url.Parse(download.URL)
+	key := strings.TrimPrefix(u.Path, "/blobs/")
+
+	body, err := service.OpenSignedBlob(ctx, key, u.Query().Get("expires"), u.Query().Get("signature"))
+	if err != nil {
+		t.Fatalf("OpenSignedBlob() error = %v", err)
+	}
+	data, _ := io.ReadAll(body)
+	body.Close()
+	if int64(len(data)) != artifact.Size {
+		t.Errorf("Downloaded %d bytes, want %d", len(data), artifact.Size)
+	}
+
+	var notFound *errors.ArtifactNotFoundError
+	if _, err := service.OpenSignedBlob(ctx, key, u.Query().Get("expires"), "forged"); !errors.As(err, &notFound) {
+		t.Errorf("Expected a forged signature to be rejected, got %v", err)
+	}
+
+	if err := service.DeleteArtifact(ctx, artifact.ID); err != nil {
+		t.Fatalf("DeleteArtifact() error = %v", err)
+	}
+	if _, err := service.GetArtifact(artifact.ID); !errors.As(err, &notFound) {
+		t.Errorf("Expected the artifact to be gone, got %v", err)
+	}
+	if _, err := blobs.Get(ctx, artifact.Key); err != blob.ErrNotFound {
+		t.Errorf("Expected the blob to be deleted, got %v", err)
+	}
+}
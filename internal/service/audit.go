@@ -0,0 +1,206 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/internal/storage"
+)
+
+// requesterKey is the context key of a request's requester
+type requesterKey struct{}
+
+// WithRequester marks a context with who sent the request, so the game analyses it runs are
+// attributed to them in the audit log. Analyses without a requester, such as those of synced
+// games, are recorded as the service's own.
+func WithRequester(ctx context.Context, requester models.Requester) context.Context {
+	return context.WithValue(ctx, requesterKey{}, requester)
+}
+
+// requesterFrom returns the requester a context was marked with
+func requesterFrom(ctx context.Context) models.Requester {
+	requester, _ := ctx.Value(requesterKey{}).(models.Requester)
+	return requester
+}
+
+// KeyFingerprint identifies an API key in the audit log without recording the key itself: the
+// first 16 hex digits of its SHA-256. An empty key has no fingerprint.
+func KeyFingerprint(key string) string {
+	if key == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:8])
+}
+
+// auditLog is where the audit log is kept beyond the store: entries are appended to a file as
+// they're recorded, one JSON object per line, and the file is rewritten with the entries the
+// store keeps once it holds twice as many
+type auditLog struct {
+	mu         sync.Mutex // Serializes writes to the file
+	maxEntries int        // Game analyses the audit log keeps (0 disables it)
+	file       string
+	lines      int // Entries in the file
+}
+
+// SetAuditLog sets how many game analyses the audit log keeps, dropping the oldest beyond that;
+// 0 disables the audit log. When file is set the log is loaded from it and entries are appended
+// to it as they're recorded, so the log survives restarts.
+func (s *AnalysisService) SetAuditLog(maxEntries int, file string) error {
+	s.audit.mu.Lock()
+	defer s.audit.mu.Unlock()
+
+	s.audit.maxEntries, s.audit.file, s.audit.lines = maxEntries, file, 0
+	if maxEntries <= 0 || file == "" {
+		return nil
+	}
+
+	f, err := os.Open(file)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		var entry models.AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return fmt.Errorf("invalid audit log file %s, line %d: %w", file, line, err)
+		}
+		s.store.SaveAuditEntry(&entry, maxEntries)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	// Drop the entries the store no longer keeps from the file
+	return s.compactAuditFile()
+}
+
+// QueryAuditLog returns a page of the audit log entries matching a query, oldest first
+func (s *AnalysisService) QueryAuditLog(query models.AuditQuery) (*models.Page[*models.AuditEntry], error) {
+	return s.store.QueryAuditEntries(query)
+}
+
+// recordAudit records a game analysis request and its outcome in the audit log
+func (s *AnalysisService) recordAudit(ctx context.Context, request *models.AnalysisRequest, analysis *models.GameAnalysis,
+	cached bool, err error, started time.Time) {
+	if s.audit.maxEntries <= 0 {
+		return
+	}
+	id, idErr := storage.NewID()
+	if idErr != nil {
+		log.Printf("Failed to record analysis request in the audit log: %v", idErr)
+		return
+	}
+
+	requester := requesterFrom(ctx)
+	entry := &models.AuditEntry{
+		ID:             id,
+		Time:           started,
+		KeyFingerprint: requester.KeyFingerprint,
+		IP:             requester.IP,
+		Endpoint:       requester.Endpoint,
+		GameID:         request.GameID,
+		Settings:       request.Settings,
+		Mode:           request.Mode,
+		Profile:        request.Profile,
+		Status:         models.AuditStatusCompleted,
+		WallClock:      time.Since(started).Milliseconds(),
+	}
+	if entry.GameID == "" {
+		entry.GameID = pgnTag(request.PGN, "Link")
+	}
+	switch {
+	case err != nil:
+		entry.Status = models.AuditStatusFailed
+		entry.Error = err.Error()
+	case cached:
+		entry.Status = models.AuditStatusCached
+		entry.AnalysisID = analysis.ID
+	default:
+		entry.AnalysisID = analysis.ID
+		if analysis.Cost != nil {
+			entry.EngineTime = analysis.Cost.EngineTime
+		}
+	}
+	s.store.SaveAuditEntry(entry, s.audit.maxEntries)
+
+	if err := s.appendAuditFile(entry); err != nil {
+		log.Printf("Failed to write analysis request to the audit log file: %v", err)
+	}
+}
+
+// appendAuditFile appends an entry to the audit log file, if there is one
+func (s *AnalysisService) appendAuditFile(entry *models.AuditEntry) error {
+	s.audit.mu.Lock()
+	defer s.audit.mu.Unlock()
+
+	if s.audit.file == "" {
+		return nil
+	}
+	if s.audit.lines >= 2*s.audit.maxEntries {
+		// The store already holds the entry
+		return s.compactAuditFile()
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.audit.file), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(s.audit.file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	s.audit.lines++
+	return f.Close()
+}
+
+// compactAuditFile rewrites the audit log file with the entries the store keeps. The caller
+// holds the audit log lock.
+func (s *AnalysisService) compactAuditFile() error {
+	entries := s.store.AuditEntries()
+	var data []byte
+	for _, entry := range entries {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		data = append(append(data, line...), '\n')
+	}
+
+	// Write a temporary file first so a crash can't leave a truncated log behind
+	if err := os.MkdirAll(filepath.Dir(s.audit.file), 0o755); err != nil {
+		return err
+	}
+	tmp := s.audit.file + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.audit.file); err != nil {
+		return err
+	}
+	s.audit.lines = len(entries)
+	return nil
+}
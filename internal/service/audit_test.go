@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+)
+
+func TestAnalysisService_AuditLog(t *testing.T) {
+	service := newTestAnalysisService()
+	service.SetAuditLog(2, "")
+
+	alice := WithRequester(context.Background(), models.Requester{KeyFingerprint: KeyFingerprint("alice-key"), IP: "10.0.0.1", Endpoint: "POST /api/v1/analyze/game"})
+	request := &models.AnalysisRequest{
+		PGN:      "[Link \"https://www.chess.com/game/live/101\"]\n\n" + annotationsTestPGN,
+		Settings: models.EngineSettings{Depth: 12},
+	}
+	service.cache.Set(service.generateCacheKey(request), &models.GameAnalysis{ID: "a1", PGN: request.PGN})
+
+	// Served from the cache, then a request that fails validation
+	if _, err := service.AnalyzeGame(alice, request); err != nil {
+		t.Fatalf("AnalyzeGame() error = %v", err)
+	}
+	failing := *request
+	failing.Mode = "unknown"
+	if _, err := service.AnalyzeGame(alice, &failing); err == nil {
+		t.Fatal("Expected an unknown mode to fail")
+	}
+
+	page, err := service.QueryAuditLog(models.AuditQuery{KeyFingerprint: KeyFingerprint("alice-key")})
+	if err != nil {
+		t.Fatalf("QueryAuditLog() error = %v", err)
+	}
+	if len(page.Items) != 2 {
+		t.Fatalf("Expected both requests to be audited, got %d", len(page.Items))
+	}
+	cached, failed := page.Items[0], page.Items[1]
+	if cached.Status != models.AuditStatusCached || cached.AnalysisID != "a1" || cached.EngineTime != 0 ||
+		cached.IP != "10.0.0.1" || cached.Endpoint != "POST /api/v1/analyze/game" || cached.Settings.Depth != 12 {
+		t.Errorf("Unexpected entry of the cached analysis: %+v", cached)
+	}
+	if cached.GameID != "https://www.chess.com/game/live/101" {
+		t.Errorf("Expected the game link of the PGN as game ID, got %q", cached.GameID)
+	}
+	if failed.Status != models.AuditStatusFailed || failed.Error == "" || failed.Mode != "unknown" {
+		t.Errorf("Unexpected entry of the failed request: %+v", failed)
+	}
+
+	// The service's own analyses have no requester; the oldest entries are dropped beyond the limit
+	started := time.Now()
+	service.recordAudit(context.Background(), &models.AnalysisRequest{GameID: "102"},
+		&models.GameAnalysis{ID: "a2", Cost: &models.AnalysisCost{EngineTime: 1500}}, false, nil, started)
+	page, err = service.QueryAuditLog(models.AuditQuery{})
+	if err != nil {
+		t.Fatalf("QueryAuditLog() error = %v", err)
+	}
+	if len(page.Items) != 2 || page.Items[0].Status != models.AuditStatusFailed {
+		t.Fatalf("Expected the oldest entry to be dropped, got %+v", page.Items)
+	}
+	own := page.Items[1]
+	if own.Status != models.AuditStatusCompleted || own.EngineTime != 1500 || own.KeyFingerprint != "" || own.IP != "" || own.GameID != "102" {
+		t.Errorf("Unexpected entry of the service's own analysis: %+v", own)
+	}
+
+	page, err = service.QueryAuditLog(models.AuditQuery{Since: started.Add(time.Second)})
+	if err != nil || len(page.Items) != 0 {
+		t.Errorf("Expected no entries after the last one, got %v, %v", page, err)
+	}
+	if page, err := service.QueryAuditLog(models.AuditQuery{GameID: "102"}); err != nil || len(page.Items) != 1 {
+		t.Errorf("Expected one entry of game 102, got %v, %v", page, err)
+	}
+}
+
+func TestAnalysisService_AuditLogDisabled(t *testing.T) {
+	service := newTestAnalysisService()
+	service.recordAudit(context.Background(), &models.AnalysisRequest{}, nil, false, fmt.Errorf("failed"), time.Now())
+
+	page, err := service.QueryAuditLog(models.AuditQuery{})
+	if err != nil || len(page.Items) != 0 {
+		t.Errorf("Expected nothing to be recorded with the audit log disabled, got %v, %v", page, err)
+	}
+}
+
+func TestKeyFingerprint(t *testing.T) {
+	fingerprint := KeyFingerprint("alice-key")
+	if len(fingerprint) != 16 || strings.Contains(fingerprint, "alice") {
+		t.Errorf("KeyFingerprint() = %q, want 16 hex digits not revealing the key", fingerprint)
+	}
+	if KeyFingerprint("alice-key") != fingerprint || KeyFingerprint("bob-key") == fingerprint {
+		t.Error("Expected fingerprints to identify keys")
+	}
+	if KeyFingerprint("") != "" {
+		t.Error("Expected no fingerprint for anonymous requests")
+	}
+}
+
+func TestAnalysisService_AuditLogFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "audit", "log.jsonl")
+	record := func(service *AnalysisService, gameID string) {
+		service.recordAudit(context.Background(), &models.AnalysisRequest{GameID: gameID},
+			&models.GameAnalysis{ID: "a" + gameID}, false, nil, time.Now())
+	}
+
+	service := newTestAnalysisService()
+	if err := service.SetAuditLog(2, file); err != nil {
+		t.Fatalf("SetAuditLog() error = %v", err)
+	}
+	for _, gameID := range []string{"1", "2", "3", "4", "5"} {
+		record(service, gameID)
+	}
+
+	// The file is rewritten with the kept entries once it holds twice as many, on the fifth entry
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("Failed to read the audit log file: %v", err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 2 {
+		t.Errorf("Expected the file to be compacted to 2 entries, got %d", lines)
+	}
+	record(service, "6")
+
+	// A restarted service loads the entries it keeps back
+	restarted := newTestAnalysisService()
+	if err := restarted.SetAuditLog(2, file); err != nil {
+		t.Fatalf("SetAuditLog() error = %v", err)
+	}
+	page, err := restarted.QueryAuditLog(models.AuditQuery{})
+	if err != nil {
+		t.Fatalf("QueryAuditLog() error = %v", err)
+	}
+	if len(page.Items) != 2 || page.Items[0].GameID != "5" || page.Items[1].GameID != "6" {
+		t.Fatalf("Expected the last two entries to be loaded, got %+v", page.Items)
+	}
+
+	if err := os.WriteFile(file, []byte("not json\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := newTestAnalysisService().SetAuditLog(2, file); err == nil {
+		t.Error("Expected an error for an invalid audit log file")
+	}
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+// defaultBatchLimit is how many items a batch may hold until SetBatchLimit applies the configured limit
+const defaultBatchLimit = 50
+
+// SetBatchLimit sets how many positions and games a batch analysis request may hold
+func (s *AnalysisService) SetBatchLimit(limit int) {
+	s.batchLimit = limit
+}
+
+// AnalyzeBatch analyzes positions and games with one set of engine settings, spreading them over
+// the engine pool. Items that fail or miss the deadline are reported in their result rather than
+// failing the whole batch.
+func (s *AnalysisService) AnalyzeBatch(ctx context.Context, request *models.BatchAnalysisRequest) (*models.BatchAnalysis, error) {
+	limit := s.batchLimit
+	if limit <= 0 {
+		limit = defaultBatchLimit
+	}
+	if len(request.Items) == 0 {
+		return nil, errors.NewValidationError("items", "at least one FEN or PGN is required")
+	}
+	if len(request.Items) > limit {
+		return nil, errors.NewValidationError("items", fmt.Sprintf("at most %d items can be analyzed in one batch", limit))
+	}
+	for i, item := range request.Items {
+		if (item.FEN == "") == (item.PGN == "") {
+			return nil, errors.NewValidationError(fmt.Sprintf("items[%d]", i), "exactly one of fen and pgn is required")
+		}
+	}
+	if request.Deadline < 0 {
+		return nil, errors.NewValidationError("deadline", "must not be negative")
+	}
+
+	// Fail fast when no engine can serve the batch, and run as many items at once as it has engines
+	pool, err := s.poolFor(request.Settings.Variant, "")
+	if err != nil {
+		return nil, err
+	}
+	workers := min(pool.Size(), len(request.Items))
+	if workers < 1 {
+		workers = 1
+	}
+
+	start := time.Now()
+	if request.Deadline > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(request.Deadline)*time.Millisecond)
+		defer cancel()
+	}
+
+	batch := &models.BatchAnalysis{Results: make([]models.BatchItemResult, len(request.Items))}
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range jobs {
+				batch.Results[index] = s.analyzeBatchItem(ctx, index, request.Items[index], request.Settings)
+			}
+		}()
+	}
+	for i := range request.Items {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	for _, result := range batch.Results {
+		if result.Error != "" {
+			batch.Failed++
+		} else {
+			batch.Succeeded++
+		}
+		batch.TimedOut = batch.TimedOut || result.TimedOut
+	}
+	batch.ElapsedMs = time.Since(start).Milliseconds()
+	return batch, nil
+}
+
+// analyzeBatchItem analyzes one batch item, recording failures in its result
+func (s *AnalysisService) analyzeBatchItem(ctx context.Context, index int, item models.BatchItem, settings models.EngineSettings) models.BatchItemResult {
+	result := models.BatchItemResult{Index: index, ID: item.ID}
+	if ctx.Err() != nil {
+		result.Error = "deadline passed before the item was analyzed"
+		result.TimedOut = true
+		return result
+	}
+
+	var err error
+	if item.FEN != "" {
+		result.Position, err = s.AnalyzePosition(ctx, item.FEN, settings)
+	} else {
+		result.Game, err = s.AnalyzeGame(ctx, &models.AnalysisRequest{PGN: item.PGN, Settings: settings})
+	}
+	if err != nil {
+		result.Position, result.Game = nil, nil
+		result.Error = err.Error()
+		result.TimedOut = ctx.Err() != nil
+	}
+	return result
+}
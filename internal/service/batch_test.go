@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+func TestAnalysisService_AnalyzeBatchValidation(t *testing.T) {
+	service := newTestAnalysisService()
+	service.SetBatchLimit(2)
+	fen := "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+
+	tests := []struct {
+		name    string
+		request models.BatchAnalysisRequest
+	}{
+		{"empty", models.BatchAnalysisRequest{}},
+		{"too many items", models.BatchAnalysisRequest{Items: []models.BatchItem{{FEN: fen}, {FEN: fen}, {FEN: fen}}}},
+		{"neither fen nor pgn", models.BatchAnalysisRequest{Items: []models.BatchItem{{ID: "a"}}}},
+		{"both fen and pgn", models.BatchAnalysisRequest{Items: []models.BatchItem{{FEN: fen, PGN: "1. e4 *"}}}},
+		{"negative deadline", models.BatchAnalysisRequest{Items: []models.BatchItem{{FEN: fen}}, Deadline: -1}},
+	}
+	for _, tt := range tests {
+		_, err := service.AnalyzeBatch(context.Background(), &tt.request)
+		var validationErr *errors.ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Errorf("%s: error = %v, want a validation error", tt.name, err)
+		}
+	}
+}
+
+func TestAnalysisService_AnalyzeBatchWithoutEngine(t *testing.T) {
+	service := NewUnavailableAnalysisService(models.EngineSettings{}, fmt.Errorf("stockfish not found"))
+
+	_, err := service.AnalyzeBatch(context.Background(), &models.BatchAnalysisRequest{
+		Items: []models.BatchItem{{FEN: "8/8/8/8/8/8/8/K6k w - - 0 1"}},
+	})
+	var unavailable *errors.EngineUnavailableError
+	if !errors.As(err, &unavailable) {
+		t.Errorf("AnalyzeBatch() error = %v, want an engine unavailable error", err)
+	}
+}
@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+// Benchmark settings and health thresholds. Positions are searched without a time limit, so the
+// depth is capped to keep a run from holding an engine for minutes.
+const (
+	defaultBenchmarkDepth = 16
+	MaxBenchmarkDepth     = 24
+	benchmarkMinSpeed     = 0.8 // Share of the baseline's nodes per second a healthy run reaches
+)
+
+// benchmarkPosition is a position of the benchmark suite and the moves that solve it
+type benchmarkPosition struct {
+	id        string
+	fen       string
+	solutions []string // UCI
+}
+
+// benchmarkSuite are positions any healthy engine solves at a modest depth: mates in one and
+// material won outright. Solving fewer of them than the baseline means the engine is misbehaving,
+// not that it's weaker.
+var benchmarkSuite = []benchmarkPosition{
+	{"mate-scholars", "r1bqkb1r/pppp1ppp/2n2n2/4p2Q/2B1P3/8/PPPP1PPP/RNB1K1NR w KQkq - 4 4", []string{"h5f7"}},
+	{"mate-back-rank", "6k1/5ppp/8/8/8/8/5PPP/3R2K1 w - - 0 1", []string{"d1d8"}},
+	{"mate-fools", "rnbqkbnr/pppp1ppp/8/4p3/6P1/5P2/PPPPP2P/RNBQKBNR b KQkq - 0 2", []string{"d8h4"}},
+	{"mate-smothered", "6rk/6pp/8/6N1/8/8/8/6K1 w - - 0 1", []string{"g5f7"}},
+	{"mate-rook", "k7/8/1K6/8/8/8/8/7R w - - 0 1", []string{"h1h8"}},
+	{"win-queen", "rnb1kbnr/pppp1ppp/8/4p3/4P2q/5N2/PPPP1PPP/RNBQKB1R w KQkq - 2 3", []string{"f3h4"}},
+	{"win-rook-fork", "r3k3/8/8/3N4/8/8/8/4K3 w - - 0 1", []string{"d5c7"}},
+	{"win-hanging-rook", "4k3/8/8/8/8/8/r7/R3K3 w - - 0 1", []string{"a1a2"}},
+	{"promotion", "8/4P3/8/8/8/8/k7/4K3 w - - 0 1", []string{"e7e8q"}},
+}
+
+// benchmarkBaseline is the benchmark run later runs are compared with, persisted to a file so it
+// outlives host and engine changes
+type benchmarkBaseline struct {
+	mu       sync.Mutex // Also keeps runs from competing for the engine
+	file     string
+	baseline *models.BenchmarkSummary
+}
+
+// SetBenchmarkFile persists the benchmark baseline to file, loading the baseline saved there if
+// there is one. Without a file the baseline is kept in memory.
+func (s *AnalysisService) SetBenchmarkFile(file string) error {
+	s.benchmarks.mu.Lock()
+	defer s.benchmarks.mu.Unlock()
+
+	s.benchmarks.file = file
+	if file == "" {
+		return nil
+	}
+	data, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var baseline models.BenchmarkSummary
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		return fmt.Errorf("invalid benchmark baseline file %s: %w", file, err)
+	}
+	s.benchmarks.baseline = &baseline
+	return nil
+}
+
+// RunBenchmark searches the benchmark suite on one engine of the default pool, with the engine's
+// configured settings and bypassing the caches, and compares the run with the baseline. Positions
+// are searched to a fixed depth so runs with the same settings do the same work.
+func (s *AnalysisService) RunBenchmark(ctx context.Context, request *models.BenchmarkRequest) (*models.BenchmarkReport, error) {
+	if request.Depth == 0 {
+		request.Depth = defaultBenchmarkDepth
+	}
+	if request.Depth < 0 || request.Depth > MaxBenchmarkDepth {
+		return nil, errors.NewValidationError("depth", fmt.Sprintf("depth must be between 1 and %d", MaxBenchmarkDepth))
+	}
+
+	pool, err := s.poolFor("", "")
+	if err != nil {
+		return nil, err
+	}
+
+	s.benchmarks.mu.Lock()
+	defer s.benchmarks.mu.Unlock()
+
+	stockfishEngine, err := pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer pool.ReturnEngine(stockfishEngine)
+
+	// Threads and hash are the ones the engine was configured with, auto-tuned or not
+	settings := stockfishEngine.EffectiveSettings(models.EngineSettings{Depth: request.Depth, MultiPV: 1})
+
+	host, _ := os.Hostname()
+	report := &models.BenchmarkReport{
+		Summary: models.BenchmarkSummary{
+			EngineVersion: stockfishEngine.GetVersion(),
+			Host:          host,
+			Depth:         settings.Depth,
+			Threads:       settings.Threads,
+			HashSize:      settings.HashSize,
+			RanAt:         time.Now(),
+		},
+		Positions: make([]models.BenchmarkPosition, 0, len(benchmarkSuite)),
+	}
+	for _, position := range benchmarkSuite {
+		result, err := stockfishEngine.AnalyzePosition(ctx, position.fen, settings)
+		if err != nil {
+			return nil, fmt.Errorf("benchmark position %s: %w", position.id, err)
+		}
+		solved := slices.Contains(position.solutions, result.BestMove)
+		report.Positions = append(report.Positions, models.BenchmarkPosition{
+			ID:        position.id,
+			FEN:       position.fen,
+			Solutions: position.solutions,
+			BestMove:  result.BestMove,
+			Solved:    solved,
+			Depth:     result.Depth,
+			Nodes:     result.Nodes,
+			Time:      result.Time,
+		})
+
+		summary := &report.Summary
+		summary.Positions++
+		if solved {
+			summary.Solved++
+		}
+		summary.Nodes += result.Nodes
+		summary.Time += result.Time
+	}
+	if report.Summary.Time > 0 {
+		report.Summary.NodesPerSecond = report.Summary.Nodes * 1000 / report.Summary.Time
+	}
+
+	if baseline := s.benchmarks.baseline; baseline != nil {
+		report.Baseline = baseline
+		report.Comparison = compareBenchmark(&report.Summary, baseline)
+	}
+	if request.SaveBaseline {
+		if err := s.saveBenchmarkBaseline(&report.Summary); err != nil {
+			return nil, errors.NewStorageError("save benchmark baseline", err)
+		}
+		report.BaselineSaved = true
+	}
+	return report, nil
+}
+
+// compareBenchmark judges a run against the baseline. A run is healthy when it solves as many
+// positions and searches at least benchmarkMinSpeed as fast; runs with other settings or on
+// another engine are compared all the same, with the difference noted.
+func compareBenchmark(run, baseline *models.BenchmarkSummary) *models.BenchmarkComparison {
+	comparison := &models.BenchmarkComparison{
+		SolvedDiff: run.Solved - baseline.Solved,
+		Healthy:    true,
+	}
+	if baseline.NodesPerSecond > 0 {
+		comparison.SpeedRatio = float64(run.NodesPerSecond) / float64(baseline.NodesPerSecond)
+	}
+
+	if comparison.SolvedDiff < 0 {
+		comparison.Healthy = false
+		comparison.Problems = append(comparison.Problems,
+			fmt.Sprintf("solved %d positions, %d fewer than the baseline", run.Solved, -comparison.SolvedDiff))
+	}
+	if baseline.NodesPerSecond > 0 && comparison.SpeedRatio < benchmarkMinSpeed {
+		comparison.Healthy = false
+		comparison.Problems = append(comparison.Problems,
+			fmt.Sprintf("searched %d nodes per second, %.0f%% of the baseline", run.NodesPerSecond, comparison.SpeedRatio*100))
+	}
+	if run.Depth != baseline.Depth || run.Threads != baseline.Threads || run.HashSize != baseline.HashSize {
+		comparison.Problems = append(comparison.Problems, fmt.Sprintf("settings differ from the baseline: depth %d, %d threads, %d MB hash",
+			baseline.Depth, baseline.Threads, baseline.HashSize))
+	}
+	if run.EngineVersion != baseline.EngineVersion {
+		comparison.Problems = append(comparison.Problems, fmt.Sprintf("baseline ran on %s", baseline.EngineVersion))
+	}
+	return comparison
+}
+
+// saveBenchmarkBaseline keeps a run as the baseline, writing it to the baseline file when there is
+// one. The caller holds the benchmark lock.
+func (s *AnalysisService) saveBenchmarkBaseline(summary *models.BenchmarkSummary) error {
+	if file := s.benchmarks.file; file != "" {
+		data, err := json.MarshalIndent(summary, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		// Write a temporary file first so a crash can't leave a truncated baseline behind
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			return err
+		}
+		tmp := file + ".tmp"
+		if err := os.WriteFile(tmp, data, 0o644); err != nil {
+			return err
+		}
+		if err := os.Rename(tmp, file); err != nil {
+			return err
+		}
+	}
+	baseline := *summary
+	s.benchmarks.baseline = &baseline
+	return nil
+}
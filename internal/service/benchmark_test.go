@@ -0,0 +1,35 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+)
+
+func TestCompareBenchmark(t *testing.T) {
+	baseline := &models.BenchmarkSummary{EngineVersion: "Stockfish 16", Depth: 16, Threads: 4, HashSize: 128, Solved: 9, NodesPerSecond: 1000000}
+
+	tests := []struct {
+		name     string
+		run      models.BenchmarkSummary
+		healthy  bool
+		problems int
+	}{
+		{"same", *baseline, true, 0},
+		{"slightly slower", models.BenchmarkSummary{EngineVersion: "Stockfish 16", Depth: 16, Threads: 4, HashSize: 128, Solved: 9, NodesPerSecond: 850000}, true, 0},
+		{"slow", models.BenchmarkSummary{EngineVersion: "Stockfish 16", Depth: 16, Threads: 4, HashSize: 128, Solved: 9, NodesPerSecond: 500000}, false, 1},
+		{"unsolved", models.BenchmarkSummary{EngineVersion: "Stockfish 16", Depth: 16, Threads: 4, HashSize: 128, Solved: 7, NodesPerSecond: 1000000}, false, 1},
+		{"other engine", models.BenchmarkSummary{EngineVersion: "Stockfish 17", Depth: 12, Threads: 4, HashSize: 128, Solved: 9, NodesPerSecond: 1200000}, true, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			comparison := compareBenchmark(&tt.run, baseline)
+			if comparison.Healthy != tt.healthy || len(comparison.Problems) != tt.problems {
+				t.Errorf("compareBenchmark() = %+v, want healthy %t with %d problems", comparison, tt.healthy, tt.problems)
+			}
+			if want := float64(tt.run.NodesPerSecond) / 1000000; comparison.SpeedRatio != want {
+				t.Errorf("Speed ratio = %v, want %v", comparison.SpeedRatio, want)
+			}
+		})
+	}
+}
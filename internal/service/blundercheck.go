@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/board"
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+)
+
+// Blunder check settings
+const (
+	blunderCheckBacklog   = 20 // Games waiting for a blunder check before new ones are dropped
+	maxRefutationPlies    = 6  // Moves of the refutation shown in a blunder check
+	blunderCheckIntervals = 2  // Games that ended longer ago than this many sync intervals aren't checked
+)
+
+// blunderCheck is a just-finished game of a synced player waiting to be scanned for blunders
+type blunderCheck struct {
+	username string
+	game     *models.GameInfo
+}
+
+// queueBlunderCheck queues a player's just-finished game for a blunder check, reporting whether it
+// was queued. Games backfilled on a first sync, or that ended a while ago, aren't worth the
+// feedback any more.
+func (s *SyncService) queueBlunderCheck(username string, game *models.GameInfo, baseline bool) bool {
+	s.notifyMu.Lock()
+	enabled := s.notify.BlunderCheck
+	s.notifyMu.Unlock()
+
+	if !enabled || baseline || game.PGN == "" || game.Rules != "chess" {
+		return false
+	}
+	if _, ok := playerColor(game, username); !ok {
+		return false
+	}
+	if s.now().Sub(gameEnd(game)) > blunderCheckIntervals*s.interval {
+		return false
+	}
+
+	select {
+	case s.blunderQueue <- blunderCheck{username: username, game: game}:
+		return true
+	default:
+		log.Printf("Blunder check backlog full, skipping %s", game.URL)
+		return false
+	}
+}
+
+// checkBlunders scans queued games as they arrive, publishing a notification for each game in
+// which the player blundered
+func (s *SyncService) checkBlunders(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case check := <-s.blunderQueue:
+			analysis, err := s.analysisService.analyzeGameMoves(ctx, &models.AnalysisRequest{
+				PGN:          check.game.PGN,
+				Settings:     s.analysisService.defaultSettings,
+				Mode:         models.AnalysisModeScan,
+				IncludeMoves: true,
+			})
+			if err != nil {
+				log.Printf("Blunder check failed for %s: %v", check.game.URL, err)
+				continue
+			}
+
+			if notification, ok := blunderCheckNotification(check.username, check.game, analysis); ok {
+				s.notifyMu.Lock()
+				channels := s.notify.channels()
+				s.notifyMu.Unlock()
+				s.publishNotifications([]models.PlayerNotification{notification}, channels)
+			}
+		}
+	}
+}
+
+// blunderCheckNotification summarizes the player's worst blunder of an analyzed game and the
+// engine's refutation of it. It reports false when the player didn't blunder.
+func blunderCheckNotification(username string, game *models.GameInfo, analysis *models.GameAnalysis) (models.PlayerNotification, bool) {
+	color, ok := playerColor(game, username)
+	if !ok {
+		return models.PlayerNotification{}, false
+	}
+	side := "white"
+	if color == board.Black {
+		side = "black"
+	}
+
+	worst, blunders := -1, 0
+	for i, move := range analysis.Moves {
+		if !move.Blunder || moveColor(move.FEN, move.MoveNumber) != side {
+			continue
+		}
+		blunders++
+		if worst == -1 || move.Accuracy < analysis.Moves[worst].Accuracy {
+			worst = i
+		}
+	}
+	if worst == -1 {
+		return models.PlayerNotification{}, false
+	}
+
+	move := analysis.Moves[worst]
+	before := 0.0
+	if worst > 0 {
+		before = analysis.Moves[worst-1].Evaluation
+	}
+	fullMove := (move.MoveNumber + 1) / 2
+	label := fmt.Sprintf("%d. %s", fullMove, move.Move)
+	if side == "black" {
+		label = fmt.Sprintf("%d... %s", fullMove, move.Move)
+	}
+
+	refutation := sanLine(move.FEN, move.BestLine)
+	if len(refutation) > maxRefutationPlies {
+		refutation = refutation[:maxRefutationPlies]
+	}
+	message := fmt.Sprintf("%s blundered on move %d with %s (%+.2f to %+.2f)", strings.ToLower(username), fullMove, label,
+		before, move.Evaluation)
+	if len(refutation) > 0 {
+		message += ", refuted by " + strings.Join(refutation, " ")
+	}
+	if blunders > 1 {
+		message += fmt.Sprintf(". %d blunders in the game", blunders)
+	}
+
+	return models.PlayerNotification{
+		Type:       models.NotificationBlunderCheck,
+		Username:   strings.ToLower(username),
+		Message:    message,
+		GameURL:    game.URL,
+		AnalysisID: analysis.ID,
+		MoveNumber: fullMove,
+		Move:       move.Move,
+		Refutation: refutation,
+		Blunders:   blunders,
+	}, true
+}
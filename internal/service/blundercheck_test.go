@@ -0,0 +1,106 @@
+package service
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/internal/storage"
+)
+
+func TestBlunderCheckNotification(t *testing.T) {
+	game := &models.GameInfo{
+		URL:         "https://www.chess.com/game/live/9",
+		WhitePlayer: models.Player{Username: "Alice"},
+		BlackPlayer: models.Player{Username: "bob"},
+	}
+	analysis := &models.GameAnalysis{
+		ID: "a1",
+		Moves: []models.MoveAnalysis{
+			{Move: "e4", MoveNumber: 1, Evaluation: 0.3},
+			{Move: "e5", MoveNumber: 2, Evaluation: 0.3},
+			{Move: "Nf3", MoveNumber: 3, Evaluation: 0.4},
+			{Move: "f6", MoveNumber: 4, Evaluation: 1.8, Accuracy: 30, Blunder: true,
+				FEN: "rnbqkbnr/pppp2pp/5p2/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - 0 3", BestLine: []string{"f3e5", "f6e5", "d1h5", "e8e7"}},
+			{Move: "Nxe5", MoveNumber: 5, Evaluation: 1.5},
+			{Move: "Qe7", MoveNumber: 6, Evaluation: 4.2, Accuracy: 20, Blunder: true,
+				FEN: "rnb1kbnr/ppppq1pp/5p2/4N3/4P3/8/PPPP1PPP/RNBQKB1R w KQkq - 1 4", BestLine: []string{"e5f3"}},
+		},
+	}
+
+	// White didn't blunder
+	if _, ok := blunderCheckNotification("alice", game, analysis); ok {
+		t.Error("Expected no notification for a game without the player's blunders")
+	}
+
+	notification, ok := blunderCheckNotification("BOB", game, analysis)
+	if !ok {
+		t.Fatal("Expected a notification for Black's blunders")
+	}
+	if notification.Type != models.NotificationBlunderCheck || notification.Username != "bob" || notification.AnalysisID != "a1" ||
+		notification.MoveNumber != 3 || notification.Move != "Qe7" || notification.Blunders != 2 {
+		t.Errorf("Unexpected notification: %+v", notification)
+	}
+	if !reflect.DeepEqual(notification.Refutation, []string{"Nf3"}) {
+		t.Errorf("Refutation = %v, want [Nf3]", notification.Refutation)
+	}
+	if want := "bob blundered on move 3 with 3... Qe7 (+1.50 to +4.20), refuted by Nf3. 2 blunders in the game"; notification.Message != want {
+		t.Errorf("Message = %q, want %q", notification.Message, want)
+	}
+}
+
+func TestSyncService_QueueBlunderCheck(t *testing.T) {
+	now := time.Date(2023, 11, 20, 12, 0, 0, 0, time.UTC)
+	ended := now.Add(-5 * time.Minute)
+	longAgo := now.Add(-3 * time.Hour)
+	game := func(end time.Time) *models.GameInfo {
+		return &models.GameInfo{PGN: "1. e4 e5 *", Rules: "chess", EndTime: &end,
+			WhitePlayer: models.Player{Username: "alice"}, BlackPlayer: models.Player{Username: "bob"}}
+	}
+
+	sync := NewSyncService(nil, nil, storage.NewMemoryStore(), nil, 30*time.Minute, false)
+	sync.now = func() time.Time { return now }
+	if sync.queueBlunderCheck("alice", game(ended), false) {
+		t.Error("Expected no blunder checks while they are disabled")
+	}
+
+	if err := sync.SetNotificationOptions(NotificationOptions{BlunderCheck: true}); err != nil {
+		t.Fatalf("SetNotificationOptions() error = %v", err)
+	}
+	if !sync.queueBlunderCheck("alice", game(ended), false) {
+		t.Error("Expected a just-finished game to be checked")
+	}
+	if sync.queueBlunderCheck("alice", game(ended), true) {
+		t.Error("Expected games of a first sync to be skipped")
+	}
+	if sync.queueBlunderCheck("alice", game(longAgo), false) {
+		t.Error("Expected a game that ended hours ago to be skipped")
+	}
+	if sync.queueBlunderCheck("carol", game(ended), false) {
+		t.Error("Expected games the player didn't play to be skipped")
+	}
+	if len(sync.blunderQueue) != 1 {
+		t.Errorf("Queued %d blunder checks, want 1", len(sync.blunderQueue))
+	}
+}
+
+func TestNotificationMessage(t *testing.T) {
+	notification := models.PlayerNotification{Type: models.NotificationBlunderCheck, Message: "bob blundered", GameURL: "https://www.chess.com/game/live/9"}
+
+	message := notificationMessage(notification)
+	if message.Text != "bob blundered\nhttps://www.chess.com/game/live/9" {
+		t.Errorf("Text = %q", message.Text)
+	}
+	body, err := json.Marshal(message.Payload)
+	if err != nil || !strings.Contains(string(body), `"type":"blunder_check"`) {
+		t.Errorf("Expected the notification itself as the webhook payload, got %s", body)
+	}
+
+	options := NotificationOptions{WebhookURL: "https://discord.com/api/webhooks/123/token"}
+	if channels := options.channels(); len(channels) != 1 || channels[0].Type != models.DeliveryDiscord {
+		t.Errorf("Expected a Discord channel for a Discord webhook URL, got %+v", channels)
+	}
+}
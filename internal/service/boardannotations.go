@@ -0,0 +1,119 @@
+package service
+
+import (
+	"math"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/board"
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+)
+
+// isBoardMoment reports whether a move gets board annotations: errors, misses and moves after
+// which a forced mate is on the board
+func isBoardMoment(move *models.MoveAnalysis) bool {
+	return move.Blunder || move.Mistake || move.Miss || math.Abs(move.Evaluation) >= mateEvaluation
+}
+
+// annotateBoard returns the arrows and highlights a board UI draws for a key moment, so frontends
+// don't have to derive them from the engine's lines. beforeFEN is the position the move was
+// played in and bestMove the engine's best move there in UCI, empty when unknown. Other moves,
+// and moves whose position can't be set up, get none.
+func annotateBoard(beforeFEN string, move *models.MoveAnalysis, bestMove string) *models.BoardAnnotations {
+	if beforeFEN == "" || !isBoardMoment(move) {
+		return nil
+	}
+	b, err := board.FromFEN(beforeFEN)
+	if err != nil {
+		return nil
+	}
+	played, err := b.ParseSAN(move.Move)
+	if err != nil {
+		return nil
+	}
+	mover := b.Turn()
+
+	annotations := &models.BoardAnnotations{
+		Arrows:     []models.BoardArrow{boardArrow(played, models.ArrowPlayed)},
+		Highlights: []models.SquareHighlight{},
+	}
+	if bestMove != "" && bestMove != played.UCI() {
+		if best, err := b.ParseUCI(bestMove); err == nil {
+			annotations.Arrows = append(annotations.Arrows, boardArrow(best, models.ArrowBest))
+		}
+	}
+
+	after := *b
+	after.Apply(played)
+	if move.BestMove != "" {
+		if reply, err := after.ParseUCI(move.BestMove); err == nil {
+			annotations.Arrows = append(annotations.Arrows, boardArrow(reply, models.ArrowThreat))
+		}
+	}
+	for _, square := range threatenedPieces(&after, mover) {
+		annotations.Highlights = append(annotations.Highlights,
+			models.SquareHighlight{Square: square.String(), Kind: models.HighlightThreatened})
+	}
+
+	// A forced mate is against Black when White's evaluation is a mate score, and vice versa
+	mated := board.White
+	if move.Evaluation > 0 {
+		mated = board.Black
+	}
+	if king := after.KingSquare(mated); math.Abs(move.Evaluation) >= mateEvaluation && king != board.NoSquare {
+		annotations.Highlights = append(annotations.Highlights,
+			models.SquareHighlight{Square: king.String(), Kind: models.HighlightMatedKing})
+		for _, square := range matingNet(&after, mated) {
+			annotations.Highlights = append(annotations.Highlights,
+				models.SquareHighlight{Square: square.String(), Kind: models.HighlightMatingNet})
+		}
+	}
+	return annotations
+}
+
+// boardArrow draws a move
+func boardArrow(m board.Move, kind string) models.BoardArrow {
+	return models.BoardArrow{From: m.From.String(), To: m.To.String(), Kind: kind}
+}
+
+// threatenedPieces returns the squares of side's pieces worth at least a minor piece that the
+// opponent attacks and that are undefended or attacked by something cheaper
+func threatenedPieces(b *board.Board, side board.Color) []board.Square {
+	// The cheapest opponent piece attacking each square
+	cheapest := make(map[board.Square]int)
+	for s := board.Square(0); s < 64; s++ {
+		attacker := b.PieceAt(s)
+		if attacker.IsEmpty() || attacker.Color == side {
+			continue
+		}
+		for _, target := range b.Attacks(s) {
+			if value, ok := cheapest[target]; !ok || motifPieceValues[attacker.Type] < value {
+				cheapest[target] = motifPieceValues[attacker.Type]
+			}
+		}
+	}
+
+	var threatened []board.Square
+	for s := board.Square(0); s < 64; s++ {
+		piece := b.PieceAt(s)
+		if piece.IsEmpty() || piece.Color != side || piece.Type == board.King || motifPieceValues[piece.Type] < minMotifPiece {
+			continue
+		}
+		attacker, attacked := cheapest[s]
+		if attacked && (attacker < motifPieceValues[piece.Type] || !b.IsAttacked(s, side)) {
+			threatened = append(threatened, s)
+		}
+	}
+	return threatened
+}
+
+// matingNet returns the squares next to side's king that it can't move to: covered by the
+// opponent or blocked by its own pieces
+func matingNet(b *board.Board, side board.Color) []board.Square {
+	king := b.KingSquare(side)
+	var net []board.Square
+	for _, s := range b.Attacks(king) {
+		if piece := b.PieceAt(s); (!piece.IsEmpty() && piece.Color == side) || b.IsAttacked(s, side.Opponent()) {
+			net = append(net, s)
+		}
+	}
+	return net
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+)
+
+func TestAnnotateBoard(t *testing.T) {
+	tests := []struct {
+		name       string
+		beforeFEN  string
+		move       models.MoveAnalysis
+		bestMove   string
+		arrows     []models.BoardArrow
+		highlights []models.SquareHighlight
+	}{
+		{
+			name:      "allows mate",
+			beforeFEN: "r1bqkbnr/pppp1ppp/2n5/4p2Q/2B1P3/8/PPPP1PPP/RNB1K1NR b KQkq - 3 3",
+			move:      models.MoveAnalysis{Move: "Nf6", Blunder: true, Evaluation: 1000, BestMove: "h5f7"},
+			bestMove:  "g7g6",
+			arrows: []models.BoardArrow{
+				{From: "g8", To: "f6", Kind: models.ArrowPlayed},
+				{From: "g7", To: "g6", Kind: models.ArrowBest},
+				{From: "h5", To: "f7", Kind: models.ArrowThreat},
+			},
+			highlights: []models.SquareHighlight{
+				{Square: "e8", Kind: models.HighlightMatedKing},
+				// e7 is still free; the queen covers it only once it takes on f7
+				{Square: "f8", Kind: models.HighlightMatingNet},
+				{Square: "d8", Kind: models.HighlightMatingNet},
+				{Square: "d7", Kind: models.HighlightMatingNet},
+				{Square: "f7", Kind: models.HighlightMatingNet},
+			},
+		},
+		{
+			name:      "hangs a bishop",
+			beforeFEN: "rnbqkbnr/pppp1ppp/4p3/8/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 0 2",
+			move:      models.MoveAnalysis{Move: "Ba6", Mistake: true, Evaluation: -2.8, BestMove: "b7a6"},
+			arrows: []models.BoardArrow{
+				{From: "f1", To: "a6", Kind: models.ArrowPlayed},
+				{From: "b7", To: "a6", Kind: models.ArrowThreat},
+			},
+			highlights: []models.SquareHighlight{{Square: "a6", Kind: models.HighlightThreatened}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			annotations := annotateBoard(tt.beforeFEN, &tt.move, tt.bestMove)
+			if annotations == nil {
+				t.Fatal("Expected board annotations")
+			}
+			if !reflect.DeepEqual(annotations.Arrows, tt.arrows) {
+				t.Errorf("Arrows = %+v, want %+v", annotations.Arrows, tt.arrows)
+			}
+			if !reflect.DeepEqual(annotations.Highlights, tt.highlights) {
+				t.Errorf("Highlights = %+v, want %+v", annotations.Highlights, tt.highlights)
+			}
+		})
+	}
+
+	// Moves that aren't key moments aren't drawn
+	quiet := models.MoveAnalysis{Move: "e5", Evaluation: 0.3}
+	if annotations := annotateBoard("rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1", &quiet, "e7e5"); annotations != nil {
+		t.Errorf("Expected no annotations for a quiet move, got %+v", annotations)
+	}
+}
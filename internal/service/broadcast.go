@@ -0,0 +1,448 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/internal/parser"
+	"github.com/pedrampdd/ChessAnalyser/internal/storage"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+// Broadcast settings
+const (
+	defaultBroadcastPollInterval = 10 // Seconds
+	maxActiveBroadcasts          = 5
+	maxBroadcastBoards           = 64
+	maxBroadcastPGNSize          = 8 << 20
+	broadcastFetchTimeout        = 30 * time.Second
+	broadcastRetention           = time.Hour // Ended broadcasts are kept this long for clients to read
+)
+
+// lichessBroadcastPGNURL is the Lichess API export of a broadcast round's PGN. Relays are only
+// fetched from there, so a user-supplied URL can't make the server reach other hosts.
+const lichessBroadcastPGNURL = "https://lichess.org/api/broadcast/round/%s.pgn"
+
+// lichessBroadcastRegex extracts the round ID from Lichess broadcast pages such as
+// /broadcast/{tour}/{round}/{roundID}, optionally followed by a game ID
+var lichessBroadcastRegex = regexp.MustCompile(`^https?://(?:www\.)?lichess\.org/broadcast/[^/]+/[^/]+/([A-Za-z0-9]{8})(?:/[A-Za-z0-9]{8})?/?(?:[?#].*)?$`)
+
+// BroadcastService follows PGN relays of live events, analyzes every board's new moves as they
+// appear and streams evaluation updates per board
+type BroadcastService struct {
+	analyze    func(ctx context.Context, fen string, settings models.EngineSettings) (*models.AnalysisResult, error)
+	httpClient *http.Client
+	mu         sync.Mutex
+	broadcasts map[string]*broadcastRelay
+	now        func() time.Time
+}
+
+// broadcastRelay is a followed broadcast and its subscribers
+type broadcastRelay struct {
+	session     models.BroadcastSession
+	settings    models.EngineSettings
+	failures    int
+	endedAt     time.Time // When the broadcast stopped polling (zero while following)
+	cancel      context.CancelFunc
+	subscribers map[chan models.BroadcastUpdate]int // Board each subscriber follows, 0 for all
+}
+
+// NewBroadcastService creates a new broadcast relay service
+func NewBroadcastService(analysisService *AnalysisService) *BroadcastService {
+	return &BroadcastService{
+		analyze:    analysisService.AnalyzePosition,
+		httpClient: &http.Client{Timeout: broadcastFetchTimeout},
+		broadcasts: make(map[string]*broadcastRelay),
+		now:        time.Now,
+	}
+}
+
+// StartBroadcast starts polling a relay in the background and returns the new session
+func (s *BroadcastService) StartBroadcast(request *models.BroadcastRequest) (*models.BroadcastSession, error) {
+	pgnURL, err := broadcastPGNURL(request.URL)
+	if err != nil {
+		return nil, err
+	}
+	if request.PollInterval == 0 {
+		request.PollInterval = defaultBroadcastPollInterval
+	}
+	if request.PollInterval < minWatchPollInterval {
+		return nil, errors.NewValidationError("poll_interval", fmt.Sprintf("must be at least %d seconds", minWatchPollInterval))
+	}
+	if request.Settings.Depth <= 0 {
+		request.Settings.Depth = defaultWatchDepth
+	}
+	request.Settings.MultiPV = 1
+
+	id, err := storage.NewID()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pruneBroadcasts()
+	active := 0
+	for _, b := range s.broadcasts {
+		if b.session.Status == models.WatchStatusWatching {
+			active++
+		}
+	}
+	if active >= maxActiveBroadcasts {
+		return nil, errors.NewCapacityError("broadcasts followed at once", maxActiveBroadcasts)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	b := &broadcastRelay{
+		session: models.BroadcastSession{
+			ID:           id,
+			URL:          request.URL,
+			PGNURL:       pgnURL,
+			Status:       models.WatchStatusWatching,
+			PollInterval: request.PollInterval,
+			StartedAt:    time.Now(),
+			LastMoveAt:   time.Now(),
+		},
+		settings:    request.Settings,
+		cancel:      cancel,
+		subscribers: make(map[chan models.BroadcastUpdate]int),
+	}
+	s.broadcasts[id] = b
+
+	go s.run(ctx, b)
+
+	return copyBroadcastSession(&b.session), nil
+}
+
+// run polls the relay until every game ends, polling fails or the broadcast is stopped
+func (s *BroadcastService) run(ctx context.Context, b *broadcastRelay) {
+	ticker := time.NewTicker(time.Duration(b.session.PollInterval) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		if status := s.poll(ctx, b); status != models.WatchStatusWatching {
+			s.finish(b, status)
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll fetches the round's PGN, analyzes moves not seen before and returns the broadcast status
+func (s *BroadcastService) poll(ctx context.Context, b *broadcastRelay) string {
+	finished, err := s.analyzeNewMoves(ctx, b)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b.session.LastPoll = time.Now()
+	if err != nil {
+		if ctx.Err() != nil {
+			return models.WatchStatusStopped
+		}
+		b.failures++
+		b.session.LastError = err.Error()
+		if b.failures >= maxWatchPollFailures {
+			return models.WatchStatusFailed
+		}
+		return models.WatchStatusWatching
+	}
+
+	b.failures = 0
+	b.session.LastError = ""
+	switch {
+	case finished:
+		return models.WatchStatusFinished
+	case time.Since(b.session.LastMoveAt) > maxWatchIdle:
+		return models.WatchStatusStopped
+	}
+	return models.WatchStatusWatching
+}
+
+// analyzeNewMoves evaluates the positions of every board that have no update yet and reports
+// whether all games of the round are over
+func (s *BroadcastService) analyzeNewMoves(ctx context.Context, b *broadcastRelay) (bool, error) {
+	games, err := s.fetchGames(ctx, b.session.PGNURL)
+	if err != nil {
+		return false, err
+	}
+	if len(games) == 0 {
+		return false, nil
+	}
+
+	finished := true
+	for i, game := range games {
+		if game == nil {
+			finished = false
+			continue
+		}
+		board := i + 1
+		next := s.syncBoard(b, board, game)
+		for ply := next; ply < len(game.Moves); ply++ {
+			move := game.Moves[ply]
+			result, err := s.analyze(ctx, move.FEN, b.settings)
+			if err != nil {
+				return false, err
+			}
+			s.publish(b, board, move, ply+1, result)
+		}
+		if game.Result == "" || game.Result == "*" {
+			finished = false
+		}
+	}
+	return finished, nil
+}
+
+// fetchGames downloads the round's PGN and parses each board's game; unreadable games are nil
+func (s *BroadcastService) fetchGames(ctx context.Context, pgnURL string) ([]*parser.ParsedGame, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pgnURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.NewAPIError("failed to fetch broadcast", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.NewAPIError("failed to fetch broadcast", fmt.Errorf("status %d", resp.StatusCode))
+	}
+
+	pgnParser := parser.NewPGNParser()
+	var games []*parser.ParsedGame
+	err = parser.SplitGames(io.LimitReader(resp.Body, maxBroadcastPGNSize), func(_ int64, pgn string) error {
+		if len(games) >= maxBroadcastBoards {
+			return nil
+		}
+		game, err := pgnParser.ParsePGN(pgn)
+		if err == nil {
+			err = pgnParser.ExtractPositions(game)
+		}
+		if err != nil {
+			// Keep board numbers stable; an unreadable game is skipped until the relay fixes it
+			game = nil
+		}
+		games = append(games, game)
+		return nil
+	})
+	if err != nil {
+		return nil, errors.NewAPIError("failed to read broadcast", err)
+	}
+	return games, nil
+}
+
+// syncBoard updates a board's players and result and returns the index of the first move that
+// needs evaluating. Updates for moves the relay has since taken back are dropped.
+func (s *BroadcastService) syncBoard(b *broadcastRelay, board int, game *parser.ParsedGame) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for len(b.session.Boards) < board {
+		b.session.Boards = append(b.session.Boards, models.BroadcastBoard{Board: len(b.session.Boards) + 1, Result: "*", Updates: []models.EvalUpdate{}})
+	}
+	entry := &b.session.Boards[board-1]
+	entry.White = game.Headers["white"]
+	entry.Black = game.Headers["black"]
+	entry.Result = game.Result
+	if entry.Result == "" {
+		entry.Result = "*"
+	}
+
+	kept := 0
+	for kept < len(entry.Updates) && kept < len(game.Moves) && entry.Updates[kept].FEN == game.Moves[kept].FEN {
+		kept++
+	}
+	if kept < len(entry.Updates) {
+		entry.Updates = entry.Updates[:kept]
+		entry.Ply = kept
+		entry.Evaluation, entry.BestMove = 0, ""
+		if kept > 0 {
+			entry.Evaluation, entry.BestMove = entry.Updates[kept-1].Evaluation, entry.Updates[kept-1].BestMove
+		}
+	}
+	return kept
+}
+
+// publish records a board's evaluation update and sends it to its subscribers
+func (s *BroadcastService) publish(b *broadcastRelay, board int, move parser.ParsedMove, ply int, result *models.AnalysisResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := &b.session.Boards[board-1]
+	previous := 0.0
+	if n := len(entry.Updates); n > 0 {
+		previous = entry.Updates[n-1].Evaluation
+	}
+	update := models.EvalUpdate{
+		Ply:        ply,
+		Move:       move.Move,
+		FEN:        move.FEN,
+		Evaluation: result.Evaluation,
+		BestMove:   result.BestMove,
+		Depth:      result.Depth,
+		Swing:      math.Max(-watchEvalCap, math.Min(watchEvalCap, result.Evaluation)) - math.Max(-watchEvalCap, math.Min(watchEvalCap, previous)),
+		Time:       time.Now(),
+	}
+
+	entry.Updates = append(entry.Updates, update)
+	entry.Ply = ply
+	entry.Evaluation = update.Evaluation
+	entry.BestMove = update.BestMove
+	b.session.LastMoveAt = update.Time
+
+	for ch, follows := range b.subscribers {
+		if follows != 0 && follows != board {
+			continue
+		}
+		select {
+		case ch <- models.BroadcastUpdate{Board: board, EvalUpdate: update}:
+		default:
+			// Slow subscribers miss updates rather than stalling the relay
+		}
+	}
+}
+
+// finish sets the final status of a broadcast and closes its subscriptions
+func (s *BroadcastService) finish(b *broadcastRelay, status string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if b.session.Status != models.WatchStatusWatching {
+		return
+	}
+	b.session.Status = status
+	b.endedAt = s.now()
+	b.cancel()
+	for ch := range b.subscribers {
+		close(ch)
+		delete(b.subscribers, ch)
+	}
+}
+
+// pruneBroadcasts forgets broadcasts that ended longer ago than the retention period. The caller
+// must hold s.mu.
+func (s *BroadcastService) pruneBroadcasts() {
+	for id, b := range s.broadcasts {
+		if !b.endedAt.IsZero() && s.now().Sub(b.endedAt) > broadcastRetention {
+			delete(s.broadcasts, id)
+		}
+	}
+}
+
+// GetBroadcast returns a broadcast session with every board's updates so far
+func (s *BroadcastService) GetBroadcast(id string) (*models.BroadcastSession, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pruneBroadcasts()
+	b, ok := s.broadcasts[id]
+	if !ok {
+		return nil, errors.NewBroadcastNotFoundError(id)
+	}
+	return copyBroadcastSession(&b.session), nil
+}
+
+// StopBroadcast stops polling a relay; its updates stay available
+func (s *BroadcastService) StopBroadcast(id string) (*models.BroadcastSession, error) {
+	s.mu.Lock()
+	b, ok := s.broadcasts[id]
+	s.mu.Unlock()
+	if !ok {
+		return nil, errors.NewBroadcastNotFoundError(id)
+	}
+
+	s.finish(b, models.WatchStatusStopped)
+	return s.GetBroadcast(id)
+}
+
+// Subscribe returns the updates of a board published so far and a channel receiving its new
+// ones, or those of every board when board is 0. The channel is closed when the broadcast ends;
+// call the returned function to unsubscribe early.
+func (s *BroadcastService) Subscribe(id string, board int) ([]models.BroadcastUpdate, <-chan models.BroadcastUpdate, func(), error) {
+	if board < 0 {
+		return nil, nil, nil, errors.NewValidationError("board", "must be a positive board number")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.broadcasts[id]
+	if !ok {
+		return nil, nil, nil, errors.NewBroadcastNotFoundError(id)
+	}
+
+	var backlog []models.BroadcastUpdate
+	for _, entry := range b.session.Boards {
+		if board != 0 && entry.Board != board {
+			continue
+		}
+		for _, update := range entry.Updates {
+			backlog = append(backlog, models.BroadcastUpdate{Board: entry.Board, EvalUpdate: update})
+		}
+	}
+
+	ch := make(chan models.BroadcastUpdate, watchSubscriberBuffer)
+	if b.session.Status != models.WatchStatusWatching {
+		close(ch)
+		return backlog, ch, func() {}, nil
+	}
+
+	b.subscribers[ch] = board
+	unsubscribe := func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if _, ok := b.subscribers[ch]; ok {
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+	return backlog, ch, unsubscribe, nil
+}
+
+// Close stops all broadcasts
+func (s *BroadcastService) Close() {
+	s.mu.Lock()
+	broadcasts := make([]*broadcastRelay, 0, len(s.broadcasts))
+	for _, b := range s.broadcasts {
+		broadcasts = append(broadcasts, b)
+	}
+	s.mu.Unlock()
+
+	for _, b := range broadcasts {
+		s.finish(b, models.WatchStatusStopped)
+	}
+}
+
+// broadcastPGNURL returns where a relay's PGN is polled from: the Lichess API export of a
+// broadcast round page
+func broadcastPGNURL(relayURL string) (string, error) {
+	matches := lichessBroadcastRegex.FindStringSubmatch(relayURL)
+	if matches == nil {
+		return "", errors.NewValidationError("url", "must be a Lichess broadcast round URL")
+	}
+	return fmt.Sprintf(lichessBroadcastPGNURL, matches[1]), nil
+}
+
+// copyBroadcastSession copies a session so callers can't race with the poller
+func copyBroadcastSession(session *models.BroadcastSession) *models.BroadcastSession {
+	copied := *session
+	copied.Boards = make([]models.BroadcastBoard, len(session.Boards))
+	for i, board := range session.Boards {
+		board.Updates = append([]models.EvalUpdate{}, board.Updates...)
+		copied.Boards[i] = board
+	}
+	return &copied
+}
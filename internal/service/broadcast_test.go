@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+)
+
+func TestBroadcastPGNURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		want    string
+		wantErr bool
+	}{
+		{"https://lichess.org/broadcast/tata-steel-2024/round-1/AbCd1234", "https://lichess.org/api/broadcast/round/AbCd1234.pgn", false},
+		{"https://lichess.org/broadcast/tata-steel-2024/round-1/AbCd1234/Xy12Zw34", "https://lichess.org/api/broadcast/round/AbCd1234.pgn", false},
+		{"https://lichess.org/broadcast/tata-steel-2024", "", true},
+		{"https://example.com/live/round1.pgn", "", true},
+		{"http://169.254.169.254/latest/meta-data", "", true},
+		{"https://lichess.org.example.com/broadcast/tata-steel-2024/round-1/AbCd1234", "", true},
+		{"round1.pgn", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := broadcastPGNURL(tt.url)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("broadcastPGNURL(%q) = %q, %v, want %q, error %v", tt.url, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestBroadcastService_Poll(t *testing.T) {
+	board1, result1 := "1. e4 e5 2. Nf3", "*"
+	board2 := "1. d4 d5"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "[White \"Carlsen\"]\n[Black \"Nakamura\"]\n[Result \"%s\"]\n\n%s %s\n\n", result1, board1, result1)
+		fmt.Fprintf(w, "[White \"Caruana\"]\n[Black \"Ding\"]\n[Result \"1/2-1/2\"]\n\n%s 1/2-1/2\n", board2)
+	}))
+	defer server.Close()
+
+	broadcasts := NewBroadcastService(nil)
+	analyzed := 0
+	broadcasts.analyze = func(ctx context.Context, fen string, settings models.EngineSettings) (*models.AnalysisResult, error) {
+		analyzed++
+		return &models.AnalysisResult{Evaluation: float64(analyzed) / 10, BestMove: "a2a3", Depth: settings.Depth}, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	b := &broadcastRelay{
+		session:     models.BroadcastSession{ID: "b1", PGNURL: server.URL, Status: models.WatchStatusWatching, LastMoveAt: time.Now()},
+		cancel:      cancel,
+		subscribers: make(map[chan models.BroadcastUpdate]int),
+	}
+	broadcasts.broadcasts["b1"] = b
+
+	if status := broadcasts.poll(ctx, b); status != models.WatchStatusWatching {
+		t.Fatalf("First poll status = %s, LastError = %s", status, b.session.LastError)
+	}
+	session, _ := broadcasts.GetBroadcast("b1")
+	if len(session.Boards) != 2 || len(session.Boards[0].Updates) != 3 || len(session.Boards[1].Updates) != 2 {
+		t.Fatalf("Unexpected boards after first poll: %+v", session.Boards)
+	}
+	if first := session.Boards[0]; first.White != "Carlsen" || first.Ply != 3 || first.Evaluation != 0.3 || first.Result != "*" {
+		t.Errorf("Unexpected first board: %+v", first)
+	}
+
+	backlog, updates, unsubscribe, err := broadcasts.Subscribe("b1", 1)
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	defer unsubscribe()
+	if len(backlog) != 3 || backlog[2].Board != 1 || backlog[2].Move != "Nf3" {
+		t.Errorf("Unexpected backlog: %+v", backlog)
+	}
+
+	// The relay takes back 2. Nf3, plays 2. Bc4 instead and the game ends
+	board1, result1 = "1. e4 e5 2. Bc4 Nc6", "1-0"
+	if status := broadcasts.poll(ctx, b); status != models.WatchStatusFinished {
+		t.Fatalf("Second poll status = %s, want finished", status)
+	}
+	if analyzed != 7 {
+		t.Errorf("Analyzed %d positions, want 7", analyzed)
+	}
+	session, _ = broadcasts.GetBroadcast("b1")
+	if moves := session.Boards[0].Updates; len(moves) != 4 || moves[2].Move != "Bc4" || session.Boards[0].Result != "1-0" {
+		t.Errorf("Unexpected first board after the takeback: %+v", session.Boards[0])
+	}
+
+	for _, want := range []string{"Bc4", "Nc6"} {
+		select {
+		case update := <-updates:
+			if update.Board != 1 || update.Move != want {
+				t.Errorf("Streamed update = board %d %s, want board 1 %s", update.Board, update.Move, want)
+			}
+		default:
+			t.Fatalf("Expected a streamed update for %s", want)
+		}
+	}
+}
+
+func TestBroadcastService_PrunesEndedBroadcasts(t *testing.T) {
+	now := time.Now()
+	broadcasts := NewBroadcastService(nil)
+	broadcasts.now = func() time.Time { return now }
+
+	for _, id := range []string{"ended", "recent", "following"} {
+		_, cancel := context.WithCancel(context.Background())
+		broadcasts.broadcasts[id] = &broadcastRelay{
+			session:     models.BroadcastSession{ID: id, Status: models.WatchStatusWatching},
+			cancel:      cancel,
+			subscribers: make(map[chan models.BroadcastUpdate]int),
+		}
+	}
+	broadcasts.finish(broadcasts.broadcasts["ended"], models.WatchStatusFinished)
+	now = now.Add(broadcastRetention / 2)
+	broadcasts.finish(broadcasts.broadcasts["recent"], models.WatchStatusStopped)
+	now = now.Add(broadcastRetention)
+
+	if _, err := broadcasts.GetBroadcast("ended"); err == nil {
+		t.Error("Expected a broadcast ended longer ago than the retention period to be forgotten")
+	}
+	for _, id := range []string{"recent", "following"} {
+		if _, err := broadcasts.GetBroadcast(id); err != nil {
+			t.Errorf("GetBroadcast(%q) error = %v", id, err)
+		}
+	}
+}
@@ -0,0 +1,426 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/board"
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/internal/parser"
+	"github.com/pedrampdd/ChessAnalyser/internal/storage"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+// Collection settings
+const (
+	maxCollectionsPerUser = 100
+	maxCollectionItems    = 500 // Analyses and games together
+	maxCollectionTags     = 20
+	maxCollectionName     = 100
+)
+
+// CollectionService groups a user's stored analyses and synced games into named collections
+// and aggregates reports over them
+type CollectionService struct {
+	getAnalysis func(id string) (*models.GameAnalysis, error)
+	findGame    func(url string) (*models.GameInfo, bool)
+	pgnParser   *parser.PGNParser
+	now         func() time.Time
+	mu          sync.Mutex
+	users       map[string]map[string]*models.Collection // Collections by user, keyed by ID
+}
+
+// NewCollectionService creates a new collection service. Games are looked up among the ones
+// synced into store.
+func NewCollectionService(analysisService *AnalysisService, store *storage.MemoryStore) *CollectionService {
+	return &CollectionService{
+		getAnalysis: analysisService.GetAnalysis,
+		findGame:    store.FindGame,
+		pgnParser:   parser.NewPGNParser(),
+		now:         time.Now,
+		users:       make(map[string]map[string]*models.Collection),
+	}
+}
+
+// Create adds an empty collection for the user
+func (s *CollectionService) Create(user string, request *models.CollectionRequest) (*models.Collection, error) {
+	if user == "" {
+		return nil, errors.NewValidationError("user", "X-API-Key header or user parameter is required")
+	}
+	if err := normalizeCollectionRequest(request); err != nil {
+		return nil, err
+	}
+
+	id, err := storage.NewID()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	collections := s.user(user)
+	if len(collections) >= maxCollectionsPerUser {
+		return nil, errors.NewStorageLimitError("collections stored", maxCollectionsPerUser)
+	}
+
+	now := s.now()
+	collection := &models.Collection{
+		ID:          id,
+		Name:        request.Name,
+		Description: request.Description,
+		Tags:        request.Tags,
+		AnalysisIDs: []string{},
+		GameURLs:    []string{},
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	collections[id] = collection
+	return copyCollection(collection), nil
+}
+
+// List returns a page of the user's collections, oldest first. A tag limits them to the ones
+// tagged with it.
+func (s *CollectionService) List(user, tag string, request models.PageRequest) (*models.Page[*models.Collection], error) {
+	if user == "" {
+		return nil, errors.NewValidationError("user", "X-API-Key header or user parameter is required")
+	}
+	tag = strings.ToLower(strings.TrimSpace(tag))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list := []*models.Collection{}
+	for _, collection := range s.users[user] {
+		if tag == "" || containsFold(collection.Tags, tag) {
+			list = append(list, copyCollection(collection))
+		}
+	}
+	return storage.Paginate(storage.ListCollections, list, func(collection *models.Collection) string {
+		return storage.TimeKey(collection.CreatedAt, collection.ID)
+	}, request)
+}
+
+// Get returns one of the user's collections
+func (s *CollectionService) Get(user, id string) (*models.Collection, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	collection, err := s.collection(user, id)
+	if err != nil {
+		return nil, err
+	}
+	return copyCollection(collection), nil
+}
+
+// Update renames a collection and replaces its description and tags, keeping its items
+func (s *CollectionService) Update(user, id string, request *models.CollectionRequest) (*models.Collection, error) {
+	if err := normalizeCollectionRequest(request); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	collection, err := s.collection(user, id)
+	if err != nil {
+		return nil, err
+	}
+	collection.Name = request.Name
+	collection.Description = request.Description
+	collection.Tags = request.Tags
+	collection.UpdatedAt = s.now()
+	return copyCollection(collection), nil
+}
+
+// Delete removes a collection. The analyses and games in it stay stored.
+func (s *CollectionService) Delete(user, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.collection(user, id); err != nil {
+		return err
+	}
+	delete(s.users[user], id)
+	return nil
+}
+
+// AddItems adds stored analyses and synced games to a collection. Items already in it are skipped.
+func (s *CollectionService) AddItems(user, id string, request *models.CollectionItemsRequest) (*models.Collection, error) {
+	// Check the items exist before taking the lock; lookups don't touch collections
+	for _, analysisID := range request.AnalysisIDs {
+		if _, err := s.getAnalysis(analysisID); err != nil {
+			return nil, errors.NewValidationError("analysis_ids", fmt.Sprintf("analysis %s not found", analysisID))
+		}
+	}
+	for _, url := range request.GameURLs {
+		if _, ok := s.findGame(url); !ok {
+			return nil, errors.NewValidationError("game_urls", fmt.Sprintf("game %s has not been synced", url))
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	collection, err := s.collection(user, id)
+	if err != nil {
+		return nil, err
+	}
+
+	analysisIDs := appendMissing(collection.AnalysisIDs, request.AnalysisIDs)
+	gameURLs := appendMissing(collection.GameURLs, request.GameURLs)
+	if len(analysisIDs)+len(gameURLs) > maxCollectionItems {
+		return nil, errors.NewValidationError("analysis_ids", fmt.Sprintf("a collection holds at most %d items", maxCollectionItems))
+	}
+
+	collection.AnalysisIDs = analysisIDs
+	collection.GameURLs = gameURLs
+	collection.UpdatedAt = s.now()
+	return copyCollection(collection), nil
+}
+
+// RemoveItems removes analyses and games from a collection. Items not in it are ignored.
+func (s *CollectionService) RemoveItems(user, id string, request *models.CollectionItemsRequest) (*models.Collection, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	collection, err := s.collection(user, id)
+	if err != nil {
+		return nil, err
+	}
+	collection.AnalysisIDs = removeAll(collection.AnalysisIDs, request.AnalysisIDs)
+	collection.GameURLs = removeAll(collection.GameURLs, request.GameURLs)
+	collection.UpdatedAt = s.now()
+	return copyCollection(collection), nil
+}
+
+// Report aggregates the results, accuracy, errors and openings of a collection's games, from the
+// player's point of view when one is given. Items deleted since they were added are listed as missing.
+func (s *CollectionService) Report(user, id, player string) (*models.CollectionReport, error) {
+	collection, err := s.Get(user, id)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.CollectionReport{
+		CollectionID: collection.ID,
+		Name:         collection.Name,
+		Player:       player,
+		Openings:     []models.OpeningStat{},
+		GeneratedAt:  s.now(),
+	}
+	agg := newCollectionAggregate()
+
+	for _, analysisID := range collection.AnalysisIDs {
+		analysis, err := s.getAnalysis(analysisID)
+		if err != nil {
+			report.Missing = append(report.Missing, analysisID)
+			continue
+		}
+		s.addGame(report, agg, analysis.PGN, analysis)
+	}
+	for _, url := range collection.GameURLs {
+		game, ok := s.findGame(url)
+		if !ok {
+			report.Missing = append(report.Missing, url)
+			continue
+		}
+		s.addGame(report, agg, game.PGN, nil)
+	}
+
+	agg.fill(report)
+	return report, nil
+}
+
+// collectionAggregate sums accuracy per opening while a collection report is built
+type collectionAggregate struct {
+	accuracySum     float64
+	openingGames    map[string]int
+	openingAnalyzed map[string]int
+	openingAccuracy map[string]float64
+}
+
+func newCollectionAggregate() *collectionAggregate {
+	return &collectionAggregate{
+		openingGames:    make(map[string]int),
+		openingAnalyzed: make(map[string]int),
+		openingAccuracy: make(map[string]float64),
+	}
+}
+
+// addGame adds a game, and its analysis when it has one, to a report. Games the report's player
+// didn't play count toward the openings but not toward results, accuracy or errors.
+func (s *CollectionService) addGame(report *models.CollectionReport, agg *collectionAggregate, pgn string, analysis *models.GameAnalysis) {
+	headers := map[string]string{}
+	result := ""
+	if parsed, err := s.pgnParser.ParsePGN(pgn); err == nil {
+		headers, result = parsed.Headers, parsed.Result
+	}
+
+	opening := openingFromHeaders(headers)
+	report.Games++
+	agg.openingGames[opening]++
+
+	color, side := board.White, ""
+	if report.Player != "" {
+		var played bool
+		if color, played = headerColor(headers, report.Player); !played {
+			return
+		}
+		side = color.String()
+	}
+
+	if score, decided := playerScore(result, color); decided {
+		switch score {
+		case 1:
+			report.Wins++
+		case 0.5:
+			report.Draws++
+		default:
+			report.Losses++
+		}
+	}
+
+	if analysis == nil {
+		return
+	}
+	report.AnalyzedGames++
+
+	accuracy := (analysis.Accuracy.WhiteAccuracy + analysis.Accuracy.BlackAccuracy) / 2
+	switch side {
+	case "white":
+		accuracy = analysis.Accuracy.WhiteAccuracy
+	case "black":
+		accuracy = analysis.Accuracy.BlackAccuracy
+	}
+	agg.accuracySum += accuracy
+	agg.openingAnalyzed[opening]++
+	agg.openingAccuracy[opening] += accuracy
+
+	for _, move := range analysis.Moves {
+		if side != "" && moveColor(move.FEN, move.MoveNumber) != side {
+			continue
+		}
+		switch {
+		case move.Blunder:
+			report.Blunders++
+		case move.Mistake:
+			report.Mistakes++
+		case move.Inaccuracy:
+			report.Inaccuracies++
+		}
+	}
+}
+
+// fill computes the averages and the openings table, most played first
+func (agg *collectionAggregate) fill(report *models.CollectionReport) {
+	if report.AnalyzedGames > 0 {
+		report.AverageAccuracy = agg.accuracySum / float64(report.AnalyzedGames)
+	}
+
+	for name, games := range agg.openingGames {
+		stat := models.OpeningStat{
+			Name:  name,
+			Games: games,
+			Share: float64(games) / float64(report.Games) * 100,
+		}
+		if analyzed := agg.openingAnalyzed[name]; analyzed > 0 {
+			stat.AverageAccuracy = agg.openingAccuracy[name] / float64(analyzed)
+		}
+		report.Openings = append(report.Openings, stat)
+	}
+	sort.Slice(report.Openings, func(i, j int) bool {
+		if report.Openings[i].Games != report.Openings[j].Games {
+			return report.Openings[i].Games > report.Openings[j].Games
+		}
+		return report.Openings[i].Name < report.Openings[j].Name
+	})
+}
+
+// collection returns one of the user's collections. Callers hold s.mu.
+func (s *CollectionService) collection(user, id string) (*models.Collection, error) {
+	collection, ok := s.users[user][id]
+	if !ok {
+		return nil, errors.NewCollectionNotFoundError(id)
+	}
+	return collection, nil
+}
+
+// user returns a user's collections, creating them on first use. Callers hold s.mu.
+func (s *CollectionService) user(user string) map[string]*models.Collection {
+	collections, ok := s.users[user]
+	if !ok {
+		collections = make(map[string]*models.Collection)
+		s.users[user] = collections
+	}
+	return collections
+}
+
+// normalizeCollectionRequest trims the name and lowercases and deduplicates the tags
+func normalizeCollectionRequest(request *models.CollectionRequest) error {
+	request.Name = strings.TrimSpace(request.Name)
+	if request.Name == "" {
+		return errors.NewValidationError("name", "name is required")
+	}
+	if len(request.Name) > maxCollectionName {
+		return errors.NewValidationError("name", fmt.Sprintf("name must be at most %d characters", maxCollectionName))
+	}
+
+	tags := []string{}
+	for _, tag := range request.Tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !containsFold(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	if len(tags) > maxCollectionTags {
+		return errors.NewValidationError("tags", fmt.Sprintf("at most %d tags are allowed", maxCollectionTags))
+	}
+	request.Tags = tags
+	return nil
+}
+
+// copyCollection copies a collection so callers can't change the stored one
+func copyCollection(collection *models.Collection) *models.Collection {
+	copied := *collection
+	copied.Tags = append([]string{}, collection.Tags...)
+	copied.AnalysisIDs = append([]string{}, collection.AnalysisIDs...)
+	copied.GameURLs = append([]string{}, collection.GameURLs...)
+	return &copied
+}
+
+// appendMissing returns a copy of items with the values it doesn't contain yet appended
+func appendMissing(items, values []string) []string {
+	result := append([]string{}, items...)
+	for _, value := range values {
+		found := false
+		for _, item := range result {
+			if item == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+// removeAll returns the items that aren't among values
+func removeAll(items, values []string) []string {
+	remove := make(map[string]bool, len(values))
+	for _, value := range values {
+		remove[value] = true
+	}
+	result := []string{}
+	for _, item := range items {
+		if !remove[item] {
+			result = append(result, item)
+		}
+	}
+	return result
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/internal/storage"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+const collectionTestPGN = `[White "alice"]
+[Black "bob"]
+[Result "0-1"]
+[Opening "French Defense"]
+
+1. e4 e6 2. d4 d5 0-1`
+
+func TestCollectionService(t *testing.T) {
+	analyses := map[string]*models.GameAnalysis{
+		"analysis-1": {
+			ID:       "analysis-1",
+			PGN:      collectionTestPGN,
+			Accuracy: models.GameAccuracy{WhiteAccuracy: 60, BlackAccuracy: 90},
+			Moves: []models.MoveAnalysis{
+				{MoveNumber: 1, Move: "e4"},
+				{MoveNumber: 2, Move: "e6", Mistake: true},
+				{MoveNumber: 3, Move: "d4", Blunder: true},
+			},
+		},
+	}
+
+	store := storage.NewMemoryStore()
+	gameURL := "https://www.chess.com/game/live/1"
+	store.SaveGames("alice", []*models.GameInfo{{URL: gameURL, PGN: collectionTestPGN}})
+
+	service := NewCollectionService(newTestAnalysisService(), store)
+	service.getAnalysis = func(id string) (*models.GameAnalysis, error) {
+		if analysis, ok := analyses[id]; ok {
+			return analysis, nil
+		}
+		return nil, errors.NewAnalysisNotFoundError(id)
+	}
+
+	collection, err := service.Create("alice", &models.CollectionRequest{Name: " French losses ", Tags: []string{"French", "french", ""}})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if collection.Name != "French losses" || len(collection.Tags) != 1 || collection.Tags[0] != "french" {
+		t.Errorf("Unexpected collection: %+v", collection)
+	}
+
+	// Collections belong to the user that created them
+	if _, err := service.Get("bob", collection.ID); err == nil {
+		t.Error("Expected another user's collection not to be found")
+	}
+	if list, _ := service.List("alice", "FRENCH", models.PageRequest{}); len(list.Items) != 1 {
+		t.Errorf("Expected one collection tagged french, got %d", len(list.Items))
+	}
+
+	if _, err := service.AddItems("alice", collection.ID, &models.CollectionItemsRequest{AnalysisIDs: []string{"missing"}}); err == nil {
+		t.Error("Expected adding an unknown analysis to fail")
+	}
+	collection, err = service.AddItems("alice", collection.ID, &models.CollectionItemsRequest{
+		AnalysisIDs: []string{"analysis-1", "analysis-1"},
+		GameURLs:    []string{gameURL},
+	})
+	if err != nil {
+		t.Fatalf("AddItems() error = %v", err)
+	}
+	if len(collection.AnalysisIDs) != 1 || len(collection.GameURLs) != 1 {
+		t.Errorf("Expected one analysis and one game, got %+v", collection)
+	}
+
+	report, err := service.Report("alice", collection.ID, "Alice")
+	if err != nil {
+		t.Fatalf("Report() error = %v", err)
+	}
+	if report.Games != 2 || report.AnalyzedGames != 1 || report.Losses != 2 || report.AverageAccuracy != 60 ||
+		report.Blunders != 1 || report.Mistakes != 0 {
+		t.Errorf("Unexpected report for alice: %+v", report)
+	}
+	if len(report.Openings) != 1 || report.Openings[0].Name != "French Defense" || report.Openings[0].Share != 100 {
+		t.Errorf("Unexpected openings: %+v", report.Openings)
+	}
+
+	// Deleted analyses are reported as missing
+	delete(analyses, "analysis-1")
+	report, err = service.Report("alice", collection.ID, "")
+	if err != nil {
+		t.Fatalf("Report() error = %v", err)
+	}
+	if report.Games != 1 || len(report.Missing) != 1 || report.Missing[0] != "analysis-1" || report.Losses != 1 {
+		t.Errorf("Unexpected report after deleting the analysis: %+v", report)
+	}
+
+	collection, err = service.RemoveItems("alice", collection.ID, &models.CollectionItemsRequest{GameURLs: []string{gameURL}})
+	if err != nil || len(collection.GameURLs) != 0 {
+		t.Errorf("RemoveItems() = %+v, %v", collection, err)
+	}
+
+	if err := service.Delete("alice", collection.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	var notFound *errors.CollectionNotFoundError
+	if _, err := service.Get("alice", collection.ID); !errors.As(err, &notFound) {
+		t.Errorf("Get() after Delete() error = %v, want CollectionNotFoundError", err)
+	}
+}
@@ -0,0 +1,297 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/board"
+	"github.com/pedrampdd/ChessAnalyser/internal/i18n"
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+// Motif detection thresholds
+const (
+	hangingLoss     = 1.0 // Pawns a move must lose for a piece left en prise to count as hanging
+	mateEvaluation  = 900 // Evaluations from here on are forced mates, mapped to ±1000
+	minMotifPiece   = 3   // Least value of a piece worth calling sacrificed or hanging
+	forkTargetCount = 2
+)
+
+// motifPieceValues are the material values used to judge forks, sacrifices and hanging pieces
+var motifPieceValues = [...]int{board.Pawn: 1, board.Knight: 3, board.Bishop: 3, board.Rook: 5, board.Queen: 9, board.King: 100}
+
+// commentaryTone holds a tone's phrasing. Every text takes the move label first.
+type commentaryTone struct {
+	moments    map[string]string // Opening sentence per key moment type
+	bestMove   string            // Names the engine's move when the played one went wrong; takes it as %s
+	evaluation string            // Describes the resulting position; takes the description as %s
+}
+
+// commentaryTones are the supported tones
+var commentaryTones = map[string]commentaryTone{
+	models.CommentaryToneCoach: {
+		moments: map[string]string{
+			models.KeyMomentBestMove:     "%s is exactly what the engine wanted. Finding moves like this over the board is a skill worth practising.",
+			models.KeyMomentMissedWin:    "%s lets a winning position slip. Before moving in a won position, look for the forcing line that keeps the advantage.",
+			models.KeyMomentTurningPoint: "%s is where the game turned. Ask yourself what changed in the position after this move.",
+			models.KeyMomentTactic:       "%s starts a tactic. Notice how the forcing move leaves the opponent without a good reply.",
+		},
+		bestMove:   "Next time, consider %s.",
+		evaluation: "The engine now rates the position as %s.",
+	},
+	models.CommentaryToneNeutral: {
+		moments: map[string]string{
+			models.KeyMomentBestMove:     "%s matches the engine's first choice.",
+			models.KeyMomentMissedWin:    "%s gives away a winning advantage.",
+			models.KeyMomentTurningPoint: "%s swings the evaluation to the other side.",
+			models.KeyMomentTactic:       "%s is a tactical blow.",
+		},
+		bestMove:   "The engine preferred %s.",
+		evaluation: "The position is %s.",
+	},
+	models.CommentaryToneBanter: {
+		moments: map[string]string{
+			models.KeyMomentBestMove:     "%s! Somebody has been doing their puzzles.",
+			models.KeyMomentMissedWin:    "%s. The win was right there, and it walked straight past it.",
+			models.KeyMomentTurningPoint: "%s, and just like that the tables turn.",
+			models.KeyMomentTactic:       "%s, and the fireworks begin.",
+		},
+		bestMove:   "%s was sitting right there, just saying.",
+		evaluation: "Scoreboard check: %s.",
+	},
+}
+
+// moveMotif is a motif detected in a move
+type moveMotif struct {
+	name    string
+	piece   board.PieceType   // Piece the motif is about: the captured, promoted, forking or abandoned piece
+	targets []board.PieceType // Pieces attacked by a fork
+}
+
+// GetCommentary comments on the key moments of a stored analysis in the given tone (default
+// neutral), writing moves in the given display notation. The text is assembled from the engine
+// data and motifs detected on the board, so no language model or network access is needed.
+func (s *AnalysisService) GetCommentary(analysisID, tone, notation string) (*models.Commentary, error) {
+	if tone == "" {
+		tone = models.CommentaryToneNeutral
+	}
+	phrasing, ok := commentaryTones[tone]
+	if !ok {
+		return nil, errors.NewValidationError("tone", "tone must be coach, neutral or banter")
+	}
+
+	analysis, err := s.GetAnalysis(analysisID)
+	if err != nil {
+		return nil, err
+	}
+	n, err := newMoveNotation(analysis, notation)
+	if err != nil {
+		return nil, err
+	}
+
+	positions := s.commentaryPositions(analysis)
+	commentary := &models.Commentary{AnalysisID: analysis.ID, Tone: tone, Paragraphs: []models.CommentaryParagraph{}}
+	// Moments keep their stored moves, which the board parses; the text is written in the notation
+	for _, moment := range selectKeyMoments(analysis, i18n.DefaultLanguage, moveNotation{}) {
+		ply := moment.MoveNumber
+		prevEval := 0.0
+		if ply >= 2 && ply-2 < len(analysis.Moves) {
+			prevEval = analysis.Moves[ply-2].Evaluation
+		}
+		loss := moverEval(prevEval, moment.Color) - moverEval(moment.Evaluation, moment.Color)
+
+		var motifs []moveMotif
+		var before *board.Board
+		if ply-1 < len(positions) {
+			before = positions[ply-1]
+			if move, err := before.ParseSAN(moment.Move); err == nil {
+				motifs = detectMotifs(before, move, loss)
+			}
+		}
+
+		sentences := []string{fmt.Sprintf(phrasing.moments[moment.Type], moveLabel(moment.FEN, ply, n.san(moment.Move)))}
+		for _, motif := range motifs {
+			sentences = append(sentences, motifSentence(motif))
+		}
+		if moment.Type == models.KeyMomentMissedWin || moment.Type == models.KeyMomentTurningPoint {
+			if best := bestMoveSAN(before, moment.BestMove); best != "" && !sameMove(moment.Move, moment.BestMove) {
+				sentences = append(sentences, fmt.Sprintf(phrasing.bestMove, n.san(best)))
+			}
+		}
+		sentences = append(sentences, fmt.Sprintf(phrasing.evaluation, describeEvaluation(moment.Evaluation)))
+
+		paragraph := models.CommentaryParagraph{
+			MoveNumber: ply,
+			Color:      moment.Color,
+			Move:       n.san(moment.Move),
+			Moment:     moment.Type,
+			Text:       strings.Join(sentences, " "),
+		}
+		for _, motif := range motifs {
+			paragraph.Motifs = append(paragraph.Motifs, motif.name)
+		}
+		commentary.Paragraphs = append(commentary.Paragraphs, paragraph)
+	}
+	return commentary, nil
+}
+
+// commentaryPositions replays the analyzed game and returns the position before every ply, or
+// as many as could be replayed. Motifs are only detected for moves that could be replayed.
+func (s *AnalysisService) commentaryPositions(analysis *models.GameAnalysis) []*board.Board {
+	game, err := s.pgnParser.ParsePGN(analysis.PGN)
+	if err != nil {
+		return nil
+	}
+
+	b := board.NewBoard()
+	if fen := game.Headers["fen"]; fen != "" {
+		if b, err = board.FromFEN(fen); err != nil {
+			return nil
+		}
+	}
+
+	positions := make([]*board.Board, 0, len(game.Moves))
+	for _, parsed := range game.Moves {
+		move, err := b.ParseSAN(parsed.Move)
+		if err != nil {
+			break
+		}
+		position := *b
+		positions = append(positions, &position)
+		b.Apply(move)
+	}
+	return positions
+}
+
+// detectMotifs finds the motifs of a move played in a position. loss is what the move cost
+// the mover, in pawns.
+func detectMotifs(before *board.Board, move board.Move, loss float64) []moveMotif {
+	after := *before
+	after.Apply(move)
+	mover := move.Piece.Color
+	moved := move.Piece.Type
+	if move.Promotion != board.NoPieceType {
+		moved = move.Promotion
+	}
+
+	var motifs []moveMotif
+	switch {
+	case move.Castle:
+		motifs = append(motifs, moveMotif{name: models.MotifCastling})
+	case move.Promotion != board.NoPieceType:
+		motifs = append(motifs, moveMotif{name: models.MotifPromotion, piece: move.Promotion})
+	}
+	if move.IsCapture() {
+		motifs = append(motifs, moveMotif{name: models.MotifCapture, piece: move.Captured.Type})
+	}
+
+	mate := false
+	if after.InCheck() {
+		mate = len(after.LegalMoves()) == 0
+		if mate {
+			motifs = append(motifs, moveMotif{name: models.MotifCheckmate})
+		} else {
+			motifs = append(motifs, moveMotif{name: models.MotifCheck})
+		}
+	}
+	if mate {
+		return motifs
+	}
+
+	// A fork attacks the king or pieces worth more than the forking piece, or undefended pieces
+	var targets []board.PieceType
+	for _, square := range after.Attacks(move.To) {
+		target := after.PieceAt(square)
+		if target.IsEmpty() || target.Color == mover || target.Type == board.Pawn {
+			continue
+		}
+		if motifPieceValues[target.Type] > motifPieceValues[moved] || !after.IsAttacked(square, mover.Opponent()) {
+			targets = append(targets, target.Type)
+		}
+	}
+	if len(targets) >= forkTargetCount {
+		motifs = append(motifs, moveMotif{name: models.MotifFork, piece: moved, targets: targets})
+	}
+
+	// A piece left en prise: attacked, undefended and not traded for something as valuable
+	if moved != board.King && motifPieceValues[moved] >= minMotifPiece &&
+		after.IsAttacked(move.To, mover.Opponent()) && !after.IsAttacked(move.To, mover) &&
+		motifPieceValues[move.Captured.Type] < motifPieceValues[moved] {
+		name := models.MotifSacrifice
+		if loss >= hangingLoss {
+			name = models.MotifHanging
+		}
+		motifs = append(motifs, moveMotif{name: name, piece: moved})
+	}
+	return motifs
+}
+
+// motifSentence describes a motif
+func motifSentence(motif moveMotif) string {
+	switch motif.name {
+	case models.MotifCheck:
+		return "It comes with check."
+	case models.MotifCheckmate:
+		return "It's checkmate."
+	case models.MotifCapture:
+		return fmt.Sprintf("It takes a %s.", motif.piece)
+	case models.MotifPromotion:
+		return fmt.Sprintf("The pawn promotes to a %s.", motif.piece)
+	case models.MotifCastling:
+		return "Castling brings the king to safety."
+	case models.MotifFork:
+		targets := make([]string, len(motif.targets))
+		for i, target := range motif.targets {
+			targets[i] = target.String()
+		}
+		return fmt.Sprintf("The %s forks the %s.", motif.piece, joinWords(targets))
+	case models.MotifSacrifice:
+		return fmt.Sprintf("The %s is offered as a sacrifice.", motif.piece)
+	case models.MotifHanging:
+		return fmt.Sprintf("It leaves the %s hanging.", motif.piece)
+	}
+	return ""
+}
+
+// joinWords joins words as in "a, b and c"
+func joinWords(words []string) string {
+	if len(words) <= 1 {
+		return strings.Join(words, "")
+	}
+	return strings.Join(words[:len(words)-1], ", ") + " and " + words[len(words)-1]
+}
+
+// bestMoveSAN converts the engine's move in a position to SAN, or returns "" when it can't
+func bestMoveSAN(position *board.Board, uci string) string {
+	if position == nil || uci == "" {
+		return ""
+	}
+	move, err := position.ParseUCI(uci)
+	if err != nil {
+		return ""
+	}
+	return position.SAN(move)
+}
+
+// describeEvaluation puts a White-relative evaluation into words
+func describeEvaluation(evaluation float64) string {
+	side := "White"
+	if evaluation < 0 {
+		side = "Black"
+	}
+	magnitude := math.Abs(evaluation)
+
+	switch {
+	case magnitude >= mateEvaluation:
+		return fmt.Sprintf("a forced mate for %s", side)
+	case magnitude < 0.5:
+		return fmt.Sprintf("roughly equal (%+.1f)", evaluation)
+	case magnitude < 1.5:
+		return fmt.Sprintf("slightly better for %s (%+.1f)", side, evaluation)
+	case magnitude < winningAdvantage:
+		return fmt.Sprintf("clearly better for %s (%+.1f)", side, evaluation)
+	}
+	return fmt.Sprintf("winning for %s (%+.1f)", side, evaluation)
+}
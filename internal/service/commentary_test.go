@@ -0,0 +1,115 @@
+package service
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/board"
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+)
+
+func TestDetectMotifs(t *testing.T) {
+	tests := []struct {
+		name string
+		fen  string
+		move string
+		loss float64
+		want []string
+	}{
+		{"knight fork with check", "r3k3/8/8/1N1q4/8/8/8/6K1 w - - 0 1", "Nc7+", 0, []string{models.MotifCheck, models.MotifFork}},
+		{"promotion with capture", "1n2k3/P7/8/8/8/8/8/4K3 w - - 0 1", "axb8=Q+", 0,
+			[]string{models.MotifPromotion, models.MotifCapture, models.MotifCheck}},
+		{"back rank mate", "6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", "Ra8#", 0, []string{models.MotifCheckmate}},
+		{"castling", "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", "O-O", 0, []string{models.MotifCastling}},
+		{"hanging queen", "4k3/8/8/3p4/8/3Q4/8/4K3 w - - 0 1", "Qc4", 9, []string{models.MotifHanging}},
+		{"queen sacrifice", "4k3/8/8/3p4/8/3Q4/8/4K3 w - - 0 1", "Qc4", 0, []string{models.MotifSacrifice}},
+		{"quiet move", board.StartFEN, "e4", 0, nil},
+	}
+
+	for _, tt := range tests {
+		b, err := board.FromFEN(tt.fen)
+		if err != nil {
+			t.Fatalf("%s: FromFEN() error = %v", tt.name, err)
+		}
+		move, err := b.ParseSAN(tt.move)
+		if err != nil {
+			t.Fatalf("%s: ParseSAN() error = %v", tt.name, err)
+		}
+
+		var got []string
+		for _, motif := range detectMotifs(b, move, tt.loss) {
+			got = append(got, motif.name)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: detectMotifs() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAnalysisService_GetCommentary(t *testing.T) {
+	service := newTestAnalysisService()
+
+	id, err := service.store.SaveAnalysis(&models.GameAnalysis{
+		PGN: "1. e4 e5 2. Nf3 f6 3. Nxe5 Qe7 4. Qh5+ *",
+		Moves: []models.MoveAnalysis{
+			{Move: "e4", MoveNumber: 1, Evaluation: 0.3, Accuracy: 97, BestMove: "e7e5"},
+			{Move: "e5", MoveNumber: 2, Evaluation: 0.3, Accuracy: 97, BestMove: "g1f3"},
+			{Move: "Nf3", MoveNumber: 3, Evaluation: 0.4, Accuracy: 96, BestMove: "f7f6"},
+			{Move: "f6", MoveNumber: 4, Evaluation: 2.5, Accuracy: 70, BestMove: "f3e5"},
+			{Move: "Nxe5", MoveNumber: 5, Evaluation: 4.5, Accuracy: 98, BestMove: "d8e7"},
+			{Move: "Qe7", MoveNumber: 6, Evaluation: 4.2, Accuracy: 96, BestMove: "e5f3"},
+			{Move: "Qh5+", MoveNumber: 7, Evaluation: -1.0, Accuracy: 40, BestMove: "g7g6"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("SaveAnalysis() error = %v", err)
+	}
+
+	commentary, err := service.GetCommentary(id, "", "")
+	if err != nil {
+		t.Fatalf("GetCommentary() error = %v", err)
+	}
+	if commentary.Tone != models.CommentaryToneNeutral {
+		t.Errorf("Expected the neutral tone by default, got %q", commentary.Tone)
+	}
+
+	byPly := make(map[int]models.CommentaryParagraph)
+	for _, paragraph := range commentary.Paragraphs {
+		byPly[paragraph.MoveNumber] = paragraph
+	}
+
+	tactic := byPly[5]
+	if tactic.Moment != models.KeyMomentTactic || !reflect.DeepEqual(tactic.Motifs, []string{models.MotifCapture, models.MotifSacrifice}) ||
+		!strings.HasPrefix(tactic.Text, "3. Nxe5 is a tactical blow. It takes a pawn.") {
+		t.Errorf("Unexpected tactic paragraph: %+v", tactic)
+	}
+
+	missed := byPly[7]
+	if missed.Moment != models.KeyMomentMissedWin || !strings.Contains(missed.Text, "The engine preferred Nf3.") ||
+		!strings.HasSuffix(missed.Text, "The position is slightly better for Black (-1.0).") {
+		t.Errorf("Unexpected missed win paragraph: %+v", missed)
+	}
+
+	german, err := service.GetCommentary(id, "", "de")
+	if err != nil {
+		t.Fatalf("GetCommentary(de) error = %v", err)
+	}
+	for _, paragraph := range german.Paragraphs {
+		if paragraph.MoveNumber == 7 && (paragraph.Move != "Dh5+" || !strings.Contains(paragraph.Text, "The engine preferred Sf3.")) {
+			t.Errorf("Expected the missed win in German notation, got %+v", paragraph)
+		}
+	}
+
+	coach, err := service.GetCommentary(id, models.CommentaryToneCoach, "")
+	if err != nil {
+		t.Fatalf("GetCommentary(coach) error = %v", err)
+	}
+	if len(coach.Paragraphs) != len(commentary.Paragraphs) || coach.Paragraphs[0].Text == commentary.Paragraphs[0].Text {
+		t.Errorf("Expected the coach tone to comment on the same moments differently, got %+v", coach.Paragraphs)
+	}
+
+	if _, err := service.GetCommentary(id, "sarcastic", ""); err == nil {
+		t.Error("Expected an unknown tone to be rejected")
+	}
+}
@@ -0,0 +1,251 @@
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/board"
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/internal/parquet"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+// Supported position dataset formats
+const (
+	DatasetFormatCSV     = "csv"
+	DatasetFormatParquet = "parquet"
+)
+
+// MaxDatasetRows bounds the rows of one position dataset, which is built in memory
+const MaxDatasetRows = 100000
+
+// datasetColumns are the columns of a position dataset, one row per analyzed move
+var datasetColumns = []parquet.Column{
+	{Name: "analysis_id", Type: parquet.String},
+	{Name: "game_id", Type: parquet.String, Optional: true},
+	{Name: "ply", Type: parquet.Int32},
+	{Name: "color", Type: parquet.String},
+	{Name: "fen", Type: parquet.String},                             // Position before the move
+	{Name: "played_move", Type: parquet.String},                     // SAN
+	{Name: "played_move_uci", Type: parquet.String, Optional: true}, // UCI
+	{Name: "best_move", Type: parquet.String, Optional: true},       // Engine's move in the position, UCI
+	{Name: "eval", Type: parquet.Double, Optional: true},            // Position before the move, White's view, pawns
+	{Name: "eval_after", Type: parquet.Double},                      // Position after the move
+	{Name: "cpl", Type: parquet.Double, Optional: true},             // Mover's centipawn loss
+	{Name: "classification", Type: parquet.String},                  // User overrides win
+	{Name: "accuracy", Type: parquet.Double},                        // Move accuracy, 0-100
+	{Name: "player", Type: parquet.String, Optional: true},          // Mover's name from the PGN tags
+	{Name: "player_rating", Type: parquet.Int32, Optional: true},    // Mover's rating from the PGN tags
+	{Name: "time_left", Type: parquet.Double, Optional: true},       // Mover's clock after the move, seconds
+}
+
+// ExportDataset exports the moves of stored analyses as (position, move, evaluation, rating, clock)
+// rows for training machine-learning models. At most the request's limit of rows is exported, the
+// first ones in analysis order; the dataset reports whether more were left out.
+func (s *AnalysisService) ExportDataset(request *models.DatasetRequest) (*models.Dataset, error) {
+	format := request.Format
+	if format == "" {
+		format = DatasetFormatCSV
+	}
+	if format != DatasetFormatCSV && format != DatasetFormatParquet {
+		return nil, errors.NewValidationError("format", fmt.Sprintf("unsupported dataset format: %s", format))
+	}
+	limit := request.Limit
+	if limit == 0 {
+		limit = MaxDatasetRows
+	}
+	if limit < 1 || limit > MaxDatasetRows {
+		return nil, errors.NewValidationError("limit", fmt.Sprintf("must be between 1 and %d", MaxDatasetRows))
+	}
+
+	analyses := s.store.ListAnalyses()
+	if len(request.AnalysisIDs) > 0 {
+		analyses = analyses[:0:0]
+		for _, id := range request.AnalysisIDs {
+			analysis, err := s.store.GetAnalysis(id)
+			if err != nil {
+				return nil, err
+			}
+			analyses = append(analyses, analysis)
+		}
+	}
+
+	dataset := &models.Dataset{}
+	var rows [][]interface{}
+	for _, analysis := range analyses {
+		analysisRows, err := s.datasetRows(analysis, request.Player)
+		if err != nil {
+			// One unreadable game shouldn't fail a bulk export
+			log.Printf("Skipping analysis %s in dataset export: %v", analysis.ID, err)
+			continue
+		}
+		if len(rows)+len(analysisRows) > limit {
+			rows = append(rows, analysisRows[:limit-len(rows)]...)
+			dataset.Truncated = true
+			break
+		}
+		rows = append(rows, analysisRows...)
+	}
+	dataset.Rows = len(rows)
+
+	var err error
+	if format == DatasetFormatParquet {
+		dataset.Data, err = encodeParquetDataset(rows)
+		dataset.ContentType = "application/vnd.apache.parquet"
+	} else {
+		dataset.Data, err = encodeCSVDataset(rows)
+		dataset.ContentType = "text/csv"
+	}
+	if err != nil {
+		return nil, err
+	}
+	return dataset, nil
+}
+
+// datasetRows builds the dataset rows of an analysis' moves, only the given player's when set
+func (s *AnalysisService) datasetRows(analysis *models.GameAnalysis, player string) ([][]interface{}, error) {
+	game, err := s.pgnParser.ParsePGN(analysis.PGN)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.pgnParser.ExtractPositions(game); err != nil {
+		return nil, err
+	}
+
+	var playerColor board.Color
+	if player != "" {
+		color, ok := headerColor(game.Headers, player)
+		if !ok {
+			return nil, nil
+		}
+		playerColor = color
+	}
+
+	start := board.NewBoard()
+	if fen := game.Headers["fen"]; fen != "" {
+		if start, err = board.FromFEN(fen); err != nil {
+			return nil, err
+		}
+	}
+	clocks, _, timed := gameClocks(s.pgnParser, game)
+
+	var rows [][]interface{}
+	for i, move := range analysis.Moves {
+		ply := move.MoveNumber
+		if ply < 1 || ply > len(game.Moves) {
+			continue
+		}
+		color := moveColor(move.FEN, ply)
+		mover := board.White
+		if color == "black" {
+			mover = board.Black
+		}
+		if player != "" && mover != playerColor {
+			continue
+		}
+
+		fen := start.FEN()
+		if ply > 1 {
+			fen = game.Moves[ply-2].FEN
+		}
+
+		// The position before the move was evaluated with the previous ply, or is the start
+		var prev *models.MoveAnalysis
+		if i > 0 && analysis.Moves[i-1].MoveNumber == ply-1 {
+			prev = &analysis.Moves[i-1]
+		}
+		var eval, cpl, bestMove interface{}
+		if prev != nil || ply == 1 {
+			before := 0.0
+			if prev != nil {
+				before = prev.Evaluation
+				if prev.BestMove != "" {
+					bestMove = prev.BestMove
+				}
+			}
+			eval = before
+			cpl = moveEvaluation(before, move.Evaluation, color).Loss() * 100
+		}
+
+		var uci, rating, timeLeft, name interface{}
+		if b, err := board.FromFEN(fen); err == nil {
+			if m, err := b.ParseSAN(move.Move); err == nil {
+				uci = m.UCI()
+			}
+		}
+		if r := headerRating(game.Headers, mover); r > 0 {
+			rating = int32(r)
+		}
+		if timed {
+			timeLeft = clocks[ply-1].Seconds()
+		}
+		if n := game.Headers[color]; n != "" {
+			name = n
+		}
+		var gameID interface{}
+		if analysis.GameID != "" {
+			gameID = analysis.GameID
+		}
+
+		rows = append(rows, []interface{}{
+			analysis.ID, gameID, int32(ply), color, fen, move.Move, uci, bestMove, eval,
+			move.Evaluation, cpl, moveClassification(move), move.Accuracy, name, rating, timeLeft,
+		})
+	}
+	return rows, nil
+}
+
+// encodeCSVDataset writes dataset rows as CSV with a header row; missing values are empty
+func encodeCSVDataset(rows [][]interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	header := make([]string, len(datasetColumns))
+	for i, column := range datasetColumns {
+		header[i] = column.Name
+	}
+	w.Write(header)
+
+	record := make([]string, len(datasetColumns))
+	for _, row := range rows {
+		for i, value := range row {
+			switch v := value.(type) {
+			case nil:
+				record[i] = ""
+			case string:
+				record[i] = v
+			case int32:
+				record[i] = strconv.Itoa(int(v))
+			case float64:
+				record[i] = strconv.FormatFloat(v, 'f', -1, 64)
+			default:
+				record[i] = fmt.Sprint(v)
+			}
+		}
+		w.Write(record)
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, errors.NewAPIError("failed to encode dataset", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeParquetDataset writes dataset rows as a Parquet file
+func encodeParquetDataset(rows [][]interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	w := parquet.NewWriter(&buf, datasetColumns)
+	for _, row := range rows {
+		if err := w.Write(row); err != nil {
+			return nil, errors.NewAPIError("failed to encode dataset", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, errors.NewAPIError("failed to encode dataset", err)
+	}
+	return buf.Bytes(), nil
+}
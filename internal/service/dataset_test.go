@@ -0,0 +1,105 @@
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+)
+
+func TestAnalysisService_ExportDataset(t *testing.T) {
+	service := newTestAnalysisService()
+
+	pgn := `[White "Alice"]
+[Black "Bob"]
+[WhiteElo "1800"]
+[BlackElo "1750"]
+[TimeControl "180+2"]
+
+1. e4 {[%clk 0:02:59]} e5 {[%clk 0:02:58]} 2. Nf3 {[%clk 0:02:55]} *`
+	id, err := service.store.SaveAnalysis(&models.GameAnalysis{
+		PGN: pgn,
+		Moves: []models.MoveAnalysis{
+			{Move: "e4", MoveNumber: 1, Evaluation: 0.3, BestMove: "e7e5", Accuracy: 100},
+			{Move: "e5", MoveNumber: 2, Evaluation: 0.5, BestMove: "g1f3", Accuracy: 90},
+			{Move: "Nf3", MoveNumber: 3, Evaluation: -1.5, Accuracy: 20, Blunder: true},
+		},
+	})
+	if err != nil {
+		t.Fatalf("SaveAnalysis() error = %v", err)
+	}
+
+	dataset, err := service.ExportDataset(&models.DatasetRequest{Player: "alice"})
+	if err != nil {
+		t.Fatalf("ExportDataset() error = %v", err)
+	}
+	if dataset.ContentType != "text/csv" || dataset.Rows != 2 || dataset.Truncated {
+		t.Errorf("Unexpected dataset: %s, %d rows, truncated %t", dataset.ContentType, dataset.Rows, dataset.Truncated)
+	}
+	records, err := csv.NewReader(bytes.NewReader(dataset.Data)).ReadAll()
+	if err != nil {
+		t.Fatalf("Invalid CSV: %v", err)
+	}
+
+	// Header plus Alice's two moves
+	if len(records) != 3 {
+		t.Fatalf("Got %d records, want 3", len(records))
+	}
+	column := map[string]int{}
+	for i, name := range records[0] {
+		column[name] = i
+	}
+	row := records[2]
+	want := map[string]string{
+		"analysis_id":     id,
+		"ply":             "3",
+		"fen":             "rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq e6 0 2",
+		"played_move":     "Nf3",
+		"played_move_uci": "g1f3",
+		"best_move":       "g1f3",
+		"eval":            "0.5",
+		"cpl":             "200",
+		"classification":  "blunder",
+		"player":          "Alice",
+		"player_rating":   "1800",
+		"time_left":       "175",
+	}
+	for name, value := range want {
+		if got := row[column[name]]; got != value {
+			t.Errorf("%s = %q, want %q", name, got, value)
+		}
+	}
+	if records[1][column["eval"]] != "0" || records[1][column["best_move"]] != "" {
+		t.Errorf("First move should be evaluated from the start without a best move: %v", records[1])
+	}
+
+	dataset, err = service.ExportDataset(&models.DatasetRequest{Format: DatasetFormatParquet, AnalysisIDs: []string{id}})
+	if err != nil {
+		t.Fatalf("ExportDataset() error = %v", err)
+	}
+	if dataset.ContentType != "application/vnd.apache.parquet" || !bytes.HasPrefix(dataset.Data, []byte("PAR1")) || dataset.Rows != 3 {
+		t.Errorf("Unexpected Parquet export: %s, %d rows, %q", dataset.ContentType, dataset.Rows, dataset.Data[:4])
+	}
+
+	// The limit keeps the first rows
+	dataset, err = service.ExportDataset(&models.DatasetRequest{Limit: 2})
+	if err != nil {
+		t.Fatalf("ExportDataset() error = %v", err)
+	}
+	if records, _ := csv.NewReader(bytes.NewReader(dataset.Data)).ReadAll(); dataset.Rows != 2 || !dataset.Truncated || len(records) != 3 || records[2][column["ply"]] != "2" {
+		t.Errorf("Expected the first 2 rows of a truncated dataset, got %d rows, truncated %t: %v", dataset.Rows, dataset.Truncated, records)
+	}
+
+	if _, err := service.ExportDataset(&models.DatasetRequest{Format: "xlsx"}); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+	for _, limit := range []int{-1, MaxDatasetRows + 1} {
+		if _, err := service.ExportDataset(&models.DatasetRequest{Limit: limit}); err == nil {
+			t.Errorf("Expected an error for a limit of %d", limit)
+		}
+	}
+	if _, err := service.ExportDataset(&models.DatasetRequest{AnalysisIDs: []string{"missing"}}); err == nil {
+		t.Error("Expected an error for an unknown analysis")
+	}
+}
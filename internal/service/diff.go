@@ -0,0 +1,119 @@
+package service
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+// DiffAnalyses compares two stored analyses of the same game move by move
+func (s *AnalysisService) DiffAnalyses(idA, idB string) (*models.AnalysisDiff, error) {
+	if idA == "" || idB == "" {
+		return nil, errors.NewValidationError("a", "two analysis IDs are required")
+	}
+
+	a, err := s.GetAnalysis(idA)
+	if err != nil {
+		return nil, err
+	}
+	b, err := s.GetAnalysis(idB)
+	if err != nil {
+		return nil, err
+	}
+
+	return diffAnalyses(a, b)
+}
+
+// diffAnalyses compares the moves both analyses cover. Analyses limited with max_moves can
+// cover different numbers of moves, but the moves they share must be the same.
+func diffAnalyses(a, b *models.GameAnalysis) (*models.AnalysisDiff, error) {
+	if a.GameID != "" && b.GameID != "" && a.GameID != b.GameID {
+		return nil, errors.NewValidationError("b", fmt.Sprintf("analyses are of different games (%s and %s)", a.GameID, b.GameID))
+	}
+
+	diff := &models.AnalysisDiff{
+		A:     diffSide(a),
+		B:     diffSide(b),
+		Moves: []models.MoveDiff{},
+	}
+
+	var totalDelta float64
+	for _, moveA := range a.Moves {
+		idx := findMoveIndex(b.Moves, moveA.MoveNumber)
+		if idx == -1 {
+			continue
+		}
+		moveB := b.Moves[idx]
+		if moveA.Move != moveB.Move {
+			return nil, errors.NewValidationError("b", fmt.Sprintf("analyses are of different games: ply %d is %s in one and %s in the other",
+				moveA.MoveNumber, moveA.Move, moveB.Move))
+		}
+
+		move := models.MoveDiff{
+			MoveNumber:      moveA.MoveNumber,
+			Move:            moveA.Move,
+			EvaluationA:     moveA.Evaluation,
+			EvaluationB:     moveB.Evaluation,
+			EvalDelta:       moveB.Evaluation - moveA.Evaluation,
+			ClassificationA: moveClassification(moveA),
+			ClassificationB: moveClassification(moveB),
+			BestMoveA:       moveA.BestMove,
+			BestMoveB:       moveB.BestMove,
+		}
+		move.ClassificationChanged = move.ClassificationA != move.ClassificationB
+		move.BestMoveDisagreement = move.BestMoveA != move.BestMoveB
+		diff.Moves = append(diff.Moves, move)
+
+		summary := &diff.Summary
+		summary.ComparedMoves++
+		if move.ClassificationChanged {
+			summary.ClassificationChanges++
+		}
+		if move.BestMoveDisagreement {
+			summary.BestMoveDisagreements++
+		}
+		delta := math.Abs(move.EvalDelta)
+		totalDelta += delta
+		if delta > summary.MaxEvalDelta {
+			summary.MaxEvalDelta = delta
+			summary.MaxEvalDeltaMoveNumber = move.MoveNumber
+		}
+	}
+
+	if diff.Summary.ComparedMoves > 0 {
+		diff.Summary.AverageEvalDelta = totalDelta / float64(diff.Summary.ComparedMoves)
+	}
+	return diff, nil
+}
+
+// diffSide describes one analysis of a diff
+func diffSide(analysis *models.GameAnalysis) models.AnalysisDiffSide {
+	return models.AnalysisDiffSide{
+		ID:             analysis.ID,
+		AnalysisTime:   analysis.AnalysisTime,
+		EngineVersion:  analysis.EngineVersion,
+		EvalScale:      analysis.EvalScale,
+		EngineSettings: analysis.EngineSettings,
+		Accuracy:       analysis.Accuracy,
+		Moves:          len(analysis.Moves),
+	}
+}
+
+// moveClassification returns a move's classification, preferring the user's override
+func moveClassification(move models.MoveAnalysis) string {
+	switch {
+	case move.ClassificationOverride != "":
+		return move.ClassificationOverride
+	case move.Blunder:
+		return "blunder"
+	case move.Mistake:
+		return "mistake"
+	case move.Inaccuracy:
+		return "inaccuracy"
+	case move.Miss:
+		return "miss"
+	}
+	return "good"
+}
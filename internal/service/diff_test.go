@@ -0,0 +1,57 @@
+package service
+
+import (
+	"math"
+	"testing"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+)
+
+func TestDiffAnalyses(t *testing.T) {
+	shallow := &models.GameAnalysis{
+		ID:             "shallow",
+		EngineSettings: models.EngineSettings{Depth: 12},
+		Moves: []models.MoveAnalysis{
+			{MoveNumber: 1, Move: "e4", Evaluation: 0.3, BestMove: "e2e4"},
+			{MoveNumber: 2, Move: "f6", Evaluation: 0.9, BestMove: "e7e5", Inaccuracy: true},
+			{MoveNumber: 3, Move: "d4", Evaluation: 0.8, BestMove: "d2d4"},
+		},
+	}
+	deep := &models.GameAnalysis{
+		ID:             "deep",
+		EngineSettings: models.EngineSettings{Depth: 24},
+		Moves: []models.MoveAnalysis{
+			{MoveNumber: 1, Move: "e4", Evaluation: 0.3, BestMove: "e2e4"},
+			{MoveNumber: 2, Move: "f6", Evaluation: 1.4, BestMove: "c7c5", Mistake: true},
+		},
+	}
+
+	diff, err := diffAnalyses(shallow, deep)
+	if err != nil {
+		t.Fatalf("diffAnalyses() error = %v", err)
+	}
+
+	if diff.A.Moves != 3 || diff.B.Moves != 2 || len(diff.Moves) != 2 {
+		t.Fatalf("Expected the two shared moves to be compared, got %+v", diff)
+	}
+
+	move := diff.Moves[1]
+	if math.Abs(move.EvalDelta-0.5) > 1e-9 || !move.ClassificationChanged || move.ClassificationA != "inaccuracy" ||
+		move.ClassificationB != "mistake" || !move.BestMoveDisagreement {
+		t.Errorf("Unexpected move diff: %+v", move)
+	}
+	if diff.Moves[0].ClassificationChanged || diff.Moves[0].BestMoveDisagreement {
+		t.Errorf("Expected identical moves to match: %+v", diff.Moves[0])
+	}
+
+	summary := diff.Summary
+	if summary.ComparedMoves != 2 || summary.ClassificationChanges != 1 || summary.BestMoveDisagreements != 1 ||
+		summary.MaxEvalDeltaMoveNumber != 2 || math.Abs(summary.AverageEvalDelta-0.25) > 1e-9 {
+		t.Errorf("Unexpected summary: %+v", summary)
+	}
+
+	other := &models.GameAnalysis{Moves: []models.MoveAnalysis{{MoveNumber: 1, Move: "d4"}}}
+	if _, err := diffAnalyses(shallow, other); err == nil {
+		t.Error("Expected analyses of different games to be rejected")
+	}
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"math"
+	"sort"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/board"
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+)
+
+// Opening divergence settings. Positions are searched one after another within the report's
+// request, so both their number and the time spent on each are bounded.
+const (
+	divergencePly            = 20   // Plies after which the opening is over and the position is assessed
+	divergenceMinGames       = 3    // Games an opening needs to be assessed, and evaluated games it needs for a verdict
+	divergenceThreshold      = 15.0 // Percentage points between results and assessment that make a verdict
+	divergenceMaxPositions   = 30   // Positions searched per report, those of the most played openings first
+	defaultDivergenceDepth   = 14
+	defaultDivergenceTimeout = 1000
+	MaxDivergenceTimeLimit   = 2000 // Milliseconds per position
+)
+
+// openingTally accumulates a player's games in one opening with one color
+type openingTally struct {
+	stat           models.OpeningDivergence
+	color          board.Color
+	evaluationSum  float64 // The player's evaluation of the positions assessed
+	expectedSum    float64 // The player's expected points in the evaluated games
+	evaluatedScore float64 // Points scored in the evaluated games
+}
+
+// openingPosition is a game's position after the opening, waiting for the engine's assessment
+type openingPosition struct {
+	tally *openingTally
+	fen   string
+	score float64
+}
+
+// openingTallies groups a player's games by opening and color
+type openingTallies struct {
+	tallies   map[string]*openingTally
+	positions []openingPosition
+}
+
+func newOpeningTallies() *openingTallies {
+	return &openingTallies{tallies: make(map[string]*openingTally)}
+}
+
+// add records a decided game. fen is the position after the opening, empty when the game ended
+// sooner or can't be assessed.
+func (o *openingTallies) add(name string, color board.Color, score float64, fen string) {
+	key := color.String() + " " + name
+	tally, ok := o.tallies[key]
+	if !ok {
+		tally = &openingTally{stat: models.OpeningDivergence{Name: name, Color: color.String()}, color: color}
+		o.tallies[key] = tally
+	}
+	tally.stat.Games++
+	switch score {
+	case 1:
+		tally.stat.Wins++
+	case 0.5:
+		tally.stat.Draws++
+	default:
+		tally.stat.Losses++
+	}
+	if fen != "" {
+		o.positions = append(o.positions, openingPosition{tally: tally, fen: fen, score: score})
+	}
+}
+
+// assess asks the engine for the positions reached after openings played often enough, those of
+// the most played openings first, and reports whether every one of them was searched. It stops at
+// divergenceMaxPositions or when ctx is done, keeping the evaluations made so far. Evaluations are
+// best effort: a position the engine fails on is left out.
+func (o *openingTallies) assess(ctx context.Context, evaluate func(context.Context, string) (*models.AnalysisResult, error)) bool {
+	positions := make([]openingPosition, 0, len(o.positions))
+	for _, position := range o.positions {
+		if position.tally.stat.Games >= divergenceMinGames {
+			positions = append(positions, position)
+		}
+	}
+	sort.SliceStable(positions, func(i, j int) bool {
+		return positions[i].tally.stat.Games > positions[j].tally.stat.Games
+	})
+
+	for i, position := range positions {
+		if i == divergenceMaxPositions || ctx.Err() != nil {
+			return false
+		}
+		result, err := evaluate(ctx, position.fen)
+		if err != nil {
+			continue
+		}
+		position.tally.addEvaluation(result, position.score)
+	}
+	return true
+}
+
+// addEvaluation records the engine's assessment of a game's position after the opening
+func (t *openingTally) addEvaluation(result *models.AnalysisResult, score float64) {
+	evaluation := result.Evaluation
+	expected := expectedPoints(evaluation)
+	if result.WDL != nil {
+		expected = result.WDL.ExpectedScore()
+	}
+	if t.color == board.Black {
+		evaluation, expected = -evaluation, 1-expected
+	}
+	t.stat.EvaluatedGames++
+	t.evaluationSum += evaluation
+	t.expectedSum += expected
+	t.evaluatedScore += score
+}
+
+// divergences summarizes every opening, most played first
+func (o *openingTallies) divergences() []models.OpeningDivergence {
+	divergences := make([]models.OpeningDivergence, 0, len(o.tallies))
+	for _, tally := range o.tallies {
+		stat := tally.stat
+		stat.Score = percentageScore(stat.Wins, stat.Draws, stat.Games)
+		if evaluated := float64(stat.EvaluatedGames); evaluated > 0 {
+			evaluation := tally.evaluationSum / evaluated
+			expected := tally.expectedSum / evaluated * 100
+			divergence := tally.evaluatedScore/evaluated*100 - expected
+			stat.AverageEvaluation, stat.ExpectedScore, stat.Divergence = &evaluation, &expected, &divergence
+			if stat.EvaluatedGames >= divergenceMinGames {
+				stat.Verdict = divergenceVerdict(divergence)
+			}
+		}
+		divergences = append(divergences, stat)
+	}
+	sort.Slice(divergences, func(i, j int) bool {
+		if divergences[i].Games != divergences[j].Games {
+			return divergences[i].Games > divergences[j].Games
+		}
+		if divergences[i].Name != divergences[j].Name {
+			return divergences[i].Name < divergences[j].Name
+		}
+		return divergences[i].Color > divergences[j].Color
+	})
+	return divergences
+}
+
+// divergenceVerdict judges how far results in an opening stray from the engine's assessment
+func divergenceVerdict(divergence float64) string {
+	switch {
+	case math.Abs(divergence) < divergenceThreshold:
+		return models.OpeningAsExpected
+	case divergence < 0:
+		return models.OpeningUnderperforming
+	default:
+		return models.OpeningOverperforming
+	}
+}
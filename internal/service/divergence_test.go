@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/board"
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+)
+
+func TestOpeningTallies(t *testing.T) {
+	openings := newOpeningTallies()
+	for _, score := range []float64{0, 0, 0.5, 0} {
+		openings.add("Italian Game", board.White, score, "italian")
+	}
+	for _, score := range []float64{1, 1, 1} {
+		openings.add("Sicilian Defense", board.Black, score, "sicilian")
+	}
+	openings.add("Sicilian Defense", board.Black, 0, "unknown")
+	openings.add("French Defense", board.White, 1, "french")
+	openings.add("French Defense", board.White, 1, "")
+
+	var searched []string
+	complete := openings.assess(context.Background(), func(ctx context.Context, fen string) (*models.AnalysisResult, error) {
+		searched = append(searched, fen)
+		switch fen {
+		case "italian":
+			return &models.AnalysisResult{Evaluation: 0.5}, nil
+		case "sicilian":
+			// Black holds the draw half the time and wins the rest
+			return &models.AnalysisResult{Evaluation: -2, WDL: &models.WDL{Draw: 500, Loss: 500}}, nil
+		}
+		return nil, fmt.Errorf("no evaluation")
+	})
+	if !complete {
+		t.Fatal("Expected every position to be searched")
+	}
+	for _, fen := range searched {
+		if fen == "french" {
+			t.Error("Expected openings played fewer than 3 times not to be searched")
+		}
+	}
+
+	divergences := openings.divergences()
+	if len(divergences) != 3 || divergences[0].Name != "Italian Game" || divergences[1].Name != "Sicilian Defense" {
+		t.Fatalf("Expected openings most played first, got %+v", divergences)
+	}
+
+	italian := divergences[0]
+	if italian.Games != 4 || italian.Losses != 3 || italian.Draws != 1 || italian.Score != 12.5 || italian.EvaluatedGames != 4 {
+		t.Errorf("Unexpected Italian Game results: %+v", italian)
+	}
+	if italian.AverageEvaluation == nil || *italian.AverageEvaluation != 0.5 {
+		t.Errorf("Expected White's evaluation of the Italian Game, got %v", italian.AverageEvaluation)
+	}
+	if italian.ExpectedScore == nil || *italian.ExpectedScore <= 50 || italian.Verdict != models.OpeningUnderperforming {
+		t.Errorf("Expected a good Italian Game position scoring badly, got %+v", italian)
+	}
+
+	sicilian := divergences[1]
+	if sicilian.Games != 4 || sicilian.EvaluatedGames != 3 || *sicilian.AverageEvaluation != 2 {
+		t.Errorf("Expected the Sicilian position the engine failed on to be left out, got %+v", sicilian)
+	}
+	if math.Abs(*sicilian.ExpectedScore-75) > 1e-9 || math.Abs(*sicilian.Divergence-25) > 1e-9 || sicilian.Verdict != models.OpeningOverperforming {
+		t.Errorf("Expected Black's expected score from the engine's odds, got %+v", sicilian)
+	}
+
+	french := divergences[2]
+	if french.Score != 100 || french.EvaluatedGames != 0 || french.ExpectedScore != nil || french.Verdict != "" {
+		t.Errorf("Expected the French Defense without an assessment, got %+v", french)
+	}
+}
+
+func TestDivergenceVerdict(t *testing.T) {
+	tests := []struct {
+		divergence float64
+		want       string
+	}{
+		{0, models.OpeningAsExpected},
+		{-14.9, models.OpeningAsExpected},
+		{-15, models.OpeningUnderperforming},
+		{20, models.OpeningOverperforming},
+	}
+	for _, tt := range tests {
+		if got := divergenceVerdict(tt.divergence); got != tt.want {
+			t.Errorf("divergenceVerdict(%v) = %q, want %q", tt.divergence, got, tt.want)
+		}
+	}
+}
+
+func TestOpeningTallies_AssessStopsEarly(t *testing.T) {
+	openings := newOpeningTallies()
+	for i := 0; i < 3; i++ {
+		openings.add("Italian Game", board.White, 1, "italian")
+	}
+	for i := 0; i < divergenceMaxPositions; i++ {
+		openings.add("Ruy Lopez", board.White, 1, "ruy")
+	}
+
+	// The most played opening is searched first, and nothing beyond the cap
+	var searched []string
+	complete := openings.assess(context.Background(), func(ctx context.Context, fen string) (*models.AnalysisResult, error) {
+		searched = append(searched, fen)
+		return &models.AnalysisResult{Evaluation: 0.3}, nil
+	})
+	if complete || len(searched) != divergenceMaxPositions || searched[len(searched)-1] != "ruy" {
+		t.Errorf("Expected the Ruy Lopez positions to fill the cap, got %d searched, complete %t", len(searched), complete)
+	}
+
+	// A deadline keeps the evaluations made before it
+	ctx, cancel := context.WithCancel(context.Background())
+	openings = newOpeningTallies()
+	for i := 0; i < 4; i++ {
+		openings.add("Italian Game", board.White, 1, "italian")
+	}
+	evaluated := 0
+	complete = openings.assess(ctx, func(ctx context.Context, fen string) (*models.AnalysisResult, error) {
+		if evaluated++; evaluated == 2 {
+			cancel()
+		}
+		return &models.AnalysisResult{Evaluation: 0.3}, nil
+	})
+	if italian := openings.divergences()[0]; complete || italian.EvaluatedGames != 2 {
+		t.Errorf("Expected two evaluations kept when the deadline passed, got %+v, complete %t", italian, complete)
+	}
+}
@@ -0,0 +1,233 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/board"
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/internal/parser"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+// Endgame classification limits
+const (
+	maxEndgamePieces   = 6 // Most pieces besides kings and pawns a position can have to count as an endgame
+	defaultReportGames = 50
+	maxReportGames     = 200
+)
+
+// GeneratePlayerReport replays a player's recent games and aggregates their results per endgame type
+// and per opening. Only the positions reached after openings played often enough are searched by the
+// engine, so it covers many more games than an analysis-based report; without an engine, openings
+// are reported without an assessment. The best and worst games are nominated among those that
+// already have a stored analysis.
+func (s *AnalyticsService) GeneratePlayerReport(ctx context.Context, request *models.PlayerReportRequest) (*models.PlayerReport, error) {
+	if request.Username == "" {
+		return nil, errors.NewValidationError("username", "username is required")
+	}
+	if request.Games <= 0 {
+		request.Games = defaultReportGames
+	}
+	if request.Games > maxReportGames {
+		request.Games = maxReportGames
+	}
+	settings := request.Settings
+	if settings.Depth == 0 {
+		settings.Depth = defaultDivergenceDepth
+	}
+	if settings.TimeLimit == 0 {
+		settings.TimeLimit = defaultDivergenceTimeout
+	}
+	if settings.TimeLimit < 0 || settings.TimeLimit > MaxDivergenceTimeLimit {
+		return nil, errors.NewValidationError("time_limit", fmt.Sprintf("time limit must be between 1 and %d milliseconds", MaxDivergenceTimeLimit))
+	}
+
+	games, archives, err := s.gameService.GetRecentGames(ctx, request.Username, request.Games)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.PlayerReport{
+		Username:       request.Username,
+		GeneratedAt:    time.Now(),
+		FailedArchives: failedArchives(archives),
+		Archives:       archives,
+	}
+	stats := make(map[string]*models.EndgameStat)
+	openings := newOpeningTallies()
+	var highlights gameHighlights
+	var analyses map[string]*models.GameAnalysis
+	if s.analysisService != nil {
+		analyses = s.analysisService.analysesByGameURL()
+	}
+
+	for _, game := range games {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		color, ok := playerColor(game, request.Username)
+		if !ok {
+			continue
+		}
+
+		parsed, err := s.pgnParser.ParsePGN(game.PGN)
+		if err != nil {
+			continue
+		}
+		if err := s.pgnParser.ExtractPositions(parsed); err != nil {
+			continue
+		}
+		score, decided := playerScore(parsed.Result, color)
+		if !decided {
+			continue
+		}
+		report.Games++
+		if analysis, ok := analyses[game.URL]; ok {
+			highlights.add(game, analysis, color, score)
+		}
+
+		fen := ""
+		if len(parsed.Moves) > divergencePly && parsed.Headers["fen"] == "" {
+			fen = parsed.Moves[divergencePly-1].FEN
+		}
+		openings.add(openingFromHeaders(parsed.Headers), color, score, fen)
+
+		endgameType, _ := classifyGameEndgame(parsed.Moves)
+		if endgameType == "" {
+			continue
+		}
+		report.EndgameGames++
+
+		stat, ok := stats[endgameType]
+		if !ok {
+			stat = &models.EndgameStat{Type: endgameType}
+			stats[endgameType] = stat
+		}
+		stat.Games++
+		switch score {
+		case 1:
+			stat.Wins++
+		case 0.5:
+			stat.Draws++
+		default:
+			stat.Losses++
+		}
+	}
+
+	for _, stat := range stats {
+		stat.Score = (float64(stat.Wins) + 0.5*float64(stat.Draws)) / float64(stat.Games) * 100
+		report.Endgames = append(report.Endgames, *stat)
+	}
+	highlights.apply(report)
+	sort.Slice(report.Endgames, func(i, j int) bool {
+		if report.Endgames[i].Games != report.Endgames[j].Games {
+			return report.Endgames[i].Games > report.Endgames[j].Games
+		}
+		return report.Endgames[i].Type < report.Endgames[j].Type
+	})
+
+	// The engine's assessment is opt-in, and a cut short one still leaves the rest of the report
+	if request.AssessOpenings && s.analysisService != nil && s.analysisService.EngineAvailable() {
+		report.OpeningsIncomplete = !openings.assess(ctx, func(ctx context.Context, fen string) (*models.AnalysisResult, error) {
+			return s.analysisService.AnalyzePosition(ctx, fen, settings)
+		})
+	}
+	report.Openings = openings.divergences()
+
+	return report, nil
+}
+
+// playerScore returns the points a side scored from a PGN result, or false for unfinished games
+func playerScore(result string, color board.Color) (float64, bool) {
+	switch result {
+	case "1-0":
+		if color == board.White {
+			return 1, true
+		}
+		return 0, true
+	case "0-1":
+		if color == board.Black {
+			return 1, true
+		}
+		return 0, true
+	case "1/2-1/2":
+		return 0.5, true
+	}
+	return 0, false
+}
+
+// classifyGameEndgame determines the endgame a game ended in from the positions after each move,
+// and the ply the endgame began at. Material only comes off the board, so the final phase is the
+// one of the last endgame position; earlier phases traded down from don't decide the type.
+// It returns an empty type if the game never reached an endgame.
+func classifyGameEndgame(moves []parser.ParsedMove) (string, int) {
+	final, start := "", 0
+	for i, move := range moves {
+		b, err := board.FromFEN(move.FEN)
+		if err != nil {
+			return "", 0
+		}
+
+		endgameType, ok := classifyEndgame(b)
+		if !ok {
+			continue
+		}
+		if start == 0 {
+			start = i + 1
+		}
+		final = endgameType
+	}
+	return final, start
+}
+
+// classifyEndgame returns the endgame type of a position, or false if it isn't an endgame yet
+func classifyEndgame(b *board.Board) (string, bool) {
+	var counts [7]int
+	var bishops [2][]bool // Square colors of each side's bishops
+	pieces := 0
+
+	for sq := board.Square(0); sq < 64; sq++ {
+		p := b.PieceAt(sq)
+		if p.IsEmpty() || p.Type == board.King || p.Type == board.Pawn {
+			continue
+		}
+		counts[p.Type]++
+		pieces++
+		if p.Type == board.Bishop {
+			bishops[p.Color] = append(bishops[p.Color], sq.IsLight())
+		}
+	}
+
+	if pieces > maxEndgamePieces {
+		return "", false
+	}
+
+	queens, rooks := counts[board.Queen], counts[board.Rook]
+	knights, bishopCount := counts[board.Knight], counts[board.Bishop]
+	minors := knights + bishopCount
+
+	switch {
+	case pieces == 0:
+		return models.EndgamePawn, true
+	case queens > 0 && rooks+minors == 0:
+		return models.EndgameQueen, true
+	case queens > 0:
+		return models.EndgameQueenPiece, true
+	case rooks > 0 && minors == 0:
+		return models.EndgameRook, true
+	case rooks > 0:
+		return models.EndgameRookMinor, true
+	case bishopCount == 0:
+		return models.EndgameKnight, true
+	case knights > 0:
+		return models.EndgameMinorPiece, true
+	case len(bishops[board.White]) == 1 && len(bishops[board.Black]) == 1 && bishops[board.White][0] != bishops[board.Black][0]:
+		return models.EndgameOppositeBishops, true
+	default:
+		return models.EndgameBishop, true
+	}
+}
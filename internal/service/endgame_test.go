@@ -0,0 +1,86 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/board"
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/internal/parser"
+)
+
+func TestClassifyEndgame(t *testing.T) {
+	tests := []struct {
+		name string
+		fen  string
+		want string
+		ok   bool
+	}{
+		{"starting position", board.StartFEN, "", false},
+		{"king and pawn", "8/4k3/8/4P3/4K3/8/8/8 w - - 0 1", models.EndgamePawn, true},
+		{"rook", "8/4k3/r7/4P3/4K3/8/8/R7 w - - 0 1", models.EndgameRook, true},
+		{"opposite bishops", "8/4k3/2b5/4P3/4K3/8/8/2B5 w - - 0 1", models.EndgameOppositeBishops, true},
+		{"same colored bishops", "8/4k3/1b6/4P3/4K3/8/8/2B5 w - - 0 1", models.EndgameBishop, true},
+		{"knight vs bishop", "8/4k3/2n5/4P3/4K3/8/8/2B5 w - - 0 1", models.EndgameMinorPiece, true},
+		{"rook and minor", "8/4k3/2n5/4P3/4K3/8/8/R7 w - - 0 1", models.EndgameRookMinor, true},
+		{"queen", "3q4/4k3/8/4P3/4K3/8/8/3Q4 w - - 0 1", models.EndgameQueen, true},
+		{"queen vs rook", "3r4/4k3/8/4P3/4K3/8/8/3Q4 w - - 0 1", models.EndgameQueenPiece, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := board.FromFEN(tt.fen)
+			if err != nil {
+				t.Fatalf("FromFEN() error = %v", err)
+			}
+			got, ok := classifyEndgame(b)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("classifyEndgame() = %q, %v, want %q, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestClassifyGameEndgame(t *testing.T) {
+	p := parser.NewPGNParser()
+	game, err := p.ParsePGN(`[FEN "8/4k3/2n5/4P3/4K3/8/8/2B5 w - - 0 40"]
+
+40. Bf4 Nxe5 41. Bxe5 Kd7 42. Kd5 Ke7 1/2-1/2`)
+	if err != nil {
+		t.Fatalf("ParsePGN() error = %v", err)
+	}
+	if err := p.ExtractPositions(game); err != nil {
+		t.Fatalf("ExtractPositions() error = %v", err)
+	}
+
+	// One ply as a minor piece endgame, then four plies with a lone bishop
+	endgameType, start := classifyGameEndgame(game.Moves)
+	if endgameType != models.EndgameBishop || start != 1 {
+		t.Errorf("classifyGameEndgame() = %q, %d, want %q, 1", endgameType, start, models.EndgameBishop)
+	}
+
+	// A long rook endgame traded down to a pawn endgame on the last plies ended as a pawn endgame
+	game, err = p.ParsePGN(`[FEN "8/p7/3k4/4r3/8/4R3/2K2P2/8 w - - 0 40"]
+
+40. Kd2 a6 41. Kc2 a5 42. Rxe5 Kxe5 1/2-1/2`)
+	if err != nil {
+		t.Fatalf("ParsePGN() error = %v", err)
+	}
+	if err := p.ExtractPositions(game); err != nil {
+		t.Fatalf("ExtractPositions() error = %v", err)
+	}
+	if endgameType, start := classifyGameEndgame(game.Moves); endgameType != models.EndgamePawn || start != 1 {
+		t.Errorf("classifyGameEndgame() = %q, %d, want %q, 1", endgameType, start, models.EndgamePawn)
+	}
+}
+
+func TestPlayerScore(t *testing.T) {
+	if score, ok := playerScore("0-1", board.Black); score != 1 || !ok {
+		t.Errorf("Expected a Black win, got %v, %v", score, ok)
+	}
+	if score, ok := playerScore("1/2-1/2", board.White); score != 0.5 || !ok {
+		t.Errorf("Expected a draw, got %v, %v", score, ok)
+	}
+	if _, ok := playerScore("*", board.White); ok {
+		t.Error("Expected unfinished games to be skipped")
+	}
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/cache"
+	"github.com/pedrampdd/ChessAnalyser/internal/engine"
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+)
+
+// defaultEvalCachePositions is how many position evaluations are kept until SetEvalCacheSize
+// applies the configured size
+const defaultEvalCachePositions = 10000
+
+// evalCache keeps the deepest engine result seen for each position. Entries are keyed by
+// position, engine and the settings that change the result apart from depth, so a request for a
+// shallower search is answered from a deeper one and a deeper search replaces the entry.
+type evalCache struct {
+	mu       sync.Mutex // Makes keeping the deeper result atomic
+	results  *cache.Cache[string, *models.AnalysisResult]
+	hits     int64
+	upgrades int64
+	misses   int64
+}
+
+// newEvalCache creates an evaluation cache holding at most maxPositions results (0 disables it)
+func newEvalCache(maxPositions int) *evalCache {
+	return &evalCache{results: cache.New[string, *models.AnalysisResult](maxPositions, 0)}
+}
+
+// evalCacheKey identifies a position analyzed by an engine. The move counters of the FEN don't
+// change the evaluation and are left out, as are the depth and the settings that only change
+// how fast a search finishes.
+func evalCacheKey(fen, engineVersion string, settings models.EngineSettings) string {
+	fields := strings.Fields(fen)
+	if len(fields) > 4 {
+		fields = fields[:4]
+	}
+	return fmt.Sprintf("%s|%s|%s_%d_%d_%s_%t_%d_%s", strings.Join(fields, " "), engineVersion,
+		strings.ToLower(settings.Variant), max(settings.MultiPV, 1), settings.SkillLevel, contemptKey(settings.Contempt),
+		settings.LimitStrength, settings.Elo, settings.EvalFile)
+}
+
+// contemptKey formats a requested contempt for a cache key, blank when the engine's configured one is used
+func contemptKey(contempt *int) string {
+	if contempt == nil {
+		return ""
+	}
+	return strconv.Itoa(*contempt)
+}
+
+// lookup returns the cached result of a position and whether it was searched at least to depth.
+// Searches without a target depth are never answered from the cache.
+func (c *evalCache) lookup(key string, depth int) (*models.AnalysisResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	result, ok := c.results.Get(key)
+	switch {
+	case !ok:
+		c.misses++
+		return nil, false
+	case depth > 0 && result.Depth >= depth:
+		c.hits++
+	default:
+		c.upgrades++
+	}
+	copied := *result
+	return &copied, depth > 0 && result.Depth >= depth
+}
+
+// store keeps a result unless the cache already holds a deeper one. Bounds and searches that
+// reached no depth aren't kept.
+func (c *evalCache) store(key string, result *models.AnalysisResult) {
+	if result == nil || result.ScoreBound != "" || result.Depth == 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if cached, ok := c.results.Get(key); ok && cached.Depth > result.Depth {
+		return
+	}
+	copied := *result
+	c.results.Set(key, &copied)
+}
+
+// stats reports the cache's use
+func (c *evalCache) stats() models.EvalCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := models.EvalCacheStats{
+		Positions:    c.results.Len(),
+		MaxPositions: c.results.MaxSize(),
+		Hits:         c.hits,
+		Upgrades:     c.upgrades,
+		Misses:       c.misses,
+	}
+	if lookups := c.hits + c.upgrades + c.misses; lookups > 0 {
+		stats.HitRate = float64(c.hits) / float64(lookups)
+	}
+	return stats
+}
+
+// SetEvalCacheSize replaces the position evaluation cache with one holding at most maxPositions
+// evaluations (0 disables it)
+func (s *AnalysisService) SetEvalCacheSize(maxPositions int) {
+	s.evals = newEvalCache(maxPositions)
+}
+
+// StreamPositionAnalysis analyzes a position, sending a cached evaluation at once when there is
+// one. A cached evaluation searched to the requested depth is final; a shallower one is followed
+// by the engine's result, which then replaces it in the cache. The channel is closed after the
+// final update.
+func (s *AnalysisService) StreamPositionAnalysis(ctx context.Context, fen string, settings models.EngineSettings) (<-chan models.PositionEvalUpdate, error) {
+	pool, err := s.poolFor(settings.Variant, "")
+	if err != nil {
+		return nil, err
+	}
+	key := evalCacheKey(fen, pool.Version, settings)
+
+	updates := make(chan models.PositionEvalUpdate, 2)
+	cached, deepEnough := s.evals.lookup(key, settings.Depth)
+	if cached != nil {
+		updates <- models.PositionEvalUpdate{Result: cached, Cached: true, Final: deepEnough}
+		if deepEnough {
+			close(updates)
+			return updates, nil
+		}
+	}
+
+	go func() {
+		defer close(updates)
+		result, err := s.searchPosition(ctx, pool, key, fen, settings)
+		if err != nil {
+			updates <- models.PositionEvalUpdate{Final: true, Error: err.Error()}
+			return
+		}
+		updates <- models.PositionEvalUpdate{Result: result, Final: true}
+	}()
+	return updates, nil
+}
+
+// searchPosition runs an engine search on a position and keeps the result in the evaluation cache
+func (s *AnalysisService) searchPosition(ctx context.Context, pool *engine.EnginePool, key, fen string,
+	settings models.EngineSettings) (*models.AnalysisResult, error) {
+	stockfishEngine, err := pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer pool.ReturnEngine(stockfishEngine)
+
+	result, err := stockfishEngine.AnalyzePosition(ctx, fen, settings)
+	if err != nil {
+		return nil, err
+	}
+	s.evals.store(key, result)
+	return result, nil
+}
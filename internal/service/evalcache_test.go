@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/engine"
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+)
+
+func TestEvalCache_KeepsDeepestResult(t *testing.T) {
+	evals := newEvalCache(10)
+	settings := models.EngineSettings{Depth: 18, TimeLimit: 5000, Threads: 4}
+	key := evalCacheKey("rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1", "Stockfish 16", settings)
+
+	// Move counters, depth, time and threads don't change the key; the engine does
+	other := models.EngineSettings{Depth: 10, Threads: 1}
+	if evalCacheKey("rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 3 9", "Stockfish 16", other) != key {
+		t.Error("Expected the same key for the same position and result-changing settings")
+	}
+	if evalCacheKey("rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1", "Stockfish 17", settings) == key {
+		t.Error("Expected another engine to get its own key")
+	}
+
+	if _, ok := evals.lookup(key, 18); ok {
+		t.Fatal("Expected a miss on an empty cache")
+	}
+	evals.store(key, &models.AnalysisResult{Evaluation: 0.3, Depth: 12})
+	if cached, ok := evals.lookup(key, 18); ok || cached == nil || cached.Depth != 12 {
+		t.Errorf("lookup(18) = %+v, %v, want the depth 12 result to upgrade", cached, ok)
+	}
+	if cached, ok := evals.lookup(key, 12); !ok || cached.Evaluation != 0.3 {
+		t.Errorf("lookup(12) = %+v, %v, want a hit", cached, ok)
+	}
+
+	// A deeper result replaces the entry; shallower results and bounds don't
+	evals.store(key, &models.AnalysisResult{Evaluation: 0.25, Depth: 20})
+	evals.store(key, &models.AnalysisResult{Evaluation: 0.9, Depth: 8})
+	evals.store(key, &models.AnalysisResult{Evaluation: 1.5, Depth: 22, ScoreBound: models.ScoreLowerBound})
+	if cached, ok := evals.lookup(key, 18); !ok || cached.Depth != 20 || cached.Evaluation != 0.25 {
+		t.Errorf("lookup(18) = %+v, %v, want the depth 20 result", cached, ok)
+	}
+
+	stats := evals.stats()
+	if stats.Positions != 1 || stats.Hits != 2 || stats.Upgrades != 1 || stats.Misses != 1 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
+
+func TestAnalysisService_StreamPositionAnalysis(t *testing.T) {
+	const fen = "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1"
+	pool := &engine.EnginePool{Version: "Stockfish 16"}
+	s := &AnalysisService{enginePool: pool, evals: newEvalCache(10)}
+	s.evals.store(evalCacheKey(fen, pool.Version, models.EngineSettings{}), &models.AnalysisResult{Evaluation: 0.3, Depth: 14})
+
+	// Deep enough: the cached evaluation is final
+	updates, err := s.StreamPositionAnalysis(context.Background(), fen, models.EngineSettings{Depth: 12})
+	if err != nil {
+		t.Fatalf("StreamPositionAnalysis() error = %v", err)
+	}
+	var received []models.PositionEvalUpdate
+	for update := range updates {
+		received = append(received, update)
+	}
+	if len(received) != 1 || !received[0].Cached || !received[0].Final || received[0].Result.Depth != 14 {
+		t.Errorf("Unexpected updates: %+v", received)
+	}
+
+	// Shallower: the cached evaluation comes first, then the deeper search (which fails here,
+	// since the pool has no engines and the request is gone)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if updates, err = s.StreamPositionAnalysis(ctx, fen, models.EngineSettings{Depth: 20}); err != nil {
+		t.Fatalf("StreamPositionAnalysis() error = %v", err)
+	}
+	received = nil
+	for update := range updates {
+		received = append(received, update)
+	}
+	if len(received) != 2 || !received[0].Cached || received[0].Final || received[0].Result.Evaluation != 0.3 ||
+		!received[1].Final || received[1].Error == "" {
+		t.Errorf("Unexpected updates: %+v", received)
+	}
+}
+
+func TestAnalysisService_GenerateCacheKeyStrengthSettings(t *testing.T) {
+	service := newTestAnalysisService()
+	zero := 0
+	base := models.AnalysisRequest{PGN: "1. e4 e5 *", Settings: models.EngineSettings{Depth: 15}}
+
+	tests := []struct {
+		name   string
+		modify func(*models.EngineSettings)
+	}{
+		{"skill level", func(s *models.EngineSettings) { s.SkillLevel = 5 }},
+		{"contempt", func(s *models.EngineSettings) { s.Contempt = &zero }},
+		{"limited strength", func(s *models.EngineSettings) { s.LimitStrength, s.Elo = true, 1500 }},
+		{"eval file", func(s *models.EngineSettings) { s.EvalFile = "nn-small.nnue" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := base
+			tt.modify(&request.Settings)
+			if service.generateCacheKey(&request) == service.generateCacheKey(&base) {
+				t.Errorf("Requests differing only in %s share a cache key", tt.name)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"math"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+)
+
+// Win/draw/loss model parameters, in pawns. Engines scale evaluations so that +1.00 wins about half the
+// time; the spread sets how quickly results become decisive, giving level positions a 60% draw rate.
+const (
+	wdlMidpoint = 1.0
+	wdlSpread   = 0.72
+)
+
+// winDrawLoss converts a White-relative evaluation in pawns to White's win, draw and loss probabilities
+func winDrawLoss(evaluation float64) (float64, float64, float64) {
+	win := 1 / (1 + math.Exp((wdlMidpoint-evaluation)/wdlSpread))
+	loss := 1 / (1 + math.Exp((wdlMidpoint+evaluation)/wdlSpread))
+	return win, 1 - win - loss, loss
+}
+
+// expectedPoints returns White's expected points in a position: win probability plus half the draw probability
+func expectedPoints(evaluation float64) float64 {
+	win, draw, _ := winDrawLoss(evaluation)
+	return win + draw/2
+}
+
+// moveExpectedPoints returns White's expected points after a move. The engine's own win/draw/loss odds
+// are preferred: they account for the material left, which the evaluation-only model can't.
+func moveExpectedPoints(move *models.MoveAnalysis) float64 {
+	if move.WDL != nil {
+		return move.WDL.ExpectedScore()
+	}
+	return expectedPoints(move.Evaluation)
+}
+
+// applyExpectedPoints records the expected points after every analyzed move, the points each mover gave away,
+// and the game's momentum series. A move following a ply the engine skipped has no known loss.
+func applyExpectedPoints(analysis *models.GameAnalysis) {
+	analysis.Momentum = make([]models.MomentumPoint, 0, len(analysis.Moves))
+	tally := newExpectedPointsTally()
+	for i := range analysis.Moves {
+		tally.add(analysis, &analysis.Moves[i])
+	}
+}
+
+// expectedPointsTally applies expected points one move at a time, in ply order, for analyses
+// that don't hold all their moves at once
+type expectedPointsTally struct {
+	before      float64 // White's expected points before the next move
+	previousPly int
+}
+
+// newExpectedPointsTally starts a tally at the initial position
+func newExpectedPointsTally() *expectedPointsTally {
+	return &expectedPointsTally{before: expectedPoints(0)}
+}
+
+// add records the expected points of the next analyzed move and appends it to the momentum series
+func (t *expectedPointsTally) add(analysis *models.GameAnalysis, move *models.MoveAnalysis) {
+	after := moveExpectedPoints(move)
+	move.ExpectedPoints = after
+
+	if move.MoveNumber == t.previousPly+1 {
+		lost := t.before - after // From White's point of view
+		if moveColor(move.FEN, move.MoveNumber) == "black" {
+			lost = -lost
+		}
+		move.ExpectedPointsLost = math.Max(lost, 0)
+	}
+	if moveColor(move.FEN, move.MoveNumber) == "white" {
+		analysis.Accuracy.WhiteExpectedPointsLost += move.ExpectedPointsLost
+	} else {
+		analysis.Accuracy.BlackExpectedPointsLost += move.ExpectedPointsLost
+	}
+
+	analysis.Momentum = append(analysis.Momentum, models.MomentumPoint{
+		Ply:            move.MoveNumber,
+		ExpectedPoints: after,
+		WhiteLost:      analysis.Accuracy.WhiteExpectedPointsLost,
+		BlackLost:      analysis.Accuracy.BlackExpectedPointsLost,
+	})
+	t.before, t.previousPly = after, move.MoveNumber
+}
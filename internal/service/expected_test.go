@@ -0,0 +1,117 @@
+package service
+
+import (
+	"math"
+	"testing"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+)
+
+func TestWinDrawLoss(t *testing.T) {
+	win, draw, loss := winDrawLoss(0)
+	if math.Abs(win-loss) > 1e-9 || draw < 0.5 || draw > 0.7 {
+		t.Errorf("Expected a level position to be mostly drawn, got %.3f/%.3f/%.3f", win, draw, loss)
+	}
+	if got := expectedPoints(0); math.Abs(got-0.5) > 1e-9 {
+		t.Errorf("expectedPoints(0) = %.3f, want 0.5", got)
+	}
+
+	win, _, _ = winDrawLoss(wdlMidpoint)
+	if math.Abs(win-0.5) > 1e-9 {
+		t.Errorf("Expected a %.1f pawn edge to win half the time, got %.3f", wdlMidpoint, win)
+	}
+
+	for _, evaluation := range []float64{0.3, 1.5, 4} {
+		if got := expectedPoints(evaluation) + expectedPoints(-evaluation); math.Abs(got-1) > 1e-9 {
+			t.Errorf("Expected points for %.1f and %.1f should add up to 1, got %.3f", evaluation, -evaluation, got)
+		}
+	}
+
+	if got := expectedPoints(999); got < 0.999 {
+		t.Errorf("Expected a forced mate to be worth a full point, got %.4f", got)
+	}
+}
+
+func TestApplyExpectedPoints(t *testing.T) {
+	analysis := &models.GameAnalysis{Moves: []models.MoveAnalysis{
+		{MoveNumber: 1, Evaluation: 0.3},
+		{MoveNumber: 2, Evaluation: 2.0},  // Black blunders
+		{MoveNumber: 3, Evaluation: 0.0},  // White gives it back
+		{MoveNumber: 4, Evaluation: -0.5}, // Black takes over
+		{MoveNumber: 6, Evaluation: -3.0}, // Follows a skipped ply
+	}}
+	applyExpectedPoints(analysis)
+
+	moves := analysis.Moves
+	if moves[0].ExpectedPointsLost != 0 || moves[3].ExpectedPointsLost != 0 {
+		t.Errorf("Expected moves that improve the mover's position to lose nothing, got %.3f and %.3f",
+			moves[0].ExpectedPointsLost, moves[3].ExpectedPointsLost)
+	}
+	if want := expectedPoints(2.0) - expectedPoints(0.3); math.Abs(moves[1].ExpectedPointsLost-want) > 1e-9 {
+		t.Errorf("Black's blunder lost %.3f, want %.3f", moves[1].ExpectedPointsLost, want)
+	}
+	if moves[4].ExpectedPointsLost != 0 {
+		t.Errorf("Expected no loss after a skipped ply, got %.3f", moves[4].ExpectedPointsLost)
+	}
+
+	accuracy := analysis.Accuracy
+	if math.Abs(accuracy.WhiteExpectedPointsLost-moves[2].ExpectedPointsLost) > 1e-9 {
+		t.Errorf("WhiteExpectedPointsLost = %.3f, want %.3f", accuracy.WhiteExpectedPointsLost, moves[2].ExpectedPointsLost)
+	}
+	if math.Abs(accuracy.BlackExpectedPointsLost-moves[1].ExpectedPointsLost) > 1e-9 {
+		t.Errorf("BlackExpectedPointsLost = %.3f, want %.3f", accuracy.BlackExpectedPointsLost, moves[1].ExpectedPointsLost)
+	}
+
+	if len(analysis.Momentum) != len(moves) {
+		t.Fatalf("Expected a momentum point per move, got %d", len(analysis.Momentum))
+	}
+	last := analysis.Momentum[len(analysis.Momentum)-1]
+	if last.Ply != 6 || last.ExpectedPoints != moves[4].ExpectedPoints || last.WhiteLost != accuracy.WhiteExpectedPointsLost || last.BlackLost != accuracy.BlackExpectedPointsLost {
+		t.Errorf("Unexpected final momentum point %+v", last)
+	}
+}
+
+func TestApplyExpectedPointsPrefersEngineWDL(t *testing.T) {
+	analysis := &models.GameAnalysis{Moves: []models.MoveAnalysis{
+		{MoveNumber: 1, Evaluation: 0.3, WDL: &models.WDL{Win: 100, Draw: 880, Loss: 20}},
+		{MoveNumber: 2, Evaluation: 1.0}, // No engine odds
+	}}
+	applyExpectedPoints(analysis)
+
+	if got := analysis.Moves[0].ExpectedPoints; math.Abs(got-0.54) > 1e-9 {
+		t.Errorf("ExpectedPoints = %.3f, want the engine's 0.540", got)
+	}
+	if got, want := analysis.Moves[1].ExpectedPoints, expectedPoints(1.0); math.Abs(got-want) > 1e-9 {
+		t.Errorf("ExpectedPoints = %.3f, want the model's %.3f", got, want)
+	}
+	if got, want := analysis.Moves[1].ExpectedPointsLost, math.Max(expectedPoints(1.0)-0.54, 0); math.Abs(got-want) > 1e-9 {
+		t.Errorf("ExpectedPointsLost = %.3f, want %.3f", got, want)
+	}
+}
+
+func TestExpectedPointsTally(t *testing.T) {
+	moves := []models.MoveAnalysis{
+		{MoveNumber: 1, Evaluation: 0.3},
+		{MoveNumber: 2, Evaluation: 2.0},
+		{MoveNumber: 3, Evaluation: 0.0},
+		{MoveNumber: 5, Evaluation: -3.0}, // Follows a skipped ply
+		{MoveNumber: 6, Evaluation: -1.0},
+	}
+	whole := &models.GameAnalysis{Moves: append([]models.MoveAnalysis(nil), moves...)}
+	applyExpectedPoints(whole)
+
+	// Streamed analyses tally moves one at a time, without holding the earlier ones
+	streamed := &models.GameAnalysis{}
+	tally := newExpectedPointsTally()
+	for i := range moves {
+		move := moves[i]
+		tally.add(streamed, &move)
+		if move.ExpectedPoints != whole.Moves[i].ExpectedPoints || move.ExpectedPointsLost != whole.Moves[i].ExpectedPointsLost {
+			t.Errorf("Ply %d: got %+v, want %+v", move.MoveNumber, move, whole.Moves[i])
+		}
+	}
+	if streamed.Accuracy != whole.Accuracy || len(streamed.Momentum) != len(whole.Momentum) ||
+		streamed.Momentum[len(moves)-1] != whole.Momentum[len(moves)-1] {
+		t.Errorf("Tallied %+v / %+v, want %+v / %+v", streamed.Accuracy, streamed.Momentum, whole.Accuracy, whole.Momentum)
+	}
+}
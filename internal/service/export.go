@@ -0,0 +1,190 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+// Supported analysis export formats
+const (
+	ExportFormatJSON = "json"
+	ExportFormatPGN  = "pgn"
+	ExportFormatHTML = "html"
+	ExportFormatGIF  = "gif"
+)
+
+// Board orientations of exports: the side shown at the bottom of the board
+const (
+	OrientationWhite = "white"
+	OrientationBlack = "black"
+)
+
+// ExportOptions tune how an analysis is exported
+type ExportOptions struct {
+	Notation    string // Display notation of the moves in JSON exports
+	Orientation string // Side shown at the bottom of boards, one of the Orientation* constants (empty = white)
+}
+
+// ExportAnalysis renders a stored analysis, including user edits, in the requested format.
+// JSON exports write the moves in the given display notation; PGN exports always use SAN, which
+// is all PGN readers accept, and record the board orientation in an Orientation tag. HTML reports
+// and GIF animations draw their boards from the orientation's side. It returns the exported
+// content and its MIME type.
+func (s *AnalysisService) ExportAnalysis(analysisID, format string, options ExportOptions) ([]byte, string, error) {
+	if err := validateOrientation(options.Orientation); err != nil {
+		return nil, "", err
+	}
+	analysis, err := s.GetAnalysis(analysisID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	switch format {
+	case "", ExportFormatJSON:
+		notated, err := s.NotateAnalysis(analysis, options.Notation)
+		if err != nil {
+			return nil, "", err
+		}
+		data, err := json.MarshalIndent(notated, "", "  ")
+		if err != nil {
+			return nil, "", errors.NewAPIError("failed to encode analysis", err)
+		}
+		return data, "application/json", nil
+	case ExportFormatPGN:
+		pgn, err := s.exportPGN(analysis)
+		if err != nil {
+			return nil, "", err
+		}
+		if options.Orientation == OrientationBlack {
+			pgn = withPGNTag(pgn, "Orientation", OrientationBlack)
+		}
+		return []byte(pgn), "application/x-chess-pgn", nil
+	case ExportFormatHTML:
+		data, err := s.exportHTML(analysis, options.Orientation)
+		if err != nil {
+			return nil, "", err
+		}
+		return data, "text/html; charset=utf-8", nil
+	case ExportFormatGIF:
+		data, err := s.exportGIF(analysis, options.Orientation)
+		if err != nil {
+			return nil, "", err
+		}
+		return data, "image/gif", nil
+	default:
+		return nil, "", errors.NewValidationError("format", fmt.Sprintf("unsupported export format: %s", format))
+	}
+}
+
+// validateOrientation checks a requested board orientation
+func validateOrientation(orientation string) error {
+	switch orientation {
+	case "", OrientationWhite, OrientationBlack:
+		return nil
+	}
+	return errors.NewValidationError("orientation", "must be one of: white, black")
+}
+
+// withPGNTag adds a tag after the existing tags of a formatted PGN game
+func withPGNTag(pgn, name, value string) string {
+	tags, movetext, _ := strings.Cut(pgn, "\n\n")
+	return fmt.Sprintf("%s\n[%s \"%s\"]\n\n%s", tags, name, value, movetext)
+}
+
+// Prefixes telling apart the engine's and the user's part of a move comment in exported PGN
+const (
+	engineCommentPrefix = "Engine:"
+	userCommentPrefix   = "User:"
+)
+
+// exportPGN renders the analyzed game as PGN, annotating every move with the engine's evaluation
+// and classification and with the user's edits
+func (s *AnalysisService) exportPGN(analysis *models.GameAnalysis) (string, error) {
+	game, err := s.pgnParser.ParsePGN(analysis.PGN)
+	if err != nil {
+		return "", errors.NewAPIError("failed to parse analyzed PGN", err)
+	}
+
+	for i := range game.Moves {
+		idx := findMoveIndex(analysis.Moves, i+1)
+		if idx == -1 {
+			continue
+		}
+		move := analysis.Moves[idx]
+		game.Moves[i].NAG = classificationNAG(move)
+		game.Moves[i].Comment = strings.TrimSpace(engineAnnotationComment(move) + " " + userAnnotationComment(move))
+	}
+
+	return s.pgnParser.FormatPGN(game), nil
+}
+
+// engineAnnotationComment builds the PGN comment for the engine's view of a move: an [%eval]
+// command and, for flagged moves, the classification and accuracy
+func engineAnnotationComment(move models.MoveAnalysis) string {
+	parts := []string{pgnEvalCommand(move.Evaluation)}
+	if classification := engineClassification(move); classification != "" {
+		parts = append(parts, fmt.Sprintf("%s %s (accuracy %.1f%%).", engineCommentPrefix, classification, move.Accuracy))
+	} else if move.ScoreBound != "" {
+		parts = append(parts, fmt.Sprintf("%s evaluation is only a %s.", engineCommentPrefix, move.ScoreBound))
+	}
+	return strings.Join(parts, " ")
+}
+
+// userAnnotationComment builds the PGN comment for a move's user edits
+func userAnnotationComment(move models.MoveAnalysis) string {
+	var parts []string
+	if move.ClassificationOverride != "" {
+		parts = append(parts, fmt.Sprintf("[%%class %s]", move.ClassificationOverride))
+	}
+	if move.UserComment != "" {
+		parts = append(parts, move.UserComment)
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return userCommentPrefix + " " + strings.Join(parts, " ")
+}
+
+// engineClassification returns the engine's classification of a flagged move, or "" for others
+func engineClassification(move models.MoveAnalysis) string {
+	switch {
+	case move.Blunder:
+		return "blunder"
+	case move.Mistake:
+		return "mistake"
+	case move.Inaccuracy:
+		return "inaccuracy"
+	case move.Miss:
+		return "miss"
+	}
+	return ""
+}
+
+// classificationNAG returns the Numeric Annotation Glyph of the engine's classification of a move
+func classificationNAG(move models.MoveAnalysis) string {
+	switch {
+	case move.Blunder:
+		return "$4"
+	case move.Mistake:
+		return "$2"
+	case move.Inaccuracy:
+		return "$6"
+	}
+	return ""
+}
+
+// pgnEvalCommand renders an evaluation as an [%eval] command, in pawns or as "#N" for mates
+func pgnEvalCommand(evaluation float64) string {
+	switch {
+	case evaluation >= 900:
+		return fmt.Sprintf("[%%eval #%d]", int(math.Round(1000-evaluation)))
+	case evaluation <= -900:
+		return fmt.Sprintf("[%%eval #-%d]", int(math.Round(1000+evaluation)))
+	}
+	return fmt.Sprintf("[%%eval %.2f]", evaluation)
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+)
+
+func TestExtendSwing(t *testing.T) {
+	const fen = "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1"
+
+	tests := []struct {
+		name         string
+		result       models.AnalysisResult
+		before       float64
+		request      models.AnalysisRequest
+		wantExtended bool
+		wantDepth    int
+	}{
+		{"small swing", models.AnalysisResult{Evaluation: 0.5, Depth: 12}, 0.2, models.AnalysisRequest{}, false, 0},
+		{"default depth increase", models.AnalysisResult{Evaluation: -3, Depth: 12}, 0.2, models.AnalysisRequest{}, true, 16},
+		{"requested depth", models.AnalysisResult{Evaluation: -3, Depth: 12}, 0.2, models.AnalysisRequest{ExtendDepth: 22}, true, 22},
+		{"depth capped", models.AnalysisResult{Evaluation: -3, Depth: 28}, 0.2, models.AnalysisRequest{}, true, MaxExtendDepth},
+		{"already at the cap", models.AnalysisResult{Evaluation: -3, Depth: MaxExtendDepth}, 0.2, models.AnalysisRequest{}, false, 0},
+		{"custom swing", models.AnalysisResult{Evaluation: 1.5, Depth: 12}, 0.2, models.AnalysisRequest{ExtendSwing: 1}, true, 16},
+		{"forced mate", models.AnalysisResult{Evaluation: mateEvaluation, Depth: 12}, 0.2, models.AnalysisRequest{}, false, 0},
+		{"bound", models.AnalysisResult{Evaluation: -3, Depth: 12, ScoreBound: "upper"}, 0.2, models.AnalysisRequest{}, false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var searched models.EngineSettings
+			var searchedTime int
+			search := func(ctx context.Context, fen string, settings models.EngineSettings, maxTime int) (*models.AnalysisResult, error) {
+				searched, searchedTime = settings, maxTime
+				return &models.AnalysisResult{Evaluation: -0.4, Depth: settings.Depth, Nodes: 1000, Time: 800}, nil
+			}
+
+			original := tt.result
+			original.Nodes, original.Time = 500, 200
+			result, extended := extendSwing(context.Background(), search, fen, models.EngineSettings{Depth: 12, TimeLimit: 1000},
+				&original, tt.before, &tt.request)
+			if extended != tt.wantExtended {
+				t.Fatalf("extended = %v, want %v", extended, tt.wantExtended)
+			}
+			if !extended {
+				if result != &original {
+					t.Error("Expected the original result to be kept")
+				}
+				return
+			}
+
+			if searched.Depth != tt.wantDepth {
+				t.Errorf("searched depth = %v, want %v", searched.Depth, tt.wantDepth)
+			}
+			if searchedTime != extendTimeLimit {
+				t.Errorf("searched time = %v, want %v", searchedTime, extendTimeLimit)
+			}
+			if result.Evaluation != -0.4 || result.Nodes != 1500 || result.Time != 1000 {
+				t.Errorf("Expected the deeper result with the shallow search's effort added, got %+v", result)
+			}
+		})
+	}
+}
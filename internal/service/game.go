@@ -1,34 +1,67 @@
 package service
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/pedrampdd/ChessAnalyser/internal/cache"
 	"github.com/pedrampdd/ChessAnalyser/internal/client"
+	"github.com/pedrampdd/ChessAnalyser/internal/engine"
 	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/internal/parser"
+	"github.com/pedrampdd/ChessAnalyser/internal/storage"
 	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
 )
 
+// Game cache defaults, until SetGameCacheOptions applies the configured ones
+const (
+	defaultGameCacheSize = 500
+	defaultGameCacheTTL  = time.Hour
+)
+
+// playerGameSearchMonths is how many of a player's latest monthly archives FindPlayerGame searches
+const playerGameSearchMonths = 3
+
 // GameAnalyzerService represents the main service for game analysis
 type GameAnalyzerService struct {
-	chessAPI  *client.ChessComAPI
-	gameCache map[string]*models.GameInfo
+	chessAPI       *client.ChessComAPI
+	pgnParser      *parser.PGNParser
+	gameCache      *cache.Cache[string, *models.GameInfo]
+	archiveWorkers int          // Archives fetched at the same time for reports
+	limiter        *hostLimiter // Rate limit of archive fetches, nil for none
+
+	archiveWalkTimeout time.Duration // Longest walk through a player's archives for a report, 0 for none
 }
 
 // NewGameAnalyzerService creates a new game analyzer service instance
 func NewGameAnalyzerService() *GameAnalyzerService {
 	return &GameAnalyzerService{
-		chessAPI:  client.NewChessComAPI(),
-		gameCache: make(map[string]*models.GameInfo),
+		chessAPI:       client.NewChessComAPI(),
+		pgnParser:      parser.NewPGNParser(),
+		gameCache:      cache.New[string, *models.GameInfo](defaultGameCacheSize, defaultGameCacheTTL),
+		archiveWorkers: defaultArchiveWorkers,
 	}
 }
 
+// SetChessAPI replaces the Chess.com client, e.g. with one routed through a proxy
+func (s *GameAnalyzerService) SetChessAPI(api *client.ChessComAPI) {
+	s.chessAPI = api
+}
+
+// SetGameCacheOptions replaces the game cache with one holding at most maxSize games for at
+// most ttl each. A maxSize of 0 disables caching; a ttl of 0 keeps games until evicted.
+func (s *GameAnalyzerService) SetGameCacheOptions(maxSize int, ttl time.Duration) {
+	s.gameCache = cache.New[string, *models.GameInfo](maxSize, ttl)
+}
+
 // GetGameByID retrieves game information by game ID
 func (s *GameAnalyzerService) GetGameByID(gameID string) (*models.GameInfo, error) {
 	// Check cache first
-	if gameInfo, exists := s.gameCache[gameID]; exists {
+	if gameInfo, exists := s.gameCache.Get(gameID); exists {
 		return gameInfo, nil
 	}
 
@@ -38,25 +71,213 @@ func (s *GameAnalyzerService) GetGameByID(gameID string) (*models.GameInfo, erro
 		return nil, errors.NewGameNotFoundError(gameID, err)
 	}
 
-	// Cache the result
-	s.gameCache[gameID] = gameInfo
+	// Cache the result, unless it's a live game still in progress
+	if gameInfo.EndTime != nil {
+		s.gameCache.Set(gameID, gameInfo)
+	}
 	return gameInfo, nil
 }
 
-// GetPlayerGames retrieves player's games for a specific month
-func (s *GameAnalyzerService) GetPlayerGames(username string, year, month int) (*models.GameInfo, error) {
+// GetPlayerGames retrieves player's games for a specific month, applying the given filter
+func (s *GameAnalyzerService) GetPlayerGames(username string, year, month int, filter models.GameFilter) ([]*models.GameInfo, error) {
+	return s.playerGames(context.Background(), username, year, month, filter)
+}
 
-	gameData, err := s.chessAPI.GetPlayerGames(username, year, month)
+// playerGames is GetPlayerGames with a context that aborts the archive download
+func (s *GameAnalyzerService) playerGames(ctx context.Context, username string, year, month int, filter models.GameFilter) ([]*models.GameInfo, error) {
+	if err := validateGameFilter(filter); err != nil {
+		return nil, err
+	}
+
+	var games []*models.GameInfo
+	err := s.chessAPI.StreamPlayerGamesContext(ctx, username, year, month, func(game *client.ArchiveGame) error {
+		if gameInfo := gameInfoFromArchive(game); matchesGameFilter(gameInfo, filter) {
+			games = append(games, gameInfo)
+		}
+		return nil
+	})
 	if err != nil {
 		return nil, errors.NewAPIError("failed to retrieve games", err)
 	}
 
-	gameInfo, err := s.parseGameData(gameData["games"].([]any)[0].(map[string]any))
+	return games, nil
+}
+
+// GetPlayerGamesPage retrieves one page of a player's games for a month in archive order, applying
+// the given filter. The archive is decoded as a stream and only the requested page is kept in memory.
+func (s *GameAnalyzerService) GetPlayerGamesPage(username string, year, month int, filter models.GameFilter, request models.PageRequest) (*models.Page[*models.GameInfo], error) {
+	if err := validateGameFilter(filter); err != nil {
+		return nil, err
+	}
+	query, err := storage.ParsePageRequest(storage.ListMonthlyGames, request)
 	if err != nil {
-		return nil, errors.NewAPIError("failed to parse games", err)
+		return nil, err
 	}
 
-	return gameInfo, nil
+	// Games are keyed by their position in the archive, which filtering doesn't change
+	type keyedGame struct {
+		key  string
+		game *models.GameInfo
+	}
+	var window []keyedGame
+	var before, after int // Matching games before and after the window
+	index := 0
+	err = s.chessAPI.StreamPlayerGames(username, year, month, func(game *client.ArchiveGame) error {
+		key := storage.IndexKey(index)
+		index++
+		gameInfo := gameInfoFromArchive(game)
+		if !matchesGameFilter(gameInfo, filter) {
+			return nil
+		}
+
+		if query.Before != "" {
+			// The window holds the latest games before the cursor
+			if key >= query.Before {
+				after++
+				return nil
+			}
+			window = append(window, keyedGame{key, gameInfo})
+			if len(window) > query.Limit {
+				window = window[1:]
+				before++
+			}
+			return nil
+		}
+
+		switch {
+		case query.After != "" && key <= query.After:
+			before++
+		case len(window) < query.Limit:
+			window = append(window, keyedGame{key, gameInfo})
+		default:
+			after++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.NewAPIError("failed to retrieve games", err)
+	}
+
+	page := &models.Page[*models.GameInfo]{
+		Items:         make([]*models.GameInfo, 0, len(window)),
+		TotalEstimate: before + len(window) + after,
+	}
+	for _, game := range window {
+		page.Items = append(page.Items, game.game)
+	}
+	if len(window) > 0 && after > 0 {
+		page.NextCursor = storage.NextCursor(storage.ListMonthlyGames, window[len(window)-1].key)
+	}
+	if len(window) > 0 && before > 0 {
+		page.PrevCursor = storage.PrevCursor(storage.ListMonthlyGames, window[0].key)
+	}
+	return page, nil
+}
+
+// GetPlayerGamesIfModified retrieves a monthly archive only if it changed since etag.
+// It returns the games along with the archive's raw response, or a nil response if the archive
+// wasn't modified.
+func (s *GameAnalyzerService) GetPlayerGamesIfModified(username string, year, month int, etag string) ([]*models.GameInfo, *client.ArchiveResponse, error) {
+	gameData, archive, err := s.chessAPI.GetPlayerGamesIfModified(username, year, month, etag)
+	if err == client.ErrNotModified {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, errors.NewAPIError("failed to retrieve games", err)
+	}
+
+	games, err := s.parseGames(gameData)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return games, archive, nil
+}
+
+// OpenPlayerGamesPGN opens a player's whole month of games as one multi-game PGN download
+func (s *GameAnalyzerService) OpenPlayerGamesPGN(username string, year, month int) (io.ReadCloser, error) {
+	body, err := s.chessAPI.OpenPlayerGamesPGN(username, year, month)
+	if err != nil {
+		return nil, errors.NewAPIError("failed to download games", err)
+	}
+	return body, nil
+}
+
+// parseGames parses the games of a monthly archive response
+func (s *GameAnalyzerService) parseGames(gameData map[string]any) ([]*models.GameInfo, error) {
+	rawGames, _ := gameData["games"].([]any)
+	games := make([]*models.GameInfo, 0, len(rawGames))
+	for _, rawGame := range rawGames {
+		data, ok := rawGame.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		gameInfo, err := s.parseGameData(data)
+		if err != nil {
+			return nil, errors.NewAPIError("failed to parse games", err)
+		}
+		games = append(games, gameInfo)
+	}
+
+	return games, nil
+}
+
+// validateGameFilter checks that filter values are supported
+func validateGameFilter(filter models.GameFilter) error {
+	switch filter.Bots {
+	case "", models.BotFilterInclude, models.BotFilterExclude, models.BotFilterOnly:
+		return nil
+	default:
+		return errors.NewValidationError("bots", fmt.Sprintf("must be one of: %s, %s, %s",
+			models.BotFilterInclude, models.BotFilterExclude, models.BotFilterOnly))
+	}
+}
+
+// filterGames applies a game filter to a list of games
+func filterGames(games []*models.GameInfo, filter models.GameFilter) []*models.GameInfo {
+	if filter.Bots == "" || filter.Bots == models.BotFilterInclude {
+		return games
+	}
+
+	filtered := make([]*models.GameInfo, 0, len(games))
+	for _, game := range games {
+		if matchesGameFilter(game, filter) {
+			filtered = append(filtered, game)
+		}
+	}
+	return filtered
+}
+
+// matchesGameFilter reports whether a game passes the filter
+func matchesGameFilter(game *models.GameInfo, filter models.GameFilter) bool {
+	vsBot := game.WhitePlayer.IsBot || game.BlackPlayer.IsBot
+	switch filter.Bots {
+	case models.BotFilterExclude:
+		return !vsBot
+	case models.BotFilterOnly:
+		return vsBot
+	default:
+		return true
+	}
+}
+
+// isBotPlayer detects Chess.com bots and computer opponents from player data
+func isBotPlayer(data map[string]any) bool {
+	return isBot(getStringValue(data, "username"), getStringValue(data, "title"), getStringValue(data, "@id"))
+}
+
+// isBot detects Chess.com bots and computer opponents from a player's username, title and profile ID
+func isBot(username, title, id string) bool {
+	if strings.EqualFold(title, "BOT") {
+		return true
+	}
+
+	username = strings.ToLower(username)
+	return strings.HasPrefix(username, "computer") ||
+		strings.HasSuffix(username, "-bot") ||
+		strings.HasSuffix(username, "_bot") ||
+		strings.Contains(id, "/computer")
 }
 
 // GetPlayerProfile retrieves player profile information
@@ -69,12 +290,284 @@ func (s *GameAnalyzerService) GetPlayerStats(username string) (map[string]any, e
 	return s.chessAPI.GetPlayerStats(username)
 }
 
+// GetPlayerTournaments retrieves the player's Chess.com tournament history
+func (s *GameAnalyzerService) GetPlayerTournaments(username string) (*client.PlayerTournaments, error) {
+	tournaments, err := s.chessAPI.GetPlayerTournaments(username)
+	if err != nil {
+		return nil, errors.NewAPIError("failed to retrieve player tournaments", err)
+	}
+	return tournaments, nil
+}
+
+// GetPlayerRating returns the player's current rating for a time class (e.g. rapid, blitz, bullet, daily)
+func (s *GameAnalyzerService) GetPlayerRating(username, timeClass string) (int, error) {
+	stats, err := s.chessAPI.GetPlayerStats(username)
+	if err != nil {
+		return 0, errors.NewAPIError("failed to retrieve player stats", err)
+	}
+
+	category, _ := stats["chess_"+timeClass].(map[string]any)
+	last, _ := category["last"].(map[string]any)
+	rating := int(getFloatValue(last, "rating"))
+	if rating == 0 {
+		return 0, errors.NewValidationError("time_class", fmt.Sprintf("player %s has no %s rating", username, timeClass))
+	}
+
+	return rating, nil
+}
+
+// GetPlayerArchives returns the player's monthly archives as (year, month) pairs, oldest first
+func (s *GameAnalyzerService) GetPlayerArchives(ctx context.Context, username string) ([][2]int, error) {
+	data, err := s.chessAPI.GetPlayerArchivesContext(ctx, username)
+	if err != nil {
+		return nil, errors.NewAPIError("failed to retrieve archives", err)
+	}
+
+	rawArchives, _ := data["archives"].([]any)
+	archives := make([][2]int, 0, len(rawArchives))
+	for _, raw := range rawArchives {
+		url, ok := raw.(string)
+		if !ok {
+			continue
+		}
+		if year, month, ok := parseArchiveURL(url); ok {
+			archives = append(archives, [2]int{year, month})
+		}
+	}
+
+	return archives, nil
+}
+
+// GetRecentGames returns the player's most recent standard chess games against humans. Archives
+// are fetched newest first, a batch of months at a time, until enough games are found, and the
+// status of every month walked is returned. Months that fail or have no archive are skipped; when
+// the context is cancelled or the walk times out, the games found so far are returned, and the
+// months not reached yet are reported as cancelled. The call fails when months failed and none
+// could be fetched, or when the walk ended before any month was.
+func (s *GameAnalyzerService) GetRecentGames(ctx context.Context, username string, limit int) ([]*models.GameInfo, []models.ArchiveStatus, error) {
+	if s.archiveWalkTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.archiveWalkTimeout)
+		defer cancel()
+	}
+	archives, err := s.GetPlayerArchives(ctx, username)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, nil, walkError(ctx)
+		}
+		return nil, nil, err
+	}
+
+	var recent []*models.GameInfo
+	var statuses []models.ArchiveStatus
+	var lastErr error
+	fetched, failed := 0, 0
+	end := len(archives)
+	for end > 0 && len(recent) < limit && ctx.Err() == nil {
+		start := end - s.archiveWorkers
+		if start < 0 {
+			start = 0
+		}
+		batch := make([][2]int, 0, end-start)
+		for i := end - 1; i >= start; i-- {
+			batch = append(batch, archives[i])
+		}
+		end = start
+
+		for _, result := range s.fetchArchives(ctx, username, batch, models.GameFilter{Bots: models.BotFilterExclude}) {
+			status := archiveStatus(username, result)
+			switch status.Status {
+			case models.ArchiveStatusFetched:
+				fetched++
+			case models.ArchiveStatusFailed:
+				failed++
+				lastErr = result.err
+			}
+			if result.err != nil {
+				statuses = append(statuses, status)
+				continue
+			}
+
+			for j := len(result.games) - 1; j >= 0 && len(recent) < limit; j-- {
+				if result.games[j].PGN != "" && result.games[j].Rules == "chess" {
+					recent = append(recent, result.games[j])
+					status.Games++
+				}
+			}
+			statuses = append(statuses, status)
+			if len(recent) >= limit {
+				break
+			}
+		}
+	}
+
+	if len(recent) < limit && ctx.Err() != nil {
+		for i := end - 1; i >= 0; i-- {
+			statuses = append(statuses, models.ArchiveStatus{
+				Username: username,
+				Year:     archives[i][0],
+				Month:    archives[i][1],
+				Status:   models.ArchiveStatusCancelled,
+				Error:    ctx.Err().Error(),
+			})
+		}
+		if fetched == 0 {
+			return nil, statuses, walkError(ctx)
+		}
+	}
+
+	if fetched == 0 && failed > 0 {
+		return nil, statuses, lastErr
+	}
+	return recent, statuses, nil
+}
+
+// walkError is the error of an archive walk that ended before any month was fetched: a timeout
+// when the walk ran out of time, or the context's error when it was cancelled
+func walkError(ctx context.Context) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return errors.NewTimeoutError("archive walk", ctx.Err())
+	}
+	return ctx.Err()
+}
+
+// GetWatchedGamePGN returns the current PGN of a player's game and whether the game has finished.
+// Ongoing daily games come from the player's current games; finished games from their latest archives.
+// An empty PGN means the game isn't visible yet, e.g. a live game still in progress.
+func (s *GameAnalyzerService) GetWatchedGamePGN(username, gameID string) (string, bool, error) {
+	data, err := s.chessAPI.GetPlayerCurrentGames(username)
+	if err != nil {
+		return "", false, errors.NewAPIError("failed to retrieve current games", err)
+	}
+
+	current, _ := data["games"].([]any)
+	for _, raw := range current {
+		game, _ := raw.(map[string]any)
+		if strings.HasSuffix(getStringValue(game, "url"), "/"+gameID) {
+			return getStringValue(game, "pgn"), false, nil
+		}
+	}
+
+	// Not ongoing: look for the finished game in this month's and last month's archives
+	thisMonth := time.Date(time.Now().Year(), time.Now().Month(), 1, 0, 0, 0, 0, time.UTC)
+	for i, month := range []time.Time{thisMonth, thisMonth.AddDate(0, -1, 0)} {
+		pgn := ""
+		err := s.chessAPI.StreamPlayerGames(username, month.Year(), int(month.Month()), func(game *client.ArchiveGame) error {
+			if strings.HasSuffix(game.URL, "/"+gameID) {
+				pgn = game.PGN
+				return client.ErrStopStream
+			}
+			return nil
+		})
+		if err != nil && i == 0 {
+			return "", false, errors.NewAPIError("failed to retrieve games", err)
+		}
+		if pgn != "" {
+			return pgn, true, nil
+		}
+	}
+
+	return "", false, nil
+}
+
+// FindPlayerGame looks a game up by its URL or ID among a player's games of the last few months,
+// newest first
+func (s *GameAnalyzerService) FindPlayerGame(ctx context.Context, username, gameID string) (*models.GameInfo, error) {
+	if _, id, ok := parseGameURL(gameID); ok {
+		gameID = id
+	}
+	archives, err := s.GetPlayerArchives(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := len(archives) - 1; i >= 0 && i >= len(archives)-playerGameSearchMonths; i-- {
+		var found *models.GameInfo
+		err := s.chessAPI.StreamPlayerGamesContext(ctx, username, archives[i][0], archives[i][1], func(game *client.ArchiveGame) error {
+			if strings.HasSuffix(game.URL, "/"+gameID) {
+				found = gameInfoFromArchive(game)
+				return client.ErrStopStream
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, errors.NewAPIError("failed to retrieve games", err)
+		}
+		if found != nil {
+			return found, nil
+		}
+	}
+	return nil, errors.NewGameNotFoundError(username+"/"+gameID, nil)
+}
+
+// GetClubMembers returns the usernames of a club's members
+func (s *GameAnalyzerService) GetClubMembers(clubID string) ([]string, error) {
+	data, err := s.chessAPI.GetClubMembers(clubID)
+	if err != nil {
+		return nil, errors.NewAPIError("failed to retrieve club members", err)
+	}
+
+	var members []string
+	for _, group := range []string{"weekly", "monthly", "all_time"} {
+		entries, _ := data[group].([]any)
+		for _, entry := range entries {
+			if member, ok := entry.(map[string]any); ok {
+				if username := getStringValue(member, "username"); username != "" {
+					members = append(members, username)
+				}
+			}
+		}
+	}
+
+	return members, nil
+}
+
+// GetCountryPlayers returns the usernames of players registered in a country
+func (s *GameAnalyzerService) GetCountryPlayers(countryCode string) ([]string, error) {
+	data, err := s.chessAPI.GetCountryPlayers(strings.ToUpper(countryCode))
+	if err != nil {
+		return nil, errors.NewAPIError("failed to retrieve country players", err)
+	}
+
+	rawPlayers, _ := data["players"].([]any)
+	players := make([]string, 0, len(rawPlayers))
+	for _, raw := range rawPlayers {
+		if username, ok := raw.(string); ok {
+			players = append(players, username)
+		}
+	}
+
+	return players, nil
+}
+
+// parseArchiveURL extracts the year and month from a monthly archive URL
+func parseArchiveURL(url string) (int, int, bool) {
+	parts := strings.Split(strings.TrimSuffix(url, "/"), "/")
+	if len(parts) < 2 {
+		return 0, 0, false
+	}
+
+	year, err := strconv.Atoi(parts[len(parts)-2])
+	if err != nil {
+		return 0, 0, false
+	}
+	month, err := strconv.Atoi(parts[len(parts)-1])
+	if err != nil {
+		return 0, 0, false
+	}
+
+	return year, month, true
+}
+
 // parseGameID handles different game ID formats
 func (s *GameAnalyzerService) parseGameID(gameID string) (*models.GameInfo, error) {
 	if strings.HasPrefix(gameID, "http") {
 		return s.getGameFromURL(gameID)
 	} else if strings.Contains(gameID, "/") {
 		parts := strings.Split(gameID, "/")
+		if len(parts) == 2 && (parts[0] == "live" || parts[0] == "daily") {
+			return s.getCallbackGame(parts[0], parts[1])
+		}
 		if len(parts) >= 3 {
 			username := parts[0]
 			year, err := strconv.Atoi(parts[1])
@@ -93,11 +586,13 @@ func (s *GameAnalyzerService) parseGameID(gameID string) (*models.GameInfo, erro
 	return s.searchGameByID(gameID)
 }
 
-// getGameFromURL extracts game information from Chess.com game URL
+// getGameFromURL retrieves a live or daily game by its Chess.com URL
 func (s *GameAnalyzerService) getGameFromURL(url string) (*models.GameInfo, error) {
-	// This would need to parse the Chess.com URL structure
-	// For now, return an error indicating this feature is not implemented
-	return nil, errors.NewAPIError("URL parsing not yet implemented", nil)
+	gameType, id, ok := parseGameURL(url)
+	if !ok {
+		return nil, errors.NewValidationError("gameID", fmt.Sprintf("game URL not recognized: %s", url))
+	}
+	return s.getCallbackGame(gameType, id)
 }
 
 // getGameFromPlayerMonth gets games from player's monthly archive
@@ -109,51 +604,34 @@ func (s *GameAnalyzerService) getGameFromPlayerMonth(username string, year, mont
 
 	// Parse games and return the first one (or implement specific game selection)
 	if games, ok := gamesData["games"].([]any); ok && len(games) > 0 {
-		gameData := games[0].(map[string]any)
-		return s.parseGameData(gameData)
+		if gameData, ok := games[0].(map[string]any); ok {
+			return s.parseGameData(gameData)
+		}
 	}
 
 	return nil, errors.NewGameNotFoundError(fmt.Sprintf("%s/%d/%02d", username, year, month), nil)
 }
 
-// searchGameByID searches for game by ID across different methods
+// searchGameByID looks up a numeric game ID as a live game, then as a daily game
 func (s *GameAnalyzerService) searchGameByID(gameID string) (*models.GameInfo, error) {
-	// This would implement a more sophisticated search
-	// For now, return an error
-	return nil, errors.NewValidationError("gameID", fmt.Sprintf("game ID format not recognized: %s", gameID))
-}
-
-// parseGameData parses raw game data from Chess.com API into GameInfo struct
-func (s *GameAnalyzerService) parseGameData(gameData map[string]any) (*models.GameInfo, error) {
-	// Extract player information
-	whiteData, _ := gameData["white"].(map[string]any)
-	blackData, _ := gameData["black"].(map[string]any)
-
-	whitePlayer := models.Player{
-		Username: getStringValue(whiteData, "username"),
-		URL:      getStringValue(whiteData, "url"),
-		Avatar:   getStringValue(whiteData, "avatar"),
-		Country:  getStringValue(whiteData, "country"),
-		Title:    getStringValue(whiteData, "title"),
+	if _, err := strconv.ParseUint(gameID, 10, 64); err != nil {
+		return nil, errors.NewValidationError("gameID", fmt.Sprintf("game ID format not recognized: %s", gameID))
 	}
 
-	if playerID, ok := whiteData["player_id"].(float64); ok {
-		id := int(playerID)
-		whitePlayer.PlayerID = &id
-	}
-
-	blackPlayer := models.Player{
-		Username: getStringValue(blackData, "username"),
-		URL:      getStringValue(blackData, "url"),
-		Avatar:   getStringValue(blackData, "avatar"),
-		Country:  getStringValue(blackData, "country"),
-		Title:    getStringValue(blackData, "title"),
+	gameInfo, err := s.getCallbackGame("live", gameID)
+	var notFound *errors.GameNotFoundError
+	if errors.As(err, &notFound) {
+		return s.getCallbackGame("daily", gameID)
 	}
+	return gameInfo, err
+}
 
-	if playerID, ok := blackData["player_id"].(float64); ok {
-		id := int(playerID)
-		blackPlayer.PlayerID = &id
-	}
+// parseGameData parses raw game data from Chess.com API into GameInfo struct. Fields of
+// unexpected types are left empty, so games of any format can be stored.
+func (s *GameAnalyzerService) parseGameData(gameData map[string]any) (*models.GameInfo, error) {
+	// Extract player information
+	whitePlayer := parsePlayerData(gameData["white"])
+	blackPlayer := parsePlayerData(gameData["black"])
 
 	// Parse timestamps
 	startTime := time.Unix(int64(getFloatValue(gameData, "start_time")), 0)
@@ -181,10 +659,130 @@ func (s *GameAnalyzerService) parseGameData(gameData map[string]any) (*models.Ga
 		Tournament:  getStringValue(gameData, "tournament"),
 		Match:       getStringValue(gameData, "match"),
 	}
+	if players, ok := gameData["players"].([]any); ok {
+		for _, player := range players {
+			gameInfo.Players = append(gameInfo.Players, parsePlayerData(player))
+		}
+	}
+	gameInfo.ResultReason = resultReason(gameInfo.WhitePlayer.Result, gameInfo.BlackPlayer.Result)
+	gameInfo.UnanalyzableReason = unanalyzableFormat(gameInfo.Rules, false)
 
 	return gameInfo, nil
 }
 
+// parsePlayerData parses a player of raw game data: an object, or the player's profile URL
+func parsePlayerData(value any) models.Player {
+	if id, ok := value.(string); ok {
+		username := id[strings.LastIndex(id, "/")+1:]
+		return models.Player{Username: username, IsBot: isBot(username, "", id)}
+	}
+
+	data, _ := value.(map[string]any)
+	player := models.Player{
+		Username: getStringValue(data, "username"),
+		URL:      getStringValue(data, "url"),
+		Avatar:   getStringValue(data, "avatar"),
+		Country:  getStringValue(data, "country"),
+		Title:    getStringValue(data, "title"),
+		Rating:   int(getFloatValue(data, "rating")),
+		IsBot:    isBotPlayer(data),
+		Result:   getStringValue(data, "result"),
+	}
+
+	if playerID, ok := data["player_id"].(float64); ok {
+		id := int(playerID)
+		player.PlayerID = &id
+	}
+	return player
+}
+
+// resultCodeReasons maps the Chess.com result codes of a losing or drawing player to result reasons
+var resultCodeReasons = map[string]models.ResultReason{
+	"checkmated":          models.ResultReasonCheckmate,
+	"resigned":            models.ResultReasonResignation,
+	"timeout":             models.ResultReasonTimeout,
+	"abandoned":           models.ResultReasonAbandoned,
+	"agreed":              models.ResultReasonAgreement,
+	"repetition":          models.ResultReasonRepetition,
+	"stalemate":           models.ResultReasonStalemate,
+	"insufficient":        models.ResultReasonInsufficientMaterial,
+	"50move":              models.ResultReasonFiftyMoveRule,
+	"timevsinsufficient":  models.ResultReasonTimeoutVsInsufficient,
+	"kingofthehill":       models.ResultReasonVariant,
+	"threecheck":          models.ResultReasonVariant,
+	"bughousepartnerlose": models.ResultReasonVariant,
+}
+
+// resultReason is how a game ended, from its players' Chess.com result codes. The winner's code
+// is "win", so the loser's code tells the reason; both players of a draw have the draw's code.
+func resultReason(whiteCode, blackCode string) models.ResultReason {
+	if reason, ok := resultCodeReasons[whiteCode]; ok {
+		return reason
+	}
+	return resultCodeReasons[blackCode]
+}
+
+// unanalyzableFormat is why a game of the given rules can't be analyzed, if it can't: only
+// standard chess and the variants that replay by its rules are, and malformed archive entries
+// can't be trusted to replay
+func unanalyzableFormat(rules string, malformed bool) string {
+	switch {
+	case malformed:
+		return models.UnanalyzableMalformed
+	case !engine.IsSupportedVariant(rules):
+		return models.UnanalyzableVariant
+	}
+	return ""
+}
+
+// gameInfoFromArchive converts a typed archive game into GameInfo
+func gameInfoFromArchive(game *client.ArchiveGame) *models.GameInfo {
+	gameInfo := &models.GameInfo{
+		URL:         game.URL,
+		FEN:         game.FEN,
+		PGN:         game.PGN,
+		TimeControl: game.TimeControl,
+		Rules:       game.Rules,
+		WhitePlayer: playerFromArchive(game.White),
+		BlackPlayer: playerFromArchive(game.Black),
+		Result:      game.Result,
+		ResultCode:  game.ResultCode,
+		TimeClass:   game.TimeClass,
+		Rated:       game.Rated,
+		StartTime:   time.Unix(game.StartTime, 0),
+		Tournament:  game.Tournament,
+		Match:       game.Match,
+
+		ResultReason:       resultReason(game.White.Result, game.Black.Result),
+		UnanalyzableReason: unanalyzableFormat(game.Rules, game.Malformed),
+	}
+
+	if game.EndTime > 0 {
+		endTime := time.Unix(game.EndTime, 0)
+		gameInfo.EndTime = &endTime
+	}
+	for _, player := range game.Players {
+		gameInfo.Players = append(gameInfo.Players, playerFromArchive(player))
+	}
+
+	return gameInfo
+}
+
+// playerFromArchive converts a typed archive player into Player
+func playerFromArchive(player client.ArchivePlayer) models.Player {
+	return models.Player{
+		Username: player.Username,
+		URL:      player.URL,
+		Avatar:   player.Avatar,
+		Country:  player.Country,
+		Title:    player.Title,
+		Rating:   int(player.Rating),
+		IsBot:    isBot(player.Username, player.Title, player.ID),
+		PlayerID: player.PlayerID,
+		Result:   player.Result,
+	}
+}
+
 // Helper functions for type conversion
 func getStringValue(data map[string]any, key string) string {
 	if val, ok := data[key].(string); ok {
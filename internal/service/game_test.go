@@ -1,8 +1,15 @@
 package service
 
 import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/internal/storage"
 )
 
 func TestParseGameID(t *testing.T) {
@@ -24,8 +31,8 @@ func TestParseGameID(t *testing.T) {
 			wantErr: true,
 		},
 		{
-			name:    "URL format (not implemented)",
-			gameID:  "https://www.chess.com/game/live/123456789",
+			name:    "Unrecognized URL",
+			gameID:  "https://www.chess.com/news/view/chess-com-update",
 			wantErr: true,
 		},
 	}
@@ -55,12 +62,14 @@ func TestParseGameData(t *testing.T) {
 			"player_id": float64(123456),
 			"title":     "GM",
 			"country":   "US",
+			"result":    "win",
 		},
 		"black": map[string]any{
 			"username":  "magnus",
 			"player_id": float64(789012),
 			"title":     "GM",
 			"country":   "NO",
+			"result":    "timeout",
 		},
 		"result":      "1-0",
 		"result_code": "win",
@@ -96,6 +105,10 @@ func TestParseGameData(t *testing.T) {
 		t.Errorf("Rated = %v, want true", gameInfo.Rated)
 	}
 
+	if gameInfo.ResultReason != models.ResultReasonTimeout || gameInfo.BlackPlayer.Result != "timeout" {
+		t.Errorf("ResultReason = %v, black result = %v, want timeout", gameInfo.ResultReason, gameInfo.BlackPlayer.Result)
+	}
+
 	// Test timestamp parsing
 	expectedStartTime := time.Unix(1640995200, 0)
 	if !gameInfo.StartTime.Equal(expectedStartTime) {
@@ -103,6 +116,49 @@ func TestParseGameData(t *testing.T) {
 	}
 }
 
+func TestResultReason(t *testing.T) {
+	tests := []struct {
+		white, black string
+		want         models.ResultReason
+	}{
+		{"win", "checkmated", models.ResultReasonCheckmate},
+		{"resigned", "win", models.ResultReasonResignation},
+		{"abandoned", "win", models.ResultReasonAbandoned},
+		{"agreed", "agreed", models.ResultReasonAgreement},
+		{"timevsinsufficient", "timevsinsufficient", models.ResultReasonTimeoutVsInsufficient},
+		{"win", "threecheck", models.ResultReasonVariant},
+		{"win", "lose", ""},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		if got := resultReason(tt.white, tt.black); got != tt.want {
+			t.Errorf("resultReason(%q, %q) = %q, want %q", tt.white, tt.black, got, tt.want)
+		}
+	}
+}
+
+func TestLostOnTimeWinning(t *testing.T) {
+	tests := []struct {
+		name      string
+		reason    models.ResultReason
+		result    string
+		finalEval float64
+		want      string
+	}{
+		{"white flagged while winning", models.ResultReasonTimeout, "0-1", 4.5, "white"},
+		{"black flagged while mating", models.ResultReasonTimeout, "1-0", -1000, "black"},
+		{"flagged in a lost position", models.ResultReasonTimeout, "0-1", -2, ""},
+		{"flagged in a balanced position", models.ResultReasonTimeout, "1-0", 0.4, ""},
+		{"resigned while winning", models.ResultReasonResignation, "0-1", 5, ""},
+		{"drawn on time", models.ResultReasonTimeoutVsInsufficient, "1/2-1/2", 5, ""},
+	}
+	for _, tt := range tests {
+		if got := lostOnTimeWinning(tt.reason, tt.result, tt.finalEval); got != tt.want {
+			t.Errorf("%s: lostOnTimeWinning() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
 func TestHelperFunctions(t *testing.T) {
 	data := map[string]interface{}{
 		"string_val": "test",
@@ -138,3 +194,159 @@ func TestHelperFunctions(t *testing.T) {
 		t.Errorf("getBoolValue() = %v, want false", got)
 	}
 }
+
+func TestIsBotPlayer(t *testing.T) {
+	tests := []struct {
+		data map[string]any
+		want bool
+	}{
+		{map[string]any{"username": "hikaru", "title": "GM"}, false},
+		{map[string]any{"username": "abbot"}, false},
+		{map[string]any{"username": "stockfish-bot"}, true},
+		{map[string]any{"username": "Computer5"}, true},
+		{map[string]any{"username": "martin", "title": "BOT"}, true},
+	}
+
+	for _, tt := range tests {
+		if got := isBotPlayer(tt.data); got != tt.want {
+			t.Errorf("isBotPlayer(%v) = %v, want %v", tt.data, got, tt.want)
+		}
+	}
+}
+
+func TestFilterGames(t *testing.T) {
+	human := &models.GameInfo{WhitePlayer: models.Player{Username: "hikaru"}, BlackPlayer: models.Player{Username: "magnus"}}
+	bot := &models.GameInfo{WhitePlayer: models.Player{Username: "hikaru"}, BlackPlayer: models.Player{Username: "computer1", IsBot: true}}
+	games := []*models.GameInfo{human, bot}
+
+	if got := filterGames(games, models.GameFilter{}); len(got) != 2 {
+		t.Errorf("Expected all games without a filter, got %d", len(got))
+	}
+
+	if got := filterGames(games, models.GameFilter{Bots: models.BotFilterExclude}); len(got) != 1 || got[0] != human {
+		t.Errorf("Expected only the human game when excluding bots, got %v", got)
+	}
+
+	if got := filterGames(games, models.GameFilter{Bots: models.BotFilterOnly}); len(got) != 1 || got[0] != bot {
+		t.Errorf("Expected only the bot game, got %v", got)
+	}
+
+	if err := validateGameFilter(models.GameFilter{Bots: "sometimes"}); err == nil {
+		t.Error("Expected an error for an unknown bot filter")
+	}
+}
+
+func TestGameAnalyzerService_GetPlayerGamesPage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var games []string
+		for i := 1; i <= 7; i++ {
+			white := "alice"
+			if i%3 == 0 {
+				white = "computer1"
+			}
+			games = append(games, fmt.Sprintf(`{"url": "game-%d", "white": {"username": "%s"}, "black": {"username": "bob"}}`, i, white))
+		}
+		fmt.Fprintf(w, `{"games": [%s]}`, strings.Join(games, ","))
+	}))
+	defer server.Close()
+
+	service := NewGameAnalyzerService()
+	service.chessAPI.BaseURL = server.URL
+
+	// Games 3 and 6 are against a bot, leaving 1, 2, 4, 5, 7
+	filter := models.GameFilter{Bots: models.BotFilterExclude}
+
+	urls := func(page *models.Page[*models.GameInfo]) string {
+		var urls []string
+		for _, game := range page.Items {
+			urls = append(urls, game.URL)
+		}
+		return strings.Join(urls, ",")
+	}
+	getPage := func(cursor string) *models.Page[*models.GameInfo] {
+		t.Helper()
+		page, err := service.GetPlayerGamesPage("alice", 2024, 1, filter, models.PageRequest{Cursor: cursor, Limit: 2})
+		if err != nil {
+			t.Fatalf("GetPlayerGamesPage() error = %v", err)
+		}
+		return page
+	}
+
+	first := getPage("")
+	if urls(first) != "game-1,game-2" || first.NextCursor == "" || first.PrevCursor != "" || first.TotalEstimate != 5 {
+		t.Errorf("Unexpected first page: %+v", first)
+	}
+	second := getPage(first.NextCursor)
+	if urls(second) != "game-4,game-5" || second.NextCursor == "" || second.PrevCursor == "" {
+		t.Errorf("Unexpected second page: %+v", second)
+	}
+	last := getPage(second.NextCursor)
+	if urls(last) != "game-7" || last.NextCursor != "" {
+		t.Errorf("Unexpected last page: %+v", last)
+	}
+	if back := getPage(last.PrevCursor); urls(back) != "game-4,game-5" || back.NextCursor == "" {
+		t.Errorf("Unexpected page before the last: %+v", back)
+	}
+
+	if _, err := service.GetPlayerGamesPage("alice", 2024, 1, filter, models.PageRequest{Limit: -1}); err == nil {
+		t.Error("Expected an error for an invalid limit")
+	}
+	otherList := storage.NextCursor(storage.ListSyncedGames, "x")
+	if _, err := service.GetPlayerGamesPage("alice", 2024, 1, filter, models.PageRequest{Cursor: otherList}); err == nil {
+		t.Error("Expected an error for another list's cursor")
+	}
+}
+
+func TestGameAnalyzerService_GetPlayerGamesOtherFormats(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"games": [
+			{"url": "game-1", "rules": "chess", "pgn": "1. e4 e5 *", "white": {"username": "alice"}, "black": {"username": "bob"}},
+			{"url": "game-2", "rules": "bughouse", "white": "https://api.chess.com/pub/player/alice", "black": {"username": "bob"},
+			 "players": [{"username": "alice"}, {"username": "bob"}, {"username": "carol"}, {"username": "dave"}]},
+			{"url": "game-3", "rules": "chess", "white": {"username": "alice", "rating": {"bughouse": 1500}}, "black": {"username": "erin"}}
+		]}`)
+	}))
+	defer server.Close()
+
+	service := NewGameAnalyzerService()
+	service.chessAPI.BaseURL = server.URL
+
+	games, err := service.GetPlayerGames("alice", 2024, 1, models.GameFilter{})
+	if err != nil {
+		t.Fatalf("GetPlayerGames() error = %v", err)
+	}
+	if len(games) != 3 {
+		t.Fatalf("Expected all 3 games, got %d", len(games))
+	}
+	if games[0].UnanalyzableReason != "" {
+		t.Errorf("Expected the standard game to be analyzable, got %q", games[0].UnanalyzableReason)
+	}
+	if bughouse := games[1]; bughouse.UnanalyzableReason != models.UnanalyzableVariant || bughouse.WhitePlayer.Username != "alice" || len(bughouse.Players) != 4 {
+		t.Errorf("Unexpected bughouse game: %+v", bughouse)
+	}
+	if malformed := games[2]; malformed.UnanalyzableReason != models.UnanalyzableMalformed || malformed.BlackPlayer.Username != "erin" {
+		t.Errorf("Unexpected malformed game: %+v", malformed)
+	}
+}
+
+func TestUnanalyzableFormat(t *testing.T) {
+	tests := []struct {
+		rules     string
+		malformed bool
+		want      string
+	}{
+		{"", false, ""},
+		{"chess", false, ""},
+		{"kingofthehill", false, ""},
+		{"chess960", false, models.UnanalyzableVariant},
+		{"crazyhouse", false, models.UnanalyzableVariant},
+		{"threecheck", false, models.UnanalyzableVariant},
+		{"bughouse", false, models.UnanalyzableVariant},
+		{"chess", true, models.UnanalyzableMalformed},
+	}
+	for _, tt := range tests {
+		if got := unanalyzableFormat(tt.rules, tt.malformed); got != tt.want {
+			t.Errorf("unanalyzableFormat(%q, %t) = %q, want %q", tt.rules, tt.malformed, got, tt.want)
+		}
+	}
+}
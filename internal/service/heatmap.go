@@ -0,0 +1,273 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/board"
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/internal/parser"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+// Heatmap sampling limits
+const (
+	defaultHeatmapGames   = 20
+	maxHeatmapGames       = 100
+	defaultHeatmapAnalyze = 5
+	maxHeatmapAnalyze     = 20
+	maxHeatmapPatterns    = 10
+	minManeuverSquares    = 3 // A maneuver is at least two consecutive moves of the same piece
+)
+
+// heatmapAggregate accumulates statistics while games are replayed
+type heatmapAggregate struct {
+	squares   [64]models.SquareStat
+	timeSum   [64]float64
+	timeCount [64]int
+	maneuvers map[models.PatternStat]int
+	breaks    map[models.PatternStat]int
+}
+
+// GetPlayerHeatmaps replays a player's recent games and aggregates statistics per square.
+// Blunder and mistake squares come from analyzing the most recent games in scan mode.
+func (s *AnalyticsService) GetPlayerHeatmaps(ctx context.Context, request *models.HeatmapRequest) (*models.PlayerHeatmaps, error) {
+	if request.Username == "" {
+		return nil, errors.NewValidationError("username", "username is required")
+	}
+	if request.Games <= 0 {
+		request.Games = defaultHeatmapGames
+	}
+	if request.Games > maxHeatmapGames {
+		request.Games = maxHeatmapGames
+	}
+	if request.Analyze < 0 {
+		request.Analyze = defaultHeatmapAnalyze
+	}
+	if request.Analyze > maxHeatmapAnalyze {
+		request.Analyze = maxHeatmapAnalyze
+	}
+
+	games, archives, err := s.gameService.GetRecentGames(ctx, request.Username, request.Games)
+	if err != nil {
+		return nil, err
+	}
+
+	agg := &heatmapAggregate{
+		maneuvers: make(map[models.PatternStat]int),
+		breaks:    make(map[models.PatternStat]int),
+	}
+	heatmaps := &models.PlayerHeatmaps{
+		Username:       request.Username,
+		FailedArchives: failedArchives(archives),
+		Archives:       archives,
+	}
+
+	for i, game := range games {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		color, ok := playerColor(game, request.Username)
+		if !ok {
+			continue
+		}
+
+		parsed, err := s.pgnParser.ParsePGN(game.PGN)
+		if err != nil {
+			continue
+		}
+
+		var flagged map[int]models.MoveAnalysis
+		if i < request.Analyze {
+			if analysis, err := s.analysisService.analyzeGameMoves(ctx, &models.AnalysisRequest{
+				PGN:      game.PGN,
+				Settings: request.Settings,
+				Mode:     models.AnalysisModeScan,
+			}); err == nil {
+				flagged = make(map[int]models.MoveAnalysis, len(analysis.Moves))
+				for _, move := range analysis.Moves {
+					flagged[move.MoveNumber] = move
+				}
+				heatmaps.AnalyzedGames++
+			}
+		}
+
+		times := s.pgnParser.ExtractMoveTimes(game.PGN)
+		if len(times) != len(parsed.Moves) {
+			times = nil
+		}
+
+		agg.addGame(parsed, color, flagged, times, game.TimeControl)
+		heatmaps.Games++
+	}
+
+	for sq := range agg.squares {
+		stat := agg.squares[sq]
+		stat.Square = board.Square(sq).String()
+		if agg.timeCount[sq] > 0 {
+			stat.AvgMoveTime = agg.timeSum[sq] / float64(agg.timeCount[sq])
+		}
+		heatmaps.Squares = append(heatmaps.Squares, stat)
+	}
+	heatmaps.Maneuvers = topPatterns(agg.maneuvers)
+	heatmaps.PawnBreaks = topPatterns(agg.breaks)
+
+	return heatmaps, nil
+}
+
+// addGame replays a game and adds the player's moves to the aggregate
+func (agg *heatmapAggregate) addGame(game *parser.ParsedGame, color board.Color, flagged map[int]models.MoveAnalysis,
+	times []parser.MoveTime, timeControl string) {
+	// Clocks of untimed games don't tell how long a move took, so only recorded elapsed times count
+	if base, increment, timed := parseTimeControl(timeControl); timed {
+		parser.FillMoveTimes(times, base, increment)
+	}
+
+	b := board.NewBoard()
+	var chain []board.Square
+	var chainPiece board.PieceType
+
+	flushChain := func() {
+		if len(chain) >= minManeuverSquares {
+			agg.maneuvers[models.PatternStat{Pattern: maneuverPattern(chainPiece, chain), Color: color.String()}]++
+		}
+		chain = nil
+	}
+
+	for i, parsedMove := range game.Moves {
+		m, err := b.ParseSAN(parsedMove.Move)
+		if err != nil {
+			// Stop at the first move that can't be replayed
+			break
+		}
+
+		if b.Turn() == color {
+			stat := &agg.squares[m.To]
+			stat.Moves++
+			if m.IsCapture() {
+				stat.Captures++
+			}
+			if analysis, ok := flagged[i+1]; ok {
+				if analysis.Blunder {
+					stat.Blunders++
+				} else if analysis.Mistake {
+					stat.Mistakes++
+				}
+			}
+
+			if i < len(times) && times[i].HasElapsed {
+				agg.timeSum[m.To] += times[i].Elapsed.Seconds()
+				agg.timeCount[m.To]++
+			}
+
+			if m.Piece.Type != board.Pawn && m.Piece.Type != board.King && len(chain) > 0 &&
+				chain[len(chain)-1] == m.From && chainPiece == m.Piece.Type {
+				chain = append(chain, m.To)
+			} else {
+				flushChain()
+				if m.Piece.Type != board.Pawn && m.Piece.Type != board.King {
+					chain = []board.Square{m.From, m.To}
+					chainPiece = m.Piece.Type
+				}
+			}
+		}
+
+		b.Apply(m)
+
+		if m.Piece.Color == color && isPawnBreak(b, m) {
+			agg.breaks[models.PatternStat{Pattern: parsedMove.Move, Color: color.String()}]++
+		}
+	}
+	flushChain()
+}
+
+// isPawnBreak reports whether a pawn push (already applied) now attacks an enemy pawn
+func isPawnBreak(b *board.Board, m board.Move) bool {
+	if m.Piece.Type != board.Pawn || m.IsCapture() || m.Promotion != board.NoPieceType {
+		return false
+	}
+
+	forward := 1
+	if m.Piece.Color == board.Black {
+		forward = -1
+	}
+	rank := m.To.Rank() + forward
+	if rank < 0 || rank > 7 {
+		return false
+	}
+
+	for _, df := range []int{-1, 1} {
+		file := m.To.File() + df
+		if file < 0 || file > 7 {
+			continue
+		}
+		if p := b.PieceAt(board.NewSquare(file, rank)); p.Type == board.Pawn && p.Color != m.Piece.Color {
+			return true
+		}
+	}
+	return false
+}
+
+// maneuverPattern formats a piece route, e.g. "Nb1-d2-f1"
+func maneuverPattern(piece board.PieceType, squares []board.Square) string {
+	names := make([]string, len(squares))
+	for i, sq := range squares {
+		names[i] = sq.String()
+	}
+	return string(piece.Letter()) + strings.Join(names, "-")
+}
+
+// topPatterns returns the most frequent patterns, most frequent first
+func topPatterns(counts map[models.PatternStat]int) []models.PatternStat {
+	patterns := make([]models.PatternStat, 0, len(counts))
+	for pattern, count := range counts {
+		pattern.Count = count
+		patterns = append(patterns, pattern)
+	}
+
+	sort.Slice(patterns, func(i, j int) bool {
+		if patterns[i].Count != patterns[j].Count {
+			return patterns[i].Count > patterns[j].Count
+		}
+		return patterns[i].Pattern < patterns[j].Pattern
+	})
+
+	if len(patterns) > maxHeatmapPatterns {
+		patterns = patterns[:maxHeatmapPatterns]
+	}
+	return patterns
+}
+
+// playerColor returns the side the player had in a game
+func playerColor(game *models.GameInfo, username string) (board.Color, bool) {
+	switch {
+	case strings.EqualFold(game.WhitePlayer.Username, username):
+		return board.White, true
+	case strings.EqualFold(game.BlackPlayer.Username, username):
+		return board.Black, true
+	}
+	return board.White, false
+}
+
+// parseTimeControl parses a "base+increment" time control in seconds, e.g. "180+2".
+// Daily time controls ("1/86400") are reported as untimed.
+func parseTimeControl(timeControl string) (time.Duration, time.Duration, bool) {
+	baseStr, incStr, _ := strings.Cut(timeControl, "+")
+	base, err := strconv.Atoi(baseStr)
+	if err != nil || base <= 0 {
+		return 0, 0, false
+	}
+
+	increment := 0
+	if incStr != "" {
+		if increment, err = strconv.Atoi(incStr); err != nil {
+			return 0, 0, false
+		}
+	}
+
+	return time.Duration(base) * time.Second, time.Duration(increment) * time.Second, true
+}
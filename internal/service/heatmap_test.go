@@ -0,0 +1,89 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/board"
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/internal/parser"
+)
+
+func TestHeatmapAggregate_AddGame(t *testing.T) {
+	pgn := `[Event "Live Chess"]
+[White "alice"]
+[Black "bob"]
+[TimeControl "60+1"]
+
+1. d4 {[%clk 0:01:00]} 1... d5 {[%clk 0:01:00]} 2. Nd2 {[%clk 0:00:58]} 2... Nf6 {[%clk 0:00:59]} 3. Nb3 {[%clk 0:00:55]} 3... e6 {[%clk 0:00:58]} 4. Na5 {[%clk 0:00:54]} 4... c5 {[%clk 0:00:50]} 5. c4 {[%clk 0:00:50]} 5... cxd4 {[%clk 0:00:49]} 1-0`
+
+	p := parser.NewPGNParser()
+	game, err := p.ParsePGN(pgn)
+	if err != nil {
+		t.Fatalf("ParsePGN() error = %v", err)
+	}
+	times := p.ExtractMoveTimes(pgn)
+	if len(times) != len(game.Moves) {
+		t.Fatalf("Expected a time per move, got %d times for %d moves", len(times), len(game.Moves))
+	}
+
+	agg := &heatmapAggregate{maneuvers: make(map[models.PatternStat]int), breaks: make(map[models.PatternStat]int)}
+	flagged := map[int]models.MoveAnalysis{9: {MoveNumber: 9, Blunder: true}}
+
+	agg.addGame(game, board.Black, flagged, times, "60+1")
+
+	square := func(name string) models.SquareStat {
+		sq, _ := board.ParseSquare(name)
+		return agg.squares[sq]
+	}
+
+	if s := square("d4"); s.Moves != 1 || s.Captures != 1 {
+		t.Errorf("Expected Black's capture on d4, got %+v", s)
+	}
+	if s := square("c5"); s.Moves != 1 {
+		t.Errorf("Expected one move to c5, got %+v", s)
+	}
+	if s := square("d2"); s.Moves != 0 {
+		t.Errorf("Expected White's moves to be ignored, got %+v", s)
+	}
+
+	// 4... c5 took 58 - 50 + 1 = 9 seconds
+	if sq, _ := board.ParseSquare("c5"); agg.timeCount[sq] != 1 || agg.timeSum[sq] != 9 {
+		t.Errorf("Expected 9 seconds on c5, got %v over %d moves", agg.timeSum[sq], agg.timeCount[sq])
+	}
+	if breaks := topPatterns(agg.breaks); len(breaks) != 1 || breaks[0].Pattern != "c5" || breaks[0].Color != "black" {
+		t.Errorf("Expected c5 to be Black's pawn break, got %+v", breaks)
+	}
+
+	// The same moves seen from White's side
+	white := &heatmapAggregate{maneuvers: make(map[models.PatternStat]int), breaks: make(map[models.PatternStat]int)}
+	white.addGame(game, board.White, flagged, p.ExtractMoveTimes(pgn), "60+1")
+
+	if maneuvers := topPatterns(white.maneuvers); len(maneuvers) != 1 || maneuvers[0].Pattern != "Nb1-d2-b3-a5" {
+		t.Errorf("Expected the Nb1-d2-b3-a5 maneuver, got %+v", maneuvers)
+	}
+	if sq, _ := board.ParseSquare("c4"); white.squares[sq].Blunders != 1 {
+		t.Errorf("Expected the flagged 5. c4 to count as a blunder, got %+v", white.squares[sq])
+	}
+}
+
+func TestParseTimeControl(t *testing.T) {
+	tests := []struct {
+		timeControl string
+		base        time.Duration
+		increment   time.Duration
+		timed       bool
+	}{
+		{"180+2", 3 * time.Minute, 2 * time.Second, true},
+		{"600", 10 * time.Minute, 0, true},
+		{"1/86400", 0, 0, false},
+		{"", 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		base, increment, timed := parseTimeControl(tt.timeControl)
+		if base != tt.base || increment != tt.increment || timed != tt.timed {
+			t.Errorf("parseTimeControl(%q) = %v, %v, %v", tt.timeControl, base, increment, timed)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"github.com/pedrampdd/ChessAnalyser/internal/board"
+	"github.com/pedrampdd/ChessAnalyser/internal/i18n"
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+)
+
+// ratingPerAccuracyPoint is the rating gap worth one point of accuracy when ranking a report's games
+const ratingPerAccuracyPoint = 20
+
+// gameHighlights picks a player report's best and worst analyzed games
+type gameHighlights struct {
+	analyzed                    int
+	best, worst                 *models.GameHighlight
+	bestAnalysis, worstAnalysis *models.GameAnalysis // Analyses of the nominated games, for their key moments
+}
+
+// add ranks an analyzed game of the player, who scored score with color
+func (h *gameHighlights) add(game *models.GameInfo, analysis *models.GameAnalysis, color board.Color, score float64) {
+	player, opponent := game.WhitePlayer, game.BlackPlayer
+	accuracy := analysis.Accuracy.WhiteAccuracy
+	if color == board.Black {
+		player, opponent = opponent, player
+		accuracy = analysis.Accuracy.BlackAccuracy
+	}
+	candidate := &models.GameHighlight{
+		URL:            game.URL,
+		AnalysisID:     analysis.ID,
+		Opponent:       opponent.Username,
+		OpponentRating: opponent.Rating,
+		Color:          color.String(),
+		Result:         scoreResult(score),
+		Accuracy:       accuracy,
+		Performance:    gamePerformance(accuracy, player.Rating, opponent.Rating),
+	}
+
+	h.analyzed++
+	if h.best == nil || candidate.Performance > h.best.Performance {
+		h.best, h.bestAnalysis = candidate, analysis
+	}
+	if h.worst == nil || candidate.Performance < h.worst.Performance {
+		h.worst, h.worstAnalysis = candidate, analysis
+	}
+}
+
+// apply nominates the best game, and the worst once at least two games were analyzed, with the
+// key moments of their analyses
+func (h *gameHighlights) apply(report *models.PlayerReport) {
+	report.AnalyzedGames = h.analyzed
+	if h.analyzed > 0 {
+		report.BestGame = withKeyMoments(h.best, h.bestAnalysis)
+	}
+	if h.analyzed > 1 {
+		report.WorstGame = withKeyMoments(h.worst, h.worstAnalysis)
+	}
+}
+
+// withKeyMoments extracts the key moments of a nominated game's analysis
+func withKeyMoments(highlight *models.GameHighlight, analysis *models.GameAnalysis) *models.GameHighlight {
+	highlight.KeyMoments = selectKeyMoments(analysis, i18n.DefaultLanguage, moveNotation{})
+	if highlight.KeyMoments == nil {
+		highlight.KeyMoments = []models.KeyMoment{}
+	}
+	return highlight
+}
+
+// gamePerformance scores a game by the player's accuracy, crediting a stronger opponent and
+// discounting a weaker one. Games without both ratings are scored by accuracy alone.
+func gamePerformance(accuracy float64, playerRating, opponentRating int) float64 {
+	if playerRating <= 0 || opponentRating <= 0 {
+		return accuracy
+	}
+	return accuracy + float64(opponentRating-playerRating)/ratingPerAccuracyPoint
+}
+
+// scoreResult names the result of a game the player scored score in
+func scoreResult(score float64) string {
+	switch score {
+	case 1:
+		return "win"
+	case 0.5:
+		return "draw"
+	}
+	return "loss"
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/board"
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+)
+
+func TestAnalyticsService_PlayerReportHighlights(t *testing.T) {
+	now := time.Now()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/player/alice/games/archives":
+			fmt.Fprintf(w, `{"archives": ["https://api.chess.com/pub/player/alice/games/%d/%02d"]}`, now.Year(), now.Month())
+		case fmt.Sprintf("/player/alice/games/%d/%02d", now.Year(), now.Month()):
+			end := now.Add(-time.Hour).Unix()
+			fmt.Fprintf(w, `{"games": [
+				{"url": "https://www.chess.com/game/live/1", "rules": "chess", "end_time": %d, "pgn": "[Result \"1-0\"]\n\n1. e4 1-0",
+				 "white": {"username": "Alice", "rating": 1500, "result": "win"}, "black": {"username": "Weak", "rating": 1400, "result": "resigned"}},
+				{"url": "https://www.chess.com/game/live/2", "rules": "chess", "end_time": %d, "pgn": "[Result \"0-1\"]\n\n1. d4 0-1",
+				 "white": {"username": "Strong", "rating": 1900, "result": "resigned"}, "black": {"username": "Alice", "rating": 1500, "result": "win"}},
+				{"url": "https://www.chess.com/game/live/3", "rules": "chess", "end_time": %d, "pgn": "[Result \"0-1\"]\n\n1. c4 0-1",
+				 "white": {"username": "Alice", "rating": 1500, "result": "resigned"}, "black": {"username": "Even", "rating": 1500, "result": "win"}},
+				{"url": "https://www.chess.com/game/live/4", "rules": "chess", "end_time": %d, "pgn": "[Result \"1-0\"]\n\n1. f4 1-0",
+				 "white": {"username": "Alice", "rating": 1500, "result": "win"}, "black": {"username": "Other", "rating": 1500, "result": "resigned"}}
+			]}`, end, end, end, end)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	gameService := NewGameAnalyzerService()
+	gameService.chessAPI.BaseURL = server.URL
+	analysisService := newTestAnalysisService()
+	analysisService.engineErr = fmt.Errorf("no engine")
+
+	// Game 4 was never analyzed; the analyses of the others are found by their game link
+	for game, accuracy := range map[int]float64{1: 95, 2: 85, 3: 80} {
+		analysis := &models.GameAnalysis{
+			PGN:      fmt.Sprintf("[Link \"https://www.chess.com/game/live/%d\"]\n\n1. e4 *", game),
+			Accuracy: models.GameAccuracy{WhiteAccuracy: accuracy, BlackAccuracy: accuracy},
+		}
+		if _, err := analysisService.store.SaveAnalysis(analysis); err != nil {
+			t.Fatalf("SaveAnalysis() error = %v", err)
+		}
+	}
+
+	analytics := NewAnalyticsService(gameService, analysisService)
+	report, err := analytics.GeneratePlayerReport(context.Background(), &models.PlayerReportRequest{Username: "alice"})
+	if err != nil {
+		t.Fatalf("GeneratePlayerReport() error = %v", err)
+	}
+	if report.Games != 4 || report.AnalyzedGames != 3 {
+		t.Fatalf("Expected 3 of 4 games analyzed, got %d of %d", report.AnalyzedGames, report.Games)
+	}
+
+	// The most accurate game came against a weaker opponent, so the win over a much stronger one ranks higher
+	best := report.BestGame
+	if best == nil || best.URL != "https://www.chess.com/game/live/2" || best.Performance != 105 ||
+		best.Opponent != "Strong" || best.OpponentRating != 1900 || best.Color != "black" || best.Result != "win" {
+		t.Errorf("Unexpected best game: %+v", best)
+	}
+	worst := report.WorstGame
+	if worst == nil || worst.URL != "https://www.chess.com/game/live/3" || worst.Performance != 80 || worst.Result != "loss" {
+		t.Errorf("Unexpected worst game: %+v", worst)
+	}
+	if best != nil && best.KeyMoments == nil {
+		t.Error("Expected the best game's key moments to be extracted")
+	}
+}
+
+func TestGameHighlights_SingleGame(t *testing.T) {
+	var highlights gameHighlights
+	game := &models.GameInfo{URL: "https://www.chess.com/game/live/1",
+		WhitePlayer: models.Player{Username: "Alice"}, BlackPlayer: models.Player{Username: "Bob"}}
+	highlights.add(game, &models.GameAnalysis{ID: "a1", Accuracy: models.GameAccuracy{WhiteAccuracy: 88}}, board.White, 0.5)
+
+	var report models.PlayerReport
+	highlights.apply(&report)
+	if report.BestGame == nil || report.BestGame.Performance != 88 || report.BestGame.Result != "draw" {
+		t.Errorf("Unexpected best game: %+v", report.BestGame)
+	}
+	if report.WorstGame != nil {
+		t.Errorf("Expected no worst game with a single analyzed game, got %+v", report.WorstGame)
+	}
+}
+
+func TestGamePerformance(t *testing.T) {
+	tests := []struct {
+		accuracy               float64
+		playerRating, opponent int
+		want                   float64
+	}{
+		{90, 1500, 1500, 90},
+		{90, 1500, 1700, 100},
+		{90, 1500, 1300, 80},
+		{90, 0, 1700, 90},
+	}
+	for _, tt := range tests {
+		if got := gamePerformance(tt.accuracy, tt.playerRating, tt.opponent); got != tt.want {
+			t.Errorf("gamePerformance(%v, %d, %d) = %v, want %v", tt.accuracy, tt.playerRating, tt.opponent, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/engine"
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+)
+
+// Miss classification settings
+const (
+	missEvalLoss        = 1.0 // Advantage (in pawns) the mover must fail to take
+	missMinProbability  = 0.2 // Below this the best move is considered too hard to count as a miss
+	findableProbability = 0.5 // Misses above this are treated as moves the player should usually find
+)
+
+// SetHumanModel plugs in a pool of Maia engines used for per-move human probabilities
+func (s *AnalysisService) SetHumanModel(pool *engine.MaiaPool) {
+	s.humanModel = pool
+}
+
+// humanSession holds the Maia engine one analysis uses for its human probabilities.
+// The engine is acquired on first use and held until release.
+type humanSession struct {
+	pool   *engine.MaiaPool
+	engine *engine.MaiaEngine
+}
+
+// newHumanSession starts a human probability session for one analysis
+func (s *AnalysisService) newHumanSession() *humanSession {
+	return &humanSession{pool: s.humanModel}
+}
+
+// probability returns how likely a player of the given rating is to find bestMove in the position
+func (h *humanSession) probability(ctx context.Context, fen, bestMove string, rating int) (float64, error) {
+	if h.pool == nil {
+		return 0, fmt.Errorf("no human move model configured")
+	}
+	if rating <= 0 {
+		return 0, fmt.Errorf("no player rating to weigh human moves by")
+	}
+
+	if h.engine == nil {
+		maia, err := h.pool.Acquire(ctx)
+		if err != nil {
+			return 0, err
+		}
+		h.engine = maia
+	}
+
+	policy, err := h.engine.MovePolicy(ctx, fen, rating)
+	if err != nil {
+		return 0, err
+	}
+	return policy[bestMove], nil
+}
+
+// release returns the session's Maia engine to the pool
+func (h *humanSession) release() {
+	if h.engine != nil {
+		h.pool.ReturnEngine(h.engine)
+		h.engine = nil
+	}
+}
+
+// moverRating returns the rating human probabilities are weighed by for a move of the given color:
+// the requested player rating, or the mover's Elo from the PGN headers when the request has none.
+// It returns 0 when neither is known.
+func moverRating(requested int, headers map[string]string, color string) int {
+	if requested > 0 {
+		return requested
+	}
+	rating, err := strconv.Atoi(strings.TrimSpace(headers[color+"elo"]))
+	if err != nil || rating <= 0 {
+		return 0
+	}
+	return rating
+}
+
+// isMiss reports whether move failed to punish the opponent's error in prev.
+// When the human probability is known, moves that players of that level rarely find are not counted.
+func isMiss(prev, move *models.MoveAnalysis, probability float64, probabilityKnown bool) bool {
+	if prev == nil || !(prev.Blunder || prev.Mistake) {
+		return false
+	}
+	if prev.BestMove == "" || sameMove(move.Move, prev.BestMove) {
+		return false
+	}
+
+	color := moveColor(move.FEN, move.MoveNumber)
+	if moverEval(prev.Evaluation, color)-moverEval(move.Evaluation, color) < missEvalLoss {
+		return false
+	}
+
+	return !probabilityKnown || probability >= missMinProbability
+}
+
+// countFindableMisses counts misses whose best move a human of the requested level would likely have played
+func countFindableMisses(analysis *models.GameAnalysis) int {
+	count := 0
+	for _, move := range analysis.Moves {
+		if move.Miss && move.HumanProbability >= findableProbability {
+			count++
+		}
+	}
+	return count
+}
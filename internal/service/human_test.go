@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/engine"
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+)
+
+func TestIsMiss(t *testing.T) {
+	// Black blunders, leaving White a winning Nxe5
+	blunder := &models.MoveAnalysis{Move: "f6", MoveNumber: 4, Evaluation: 2.5, Blunder: true, BestMove: "f3e5"}
+
+	tests := []struct {
+		name        string
+		prev        *models.MoveAnalysis
+		move        models.MoveAnalysis
+		probability float64
+		known       bool
+		want        bool
+	}{
+		{"punished", blunder, models.MoveAnalysis{Move: "Nxe5", MoveNumber: 5, Evaluation: 2.8}, 0.6, true, false},
+		{"findable miss", blunder, models.MoveAnalysis{Move: "Bc4", MoveNumber: 5, Evaluation: 0.5}, 0.6, true, true},
+		{"too hard to find", blunder, models.MoveAnalysis{Move: "Bc4", MoveNumber: 5, Evaluation: 0.5}, 0.05, true, false},
+		{"no human model", blunder, models.MoveAnalysis{Move: "Bc4", MoveNumber: 5, Evaluation: 0.5}, 0, false, true},
+		{"small loss", blunder, models.MoveAnalysis{Move: "Bc4", MoveNumber: 5, Evaluation: 2.0}, 0.6, true, false},
+		{"opponent played well", &models.MoveAnalysis{Move: "e5", MoveNumber: 2, Evaluation: 0.3, BestMove: "g1f3"},
+			models.MoveAnalysis{Move: "a3", MoveNumber: 3, Evaluation: -1.0}, 0.6, true, false},
+		{"first move", nil, models.MoveAnalysis{Move: "e4", MoveNumber: 1, Evaluation: 0.3}, 0, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isMiss(tt.prev, &tt.move, tt.probability, tt.known); got != tt.want {
+				t.Errorf("isMiss() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoverRating(t *testing.T) {
+	headers := map[string]string{"whiteelo": "1720", "blackelo": "?"}
+
+	tests := []struct {
+		name      string
+		requested int
+		color     string
+		want      int
+	}{
+		{"requested rating", 1300, "white", 1300},
+		{"white elo tag", 0, "white", 1720},
+		{"unrated black", 0, "black", 0},
+		{"requested rating for unrated mover", 1300, "black", 1300},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := moverRating(tt.requested, headers, tt.color); got != tt.want {
+				t.Errorf("moverRating() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHumanSession_NoRating(t *testing.T) {
+	maia := &engine.MaiaEngine{}
+	pool := &engine.MaiaPool{Engines: []*engine.MaiaEngine{maia}, Available: make(chan *engine.MaiaEngine, 1)}
+	pool.Available <- maia
+
+	human := &humanSession{pool: pool}
+	if _, err := human.probability(context.Background(), "8/8/8/8/8/8/8/8 w - - 0 1", "e2e4", 0); err == nil {
+		t.Error("probability() without a rating succeeded, want an error")
+	}
+	if pool.Idle() != 1 {
+		t.Errorf("Idle() = %d, want the engine left in the pool", pool.Idle())
+	}
+
+	human.release()
+	if pool.Idle() != 1 {
+		t.Errorf("Idle() = %d after release, want 1", pool.Idle())
+	}
+}
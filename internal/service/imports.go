@@ -0,0 +1,457 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/internal/parser"
+	"github.com/pedrampdd/ChessAnalyser/internal/storage"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+// Import settings
+const (
+	maxImportIdle = 24 * time.Hour // Unfinished uploads are discarded after this long without a chunk
+)
+
+// ImportService receives large PGN databases as resumable chunked uploads and parses them in the background
+type ImportService struct {
+	pgnParser *parser.PGNParser
+	dir       string // Directory holding the uploaded files
+	maxSize   int64  // Largest accepted upload in bytes
+	// openArchive opens the PGN download of a player's monthly Chess.com archive
+	openArchive func(username string, year, month int) (io.ReadCloser, error)
+	mu          sync.Mutex
+	imports     map[string]*pgnImport
+}
+
+// pgnImport is an upload, its file on disk and the games found in it
+type pgnImport struct {
+	state   models.ImportUpload
+	path    string
+	writeMu sync.Mutex // Serializes chunks so they are appended in order
+	games   []importedGame
+	cancel  context.CancelFunc
+}
+
+// importedGame locates a game in the uploaded file
+type importedGame struct {
+	summary models.ImportedGame
+	offset  int64
+	length  int
+}
+
+// NewImportService creates a new import service storing uploads in dir
+func NewImportService(dir string, maxSize int64) *ImportService {
+	return &ImportService{
+		pgnParser: parser.NewPGNParser(),
+		dir:       dir,
+		maxSize:   maxSize,
+		imports:   make(map[string]*pgnImport),
+	}
+}
+
+// SetGameService sets the service Chess.com archives are downloaded through
+func (s *ImportService) SetGameService(gameService *GameAnalyzerService) {
+	s.openArchive = gameService.OpenPlayerGamesPGN
+}
+
+// CreateImport starts a resumable upload of the given size and returns its upload token. When the
+// request names a Chess.com archive, the archive is downloaded and parsed in the background instead.
+func (s *ImportService) CreateImport(request *models.ImportRequest) (*models.ImportUpload, error) {
+	if request.ChessCom != nil {
+		return s.importArchive(request.ChessCom)
+	}
+	if request.Size <= 0 {
+		return nil, errors.NewValidationError("size", "upload size in bytes is required")
+	}
+	if request.Size > s.maxSize {
+		return nil, errors.NewValidationError("size", fmt.Sprintf("upload exceeds the maximum size of %d bytes", s.maxSize))
+	}
+
+	filename := request.Filename
+	if filename != "" {
+		filename = filepath.Base(filename)
+	}
+
+	imp, err := s.newImport(filename, request.Size, models.ImportStatusUploading)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	state := imp.state
+	s.mu.Unlock()
+
+	return &state, nil
+}
+
+// importArchive starts downloading a player's monthly archive as one multi-game PGN, which is then
+// parsed like a completed upload
+func (s *ImportService) importArchive(archive *models.ChessComArchive) (*models.ImportUpload, error) {
+	if s.openArchive == nil {
+		return nil, errors.NewValidationError("chesscom", "Chess.com imports are not available")
+	}
+	if archive.Username == "" {
+		return nil, errors.NewValidationError("chesscom.username", "username is required")
+	}
+	if archive.Month < 1 || archive.Month > 12 {
+		return nil, errors.NewValidationError("chesscom.month", "month must be between 1 and 12")
+	}
+	if archive.Year < 2007 || archive.Year > time.Now().Year() {
+		return nil, errors.NewValidationError("chesscom.year", "year is out of range")
+	}
+
+	filename := fmt.Sprintf("%s-%d-%02d.pgn", archive.Username, archive.Year, archive.Month)
+	imp, err := s.newImport(filename, 0, models.ImportStatusDownloading)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	ctx, cancel := context.WithCancel(context.Background())
+	imp.cancel = cancel
+	state := imp.state
+	s.mu.Unlock()
+
+	go s.download(ctx, imp, *archive)
+
+	return &state, nil
+}
+
+// newImport registers an import with an empty file in the import directory
+func (s *ImportService) newImport(filename string, size int64, status string) (*pgnImport, error) {
+	s.discardAbandoned()
+
+	id, err := storage.NewID()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return nil, errors.NewStorageError("create import directory", err)
+	}
+
+	path := filepath.Join(s.dir, id+".pgn")
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, errors.NewStorageError("create import file", err)
+	}
+	file.Close()
+
+	now := time.Now()
+	imp := &pgnImport{
+		state: models.ImportUpload{
+			ID:        id,
+			Filename:  filename,
+			Status:    status,
+			Size:      size,
+			CreatedAt: now,
+			UpdatedAt: now,
+		},
+		path: path,
+	}
+
+	s.mu.Lock()
+	s.imports[id] = imp
+	s.mu.Unlock()
+
+	return imp, nil
+}
+
+// download writes an archive's PGN to the import file, then parses it
+func (s *ImportService) download(ctx context.Context, imp *pgnImport, archive models.ChessComArchive) {
+	body, err := s.openArchive(archive.Username, archive.Year, archive.Month)
+	if err != nil {
+		s.finishImport(imp, err)
+		return
+	}
+	defer body.Close()
+
+	file, err := os.OpenFile(imp.path, os.O_WRONLY|os.O_TRUNC, 0)
+	if err != nil {
+		s.finishImport(imp, errors.NewStorageError("open import file", err))
+		return
+	}
+
+	// Read one byte past the limit to tell a full-size archive from an oversized one
+	written, err := io.Copy(file, io.LimitReader(body, s.maxSize+1))
+	file.Close()
+	switch {
+	case ctx.Err() != nil:
+		return
+	case err != nil:
+		s.finishImport(imp, fmt.Errorf("download interrupted after %d bytes: %w", written, err))
+		return
+	case written > s.maxSize:
+		s.finishImport(imp, fmt.Errorf("archive exceeds the maximum import size of %d bytes", s.maxSize))
+		return
+	}
+
+	s.mu.Lock()
+	imp.state.Size = written
+	imp.state.Offset = written
+	imp.state.Status = models.ImportStatusParsing
+	imp.state.UpdatedAt = time.Now()
+	s.mu.Unlock()
+
+	s.parse(ctx, imp)
+}
+
+// AppendChunk writes a chunk starting at offset. Bytes received before a dropped connection are
+// kept, so the client can resume from the offset reported by GetImport. Parsing starts in the
+// background once the last byte arrives.
+func (s *ImportService) AppendChunk(id string, offset int64, chunk io.Reader) (*models.ImportUpload, error) {
+	imp, err := s.lookup(id)
+	if err != nil {
+		return nil, err
+	}
+
+	imp.writeMu.Lock()
+	defer imp.writeMu.Unlock()
+
+	s.mu.Lock()
+	expected, size, status := imp.state.Offset, imp.state.Size, imp.state.Status
+	s.mu.Unlock()
+
+	if status != models.ImportStatusUploading || offset != expected {
+		return nil, errors.NewUploadOffsetError(expected, offset)
+	}
+
+	file, err := os.OpenFile(imp.path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		return nil, errors.NewStorageError("open import file", err)
+	}
+	defer file.Close()
+
+	written, copyErr := io.Copy(file, io.LimitReader(chunk, size-offset))
+
+	// Reject chunks running past the declared size without keeping any of their bytes
+	if copyErr == nil {
+		if n, _ := chunk.Read(make([]byte, 1)); n > 0 {
+			if err := file.Truncate(offset); err != nil {
+				return nil, errors.NewStorageError("discard oversized chunk", err)
+			}
+			return nil, errors.NewValidationError("chunk", "chunk exceeds the declared upload size")
+		}
+	}
+
+	s.mu.Lock()
+	imp.state.Offset += written
+	imp.state.UpdatedAt = time.Now()
+	if imp.state.Offset == imp.state.Size {
+		imp.state.Status = models.ImportStatusParsing
+		ctx, cancel := context.WithCancel(context.Background())
+		imp.cancel = cancel
+		go s.parse(ctx, imp)
+	}
+	state := imp.state
+	s.mu.Unlock()
+
+	if copyErr != nil {
+		return &state, fmt.Errorf("upload interrupted after %d bytes: %w", state.Offset, copyErr)
+	}
+	return &state, nil
+}
+
+// parse splits the uploaded database into games and records progress as it goes
+func (s *ImportService) parse(ctx context.Context, imp *pgnImport) {
+	file, err := os.Open(imp.path)
+	if err != nil {
+		s.finishImport(imp, err)
+		return
+	}
+	defer file.Close()
+
+	index := 0
+	err = parser.SplitGames(file, func(offset int64, pgn string) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		game := importedGame{offset: offset, length: len(pgn), summary: models.ImportedGame{Index: index}}
+		parsed, parseErr := s.pgnParser.ParsePGN(pgn)
+		if parseErr != nil {
+			game.summary.Error = parseErr.Error()
+		} else {
+			game.summary.White = parsed.Headers["white"]
+			game.summary.Black = parsed.Headers["black"]
+			game.summary.Result = parsed.Headers["result"]
+			game.summary.Date = parsed.Headers["date"]
+			game.summary.Event = parsed.Headers["event"]
+			game.summary.Moves = parsed.MoveCount
+		}
+		index++
+
+		s.mu.Lock()
+		imp.games = append(imp.games, game)
+		if parseErr != nil {
+			imp.state.FailedGames++
+		} else {
+			imp.state.Games++
+		}
+		imp.state.ParsedBytes = offset + int64(len(pgn))
+		imp.state.Progress = float64(imp.state.ParsedBytes) / float64(imp.state.Size)
+		imp.state.UpdatedAt = time.Now()
+		s.mu.Unlock()
+		return nil
+	})
+
+	s.finishImport(imp, err)
+}
+
+// finishImport marks an import completed, or failed when parsing stopped with an error
+func (s *ImportService) finishImport(imp *pgnImport, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	imp.state.UpdatedAt = now
+	imp.state.CompletedAt = &now
+	if err != nil {
+		imp.state.Status = models.ImportStatusFailed
+		imp.state.Error = err.Error()
+		log.Printf("Import %s failed: %v", imp.state.ID, err)
+		return
+	}
+	imp.state.Status = models.ImportStatusCompleted
+	imp.state.ParsedBytes = imp.state.Size
+	imp.state.Progress = 1
+}
+
+// GetImport returns the upload and parsing progress of an import
+func (s *ImportService) GetImport(id string) (*models.ImportUpload, error) {
+	imp, err := s.lookup(id)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state := imp.state
+	return &state, nil
+}
+
+// GetImportedGames returns a page of the games parsed so far, in database order, with their PGN
+// read back from the upload
+func (s *ImportService) GetImportedGames(id string, request models.PageRequest) (*models.Page[models.ImportedGame], error) {
+	imp, err := s.lookup(id)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	games := append([]importedGame(nil), imp.games...)
+	s.mu.Unlock()
+
+	page, err := storage.Paginate(storage.ListImportedGames, games, func(game importedGame) string {
+		return storage.IndexKey(game.summary.Index)
+	}, request)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.Page[models.ImportedGame]{
+		Items:         make([]models.ImportedGame, 0, len(page.Items)),
+		NextCursor:    page.NextCursor,
+		PrevCursor:    page.PrevCursor,
+		TotalEstimate: page.TotalEstimate,
+	}
+	if len(page.Items) == 0 {
+		return result, nil
+	}
+
+	file, err := os.Open(imp.path)
+	if err != nil {
+		return nil, errors.NewStorageError("open import file", err)
+	}
+	defer file.Close()
+
+	for _, game := range page.Items {
+		buf := make([]byte, game.length)
+		if _, err := file.ReadAt(buf, game.offset); err != nil {
+			return nil, errors.NewStorageError(fmt.Sprintf("read game %d", game.summary.Index), err)
+		}
+		summary := game.summary
+		summary.PGN = string(buf)
+		result.Items = append(result.Items, summary)
+	}
+	return result, nil
+}
+
+// DeleteImport stops parsing an import and removes its file
+func (s *ImportService) DeleteImport(id string) error {
+	s.mu.Lock()
+	imp, ok := s.imports[id]
+	delete(s.imports, id)
+	s.mu.Unlock()
+
+	if !ok {
+		return errors.NewImportNotFoundError(id)
+	}
+	s.remove(imp)
+	return nil
+}
+
+// Close stops all background parsing and removes the uploaded files
+func (s *ImportService) Close() {
+	s.mu.Lock()
+	imports := s.imports
+	s.imports = make(map[string]*pgnImport)
+	s.mu.Unlock()
+
+	for _, imp := range imports {
+		s.remove(imp)
+	}
+}
+
+// discardAbandoned removes uploads that haven't received a chunk for maxImportIdle
+func (s *ImportService) discardAbandoned() {
+	cutoff := time.Now().Add(-maxImportIdle)
+
+	s.mu.Lock()
+	var abandoned []*pgnImport
+	for id, imp := range s.imports {
+		if imp.state.Status == models.ImportStatusUploading && imp.state.UpdatedAt.Before(cutoff) {
+			abandoned = append(abandoned, imp)
+			delete(s.imports, id)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, imp := range abandoned {
+		s.remove(imp)
+	}
+}
+
+// remove cancels parsing and deletes an import's file
+func (s *ImportService) remove(imp *pgnImport) {
+	s.mu.Lock()
+	cancel := imp.cancel
+	s.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+	if err := os.Remove(imp.path); err != nil && !os.IsNotExist(err) {
+		log.Printf("Failed to remove import file %s: %v", imp.path, err)
+	}
+}
+
+// lookup finds an import by ID
+func (s *ImportService) lookup(id string) (*pgnImport, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	imp, ok := s.imports[id]
+	if !ok {
+		return nil, errors.NewImportNotFoundError(id)
+	}
+	return imp, nil
+}
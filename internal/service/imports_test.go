@@ -0,0 +1,156 @@
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+const importDatabase = `[Event "One"]
+[White "Alice"]
+[Black "Bob"]
+[Result "1-0"]
+
+1. e4 e5 2. Qh5 Nc6 3. Bc4 Nf6 4. Qxf7# 1-0
+
+[Event "Two"]
+[White "Carol"]
+[Black "Dave"]
+[Result "0-1"]
+
+1. f3 e5 2. g4 Qh4# 0-1
+`
+
+func TestImportService_ResumableUpload(t *testing.T) {
+	s := NewImportService(t.TempDir(), 1<<20)
+	defer s.Close()
+
+	upload, err := s.CreateImport(&models.ImportRequest{Filename: "/tmp/games.pgn", Size: int64(len(importDatabase))})
+	if err != nil {
+		t.Fatalf("CreateImport() error = %v", err)
+	}
+	if upload.Filename != "games.pgn" || upload.Status != models.ImportStatusUploading {
+		t.Errorf("Unexpected upload: %+v", upload)
+	}
+
+	first := importDatabase[:40]
+	if upload, err = s.AppendChunk(upload.ID, 0, strings.NewReader(first)); err != nil || upload.Offset != 40 {
+		t.Fatalf("AppendChunk() = %+v, %v", upload, err)
+	}
+
+	// A retried chunk from the wrong offset is rejected with the offset to resume from
+	_, err = s.AppendChunk(upload.ID, 0, strings.NewReader(first))
+	if offsetErr, ok := err.(*errors.UploadOffsetError); !ok || offsetErr.Expected != 40 {
+		t.Fatalf("Expected an offset error at 40, got %v", err)
+	}
+
+	// A chunk running past the declared size is discarded
+	if _, err = s.AppendChunk(upload.ID, 40, strings.NewReader(importDatabase[40:]+"extra")); err == nil {
+		t.Fatal("Expected an oversized chunk to be rejected")
+	}
+
+	if upload, err = s.AppendChunk(upload.ID, 40, strings.NewReader(importDatabase[40:])); err != nil {
+		t.Fatalf("AppendChunk() error = %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for upload.Status == models.ImportStatusParsing && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		upload, _ = s.GetImport(upload.ID)
+	}
+	if upload.Status != models.ImportStatusCompleted || upload.Games != 2 || upload.Progress != 1 {
+		t.Fatalf("Unexpected import state: %+v", upload)
+	}
+
+	page, err := s.GetImportedGames(upload.ID, models.PageRequest{Limit: 1})
+	if err != nil {
+		t.Fatalf("GetImportedGames() error = %v", err)
+	}
+	if page.TotalEstimate != 2 || len(page.Items) != 1 || page.NextCursor == "" {
+		t.Fatalf("Unexpected first page: %+v", page)
+	}
+	games, err := s.GetImportedGames(upload.ID, models.PageRequest{Cursor: page.NextCursor, Limit: 10})
+	if err != nil {
+		t.Fatalf("GetImportedGames() error = %v", err)
+	}
+	if games.TotalEstimate != 2 || len(games.Items) != 1 || games.NextCursor != "" || games.PrevCursor == "" {
+		t.Fatalf("Unexpected page: %+v", games)
+	}
+	if game := games.Items[0]; game.Index != 1 || game.White != "Carol" || game.Moves != 4 ||
+		!strings.HasPrefix(game.PGN, `[Event "Two"]`) {
+		t.Errorf("Unexpected game: %+v", game)
+	}
+
+	if err := s.DeleteImport(upload.ID); err != nil {
+		t.Fatalf("DeleteImport() error = %v", err)
+	}
+	if _, err := s.GetImport(upload.ID); err == nil {
+		t.Error("Expected the deleted import to be gone")
+	}
+}
+
+func TestImportService_CreateImportValidation(t *testing.T) {
+	s := NewImportService(t.TempDir(), 100)
+
+	for _, size := range []int64{0, 101} {
+		if _, err := s.CreateImport(&models.ImportRequest{Size: size}); err == nil {
+			t.Errorf("Expected size %d to be rejected", size)
+		}
+	}
+}
+
+func TestImportService_ChessComArchive(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/player/alice/games/2024/05/pgn" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(importDatabase))
+	}))
+	defer server.Close()
+
+	gameService := NewGameAnalyzerService()
+	gameService.chessAPI.BaseURL = server.URL
+	s := NewImportService(t.TempDir(), 1<<20)
+	s.SetGameService(gameService)
+	defer s.Close()
+
+	upload, err := s.CreateImport(&models.ImportRequest{ChessCom: &models.ChessComArchive{Username: "alice", Year: 2024, Month: 5}})
+	if err != nil {
+		t.Fatalf("CreateImport() error = %v", err)
+	}
+	if upload.Filename != "alice-2024-05.pgn" || upload.Status != models.ImportStatusDownloading {
+		t.Errorf("Unexpected import: %+v", upload)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for upload.CompletedAt == nil && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		upload, _ = s.GetImport(upload.ID)
+	}
+	if upload.Status != models.ImportStatusCompleted || upload.Games != 2 || upload.Size != int64(len(importDatabase)) {
+		t.Fatalf("Unexpected import state: %+v", upload)
+	}
+
+	// A missing archive fails the import
+	upload, err = s.CreateImport(&models.ImportRequest{ChessCom: &models.ChessComArchive{Username: "bob", Year: 2024, Month: 5}})
+	if err != nil {
+		t.Fatalf("CreateImport() error = %v", err)
+	}
+	for upload.CompletedAt == nil && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		upload, _ = s.GetImport(upload.ID)
+	}
+	if upload.Status != models.ImportStatusFailed || upload.Error == "" {
+		t.Errorf("Expected the missing archive to fail the import, got %+v", upload)
+	}
+
+	if _, err := s.CreateImport(&models.ImportRequest{ChessCom: &models.ChessComArchive{Username: "alice", Year: 2024, Month: 13}}); err == nil {
+		t.Error("Expected month 13 to be rejected")
+	}
+}
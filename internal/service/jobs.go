@@ -0,0 +1,203 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/internal/parser"
+	"github.com/pedrampdd/ChessAnalyser/internal/storage"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+// Analysis job settings
+const (
+	defaultSyncAnalysisPlies = 80        // Games up to this many plies are analyzed within the request
+	defaultMaxRunningJobs    = 4         // Game analyses, within requests or as jobs, running at once
+	analysisJobRetention     = time.Hour // Finished jobs are kept this long for clients to poll
+)
+
+// AnalysisJobService fetches Chess.com games and analyzes them in one call. Short games are
+// analyzed within the call; longer ones run in the background as jobs clients poll.
+type AnalysisJobService struct {
+	gameService *GameAnalyzerService
+	analyze     func(ctx context.Context, request *models.AnalysisRequest) (*models.GameAnalysis, error)
+	pgnParser   *parser.PGNParser
+	syncPlies   int
+	maxRunning  int
+	ctx         context.Context // Cancelled on Close, stopping running jobs
+	cancel      context.CancelFunc
+	now         func() time.Time
+
+	mu      sync.Mutex
+	jobs    map[string]*models.AnalysisJob
+	running int // Analyses started by AnalyzeGame that haven't finished
+}
+
+// NewAnalysisJobService creates a new analysis job service
+func NewAnalysisJobService(gameService *GameAnalyzerService, analysisService *AnalysisService) *AnalysisJobService {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &AnalysisJobService{
+		gameService: gameService,
+		analyze:     analysisService.AnalyzeGame,
+		pgnParser:   parser.NewPGNParser(),
+		syncPlies:   defaultSyncAnalysisPlies,
+		maxRunning:  defaultMaxRunningJobs,
+		ctx:         ctx,
+		cancel:      cancel,
+		now:         time.Now,
+		jobs:        make(map[string]*models.AnalysisJob),
+	}
+}
+
+// SetSyncPlies sets the longest game, in plies, analyzed within the request (0 = always use a job)
+func (s *AnalysisJobService) SetSyncPlies(plies int) {
+	s.syncPlies = plies
+}
+
+// SetMaxRunning sets how many game analyses AnalyzeGame runs at once, within requests and as jobs
+// together. Calls beyond that fail with a capacity error until one finishes.
+func (s *AnalysisJobService) SetMaxRunning(n int) {
+	s.maxRunning = n
+}
+
+// AnalyzeGame fetches a game by its Chess.com URL or ID and analyzes it with the request's
+// settings. With a username, the game is looked up among that player's recent games. Games up to
+// the sync limit return their analysis; longer ones return the job analyzing them in the
+// background.
+func (s *AnalysisJobService) AnalyzeGame(ctx context.Context, gameID, username string, request models.AnalysisRequest) (*models.GameAnalysis, *models.AnalysisJob, error) {
+	if gameID == "" {
+		return nil, nil, errors.NewValidationError("url", "a Chess.com game URL or game ID is required")
+	}
+	var game *models.GameInfo
+	var err error
+	if username != "" {
+		game, err = s.gameService.FindPlayerGame(ctx, username, gameID)
+	} else {
+		game, err = s.gameService.GetGameByID(gameID)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if game.Rules != "" && game.Rules != "chess" {
+		return nil, nil, errors.NewUnanalyzableGameError(game.URL, models.UnanalyzableVariant, nil)
+	}
+	parsed, err := s.pgnParser.ParsePGN(game.PGN)
+	if err != nil || len(parsed.Moves) == 0 {
+		return nil, nil, errors.NewUnanalyzableGameError(game.URL, models.UnanalyzableNoMoves, err)
+	}
+	request.PGN = game.PGN
+
+	id, err := storage.NewID()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.acquire(); err != nil {
+		return nil, nil, err
+	}
+
+	if len(parsed.Moves) <= s.syncPlies {
+		defer s.release()
+		analysis, err := s.analyze(ctx, &request)
+		return analysis, nil, err
+	}
+
+	job := &models.AnalysisJob{
+		ID:        id,
+		GameURL:   game.URL,
+		Plies:     len(parsed.Moves),
+		Status:    models.JobStatusRunning,
+		CreatedAt: s.now(),
+	}
+
+	s.mu.Lock()
+	s.pruneJobs()
+	s.jobs[id] = job
+	snapshot := *job
+	s.mu.Unlock()
+
+	go s.run(WithRequester(s.ctx, requesterFrom(ctx)), job, request)
+	return nil, &snapshot, nil
+}
+
+// run analyzes a job's game and records the outcome. ctx carries the requester of the job.
+func (s *AnalysisJobService) run(ctx context.Context, job *models.AnalysisJob, request models.AnalysisRequest) {
+	analysis, err := s.analyze(ctx, &request)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running--
+	completed := s.now()
+	job.CompletedAt = &completed
+	if err != nil {
+		job.Status = models.JobStatusFailed
+		job.Error = err.Error()
+		return
+	}
+	job.Status = models.JobStatusCompleted
+	job.AnalysisID = analysis.ID
+}
+
+// acquire counts a game analysis as running, or fails with a capacity error when as many as
+// allowed already are
+func (s *AnalysisJobService) acquire() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.maxRunning > 0 && s.running >= s.maxRunning {
+		return errors.NewCapacityError("game analyses running at once", s.maxRunning)
+	}
+	s.running++
+	return nil
+}
+
+// release counts a game analysis run within a request as finished
+func (s *AnalysisJobService) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running--
+}
+
+// GetJob returns the state of an analysis job
+func (s *AnalysisJobService) GetJob(jobID string) (*models.AnalysisJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[jobID]
+	if !ok {
+		return nil, errors.NewAnalysisJobNotFoundError(jobID)
+	}
+	snapshot := *job
+	return &snapshot, nil
+}
+
+// ListJobs returns a page of the analysis jobs kept, oldest first
+func (s *AnalysisJobService) ListJobs(request models.PageRequest) (*models.Page[*models.AnalysisJob], error) {
+	s.mu.Lock()
+	s.pruneJobs()
+	jobs := make([]*models.AnalysisJob, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		snapshot := *job
+		jobs = append(jobs, &snapshot)
+	}
+	s.mu.Unlock()
+
+	return storage.Paginate(storage.ListAnalysisJobs, jobs, func(job *models.AnalysisJob) string {
+		return storage.TimeKey(job.CreatedAt, job.ID)
+	}, request)
+}
+
+// pruneJobs forgets jobs that finished longer ago than the retention period. The caller must hold s.mu.
+func (s *AnalysisJobService) pruneJobs() {
+	for id, job := range s.jobs {
+		if job.CompletedAt != nil && s.now().Sub(*job.CompletedAt) > analysisJobRetention {
+			delete(s.jobs, id)
+		}
+	}
+}
+
+// Close stops running jobs
+func (s *AnalysisJobService) Close() {
+	s.cancel()
+}
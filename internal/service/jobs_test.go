@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/internal/storage"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+func TestAnalysisJobService_AnalyzeGame(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/callback/live/game/101" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `{"game": {"moveList": "mC0K", "isFinished": true, "endTime": 1700000600,
+			"pgnHeaders": {"White": "alice", "Black": "bob", "Result": "1-0", "TimeControl": "180+2"}}}`)
+	}))
+	defer server.Close()
+
+	gameService := NewGameAnalyzerService()
+	gameService.chessAPI.CallbackURL = server.URL + "/callback"
+	jobs := NewAnalysisJobService(gameService, &AnalysisService{})
+	defer jobs.Close()
+
+	release := make(chan struct{})
+	jobs.analyze = func(ctx context.Context, request *models.AnalysisRequest) (*models.GameAnalysis, error) {
+		if request.Profile != "quick" {
+			return nil, fmt.Errorf("unexpected profile %q", request.Profile)
+		}
+		<-release
+		return &models.GameAnalysis{ID: "a1", PGN: request.PGN}, nil
+	}
+
+	// A game within the sync limit is analyzed within the call
+	jobs.SetSyncPlies(2)
+	close(release)
+	analysis, job, err := jobs.AnalyzeGame(context.Background(), "https://www.chess.com/game/live/101", "", models.AnalysisRequest{Profile: "quick"})
+	if err != nil || job != nil || analysis == nil || analysis.ID != "a1" {
+		t.Fatalf("AnalyzeGame() = %+v, %+v, %v, want the analysis", analysis, job, err)
+	}
+
+	// A longer game runs as a job
+	release = make(chan struct{})
+	jobs.SetSyncPlies(1)
+	analysis, job, err = jobs.AnalyzeGame(context.Background(), "101", "", models.AnalysisRequest{Profile: "quick"})
+	if err != nil || analysis != nil || job == nil || job.Status != models.JobStatusRunning || job.Plies != 2 {
+		t.Fatalf("AnalyzeGame() = %+v, %+v, %v, want a running job", analysis, job, err)
+	}
+	close(release)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for job.Status == models.JobStatusRunning && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+		if job, err = jobs.GetJob(job.ID); err != nil {
+			t.Fatalf("GetJob() error = %v", err)
+		}
+	}
+	if job.Status != models.JobStatusCompleted || job.AnalysisID != "a1" || job.CompletedAt == nil {
+		t.Errorf("Unexpected job after the analysis: %+v", job)
+	}
+
+	var notFound *errors.AnalysisJobNotFoundError
+	if _, err := jobs.GetJob("missing"); !errors.As(err, &notFound) {
+		t.Errorf("GetJob() error = %v, want AnalysisJobNotFoundError", err)
+	}
+	if _, _, err := jobs.AnalyzeGame(context.Background(), "", "", models.AnalysisRequest{}); err == nil {
+		t.Error("Expected an error without a game URL or ID")
+	}
+}
+
+func TestAnalysisJobService_AnalyzeGameLimits(t *testing.T) {
+	now := time.Now()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/player/alice/games/archives":
+			fmt.Fprintf(w, `{"archives": ["https://api.chess.com/pub/player/alice/games/%d/%02d"]}`, now.Year(), now.Month())
+		case fmt.Sprintf("/player/alice/games/%d/%02d", now.Year(), now.Month()):
+			fmt.Fprint(w, `{"games": [
+				{"url": "https://www.chess.com/game/live/201", "rules": "chess", "pgn": "1. e4 e5 2. Nf3 *"},
+				{"url": "https://www.chess.com/game/live/202", "rules": "chess", "pgn": "1. d4 *"}
+			]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	gameService := NewGameAnalyzerService()
+	gameService.chessAPI.BaseURL = server.URL
+	jobs := NewAnalysisJobService(gameService, &AnalysisService{})
+	defer jobs.Close()
+	jobs.SetSyncPlies(1)
+	jobs.SetMaxRunning(1)
+
+	release := make(chan struct{})
+	jobs.analyze = func(ctx context.Context, request *models.AnalysisRequest) (*models.GameAnalysis, error) {
+		<-release
+		return &models.GameAnalysis{ID: "a1", PGN: request.PGN}, nil
+	}
+
+	// The game is found among the player's games by its ID
+	_, job, err := jobs.AnalyzeGame(context.Background(), "201", "alice", models.AnalysisRequest{})
+	if err != nil || job == nil || job.GameURL != "https://www.chess.com/game/live/201" {
+		t.Fatalf("AnalyzeGame() = %+v, %v, want a job for the player's game", job, err)
+	}
+
+	// Another game waits for the running one to finish
+	var capacity *errors.CapacityError
+	if _, _, err := jobs.AnalyzeGame(context.Background(), "202", "alice", models.AnalysisRequest{}); !errors.As(err, &capacity) {
+		t.Errorf("AnalyzeGame() error = %v, want a capacity error while a job runs", err)
+	}
+	close(release)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		analysis, _, err := jobs.AnalyzeGame(context.Background(), "202", "alice", models.AnalysisRequest{})
+		if err == nil {
+			if analysis == nil || analysis.PGN != "1. d4 *" {
+				t.Errorf("AnalyzeGame() = %+v, want the short game analyzed within the call", analysis)
+			}
+			break
+		}
+		if !errors.As(err, &capacity) || time.Now().After(deadline) {
+			t.Fatalf("AnalyzeGame() error = %v once the job finished", err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	var notFound *errors.GameNotFoundError
+	if _, _, err := jobs.AnalyzeGame(context.Background(), "999", "alice", models.AnalysisRequest{}); !errors.As(err, &notFound) {
+		t.Errorf("AnalyzeGame() error = %v, want GameNotFoundError for a game the player didn't play", err)
+	}
+}
+
+func TestAnalysisJobService_ListJobs(t *testing.T) {
+	jobs := NewAnalysisJobService(NewGameAnalyzerService(), &AnalysisService{})
+	defer jobs.Close()
+
+	// Jobs created in the same instant are ordered by ID
+	start := time.Now()
+	for i, id := range []string{"j1", "j2", "j3", "j4", "j5"} {
+		jobs.jobs[id] = &models.AnalysisJob{ID: id, Status: models.JobStatusRunning, CreatedAt: start.Add(time.Duration(i/2) * time.Second)}
+	}
+
+	ids := func(page *models.Page[*models.AnalysisJob]) string {
+		var ids []string
+		for _, job := range page.Items {
+			ids = append(ids, job.ID)
+		}
+		return strings.Join(ids, ",")
+	}
+	list := func(request models.PageRequest) *models.Page[*models.AnalysisJob] {
+		t.Helper()
+		page, err := jobs.ListJobs(request)
+		if err != nil {
+			t.Fatalf("ListJobs(%+v) error = %v", request, err)
+		}
+		return page
+	}
+
+	first := list(models.PageRequest{Limit: 2})
+	if ids(first) != "j1,j2" || first.PrevCursor != "" || first.NextCursor == "" || first.TotalEstimate != 5 {
+		t.Errorf("Unexpected first page: %s %+v", ids(first), first)
+	}
+	second := list(models.PageRequest{Cursor: first.NextCursor, Limit: 2})
+	if ids(second) != "j3,j4" || second.PrevCursor == "" || second.NextCursor == "" {
+		t.Errorf("Unexpected second page: %s %+v", ids(second), second)
+	}
+
+	// A job created meanwhile doesn't shift the pages
+	jobs.jobs["j0"] = &models.AnalysisJob{ID: "j0", CreatedAt: start.Add(-time.Second)}
+	if third := list(models.PageRequest{Cursor: second.NextCursor, Limit: 2}); ids(third) != "j5" || third.NextCursor != "" {
+		t.Errorf("Unexpected last page: %s %+v", ids(third), third)
+	}
+	if back := list(models.PageRequest{Cursor: second.PrevCursor, Limit: 2}); ids(back) != "j1,j2" || back.PrevCursor == "" {
+		t.Errorf("Unexpected page before the second: %s %+v", ids(back), back)
+	}
+
+	var validation *errors.ValidationError
+	for _, request := range []models.PageRequest{{Cursor: "not a cursor"}, {Limit: 501}, {Cursor: storage.NextCursor(storage.ListAnalyses, "x")}} {
+		if _, err := jobs.ListJobs(request); !errors.As(err, &validation) {
+			t.Errorf("ListJobs(%+v) error = %v, want a validation error", request, err)
+		}
+	}
+}
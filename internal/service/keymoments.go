@@ -0,0 +1,225 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/i18n"
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+)
+
+// Key moment selection thresholds (in pawns, from the mover's point of view)
+const (
+	winningAdvantage   = 3.0 // Evaluation considered a winning position
+	convertedAdvantage = 1.5 // Evaluation still considered clearly better
+	tacticGain         = 1.5 // Minimum gain for a move to count as a tactic
+	turningPointSwing  = 2.0 // Minimum swing for a turning point
+
+	maxBestMoveMoments = 3
+	maxTacticMoments   = 3
+	maxMissedWins      = 3
+)
+
+// GetKeyMoments returns the curated key moments of a stored analysis, described in the given
+// language with their moves in the given display notation
+func (s *AnalysisService) GetKeyMoments(analysisID, language, notation string) ([]models.KeyMoment, error) {
+	language, ok := i18n.Normalize(language)
+	if !ok {
+		return nil, unsupportedLanguageError()
+	}
+
+	analysis, err := s.GetAnalysis(analysisID)
+	if err != nil {
+		return nil, err
+	}
+	n, err := newMoveNotation(analysis, notation)
+	if err != nil {
+		return nil, err
+	}
+	return selectKeyMoments(analysis, language, n), nil
+}
+
+// selectKeyMoments picks the positions worth stepping through in a guided review, with their
+// moves in the given notation. Evaluations are treated from White's point of view, as everywhere
+// else in the analysis.
+func selectKeyMoments(analysis *models.GameAnalysis, language string, n moveNotation) []models.KeyMoment {
+	var missedWins, tactics, bestMoves []models.KeyMoment
+	var turningPoint *models.KeyMoment
+	var turningSwing float64
+
+	prevEval := 0.0
+	prevBest, prevFEN := "", ""
+	for _, move := range analysis.Moves {
+		color := moveColor(move.FEN, move.MoveNumber)
+		before := moverEval(prevEval, color)
+		after := moverEval(move.Evaluation, color)
+		foundBest := prevBest != "" && sameMove(move.Move, prevBest)
+
+		moment := models.KeyMoment{
+			MoveNumber: move.MoveNumber,
+			Color:      color,
+			Move:       n.san(move.Move),
+			BestMove:   n.engineMove(prevFEN, prevBest),
+			Evaluation: move.Evaluation,
+			FEN:        move.FEN,
+		}
+		label := moveLabel(move.FEN, move.MoveNumber, moment.Move)
+
+		switch {
+		case before >= winningAdvantage && after < convertedAdvantage:
+			moment.Type = models.KeyMomentMissedWin
+			if prevBest != "" {
+				moment.Description = i18n.Translate(language, i18n.MomentMissedWinBest, label, moment.BestMove)
+			} else {
+				moment.Description = i18n.Translate(language, i18n.MomentMissedWin, label)
+			}
+			missedWins = append(missedWins, moment)
+		case foundBest && isForcingMove(move.Move) && after-before >= tacticGain:
+			moment.Type = models.KeyMomentTactic
+			moment.Description = i18n.Translate(language, i18n.MomentTactic, label, after-before)
+			tactics = append(tactics, moment)
+		case foundBest && move.Accuracy >= 95:
+			moment.Type = models.KeyMomentBestMove
+			moment.Description = i18n.Translate(language, i18n.MomentBestMove, label)
+			bestMoves = append(bestMoves, moment)
+		}
+
+		// A turning point is the largest swing that hands the advantage to the other side
+		swing := math.Abs(move.Evaluation - prevEval)
+		if swing >= turningPointSwing && swing > turningSwing && changesHands(prevEval, move.Evaluation) {
+			tp := moment
+			tp.Type = models.KeyMomentTurningPoint
+			tp.Description = i18n.Translate(language, i18n.MomentTurningPoint, label, prevEval, move.Evaluation)
+			turningPoint = &tp
+			turningSwing = swing
+		}
+
+		prevEval = move.Evaluation
+		prevBest, prevFEN = move.BestMove, move.FEN
+	}
+
+	// Keep the most significant moments of each kind and avoid repeating a ply
+	sortBySignificance(bestMoves)
+	sortBySignificance(tactics)
+
+	var moments []models.KeyMoment
+	seen := make(map[int]bool)
+	add := func(candidates []models.KeyMoment, limit int) {
+		for _, m := range candidates {
+			if limit == 0 {
+				return
+			}
+			if seen[m.MoveNumber] {
+				continue
+			}
+			seen[m.MoveNumber] = true
+			moments = append(moments, m)
+			limit--
+		}
+	}
+
+	add(missedWins, maxMissedWins)
+	if turningPoint != nil {
+		add([]models.KeyMoment{*turningPoint}, 1)
+	}
+	add(tactics, maxTacticMoments)
+	add(bestMoves, maxBestMoveMoments)
+
+	sort.Slice(moments, func(i, j int) bool {
+		return moments[i].MoveNumber < moments[j].MoveNumber
+	})
+
+	return moments
+}
+
+// sortBySignificance orders moments by how sharp the resulting position is
+func sortBySignificance(moments []models.KeyMoment) {
+	sort.SliceStable(moments, func(i, j int) bool {
+		return math.Abs(moments[i].Evaluation) > math.Abs(moments[j].Evaluation)
+	})
+}
+
+// moveColor returns the side that played a move: the side not to move in the position after it.
+// Games set up from a position with Black to move start with a Black ply, so the ply's parity
+// only stands in when the position is unknown.
+func moveColor(fen string, ply int) string {
+	if fields := strings.Fields(fen); len(fields) > 1 {
+		switch fields[1] {
+		case "w":
+			return "black"
+		case "b":
+			return "white"
+		}
+	}
+	if ply%2 == 1 {
+		return "white"
+	}
+	return "black"
+}
+
+// moverEval converts a White-relative evaluation to the given side's point of view
+func moverEval(evaluation float64, color string) float64 {
+	if color == "black" {
+		return -evaluation
+	}
+	return evaluation
+}
+
+// changesHands reports whether the advantage moved from one side to the other
+func changesHands(before, after float64) bool {
+	return (before > 0.5 && after < -0.5) || (before < -0.5 && after > 0.5)
+}
+
+// moveLabel formats a move with its move number, e.g. "12. Nf3" or "12... Nc6". The number is
+// read from the position after the move when it's known, since set-up games don't start at move 1.
+func moveLabel(fen string, ply int, move string) string {
+	color := moveColor(fen, ply)
+	number := (ply + 1) / 2
+	if fields := strings.Fields(fen); len(fields) > 5 {
+		if fullMoves, err := strconv.Atoi(fields[5]); err == nil {
+			number = fullMoves
+			if color == "black" {
+				number-- // The move number goes up after Black's move
+			}
+		}
+	}
+	if color == "white" {
+		return fmt.Sprintf("%d. %s", number, move)
+	}
+	return fmt.Sprintf("%d... %s", number, move)
+}
+
+// isForcingMove reports whether a SAN move is a capture or a check
+func isForcingMove(san string) bool {
+	return strings.ContainsAny(san, "x+#")
+}
+
+// sameMove compares a SAN move with an engine (UCI) move by target square and promotion
+func sameMove(san, uci string) bool {
+	if len(uci) < 4 {
+		return false
+	}
+
+	san = strings.TrimRight(san, "+#!?")
+	switch san {
+	case "O-O":
+		return uci == "e1g1" || uci == "e8g8"
+	case "O-O-O":
+		return uci == "e1c1" || uci == "e8c8"
+	}
+
+	promotion := ""
+	if idx := strings.Index(san, "="); idx != -1 {
+		promotion = strings.ToLower(san[idx+1:])
+		san = san[:idx]
+	}
+	if len(san) < 2 {
+		return false
+	}
+
+	target := san[len(san)-2:]
+	return target == uci[2:4] && promotion == uci[4:]
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/i18n"
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+)
+
+func TestSelectKeyMoments(t *testing.T) {
+	analysis := &models.GameAnalysis{
+		Moves: []models.MoveAnalysis{
+			{Move: "e4", MoveNumber: 1, Evaluation: 0.3, Accuracy: 97, BestMove: "e7e5"},
+			{Move: "e5", MoveNumber: 2, Evaluation: 0.3, Accuracy: 97, BestMove: "g1f3"},
+			{Move: "Nf3", MoveNumber: 3, Evaluation: 0.4, Accuracy: 96, BestMove: "f7f6"},
+			{Move: "f6", MoveNumber: 4, Evaluation: 2.5, Accuracy: 70, BestMove: "f3e5"},
+			{Move: "Nxe5", MoveNumber: 5, Evaluation: 4.5, Accuracy: 98, BestMove: "d8e7"},
+			{Move: "Qe7", MoveNumber: 6, Evaluation: 4.2, Accuracy: 96, BestMove: "e5f3"},
+			{Move: "Qh5+", MoveNumber: 7, Evaluation: -1.0, Accuracy: 40, BestMove: "g7g6"},
+		},
+	}
+
+	moments := selectKeyMoments(analysis, i18n.DefaultLanguage, moveNotation{})
+
+	byType := make(map[string]models.KeyMoment)
+	for _, m := range moments {
+		byType[m.Type] = m
+	}
+
+	tactic, ok := byType[models.KeyMomentTactic]
+	if !ok || tactic.MoveNumber != 5 {
+		t.Errorf("Expected Nxe5 to be selected as a tactic, got %+v", moments)
+	}
+
+	missed, ok := byType[models.KeyMomentMissedWin]
+	if !ok || missed.MoveNumber != 7 || missed.BestMove != "e5f3" {
+		t.Errorf("Expected Qh5+ to be selected as a missed win, got %+v", moments)
+	}
+
+	if _, ok := byType[models.KeyMomentBestMove]; !ok {
+		t.Errorf("Expected at least one best move moment, got %+v", moments)
+	}
+
+	for i := 1; i < len(moments); i++ {
+		if moments[i].MoveNumber <= moments[i-1].MoveNumber {
+			t.Errorf("Expected moments ordered by move number without duplicates, got %+v", moments)
+		}
+	}
+}
+
+func TestMoveColorAndLabel(t *testing.T) {
+	tests := []struct {
+		fen       string
+		ply       int
+		move      string
+		wantColor string
+		wantLabel string
+	}{
+		{"rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1", 1, "e4", "white", "1. e4"},
+		{"rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 0 2", 2, "e5", "black", "1... e5"},
+		// A set-up game with Black to move starts with a Black ply
+		{"6k1/8/8/8/8/8/5r2/6K1 w - - 1 41", 1, "Rf2", "black", "40... Rf2"},
+		{"6k1/8/8/8/8/8/5r2/5K2 b - - 2 41", 2, "Kf1", "white", "41. Kf1"},
+		// Without a position the ply's parity stands in
+		{"", 3, "Nf3", "white", "2. Nf3"},
+		{"", 4, "Nc6", "black", "2... Nc6"},
+	}
+	for _, tt := range tests {
+		if got := moveColor(tt.fen, tt.ply); got != tt.wantColor {
+			t.Errorf("moveColor(%q, %d) = %q, want %q", tt.fen, tt.ply, got, tt.wantColor)
+		}
+		if got := moveLabel(tt.fen, tt.ply, tt.move); got != tt.wantLabel {
+			t.Errorf("moveLabel(%q, %d) = %q, want %q", tt.fen, tt.ply, got, tt.wantLabel)
+		}
+	}
+}
+
+func TestSameMove(t *testing.T) {
+	tests := []struct {
+		san  string
+		uci  string
+		want bool
+	}{
+		{"Nf3", "g1f3", true},
+		{"exd5", "e4d5", true},
+		{"O-O", "e1g1", true},
+		{"O-O-O+", "e8c8", true},
+		{"e8=Q+", "e7e8q", true},
+		{"e8=N", "e7e8q", false},
+		{"Nf3", "g1h3", false},
+		{"Nf3", "", false},
+	}
+
+	for _, tt := range tests {
+		if got := sameMove(tt.san, tt.uci); got != tt.want {
+			t.Errorf("sameMove(%q, %q) = %v, want %v", tt.san, tt.uci, got, tt.want)
+		}
+	}
+}
+
+func TestLocalizeAnalysis(t *testing.T) {
+	s := &AnalysisService{}
+	analysis := &models.GameAnalysis{
+		Language: i18n.DefaultLanguage,
+		Accuracy: models.GameAccuracy{AverageAccuracy: 70},
+	}
+	analysis.Summary.Recommendations = s.generateRecommendations(analysis)
+
+	localized, err := s.LocalizeAnalysis(analysis, "es-ES")
+	if err != nil {
+		t.Fatalf("LocalizeAnalysis() error = %v", err)
+	}
+	if localized.Language != "es" || len(localized.Summary.Recommendations) != 1 ||
+		localized.Summary.Recommendations[0].Text != i18n.Translate("es", i18n.RecommendAccuracy) {
+		t.Errorf("Expected Spanish recommendations, got %+v", localized.Summary)
+	}
+	if analysis.Summary.Recommendations[0].Text != i18n.Translate("en", i18n.RecommendAccuracy) {
+		t.Errorf("Expected the original analysis to stay in English, got %v", analysis.Summary.Recommendations)
+	}
+
+	// Codes and params don't depend on the language
+	recommendation := localized.Summary.Recommendations[0]
+	if recommendation.Code != models.RecommendationAccuracy || recommendation.Category != models.CategoryAccuracy ||
+		recommendation.Severity != models.SeverityMedium || recommendation.Params["accuracy"] != 70.0 {
+		t.Errorf("Unexpected structured recommendation: %+v", recommendation)
+	}
+
+	if _, err := s.LocalizeAnalysis(analysis, "tlh"); err == nil {
+		t.Error("Expected an error for an unsupported language")
+	}
+}
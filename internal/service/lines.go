@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/board"
+	"github.com/pedrampdd/ChessAnalyser/internal/engine"
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+// maxExploredLines caps Multi-PV for the lines explorer; each extra line slows the search down
+const maxExploredLines = 10
+
+// ExploreLines returns the engine's top lines for a position as candidate moves with their continuations
+func (s *AnalysisService) ExploreLines(ctx context.Context, fen string, settings models.EngineSettings) (*models.PositionLines, error) {
+	if settings.MultiPV < 1 || settings.MultiPV > maxExploredLines {
+		return nil, errors.NewValidationError("multipv", fmt.Sprintf("must be between 1 and %d", maxExploredLines))
+	}
+
+	result, err := s.AnalyzePosition(ctx, fen, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := &models.PositionLines{
+		FEN:        fen,
+		Evaluation: result.Evaluation,
+		Depth:      result.Depth,
+		Lines:      []models.PositionLine{},
+	}
+	for i, pv := range result.Lines {
+		if len(pv.Moves) == 0 {
+			continue
+		}
+		line := models.PositionLine{
+			Rank:         i + 1,
+			Move:         pv.Moves[0],
+			Evaluation:   pv.Evaluation,
+			Depth:        pv.Depth,
+			Continuation: pv.Moves[1:],
+		}
+		if engine.IsStandardVariant(settings.Variant) {
+			describeLine(fen, &line)
+		}
+		lines.Lines = append(lines.Lines, line)
+	}
+	return lines, nil
+}
+
+// describeLine fills in the SAN of a line and the position after its candidate move
+func describeLine(fen string, line *models.PositionLine) {
+	b, err := board.FromFEN(fen)
+	if err != nil {
+		return
+	}
+	m, err := b.ParseUCI(line.Move)
+	if err != nil {
+		return
+	}
+
+	line.MoveSAN = b.SAN(m)
+	b.Apply(m)
+	line.FEN = b.FEN()
+	line.ContinuationSAN = sanLine(line.FEN, line.Continuation)
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+func TestDescribeLine(t *testing.T) {
+	fen := "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+	line := models.PositionLine{Move: "g1f3", Continuation: []string{"d7d5", "g2g3"}}
+
+	describeLine(fen, &line)
+	if line.MoveSAN != "Nf3" || line.FEN != "rnbqkbnr/pppppppp/8/8/8/5N2/PPPPPPPP/RNBQKB1R b KQkq - 1 1" {
+		t.Errorf("Unexpected candidate move: %+v", line)
+	}
+	if want := []string{"d5", "g3"}; !reflect.DeepEqual(line.ContinuationSAN, want) {
+		t.Errorf("ContinuationSAN = %v, want %v", line.ContinuationSAN, want)
+	}
+}
+
+func TestAnalysisService_ExploreLinesValidation(t *testing.T) {
+	service := newTestAnalysisService()
+	fen := "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+
+	for _, multiPV := range []int{0, maxExploredLines + 1} {
+		var validation *errors.ValidationError
+		if _, err := service.ExploreLines(context.Background(), fen, models.EngineSettings{MultiPV: multiPV}); !errors.As(err, &validation) {
+			t.Errorf("Expected a validation error for multipv %d, got %v", multiPV, err)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/client"
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/internal/parser"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+// Estimated game durations (base time plus 40 increments, in seconds) that separate Chess.com's time classes
+const (
+	maxBulletDuration = 180
+	maxBlitzDuration  = 600
+)
+
+// getCallbackGame retrieves a single live or daily game by its numeric ID. Games still in
+// progress are returned with the moves played so far and no end time.
+func (s *GameAnalyzerService) getCallbackGame(gameType, gameID string) (*models.GameInfo, error) {
+	game, err := s.chessAPI.GetCallbackGame(gameType, gameID)
+	if err == client.ErrGameNotFound {
+		return nil, errors.NewGameNotFoundError(gameID, err)
+	}
+	if err != nil {
+		return nil, errors.NewAPIError("failed to retrieve game", err)
+	}
+
+	url := fmt.Sprintf("https://www.chess.com/game/%s/%s", gameType, gameID)
+	headers, moves, err := decodeCallbackGame(url, game)
+	if err != nil {
+		return nil, err
+	}
+	return callbackGameInfo(s.pgnParser, gameType, gameID, url, game, headers, moves), nil
+}
+
+// callbackGameInfo converts a decoded callback game into GameInfo, with the clock after each ply
+func callbackGameInfo(pgnParser *parser.PGNParser, gameType, gameID, url string, game *client.CallbackGame,
+	headers map[string]string, moves []parser.ParsedMove) *models.GameInfo {
+	parsed := &parser.ParsedGame{
+		Headers: make(map[string]string, len(headers)),
+		Moves:   moves,
+		Result:  headers["Result"],
+		PGN:     callbackGamePGN(pgnParser, headers, moves),
+	}
+	for name, value := range headers {
+		parsed.Headers[strings.ToLower(name)] = value
+	}
+
+	gameInfo := pgnParser.ConvertToGameInfo(parsed)
+	gameInfo.GameID = gameID
+	gameInfo.URL = url
+	gameInfo.Rated = game.IsRated
+	gameInfo.TimeClass = callbackTimeClass(gameType, headers["TimeControl"])
+	gameInfo.WhitePlayer.Rating, _ = strconv.Atoi(headers["WhiteElo"])
+	gameInfo.BlackPlayer.Rating, _ = strconv.Atoi(headers["BlackElo"])
+
+	gameInfo.Rules = "chess"
+	if variant := headers["Variant"]; variant != "" {
+		gameInfo.Rules = strings.ToLower(variant)
+	}
+
+	gameInfo.FEN = headers["FEN"]
+	if len(moves) > 0 {
+		gameInfo.FEN = moves[len(moves)-1].FEN
+	}
+
+	if start, err := time.Parse("2006.01.02 15:04:05", headers["UTCDate"]+" "+headers["UTCTime"]); err == nil {
+		gameInfo.StartTime = start
+	}
+	if game.IsFinished && game.EndTime > 0 {
+		endTime := time.Unix(game.EndTime, 0)
+		gameInfo.EndTime = &endTime
+	}
+
+	// The timestamps are the mover's remaining clock after each ply
+	for i, timestamp := range strings.Split(game.MoveTimestamps, ",") {
+		if i >= len(gameInfo.Moves) {
+			break
+		}
+		if tenths, err := strconv.Atoi(strings.TrimSpace(timestamp)); err == nil {
+			remaining := tenths / 10
+			gameInfo.Moves[i].TimeRemaining = &remaining
+		}
+	}
+
+	return gameInfo
+}
+
+// callbackTimeClass derives the time class of a game from its type and TimeControl tag, e.g. "180+2"
+func callbackTimeClass(gameType, timeControl string) string {
+	if gameType == "daily" {
+		return "daily"
+	}
+
+	base, increment, _ := strings.Cut(timeControl, "+")
+	baseSeconds, err := strconv.Atoi(base)
+	if err != nil {
+		return ""
+	}
+	incrementSeconds, _ := strconv.Atoi(increment)
+
+	switch duration := baseSeconds + 40*incrementSeconds; {
+	case duration < maxBulletDuration:
+		return "bullet"
+	case duration < maxBlitzDuration:
+		return "blitz"
+	default:
+		return "rapid"
+	}
+}
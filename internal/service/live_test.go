@@ -0,0 +1,101 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+func TestGetGameByID_CallbackGames(t *testing.T) {
+	requests := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests[r.URL.Path]++
+		switch r.URL.Path {
+		case "/callback/live/game/101":
+			fmt.Fprint(w, `{"game": {"moveList": "mC0K", "moveTimestamps": "1795,1780", "isFinished": true,
+				"isRated": true, "endTime": 1700000600, "pgnHeaders": {"White": "alice", "Black": "bob",
+				"WhiteElo": 1500, "BlackElo": 1480, "Result": "1-0", "TimeControl": "180+2",
+				"UTCDate": "2023.11.14", "UTCTime": "22:13:20"}}}`)
+		case "/callback/daily/game/202":
+			fmt.Fprint(w, `{"game": {"moveList": "mC", "isFinished": false,
+				"pgnHeaders": {"White": "carol", "Black": "dave", "Result": "*", "TimeControl": "1/86400"}}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	service := NewGameAnalyzerService()
+	service.chessAPI.CallbackURL = server.URL + "/callback"
+
+	game, err := service.GetGameByID("101")
+	if err != nil {
+		t.Fatalf("GetGameByID() error = %v", err)
+	}
+	if game.URL != "https://www.chess.com/game/live/101" || game.TimeClass != "blitz" || game.Rules != "chess" || !game.Rated {
+		t.Errorf("Unexpected game: %+v", game)
+	}
+	if game.WhitePlayer.Username != "alice" || game.WhitePlayer.Rating != 1500 || game.BlackPlayer.Rating != 1480 {
+		t.Errorf("Unexpected players: %+v vs %+v", game.WhitePlayer, game.BlackPlayer)
+	}
+	if game.StartTime.Unix() != 1700000000 || game.EndTime == nil || game.EndTime.Unix() != 1700000600 {
+		t.Errorf("Unexpected start and end times: %v, %v", game.StartTime, game.EndTime)
+	}
+	if len(game.Moves) != 2 || game.Moves[1].BlackMove != "e5" || game.Moves[1].TimeRemaining == nil || *game.Moves[1].TimeRemaining != 178 {
+		t.Errorf("Unexpected moves: %+v", game.Moves)
+	}
+	if game.FEN != "rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq e6 0 2" || !strings.Contains(game.PGN, "1. e4 e5 1-0") {
+		t.Errorf("Unexpected position or PGN: %s\n%s", game.FEN, game.PGN)
+	}
+
+	// Finished games are cached, and the same game can be requested by URL
+	if _, err := service.GetGameByID("101"); err != nil || requests["/callback/live/game/101"] != 1 {
+		t.Errorf("Expected the finished game to be cached, got %d requests (%v)", requests["/callback/live/game/101"], err)
+	}
+	if game, err := service.GetGameByID("https://www.chess.com/game/live/101"); err != nil || game.GameID != "101" {
+		t.Errorf("Expected the game by URL, got %+v (%v)", game, err)
+	}
+
+	// A numeric ID that isn't a live game is looked up as a daily game, which isn't cached while in progress
+	game, err = service.GetGameByID("202")
+	if err != nil {
+		t.Fatalf("GetGameByID() error = %v", err)
+	}
+	if game.TimeClass != "daily" || game.EndTime != nil || len(game.Moves) != 1 {
+		t.Errorf("Unexpected daily game: %+v", game)
+	}
+	service.GetGameByID("202")
+	if requests["/callback/daily/game/202"] != 2 {
+		t.Errorf("Expected the game in progress to be fetched again, got %d requests", requests["/callback/daily/game/202"])
+	}
+
+	var notFound *errors.GameNotFoundError
+	if _, err := service.GetGameByID("303"); !errors.As(err, &notFound) {
+		t.Errorf("Expected game not found, got %v", err)
+	}
+}
+
+func TestCallbackTimeClass(t *testing.T) {
+	tests := []struct {
+		gameType    string
+		timeControl string
+		want        string
+	}{
+		{"live", "60", "bullet"},
+		{"live", "120+1", "bullet"},
+		{"live", "180+2", "blitz"},
+		{"live", "600", "rapid"},
+		{"daily", "1/86400", "daily"},
+		{"live", "", ""},
+	}
+
+	for _, tt := range tests {
+		if got := callbackTimeClass(tt.gameType, tt.timeControl); got != tt.want {
+			t.Errorf("callbackTimeClass(%q, %q) = %q, want %q", tt.gameType, tt.timeControl, got, tt.want)
+		}
+	}
+}
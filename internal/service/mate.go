@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/board"
+	"github.com/pedrampdd/ChessAnalyser/internal/engine"
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+// Mate search limits
+const (
+	maxMateMoves          = 20
+	defaultMateSearchTime = 10000 // Milliseconds
+	maxMateSearchTime     = 25000 // Stays below the engine's 30 second output timeout
+)
+
+// FindMate asks the engine whether the side to move has a forced mate within the given number of moves.
+// "No mate" is only reported once the search got at least 2N-1 plies deep without finding one.
+func (s *AnalysisService) FindMate(ctx context.Context, fen string, moves int, settings models.EngineSettings) (*models.MateSearch, error) {
+	if moves < 1 || moves > maxMateMoves {
+		return nil, errors.NewValidationError("moves", fmt.Sprintf("must be between 1 and %d", maxMateMoves))
+	}
+	if settings.TimeLimit <= 0 {
+		settings.TimeLimit = defaultMateSearchTime
+	}
+	if settings.TimeLimit > maxMateSearchTime {
+		settings.TimeLimit = maxMateSearchTime
+	}
+
+	// A position without legal moves has nothing to search; Stockfish would answer "bestmove (none)"
+	if engine.IsStandardVariant(settings.Variant) {
+		if b, err := board.FromFEN(fen); err == nil && b.Validate() == nil && len(b.LegalMoves()) == 0 {
+			return nil, errors.NewValidationError("fen", "the side to move has no legal moves")
+		}
+	}
+
+	pool, err := s.poolFor(settings.Variant, "")
+	if err != nil {
+		return nil, err
+	}
+	stockfishEngine, err := pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer pool.ReturnEngine(stockfishEngine)
+
+	result, mateIn, err := stockfishEngine.SearchMate(ctx, fen, moves, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	search := &models.MateSearch{
+		FEN:    fen,
+		Moves:  moves,
+		Status: mateStatus(mateIn, moves, result.Depth),
+		Depth:  result.Depth,
+		Nodes:  result.Nodes,
+		Time:   result.Time,
+	}
+	if search.Status == models.MateStatusFound {
+		search.MateIn = mateIn
+		search.Line = result.PrincipalVariation
+		search.LineSAN = sanLine(fen, result.PrincipalVariation)
+	}
+	return search, nil
+}
+
+// mateStatus decides the outcome of a mate search from the mate distance found and the depth reached
+func mateStatus(mateIn, moves, depth int) string {
+	switch {
+	case mateIn > 0 && mateIn <= moves:
+		return models.MateStatusFound
+	case depth >= 2*moves-1:
+		return models.MateStatusNone
+	}
+	return models.MateStatusUnknown
+}
+
+// sanLine converts a line of UCI moves to SAN, stopping at the first move that can't be replayed
+func sanLine(fen string, line []string) []string {
+	b, err := board.FromFEN(fen)
+	if err != nil {
+		return nil
+	}
+
+	var san []string
+	for _, uci := range line {
+		m, err := b.ParseUCI(uci)
+		if err != nil {
+			break
+		}
+		san = append(san, b.SAN(m))
+		b.Apply(m)
+	}
+	return san
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+func TestMateStatus(t *testing.T) {
+	tests := []struct {
+		mateIn, moves, depth int
+		want                 string
+	}{
+		{2, 3, 4, models.MateStatusFound},
+		{4, 3, 9, models.MateStatusNone}, // A longer mate isn't a mate within the requested moves
+		{0, 3, 5, models.MateStatusNone},
+		{0, 3, 4, models.MateStatusUnknown},
+		{-2, 3, 12, models.MateStatusNone}, // The side to move is the one getting mated
+	}
+
+	for _, tt := range tests {
+		if got := mateStatus(tt.mateIn, tt.moves, tt.depth); got != tt.want {
+			t.Errorf("mateStatus(%d, %d, %d) = %s, want %s", tt.mateIn, tt.moves, tt.depth, got, tt.want)
+		}
+	}
+}
+
+func TestSanLine(t *testing.T) {
+	fen := "rnbqkbnr/ppppp2p/8/5pp1/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 0 3"
+	got := sanLine(fen, []string{"d1h5", "e8e7"})
+	if want := []string{"Qh5#"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("sanLine() = %v, want %v", got, want)
+	}
+}
+
+func TestAnalysisService_FindMateValidation(t *testing.T) {
+	service := newTestAnalysisService()
+	var validation *errors.ValidationError
+
+	if _, err := service.FindMate(context.Background(), "4k3/8/8/8/8/8/8/4K3 w - - 0 1", 0, models.EngineSettings{}); !errors.As(err, &validation) {
+		t.Errorf("Expected a validation error for 0 moves, got %v", err)
+	}
+
+	// Fool's mate: White is already checkmated
+	mated := "rnb1kbnr/pppp1ppp/8/4p3/6Pq/5P2/PPPPP2P/RNBQKBNR w KQkq - 1 3"
+	if _, err := service.FindMate(context.Background(), mated, 2, models.EngineSettings{}); !errors.As(err, &validation) {
+		t.Errorf("Expected a validation error for a position without legal moves, got %v", err)
+	}
+}
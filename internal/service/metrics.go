@@ -0,0 +1,98 @@
+package service
+
+import (
+	"sync"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+)
+
+// analysisMetrics aggregates per-analysis costs across the lifetime of the service
+type analysisMetrics struct {
+	mu              sync.Mutex
+	analyses        int64
+	cacheHits       int64
+	totalEngineTime int64
+	totalWallClock  int64
+	totalNodes      int64
+	totalPositions  int64
+	peakHashMB      float64
+}
+
+// recordAnalysis adds the cost of a freshly computed analysis
+func (m *analysisMetrics) recordAnalysis(cost *models.AnalysisCost) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.analyses++
+	m.totalEngineTime += cost.EngineTime
+	m.totalWallClock += cost.WallClock
+	m.totalNodes += cost.Nodes
+	m.totalPositions += int64(cost.Positions)
+	if cost.PeakHashMB > m.peakHashMB {
+		m.peakHashMB = cost.PeakHashMB
+	}
+}
+
+// recordCacheHit counts a request served from the cache
+func (m *analysisMetrics) recordCacheHit() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.cacheHits++
+}
+
+// snapshot returns the aggregated metrics
+func (m *analysisMetrics) snapshot() models.MetricsSnapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snapshot := models.MetricsSnapshot{
+		Analyses:        m.analyses,
+		CacheHits:       m.cacheHits,
+		TotalEngineTime: m.totalEngineTime,
+		TotalWallClock:  m.totalWallClock,
+		TotalNodes:      m.totalNodes,
+		TotalPositions:  m.totalPositions,
+		PeakHashMB:      m.peakHashMB,
+	}
+	if requests := m.analyses + m.cacheHits; requests > 0 {
+		snapshot.CacheHitRate = float64(m.cacheHits) / float64(requests)
+	}
+	if m.analyses > 0 {
+		snapshot.AverageWallClock = float64(m.totalWallClock) / float64(m.analyses)
+	}
+	return snapshot
+}
+
+// GetMetrics returns aggregated analysis costs and the current engine pool usage
+func (s *AnalysisService) GetMetrics() models.MetricsSnapshot {
+	snapshot := s.metrics.snapshot()
+	for _, pool := range s.enginePoolStatuses() {
+		snapshot.TotalEngines += pool.TotalEngines
+		snapshot.AvailableEngines += pool.AvailableEngines
+	}
+	return snapshot
+}
+
+// hashUsageMB converts the engine's hashfull (permille) to megabytes of the configured hash
+func hashUsageMB(hashFull, hashSize int) float64 {
+	return float64(hashFull) * float64(hashSize) / 1000
+}
+
+// recordCacheHit counts a cache hit globally and on the stored analysis
+func (s *AnalysisService) recordCacheHit(analysis *models.GameAnalysis) {
+	s.metrics.recordCacheHit()
+	if analysis.ID == "" {
+		return
+	}
+
+	// Stored analyses are copy-on-write, so replace the cost rather than mutating the shared one
+	_, _ = s.store.UpdateAnalysis(analysis.ID, func(stored *models.GameAnalysis) error {
+		cost := models.AnalysisCost{}
+		if stored.Cost != nil {
+			cost = *stored.Cost
+		}
+		cost.CacheHits++
+		stored.Cost = &cost
+		return nil
+	})
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+)
+
+func TestAnalysisService_Metrics(t *testing.T) {
+	service := newTestAnalysisService()
+
+	service.metrics.recordAnalysis(&models.AnalysisCost{EngineTime: 1200, WallClock: 1500, Nodes: 4000, Positions: 10, PeakHashMB: 12.8})
+	service.metrics.recordAnalysis(&models.AnalysisCost{EngineTime: 800, WallClock: 500, Nodes: 1000, Positions: 6, PeakHashMB: 3.2})
+
+	id, err := service.store.SaveAnalysis(&models.GameAnalysis{Cost: &models.AnalysisCost{Nodes: 4000}})
+	if err != nil {
+		t.Fatalf("SaveAnalysis() error = %v", err)
+	}
+	cached, _ := service.store.GetAnalysis(id)
+	service.recordCacheHit(cached)
+	service.recordCacheHit(cached)
+
+	metrics := service.GetMetrics()
+	if metrics.Analyses != 2 || metrics.CacheHits != 2 || metrics.TotalNodes != 5000 || metrics.TotalPositions != 16 {
+		t.Errorf("Unexpected totals: %+v", metrics)
+	}
+	if metrics.PeakHashMB != 12.8 || metrics.AverageWallClock != 1000 || metrics.CacheHitRate != 0.5 {
+		t.Errorf("Unexpected derived metrics: %+v", metrics)
+	}
+
+	stored, _ := service.store.GetAnalysis(id)
+	if stored.Cost.CacheHits != 2 || stored.Cost.Nodes != 4000 {
+		t.Errorf("Expected stored cost to count cache hits, got %+v", stored.Cost)
+	}
+	if cached.Cost.CacheHits != 0 {
+		t.Errorf("Expected the previously returned analysis to be left untouched, got %+v", cached.Cost)
+	}
+}
+
+func TestHashUsageMB(t *testing.T) {
+	if got := hashUsageMB(250, 128); got != 32 {
+		t.Errorf("hashUsageMB(250, 128) = %v, want 32", got)
+	}
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/internal/storage"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+// DefaultNewGamesLimit is how many new games a poll returns when it doesn't set a limit
+const DefaultNewGamesLimit = 100
+
+// GetNewGames returns the games a player finished after a cursor, the end time of the last game
+// seen, oldest first. Without since, the cursor stored for the player and consumer by their
+// previous poll is used, so integrators polling for new games get each game once; consumers keep
+// separate cursors. A first poll returns the games of the player's latest archive month; when
+// there are none, the cursor starts at the time of the poll.
+// Only the archives from the cursor's month on are fetched, and the stored cursor advances to the
+// last game returned. Games ending in the same second are never split between polls.
+func (s *SyncService) GetNewGames(ctx context.Context, username, consumer string, since *int64, limit int) (*models.NewGames, error) {
+	if username == "" {
+		return nil, errors.NewValidationError("username", "username is required")
+	}
+	if since != nil && *since < 0 {
+		return nil, errors.NewValidationError("since", "must not be negative")
+	}
+	if limit == 0 {
+		limit = DefaultNewGamesLimit
+	}
+	if limit < 0 || limit > storage.MaxPageSize {
+		return nil, errors.NewValidationError("limit", fmt.Sprintf("must be between 1 and %d", storage.MaxPageSize))
+	}
+
+	// Polls of the same consumer must not both be given the games after the same cursor
+	s.cursorMu.Lock()
+	defer s.cursorMu.Unlock()
+
+	result := &models.NewGames{Username: strings.ToLower(username), Consumer: consumer, Games: []*models.GameInfo{}}
+	lastArchive := ""
+	if since != nil {
+		result.Since = *since
+		lastArchive = cursorArchive(*since)
+	} else if cursor, ok := s.store.GetGameCursor(username, consumer); ok {
+		result.Since = cursor
+		lastArchive = cursorArchive(cursor)
+	}
+	// Taken before the archives are read, so games ending during the poll aren't skipped
+	polled := s.now().Unix()
+
+	archives, err := s.gameService.GetPlayerArchives(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	pending := archivesToSync(archives, lastArchive)
+	for i, archive := range pending {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		games, err := s.gameService.GetPlayerGames(username, archive[0], archive[1], models.GameFilter{})
+		if isArchiveNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, game := range games {
+			if game.EndTime != nil && game.EndTime.Unix() > result.Since {
+				result.Games = append(result.Games, game)
+			}
+		}
+
+		// Later months only hold later games
+		if len(result.Games) > limit {
+			result.HasMore = i < len(pending)-1
+			break
+		}
+	}
+
+	sort.SliceStable(result.Games, func(i, j int) bool {
+		return result.Games[i].EndTime.Before(*result.Games[j].EndTime)
+	})
+	if len(result.Games) > limit {
+		end := limit
+		for end < len(result.Games) && result.Games[end].EndTime.Unix() == result.Games[limit-1].EndTime.Unix() {
+			end++
+		}
+		result.HasMore = result.HasMore || end < len(result.Games)
+		result.Games = result.Games[:end]
+	}
+
+	result.Cursor = result.Since
+	switch {
+	case len(result.Games) > 0:
+		result.Cursor = result.Games[len(result.Games)-1].EndTime.Unix()
+	case lastArchive == "":
+		// A cursor of 0 would make the next poll walk every archive since 1970
+		result.Cursor = polled
+	}
+	s.store.SaveGameCursor(username, consumer, result.Cursor)
+	return result, nil
+}
+
+// cursorArchive returns the archive month holding the games that ended at a cursor
+func cursorArchive(cursor int64) string {
+	end := time.Unix(cursor, 0).UTC()
+	return archiveKey(end.Year(), int(end.Month()))
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/board"
+	"github.com/pedrampdd/ChessAnalyser/internal/engine"
+	"github.com/pedrampdd/ChessAnalyser/internal/i18n"
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+// displayNotation normalizes the notation a request asks moves to be displayed in
+func displayNotation(notation string) (string, error) {
+	normalized, ok := i18n.NormalizeNotation(notation)
+	if !ok {
+		return "", errors.NewValidationError("notation", fmt.Sprintf("unsupported notation; supported notations: %s", strings.Join(i18n.Notations(), ", ")))
+	}
+	return normalized, nil
+}
+
+// moveNotation writes the moves of an analysis in a display notation. Played moves are stored in
+// SAN and only change their piece letters. Engine moves are stored in UCI and are converted to
+// SAN in the position they're played in first, which the board can only do in standard chess.
+type moveNotation struct {
+	notation string // Normalized display notation, empty to leave moves as they're stored
+	engine   bool   // Whether engine moves can be converted
+}
+
+// newMoveNotation prepares the display notation of an analysis' moves. An empty notation leaves
+// them as they're stored: played moves in SAN, engine moves in UCI.
+func newMoveNotation(analysis *models.GameAnalysis, notation string) (moveNotation, error) {
+	if strings.TrimSpace(notation) == "" {
+		return moveNotation{}, nil
+	}
+	notation, err := displayNotation(notation)
+	if err != nil {
+		return moveNotation{}, err
+	}
+	return moveNotation{notation: notation, engine: engine.IsStandardVariant(analysis.EngineSettings.Variant)}, nil
+}
+
+// san writes a move in SAN in the display notation
+func (n moveNotation) san(move string) string {
+	return i18n.FormatSAN(n.notation, move)
+}
+
+// engineMove writes an engine move played in the position fen in the display notation. Moves
+// that can't be played there are left in UCI.
+func (n moveNotation) engineMove(fen, uci string) string {
+	if n.notation == "" || !n.engine || uci == "" {
+		return uci
+	}
+	b, err := board.FromFEN(fen)
+	if err != nil {
+		return uci
+	}
+	move, err := b.ParseUCI(uci)
+	if err != nil {
+		return uci
+	}
+	return n.san(b.SAN(move))
+}
+
+// engineLine writes an engine line starting in the position fen in the display notation. Lines
+// that can't be played out are left in UCI.
+func (n moveNotation) engineLine(fen string, line []string) []string {
+	if n.notation == "" || !n.engine || len(line) == 0 {
+		return line
+	}
+	san := sanLine(fen, line)
+	if len(san) != len(line) {
+		return line
+	}
+	return i18n.FormatLine(n.notation, san)
+}
+
+// NotateAnalysis returns a copy of an analysis with its moves in the given display notation:
+// the moves played, and the engine's best moves, lines, alternatives and human moves. Without a
+// notation the analysis is returned as stored. Analyses are cached and stored in SAN and UCI,
+// which the analyzer parses back, so notation is only applied to the copy served.
+func (s *AnalysisService) NotateAnalysis(analysis *models.GameAnalysis, notation string) (*models.GameAnalysis, error) {
+	n, err := newMoveNotation(analysis, notation)
+	if err != nil {
+		return nil, err
+	}
+	if n.notation == "" {
+		return analysis, nil
+	}
+
+	notated := *analysis
+	notated.Moves = make([]models.MoveAnalysis, len(analysis.Moves))
+	for i, move := range analysis.Moves {
+		// Engine moves answer the position after the move
+		move.Move = n.san(move.Move)
+		move.BestMove = n.engineMove(move.FEN, move.BestMove)
+		move.BestLine = n.engineLine(move.FEN, move.BestLine)
+		move.HumanMove = n.engineMove(move.FEN, move.HumanMove)
+		if move.Alternatives != nil {
+			alternatives := make([]models.MoveAlternative, len(move.Alternatives))
+			for j, alternative := range move.Alternatives {
+				alternative.Move = n.engineMove(move.FEN, alternative.Move)
+				alternatives[j] = alternative
+			}
+			move.Alternatives = alternatives
+		}
+		notated.Moves[i] = move
+	}
+	return &notated, nil
+}
+
+// NotatePositionLines rewrites the SAN of explored lines in the given display notation
+func NotatePositionLines(lines *models.PositionLines, notation string) error {
+	notation, err := displayNotation(notation)
+	if err != nil {
+		return err
+	}
+	for i := range lines.Lines {
+		line := &lines.Lines[i]
+		line.MoveSAN = i18n.FormatSAN(notation, line.MoveSAN)
+		line.ContinuationSAN = i18n.FormatLine(notation, line.ContinuationSAN)
+	}
+	return nil
+}
+
+// NotateMateSearch rewrites the SAN of a mating line in the given display notation
+func NotateMateSearch(search *models.MateSearch, notation string) error {
+	notation, err := displayNotation(notation)
+	if err != nil {
+		return err
+	}
+	search.LineSAN = i18n.FormatLine(notation, search.LineSAN)
+	return nil
+}
+
+// NotatePlyPosition rewrites the move and best line of a ply position in the given display notation
+func NotatePlyPosition(position *models.PlyPosition, notation string) error {
+	notation, err := displayNotation(notation)
+	if err != nil {
+		return err
+	}
+	position.Move = i18n.FormatSAN(notation, position.Move)
+	position.BestLineSAN = i18n.FormatLine(notation, position.BestLineSAN)
+	return nil
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/i18n"
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+// Positions after 1. e4, 1... e5 and 2. Nf3
+const (
+	afterE4  = "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1"
+	afterE5  = "rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq e6 0 2"
+	afterNf3 = "rnbqkbnr/pppp1ppp/8/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R b KQkq - 1 2"
+)
+
+func TestAnalysisService_NotateAnalysis(t *testing.T) {
+	service := newTestAnalysisService()
+	analysis := &models.GameAnalysis{Moves: []models.MoveAnalysis{
+		{Move: "e4", MoveNumber: 1, FEN: afterE4, BestMove: "g8f6", BestLine: []string{"g8f6", "b1c3"},
+			Alternatives: []models.MoveAlternative{{Move: "b8c6", Evaluation: 0.4}}, HumanMove: "e7e5"},
+		{Move: "e5", MoveNumber: 2, FEN: afterE5, BestMove: "g1f3", BestLine: []string{"g1f3", "b8c6", "f1b5"}},
+		// A line that can't be played out is left in UCI
+		{Move: "Nf3", MoveNumber: 3, FEN: afterNf3, BestMove: "b8c6", BestLine: []string{"b8c6", "a1a8"}},
+	}}
+
+	notated, err := service.NotateAnalysis(analysis, "de")
+	if err != nil {
+		t.Fatalf("NotateAnalysis() error = %v", err)
+	}
+	tests := []struct {
+		got, want any
+	}{
+		{notated.Moves[0].Move, "e4"},
+		{notated.Moves[0].BestMove, "Sf6"},
+		{notated.Moves[0].BestLine, []string{"Sf6", "Sc3"}},
+		{notated.Moves[0].Alternatives[0].Move, "Sc6"},
+		{notated.Moves[0].HumanMove, "e5"},
+		{notated.Moves[1].BestLine, []string{"Sf3", "Sc6", "Lb5"}},
+		{notated.Moves[2].Move, "Sf3"},
+		{notated.Moves[2].BestMove, "Sc6"},
+		{notated.Moves[2].BestLine, []string{"b8c6", "a1a8"}},
+	}
+	for i, tt := range tests {
+		if !reflect.DeepEqual(tt.got, tt.want) {
+			t.Errorf("Notated field %d = %v, want %v", i, tt.got, tt.want)
+		}
+	}
+
+	// The stored analysis stays in SAN and UCI
+	if first := analysis.Moves[0]; first.BestMove != "g8f6" || first.BestLine[0] != "g8f6" || first.Alternatives[0].Move != "b8c6" ||
+		analysis.Moves[2].Move != "Nf3" {
+		t.Errorf("Expected the analysis to be left alone, got %+v", analysis.Moves)
+	}
+
+	// An explicit san converts engine moves too; variants' engine moves stay in UCI
+	if san, _ := service.NotateAnalysis(analysis, "san"); san.Moves[1].BestMove != "Nf3" {
+		t.Errorf("Expected the best move in SAN, got %s", san.Moves[1].BestMove)
+	}
+	variant := *analysis
+	variant.EngineSettings.Variant = "crazyhouse"
+	if notated, _ := service.NotateAnalysis(&variant, "de"); notated.Moves[1].BestMove != "g1f3" || notated.Moves[2].Move != "Sf3" {
+		t.Errorf("Expected a variant's engine moves in UCI, got %+v", notated.Moves[1:])
+	}
+
+	if same, err := service.NotateAnalysis(analysis, ""); err != nil || same != analysis {
+		t.Errorf("Expected SAN to return the analysis itself, got %p, %v", same, err)
+	}
+	_, err = service.NotateAnalysis(analysis, "ja")
+	var validation *errors.ValidationError
+	if !errors.As(err, &validation) || validation.Field != "notation" {
+		t.Errorf("Expected a validation error for an unsupported notation, got %v", err)
+	}
+}
+
+func TestSelectKeyMoments_Notation(t *testing.T) {
+	analysis := &models.GameAnalysis{Moves: []models.MoveAnalysis{
+		{Move: "e4", MoveNumber: 1, FEN: afterE4, Evaluation: 3.5, BestMove: "e7e5"},
+		{Move: "e5", MoveNumber: 2, FEN: afterE5, Evaluation: 3.5, BestMove: "g1f3"},
+		{Move: "Nc3", MoveNumber: 3, Evaluation: 0.5, BestMove: "g8f6"},
+	}}
+	n, err := newMoveNotation(analysis, "de")
+	if err != nil {
+		t.Fatalf("newMoveNotation() error = %v", err)
+	}
+
+	moments := selectKeyMoments(analysis, i18n.DefaultLanguage, n)
+	if len(moments) != 1 || moments[0].Type != models.KeyMomentMissedWin {
+		t.Fatalf("Expected Nc3 to be a missed win, got %+v", moments)
+	}
+	if missed := moments[0]; missed.Move != "Sc3" || missed.BestMove != "Sf3" ||
+		!strings.Contains(missed.Description, "2. Sc3") || !strings.Contains(missed.Description, "Sf3") {
+		t.Errorf("Expected the missed win in German notation, got %+v", missed)
+	}
+}
@@ -0,0 +1,345 @@
+package service
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/board"
+	"github.com/pedrampdd/ChessAnalyser/internal/client"
+	"github.com/pedrampdd/ChessAnalyser/internal/engine"
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/internal/parser"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+// Novelty search limits
+const (
+	defaultNoveltyGames   = 50
+	noveltyMaxPlies       = 40    // Games still in theory after that many plies have no novelty
+	maxExplorerPositions  = 20000 // Explorer lookups kept before the cache starts over
+	defaultNoveltyDepth   = 16
+	defaultNoveltyTimeout = 1000
+)
+
+// Opening reference databases
+const (
+	ReferenceEmbedded = "embedded"
+	ReferenceLichess  = "lichess"
+)
+
+// masterGamesPGN is the embedded database of classic master games
+//
+//go:embed reference/masters.pgn
+var masterGamesPGN string
+
+// OpeningReference is a database of reference games novelties are found against
+type OpeningReference interface {
+	// Name identifies the reference in requests
+	Name() string
+	// Continuations returns the moves reference games played from a position, keyed by
+	// openingMoveKey, with the number of games that played each. It is empty for positions no
+	// reference game reached.
+	Continuations(ctx context.Context, fen string) (map[string]int, error)
+}
+
+// embeddedReference is a small reference built into the binary: a collection of classic master
+// games and the main lines of the most played openings, each line counting as one game
+type embeddedReference struct {
+	once      sync.Once
+	positions map[string]map[string]int // Moves played by position key
+}
+
+// NewEmbeddedReference returns the reference built into the binary. It works offline, but only
+// knows the main lines of popular openings.
+func NewEmbeddedReference() OpeningReference {
+	return &embeddedReference{}
+}
+
+// Name identifies the embedded reference
+func (r *embeddedReference) Name() string {
+	return ReferenceEmbedded
+}
+
+// Continuations returns the moves played from a position in the embedded games
+func (r *embeddedReference) Continuations(ctx context.Context, fen string) (map[string]int, error) {
+	r.once.Do(r.build)
+	return r.positions[positionKey(fen)], nil
+}
+
+// build indexes the opening moves of the embedded games and lines by position
+func (r *embeddedReference) build() {
+	r.positions = make(map[string]map[string]int)
+	for _, line := range popularECOLines {
+		r.add(strings.Fields(line.Moves))
+	}
+
+	pgnParser := parser.NewPGNParser()
+	parser.SplitGames(strings.NewReader(masterGamesPGN), func(_ int64, pgn string) error {
+		game, err := pgnParser.ParsePGN(pgn)
+		if err != nil {
+			return nil
+		}
+		moves := make([]string, len(game.Moves))
+		for i, move := range game.Moves {
+			moves[i] = move.Move
+		}
+		r.add(moves)
+		return nil
+	})
+}
+
+// add records the first noveltyMaxPlies moves of a game from the starting position
+func (r *embeddedReference) add(moves []string) {
+	b := board.NewBoard()
+	for i, san := range moves {
+		if i == noveltyMaxPlies {
+			break
+		}
+		move, err := b.ParseSAN(san)
+		if err != nil {
+			return
+		}
+		key := positionKey(b.FEN())
+		if r.positions[key] == nil {
+			r.positions[key] = make(map[string]int)
+		}
+		r.positions[key][openingMoveKey(san)]++
+		b.Apply(move)
+	}
+}
+
+// lichessMastersReference looks positions up in the Lichess opening explorer's master games,
+// caching the answers since a player's games share their openings
+type lichessMastersReference struct {
+	api *client.LichessAPI
+
+	mu    sync.Mutex
+	cache map[string]map[string]int
+}
+
+// NewLichessMastersReference returns a reference of the over-the-board master games in the
+// Lichess opening explorer
+func NewLichessMastersReference(api *client.LichessAPI) OpeningReference {
+	return &lichessMastersReference{api: api, cache: make(map[string]map[string]int)}
+}
+
+// Name identifies the Lichess reference
+func (r *lichessMastersReference) Name() string {
+	return ReferenceLichess
+}
+
+// Continuations returns the moves masters played from a position
+func (r *lichessMastersReference) Continuations(ctx context.Context, fen string) (map[string]int, error) {
+	key := positionKey(fen)
+	r.mu.Lock()
+	cached, ok := r.cache[key]
+	r.mu.Unlock()
+	if ok {
+		return cached, nil
+	}
+
+	position, err := r.api.GetMastersPosition(ctx, fen)
+	if err != nil {
+		return nil, fmt.Errorf("opening explorer lookup failed: %w", err)
+	}
+	moves := make(map[string]int, len(position.Moves))
+	for _, move := range position.Moves {
+		if games := move.Games(); games > 0 {
+			moves[openingMoveKey(move.SAN)] += games
+		}
+	}
+
+	r.mu.Lock()
+	if len(r.cache) >= maxExplorerPositions {
+		r.cache = make(map[string]map[string]int)
+	}
+	r.cache[key] = moves
+	r.mu.Unlock()
+	return moves, nil
+}
+
+// AddOpeningReference makes a reference database available to novelty searches under its name
+func (s *AnalyticsService) AddOpeningReference(reference OpeningReference) {
+	s.referencesMu.Lock()
+	defer s.referencesMu.Unlock()
+	s.references[reference.Name()] = reference
+}
+
+// openingReference returns the reference database with the given name
+func (s *AnalyticsService) openingReference(name string) (OpeningReference, bool) {
+	s.referencesMu.RLock()
+	defer s.referencesMu.RUnlock()
+	reference, ok := s.references[name]
+	return reference, ok
+}
+
+// FindNovelties follows a player's recent games through a reference database and reports the
+// first move of each that no reference game played, with the engine's evaluation after it
+func (s *AnalyticsService) FindNovelties(ctx context.Context, request *models.NoveltyRequest) (*models.NoveltyReport, error) {
+	if request.Username == "" {
+		return nil, errors.NewValidationError("username", "username is required")
+	}
+	if request.Source == "" {
+		request.Source = ReferenceEmbedded
+	}
+	reference, ok := s.openingReference(request.Source)
+	if !ok {
+		return nil, errors.NewValidationError("source", fmt.Sprintf("unknown reference database %q", request.Source))
+	}
+	if request.Games <= 0 {
+		request.Games = defaultNoveltyGames
+	}
+	if request.Games > maxReportGames {
+		request.Games = maxReportGames
+	}
+	settings := request.Settings
+	if settings.Depth == 0 {
+		settings.Depth = defaultNoveltyDepth
+	}
+	if settings.TimeLimit == 0 {
+		settings.TimeLimit = defaultNoveltyTimeout
+	}
+
+	games, archives, err := s.gameService.GetRecentGames(ctx, request.Username, request.Games)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.NoveltyReport{
+		Username:       request.Username,
+		Source:         reference.Name(),
+		GeneratedAt:    time.Now(),
+		Games:          []models.GameNovelty{},
+		FailedArchives: failedArchives(archives),
+		Archives:       archives,
+	}
+	var plySum, evalSum float64
+	var evaluated int
+
+	for _, game := range games {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		color, ok := playerColor(game, request.Username)
+		if !ok {
+			continue
+		}
+		parsed, err := s.pgnParser.ParsePGN(game.PGN)
+		if err != nil || parsed.Headers["fen"] != "" || !engine.IsStandardVariant(game.Rules) {
+			continue
+		}
+		moves := make([]string, len(parsed.Moves))
+		for i, move := range parsed.Moves {
+			moves[i] = move.Move
+		}
+
+		novelty, err := findNovelty(ctx, reference, moves)
+		if err != nil {
+			return nil, err
+		}
+		entry := models.GameNovelty{
+			URL:         game.URL,
+			White:       game.WhitePlayer.Username,
+			Black:       game.BlackPlayer.Username,
+			PlayerColor: color.String(),
+			Opening:     openingFromHeaders(parsed.Headers),
+			Novelty:     novelty,
+		}
+		report.Games = append(report.Games, entry)
+		if novelty == nil {
+			continue
+		}
+
+		// Evaluations are best effort; where the game left theory is useful without them
+		if s.analysisService != nil {
+			if result, err := s.analysisService.AnalyzePosition(ctx, novelty.FEN, settings); err == nil {
+				evaluation := result.Evaluation
+				novelty.Evaluation = &evaluation
+			}
+		}
+
+		novelty.ByPlayer = novelty.Color == color.String()
+		if !novelty.ByPlayer {
+			report.OpponentNovelties++
+			continue
+		}
+		report.PlayerNovelties++
+		plySum += float64(novelty.Ply)
+		if novelty.Evaluation != nil {
+			evaluation := *novelty.Evaluation
+			if color == board.Black {
+				evaluation = -evaluation
+			}
+			evalSum += evaluation
+			evaluated++
+		}
+	}
+
+	if report.PlayerNovelties > 0 {
+		report.AveragePly = plySum / float64(report.PlayerNovelties)
+	}
+	if evaluated > 0 {
+		report.AverageEvaluation = evalSum / float64(evaluated)
+	}
+	return report, nil
+}
+
+// findNovelty follows a game's moves through the reference and returns the first one no reference
+// game played, or nil when the game stays in theory for noveltyMaxPlies or ends there. A game
+// whose starting position the reference doesn't know has no novelty either.
+func findNovelty(ctx context.Context, reference OpeningReference, moves []string) (*models.Novelty, error) {
+	b := board.NewBoard()
+	for i, san := range moves {
+		if i == noveltyMaxPlies {
+			break
+		}
+		fen := b.FEN()
+		continuations, err := reference.Continuations(ctx, fen)
+		if err != nil {
+			return nil, err
+		}
+		if len(continuations) == 0 {
+			return nil, nil
+		}
+		move, err := b.ParseSAN(san)
+		if err != nil {
+			return nil, nil
+		}
+		mover := b.Turn()
+		fullMoves := b.FullMoves()
+		b.Apply(move)
+
+		if _, known := continuations[openingMoveKey(san)]; known {
+			continue
+		}
+		novelty := &models.Novelty{
+			Ply:        i + 1,
+			MoveNumber: fullMoves,
+			Color:      mover.String(),
+			Move:       san,
+			FENBefore:  fen,
+			FEN:        b.FEN(),
+		}
+		novelty.ReferenceMove, novelty.ReferenceGames = mostPlayedContinuation(continuations)
+		return novelty, nil
+	}
+	return nil, nil
+}
+
+// mostPlayedContinuation returns the move played in the most reference games, breaking ties by
+// the move, and the number of games that reached the position
+func mostPlayedContinuation(continuations map[string]int) (string, int) {
+	var best string
+	total := 0
+	for move, games := range continuations {
+		total += games
+		if best == "" || games > continuations[best] || (games == continuations[best] && move < best) {
+			best = move
+		}
+	}
+	return best, total
+}
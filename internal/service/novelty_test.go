@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	stderrors "errors"
+	"strings"
+	"testing"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+// failingReference is a reference database that can't be reached
+type failingReference struct{}
+
+func (failingReference) Name() string { return "failing" }
+
+func (failingReference) Continuations(ctx context.Context, fen string) (map[string]int, error) {
+	return nil, stderrors.New("unreachable")
+}
+
+func TestFindNovelty(t *testing.T) {
+	reference := NewEmbeddedReference()
+
+	tests := []struct {
+		name  string
+		moves string
+		want  *models.Novelty // Only the move fields are compared
+	}{
+		{
+			name:  "leaves the Giuoco Pianissimo",
+			moves: "e4 e5 Nf3 Nc6 Bc4 Bc5 c3 Nf6 d3 h6 O-O",
+			want:  &models.Novelty{Ply: 10, MoveNumber: 5, Color: "black", Move: "h6", ReferenceMove: "d6", ReferenceGames: 1},
+		},
+		{
+			name:  "first move",
+			moves: "a3 e5",
+			want:  &models.Novelty{Ply: 1, MoveNumber: 1, Color: "white", Move: "a3", ReferenceMove: "e4"},
+		},
+		{
+			name:  "follows a master game",
+			moves: "e4 e5 Nf3 d6 d4 Bg4 dxe5 Bxf3 Qxf3 dxe5 Bc4 Nf6 Qb3 Qe7 Nc3 c6 Bg5 b5 Nxb5 cxb5 Bxb5+ Nbd7 O-O-O Rd8 Rxd7 Rxd7 Rd1 Qe6 Bxd7+ Nxd7 Qb8+ Nxb8 Rd8#",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			novelty, err := findNovelty(context.Background(), reference, strings.Fields(tt.moves))
+			if err != nil {
+				t.Fatalf("findNovelty() error = %v", err)
+			}
+			if tt.want == nil {
+				if novelty != nil {
+					t.Errorf("Expected the game to stay in theory, got %+v", novelty)
+				}
+				return
+			}
+			if novelty == nil {
+				t.Fatalf("Expected a novelty, got none")
+			}
+			if novelty.Ply != tt.want.Ply || novelty.MoveNumber != tt.want.MoveNumber || novelty.Color != tt.want.Color ||
+				novelty.Move != tt.want.Move || novelty.ReferenceMove != tt.want.ReferenceMove {
+				t.Errorf("Novelty = %+v, want %+v", novelty, tt.want)
+			}
+			if tt.want.ReferenceGames != 0 && novelty.ReferenceGames != tt.want.ReferenceGames {
+				t.Errorf("Reference games = %d, want %d", novelty.ReferenceGames, tt.want.ReferenceGames)
+			}
+		})
+	}
+
+	if _, err := findNovelty(context.Background(), failingReference{}, []string{"e4"}); err == nil {
+		t.Error("Expected the reference's error to be returned")
+	}
+}
+
+func TestAnalyticsService_FindNoveltiesValidation(t *testing.T) {
+	analytics := NewAnalyticsService(NewGameAnalyzerService(), nil)
+
+	requests := []models.NoveltyRequest{
+		{Source: ReferenceEmbedded},
+		{Username: "alice", Source: "chessbase"},
+	}
+	for _, request := range requests {
+		var validation *errors.ValidationError
+		if _, err := analytics.FindNovelties(context.Background(), &request); !errors.As(err, &validation) {
+			t.Errorf("Expected a validation error for %+v, got %v", request, err)
+		}
+	}
+}
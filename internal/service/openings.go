@@ -0,0 +1,401 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/engine"
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/internal/parser"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+	"github.com/pedrampdd/ChessAnalyser/pkg/position"
+)
+
+// Opening cache defaults, until SetOpeningCacheOptions applies the configured ones
+const (
+	defaultOpeningCachePlies     = 14
+	defaultOpeningCachePositions = 100000
+	defaultWarmupLines           = 20
+)
+
+// openingNode is a position reached by a sequence of moves from the starting position
+type openingNode struct {
+	children map[string]*openingNode
+	results  map[string]*models.AnalysisResult // Engine results by settings key
+}
+
+// openingCache is a trie of the move sequences that open analyzed games. Games from the
+// starting position share their first plies, so the engine results of those positions are kept
+// by move sequence and served to every later game that opens the same way.
+type openingCache struct {
+	mu           sync.Mutex
+	root         *openingNode
+	maxPlies     int
+	maxPositions int
+	file         string // Persisted to this file when set
+	positions    int
+	hits, misses int64
+	dirty        bool // Results added since the cache was last saved
+	warmup       *models.OpeningWarmup
+	stopWarmup   context.CancelFunc
+}
+
+// persistedOpening is one cached result in the opening cache file
+type persistedOpening struct {
+	Moves        []string               `json:"moves"`
+	Settings     string                 `json:"settings"`
+	Result       *models.AnalysisResult `json:"result"`
+	ScoreVersion int                    `json:"score_version"` // engine.ScoreVersion the result was scored with
+}
+
+// newOpeningCache creates an empty opening cache keeping the first maxPlies of every game
+func newOpeningCache(maxPlies, maxPositions int) *openingCache {
+	return &openingCache{root: &openingNode{}, maxPlies: maxPlies, maxPositions: maxPositions}
+}
+
+// openingMoveKey normalizes a SAN move so annotations and castling notation don't split the trie
+func openingMoveKey(san string) string {
+	san = strings.TrimRight(san, "+#!?")
+	return strings.ReplaceAll(san, "0", "O")
+}
+
+// openingSettingsKey identifies the engine settings that change an analysis result. Threads and
+// hash size only change how fast the same search finishes, so they are left out.
+func openingSettingsKey(settings models.EngineSettings, scan bool) string {
+	return fmt.Sprintf("%t_%d_%d_%d_%d_%s_%t_%d_%s", scan, settings.Depth, settings.TimeLimit,
+		settings.MultiPV, settings.SkillLevel, contemptKey(settings.Contempt), settings.LimitStrength, settings.Elo,
+		settings.EvalFile)
+}
+
+// get returns the cached result of the position reached by moves. Sequences longer than the
+// cached plies aren't counted as misses.
+func (c *openingCache) get(moves []string, settings string) (*models.AnalysisResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(moves) == 0 || len(moves) > c.maxPlies {
+		return nil, false
+	}
+
+	if node := c.find(moves); node != nil {
+		if result, ok := node.results[settings]; ok {
+			c.hits++
+			copied := *result
+			return &copied, true
+		}
+	}
+	c.misses++
+	return nil, false
+}
+
+// find returns the node reached by moves, or nil when no cached sequence starts with them.
+// The caller holds the lock.
+func (c *openingCache) find(moves []string) *openingNode {
+	node := c.root
+	for _, move := range moves {
+		if node = node.children[openingMoveKey(move)]; node == nil {
+			return nil
+		}
+	}
+	return node
+}
+
+// put caches the result of the position reached by moves
+func (c *openingCache) put(moves []string, settings string, result *models.AnalysisResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(moves) == 0 || len(moves) > c.maxPlies {
+		return
+	}
+
+	node := c.root
+	for _, move := range moves {
+		key := openingMoveKey(move)
+		child := node.children[key]
+		if child == nil {
+			if node.children == nil {
+				node.children = make(map[string]*openingNode)
+			}
+			child = &openingNode{}
+			node.children[key] = child
+		}
+		node = child
+	}
+
+	if _, ok := node.results[settings]; !ok {
+		if c.positions >= c.maxPositions {
+			return // Full; the cached openings keep being served
+		}
+		c.positions++
+	}
+	if node.results == nil {
+		node.results = make(map[string]*models.AnalysisResult)
+	}
+	copied := *result
+	node.results[settings] = &copied
+	c.dirty = true
+}
+
+// stats reports the cache's size, hit rate and warmup progress
+func (c *openingCache) stats() models.OpeningCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := models.OpeningCacheStats{
+		MaxPlies:     c.maxPlies,
+		Positions:    c.positions,
+		MaxPositions: c.maxPositions,
+		Hits:         c.hits,
+		Misses:       c.misses,
+		File:         c.file,
+	}
+	if lookups := c.hits + c.misses; lookups > 0 {
+		stats.HitRate = float64(c.hits) / float64(lookups)
+	}
+	if c.warmup != nil {
+		warmup := *c.warmup
+		stats.Warmup = &warmup
+	}
+	return stats
+}
+
+// load reads the cache persisted in the cache file, if there is one
+func (c *openingCache) load() error {
+	data, err := os.ReadFile(c.file)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var entries []persistedOpening
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("invalid opening cache file %s: %w", c.file, err)
+	}
+	// Results scored another way, e.g. from the side to move by older versions, are searched again
+	for _, entry := range entries {
+		if entry.Result != nil && entry.ScoreVersion == engine.ScoreVersion {
+			c.put(entry.Moves, entry.Settings, entry.Result)
+		}
+	}
+
+	c.mu.Lock()
+	c.dirty = false
+	c.mu.Unlock()
+	return nil
+}
+
+// save writes the cache to the cache file when results were added since it was last saved
+func (c *openingCache) save() error {
+	c.mu.Lock()
+	if c.file == "" || !c.dirty {
+		c.mu.Unlock()
+		return nil
+	}
+	var entries []persistedOpening
+	var walk func(node *openingNode, moves []string)
+	walk = func(node *openingNode, moves []string) {
+		for settings, result := range node.results {
+			entries = append(entries, persistedOpening{Moves: moves, Settings: settings, Result: result, ScoreVersion: engine.ScoreVersion})
+		}
+		for move, child := range node.children {
+			walk(child, append(moves[:len(moves):len(moves)], move))
+		}
+	}
+	walk(c.root, nil)
+	c.dirty = false
+	c.mu.Unlock()
+
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+
+	// Write a temporary file first so a crash can't leave a truncated cache behind
+	if err := os.MkdirAll(filepath.Dir(c.file), 0o755); err != nil {
+		return err
+	}
+	tmp := c.file + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.file)
+}
+
+// SetOpeningCacheOptions caches the engine results of the first maxPlies of every game from the
+// starting position, keeping at most maxPositions of them. When file is set the cache is loaded
+// from it and saved back after warmups and on Close. A maxPlies of 0 disables the cache.
+func (s *AnalysisService) SetOpeningCacheOptions(maxPlies, maxPositions int, file string) error {
+	s.openings.stop()
+
+	openings := newOpeningCache(maxPlies, maxPositions)
+	openings.file = file
+	if file != "" {
+		if err := openings.load(); err != nil {
+			return err
+		}
+	}
+	s.openings = openings
+	return nil
+}
+
+// GetOpeningCacheStats returns the opening cache's size, hit rate and warmup progress
+func (s *AnalysisService) GetOpeningCacheStats() models.OpeningCacheStats {
+	return s.openings.stats()
+}
+
+// WarmOpeningCache starts pre-analyzing the most popular ECO lines in the background, one
+// position at a time so regular analyses keep getting engines. A warmup that is already
+// running is reported instead of starting another.
+func (s *AnalysisService) WarmOpeningCache(request *models.OpeningWarmupRequest) (models.OpeningCacheStats, error) {
+	if s.openings.maxPlies == 0 {
+		return models.OpeningCacheStats{}, errors.NewValidationError("lines", "the opening cache is disabled")
+	}
+	if request.Lines < 0 || request.Lines > len(popularECOLines) {
+		return models.OpeningCacheStats{}, errors.NewValidationError("lines",
+			fmt.Sprintf("lines must be between 1 and %d", len(popularECOLines)))
+	}
+	lines := request.Lines
+	if lines == 0 {
+		lines = defaultWarmupLines
+	}
+
+	pool, err := s.poolFor("", "")
+	if err != nil {
+		return models.OpeningCacheStats{}, err
+	}
+
+	// Collect every distinct prefix of the lines, shortest first
+	var prefixes [][]string
+	seen := make(map[string]bool)
+	for _, line := range popularECOLines[:lines] {
+		moves := strings.Fields(line.Moves)
+		for ply := 1; ply <= len(moves) && ply <= s.openings.maxPlies; ply++ {
+			key := strings.Join(moves[:ply], " ")
+			if !seen[key] {
+				seen[key] = true
+				prefixes = append(prefixes, moves[:ply])
+			}
+		}
+	}
+
+	openings := s.openings
+	openings.mu.Lock()
+	if openings.warmup != nil && openings.warmup.Running {
+		openings.mu.Unlock()
+		return openings.stats(), nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	openings.warmup = &models.OpeningWarmup{
+		Lines:     lines,
+		Positions: len(prefixes),
+		Running:   true,
+		StartedAt: time.Now(),
+	}
+	openings.stopWarmup = cancel
+	openings.mu.Unlock()
+
+	go func() {
+		defer cancel()
+		err := openings.warm(ctx, pool, prefixes, request.Settings)
+
+		openings.mu.Lock()
+		finished := time.Now()
+		openings.warmup.Running = false
+		openings.warmup.FinishedAt = &finished
+		if err != nil {
+			openings.warmup.Error = err.Error()
+		}
+		openings.mu.Unlock()
+
+		if err := openings.save(); err != nil {
+			openings.mu.Lock()
+			openings.warmup.Error = fmt.Sprintf("failed to save opening cache: %v", err)
+			openings.mu.Unlock()
+		}
+	}()
+
+	return openings.stats(), nil
+}
+
+// warm analyzes the positions reached by the prefixes that aren't cached yet
+func (c *openingCache) warm(ctx context.Context, pool *engine.EnginePool, prefixes [][]string, settings models.EngineSettings) error {
+	key := openingSettingsKey(settings, false)
+
+	for _, moves := range prefixes {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if !c.cached(moves, key) {
+			p := position.New()
+			for _, move := range moves {
+				if _, err := p.MakeMove(move); err != nil {
+					return fmt.Errorf("invalid opening line %s: %w", strings.Join(moves, " "), err)
+				}
+			}
+
+			stockfishEngine, err := pool.Acquire(ctx)
+			if err != nil {
+				return err
+			}
+			result, err := stockfishEngine.AnalyzePosition(ctx, p.FEN(), settings)
+			pool.ReturnEngine(stockfishEngine)
+			if err != nil {
+				return err
+			}
+			c.put(moves, key, result)
+		}
+
+		c.mu.Lock()
+		c.warmup.Analyzed++
+		c.mu.Unlock()
+	}
+	return nil
+}
+
+// cached reports whether the position reached by moves is cached, without counting a hit or miss
+func (c *openingCache) cached(moves []string, settings string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	node := c.find(moves)
+	if node == nil {
+		return false
+	}
+	_, ok := node.results[settings]
+	return ok
+}
+
+// stop cancels a running warmup
+func (c *openingCache) stop() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stopWarmup != nil {
+		c.stopWarmup()
+	}
+}
+
+// openingLine returns the moves of a game, or nil when its positions can't be shared with other
+// games: it starts from a set-up position or is a variant
+func openingLine(game *parser.ParsedGame, variant string) []string {
+	if game.Headers["fen"] != "" || !engine.IsStandardVariant(variant) {
+		return nil
+	}
+	moves := make([]string, len(game.Moves))
+	for i, move := range game.Moves {
+		moves[i] = move.Move
+	}
+	return moves
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/pkg/position"
+)
+
+func TestPopularECOLines(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, line := range popularECOLines {
+		if seen[line.Moves] {
+			t.Errorf("%s %s is listed twice", line.ECO, line.Name)
+		}
+		seen[line.Moves] = true
+
+		p := position.New()
+		for _, move := range strings.Fields(line.Moves) {
+			if _, err := p.MakeMove(move); err != nil {
+				t.Errorf("%s %s: %s is illegal: %v", line.ECO, line.Name, move, err)
+				break
+			}
+		}
+	}
+}
+
+func TestOpeningCache(t *testing.T) {
+	c := newOpeningCache(4, 3)
+	key := openingSettingsKey(models.EngineSettings{Depth: 15}, false)
+	result := &models.AnalysisResult{Evaluation: 0.3, BestMove: "e7e5"}
+
+	if _, ok := c.get([]string{"e4"}, key); ok {
+		t.Fatal("Expected an empty cache to miss")
+	}
+
+	c.put([]string{"e4"}, key, result)
+	c.put([]string{"e4", "e5", "Nf3", "Nc6", "Bb5"}, key, result) // Beyond the cached plies
+
+	got, ok := c.get([]string{"e4!"}, key)
+	if !ok || got.BestMove != "e7e5" {
+		t.Fatalf("get(e4!) = %+v, %v, want the cached result", got, ok)
+	}
+	if _, ok := c.get([]string{"e4"}, openingSettingsKey(models.EngineSettings{Depth: 20}, false)); ok {
+		t.Error("Expected other settings to miss")
+	}
+	if _, ok := c.get([]string{"e4", "e5", "Nf3", "Nc6", "Bb5"}, key); ok {
+		t.Error("Expected a ply beyond the cached plies to miss")
+	}
+
+	// Castling with zeros and checks land on the same positions
+	c.put([]string{"e4", "e5", "Qh5", "Ke7"}, key, result)
+	c.put([]string{"d4", "d5", "Bf4", "Bf5"}, key, result)
+	c.put([]string{"c4"}, key, result) // Full
+	if _, ok := c.get([]string{"e4", "e5", "Qh5", "Ke7+"}, key); !ok {
+		t.Error("Expected a move with a check sign to hit")
+	}
+
+	stats := c.stats()
+	if stats.Positions != 3 || stats.Hits != 2 || stats.Misses != 2 || stats.HitRate != 0.5 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+	if openingMoveKey("0-0+") != "O-O" {
+		t.Errorf("openingMoveKey(0-0+) = %q, want O-O", openingMoveKey("0-0+"))
+	}
+}
+
+func TestOpeningCache_Persistence(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "cache", "openings.json")
+	key := openingSettingsKey(models.EngineSettings{Depth: 15}, false)
+
+	s := &AnalysisService{}
+	if err := s.SetOpeningCacheOptions(10, 100, file); err != nil {
+		t.Fatalf("SetOpeningCacheOptions() error = %v", err)
+	}
+	s.openings.put([]string{"e4", "c5"}, key, &models.AnalysisResult{Evaluation: 0.4, BestMove: "g1f3"})
+	if err := s.openings.save(); err != nil {
+		t.Fatalf("save() error = %v", err)
+	}
+
+	reloaded := &AnalysisService{}
+	if err := reloaded.SetOpeningCacheOptions(10, 100, file); err != nil {
+		t.Fatalf("SetOpeningCacheOptions() error = %v", err)
+	}
+	got, ok := reloaded.openings.get([]string{"e4", "c5"}, key)
+	if !ok || got.BestMove != "g1f3" || got.Evaluation != 0.4 {
+		t.Errorf("Reloaded result = %+v, %v", got, ok)
+	}
+	if stats := reloaded.GetOpeningCacheStats(); stats.Positions != 1 || stats.File != file {
+		t.Errorf("Unexpected stats after reload: %+v", stats)
+	}
+
+	// Results scored from the side to move by older versions are dropped
+	stale := `[{"moves":["e4","c5"],"settings":"` + key + `","result":{"evaluation":-0.4}}]`
+	if err := os.WriteFile(file, []byte(stale), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	reloaded = &AnalysisService{}
+	if err := reloaded.SetOpeningCacheOptions(10, 100, file); err != nil {
+		t.Fatalf("SetOpeningCacheOptions() error = %v", err)
+	}
+	if got, ok := reloaded.openings.get([]string{"e4", "c5"}, key); ok {
+		t.Errorf("Expected a result without a score version to be dropped, got %+v", got)
+	}
+}
@@ -0,0 +1,367 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/board"
+	"github.com/pedrampdd/ChessAnalyser/internal/engine"
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/internal/parser"
+	"github.com/pedrampdd/ChessAnalyser/internal/storage"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+// Play session settings
+const (
+	maxPlaySessions       = 200
+	playSessionIdle       = 24 * time.Hour // Sessions without activity for this long are dropped
+	defaultPlaySkillLevel = 10
+	defaultPlayDepth      = 12
+	defaultPlayTime       = 1000 // Milliseconds per engine move
+	maxPlayTime           = 5000
+)
+
+// PlayService runs games between users and the engine
+type PlayService struct {
+	analyze   func(ctx context.Context, fen string, settings models.EngineSettings) (*models.AnalysisResult, error)
+	pgnParser *parser.PGNParser
+	mu        sync.Mutex
+	games     map[string]*playGame
+}
+
+// playGame is a game against the engine. Its mutex serializes moves, and is held while the engine searches its reply.
+type playGame struct {
+	mu         sync.Mutex
+	session    models.PlaySession
+	board      board.Board
+	settings   models.EngineSettings
+	showEval   bool
+	seen       map[string]int // Positions reached, for threefold repetition
+	moves      []parser.ParsedMove
+	lastActive time.Time // Guarded by the service's mutex
+}
+
+// NewPlayService creates a new play service
+func NewPlayService(analysisService *AnalysisService) *PlayService {
+	return &PlayService{
+		analyze:   analysisService.AnalyzePosition,
+		pgnParser: parser.NewPGNParser(),
+		games:     make(map[string]*playGame),
+	}
+}
+
+// CreateGame starts a game against the engine. When the engine has the first move, it's played before returning.
+func (s *PlayService) CreateGame(ctx context.Context, request *models.PlayRequest) (*models.PlaySession, error) {
+	request.Color = strings.ToLower(request.Color)
+	if request.Color == "" {
+		request.Color = "white"
+	}
+	if request.Color != "white" && request.Color != "black" {
+		return nil, errors.NewValidationError("color", "color must be white or black")
+	}
+	if request.SkillLevel == 0 {
+		request.SkillLevel = defaultPlaySkillLevel
+	}
+	if request.SkillLevel < 1 || request.SkillLevel > 20 {
+		return nil, errors.NewValidationError("skill_level", "must be between 1 and 20")
+	}
+	if !engine.IsStandardVariant(request.Settings.Variant) {
+		return nil, errors.NewValidationError("variant", "games against the engine support standard chess only")
+	}
+	if request.FEN == "" {
+		request.FEN = board.StartFEN
+	}
+	b, err := board.FromFEN(request.FEN)
+	if err != nil {
+		return nil, errors.NewValidationError("fen", err.Error())
+	}
+	if err := b.Validate(); err != nil {
+		return nil, errors.NewValidationError("fen", err.Error())
+	}
+
+	settings := request.Settings
+	settings.MultiPV = 1
+	if settings.Depth <= 0 {
+		settings.Depth = defaultPlayDepth
+	}
+	if settings.TimeLimit <= 0 {
+		settings.TimeLimit = defaultPlayTime
+	}
+	if settings.TimeLimit > maxPlayTime {
+		settings.TimeLimit = maxPlayTime
+	}
+	if request.Elo > 0 {
+		settings.LimitStrength = true
+		settings.Elo = engine.ClampElo(request.Elo)
+		request.SkillLevel = 0
+	} else {
+		settings.SkillLevel = request.SkillLevel
+	}
+
+	id, err := storage.NewID()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	g := &playGame{
+		session: models.PlaySession{
+			ID:         id,
+			Color:      request.Color,
+			SkillLevel: request.SkillLevel,
+			Elo:        settings.Elo,
+			StartFEN:   b.FEN(),
+			FEN:        b.FEN(),
+			Moves:      []string{},
+			Status:     models.PlayStatusActive,
+			Result:     "*",
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		},
+		board:      *b,
+		settings:   settings,
+		showEval:   request.ShowEval,
+		seen:       map[string]int{positionKey(b.FEN()): 1},
+		lastActive: now,
+	}
+	g.finishIfOver()
+
+	if g.session.Status == models.PlayStatusActive && g.board.Turn().String() != g.session.Color {
+		m, result, err := s.engineMove(ctx, g, &g.board)
+		if err != nil {
+			return nil, err
+		}
+		g.play(m)
+		g.recordReply(result)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pruneIdle(now)
+	if len(s.games) >= maxPlaySessions {
+		return nil, errors.NewCapacityError("games played at once", maxPlaySessions)
+	}
+	s.games[id] = g
+
+	session := g.snapshot()
+	return &session, nil
+}
+
+// GetGame returns the current state of a game
+func (s *PlayService) GetGame(id string) (*models.PlaySession, error) {
+	g, err := s.game(id)
+	if err != nil {
+		return nil, err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	session := g.snapshot()
+	return &session, nil
+}
+
+// MakeMove plays the user's move and, unless it ends the game, the engine's reply.
+// When the engine fails to reply, the user's move is taken back so it can be sent again.
+func (s *PlayService) MakeMove(ctx context.Context, id string, request *models.PlayMoveRequest) (*models.PlaySession, error) {
+	g, err := s.game(id)
+	if err != nil {
+		return nil, err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.session.Status != models.PlayStatusActive {
+		return nil, errors.NewValidationError("move", "the game is over")
+	}
+	m, err := parseUserMove(&g.board, strings.TrimSpace(request.Move))
+	if err != nil {
+		return nil, errors.NewValidationError("move", err.Error())
+	}
+
+	// Search the reply before changing the game, so a failed search leaves it as it was
+	after := g.board
+	after.Apply(m)
+	var reply board.Move
+	var result *models.AnalysisResult
+	if !g.wouldEnd(&after) {
+		if reply, result, err = s.engineMove(ctx, g, &after); err != nil {
+			return nil, err
+		}
+	}
+
+	g.session.EngineMove = ""
+	g.session.Evaluation = nil
+	g.play(m)
+	if result != nil {
+		g.play(reply)
+		g.recordReply(result)
+	}
+
+	session := g.snapshot()
+	return &session, nil
+}
+
+// Resign ends a game as a loss for the user
+func (s *PlayService) Resign(id string) (*models.PlaySession, error) {
+	g, err := s.game(id)
+	if err != nil {
+		return nil, err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.session.Status != models.PlayStatusActive {
+		return nil, errors.NewValidationError("move", "the game is over")
+	}
+	result := "0-1"
+	if g.session.Color == "black" {
+		result = "1-0"
+	}
+	g.finish(models.TerminationResignation, result)
+
+	session := g.snapshot()
+	return &session, nil
+}
+
+// GamePGN returns a game as PGN, finished or not
+func (s *PlayService) GamePGN(id string) (string, error) {
+	g, err := s.game(id)
+	if err != nil {
+		return "", err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	white, black := "Player", "Stockfish"
+	if g.session.Color == "black" {
+		white, black = black, white
+	}
+	termination := g.session.Termination
+	if termination == "" {
+		termination = "unterminated"
+	}
+	return playedGamePGN(s.pgnParser, "Game against the engine", white, black, g.session.StartFEN, g.session.Result, termination, g.moves), nil
+}
+
+// DeleteGame removes a game
+func (s *PlayService) DeleteGame(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.games[id]; !ok {
+		return errors.NewPlaySessionNotFoundError(id)
+	}
+	delete(s.games, id)
+	return nil
+}
+
+// game looks up a game and marks it as active
+func (s *PlayService) game(id string) (*playGame, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	g, ok := s.games[id]
+	if !ok {
+		return nil, errors.NewPlaySessionNotFoundError(id)
+	}
+	g.lastActive = time.Now()
+	return g, nil
+}
+
+// pruneIdle drops games nobody has touched for a day. Callers hold the service's mutex.
+func (s *PlayService) pruneIdle(now time.Time) {
+	for id, g := range s.games {
+		if now.Sub(g.lastActive) > playSessionIdle {
+			delete(s.games, id)
+		}
+	}
+}
+
+// engineMove searches the engine's move in a position
+func (s *PlayService) engineMove(ctx context.Context, g *playGame, b *board.Board) (board.Move, *models.AnalysisResult, error) {
+	result, err := s.analyze(ctx, b.FEN(), g.settings)
+	if err != nil {
+		return board.Move{}, nil, err
+	}
+	m, err := b.ParseUCI(result.BestMove)
+	if err != nil {
+		return board.Move{}, nil, errors.NewAPIError(fmt.Sprintf("engine played an illegal move %q", result.BestMove), err)
+	}
+	return m, result, nil
+}
+
+// parseUserMove accepts a move in SAN or UCI notation
+func parseUserMove(b *board.Board, move string) (board.Move, error) {
+	if move == "" {
+		return board.Move{}, fmt.Errorf("move is required")
+	}
+	if m, err := b.ParseSAN(move); err == nil {
+		return m, nil
+	}
+	if m, err := b.ParseUCI(move); err == nil {
+		return m, nil
+	}
+	return board.Move{}, fmt.Errorf("illegal move %q", move)
+}
+
+// play applies a move to the game and ends it if the rules say so
+func (g *playGame) play(m board.Move) {
+	san := g.board.SAN(m)
+	g.moves = append(g.moves, parser.ParsedMove{MoveNumber: g.board.FullMoves(), Move: san, Color: g.board.Turn().String()})
+	g.session.Moves = append(g.session.Moves, san)
+
+	g.board.Apply(m)
+	g.seen[positionKey(g.board.FEN())]++
+	g.session.FEN = g.board.FEN()
+	g.session.UpdatedAt = time.Now()
+	g.finishIfOver()
+}
+
+// recordReply reports the engine's last move, and its evaluation when the user asked for it
+func (g *playGame) recordReply(result *models.AnalysisResult) {
+	g.session.EngineMove = g.session.Moves[len(g.session.Moves)-1]
+	if g.showEval {
+		evaluation := result.Evaluation
+		g.session.Evaluation = &evaluation
+	}
+}
+
+// wouldEnd reports whether reaching a position would end the game
+func (g *playGame) wouldEnd(b *board.Board) bool {
+	key := positionKey(b.FEN())
+	g.seen[key]++
+	defer func() { g.seen[key]-- }()
+
+	_, _, over := gameOver(b, g.seen)
+	return over
+}
+
+// finishIfOver ends the game when the current position is final
+func (g *playGame) finishIfOver() {
+	if termination, result, over := gameOver(&g.board, g.seen); over {
+		g.finish(termination, result)
+	}
+}
+
+// finish ends the game
+func (g *playGame) finish(termination, result string) {
+	g.session.Status = models.PlayStatusFinished
+	g.session.Termination = termination
+	g.session.Result = result
+	g.session.UpdatedAt = time.Now()
+}
+
+// snapshot copies the session so it can be returned without holding the game's mutex
+func (g *playGame) snapshot() models.PlaySession {
+	session := g.session
+	session.Moves = append([]string{}, g.session.Moves...)
+	return session
+}
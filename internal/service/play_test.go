@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+// newTestPlayService creates a play service whose engine plays the given UCI moves in order
+func newTestPlayService(moves ...string) (*PlayService, *int) {
+	service := NewPlayService(newTestAnalysisService())
+	searches := 0
+	service.analyze = func(ctx context.Context, fen string, settings models.EngineSettings) (*models.AnalysisResult, error) {
+		if searches >= len(moves) {
+			return nil, fmt.Errorf("engine unavailable")
+		}
+		move := moves[searches]
+		searches++
+		return &models.AnalysisResult{BestMove: move, Evaluation: 0.4}, nil
+	}
+	return service, &searches
+}
+
+func TestPlayService_Game(t *testing.T) {
+	service, _ := newTestPlayService("e2e4", "g1f3")
+
+	// The engine has White, so it moves first
+	session, err := service.CreateGame(context.Background(), &models.PlayRequest{Color: "black", ShowEval: true})
+	if err != nil {
+		t.Fatalf("CreateGame() error = %v", err)
+	}
+	if session.EngineMove != "e4" || session.Evaluation == nil || *session.Evaluation != 0.4 {
+		t.Errorf("Expected the engine to open with e4, got %+v", session)
+	}
+
+	session, err = service.MakeMove(context.Background(), session.ID, &models.PlayMoveRequest{Move: "e5"})
+	if err != nil {
+		t.Fatalf("MakeMove() error = %v", err)
+	}
+	if want := []string{"e4", "e5", "Nf3"}; !reflect.DeepEqual(session.Moves, want) || session.EngineMove != "Nf3" {
+		t.Errorf("Moves = %v (engine %s), want %v", session.Moves, session.EngineMove, want)
+	}
+
+	// Illegal moves are rejected
+	var validation *errors.ValidationError
+	if _, err := service.MakeMove(context.Background(), session.ID, &models.PlayMoveRequest{Move: "e4"}); !errors.As(err, &validation) {
+		t.Errorf("Expected a validation error for an illegal move, got %v", err)
+	}
+
+	// A failed engine reply takes the user's move back
+	if _, err := service.MakeMove(context.Background(), session.ID, &models.PlayMoveRequest{Move: "b8c6"}); err == nil {
+		t.Error("Expected the engine failure to be reported")
+	}
+	if session, _ = service.GetGame(session.ID); len(session.Moves) != 3 || session.Status != models.PlayStatusActive {
+		t.Errorf("Expected the game to be unchanged, got %+v", session)
+	}
+
+	session, err = service.Resign(session.ID)
+	if err != nil {
+		t.Fatalf("Resign() error = %v", err)
+	}
+	if session.Result != "1-0" || session.Termination != models.TerminationResignation {
+		t.Errorf("Expected Black's resignation, got %+v", session)
+	}
+
+	pgn, err := service.GamePGN(session.ID)
+	if err != nil {
+		t.Fatalf("GamePGN() error = %v", err)
+	}
+	for _, want := range []string{`[White "Stockfish"]`, `[Black "Player"]`, `[Termination "resignation"]`, "1. e4 e5 2. Nf3 1-0"} {
+		if !strings.Contains(pgn, want) {
+			t.Errorf("Expected PGN to contain %q, got:\n%s", want, pgn)
+		}
+	}
+}
+
+func TestPlayService_MateEndsGame(t *testing.T) {
+	service, searches := newTestPlayService()
+
+	session, err := service.CreateGame(context.Background(), &models.PlayRequest{FEN: "7k/8/6K1/8/8/8/8/R7 w - - 0 1"})
+	if err != nil {
+		t.Fatalf("CreateGame() error = %v", err)
+	}
+	session, err = service.MakeMove(context.Background(), session.ID, &models.PlayMoveRequest{Move: "Ra8#"})
+	if err != nil {
+		t.Fatalf("MakeMove() error = %v", err)
+	}
+	if session.Status != models.PlayStatusFinished || session.Result != "1-0" || session.Termination != models.TerminationCheckmate || *searches != 0 {
+		t.Errorf("Expected the mate to end the game without an engine search, got %+v", session)
+	}
+
+	var validation *errors.ValidationError
+	if _, err := service.MakeMove(context.Background(), session.ID, &models.PlayMoveRequest{Move: "Kh7"}); !errors.As(err, &validation) {
+		t.Errorf("Expected moves after the end to be rejected, got %v", err)
+	}
+}
+
+func TestPlayService_NotFound(t *testing.T) {
+	service, _ := newTestPlayService()
+
+	var notFound *errors.PlaySessionNotFoundError
+	if _, err := service.GetGame("missing"); !errors.As(err, &notFound) {
+		t.Errorf("Expected a not found error, got %v", err)
+	}
+	if err := service.DeleteGame("missing"); !errors.As(err, &notFound) {
+		t.Errorf("Expected a not found error, got %v", err)
+	}
+}
@@ -0,0 +1,317 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/board"
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/internal/notify"
+	"github.com/pedrampdd/ChessAnalyser/internal/storage"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+// Player status notification settings
+const (
+	defaultMilestoneStep       = 100
+	defaultStreakLength        = 5
+	maxRecentNotifications     = 100
+	notificationSubscriberSize = 32
+)
+
+// NotificationOptions configures the player status notifications of synced players
+type NotificationOptions struct {
+	WebhookURL    string                       // Notifications are posted here as JSON when set, or as messages to a Discord webhook
+	Channels      []models.NotificationChannel // More channels notifications are delivered to
+	MilestoneStep int                          // Ratings notify when crossing a multiple of this (0 = off)
+	StreakLength  int                          // Streaks notify at this many games in a row and every multiple (0 = off)
+	BlunderCheck  bool                         // Scan just-finished games and notify about the player's worst blunder
+}
+
+// channels returns every channel notifications are delivered to
+func (o NotificationOptions) channels() []models.NotificationChannel {
+	channels := o.Channels
+	if o.WebhookURL != "" {
+		channels = append([]models.NotificationChannel{notify.WebhookChannel(o.WebhookURL)}, channels...)
+	}
+	return channels
+}
+
+// SetNotifier sets the notifier that delivers notifications to their channels
+func (s *SyncService) SetNotifier(notifier *notify.Notifier) {
+	s.notifier = notifier
+}
+
+// SetNotificationOptions configures rating milestone, title and streak notifications
+func (s *SyncService) SetNotificationOptions(options NotificationOptions) error {
+	if options.MilestoneStep < 0 || options.StreakLength < 0 {
+		return errors.NewValidationError("notifications", "milestone step and streak length can't be negative")
+	}
+	for _, channel := range options.channels() {
+		if err := s.notifier.Validate(channel); err != nil {
+			return err
+		}
+	}
+	s.notifyMu.Lock()
+	defer s.notifyMu.Unlock()
+	s.notify = options
+	return nil
+}
+
+// GetNotifications returns a page of the most recent notifications, newest first, optionally of
+// one player
+func (s *SyncService) GetNotifications(username string, request models.PageRequest) (*models.Page[models.PlayerNotification], error) {
+	s.notifyMu.Lock()
+	defer s.notifyMu.Unlock()
+
+	// Keys count down from the first notification ever published, so pages run newest first and
+	// cursors stay valid as notifications are published and trimmed
+	first := s.published - len(s.notifications)
+	positions := []int{}
+	for i, notification := range s.notifications {
+		if username == "" || strings.EqualFold(notification.Username, username) {
+			positions = append(positions, i)
+		}
+	}
+	page, err := storage.Paginate(storage.ListNotifications, positions, func(i int) string {
+		return storage.IndexKey(math.MaxInt32 - first - i)
+	}, request)
+	if err != nil {
+		return nil, err
+	}
+
+	notifications := &models.Page[models.PlayerNotification]{
+		Items:         make([]models.PlayerNotification, 0, len(page.Items)),
+		NextCursor:    page.NextCursor,
+		PrevCursor:    page.PrevCursor,
+		TotalEstimate: page.TotalEstimate,
+	}
+	for _, i := range page.Items {
+		notifications.Items = append(notifications.Items, s.notifications[i])
+	}
+	return notifications, nil
+}
+
+// SubscribeNotifications returns a channel receiving new notifications. Call the returned function
+// to unsubscribe.
+func (s *SyncService) SubscribeNotifications() (<-chan models.PlayerNotification, func()) {
+	s.notifyMu.Lock()
+	defer s.notifyMu.Unlock()
+
+	ch := make(chan models.PlayerNotification, notificationSubscriberSize)
+	s.subscribers[ch] = struct{}{}
+	unsubscribe := func() {
+		s.notifyMu.Lock()
+		defer s.notifyMu.Unlock()
+		if _, ok := s.subscribers[ch]; ok {
+			delete(s.subscribers, ch)
+			close(ch)
+		}
+	}
+	return ch, unsubscribe
+}
+
+// detectStatusChanges updates a player's ratings, title and streak from newly synced games and
+// publishes the changes. On a player's first sync the status is only recorded, so backfilled
+// games don't flood subscribers.
+func (s *SyncService) detectStatusChanges(state *models.SyncState, username string, games []*models.GameInfo, baseline bool) {
+	s.notifyMu.Lock()
+	options := s.notify
+	s.notifyMu.Unlock()
+
+	sorted := append([]*models.GameInfo(nil), games...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return gameEnd(sorted[i]).Before(gameEnd(sorted[j]))
+	})
+
+	var notifications []models.PlayerNotification
+	for _, game := range sorted {
+		color, ok := playerColor(game, username)
+		if !ok {
+			continue
+		}
+		player := game.WhitePlayer
+		if color == board.Black {
+			player = game.BlackPlayer
+		}
+		notify := func(notification models.PlayerNotification) {
+			if !baseline {
+				notification.Username = state.Username
+				notification.GameURL = game.URL
+				notifications = append(notifications, notification)
+			}
+		}
+
+		// A player seen for the first time has no title to change from
+		if len(state.Ratings) > 0 && player.Title != state.Title {
+			notify(models.PlayerNotification{
+				Type:          models.NotificationTitleChange,
+				Title:         player.Title,
+				PreviousTitle: state.Title,
+				Message:       titleChangeMessage(state.Username, state.Title, player.Title),
+			})
+		}
+		state.Title = player.Title
+
+		if game.Rated && player.Rating > 0 && game.TimeClass != "" {
+			if state.Ratings == nil {
+				state.Ratings = make(map[string]int)
+			}
+			previous, seen := state.Ratings[game.TimeClass]
+			if milestone, crossed := crossedMilestone(previous, player.Rating, options.MilestoneStep); seen && crossed {
+				direction := "reached"
+				if player.Rating < previous {
+					direction = "dropped below"
+				}
+				notify(models.PlayerNotification{
+					Type:           models.NotificationRatingMilestone,
+					TimeClass:      game.TimeClass,
+					Rating:         player.Rating,
+					PreviousRating: previous,
+					Milestone:      milestone,
+					Message: fmt.Sprintf("%s %s %d in %s (%d, was %d)", state.Username, direction, milestone,
+						game.TimeClass, player.Rating, previous),
+				})
+			}
+			state.Ratings[game.TimeClass] = player.Rating
+		}
+
+		parsed, err := s.pgnParser.ParsePGN(game.PGN)
+		if err != nil {
+			continue
+		}
+		score, decided := playerScore(parsed.Result, color)
+		if !decided {
+			continue
+		}
+		switch score {
+		case 1:
+			state.Streak = max(state.Streak, 0) + 1
+		case 0:
+			state.Streak = min(state.Streak, 0) - 1
+		default:
+			state.Streak = 0
+		}
+
+		length := abs(state.Streak)
+		if options.StreakLength > 0 && length >= options.StreakLength && length%options.StreakLength == 0 {
+			notification := models.PlayerNotification{
+				Type:    models.NotificationWinStreak,
+				Streak:  length,
+				Message: fmt.Sprintf("%s won %d games in a row", state.Username, length),
+			}
+			if state.Streak < 0 {
+				notification.Type = models.NotificationLossStreak
+				notification.Message = fmt.Sprintf("%s lost %d games in a row", state.Username, length)
+			}
+			notify(notification)
+		}
+	}
+
+	s.publishNotifications(notifications, options.channels())
+}
+
+// publishNotifications keeps notifications for listing, sends them to subscribers and delivers
+// them to the channels
+func (s *SyncService) publishNotifications(notifications []models.PlayerNotification, channels []models.NotificationChannel) {
+	if len(notifications) == 0 {
+		return
+	}
+
+	s.notifyMu.Lock()
+	defer s.notifyMu.Unlock()
+
+	for _, notification := range notifications {
+		id, err := storage.NewID()
+		if err != nil {
+			log.Printf("Failed to publish %s notification for %s: %v", notification.Type, notification.Username, err)
+			continue
+		}
+		notification.ID = id
+		notification.Time = s.now()
+
+		s.notifications = append(s.notifications, notification)
+		s.published++
+		if len(s.notifications) > maxRecentNotifications {
+			s.notifications = s.notifications[len(s.notifications)-maxRecentNotifications:]
+		}
+
+		for ch := range s.subscribers {
+			select {
+			case ch <- notification:
+			default:
+				// Slow subscribers miss notifications rather than stalling the sync
+			}
+		}
+
+		if len(channels) > 0 {
+			go s.sendNotification(channels, notification)
+		}
+	}
+}
+
+// sendNotification delivers a notification to the channels, logging failures
+func (s *SyncService) sendNotification(channels []models.NotificationChannel, notification models.PlayerNotification) {
+	err := s.notifier.Send(context.Background(), channels, notificationMessage(notification))
+	if err != nil {
+		log.Printf("Notification delivery failed for %s: %v", notification.Username, err)
+	}
+}
+
+// notificationMessage is a notification as a message: its text with the game's link for chat
+// and email channels, and the notification itself for webhooks
+func notificationMessage(notification models.PlayerNotification) notify.Message {
+	text := notification.Message
+	if notification.GameURL != "" {
+		text += "\n" + notification.GameURL
+	}
+	return notify.Message{Text: text, Payload: notification}
+}
+
+// crossedMilestone reports the multiple of step a rating change reached (climbing) or dropped
+// below (falling)
+func crossedMilestone(previous, rating, step int) (int, bool) {
+	if step <= 0 {
+		return 0, false
+	}
+	before, after := previous/step, rating/step
+	switch {
+	case after > before:
+		return after * step, true
+	case after < before:
+		return before * step, true
+	}
+	return 0, false
+}
+
+// titleChangeMessage describes a title being earned, changed or dropped
+func titleChangeMessage(username, previous, title string) string {
+	switch {
+	case previous == "":
+		return fmt.Sprintf("%s earned the %s title", username, title)
+	case title == "":
+		return fmt.Sprintf("%s no longer plays under the %s title", username, previous)
+	}
+	return fmt.Sprintf("%s's title changed from %s to %s", username, previous, title)
+}
+
+// gameEnd returns when a game ended, or when it started for games without an end time
+func gameEnd(game *models.GameInfo) time.Time {
+	if game.EndTime != nil {
+		return *game.EndTime
+	}
+	return game.StartTime
+}
+
+// abs returns the absolute value of n
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
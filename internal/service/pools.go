@@ -0,0 +1,240 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/engine"
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+// defaultPoolName names the pool created from the main Stockfish configuration
+const defaultPoolName = "standard"
+
+// enginePartition is an additional engine pool and the requests routed to it
+type enginePartition struct {
+	config engine.PoolConfig
+	pool   *engine.EnginePool
+	err    error // Startup error; requests routed here fail with it
+}
+
+// AddEnginePool starts an additional engine pool for the configured variants and profiles.
+// A pool that fails to start is still registered so its error shows up in the engine status,
+// and requests routed to it fail instead of silently falling back to another engine.
+func (s *AnalysisService) AddEnginePool(config engine.PoolConfig) error {
+	if config.Name == "" || strings.EqualFold(config.Name, defaultPoolName) {
+		return fmt.Errorf("engine pool name %q is reserved or empty", config.Name)
+	}
+	if config.Size <= 0 {
+		config.Size = 1
+	}
+	for _, variant := range config.Variants {
+		if !engine.IsSupportedVariant(variant) {
+			return fmt.Errorf("engine pool %s: variant %s can't be analyzed", config.Name, variant)
+		}
+	}
+
+	partition := &enginePartition{config: config}
+	if s.lazyEngines {
+		partition.pool, partition.err = engine.NewLazyEnginePool(config.Size, config.ExecutablePath, s.defaultSettings, s.engineSandbox, s.engineIdle)
+	} else {
+		partition.pool, partition.err = engine.NewSandboxedEnginePool(config.Size, config.ExecutablePath, s.defaultSettings, s.engineSandbox)
+	}
+	if partition.pool != nil {
+		partition.pool.SetMaxQueue(s.queueLimit)
+		if err := partition.pool.SetResourceLimits(s.engineLimits); err != nil {
+			partition.pool.Close()
+			partition.pool, partition.err = nil, err
+		}
+	}
+
+	s.partitions = append(s.partitions, partition)
+	return partition.err
+}
+
+// SetQueueLimit bounds how many client requests wait for an engine in each pool, including pools
+// added later. Requests beyond the bound are rejected as rate limited; 0 means no bound.
+func (s *AnalysisService) SetQueueLimit(limit int) {
+	s.queueLimit = limit
+	if s.enginePool != nil {
+		s.enginePool.SetMaxQueue(limit)
+	}
+	for _, partition := range s.partitions {
+		if partition.pool != nil {
+			partition.pool.SetMaxQueue(limit)
+		}
+	}
+}
+
+// SetLoadShedding sets how the searches of non-priority game analyses are cut back while the
+// queue of the pool they run on is long
+func (s *AnalysisService) SetLoadShedding(policy models.LoadShedding) {
+	s.loadShedding = policy
+}
+
+// SetEngineTuning records how auto-tuning sized the default pool, for the engine status
+func (s *AnalysisService) SetEngineTuning(tuning models.EngineTuning) {
+	s.engineTuning = &tuning
+}
+
+// SetEngineSandbox sets the restrictions the engines of pools added later start with. The default
+// pool's engines are already running; NewSandboxedAnalysisService starts them sandboxed.
+func (s *AnalysisService) SetEngineSandbox(sandbox models.EngineSandbox) error {
+	if err := engine.ValidateSandbox(sandbox); err != nil {
+		return errors.NewValidationError("sandbox", err.Error())
+	}
+	s.engineSandbox = sandbox
+	return nil
+}
+
+// SetLazyEngines makes pools added later start engines on first use and stop engines left idle for
+// idleTimeout (0 keeps them running once started)
+func (s *AnalysisService) SetLazyEngines(idleTimeout time.Duration) {
+	s.lazyEngines = true
+	s.engineIdle = idleTimeout
+}
+
+// SetEngineLimits restricts the CPU and memory of the engine processes of every pool, including
+// pools added later, so a busy pool doesn't starve the rest of the host
+func (s *AnalysisService) SetEngineLimits(limits models.EngineLimits) error {
+	if err := engine.ValidateLimits(limits); err != nil {
+		return errors.NewValidationError("limits", err.Error())
+	}
+	s.engineLimits = limits
+
+	if s.enginePool != nil {
+		if err := s.enginePool.SetResourceLimits(limits); err != nil {
+			return err
+		}
+	}
+	for _, partition := range s.partitions {
+		if partition.pool != nil {
+			if err := partition.pool.SetResourceLimits(limits); err != nil {
+				return fmt.Errorf("engine pool %s: %w", partition.config.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// poolFor returns the engine pool a request is routed to.
+// Variant routes take precedence over profile routes; everything else uses the default pool.
+func (s *AnalysisService) poolFor(variant, profile string) (*engine.EnginePool, error) {
+	if !engine.IsSupportedVariant(variant) {
+		return nil, errors.NewUnsupportedVariantError(variant)
+	}
+	if !engine.IsStandardVariant(variant) {
+		for _, partition := range s.partitions {
+			if slices.ContainsFunc(partition.config.Variants, func(v string) bool { return engine.SameVariant(v, variant) }) {
+				return partition.available()
+			}
+		}
+		return nil, errors.NewUnsupportedVariantError(variant)
+	}
+
+	if profile != "" {
+		for _, partition := range s.partitions {
+			if containsFold(partition.config.Profiles, profile) {
+				return partition.available()
+			}
+		}
+	}
+
+	if s.engineErr != nil {
+		return nil, errors.NewEngineUnavailableError(defaultPoolName, s.engineErr)
+	}
+	return s.enginePool, nil
+}
+
+// EngineAvailable reports whether the default engine pool runs, i.e. whether standard analysis works
+func (s *AnalysisService) EngineAvailable() bool {
+	return s.engineErr == nil
+}
+
+// available returns the partition's pool, or why it can't serve requests
+func (p *enginePartition) available() (*engine.EnginePool, error) {
+	if p.err != nil {
+		return nil, errors.NewEngineUnavailableError(p.config.Name, p.err)
+	}
+	return p.pool, nil
+}
+
+// enginePoolStatuses reports every engine pool, including the Maia model when loaded
+func (s *AnalysisService) enginePoolStatuses() []models.EnginePoolStatus {
+	standard := poolStatus(defaultPoolName, s.enginePool)
+	if s.engineErr != nil {
+		standard.Error = s.engineErr.Error()
+	}
+	statuses := []models.EnginePoolStatus{standard}
+
+	for _, partition := range s.partitions {
+		status := poolStatus(partition.config.Name, partition.pool)
+		status.Variants = partition.config.Variants
+		status.Profiles = partition.config.Profiles
+		if partition.err != nil {
+			status.Healthy = false
+			status.Error = partition.err.Error()
+		}
+		statuses = append(statuses, status)
+	}
+
+	if s.humanModel != nil {
+		statuses = append(statuses, models.EnginePoolStatus{
+			Name:             "maia",
+			Engine:           "Lc0 (Maia)",
+			TotalEngines:     s.humanModel.Size(),
+			AvailableEngines: s.humanModel.Idle(),
+			RunningEngines:   s.humanModel.Size(),
+			Healthy:          s.humanModel.Size() > 0,
+		})
+	}
+
+	return statuses
+}
+
+// poolStatus reports the capacity of an engine pool
+func poolStatus(name string, pool *engine.EnginePool) models.EnginePoolStatus {
+	status := models.EnginePoolStatus{Name: name}
+	if pool == nil {
+		return status
+	}
+
+	status.TotalEngines = pool.Size()
+	status.AvailableEngines = pool.Idle()
+	status.RunningEngines = pool.Running()
+	var wait time.Duration
+	status.Queued, status.MaxQueue, wait = pool.QueueStatus()
+	status.EstimatedWaitMS = wait.Milliseconds()
+	status.Healthy = status.TotalEngines > 0
+	status.Engine = pool.Version
+	status.EvalScale = pool.EvalScale
+	if limits := pool.ResourceLimits(); !limits.IsZero() {
+		status.Limits = &limits
+	}
+	if sandbox := pool.Sandbox(); !sandbox.IsZero() {
+		status.Sandbox = &sandbox
+	}
+	return status
+}
+
+// closePartitions shuts down the additional engine pools
+func (s *AnalysisService) closePartitions() {
+	for _, partition := range s.partitions {
+		if partition.pool != nil {
+			partition.pool.Close()
+		}
+	}
+}
+
+// containsFold reports whether values contains value, ignoring case
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
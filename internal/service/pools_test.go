@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/engine"
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+func TestAnalysisService_PoolFor(t *testing.T) {
+	standard, fairy, deep := &engine.EnginePool{}, &engine.EnginePool{}, &engine.EnginePool{}
+	s := &AnalysisService{
+		enginePool: standard,
+		partitions: []*enginePartition{
+			{config: engine.PoolConfig{Name: "fairy", Variants: []string{"kingofthehill"}}, pool: fairy},
+			{config: engine.PoolConfig{Name: "deep", Profiles: []string{"deep"}}, pool: deep},
+			{config: engine.PoolConfig{Name: "racing", Variants: []string{"racingkings"}}, err: fmt.Errorf("executable not found")},
+		},
+	}
+
+	tests := []struct {
+		variant, profile string
+		want             *engine.EnginePool
+		wantErr          bool
+	}{
+		{"", "", standard, false},
+		{"King of the Hill", "deep", fairy, false},
+		{"", "deep", deep, false},
+		{"", "fast", standard, false},
+		{"racingkings", "", nil, true},
+		{"chess960", "", nil, true},
+		{"crazyhouse", "", nil, true},
+	}
+
+	for _, tt := range tests {
+		got, err := s.poolFor(tt.variant, tt.profile)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("poolFor(%q, %q) = %p, %v, want %p, error %v", tt.variant, tt.profile, got, err, tt.want, tt.wantErr)
+		}
+	}
+
+	statuses := s.enginePoolStatuses()
+	if len(statuses) != 4 || statuses[0].Name != defaultPoolName || statuses[3].Healthy || statuses[3].Error == "" {
+		t.Errorf("Unexpected pool statuses: %+v", statuses)
+	}
+}
+
+func TestAnalysisService_AddEnginePoolRejectsUnsupportedVariants(t *testing.T) {
+	s := &AnalysisService{}
+	if err := s.AddEnginePool(engine.PoolConfig{Name: "fairy", Variants: []string{"kingofthehill", "crazyhouse"}}); err == nil {
+		t.Error("Expected a pool for crazyhouse to be refused")
+	}
+	if len(s.partitions) != 0 {
+		t.Errorf("Expected the refused pool not to be registered, got %d pools", len(s.partitions))
+	}
+}
+
+func TestAnalysisService_AnalyzeGameRejectsUnreplayableVariants(t *testing.T) {
+	s := NewUnavailableAnalysisService(models.EngineSettings{Depth: 10}, fmt.Errorf("executable not found"))
+	defer s.Close()
+
+	pgn := `[Variant "Chess960"]
+[FEN "bqnbrkrn/pppppppp/8/8/8/8/PPPPPPPP/BQNBRKRN w GEge - 0 1"]
+
+1. e4 e5 2. O-O *`
+	var unsupported *errors.UnsupportedVariantError
+	if _, err := s.AnalyzeGame(context.Background(), &models.AnalysisRequest{PGN: pgn}); !errors.As(err, &unsupported) {
+		t.Errorf("Expected a Chess960 game to be refused before it's replayed, got %v", err)
+	}
+}
+
+func TestAnalysisService_EngineUnavailable(t *testing.T) {
+	s := NewUnavailableAnalysisService(models.EngineSettings{Depth: 10}, fmt.Errorf("executable not found"))
+	defer s.Close()
+
+	if s.EngineAvailable() {
+		t.Error("Expected analysis to be unavailable")
+	}
+
+	_, err := s.AnalyzePosition(context.Background(), "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1", models.EngineSettings{Depth: 10})
+	if errors.HTTPStatus(err) != http.StatusServiceUnavailable {
+		t.Errorf("AnalyzePosition error = %v, want 503", err)
+	}
+
+	status := s.GetEngineStatus()
+	if status["analysis_available"] != false || status["engine_error"] == nil || status["total_engines"] != 0 {
+		t.Errorf("Unexpected engine status: %+v", status)
+	}
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"math"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/internal/parser"
+)
+
+// Practical mode settings
+const (
+	practicalMultiPV = 2   // Sharpness compares the best and second-best reply
+	sharpGap         = 1.5 // Pawns lost by the second-best reply that make a position sharp
+	tenseGap         = 0.7 // Pawns lost by the second-best reply that make a position tense
+	maxSharpness     = 10.0
+
+	criticalPressureShare = 0.10 // Share of the base time left that counts as critical time pressure
+	lowPressureShare      = 0.25
+	criticalPressureCap   = time.Minute // Long games are in critical time pressure below a minute
+	lowPressureCap        = 5 * time.Minute
+)
+
+// pressureWeights is the share of a position's sharpness held against a player short of time
+var pressureWeights = map[string]float64{
+	models.TimePressureNone:     0,
+	models.TimePressureLow:      0.25,
+	models.TimePressureCritical: 0.5,
+}
+
+// gameClocks returns the clock after every ply and the game's base time, or false when the
+// game isn't timed or the clock after some move is neither recorded ([%clk]) nor derivable
+// from its elapsed time ([%emt] or [%timestamp])
+func gameClocks(p *parser.PGNParser, game *parser.ParsedGame) ([]time.Duration, time.Duration, bool) {
+	base, increment, timed := parseTimeControl(game.Headers["timecontrol"])
+	if !timed {
+		return nil, 0, false
+	}
+
+	times := p.ExtractMoveTimes(game.PGN)
+	if len(times) == 0 || len(times) != len(game.Moves) {
+		return nil, 0, false
+	}
+	parser.FillMoveTimes(times, base, increment)
+
+	clocks := make([]time.Duration, len(times))
+	for i, t := range times {
+		if !t.HasClock {
+			return nil, 0, false
+		}
+		clocks[i] = t.Clock
+	}
+	return clocks, base, true
+}
+
+// assessPractical weighs the evaluation after a ply with both players' clocks. A position is
+// sharp when the reply's second-best move loses a lot; finding the only move is harder short
+// of time, so the evaluation is shifted against the player to move by part of that gap.
+func assessPractical(move models.MoveAnalysis, result *models.AnalysisResult, ply int,
+	clocks []time.Duration, base time.Duration) *models.PracticalAssessment {
+	mover := clocks[ply-1]
+	opponent := base
+	if ply >= 2 {
+		opponent = clocks[ply-2]
+	}
+
+	assessment := &models.PracticalAssessment{
+		MoverClock:       mover.Seconds(),
+		OpponentClock:    opponent.Seconds(),
+		MoverPressure:    timePressure(mover, base),
+		OpponentPressure: timePressure(opponent, base),
+	}
+	assessment.MutualTimeTrouble = assessment.MoverPressure != models.TimePressureNone &&
+		assessment.OpponentPressure != models.TimePressureNone
+
+	if len(result.LineEvaluations) >= 2 {
+		assessment.Sharpness = math.Min(math.Abs(result.LineEvaluations[0]-result.LineEvaluations[1]), maxSharpness)
+	}
+
+	// After a White move the player to move next is Black
+	shift := assessment.Sharpness * pressureWeights[assessment.OpponentPressure]
+	if moveColor(move.FEN, ply) == "white" {
+		shift = -shift
+	}
+	assessment.PracticalEvaluation = move.Evaluation - shift
+
+	switch {
+	case assessment.Sharpness >= sharpGap &&
+		(assessment.OpponentPressure == models.TimePressureCritical || assessment.MutualTimeTrouble):
+		assessment.Risk = models.RiskHigh
+	case assessment.Sharpness >= sharpGap && assessment.OpponentPressure == models.TimePressureLow,
+		assessment.Sharpness >= tenseGap && assessment.OpponentPressure != models.TimePressureNone:
+		assessment.Risk = models.RiskMedium
+	default:
+		assessment.Risk = models.RiskLow
+	}
+
+	return assessment
+}
+
+// timePressure classifies the time left relative to the base time, capped so long games
+// aren't in time pressure with many minutes on the clock
+func timePressure(remaining, base time.Duration) string {
+	critical := time.Duration(float64(base) * criticalPressureShare)
+	if critical > criticalPressureCap {
+		critical = criticalPressureCap
+	}
+	low := time.Duration(float64(base) * lowPressureShare)
+	if low > lowPressureCap {
+		low = lowPressureCap
+	}
+
+	switch {
+	case remaining < critical:
+		return models.TimePressureCritical
+	case remaining < low:
+		return models.TimePressureLow
+	}
+	return models.TimePressureNone
+}
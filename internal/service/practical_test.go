@@ -0,0 +1,93 @@
+package service
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/internal/parser"
+)
+
+func TestGameClocks(t *testing.T) {
+	p := parser.NewPGNParser()
+	pgn := `[TimeControl "180+2"]
+
+1. e4 {[%clk 0:02:59]} e5 {[%clk 0:02:58]} 2. Nf3 {[%clk 0:02:55]} *`
+
+	game, err := p.ParsePGN(pgn)
+	if err != nil {
+		t.Fatalf("ParsePGN() error = %v", err)
+	}
+	clocks, base, ok := gameClocks(p, game)
+	if !ok || base != 3*time.Minute || len(clocks) != 3 || clocks[2] != 2*time.Minute+55*time.Second {
+		t.Errorf("gameClocks() = %v, %v, %v", clocks, base, ok)
+	}
+
+	game.Headers["timecontrol"] = "1/86400"
+	if _, _, ok := gameClocks(p, game); ok {
+		t.Error("Expected daily games to have no usable clocks")
+	}
+
+	// Elapsed times from other exporters give the same clocks
+	emt, err := p.ParsePGN(`[TimeControl "180+2"]
+
+1. e4 {[%emt 0:00:03]} e5 {[%timestamp 40]} 2. Nf3 {[%emt 0:00:06]} *`)
+	if err != nil {
+		t.Fatalf("ParsePGN() error = %v", err)
+	}
+	if clocks, _, ok := gameClocks(p, emt); !ok || clocks[0] != 179*time.Second || clocks[1] != 178*time.Second || clocks[2] != 175*time.Second {
+		t.Errorf("gameClocks() with elapsed times = %v, %v", clocks, ok)
+	}
+}
+
+func TestAssessPractical(t *testing.T) {
+	base := 3 * time.Minute
+	sharp := &models.AnalysisResult{LineEvaluations: []float64{0.1, -2.5}}
+	quiet := &models.AnalysisResult{LineEvaluations: []float64{0.1, 0}}
+
+	// Both players are down to a few seconds after White's 30th move and Black must find the only move
+	scramble := []time.Duration{}
+	for ply := 1; ply <= 59; ply++ {
+		scramble = append(scramble, 8*time.Second)
+	}
+
+	assessment := assessPractical(models.MoveAnalysis{Evaluation: 0.1}, sharp, 59, scramble, base)
+	if !assessment.MutualTimeTrouble || assessment.OpponentPressure != models.TimePressureCritical ||
+		assessment.Risk != models.RiskHigh {
+		t.Errorf("Expected a sharp position in mutual time trouble to be high risk, got %+v", assessment)
+	}
+	// The sharpness is held against Black, who is to move
+	if want := 0.1 + 2.6*0.5; math.Abs(assessment.PracticalEvaluation-want) > 1e-9 {
+		t.Errorf("PracticalEvaluation = %v, want %v", assessment.PracticalEvaluation, want)
+	}
+
+	if assessment := assessPractical(models.MoveAnalysis{Evaluation: 0.1}, quiet, 59, scramble, base); assessment.Risk != models.RiskLow {
+		t.Errorf("Expected a quiet position to be low risk, got %+v", assessment)
+	}
+
+	relaxed := []time.Duration{base, base}
+	if assessment := assessPractical(models.MoveAnalysis{Evaluation: 0.1}, sharp, 2, relaxed, base); assessment.Risk != models.RiskLow ||
+		assessment.PracticalEvaluation != 0.1 {
+		t.Errorf("Expected no practical adjustment with plenty of time, got %+v", assessment)
+	}
+}
+
+func TestTimePressure(t *testing.T) {
+	tests := []struct {
+		remaining, base time.Duration
+		want            string
+	}{
+		{15 * time.Second, 3 * time.Minute, models.TimePressureCritical},
+		{40 * time.Second, 3 * time.Minute, models.TimePressureLow},
+		{2 * time.Minute, 3 * time.Minute, models.TimePressureNone},
+		{4 * time.Minute, 90 * time.Minute, models.TimePressureLow},
+		{50 * time.Second, 90 * time.Minute, models.TimePressureCritical},
+	}
+
+	for _, tt := range tests {
+		if got := timePressure(tt.remaining, tt.base); got != tt.want {
+			t.Errorf("timePressure(%v, %v) = %v, want %v", tt.remaining, tt.base, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/internal/storage"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+// PreferencesService manages saved per-user settings
+type PreferencesService struct {
+	store *storage.MemoryStore
+}
+
+// NewPreferencesService creates a new preferences service
+func NewPreferencesService(store *storage.MemoryStore) *PreferencesService {
+	return &PreferencesService{
+		store: store,
+	}
+}
+
+// GetPreferences returns the saved preferences for a user, or nil if none are saved
+func (s *PreferencesService) GetPreferences(user string) *models.UserPreferences {
+	if user == "" {
+		return nil
+	}
+	return s.store.GetPreferences(user)
+}
+
+// SavePreferences validates and stores preferences for a user
+func (s *PreferencesService) SavePreferences(user string, prefs *models.UserPreferences) (*models.UserPreferences, error) {
+	if user == "" {
+		return nil, errors.NewValidationError("user", "an API key or username is required")
+	}
+
+	if prefs.DefaultProfile != "" && !IsValidProfile(prefs.DefaultProfile) {
+		return nil, errors.NewValidationError("default_profile", fmt.Sprintf("unknown analysis profile: %s", prefs.DefaultProfile))
+	}
+
+	switch prefs.ExportFormat {
+	case "", ExportFormatPGN, ExportFormatJSON, ExportFormatHTML, ExportFormatGIF:
+	default:
+		return nil, errors.NewValidationError("export_format", "must be one of: pgn, json, html, gif")
+	}
+
+	if err := validateOrientation(prefs.BoardOrientation); err != nil {
+		return nil, errors.NewValidationError("board_orientation", "must be one of: white, black")
+	}
+
+	if err := validateThresholds(prefs.Thresholds); err != nil {
+		return nil, err
+	}
+	if _, ok := accuracyModels[prefs.AccuracyModel]; prefs.AccuracyModel != "" && !ok {
+		return nil, unknownAccuracyModelError(prefs.AccuracyModel)
+	}
+
+	prefs.User = user
+	prefs.UpdatedAt = time.Now()
+	s.store.SavePreferences(prefs)
+
+	return prefs, nil
+}
+
+// DeletePreferences removes the saved preferences for a user
+func (s *PreferencesService) DeletePreferences(user string) {
+	s.store.DeletePreferences(user)
+}
+
+// ApplyPreferences fills fields omitted in an analysis request from the user's preferences
+func (s *PreferencesService) ApplyPreferences(user string, request *models.AnalysisRequest) {
+	prefs := s.GetPreferences(user)
+	if prefs == nil {
+		return
+	}
+
+	if request.Profile == "" {
+		request.Profile = prefs.DefaultProfile
+	}
+	if request.Thresholds == nil && prefs.Thresholds != nil {
+		thresholds := *prefs.Thresholds
+		request.Thresholds = &thresholds
+	}
+	if request.AccuracyModel == "" {
+		request.AccuracyModel = prefs.AccuracyModel
+	}
+}
+
+// ApplyReclassifyPreferences fills the thresholds and accuracy model omitted in a reclassification
+// from the user's preferences
+func (s *PreferencesService) ApplyReclassifyPreferences(user string, request *models.ReclassifyRequest) {
+	prefs := s.GetPreferences(user)
+	if prefs == nil {
+		return
+	}
+
+	if request.Thresholds == nil && prefs.Thresholds != nil {
+		thresholds := *prefs.Thresholds
+		request.Thresholds = &thresholds
+	}
+	if request.AccuracyModel == "" {
+		request.AccuracyModel = prefs.AccuracyModel
+	}
+}
+
+// validateThresholds checks that classification thresholds are ordered percentages
+func validateThresholds(t *models.ClassificationThresholds) error {
+	if t != nil && (t.Blunder < 0 || t.Blunder > t.Mistake || t.Mistake > t.Inaccuracy || t.Inaccuracy > 100) {
+		return errors.NewValidationError("thresholds", "must satisfy 0 <= blunder <= mistake <= inaccuracy <= 100")
+	}
+	return nil
+}
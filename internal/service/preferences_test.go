@@ -0,0 +1,107 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/internal/storage"
+)
+
+func TestPreferencesService_SavePreferences(t *testing.T) {
+	service := NewPreferencesService(storage.NewMemoryStore())
+
+	tests := []struct {
+		name    string
+		user    string
+		prefs   models.UserPreferences
+		wantErr bool
+	}{
+		{
+			name:  "Valid preferences",
+			user:  "alice",
+			prefs: models.UserPreferences{DefaultProfile: "deep", ExportFormat: "pgn", BoardOrientation: "black"},
+		},
+		{
+			name:    "Missing user",
+			prefs:   models.UserPreferences{DefaultProfile: "fast"},
+			wantErr: true,
+		},
+		{
+			name:    "Unknown profile",
+			user:    "alice",
+			prefs:   models.UserPreferences{DefaultProfile: "ultra"},
+			wantErr: true,
+		},
+		{
+			name:    "Invalid orientation",
+			user:    "alice",
+			prefs:   models.UserPreferences{BoardOrientation: "sideways"},
+			wantErr: true,
+		},
+		{
+			name:    "Unordered thresholds",
+			user:    "alice",
+			prefs:   models.UserPreferences{Thresholds: &models.ClassificationThresholds{Blunder: 90, Mistake: 80, Inaccuracy: 95}},
+			wantErr: true,
+		},
+		{
+			name:    "Unknown accuracy model",
+			user:    "alice",
+			prefs:   models.UserPreferences{AccuracyModel: "elo"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.SavePreferences(tt.user, &tt.prefs)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SavePreferences() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPreferencesService_ApplyPreferences(t *testing.T) {
+	service := NewPreferencesService(storage.NewMemoryStore())
+
+	thresholds := &models.ClassificationThresholds{Blunder: 40, Mistake: 70, Inaccuracy: 85}
+	if _, err := service.SavePreferences("alice", &models.UserPreferences{DefaultProfile: "deep", Thresholds: thresholds,
+		AccuracyModel: models.AccuracyModelCPL}); err != nil {
+		t.Fatalf("SavePreferences() error = %v", err)
+	}
+
+	// Omitted fields are filled from preferences
+	request := &models.AnalysisRequest{}
+	service.ApplyPreferences("alice", request)
+	if request.Profile != "deep" {
+		t.Errorf("Profile = %v, want deep", request.Profile)
+	}
+	if request.Thresholds == nil || *request.Thresholds != *thresholds {
+		t.Errorf("Thresholds = %v, want %v", request.Thresholds, thresholds)
+	}
+	if request.AccuracyModel != models.AccuracyModelCPL {
+		t.Errorf("AccuracyModel = %v, want cpl", request.AccuracyModel)
+	}
+
+	// Reclassifications are filled the same way
+	reclassify := &models.ReclassifyRequest{AccuracyModel: models.AccuracyModelLinear}
+	service.ApplyReclassifyPreferences("alice", reclassify)
+	if reclassify.Thresholds == nil || *reclassify.Thresholds != *thresholds || reclassify.AccuracyModel != models.AccuracyModelLinear {
+		t.Errorf("Unexpected reclassify request: %+v", reclassify)
+	}
+
+	// Explicit request fields win over preferences
+	request = &models.AnalysisRequest{Profile: "fast"}
+	service.ApplyPreferences("alice", request)
+	if request.Profile != "fast" {
+		t.Errorf("Profile = %v, want fast", request.Profile)
+	}
+
+	// Unknown users are left untouched
+	request = &models.AnalysisRequest{}
+	service.ApplyPreferences("bob", request)
+	if request.Profile != "" || request.Thresholds != nil {
+		t.Errorf("Expected request to be untouched, got %+v", request)
+	}
+}
@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/board"
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+// Opening preparation limits
+const (
+	defaultPrepGames = 100
+	prepLinePlies    = 10 // Longest opponent line followed into the opening
+	prepOpenings     = 5  // Most played openings that get engine antidotes
+	prepAntidotes    = 2  // Engine replies suggested per opening
+)
+
+// repertoireOpening accumulates the games an opponent played in one opening
+type repertoireOpening struct {
+	stat  *models.PreparedOpening
+	lines map[string]*repertoireLine
+}
+
+// repertoireLine is a move sequence the opponent played and how often
+type repertoireLine struct {
+	moves []string
+	fen   string
+	games int
+}
+
+// PrepareAgainst builds an opening dossier on an opponent from their recent games with the color
+// they'll play, and asks the engine for the best replies to their most common lines
+func (s *AnalyticsService) PrepareAgainst(ctx context.Context, request *models.PreparationRequest) (*models.PreparationDossier, error) {
+	if request.Opponent == "" {
+		return nil, errors.NewValidationError("opponent", "opponent is required")
+	}
+	request.Color = strings.ToLower(request.Color)
+	if request.Color != "white" && request.Color != "black" {
+		return nil, errors.NewValidationError("color", "color must be white or black")
+	}
+	if request.Games <= 0 {
+		request.Games = defaultPrepGames
+	}
+	if request.Games > maxReportGames {
+		request.Games = maxReportGames
+	}
+	settings := request.Settings
+	if settings.Depth == 0 {
+		settings.Depth = 18
+	}
+	if settings.TimeLimit == 0 {
+		settings.TimeLimit = 3000
+	}
+	if settings.Threads == 0 {
+		settings.Threads = 4
+	}
+	if settings.HashSize == 0 {
+		settings.HashSize = 128
+	}
+	settings.MultiPV = prepAntidotes
+
+	games, archives, err := s.gameService.GetRecentGames(ctx, request.Opponent, request.Games)
+	if err != nil {
+		return nil, err
+	}
+
+	opponentColor := board.White
+	if request.Color == "white" {
+		opponentColor = board.Black
+	}
+	dossier := s.buildRepertoire(games, request.Opponent, opponentColor)
+	dossier.Color = request.Color
+	dossier.FailedArchives = failedArchives(archives)
+	dossier.Archives = archives
+
+	// Antidotes are best effort; the opponent's statistics are useful without them
+	for i := range dossier.Openings {
+		if i == prepOpenings {
+			break
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		opening := &dossier.Openings[i]
+		if opening.FEN == "" {
+			continue
+		}
+		lines, err := s.analysisService.ExploreLines(ctx, opening.FEN, settings)
+		if err != nil {
+			continue
+		}
+		opening.Antidotes = lines.Lines
+	}
+
+	return dossier, nil
+}
+
+// buildRepertoire aggregates the opponent's results per opening, along with the line they play most often
+// in each, over the games where they had the given color
+func (s *AnalyticsService) buildRepertoire(games []*models.GameInfo, opponent string, color board.Color) *models.PreparationDossier {
+	dossier := &models.PreparationDossier{
+		Opponent:      opponent,
+		OpponentColor: color.String(),
+		GeneratedAt:   time.Now(),
+		Openings:      []models.PreparedOpening{},
+	}
+	openings := make(map[string]*repertoireOpening)
+
+	for _, game := range games {
+		if c, ok := playerColor(game, opponent); !ok || c != color {
+			continue
+		}
+		parsed, err := s.pgnParser.ParsePGN(game.PGN)
+		if err != nil {
+			continue
+		}
+		if err := s.pgnParser.ExtractPositions(parsed); err != nil {
+			continue
+		}
+		score, decided := playerScore(parsed.Result, color)
+		if !decided {
+			continue
+		}
+
+		name := openingFromHeaders(parsed.Headers)
+		opening, ok := openings[name]
+		if !ok {
+			opening = &repertoireOpening{
+				stat:  &models.PreparedOpening{Name: name},
+				lines: make(map[string]*repertoireLine),
+			}
+			openings[name] = opening
+		}
+		dossier.Games++
+		opening.stat.Games++
+		switch score {
+		case 1:
+			dossier.Wins++
+			opening.stat.Wins++
+		case 0.5:
+			dossier.Draws++
+			opening.stat.Draws++
+		default:
+			dossier.Losses++
+			opening.stat.Losses++
+		}
+
+		// Follow the game until the opponent's last move within the line limit
+		plies := min(len(parsed.Moves), prepLinePlies)
+		if plies > 0 && parsed.Moves[plies-1].Color != color.String() {
+			plies--
+		}
+		if plies == 0 {
+			continue
+		}
+		moves := make([]string, plies)
+		for i := range moves {
+			moves[i] = parsed.Moves[i].Move
+		}
+		key := strings.Join(moves, " ")
+		line, ok := opening.lines[key]
+		if !ok {
+			line = &repertoireLine{moves: moves, fen: parsed.Moves[plies-1].FEN}
+			opening.lines[key] = line
+		}
+		line.games++
+	}
+
+	if dossier.Games > 0 {
+		dossier.Score = percentageScore(dossier.Wins, dossier.Draws, dossier.Games)
+	}
+	for _, opening := range openings {
+		stat := opening.stat
+		stat.Share = float64(stat.Games) / float64(dossier.Games) * 100
+		stat.Score = percentageScore(stat.Wins, stat.Draws, stat.Games)
+		if line := mostPlayedLine(opening.lines); line != nil {
+			stat.Line, stat.LineGames, stat.FEN = line.moves, line.games, line.fen
+		}
+		dossier.Openings = append(dossier.Openings, *stat)
+	}
+	sort.Slice(dossier.Openings, func(i, j int) bool {
+		if dossier.Openings[i].Games != dossier.Openings[j].Games {
+			return dossier.Openings[i].Games > dossier.Openings[j].Games
+		}
+		return dossier.Openings[i].Name < dossier.Openings[j].Name
+	})
+
+	return dossier
+}
+
+// mostPlayedLine returns the line played in the most games, breaking ties by the move sequence
+func mostPlayedLine(lines map[string]*repertoireLine) *repertoireLine {
+	var best *repertoireLine
+	var bestKey string
+	for key, line := range lines {
+		if best == nil || line.games > best.games || (line.games == best.games && key < bestKey) {
+			best, bestKey = line, key
+		}
+	}
+	return best
+}
+
+// percentageScore converts results to a score percentage
+func percentageScore(wins, draws, games int) float64 {
+	return (float64(wins) + 0.5*float64(draws)) / float64(games) * 100
+}
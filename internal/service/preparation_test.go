@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/board"
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+func TestAnalyticsService_BuildRepertoire(t *testing.T) {
+	analytics := NewAnalyticsService(NewGameAnalyzerService(), nil)
+	sicilian := "https://www.chess.com/openings/Sicilian-Defense"
+	game := func(white, black, eco, result, moves string) *models.GameInfo {
+		return &models.GameInfo{
+			WhitePlayer: models.Player{Username: white},
+			BlackPlayer: models.Player{Username: black},
+			PGN:         `[ECOUrl "` + eco + `"]` + "\n[Result \"" + result + "\"]\n\n" + moves + " " + result,
+		}
+	}
+
+	games := []*models.GameInfo{
+		game("Bob", "Alice", sicilian, "0-1", "1. e4 c5 2. Nf3 d6 3. d4 cxd4 4. Nxd4 Nf6 5. Nc3 a6 6. Be2"),
+		game("Carol", "Alice", sicilian, "1-0", "1. e4 c5 2. Nf3 d6 3. d4 cxd4 4. Nxd4 Nf6 5. Nc3 a6 6. Bg5"),
+		game("Dave", "Alice", sicilian, "1/2-1/2", "1. e4 c5 2. Nc3 Nc6"),
+		game("Erin", "Alice", "https://www.chess.com/openings/Queens-Gambit-Declined", "0-1", "1. d4 d5 2. c4 e6"),
+		game("Alice", "Bob", sicilian, "1-0", "1. e4 c5"), // Alice had White
+		game("Frank", "Alice", sicilian, "*", "1. e4 c5"), // Unfinished
+	}
+
+	dossier := analytics.buildRepertoire(games, "alice", board.Black)
+	if dossier.Games != 4 || dossier.Wins != 2 || dossier.Draws != 1 || dossier.Losses != 1 || dossier.Score != 62.5 {
+		t.Fatalf("Unexpected totals: %+v", dossier)
+	}
+	if len(dossier.Openings) != 2 || dossier.Openings[0].Name != "Sicilian Defense" {
+		t.Fatalf("Expected the Sicilian first, got %+v", dossier.Openings)
+	}
+
+	sicilianStat := dossier.Openings[0]
+	if sicilianStat.Games != 3 || sicilianStat.Share != 75 || sicilianStat.Score != 50 {
+		t.Errorf("Unexpected Sicilian statistics: %+v", sicilianStat)
+	}
+	// The line stops after Black's fifth move, before the games diverge on White's sixth
+	wantLine := []string{"e4", "c5", "Nf3", "d6", "d4", "cxd4", "Nxd4", "Nf6", "Nc3", "a6"}
+	if !reflect.DeepEqual(sicilianStat.Line, wantLine) || sicilianStat.LineGames != 2 {
+		t.Errorf("Line = %v (%d games), want %v", sicilianStat.Line, sicilianStat.LineGames, wantLine)
+	}
+	if sicilianStat.FEN != "rnbqkb1r/1p2pppp/p2p1n2/8/3NP3/2N5/PPP2PPP/R1BQKB1R w KQkq - 0 6" {
+		t.Errorf("FEN = %q, want the position with White to move", sicilianStat.FEN)
+	}
+
+	if qgd := dossier.Openings[1]; !reflect.DeepEqual(qgd.Line, []string{"d4", "d5", "c4", "e6"}) || qgd.Score != 100 {
+		t.Errorf("Unexpected Queen's Gambit statistics: %+v", qgd)
+	}
+}
+
+func TestAnalyticsService_PrepareAgainstValidation(t *testing.T) {
+	analytics := NewAnalyticsService(NewGameAnalyzerService(), nil)
+
+	requests := []models.PreparationRequest{
+		{Color: "white"},
+		{Opponent: "alice", Color: "red"},
+	}
+	for _, request := range requests {
+		var validation *errors.ValidationError
+		if _, err := analytics.PrepareAgainst(context.Background(), &request); !errors.As(err, &validation) {
+			t.Errorf("Expected a validation error for %+v, got %v", request, err)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+)
+
+// analysisProfiles holds the built-in named analysis profiles. Searches with a time limit run for
+// that long whatever the depth, so each profile sets its own.
+var analysisProfiles = map[string]models.EngineSettings{
+	"fast":     {Depth: 10, TimeLimit: 1000, MultiPV: 1},
+	"standard": {Depth: 15, TimeLimit: 5000, MultiPV: 1},
+	"deep":     {Depth: 22, TimeLimit: 15000, MultiPV: 3},
+}
+
+// IsValidProfile reports whether a named analysis profile exists
+func IsValidProfile(name string) bool {
+	_, ok := analysisProfiles[name]
+	return ok
+}
+
+// ApplyProfile fills settings left unset in the request from the named profile
+func ApplyProfile(settings *models.EngineSettings, profile string) error {
+	if profile == "" {
+		return nil
+	}
+
+	preset, ok := analysisProfiles[profile]
+	if !ok {
+		return fmt.Errorf("unknown analysis profile: %s", profile)
+	}
+
+	if settings.Depth == 0 {
+		settings.Depth = preset.Depth
+	}
+	if settings.TimeLimit == 0 {
+		settings.TimeLimit = preset.TimeLimit
+	}
+	if settings.MultiPV == 0 {
+		settings.MultiPV = preset.MultiPV
+	}
+
+	return nil
+}
@@ -0,0 +1,41 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+)
+
+func TestApplyProfile(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings models.EngineSettings
+		profile  string
+		want     models.EngineSettings
+	}{
+		{"fast", models.EngineSettings{}, "fast", models.EngineSettings{Depth: 10, TimeLimit: 1000, MultiPV: 1}},
+		{"standard", models.EngineSettings{}, "standard", models.EngineSettings{Depth: 15, TimeLimit: 5000, MultiPV: 1}},
+		{"deep", models.EngineSettings{}, "deep", models.EngineSettings{Depth: 22, TimeLimit: 15000, MultiPV: 3}},
+		{"request settings win", models.EngineSettings{TimeLimit: 200, MultiPV: 2}, "deep", models.EngineSettings{Depth: 22, TimeLimit: 200, MultiPV: 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := tt.settings
+			if err := ApplyProfile(&settings, tt.profile); err != nil {
+				t.Fatalf("ApplyProfile() error = %v", err)
+			}
+			// The defaults must not overwrite what the profile chose; the time limit decides how
+			// long the engine searches
+			ApplyDefaultSettings(&settings)
+			if settings.Depth != tt.want.Depth || settings.TimeLimit != tt.want.TimeLimit || settings.MultiPV != tt.want.MultiPV {
+				t.Errorf("Settings = depth %d, time limit %d, multipv %d, want depth %d, time limit %d, multipv %d",
+					settings.Depth, settings.TimeLimit, settings.MultiPV, tt.want.Depth, tt.want.TimeLimit, tt.want.MultiPV)
+			}
+		})
+	}
+
+	if err := ApplyProfile(&models.EngineSettings{}, "unknown"); err == nil {
+		t.Error("ApplyProfile() with an unknown profile succeeded, want an error")
+	}
+}
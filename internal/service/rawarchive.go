@@ -0,0 +1,216 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/blob"
+	"github.com/pedrampdd/ChessAnalyser/internal/client"
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/internal/storage"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+// rawArchiveIndexKey is the blob the snapshot index is kept in next to the snapshots, so they
+// stay listed, and remain the source of their games, across restarts
+const rawArchiveIndexKey = "raw/chesscom/index.json"
+
+// rawArchiveIndexEntry is a snapshot in the index, with the games it contains
+type rawArchiveIndexEntry struct {
+	*models.RawArchive
+	GameURLs []string `json:"game_urls"`
+}
+
+// SetRawArchiveStore keeps every modified archive the sync fetches, byte for byte, in a blob store,
+// and loads the snapshots an earlier run kept there. Snapshots older than retention are deleted
+// once a newer snapshot has replaced them as the source of all their games; a retention of 0
+// keeps every snapshot. A nil store disables raw archiving.
+func (s *SyncService) SetRawArchiveStore(ctx context.Context, store blob.Store, retention time.Duration) error {
+	s.rawArchives = store
+	s.rawRetention = retention
+	if store == nil {
+		return nil
+	}
+
+	s.rawMu.Lock()
+	defer s.rawMu.Unlock()
+
+	body, err := store.Get(ctx, rawArchiveIndexKey)
+	if stderrors.Is(err, blob.ErrNotFound) {
+		return nil // Nothing kept yet
+	}
+	if err != nil {
+		return errors.NewStorageError("read raw archive index", err)
+	}
+	defer body.Close()
+
+	var index []rawArchiveIndexEntry
+	if err := json.NewDecoder(body).Decode(&index); err != nil {
+		return errors.NewStorageError("decode raw archive index", err)
+	}
+	players := map[string]bool{}
+	for _, entry := range index { // In fetch order, so each game's source is its latest snapshot
+		if entry.RawArchive == nil {
+			continue
+		}
+		entry.RawArchive.GameURLs = entry.GameURLs
+		s.store.SaveRawArchive(entry.RawArchive)
+		players[entry.Username] = true
+	}
+
+	pruned := false
+	for username := range players {
+		if s.pruneRawArchives(ctx, username) {
+			pruned = true
+		}
+	}
+	if pruned {
+		return s.saveRawArchiveIndex(ctx)
+	}
+	return nil
+}
+
+// saveRawArchive keeps a fetched archive in the blob store and records it as the source of its games
+func (s *SyncService) saveRawArchive(ctx context.Context, username string, year, month int,
+	archive *client.ArchiveResponse, games []*models.GameInfo) error {
+	if s.rawArchives == nil {
+		return nil
+	}
+
+	raw := &models.RawArchive{
+		Key: fmt.Sprintf("raw/chesscom/%s/%04d/%02d/%s.json", strings.ToLower(username), year, month,
+			archive.FetchedAt.UTC().Format("20060102T150405.000000000Z")),
+		Username:  strings.ToLower(username),
+		Year:      year,
+		Month:     month,
+		URL:       archive.URL,
+		ETag:      archive.ETag,
+		FetchedAt: archive.FetchedAt,
+		Size:      int64(len(archive.Body)),
+		Games:     len(games),
+	}
+	for _, game := range games {
+		raw.GameURLs = append(raw.GameURLs, game.URL)
+	}
+
+	if err := s.rawArchives.Put(ctx, raw.Key, archive.Body, "application/json"); err != nil {
+		return errors.NewStorageError("save raw archive", err)
+	}
+
+	s.rawMu.Lock()
+	defer s.rawMu.Unlock()
+	s.store.SaveRawArchive(raw)
+	s.pruneRawArchives(ctx, username)
+	return s.saveRawArchiveIndex(ctx)
+}
+
+// pruneRawArchives deletes a player's snapshots that outlived the retention and are no longer the
+// source of any game, reporting whether any were. The caller holds rawMu.
+func (s *SyncService) pruneRawArchives(ctx context.Context, username string) bool {
+	if s.rawRetention <= 0 {
+		return false
+	}
+	pruned := s.store.PruneRawArchives(username, s.now().Add(-s.rawRetention))
+	for _, archive := range pruned {
+		if err := s.rawArchives.Delete(ctx, archive.Key); err != nil {
+			log.Printf("Failed to delete raw archive %s: %v", archive.Key, err)
+		}
+	}
+	return len(pruned) > 0
+}
+
+// saveRawArchiveIndex writes the snapshot index to the blob store. The caller holds rawMu.
+func (s *SyncService) saveRawArchiveIndex(ctx context.Context) error {
+	archives := s.store.AllRawArchives()
+	index := make([]rawArchiveIndexEntry, 0, len(archives))
+	for _, archive := range archives {
+		index = append(index, rawArchiveIndexEntry{RawArchive: archive, GameURLs: archive.GameURLs})
+	}
+	data, err := json.Marshal(index)
+	if err != nil {
+		return errors.NewStorageError("encode raw archive index", err)
+	}
+	if err := s.rawArchives.Put(ctx, rawArchiveIndexKey, data, "application/json"); err != nil {
+		return errors.NewStorageError("save raw archive index", err)
+	}
+	return nil
+}
+
+// ListRawArchives returns a page of the raw archive snapshots kept for a player, oldest month first
+func (s *SyncService) ListRawArchives(username string, request models.PageRequest) (*models.Page[*models.RawArchive], error) {
+	if username == "" {
+		return nil, errors.NewValidationError("username", "username is required")
+	}
+	return storage.Paginate(storage.ListRawArchives, s.store.ListRawArchives(username), func(archive *models.RawArchive) string {
+		return fmt.Sprintf("%04d%02d|", archive.Year, archive.Month) + storage.TimeKey(archive.FetchedAt, archive.Key)
+	}, request)
+}
+
+// GetGameSource returns a stored game's entry in the latest raw archive that contained it
+func (s *SyncService) GetGameSource(ctx context.Context, username, gameURL string) (*models.GameSource, error) {
+	if gameURL == "" {
+		return nil, errors.NewValidationError("url", "game URL is required")
+	}
+	if s.rawArchives == nil {
+		return nil, errors.NewStorageError("read raw archive", fmt.Errorf("raw archiving is disabled"))
+	}
+
+	stored := false
+	for _, game := range s.store.GetGames(username) {
+		if game.URL == gameURL {
+			stored = true
+			break
+		}
+	}
+	if !stored {
+		return nil, errors.NewGameNotFoundError(gameURL, fmt.Errorf("not a stored game of %s", username))
+	}
+
+	archive := s.store.GetGameSource(gameURL)
+	if archive == nil {
+		return nil, errors.NewGameNotFoundError(gameURL, fmt.Errorf("no raw archive contains the game"))
+	}
+
+	body, err := s.rawArchives.Get(ctx, archive.Key)
+	if err != nil {
+		return nil, errors.NewStorageError("read raw archive", err)
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, errors.NewStorageError("read raw archive", err)
+	}
+
+	game, err := findRawGame(data, gameURL)
+	if err != nil {
+		return nil, errors.NewStorageError("decode raw archive", err)
+	}
+	return &models.GameSource{GameURL: gameURL, Archive: archive, Game: game}, nil
+}
+
+// findRawGame returns a game's entry in a raw monthly archive without re-encoding it
+func findRawGame(data []byte, gameURL string) (json.RawMessage, error) {
+	var archive struct {
+		Games []json.RawMessage `json:"games"`
+	}
+	if err := json.Unmarshal(data, &archive); err != nil {
+		return nil, err
+	}
+
+	for _, raw := range archive.Games {
+		var game struct {
+			URL string `json:"url"`
+		}
+		if err := json.Unmarshal(raw, &game); err == nil && game.URL == gameURL {
+			return raw, nil
+		}
+	}
+	return nil, fmt.Errorf("game %s is missing from archive", gameURL)
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+)
+
+// ReclassifyAnalysis re-derives the accuracy and classification of every move of a stored analysis
+// from the evaluations it already holds, so new thresholds or another accuracy model apply without
+// running the engine again. User classification overrides and comments are kept. Cached analyses
+// stay as they are, since they answer requests made with the original thresholds and model.
+func (s *AnalysisService) ReclassifyAnalysis(analysisID string, request *models.ReclassifyRequest) (*models.GameAnalysis, error) {
+	if err := validateThresholds(request.Thresholds); err != nil {
+		return nil, err
+	}
+	thresholds := models.DefaultClassificationThresholds()
+	if request.Thresholds != nil {
+		thresholds = *request.Thresholds
+	}
+	modelName, accuracyModel, err := s.requestAccuracyModel(&models.AnalysisRequest{AccuracyModel: request.AccuracyModel})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.store.UpdateAnalysis(analysisID, func(analysis *models.GameAnalysis) error {
+		s.reclassifyMoves(analysis, modelName, accuracyModel, thresholds)
+		return nil
+	})
+}
+
+// reclassifyMoves scores and classifies the moves of an analysis again and recomputes the game's
+// statistics from them
+func (s *AnalysisService) reclassifyMoves(analysis *models.GameAnalysis, modelName string, accuracyModel AccuracyModel,
+	thresholds models.ClassificationThresholds) {
+	var whiteBlunders, blackBlunders int
+	var whiteMistakes, blackMistakes int
+	var whiteInaccuracies, blackInaccuracies int
+	var whiteBestMoves, blackBestMoves int
+	analysis.Summary.Misses = 0
+
+	for i := range analysis.Moves {
+		move := &analysis.Moves[i]
+
+		// As when the game was analyzed: the previous ply's evaluation, level at the start, and
+		// no loss charged after a ply the engine skipped
+		var prev *models.MoveAnalysis
+		if i > 0 && analysis.Moves[i-1].MoveNumber == move.MoveNumber-1 {
+			prev = &analysis.Moves[i-1]
+		}
+		before := move.Evaluation
+		if prev != nil {
+			before = prev.Evaluation
+		} else if move.MoveNumber == 1 {
+			before = 0
+		}
+
+		color := moveColor(move.FEN, move.MoveNumber)
+		move.Accuracy = accuracyModel.MoveAccuracy(moveEvaluation(before, move.Evaluation, color))
+		move.Blunder = move.Accuracy < thresholds.Blunder
+		move.Mistake = move.Accuracy >= thresholds.Blunder && move.Accuracy < thresholds.Mistake
+		move.Inaccuracy = move.Accuracy >= thresholds.Mistake && move.Accuracy < thresholds.Inaccuracy
+		if move.ScoreBound != "" {
+			move.Blunder, move.Mistake, move.Inaccuracy = false, false, false
+		}
+
+		// Whether a move is a miss depends on the opponent's move being an error
+		move.Miss = isMiss(prev, move, move.HumanProbability, move.HumanProbability > 0)
+		if move.Miss {
+			analysis.Summary.Misses++
+		}
+
+		// The classification decides which moves are drawn. The position before a ply is only
+		// known from the previous one, so the first ply keeps its drawing while it stays a key moment.
+		if prev != nil {
+			move.Board = annotateBoard(prev.FEN, move, prev.BestMove)
+		} else if !isBoardMoment(move) {
+			move.Board = nil
+		}
+
+		if color == "white" {
+			if move.Blunder {
+				whiteBlunders++
+			} else if move.Mistake {
+				whiteMistakes++
+			} else if move.Inaccuracy {
+				whiteInaccuracies++
+			} else if move.Accuracy >= 95 {
+				whiteBestMoves++
+			}
+		} else {
+			if move.Blunder {
+				blackBlunders++
+			} else if move.Mistake {
+				blackMistakes++
+			} else if move.Inaccuracy {
+				blackInaccuracies++
+			} else if move.Accuracy >= 95 {
+				blackBestMoves++
+			}
+		}
+	}
+
+	analysis.Accuracy.Model = modelName
+	if len(analysis.Moves) > 0 {
+		applyExpectedPoints(analysis)
+	}
+	s.calculateGameStatistics(analysis, tallyAccuracy(analysis.Moves), analysis.Summary.NodesSearched, analysis.Summary.TotalTime,
+		whiteBlunders, blackBlunders, whiteMistakes, blackMistakes,
+		whiteInaccuracies, blackInaccuracies, whiteBestMoves, blackBestMoves)
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+)
+
+func TestAnalysisService_ReclassifyAnalysis(t *testing.T) {
+	service := newTestAnalysisService()
+
+	id, err := service.store.SaveAnalysis(&models.GameAnalysis{
+		Accuracy: models.GameAccuracy{Model: models.AccuracyModelLegacy},
+		Moves: []models.MoveAnalysis{
+			{Move: "e4", MoveNumber: 1, Evaluation: 0.2, Accuracy: 98},
+			{Move: "f6", MoveNumber: 2, Evaluation: 2.2, Accuracy: 67, Blunder: true, ClassificationOverride: "blunder"},
+			{Move: "Nf3", MoveNumber: 3, Evaluation: 0.2, Accuracy: 98, UserComment: "Missed Qh5+"},
+		},
+		Summary: models.AnalysisSummary{TotalTime: 1200},
+	})
+	if err != nil {
+		t.Fatalf("SaveAnalysis() error = %v", err)
+	}
+
+	// The linear model charges 10 points per pawn lost: f6 and Nf3 each lose 2 pawns
+	thresholds := &models.ClassificationThresholds{Blunder: 50, Mistake: 85, Inaccuracy: 95}
+	analysis, err := service.ReclassifyAnalysis(id, &models.ReclassifyRequest{
+		Thresholds:    thresholds,
+		AccuracyModel: models.AccuracyModelLinear,
+	})
+	if err != nil {
+		t.Fatalf("ReclassifyAnalysis() error = %v", err)
+	}
+	for _, ply := range []int{1, 2} {
+		move := analysis.Moves[ply]
+		if move.Accuracy != 80 || !move.Mistake || move.Blunder || move.Inaccuracy {
+			t.Errorf("Ply %d = %+v, want an 80%% mistake", move.MoveNumber, move)
+		}
+	}
+	if analysis.Accuracy.Model != models.AccuracyModelLinear || analysis.Accuracy.Mistakes != 2 ||
+		analysis.Accuracy.Blunders != 0 || analysis.Accuracy.BlackAccuracy != 80 || analysis.Summary.TotalTime != 1200 {
+		t.Errorf("Unexpected game statistics: %+v %+v", analysis.Accuracy, analysis.Summary)
+	}
+	if analysis.Moves[1].ClassificationOverride != "blunder" || analysis.Moves[2].UserComment != "Missed Qh5+" {
+		t.Error("Expected user edits to be kept")
+	}
+	if stored, _ := service.GetAnalysis(id); stored.Accuracy.Mistakes != 2 {
+		t.Error("Expected the stored analysis to be replaced")
+	}
+
+	// Default thresholds call an 80% move an inaccuracy
+	analysis, err = service.ReclassifyAnalysis(id, &models.ReclassifyRequest{AccuracyModel: models.AccuracyModelLinear})
+	if err != nil {
+		t.Fatalf("ReclassifyAnalysis() error = %v", err)
+	}
+	if analysis.Accuracy.Inaccuracies != 2 || analysis.Accuracy.Mistakes != 0 {
+		t.Errorf("Expected two inaccuracies with the default thresholds, got %+v", analysis.Accuracy)
+	}
+
+	if _, err := service.ReclassifyAnalysis(id, &models.ReclassifyRequest{AccuracyModel: "unknown"}); err == nil {
+		t.Error("Expected an unknown accuracy model to be rejected")
+	}
+	if _, err := service.ReclassifyAnalysis(id, &models.ReclassifyRequest{
+		Thresholds: &models.ClassificationThresholds{Blunder: 90, Mistake: 50, Inaccuracy: 95},
+	}); err == nil {
+		t.Error("Expected unordered thresholds to be rejected")
+	}
+	if _, err := service.ReclassifyAnalysis("missing", &models.ReclassifyRequest{}); err == nil {
+		t.Error("Expected a missing analysis to be reported")
+	}
+}
+
+func TestAnalysisService_ReclassifyAnalysisSkipsScoreBounds(t *testing.T) {
+	service := newTestAnalysisService()
+
+	id, err := service.store.SaveAnalysis(&models.GameAnalysis{
+		Moves: []models.MoveAnalysis{
+			{Move: "e4", MoveNumber: 1, Evaluation: 0.2},
+			{Move: "e5", MoveNumber: 2, Evaluation: 4.0, ScoreBound: models.ScoreLowerBound},
+		},
+	})
+	if err != nil {
+		t.Fatalf("SaveAnalysis() error = %v", err)
+	}
+
+	analysis, err := service.ReclassifyAnalysis(id, &models.ReclassifyRequest{AccuracyModel: models.AccuracyModelLinear})
+	if err != nil {
+		t.Fatalf("ReclassifyAnalysis() error = %v", err)
+	}
+	if move := analysis.Moves[1]; move.Blunder || move.Mistake || move.Inaccuracy {
+		t.Errorf("A move evaluated with only a bound was classified: %+v", move)
+	}
+}
@@ -0,0 +1,236 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/board"
+	"github.com/pedrampdd/ChessAnalyser/internal/client"
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/internal/parser"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+// RepairGame fills in a game's missing PGN or FEN. A missing PGN is rebuilt from the game's moves
+// re-fetched through Chess.com's callback endpoint; a missing FEN is the PGN's final position.
+// Games that can't be repaired fail with an UnanalyzableGameError carrying the reason.
+func (s *GameAnalyzerService) RepairGame(game *models.GameInfo) (*models.GameInfo, error) {
+	if game.Rules != "" && game.Rules != "chess" {
+		return nil, errors.NewUnanalyzableGameError(game.URL, models.UnanalyzableVariant, nil)
+	}
+
+	repaired := *game
+	repaired.UnanalyzableReason = ""
+	if repaired.PGN == "" {
+		pgn, err := s.refetchPGN(game.URL)
+		if err != nil {
+			return nil, err
+		}
+		repaired.PGN = pgn
+	}
+
+	if repaired.FEN == "" {
+		parsed, err := s.pgnParser.ParsePGN(repaired.PGN)
+		if err == nil {
+			err = s.pgnParser.ExtractPositions(parsed)
+		}
+		if err != nil {
+			return nil, errors.NewUnanalyzableGameError(game.URL, models.UnanalyzableInvalidMoves, err)
+		}
+		if len(parsed.Moves) == 0 {
+			return nil, errors.NewUnanalyzableGameError(game.URL, models.UnanalyzableNoMoves, nil)
+		}
+		repaired.FEN = parsed.Moves[len(parsed.Moves)-1].FEN
+	}
+
+	return &repaired, nil
+}
+
+// refetchPGN rebuilds a game's PGN from the callback endpoint's headers and TCN move list
+func (s *GameAnalyzerService) refetchPGN(url string) (string, error) {
+	gameType, gameID, ok := parseGameURL(url)
+	if !ok {
+		return "", errors.NewUnanalyzableGameError(url, models.UnanalyzableUnrecognized, nil)
+	}
+
+	game, err := s.chessAPI.GetCallbackGame(gameType, gameID)
+	if err == client.ErrGameNotFound {
+		return "", errors.NewUnanalyzableGameError(url, models.UnanalyzableNotFound, nil)
+	}
+	if err != nil {
+		return "", errors.NewAPIError("failed to re-fetch game", err)
+	}
+
+	headers, moves, err := decodeCallbackGame(url, game)
+	if err != nil {
+		return "", err
+	}
+	if len(moves) == 0 {
+		return "", errors.NewUnanalyzableGameError(url, models.UnanalyzableNoMoves, nil)
+	}
+
+	return callbackGamePGN(s.pgnParser, headers, moves), nil
+}
+
+// decodeCallbackGame returns a callback game's headers as strings and replays its TCN move list
+func decodeCallbackGame(url string, game *client.CallbackGame) (map[string]string, []parser.ParsedMove, error) {
+	uciMoves, err := client.DecodeTCN(game.MoveList)
+	if err != nil {
+		return nil, nil, errors.NewUnanalyzableGameError(url, models.UnanalyzableInvalidMoves, err)
+	}
+
+	headers := make(map[string]string, len(game.PGNHeaders))
+	for name, value := range game.PGNHeaders {
+		headers[name] = fmt.Sprint(value)
+	}
+	startFEN := board.StartFEN
+	if fen := headers["FEN"]; fen != "" {
+		startFEN = fen
+	}
+
+	moves, err := replayUCI(startFEN, uciMoves)
+	if err != nil {
+		return nil, nil, errors.NewUnanalyzableGameError(url, models.UnanalyzableInvalidMoves, err)
+	}
+	return headers, moves, nil
+}
+
+// replayUCI plays UCI moves from a position, returning them as SAN with the position after each
+func replayUCI(fen string, uciMoves []string) ([]parser.ParsedMove, error) {
+	b, err := board.FromFEN(fen)
+	if err != nil {
+		return nil, err
+	}
+
+	moves := make([]parser.ParsedMove, 0, len(uciMoves))
+	for _, uci := range uciMoves {
+		move, err := b.ParseUCI(uci)
+		if err != nil {
+			return nil, fmt.Errorf("move %d (%s): %w", len(moves)+1, uci, err)
+		}
+
+		parsed := parser.ParsedMove{MoveNumber: b.FullMoves(), Move: b.SAN(move), Color: b.Turn().String()}
+		b.Apply(move)
+		parsed.FEN = b.FEN()
+		moves = append(moves, parsed)
+	}
+	return moves, nil
+}
+
+// callbackGamePGN renders a re-fetched game as PGN, keeping every header Chess.com sent
+func callbackGamePGN(pgnParser *parser.PGNParser, headers map[string]string, moves []parser.ParsedMove) string {
+	game := &parser.ParsedGame{Headers: make(map[string]string), Moves: moves, Result: headers["Result"]}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var tags strings.Builder
+	for _, name := range names {
+		game.Headers[strings.ToLower(name)] = headers[name]
+		fmt.Fprintf(&tags, "[%s \"%s\"]\n", name, strings.ReplaceAll(headers[name], `"`, `'`))
+	}
+	game.PGN = tags.String() // FormatPGN copies extra tags from the PGN's tag section
+
+	return pgnParser.FormatPGN(game)
+}
+
+// parseGameURL extracts the game type (live or daily) and numeric ID from a Chess.com game URL,
+// e.g. https://www.chess.com/game/live/123 or https://www.chess.com/daily/game/456
+func parseGameURL(url string) (string, string, bool) {
+	parts := strings.Split(strings.Trim(url, "/"), "/")
+	if len(parts) < 3 {
+		return "", "", false
+	}
+
+	gameID := parts[len(parts)-1]
+	if _, err := strconv.ParseUint(gameID, 10, 64); err != nil {
+		return "", "", false
+	}
+
+	for _, part := range parts[len(parts)-3 : len(parts)-1] {
+		if part == "live" || part == "daily" {
+			return part, gameID, true
+		}
+	}
+	return "", "", false
+}
+
+// RepairGames repairs a player's stored games that lack a PGN or FEN. Games that can't be
+// repaired are marked unanalyzable and skipped by later runs; games whose re-fetch failed are
+// retried. One bad game never fails the rest of the batch.
+func (s *SyncService) RepairGames(ctx context.Context, username string) (*models.RepairReport, error) {
+	if username == "" {
+		return nil, errors.NewValidationError("username", "username is required")
+	}
+
+	release, err := s.claimPlayer(username)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	report := &models.RepairReport{Username: strings.ToLower(username), Games: []models.RepairedGame{}}
+	for _, game := range s.store.GetGames(username) {
+		if !needsRepair(game) {
+			continue
+		}
+		if ctx.Err() != nil {
+			return report, ctx.Err()
+		}
+
+		outcome, repaired := s.repairGame(username, game)
+		report.Checked++
+		switch outcome.Status {
+		case models.RepairStatusRepaired:
+			report.Repaired++
+			s.queueAnalysis(repaired)
+		case models.RepairStatusUnanalyzable:
+			report.Unanalyzable++
+		default:
+			report.Failed++
+		}
+		report.Games = append(report.Games, outcome)
+	}
+
+	if state := s.store.GetSyncState(username); state != nil {
+		state.GamesRepaired += report.Repaired
+		state.Unanalyzable += report.Unanalyzable
+		s.store.SaveSyncState(state)
+	}
+	return report, nil
+}
+
+// repairGame repairs one stored game, saving the repaired game or its unanalyzable reason. It
+// returns the outcome and the game as it is now stored.
+func (s *SyncService) repairGame(username string, game *models.GameInfo) (models.RepairedGame, *models.GameInfo) {
+	outcome := models.RepairedGame{URL: game.URL}
+
+	repaired, err := s.gameService.RepairGame(game)
+	var unanalyzable *errors.UnanalyzableGameError
+	switch {
+	case err == nil:
+		outcome.Status = models.RepairStatusRepaired
+		s.store.ReplaceGame(username, repaired)
+		return outcome, repaired
+	case errors.As(err, &unanalyzable):
+		outcome.Status, outcome.Reason = models.RepairStatusUnanalyzable, unanalyzable.Reason
+		marked := *game
+		marked.UnanalyzableReason = unanalyzable.Reason
+		s.store.ReplaceGame(username, &marked)
+		return outcome, &marked
+	default:
+		outcome.Status, outcome.Reason = models.RepairStatusFailed, err.Error()
+		return outcome, game
+	}
+}
+
+// needsRepair reports whether a stored game lacks the data analysis needs and hasn't been given up on
+func needsRepair(game *models.GameInfo) bool {
+	return (game.PGN == "" || game.FEN == "") && game.UnanalyzableReason == ""
+}
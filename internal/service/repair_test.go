@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/internal/storage"
+)
+
+func TestSyncService_RepairGames(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/callback/live/game/1":
+			fmt.Fprint(w, `{"game": {"moveList": "mC0K", "isFinished": true,
+				"pgnHeaders": {"Event": "Live Chess", "White": "alice", "Black": "bob", "Result": "1-0", "WhiteElo": 1500}}}`)
+		case "/callback/live/game/2":
+			fmt.Fprint(w, `{"game": {"moveList": "", "isFinished": true, "pgnHeaders": {"Result": "*"}}}`)
+		case "/callback/daily/game/3":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	gameService := NewGameAnalyzerService()
+	gameService.chessAPI.CallbackURL = server.URL + "/callback"
+	store := storage.NewMemoryStore()
+	sync := NewSyncService(gameService, nil, store, nil, time.Minute, false)
+
+	start := time.Unix(1700000000, 0)
+	store.SaveGames("alice", []*models.GameInfo{
+		{URL: "https://www.chess.com/game/live/1", Rules: "chess", StartTime: start},
+		{URL: "https://www.chess.com/game/live/2", Rules: "chess", StartTime: start.Add(time.Minute)},
+		{URL: "https://www.chess.com/game/daily/3", Rules: "chess", StartTime: start.Add(2 * time.Minute)},
+		{URL: "https://www.chess.com/game/live/4", Rules: "chess960", StartTime: start.Add(3 * time.Minute)},
+		{URL: "https://www.chess.com/game/live/5", Rules: "chess", PGN: annotationsTestPGN, StartTime: start.Add(4 * time.Minute)},
+		{URL: "https://www.chess.com/game/live/6", Rules: "chess", StartTime: start.Add(5 * time.Minute)},
+		{URL: "https://www.chess.com/game/live/7", Rules: "chess", PGN: annotationsTestPGN, FEN: "8/8/8/8/8/8/8/8 w - - 0 1", StartTime: start.Add(6 * time.Minute)},
+	})
+
+	report, err := sync.RepairGames(context.Background(), "alice")
+	if err != nil {
+		t.Fatalf("RepairGames() error = %v", err)
+	}
+	if report.Checked != 6 || report.Repaired != 2 || report.Unanalyzable != 3 || report.Failed != 1 {
+		t.Errorf("Unexpected repair report: %+v", report)
+	}
+
+	want := map[string]string{
+		"https://www.chess.com/game/live/2":  models.UnanalyzableNoMoves,
+		"https://www.chess.com/game/daily/3": "",
+		"https://www.chess.com/game/live/4":  models.UnanalyzableVariant,
+		"https://www.chess.com/game/live/6":  models.UnanalyzableNotFound,
+	}
+	page, err := sync.GetSyncedGames("alice", models.PageRequest{})
+	if err != nil {
+		t.Fatalf("GetSyncedGames() error = %v", err)
+	}
+	games := page.Items
+	for _, game := range games {
+		if reason, ok := want[game.URL]; ok && game.UnanalyzableReason != reason {
+			t.Errorf("%s: reason = %q, want %q", game.URL, game.UnanalyzableReason, reason)
+		}
+	}
+
+	repaired := games[0]
+	for _, fragment := range []string{`[White "alice"]`, `[WhiteElo "1500"]`, "1. e4 e5 1-0"} {
+		if !strings.Contains(repaired.PGN, fragment) {
+			t.Errorf("Expected the rebuilt PGN to contain %q, got:\n%s", fragment, repaired.PGN)
+		}
+	}
+	if repaired.FEN != "rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq e6 0 2" {
+		t.Errorf("Unexpected FEN of the repaired game: %s", repaired.FEN)
+	}
+	if games[4].FEN == "" {
+		t.Error("Expected the missing FEN to be taken from the PGN")
+	}
+
+	// Only the game whose re-fetch failed is tried again
+	if report, _ = sync.RepairGames(context.Background(), "alice"); report.Checked != 1 || report.Games[0].Status != models.RepairStatusFailed {
+		t.Errorf("Expected only the failed game to be retried, got %+v", report)
+	}
+}
+
+func TestSyncService_SyncPlayerSkipsUnanalyzable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/player/alice/games/archives":
+			fmt.Fprintf(w, `{"archives": ["%s/player/alice/games/2023/11"]}`, "https://api.chess.com/pub")
+		case "/player/alice/games/2023/11":
+			// The first game has an illegal move and no FEN, so repairing it marks it unanalyzable
+			fmt.Fprint(w, `{"games": [
+				{"url": "https://www.chess.com/game/live/1", "rules": "chess", "start_time": 1700000000, "pgn": "1. e4 e9 *"},
+				{"url": "https://www.chess.com/game/live/2", "rules": "chess", "start_time": 1700001000, "pgn": "1. e4 e5 *", "fen": "rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq e6 0 2"}
+			]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	gameService := NewGameAnalyzerService()
+	gameService.chessAPI.BaseURL = server.URL
+	sync := NewSyncService(gameService, nil, storage.NewMemoryStore(), []string{"alice"}, time.Minute, true)
+
+	state, err := sync.SyncPlayer(context.Background(), "alice")
+	if err != nil {
+		t.Fatalf("SyncPlayer() error = %v", err)
+	}
+	if state.GamesSynced != 2 || state.Unanalyzable != 1 || state.AnalysesQueued != 1 {
+		t.Errorf("Unexpected state: %+v", state)
+	}
+	if queued := <-sync.analysisQueue; queued.URL != "https://www.chess.com/game/live/2" {
+		t.Errorf("Queued %s, want only the analyzable game", queued.URL)
+	}
+}
+
+func TestParseGameURL(t *testing.T) {
+	tests := []struct {
+		url      string
+		wantType string
+		wantID   string
+		wantOK   bool
+	}{
+		{"https://www.chess.com/game/live/123", "live", "123", true},
+		{"https://www.chess.com/game/daily/456/", "daily", "456", true},
+		{"https://www.chess.com/live/game/789", "live", "789", true},
+		{"https://www.chess.com/game/live/abc", "", "", false},
+		{"https://www.chess.com/analysis/123", "", "", false},
+	}
+
+	for _, tt := range tests {
+		gameType, gameID, ok := parseGameURL(tt.url)
+		if gameType != tt.wantType || gameID != tt.wantID || ok != tt.wantOK {
+			t.Errorf("parseGameURL(%q) = %q, %q, %v", tt.url, gameType, gameID, ok)
+		}
+	}
+}
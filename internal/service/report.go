@@ -0,0 +1,238 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/board"
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+// replayedMove is a move of an exported game and the position it leads to
+type replayedMove struct {
+	SAN      string
+	Move     board.Move
+	Board    board.Board
+	Analysis *models.MoveAnalysis // nil when the move wasn't analyzed
+}
+
+// replayGame plays the moves of an analyzed game from its starting position, pairing every move
+// with its analysis. Exports that draw boards use it; games whose moves can't be replayed on a
+// standard board are rejected.
+func (s *AnalysisService) replayGame(analysis *models.GameAnalysis) (map[string]string, board.Board, []replayedMove, error) {
+	game, err := s.pgnParser.ParsePGN(analysis.PGN)
+	if err != nil {
+		return nil, board.Board{}, nil, errors.NewAPIError("failed to parse analyzed PGN", err)
+	}
+
+	startFEN := board.StartFEN
+	if fen := game.Headers["fen"]; fen != "" {
+		startFEN = fen
+	}
+	start, err := board.FromFEN(startFEN)
+	if err != nil {
+		return nil, board.Board{}, nil, errors.NewValidationError("format", "the game's starting position can't be drawn")
+	}
+
+	position := *start
+	moves := make([]replayedMove, 0, len(game.Moves))
+	for i, parsed := range game.Moves {
+		move, err := position.ParseSAN(parsed.Move)
+		if err != nil {
+			return nil, board.Board{}, nil, errors.NewValidationError("format", fmt.Sprintf("move %s can't be drawn: %v", parsed.Move, err))
+		}
+		position.Apply(move)
+
+		replayed := replayedMove{SAN: parsed.Move, Move: move, Board: position}
+		if idx := findMoveIndex(analysis.Moves, i+1); idx != -1 {
+			replayed.Analysis = &analysis.Moves[idx]
+		}
+		moves = append(moves, replayed)
+	}
+
+	return game.Headers, *start, moves, nil
+}
+
+// displaySquares returns the squares of a board row by row from the top, as seen by the side
+// at the bottom of the board
+func displaySquares(orientation string) [8][8]board.Square {
+	var rows [8][8]board.Square
+	for row := 0; row < 8; row++ {
+		for col := 0; col < 8; col++ {
+			if orientation == OrientationBlack {
+				rows[row][col] = board.NewSquare(7-col, row)
+			} else {
+				rows[row][col] = board.NewSquare(col, 7-row)
+			}
+		}
+	}
+	return rows
+}
+
+// pieceGlyphs are the Unicode chess symbols of each piece type, indexed by color
+var pieceGlyphs = [2][7]string{
+	board.White: {"", "♙", "♘", "♗", "♖", "♕", "♔"},
+	board.Black: {"", "♟", "♞", "♝", "♜", "♛", "♚"},
+}
+
+// reportSquare is a square of a board diagram in an HTML report
+type reportSquare struct {
+	Glyph     string
+	Light     bool
+	Highlight bool // Origin or destination of the move leading to the position
+}
+
+// reportBoard is a board diagram in an HTML report
+type reportBoard struct {
+	Caption string
+	Rows    [8][8]reportSquare
+}
+
+// reportMove is a row of the move table in an HTML report
+type reportMove struct {
+	Number         string
+	SAN            string
+	Evaluation     string
+	BestMove       string
+	Classification string
+}
+
+// reportData is what the HTML report template renders
+type reportData struct {
+	Title    string
+	Subtitle string
+	Accuracy models.GameAccuracy
+	Moves    []reportMove
+	Boards   []reportBoard
+}
+
+// reportTemplate renders an analysis as a standalone HTML page
+var reportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table.moves { border-collapse: collapse; }
+table.moves td, table.moves th { padding: 0.2em 0.8em; border-bottom: 1px solid #ddd; text-align: left; }
+tr.blunder { background: #f8d0d0; } tr.mistake { background: #fbe3c8; } tr.inaccuracy { background: #fbf3c8; } tr.miss { background: #e3dcf5; }
+figure { display: inline-block; margin: 0 1.5em 1.5em 0; }
+table.board { border-collapse: collapse; border: 2px solid #444; }
+table.board td { width: 40px; height: 40px; font-size: 32px; text-align: center; vertical-align: middle; padding: 0; }
+td.light { background: #eeeed2; } td.dark { background: #769656; } td.light.last { background: #f6f669; } td.dark.last { background: #baca2b; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{if .Subtitle}}<p>{{.Subtitle}}</p>{{end}}
+<h2>Accuracy</h2>
+<p>White {{printf "%.1f" .Accuracy.WhiteAccuracy}}% · Black {{printf "%.1f" .Accuracy.BlackAccuracy}}% · {{.Accuracy.Blunders}} blunders, {{.Accuracy.Mistakes}} mistakes, {{.Accuracy.Inaccuracies}} inaccuracies</p>
+<h2>Key positions</h2>
+{{range .Boards}}<figure>
+<table class="board">
+{{range .Rows}}<tr>{{range .}}<td class="{{if .Light}}light{{else}}dark{{end}}{{if .Highlight}} last{{end}}">{{.Glyph}}</td>{{end}}</tr>
+{{end}}</table>
+<figcaption>{{.Caption}}</figcaption>
+</figure>
+{{end}}
+<h2>Moves</h2>
+<table class="moves">
+<tr><th>Move</th><th></th><th>Evaluation</th><th>Best move</th><th>Classification</th></tr>
+{{range .Moves}}<tr{{if .Classification}} class="{{.Classification}}"{{end}}><td>{{.Number}}</td><td>{{.SAN}}</td><td>{{.Evaluation}}</td><td>{{.BestMove}}</td><td>{{.Classification}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// exportHTML renders an analysis as an HTML report: the accuracy, a diagram of every flagged
+// move and of the final position, drawn from the orientation's side, and the annotated moves
+func (s *AnalysisService) exportHTML(analysis *models.GameAnalysis, orientation string) ([]byte, error) {
+	headers, start, moves, err := s.replayGame(analysis)
+	if err != nil {
+		return nil, err
+	}
+
+	data := reportData{
+		Title:    fmt.Sprintf("%s vs %s", headerOr(headers, "white", "White"), headerOr(headers, "black", "Black")),
+		Subtitle: headers["event"],
+		Accuracy: analysis.Accuracy,
+	}
+	if result := headers["result"]; result != "" {
+		data.Title += " (" + result + ")"
+	}
+
+	for i, move := range moves {
+		row := reportMove{Number: moveNumberLabel(start, i), SAN: move.SAN}
+		if move.Analysis != nil {
+			row.Evaluation = formatEvaluation(move.Analysis.Evaluation)
+			row.BestMove = move.Analysis.BestMove
+			row.Classification = engineClassification(*move.Analysis)
+			if row.Classification != "" {
+				caption := fmt.Sprintf("%s %s (%s)", row.Number, move.SAN, row.Classification)
+				data.Boards = append(data.Boards, newReportBoard(move.Board, move.Move, true, orientation, caption))
+			}
+		}
+		data.Moves = append(data.Moves, row)
+	}
+
+	final := reportBoard{}
+	if len(moves) > 0 {
+		last := moves[len(moves)-1]
+		final = newReportBoard(last.Board, last.Move, true, orientation, "Final position")
+	} else {
+		final = newReportBoard(start, board.Move{}, false, orientation, "Final position")
+	}
+	data.Boards = append(data.Boards, final)
+
+	var buf bytes.Buffer
+	if err := reportTemplate.Execute(&buf, data); err != nil {
+		return nil, errors.NewAPIError("failed to render HTML report", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// newReportBoard builds the diagram of a position, highlighting the move that led to it
+func newReportBoard(position board.Board, last board.Move, highlight bool, orientation, caption string) reportBoard {
+	diagram := reportBoard{Caption: caption}
+	for row, squares := range displaySquares(orientation) {
+		for col, square := range squares {
+			piece := position.PieceAt(square)
+			diagram.Rows[row][col] = reportSquare{
+				Glyph:     pieceGlyphs[piece.Color][piece.Type],
+				Light:     square.IsLight(),
+				Highlight: highlight && (square == last.From || square == last.To),
+			}
+		}
+	}
+	return diagram
+}
+
+// moveNumberLabel returns the move number of the i-th move played from start, e.g. "12." or "12..."
+func moveNumberLabel(start board.Board, i int) string {
+	ply := i
+	if start.Turn() == board.Black {
+		ply++
+	}
+	number := start.FullMoves() + ply/2
+	if ply%2 == 1 {
+		return fmt.Sprintf("%d...", number)
+	}
+	return fmt.Sprintf("%d.", number)
+}
+
+// formatEvaluation renders an evaluation in pawns, or as a mate distance
+func formatEvaluation(evaluation float64) string {
+	command := pgnEvalCommand(evaluation)
+	return command[len("[%eval ") : len(command)-1]
+}
+
+// headerOr returns a PGN header, or fallback when the game doesn't have it
+func headerOr(headers map[string]string, name, fallback string) string {
+	if value := headers[name]; value != "" && value != "?" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"bytes"
+	"image/gif"
+	"strings"
+	"testing"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+)
+
+func TestAnalysisService_ExportHTML(t *testing.T) {
+	service := newTestAnalysisService()
+	id, err := service.store.SaveAnalysis(&models.GameAnalysis{
+		PGN:   annotationsTestPGN,
+		Moves: []models.MoveAnalysis{{Move: "Nf3", MoveNumber: 3, Evaluation: -0.5, Mistake: true, BestMove: "d2d4"}},
+	})
+	if err != nil {
+		t.Fatalf("SaveAnalysis() error = %v", err)
+	}
+
+	tests := []struct {
+		orientation string
+		topLeft     string // Piece on the top left square of the final position
+	}{
+		{OrientationWhite, "♜"},
+		{OrientationBlack, "♖"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.orientation, func(t *testing.T) {
+			data, contentType, err := service.ExportAnalysis(id, ExportFormatHTML, ExportOptions{Orientation: tt.orientation})
+			if err != nil {
+				t.Fatalf("ExportAnalysis() error = %v", err)
+			}
+			if contentType != "text/html; charset=utf-8" {
+				t.Errorf("Content type = %q", contentType)
+			}
+
+			report := string(data)
+			if !strings.Contains(report, "TestWhite vs TestBlack (1-0)") || !strings.Contains(report, `<tr class="mistake"><td>2.</td><td>Nf3</td><td>-0.50</td>`) {
+				t.Errorf("Report is missing the title or the mistake:\n%s", report)
+			}
+			// The mistake and the final position are drawn
+			if boards := strings.Count(report, `<table class="board">`); boards != 2 {
+				t.Fatalf("Report has %d boards, want 2", boards)
+			}
+			final := report[strings.LastIndex(report, `<table class="board">`):]
+			cell := final[strings.Index(final, "<td"):]
+			cell = cell[strings.Index(cell, ">")+1 : strings.Index(cell, "</td>")]
+			if cell != tt.topLeft {
+				t.Errorf("Top left square = %q, want %q", cell, tt.topLeft)
+			}
+		})
+	}
+}
+
+func TestAnalysisService_ExportGIF(t *testing.T) {
+	service := newTestAnalysisService()
+	id, err := service.store.SaveAnalysis(&models.GameAnalysis{PGN: annotationsTestPGN})
+	if err != nil {
+		t.Fatalf("SaveAnalysis() error = %v", err)
+	}
+
+	tests := []struct {
+		orientation string
+		topLeft     uint8 // Body color of the rook on the top left square
+	}{
+		{OrientationWhite, gifBlackPiece},
+		{OrientationBlack, gifWhitePiece},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.orientation, func(t *testing.T) {
+			data, contentType, err := service.ExportAnalysis(id, ExportFormatGIF, ExportOptions{Orientation: tt.orientation})
+			if err != nil {
+				t.Fatalf("ExportAnalysis() error = %v", err)
+			}
+			if contentType != "image/gif" {
+				t.Errorf("Content type = %q", contentType)
+			}
+
+			animation, err := gif.DecodeAll(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("DecodeAll() error = %v", err)
+			}
+			// The starting position and one frame per move
+			if len(animation.Image) != 5 {
+				t.Fatalf("GIF has %d frames, want 5", len(animation.Image))
+			}
+			if delay := animation.Delay[len(animation.Delay)-1]; delay != gifFinalDelay {
+				t.Errorf("Final frame delay = %d, want %d", delay, gifFinalDelay)
+			}
+
+			// A body cell of the rook sprite, fourth row
+			x, y := 2+4*gifSpriteScale+1, 2+3*gifSpriteScale+1
+			if got := animation.Image[0].ColorIndexAt(x, y); got != tt.topLeft {
+				t.Errorf("Top left rook color = %d, want %d", got, tt.topLeft)
+			}
+		})
+	}
+}
+
+func TestPieceSprites(t *testing.T) {
+	for piece, sprite := range pieceSprites[1:] {
+		for row, line := range sprite {
+			if len(line) != 12 {
+				t.Errorf("Sprite %d row %d is %d cells wide, want 12", piece+1, row, len(line))
+			}
+		}
+	}
+}
@@ -0,0 +1,347 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/board"
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/internal/parser"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+// ScreeningDisclaimer is included in every screening report
+const ScreeningDisclaimer = "This report is a statistical screening aid, not evidence of fair play violations. " +
+	"Strong players often match the engine, play at an even pace and outperform their rating. " +
+	"Signals must be weighed against the player's history and reviewed by a qualified arbiter before any action is taken."
+
+// Screening limits
+const (
+	defaultScreeningGames = 20
+	maxScreeningGames     = 50
+	maxScreeningDepths    = 3
+	maxScreeningDepth     = 30
+	screeningBookPlies    = 16  // Plies treated as opening theory and left out of engine matching
+	screeningDecidedEval  = 3.0 // Evaluation in pawns beyond which a position counts as decided
+	performanceRatingStep = 400 // Rating points the linear performance rating credits per net win per game
+)
+
+// defaultScreeningDepths are compared when a request doesn't choose depths
+var defaultScreeningDepths = []int{10, 18}
+
+// Levels at which a screening signal is reported as an observation
+const (
+	observationMinMoves  = 50
+	observationMatchRate = 70.0
+	observationCPL       = 10.0
+	observationVariation = 0.35
+	observationMinGames  = 5
+	observationRatingGap = 400
+)
+
+// screenedGame is a parsed game the screened player took part in
+type screenedGame struct {
+	parsed *parser.ParsedGame
+	color  board.Color
+}
+
+// ScreenPlayer computes engine-match, move-time and performance signals across a set of a player's
+// games. The caller must acknowledge that the report is a screening aid and not proof of anything.
+func (s *AnalyticsService) ScreenPlayer(ctx context.Context, request *models.ScreeningRequest) (*models.ScreeningReport, error) {
+	if err := normalizeScreeningRequest(request); err != nil {
+		return nil, err
+	}
+
+	report := &models.ScreeningReport{
+		Username:     request.Username,
+		GeneratedAt:  time.Now(),
+		Disclaimer:   ScreeningDisclaimer,
+		Observations: []string{},
+	}
+
+	pgns := request.PGNs
+	if len(pgns) == 0 {
+		games, archives, err := s.gameService.GetRecentGames(ctx, request.Username, request.Games)
+		if err != nil {
+			return nil, err
+		}
+		report.FailedArchives = failedArchives(archives)
+		report.Archives = archives
+		for _, game := range games {
+			pgns = append(pgns, game.PGN)
+		}
+	}
+
+	var games []screenedGame
+	for _, pgn := range pgns {
+		parsed, err := s.pgnParser.ParsePGN(pgn)
+		if err != nil {
+			report.SkippedGames++
+			continue
+		}
+		color, ok := headerColor(parsed.Headers, request.Username)
+		if !ok {
+			report.SkippedGames++
+			continue
+		}
+		games = append(games, screenedGame{parsed: parsed, color: color})
+	}
+	report.Games = len(games)
+
+	for _, depth := range request.Depths {
+		stat := models.EngineMatchStat{Depth: depth}
+		cplSum := 0.0
+		for _, game := range games {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+
+			analysis, err := s.analysisService.analyzeGameMoves(ctx, &models.AnalysisRequest{
+				PGN:      game.parsed.PGN,
+				Settings: models.EngineSettings{Depth: depth, MultiPV: 1},
+				Mode:     models.AnalysisModeFull,
+			})
+			if err != nil {
+				continue
+			}
+
+			moves, matches, loss := engineMatch(analysis.Moves, game.color.String())
+			stat.Games++
+			stat.Moves += moves
+			stat.Matches += matches
+			cplSum += loss
+		}
+		if stat.Moves > 0 {
+			stat.MatchRate = float64(stat.Matches) / float64(stat.Moves) * 100
+			stat.AverageCentipawnLoss = cplSum / float64(stat.Moves)
+		}
+		report.EngineMatch = append(report.EngineMatch, stat)
+	}
+
+	var spent []float64
+	for _, game := range games {
+		spent = append(spent, s.moveTimes(game)...)
+	}
+	report.MoveTimes = moveTimeStat(spent)
+
+	report.Performance = performanceStat(games)
+	report.Observations = append(report.Observations, screeningObservations(report)...)
+
+	return report, nil
+}
+
+// normalizeScreeningRequest validates a screening request and applies defaults
+func normalizeScreeningRequest(request *models.ScreeningRequest) error {
+	if !request.AcknowledgeDisclaimer {
+		return errors.NewValidationError("acknowledge_disclaimer",
+			"screening reports are opt-in; set acknowledge_disclaimer to confirm they are not evidence of cheating")
+	}
+	if request.Username == "" {
+		return errors.NewValidationError("username", "username is required")
+	}
+	if len(request.PGNs) > maxScreeningGames {
+		return errors.NewValidationError("pgns", fmt.Sprintf("at most %d games can be screened", maxScreeningGames))
+	}
+
+	if request.Games <= 0 {
+		request.Games = defaultScreeningGames
+	}
+	if request.Games > maxScreeningGames {
+		request.Games = maxScreeningGames
+	}
+
+	if len(request.Depths) == 0 {
+		request.Depths = append([]int(nil), defaultScreeningDepths...)
+	}
+	if len(request.Depths) > maxScreeningDepths {
+		return errors.NewValidationError("depths", fmt.Sprintf("at most %d depths can be compared", maxScreeningDepths))
+	}
+	for _, depth := range request.Depths {
+		if depth < 1 || depth > maxScreeningDepth {
+			return errors.NewValidationError("depths", fmt.Sprintf("depths must be between 1 and %d", maxScreeningDepth))
+		}
+	}
+	sort.Ints(request.Depths)
+
+	return nil
+}
+
+// headerColor returns the side a player had from a game's White and Black tags
+func headerColor(headers map[string]string, username string) (board.Color, bool) {
+	switch {
+	case strings.EqualFold(headers["white"], username):
+		return board.White, true
+	case strings.EqualFold(headers["black"], username):
+		return board.Black, true
+	}
+	return board.White, false
+}
+
+// engineMatch counts a side's moves that equal the engine's first choice in the position before
+// them, and sums their centipawn loss. Opening theory and already decided positions are skipped,
+// since matching the engine there says little about the player.
+func engineMatch(moves []models.MoveAnalysis, color string) (int, int, float64) {
+	compared, matches, loss := 0, 0, 0.0
+	for i := 1; i < len(moves); i++ {
+		move, prev := moves[i], moves[i-1]
+		if moveColor(move.FEN, move.MoveNumber) != color || move.MoveNumber <= screeningBookPlies {
+			continue
+		}
+		if prev.MoveNumber != move.MoveNumber-1 || prev.BestMove == "" {
+			continue
+		}
+		before := moverEval(prev.Evaluation, color)
+		if math.Abs(before) > screeningDecidedEval {
+			continue
+		}
+
+		compared++
+		if matchesEngine(prev.FEN, move.Move, prev.BestMove) {
+			matches++
+		}
+		loss += math.Max(0, before-moverEval(move.Evaluation, color)) * 100
+	}
+	return compared, matches, loss
+}
+
+// matchesEngine reports whether a SAN move played in a position is the engine's (UCI) move.
+// It falls back to comparing target squares when the position can't be replayed.
+func matchesEngine(fen, san, uci string) bool {
+	if b, err := board.FromFEN(fen); err == nil {
+		if m, err := b.ParseSAN(san); err == nil {
+			return m.UCI() == uci
+		}
+	}
+	return sameMove(san, uci)
+}
+
+// moveTimes returns the seconds the player spent on each of their moves that has a recorded or
+// derivable elapsed time
+func (s *AnalyticsService) moveTimes(game screenedGame) []float64 {
+	times := s.pgnParser.ExtractMoveTimes(game.parsed.PGN)
+	if base, increment, timed := parseTimeControl(game.parsed.Headers["timecontrol"]); timed {
+		parser.FillMoveTimes(times, base, increment)
+	}
+
+	first := 0
+	if game.color == board.Black {
+		first = 1
+	}
+
+	var spent []float64
+	for i := first; i < len(times); i += 2 {
+		if times[i].HasElapsed {
+			spent = append(spent, times[i].Elapsed.Seconds())
+		}
+	}
+	return spent
+}
+
+// moveTimeStat summarizes the time spent per move, or returns nil without timed moves
+func moveTimeStat(spent []float64) *models.MoveTimeStat {
+	if len(spent) == 0 {
+		return nil
+	}
+
+	sum := 0.0
+	for _, seconds := range spent {
+		sum += seconds
+	}
+	mean := sum / float64(len(spent))
+
+	variance := 0.0
+	for _, seconds := range spent {
+		variance += (seconds - mean) * (seconds - mean)
+	}
+	stat := &models.MoveTimeStat{
+		Moves:  len(spent),
+		Mean:   mean,
+		StdDev: math.Sqrt(variance / float64(len(spent))),
+	}
+	if mean > 0 {
+		stat.Variation = stat.StdDev / mean
+	}
+	return stat
+}
+
+// performanceStat compares the player's score in decided, rated games with the Elo expectation,
+// or returns nil when no game had both ratings
+func performanceStat(games []screenedGame) *models.PerformanceStat {
+	stat := &models.PerformanceStat{}
+	ratingSum, opponentSum, wins, losses := 0, 0, 0, 0
+
+	for _, game := range games {
+		score, decided := playerScore(game.parsed.Result, game.color)
+		if !decided {
+			continue
+		}
+		own, opponent := headerRating(game.parsed.Headers, game.color), headerRating(game.parsed.Headers, game.color.Opponent())
+		if own <= 0 || opponent <= 0 {
+			continue
+		}
+
+		stat.Games++
+		stat.Score += score
+		stat.ExpectedScore += 1 / (1 + math.Pow(10, float64(opponent-own)/400))
+		ratingSum += own
+		opponentSum += opponent
+		switch score {
+		case 1:
+			wins++
+		case 0:
+			losses++
+		}
+	}
+	if stat.Games == 0 {
+		return nil
+	}
+
+	stat.AverageRating = ratingSum / stat.Games
+	stat.AverageOpponentRating = opponentSum / stat.Games
+	stat.PerformanceRating = stat.AverageOpponentRating + performanceRatingStep*(wins-losses)/stat.Games
+	stat.RatingDeviation = stat.PerformanceRating - stat.AverageRating
+	return stat
+}
+
+// headerRating returns a side's rating from the WhiteElo or BlackElo tag, or 0 if it's missing
+func headerRating(headers map[string]string, color board.Color) int {
+	rating, err := strconv.Atoi(headers[color.String()+"elo"])
+	if err != nil {
+		return 0
+	}
+	return rating
+}
+
+// screeningObservations describes the signals of a report that are outside the usual range
+func screeningObservations(report *models.ScreeningReport) []string {
+	var observations []string
+
+	if n := len(report.EngineMatch); n > 0 {
+		deepest := report.EngineMatch[n-1]
+		if deepest.Moves >= observationMinMoves && deepest.MatchRate >= observationMatchRate {
+			observations = append(observations, fmt.Sprintf(
+				"%.0f%% of %d moves matched the engine's first choice at depth %d", deepest.MatchRate, deepest.Moves, deepest.Depth))
+		}
+		if deepest.Moves >= observationMinMoves && deepest.AverageCentipawnLoss <= observationCPL {
+			observations = append(observations, fmt.Sprintf(
+				"Average centipawn loss of %.1f at depth %d", deepest.AverageCentipawnLoss, deepest.Depth))
+		}
+	}
+
+	if times := report.MoveTimes; times != nil && times.Moves >= observationMinMoves && times.Variation < observationVariation {
+		observations = append(observations, fmt.Sprintf(
+			"Move times were unusually even: %.1fs per move with a variation of %.2f", times.Mean, times.Variation))
+	}
+
+	if perf := report.Performance; perf != nil && perf.Games >= observationMinGames && perf.RatingDeviation >= observationRatingGap {
+		observations = append(observations, fmt.Sprintf(
+			"Performed %d points above their rating over %d games", perf.RatingDeviation, perf.Games))
+	}
+
+	return observations
+}
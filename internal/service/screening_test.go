@@ -0,0 +1,176 @@
+package service
+
+import (
+	"math"
+	"testing"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/internal/parser"
+)
+
+func TestNormalizeScreeningRequest(t *testing.T) {
+	request := &models.ScreeningRequest{Username: "alice", Games: 500, Depths: []int{20, 8}, AcknowledgeDisclaimer: true}
+	if err := normalizeScreeningRequest(request); err != nil {
+		t.Fatalf("normalizeScreeningRequest() error = %v", err)
+	}
+	if request.Games != maxScreeningGames {
+		t.Errorf("Games = %d, want %d", request.Games, maxScreeningGames)
+	}
+	if request.Depths[0] != 8 || request.Depths[1] != 20 {
+		t.Errorf("Expected depths sorted shallowest first, got %v", request.Depths)
+	}
+
+	defaults := &models.ScreeningRequest{Username: "alice", AcknowledgeDisclaimer: true}
+	if err := normalizeScreeningRequest(defaults); err != nil || len(defaults.Depths) != len(defaultScreeningDepths) {
+		t.Errorf("Expected the default depths, got %v (%v)", defaults.Depths, err)
+	}
+
+	invalid := []*models.ScreeningRequest{
+		{Username: "alice"}, // Disclaimer not acknowledged
+		{AcknowledgeDisclaimer: true},
+		{Username: "alice", Depths: []int{0}, AcknowledgeDisclaimer: true},
+		{Username: "alice", Depths: []int{8, 12, 16, 20}, AcknowledgeDisclaimer: true},
+		{Username: "alice", PGNs: make([]string, maxScreeningGames+1), AcknowledgeDisclaimer: true},
+	}
+	for i, request := range invalid {
+		if err := normalizeScreeningRequest(request); err == nil {
+			t.Errorf("Expected request %d to be rejected", i)
+		}
+	}
+}
+
+func TestEngineMatch(t *testing.T) {
+	moves := []models.MoveAnalysis{
+		{MoveNumber: 15, Move: "Nf3", Evaluation: 0.2, BestMove: "e7e5"},
+		{MoveNumber: 16, Move: "e5", Evaluation: 0.2, BestMove: "b1c3"}, // Opening theory
+		{MoveNumber: 17, Move: "Nc3", Evaluation: 0.3, BestMove: "d7d5"},
+		{MoveNumber: 18, Move: "d5", Evaluation: 0.3, BestMove: "c1f4"}, // Engine move
+		{MoveNumber: 19, Move: "Bf4", Evaluation: 0.5, BestMove: "c7c5"},
+		{MoveNumber: 20, Move: "e6", Evaluation: 1.0, BestMove: "e2e4"}, // Loses half a pawn
+		{MoveNumber: 21, Move: "e4", Evaluation: 4.0, BestMove: "a7a6"},
+		{MoveNumber: 22, Move: "a6", Evaluation: 4.0, BestMove: "f1d3"}, // Already lost
+	}
+
+	compared, matches, loss := engineMatch(moves, "black")
+	if compared != 2 || matches != 1 {
+		t.Errorf("engineMatch() = %d moves, %d matches, want 2 and 1", compared, matches)
+	}
+	if math.Abs(loss-50) > 1e-9 {
+		t.Errorf("Centipawn loss = %v, want 50", loss)
+	}
+}
+
+func TestMatchesEngine(t *testing.T) {
+	fen := "4k3/8/8/8/8/8/8/1N2KN2 w - - 0 1"
+	if !matchesEngine(fen, "Nbd2", "b1d2") {
+		t.Error("Expected Nbd2 to match b1d2")
+	}
+	if matchesEngine(fen, "Nbd2", "f1d2") {
+		t.Error("Expected a different knight to the same square not to match")
+	}
+	if !matchesEngine("", "Nf3", "g1f3") {
+		t.Error("Expected target squares to be compared without a position")
+	}
+}
+
+func TestAnalyticsService_MoveTimes(t *testing.T) {
+	pgn := `[White "alice"]
+[Black "bob"]
+[TimeControl "60+1"]
+
+1. d4 {[%clk 0:01:00]} 1... d5 {[%clk 0:01:00]} 2. Nd2 {[%clk 0:00:58]} 2... Nf6 {[%clk 0:00:59]} 3. Nb3 {[%clk 0:00:55]} 3... e6 {[%clk 0:00:58]} 4. Na5 {[%clk 0:00:54]} 4... c5 {[%clk 0:00:50]} 5. c4 {[%clk 0:00:50]} 5... cxd4 {[%clk 0:00:49]} 1-0`
+
+	s := &AnalyticsService{pgnParser: parser.NewPGNParser()}
+	games := parseScreenedGames(t, s, "alice", pgn)
+
+	spent := s.moveTimes(games[0])
+	want := []float64{1, 3, 4, 2, 5}
+	if len(spent) != len(want) {
+		t.Fatalf("moveTimes() = %v, want %v", spent, want)
+	}
+	for i := range want {
+		if spent[i] != want[i] {
+			t.Errorf("moveTimes()[%d] = %v, want %v", i, spent[i], want[i])
+		}
+	}
+
+	stat := moveTimeStat(spent)
+	if stat.Moves != 5 || stat.Mean != 3 || math.Abs(stat.StdDev-math.Sqrt2) > 1e-9 {
+		t.Errorf("Unexpected move time statistics %+v", stat)
+	}
+	if math.Abs(stat.Variation-math.Sqrt2/3) > 1e-9 {
+		t.Errorf("Variation = %v, want %v", stat.Variation, math.Sqrt2/3)
+	}
+	if moveTimeStat(nil) != nil {
+		t.Error("Expected no statistics without timed moves")
+	}
+}
+
+func TestPerformanceStat(t *testing.T) {
+	s := &AnalyticsService{pgnParser: parser.NewPGNParser()}
+	games := parseScreenedGames(t, s, "alice",
+		"[White \"alice\"]\n[Black \"bob\"]\n[WhiteElo \"1500\"]\n[BlackElo \"1500\"]\n[Result \"1-0\"]\n\n1. e4 e5 1-0",
+		"[White \"carl\"]\n[Black \"alice\"]\n[WhiteElo \"1700\"]\n[BlackElo \"1500\"]\n[Result \"0-1\"]\n\n1. e4 e5 0-1",
+		"[White \"alice\"]\n[Black \"dana\"]\n[Result \"0-1\"]\n\n1. e4 e5 0-1", // Unrated
+	)
+
+	stat := performanceStat(games)
+	if stat == nil {
+		t.Fatal("Expected performance statistics")
+	}
+	if stat.Games != 2 || stat.Score != 2 {
+		t.Errorf("Expected 2 points from 2 games, got %+v", stat)
+	}
+	if math.Abs(stat.ExpectedScore-0.7403) > 0.001 {
+		t.Errorf("ExpectedScore = %v, want about 0.74", stat.ExpectedScore)
+	}
+	if stat.AverageOpponentRating != 1600 || stat.PerformanceRating != 2000 || stat.RatingDeviation != 500 {
+		t.Errorf("Unexpected ratings %+v", stat)
+	}
+
+	if performanceStat(games[2:]) != nil {
+		t.Error("Expected no statistics without rated games")
+	}
+}
+
+func TestScreeningObservations(t *testing.T) {
+	report := &models.ScreeningReport{
+		EngineMatch: []models.EngineMatchStat{
+			{Depth: 10, Moves: 120, MatchRate: 80, AverageCentipawnLoss: 5},
+			{Depth: 18, Moves: 120, MatchRate: 60, AverageCentipawnLoss: 25},
+		},
+		MoveTimes:   &models.MoveTimeStat{Moves: 120, Mean: 8, Variation: 0.2},
+		Performance: &models.PerformanceStat{Games: 3, RatingDeviation: 600},
+	}
+
+	// Only the deepest comparison counts, and three games are too few to judge performance
+	observations := screeningObservations(report)
+	if len(observations) != 1 {
+		t.Fatalf("Expected only the move time observation, got %v", observations)
+	}
+
+	report.EngineMatch[1].MatchRate = 75
+	report.Performance.Games = 9
+	if observations := screeningObservations(report); len(observations) != 3 {
+		t.Errorf("Expected engine match, move time and performance observations, got %v", observations)
+	}
+}
+
+// parseScreenedGames parses games of a player for screening
+func parseScreenedGames(t *testing.T, s *AnalyticsService, username string, pgns ...string) []screenedGame {
+	t.Helper()
+
+	var games []screenedGame
+	for _, pgn := range pgns {
+		parsed, err := s.pgnParser.ParsePGN(pgn)
+		if err != nil {
+			t.Fatalf("ParsePGN() error = %v", err)
+		}
+		color, ok := headerColor(parsed.Headers, username)
+		if !ok {
+			t.Fatalf("Expected %s to play in the game", username)
+		}
+		games = append(games, screenedGame{parsed: parsed, color: color})
+	}
+	return games
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/board"
+	"github.com/pedrampdd/ChessAnalyser/internal/engine"
+	"github.com/pedrampdd/ChessAnalyser/internal/i18n"
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+// GetPlyPosition returns the position, evaluation and annotations at one ply of a stored analysis
+func (s *AnalysisService) GetPlyPosition(analysisID string, ply int) (*models.PlyPosition, error) {
+	analysis, err := s.GetAnalysis(analysisID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Replay the game for the starting position and plies the engine skipped
+	startFEN := board.StartFEN
+	var moves []models.MoveAnalysis
+	game, err := s.pgnParser.ParsePGN(analysis.PGN)
+	if err == nil {
+		err = s.pgnParser.ExtractPositions(game)
+	}
+	if err == nil {
+		if fen := game.Headers["fen"]; fen != "" {
+			startFEN = fen
+		}
+		for i, move := range game.Moves {
+			moves = append(moves, models.MoveAnalysis{Move: move.Move, MoveNumber: i + 1, FEN: move.FEN})
+		}
+	}
+	for _, move := range analysis.Moves {
+		if move.MoveNumber > len(moves) {
+			moves = append(moves, make([]models.MoveAnalysis, move.MoveNumber-len(moves))...)
+		}
+	}
+
+	if ply < 0 || ply > len(moves) {
+		return nil, errors.NewValidationError("ply", fmt.Sprintf("must be between 0 and %d", len(moves)))
+	}
+
+	position := &models.PlyPosition{
+		AnalysisID: analysis.ID,
+		Ply:        ply,
+		TotalPlies: len(moves),
+		FEN:        startFEN,
+	}
+	if ply == 0 {
+		return position, nil
+	}
+
+	move := moves[ply-1]
+	if i := findMoveIndex(analysis.Moves, ply); i >= 0 {
+		move = analysis.Moves[i]
+		position.Analyzed = true
+		position.Evaluation = move.Evaluation
+		position.BestMove = move.BestMove
+		position.BestLine = move.BestLine
+		position.Classification = moveClassification(move)
+		position.Comment = move.UserComment
+		if engine.IsStandardVariant(analysis.EngineSettings.Variant) {
+			position.BestLineSAN = sanLine(move.FEN, move.BestLine)
+		}
+	}
+	position.FEN = move.FEN
+	position.Move = move.Move
+	position.Color = moveColor(move.FEN, ply)
+
+	return position, nil
+}
+
+// FilterMoves returns a copy of an analysis holding only the moves the filter selects, so clients
+// displaying part of a long game don't fetch every line. The summary still covers the whole game.
+func (s *AnalysisService) FilterMoves(analysis *models.GameAnalysis, filter models.MoveFilter) (*models.GameAnalysis, error) {
+	if filter.FromPly < 0 {
+		return nil, errors.NewValidationError("from_ply", "must not be negative")
+	}
+	if filter.ToPly < 0 || (filter.ToPly > 0 && filter.ToPly < filter.FromPly) {
+		return nil, errors.NewValidationError("to_ply", "must not be negative or before from_ply")
+	}
+
+	var selected func(models.MoveAnalysis) bool
+	switch filter.Only {
+	case "":
+		selected = func(models.MoveAnalysis) bool { return true }
+	case models.MoveFilterBlunders:
+		selected = func(move models.MoveAnalysis) bool { return moveClassification(move) == "blunder" }
+	case models.MoveFilterMistakes:
+		selected = func(move models.MoveAnalysis) bool { return moveClassification(move) == "mistake" }
+	case models.MoveFilterKey:
+		key := make(map[int]bool)
+		for _, moment := range selectKeyMoments(analysis, i18n.DefaultLanguage, moveNotation{}) {
+			key[moment.MoveNumber] = true
+		}
+		selected = func(move models.MoveAnalysis) bool { return key[move.MoveNumber] }
+	default:
+		return nil, errors.NewValidationError("only", fmt.Sprintf("unknown move filter: %s", filter.Only))
+	}
+	if filter.IsZero() {
+		return analysis, nil
+	}
+
+	filtered := *analysis
+	filtered.Moves = []models.MoveAnalysis{}
+	for _, move := range analysis.Moves {
+		if move.MoveNumber < filter.FromPly || (filter.ToPly > 0 && move.MoveNumber > filter.ToPly) {
+			continue
+		}
+		if selected(move) {
+			filtered.Moves = append(filtered.Moves, move)
+		}
+	}
+	return &filtered, nil
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/board"
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+func TestAnalysisService_GetPlyPosition(t *testing.T) {
+	service := newTestAnalysisService()
+
+	afterE4 := "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1"
+	id, err := service.store.SaveAnalysis(&models.GameAnalysis{
+		PGN: annotationsTestPGN,
+		Moves: []models.MoveAnalysis{
+			{Move: "e4", MoveNumber: 1, FEN: afterE4, Evaluation: 0.3, BestMove: "e7e5", BestLine: []string{"e7e5", "g1f3"}, UserComment: "Best by test"},
+			{Move: "Nf3", MoveNumber: 3, FEN: "rnbqkbnr/pppp1ppp/8/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R b KQkq - 1 2", Mistake: true},
+		},
+	})
+	if err != nil {
+		t.Fatalf("SaveAnalysis() error = %v", err)
+	}
+
+	start, err := service.GetPlyPosition(id, 0)
+	if err != nil {
+		t.Fatalf("GetPlyPosition(0) error = %v", err)
+	}
+	if start.FEN != board.StartFEN || start.TotalPlies != 4 || start.Analyzed {
+		t.Errorf("Unexpected starting position: %+v", start)
+	}
+
+	first, err := service.GetPlyPosition(id, 1)
+	if err != nil {
+		t.Fatalf("GetPlyPosition(1) error = %v", err)
+	}
+	if first.FEN != afterE4 || first.Move != "e4" || first.Color != "white" || !first.Analyzed || first.Evaluation != 0.3 || first.Comment != "Best by test" {
+		t.Errorf("Unexpected first position: %+v", first)
+	}
+	if want := []string{"e5", "Nf3"}; !reflect.DeepEqual(first.BestLineSAN, want) {
+		t.Errorf("BestLineSAN = %v, want %v", first.BestLineSAN, want)
+	}
+
+	// The engine skipped the second ply; its position comes from the PGN
+	second, err := service.GetPlyPosition(id, 2)
+	if err != nil {
+		t.Fatalf("GetPlyPosition(2) error = %v", err)
+	}
+	if second.Analyzed || second.Move != "e5" || second.FEN != "rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq e6 0 2" {
+		t.Errorf("Unexpected unanalyzed position: %+v", second)
+	}
+
+	if third, _ := service.GetPlyPosition(id, 3); third.Classification != "mistake" {
+		t.Errorf("Classification = %q, want mistake", third.Classification)
+	}
+
+	var validation *errors.ValidationError
+	if _, err := service.GetPlyPosition(id, 5); !errors.As(err, &validation) {
+		t.Errorf("Expected a validation error past the last ply, got %v", err)
+	}
+}
+
+func TestAnalysisService_FilterMoves(t *testing.T) {
+	service := newTestAnalysisService()
+
+	analysis := &models.GameAnalysis{Moves: []models.MoveAnalysis{
+		{Move: "e4", MoveNumber: 1, BestMove: "e7e5"},
+		{Move: "e5", MoveNumber: 2, Evaluation: 0.2},
+		{Move: "Qh5", MoveNumber: 3, Evaluation: -0.5, Mistake: true},
+		{Move: "g6", MoveNumber: 4, Evaluation: 0.1, ClassificationOverride: "blunder"},
+		{Move: "Qxf7", MoveNumber: 5, Evaluation: -4, Blunder: true},
+	}}
+
+	plies := func(filter models.MoveFilter) []int {
+		t.Helper()
+		filtered, err := service.FilterMoves(analysis, filter)
+		if err != nil {
+			t.Fatalf("FilterMoves(%+v) error = %v", filter, err)
+		}
+		numbers := []int{}
+		for _, move := range filtered.Moves {
+			numbers = append(numbers, move.MoveNumber)
+		}
+		return numbers
+	}
+
+	tests := []struct {
+		filter models.MoveFilter
+		want   []int
+	}{
+		{models.MoveFilter{}, []int{1, 2, 3, 4, 5}},
+		{models.MoveFilter{FromPly: 2, ToPly: 4}, []int{2, 3, 4}},
+		{models.MoveFilter{FromPly: 4}, []int{4, 5}},
+		{models.MoveFilter{Only: models.MoveFilterBlunders}, []int{4, 5}},
+		{models.MoveFilter{ToPly: 4, Only: models.MoveFilterBlunders}, []int{4}},
+		{models.MoveFilter{Only: models.MoveFilterMistakes}, []int{3}},
+	}
+	for _, tt := range tests {
+		if got := plies(tt.filter); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("FilterMoves(%+v) plies = %v, want %v", tt.filter, got, tt.want)
+		}
+	}
+	if len(analysis.Moves) != 5 {
+		t.Error("FilterMoves() modified the stored analysis")
+	}
+
+	var validation *errors.ValidationError
+	for _, filter := range []models.MoveFilter{{FromPly: -1}, {FromPly: 4, ToPly: 2}, {Only: "good"}} {
+		if _, err := service.FilterMoves(analysis, filter); !errors.As(err, &validation) {
+			t.Errorf("FilterMoves(%+v) error = %v, want a validation error", filter, err)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/board"
+	"github.com/pedrampdd/ChessAnalyser/internal/engine"
+	"github.com/pedrampdd/ChessAnalyser/internal/i18n"
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/internal/parser"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+// Self-play limits
+const (
+	defaultSelfPlayPlies = 80
+	maxSelfPlayPlies     = 300
+	defaultSelfPlayDepth = 12
+	defaultSelfPlayTime  = 500  // Milliseconds per move
+	maxSelfPlayTime      = 5000 // Keeps a full game within a few minutes at most
+)
+
+// searchFunc finds the engine's move in a position with the given side to move
+type searchFunc func(fen string, turn board.Color) (*models.AnalysisResult, error)
+
+// SelfPlay has the engine play a position out against itself and returns the continuation as PGN
+func (s *AnalysisService) SelfPlay(ctx context.Context, request *models.SelfPlayRequest) (*models.SelfPlayGame, error) {
+	if !engine.IsStandardVariant(request.Settings.Variant) {
+		return nil, errors.NewValidationError("variant", "self-play supports standard chess only")
+	}
+	if request.FEN == "" {
+		request.FEN = board.StartFEN
+	}
+	b, err := board.FromFEN(request.FEN)
+	if err != nil {
+		return nil, errors.NewValidationError("fen", err.Error())
+	}
+	if err := b.Validate(); err != nil {
+		return nil, errors.NewValidationError("fen", err.Error())
+	}
+	notation, err := displayNotation(request.Notation)
+	if err != nil {
+		return nil, err
+	}
+	if request.MaxPlies <= 0 {
+		request.MaxPlies = defaultSelfPlayPlies
+	}
+	if request.MaxPlies > maxSelfPlayPlies {
+		request.MaxPlies = maxSelfPlayPlies
+	}
+
+	var sides [2]models.EngineSettings
+	for c, side := range [2]models.SelfPlaySide{board.White: request.White, board.Black: request.Black} {
+		settings := request.Settings
+		settings.MultiPV = 1
+		settings.Depth = side.Depth
+		settings.TimeLimit = side.TimeLimit
+		if settings.Depth <= 0 {
+			settings.Depth = defaultSelfPlayDepth
+		}
+		if settings.TimeLimit <= 0 {
+			settings.TimeLimit = defaultSelfPlayTime
+		}
+		if settings.TimeLimit > maxSelfPlayTime {
+			settings.TimeLimit = maxSelfPlayTime
+		}
+		sides[c] = settings
+	}
+
+	pool, err := s.poolFor(request.Settings.Variant, "")
+	if err != nil {
+		return nil, err
+	}
+	stockfishEngine, err := pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer pool.ReturnEngine(stockfishEngine)
+
+	game, err := s.playOut(ctx, b, request.MaxPlies, func(fen string, turn board.Color) (*models.AnalysisResult, error) {
+		return stockfishEngine.AnalyzePosition(ctx, fen, sides[turn])
+	})
+	if err != nil {
+		return nil, err
+	}
+	// The PGN stays in SAN so it can be imported anywhere
+	game.Moves = i18n.FormatLine(notation, game.Moves)
+	return game, nil
+}
+
+// playOut plays moves chosen by search until the game ends or maxPlies moves have been played
+func (s *AnalysisService) playOut(ctx context.Context, b *board.Board, maxPlies int, search searchFunc) (*models.SelfPlayGame, error) {
+	game := &models.SelfPlayGame{
+		FEN:         b.FEN(),
+		Moves:       []string{},
+		UCIMoves:    []string{},
+		Evaluations: []float64{},
+	}
+	var pgnMoves []parser.ParsedMove
+	seen := map[string]int{positionKey(game.FEN): 1}
+
+	for {
+		if termination, result, over := gameOver(b, seen); over {
+			game.Termination, game.Result = termination, result
+			break
+		}
+		if len(game.Moves) == maxPlies {
+			game.Termination, game.Result = models.TerminationMaxPlies, "*"
+			break
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		result, err := search(b.FEN(), b.Turn())
+		if err != nil {
+			return nil, err
+		}
+		m, err := b.ParseUCI(result.BestMove)
+		if err != nil {
+			return nil, errors.NewAPIError(fmt.Sprintf("engine played an illegal move %q", result.BestMove), err)
+		}
+
+		san := b.SAN(m)
+		pgnMoves = append(pgnMoves, parser.ParsedMove{MoveNumber: b.FullMoves(), Move: san, Color: b.Turn().String()})
+		game.Moves = append(game.Moves, san)
+		game.UCIMoves = append(game.UCIMoves, m.UCI())
+		game.Evaluations = append(game.Evaluations, result.Evaluation)
+
+		b.Apply(m)
+		seen[positionKey(b.FEN())]++
+	}
+
+	game.FinalFEN = b.FEN()
+	game.PGN = playedGamePGN(s.pgnParser, "Engine self-play", "Stockfish", "Stockfish", game.FEN, game.Result, game.Termination, pgnMoves)
+	return game, nil
+}
+
+// gameOver reports whether the game has ended by the rules, with the reason and result
+func gameOver(b *board.Board, seen map[string]int) (string, string, bool) {
+	if len(b.LegalMoves()) == 0 {
+		if !b.InCheck() {
+			return models.TerminationStalemate, "1/2-1/2", true
+		}
+		if b.Turn() == board.White {
+			return models.TerminationCheckmate, "0-1", true
+		}
+		return models.TerminationCheckmate, "1-0", true
+	}
+
+	switch {
+	case b.InsufficientMaterial():
+		return models.TerminationInsufficientMaterial, "1/2-1/2", true
+	case b.HalfMoves() >= 100:
+		return models.TerminationFiftyMoveRule, "1/2-1/2", true
+	case seen[positionKey(b.FEN())] >= 3:
+		return models.TerminationThreefoldRepetition, "1/2-1/2", true
+	}
+	return "", "", false
+}
+
+// positionKey identifies a position for repetition checks: placement, side to move, castling and en passant
+func positionKey(fen string) string {
+	fields := strings.Fields(fen)
+	if len(fields) > 4 {
+		fields = fields[:4]
+	}
+	return strings.Join(fields, " ")
+}
+
+// playedGamePGN renders a game played on the server as PGN, with a FEN tag when it didn't start
+// from the initial position
+func playedGamePGN(pgnParser *parser.PGNParser, event, white, black, startFEN, result, termination string, moves []parser.ParsedMove) string {
+	tags := fmt.Sprintf("[Termination \"%s\"]\n", termination)
+	if startFEN != board.StartFEN {
+		tags += fmt.Sprintf("[SetUp \"1\"]\n[FEN \"%s\"]\n", startFEN)
+	}
+
+	return pgnParser.FormatPGN(&parser.ParsedGame{
+		Headers: map[string]string{
+			"event":  event,
+			"site":   "ChessAnalyser",
+			"date":   time.Now().Format("2006.01.02"),
+			"round":  "-",
+			"white":  white,
+			"black":  black,
+			"result": result,
+		},
+		Moves:  moves,
+		Result: result,
+		PGN:    tags, // FormatPGN copies extra tags from the PGN's tag section
+	})
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/board"
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+)
+
+// scriptedSearch plays the given UCI moves in order, repeating them when they run out
+func scriptedSearch(moves ...string) searchFunc {
+	ply := 0
+	return func(fen string, turn board.Color) (*models.AnalysisResult, error) {
+		move := moves[ply%len(moves)]
+		ply++
+		return &models.AnalysisResult{BestMove: move, Evaluation: 5}, nil
+	}
+}
+
+func TestAnalysisService_PlayOut(t *testing.T) {
+	service := newTestAnalysisService()
+	pawnEnding := "4k3/8/8/8/8/8/4P3/4K3 w - - 0 1"
+
+	tests := []struct {
+		name            string
+		fen             string
+		maxPlies        int
+		moves           []string
+		wantPlies       int
+		wantResult      string
+		wantTermination string
+	}{
+		{"checkmate", "7k/8/6K1/8/8/8/8/R7 w - - 0 1", 10, []string{"a1a8"}, 1, "1-0", models.TerminationCheckmate},
+		{"repetition", pawnEnding, 20, []string{"e1d1", "e8d8", "d1e1", "d8e8"}, 8, "1/2-1/2", models.TerminationThreefoldRepetition},
+		{"ply limit", pawnEnding, 2, []string{"e1d1", "e8d8", "d1e1", "d8e8"}, 2, "*", models.TerminationMaxPlies},
+		{"insufficient material", "4k3/8/8/8/8/8/8/4K3 w - - 0 1", 10, []string{"e1d1"}, 0, "1/2-1/2", models.TerminationInsufficientMaterial},
+	}
+
+	for _, tt := range tests {
+		b, err := board.FromFEN(tt.fen)
+		if err != nil {
+			t.Fatalf("FromFEN() error = %v", err)
+		}
+		game, err := service.playOut(context.Background(), b, tt.maxPlies, scriptedSearch(tt.moves...))
+		if err != nil {
+			t.Fatalf("%s: playOut() error = %v", tt.name, err)
+		}
+		if len(game.Moves) != tt.wantPlies || game.Result != tt.wantResult || game.Termination != tt.wantTermination {
+			t.Errorf("%s: got %d plies, %s by %s, want %d plies, %s by %s",
+				tt.name, len(game.Moves), game.Result, game.Termination, tt.wantPlies, tt.wantResult, tt.wantTermination)
+		}
+	}
+}
+
+func TestAnalysisService_PlayOutPGN(t *testing.T) {
+	service := newTestAnalysisService()
+	fen := "7k/8/6K1/8/8/8/8/R7 w - - 0 1"
+	b, _ := board.FromFEN(fen)
+
+	game, err := service.playOut(context.Background(), b, 10, scriptedSearch("a1a8"))
+	if err != nil {
+		t.Fatalf("playOut() error = %v", err)
+	}
+	for _, want := range []string{`[FEN "` + fen + `"]`, `[Termination "checkmate"]`, `[Result "1-0"]`, "1. Ra8# 1-0"} {
+		if !strings.Contains(game.PGN, want) {
+			t.Errorf("Expected PGN to contain %q, got:\n%s", want, game.PGN)
+		}
+	}
+	if game.FinalFEN != "R6k/8/6K1/8/8/8/8/8 b - - 1 1" {
+		t.Errorf("FinalFEN = %q", game.FinalFEN)
+	}
+
+	// Illegal engine moves are reported instead of corrupting the game
+	b, _ = board.FromFEN(fen)
+	if _, err := service.playOut(context.Background(), b, 10, scriptedSearch("a1b2")); err == nil {
+		t.Error("Expected an error for an illegal engine move")
+	}
+}
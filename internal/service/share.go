@@ -0,0 +1,53 @@
+package service
+
+import (
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/internal/storage"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+// CreateShareLink creates a read-only public link to a stored analysis
+func (s *AnalysisService) CreateShareLink(analysisID string, expiresIn time.Duration) (*models.ShareLink, error) {
+	if expiresIn < 0 {
+		return nil, errors.NewValidationError("expires_in", "must not be negative")
+	}
+
+	if _, err := s.GetAnalysis(analysisID); err != nil {
+		return nil, err
+	}
+
+	token, err := storage.NewID()
+	if err != nil {
+		return nil, errors.NewAPIError("failed to create share link", err)
+	}
+
+	link := &models.ShareLink{
+		Token:      token,
+		AnalysisID: analysisID,
+		CreatedAt:  time.Now(),
+	}
+	if expiresIn > 0 {
+		expiresAt := link.CreatedAt.Add(expiresIn)
+		link.ExpiresAt = &expiresAt
+	}
+
+	s.store.SaveShareLink(link)
+	return link, nil
+}
+
+// GetSharedAnalysis returns the analysis behind a share link, if the link is still valid
+func (s *AnalysisService) GetSharedAnalysis(token string) (*models.GameAnalysis, error) {
+	link, err := s.store.GetShareLink(token)
+	if err != nil {
+		return nil, err
+	}
+
+	if link.Expired(time.Now()) {
+		s.store.DeleteShareLink(token)
+		return nil, errors.NewShareLinkNotFoundError(token)
+	}
+
+	return s.GetAnalysis(link.AnalysisID)
+}
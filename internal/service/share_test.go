@@ -0,0 +1,53 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+func TestAnalysisService_ShareLinks(t *testing.T) {
+	service := newTestAnalysisService()
+
+	id, err := service.store.SaveAnalysis(&models.GameAnalysis{PGN: annotationsTestPGN})
+	if err != nil {
+		t.Fatalf("SaveAnalysis() error = %v", err)
+	}
+
+	link, err := service.CreateShareLink(id, 0)
+	if err != nil {
+		t.Fatalf("CreateShareLink() error = %v", err)
+	}
+	if link.ExpiresAt != nil {
+		t.Errorf("Expected a link without expiry, got %v", link.ExpiresAt)
+	}
+
+	shared, err := service.GetSharedAnalysis(link.Token)
+	if err != nil {
+		t.Fatalf("GetSharedAnalysis() error = %v", err)
+	}
+	if shared.ID != id {
+		t.Errorf("Shared analysis ID = %v, want %v", shared.ID, id)
+	}
+
+	// Expired links are rejected
+	expiring, err := service.CreateShareLink(id, time.Hour)
+	if err != nil {
+		t.Fatalf("CreateShareLink() error = %v", err)
+	}
+	past := time.Now().Add(-time.Minute)
+	expiring.ExpiresAt = &past
+
+	if _, err := service.GetSharedAnalysis(expiring.Token); err == nil {
+		t.Error("Expected an error for an expired share link")
+	} else if _, ok := err.(*errors.ShareLinkNotFoundError); !ok {
+		t.Errorf("Expected ShareLinkNotFoundError, got %T", err)
+	}
+
+	// Links can only be created for stored analyses
+	if _, err := service.CreateShareLink("missing", 0); err == nil {
+		t.Error("Expected an error when sharing a missing analysis")
+	}
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/engine"
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+)
+
+// shedLoad cuts back the settings of a non-priority analysis about to wait for an engine of pool
+// when the pool's queue has reached the load-shedding length. Practical analyses keep at least
+// minMultiPV lines. A capped depth drops the time limit, since searches with a time limit ignore
+// the depth. It returns what was cut back, or nil when the analysis runs as requested.
+func (s *AnalysisService) shedLoad(pool *engine.EnginePool, settings *models.EngineSettings, minMultiPV int) *models.ReducedQuality {
+	policy := s.loadShedding
+	if !policy.Enabled() {
+		return nil
+	}
+	waiting, _, _ := pool.QueueStatus()
+	if waiting < policy.QueueLength {
+		return nil
+	}
+
+	reduced := &models.ReducedQuality{
+		Reason:                fmt.Sprintf("reduced under load: %d requests were waiting for an engine", waiting),
+		QueueLength:           waiting,
+		RequestedMultiPV:      settings.MultiPV,
+		RequestedDepth:        settings.Depth,
+		RequestedTimeLimit:    settings.TimeLimit,
+		EligibleForReanalysis: true,
+	}
+	changed := false
+	if limit := max(policy.MaxMultiPV, minMultiPV); policy.MaxMultiPV > 0 && settings.MultiPV > limit {
+		settings.MultiPV = limit
+		changed = true
+	}
+	depth := settings.Depth
+	if depth == 0 {
+		depth = s.defaultSettings.Depth
+	}
+	if policy.MaxDepth > 0 && (depth == 0 || depth > policy.MaxDepth) {
+		settings.Depth = policy.MaxDepth
+		settings.TimeLimit = 0
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	return reduced
+}
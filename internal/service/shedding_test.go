@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/engine"
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+)
+
+func TestAnalysisService_ShedLoad(t *testing.T) {
+	service := newTestAnalysisService()
+	service.defaultSettings.Depth = 15
+	service.SetLoadShedding(models.LoadShedding{QueueLength: 2, MaxMultiPV: 1, MaxDepth: 10})
+
+	// Every engine is busy and requests pile up
+	pool := &engine.EnginePool{Engines: []*engine.StockfishEngine{{}}, Available: make(chan *engine.StockfishEngine, 1)}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	queue := func(n int) {
+		t.Helper()
+		for waiting, _, _ := pool.QueueStatus(); waiting < n; waiting++ {
+			go pool.Acquire(ctx)
+		}
+		deadline := time.Now().Add(time.Second)
+		for waiting, _, _ := pool.QueueStatus(); waiting < n; waiting, _, _ = pool.QueueStatus() {
+			if time.Now().After(deadline) {
+				t.Fatalf("%d requests waiting, want %d", waiting, n)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	// Settings as the handlers pass them, with the default time limit filled in
+	settings := models.EngineSettings{Depth: 20, MultiPV: 3}
+	service.ApplyDefaultSettings(&settings)
+	queue(1)
+	if reduced := service.shedLoad(pool, &settings, 1); reduced != nil || settings.Depth != 20 || settings.MultiPV != 3 || settings.TimeLimit != 5000 {
+		t.Errorf("shedLoad() below the queue length = %+v, settings %+v", reduced, settings)
+	}
+
+	queue(2)
+	reduced := service.shedLoad(pool, &settings, 1)
+	if reduced == nil || !reduced.EligibleForReanalysis || reduced.RequestedDepth != 20 || reduced.RequestedMultiPV != 3 || reduced.RequestedTimeLimit != 5000 || reduced.QueueLength != 2 {
+		t.Fatalf("shedLoad() = %+v, want the requested settings recorded", reduced)
+	}
+	// A time limit would override the depth cap, so the search runs to depth instead
+	if settings.Depth != 10 || settings.MultiPV != 1 || settings.TimeLimit != 0 {
+		t.Errorf("Reduced settings = %+v, want depth 10, one line and no time limit", settings)
+	}
+
+	// Practical analyses keep their lines, and the default depth is cut back too
+	practical := models.EngineSettings{MultiPV: 3}
+	service.ApplyDefaultSettings(&practical)
+	if reduced := service.shedLoad(pool, &practical, practicalMultiPV); reduced == nil || practical.MultiPV != practicalMultiPV || practical.Depth != 10 || practical.TimeLimit != 0 {
+		t.Errorf("Practical settings = %+v (%+v), want %d lines at depth 10", practical, reduced, practicalMultiPV)
+	}
+
+	// Analyses already within the policy run as requested, time limit included
+	shallow := models.EngineSettings{Depth: 8, MultiPV: 1}
+	service.ApplyDefaultSettings(&shallow)
+	if reduced := service.shedLoad(pool, &shallow, 1); reduced != nil || shallow.TimeLimit != 5000 {
+		t.Errorf("shedLoad() reduced an analysis within the policy: %+v, settings %+v", reduced, shallow)
+	}
+}
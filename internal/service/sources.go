@@ -0,0 +1,575 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/client"
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/internal/parser"
+	"github.com/pedrampdd/ChessAnalyser/internal/storage"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+// GameSource fetches games from somewhere games are published or kept, converted into GameInfo.
+// Every game gets an ID of the form "<source>:<id within the source>", so games of different
+// sources never collide in the store.
+type GameSource interface {
+	// Name is the source's name in ingest requests and game IDs
+	Name() string
+	// FetchGames calls fn with each game matching the query. A game that couldn't be read is
+	// passed as an error instead and skipped; fetching stops when fn returns an error.
+	FetchGames(ctx context.Context, query models.GameSourceQuery, fn func(game *models.GameInfo, err error) error) error
+}
+
+// ChessComSource fetches a player's games from their Chess.com monthly archives, newest month first
+type ChessComSource struct {
+	gameService *GameAnalyzerService
+}
+
+// NewChessComSource creates a game source reading archives through gameService
+func NewChessComSource(gameService *GameAnalyzerService) *ChessComSource {
+	return &ChessComSource{gameService: gameService}
+}
+
+// Name returns the source's name
+func (s *ChessComSource) Name() string {
+	return models.GameSourceChessCom
+}
+
+// FetchGames streams the games of the player's archives overlapping the query's time range
+func (s *ChessComSource) FetchGames(ctx context.Context, query models.GameSourceQuery, fn func(*models.GameInfo, error) error) error {
+	if query.Player == "" {
+		return errors.NewValidationError("player", "player is required for Chess.com")
+	}
+
+	archives, err := s.gameService.GetPlayerArchives(ctx, query.Player)
+	if err != nil {
+		return err
+	}
+
+	for i := len(archives) - 1; i >= 0; i-- {
+		start := time.Date(archives[i][0], time.Month(archives[i][1]), 1, 0, 0, 0, 0, time.UTC)
+		if !query.Until.IsZero() && !start.Before(query.Until) {
+			continue
+		}
+		if !query.Since.IsZero() && !start.AddDate(0, 1, 0).After(query.Since) {
+			break
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		var stop error
+		err := s.gameService.chessAPI.StreamPlayerGames(query.Player, archives[i][0], archives[i][1], func(game *client.ArchiveGame) error {
+			gameInfo := gameInfoFromArchive(game)
+			if gameType, id, ok := parseGameURL(game.URL); ok {
+				gameInfo.GameID = models.GameSourceChessCom + ":" + gameType + "/" + id
+			}
+			if stop = fn(gameInfo, nil); stop != nil {
+				return client.ErrStopStream
+			}
+			return nil
+		})
+		if stop != nil {
+			return stop
+		}
+		if err != nil {
+			return errors.NewAPIError("failed to retrieve games", err)
+		}
+	}
+	return nil
+}
+
+// LichessSource fetches a player's games from the Lichess export API, newest first
+type LichessSource struct {
+	api       *client.LichessAPI
+	pgnParser *parser.PGNParser
+}
+
+// NewLichessSource creates a game source reading exports through api
+func NewLichessSource(api *client.LichessAPI) *LichessSource {
+	return &LichessSource{api: api, pgnParser: parser.NewPGNParser()}
+}
+
+// Name returns the source's name
+func (s *LichessSource) Name() string {
+	return models.GameSourceLichess
+}
+
+// FetchGames streams the player's games started within the query's time range
+func (s *LichessSource) FetchGames(ctx context.Context, query models.GameSourceQuery, fn func(*models.GameInfo, error) error) error {
+	if query.Player == "" {
+		return errors.NewValidationError("player", "player is required for Lichess")
+	}
+
+	export := client.LichessExport{Since: query.Since, Until: query.Until}
+	if query.Max > 0 {
+		export.Max = query.Max + 1 // One more tells the caller the export was truncated
+	}
+
+	var stop error
+	err := s.api.StreamUserGames(ctx, query.Player, export, func(game *client.LichessGame) error {
+		gameInfo, err := s.gameInfo(game)
+		if stop = fn(gameInfo, err); stop != nil {
+			return client.ErrStopStream
+		}
+		return nil
+	})
+	if stop != nil {
+		return stop
+	}
+	if err != nil {
+		return errors.NewAPIError("failed to retrieve Lichess games", err)
+	}
+	return nil
+}
+
+// gameInfo converts an exported Lichess game into GameInfo
+func (s *LichessSource) gameInfo(game *client.LichessGame) (*models.GameInfo, error) {
+	id := models.GameSourceLichess + ":" + game.ID
+	gameInfo, err := pgnGameInfo(s.pgnParser, id, game.PGN)
+	if err != nil {
+		return nil, fmt.Errorf("game %s: %w", id, err)
+	}
+
+	gameInfo.URL = s.api.BaseURL + "/" + game.ID
+	gameInfo.Rated = game.Rated
+	gameInfo.WhitePlayer = lichessPlayer(game.Players.White, s.api.BaseURL)
+	gameInfo.BlackPlayer = lichessPlayer(game.Players.Black, s.api.BaseURL)
+	gameInfo.StartTime = time.UnixMilli(game.CreatedAt)
+	if game.Status != "created" && game.Status != "started" && game.LastMoveAt > 0 {
+		endTime := time.UnixMilli(game.LastMoveAt)
+		gameInfo.EndTime = &endTime
+	}
+	gameInfo.Tournament = game.Tournament
+
+	switch game.Variant {
+	case "", "standard", "fromPosition":
+		gameInfo.Rules = "chess"
+	default:
+		gameInfo.Rules = strings.ToLower(game.Variant)
+	}
+
+	// Time controls and classes follow Chess.com's conventions
+	switch {
+	case game.Clock != nil:
+		gameInfo.TimeControl = strconv.Itoa(game.Clock.Initial)
+		if game.Clock.Increment > 0 {
+			gameInfo.TimeControl += "+" + strconv.Itoa(game.Clock.Increment)
+		}
+	case game.DaysPerTurn > 0:
+		gameInfo.TimeControl = "1/" + strconv.Itoa(game.DaysPerTurn*86400)
+	}
+	switch game.Speed {
+	case "ultraBullet":
+		gameInfo.TimeClass = "bullet"
+	case "correspondence":
+		gameInfo.TimeClass = "daily"
+	default:
+		gameInfo.TimeClass = game.Speed
+	}
+
+	return gameInfo, nil
+}
+
+// lichessPlayer converts a Lichess player into Player. Lichess's computer opponent has no account.
+func lichessPlayer(player client.LichessPlayer, baseURL string) models.Player {
+	if player.User == nil {
+		if player.AILevel > 0 {
+			return models.Player{Username: fmt.Sprintf("Stockfish level %d", player.AILevel), IsBot: true}
+		}
+		return models.Player{Username: "Anonymous"}
+	}
+	return models.Player{
+		Username: player.User.Name,
+		URL:      baseURL + "/@/" + player.User.Name,
+		Title:    player.User.Title,
+		Rating:   player.Rating,
+		IsBot:    player.User.Title == "BOT",
+	}
+}
+
+// PGNDirectorySource reads games from the PGN files of a local directory, e.g. over-the-board
+// tournament databases. Files are read in name order, so a game's index within its file is stable.
+// Symbolic links are never followed, so nothing outside the directory can be read.
+type PGNDirectorySource struct {
+	dir       string
+	pgnParser *parser.PGNParser
+}
+
+// NewPGNDirectorySource creates a game source reading the PGN files under dir
+func NewPGNDirectorySource(dir string) *PGNDirectorySource {
+	return &PGNDirectorySource{dir: dir, pgnParser: parser.NewPGNParser()}
+}
+
+// Name returns the source's name
+func (s *PGNDirectorySource) Name() string {
+	return models.GameSourcePGN
+}
+
+// FetchGames reads the games of the PGN files in the directory, or only of the file or
+// subdirectory named by the query's collection
+func (s *PGNDirectorySource) FetchGames(ctx context.Context, query models.GameSourceQuery, fn func(*models.GameInfo, error) error) error {
+	root := s.dir
+	if query.Collection != "" {
+		if !filepath.IsLocal(query.Collection) {
+			return errors.NewValidationError("collection", "collection must be a path within the PGN directory")
+		}
+		root = filepath.Join(s.dir, query.Collection)
+		if err := checkNoSymlinks(s.dir, query.Collection); err != nil {
+			return err
+		}
+	}
+
+	var files []string
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.Type()&fs.ModeSymlink != 0 {
+			return nil // Not followed, even when it points within the directory
+		}
+		if !entry.IsDir() && strings.EqualFold(filepath.Ext(path), ".pgn") {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if os.IsNotExist(err) {
+		return errors.NewValidationError("collection", fmt.Sprintf("%q not found in the PGN directory", query.Collection))
+	}
+	if err != nil {
+		return errors.NewStorageError("read PGN directory", err)
+	}
+	slices.Sort(files)
+
+	for _, path := range files {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		rel, _ := filepath.Rel(s.dir, path)
+		if err := s.readFile(path, models.GameSourcePGN+":"+filepath.ToSlash(rel), fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkNoSymlinks rejects a collection path within dir when any of its elements is a symbolic link
+func checkNoSymlinks(dir, collection string) error {
+	path := dir
+	for _, name := range strings.Split(filepath.Clean(collection), string(filepath.Separator)) {
+		path = filepath.Join(path, name)
+		info, err := os.Lstat(path)
+		if os.IsNotExist(err) {
+			return errors.NewValidationError("collection", fmt.Sprintf("%q not found in the PGN directory", collection))
+		}
+		if err != nil {
+			return errors.NewStorageError("read PGN directory", err)
+		}
+		if info.Mode()&fs.ModeSymlink != 0 {
+			return errors.NewValidationError("collection", "collection must not go through a symbolic link")
+		}
+	}
+	return nil
+}
+
+// readFile reads the games of one PGN file
+func (s *PGNDirectorySource) readFile(path, id string, fn func(*models.GameInfo, error) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return errors.NewStorageError("open PGN file", err)
+	}
+	defer file.Close()
+
+	return readPGNGames(s.pgnParser, file, id, fn)
+}
+
+// TWICSource reads games from issues of The Week in Chess
+type TWICSource struct {
+	api       *client.TWICClient
+	pgnParser *parser.PGNParser
+}
+
+// NewTWICSource creates a game source downloading issues through api
+func NewTWICSource(api *client.TWICClient) *TWICSource {
+	return &TWICSource{api: api, pgnParser: parser.NewPGNParser()}
+}
+
+// Name returns the source's name
+func (s *TWICSource) Name() string {
+	return models.GameSourceTWIC
+}
+
+// FetchGames reads the games of the issue named by the query's collection
+func (s *TWICSource) FetchGames(ctx context.Context, query models.GameSourceQuery, fn func(*models.GameInfo, error) error) error {
+	issue, err := strconv.Atoi(query.Collection)
+	if err != nil || issue <= 0 {
+		return errors.NewValidationError("collection", "collection must be a TWIC issue number")
+	}
+
+	body, err := s.api.OpenIssuePGN(ctx, issue)
+	if err != nil {
+		return errors.NewAPIError("failed to download TWIC issue", err)
+	}
+	defer body.Close()
+
+	return readPGNGames(s.pgnParser, body, models.GameSourceTWIC+":"+strconv.Itoa(issue), fn)
+}
+
+// readPGNGames reads the games of a multi-game PGN, identifying each by its 1-based index
+func readPGNGames(pgnParser *parser.PGNParser, r io.Reader, id string, fn func(*models.GameInfo, error) error) error {
+	var stop error
+	index := 0
+	err := parser.SplitGames(r, func(_ int64, pgn string) error {
+		index++
+		gameID := fmt.Sprintf("%s#%d", id, index)
+		gameInfo, err := pgnGameInfo(pgnParser, gameID, pgn)
+		if err != nil {
+			err = fmt.Errorf("game %s: %w", gameID, err)
+		}
+		if stop = fn(gameInfo, err); stop != nil {
+			return stop
+		}
+		return nil
+	})
+	if stop != nil {
+		return stop
+	}
+	if err != nil {
+		return errors.NewStorageError("read PGN", err)
+	}
+	return nil
+}
+
+// pgnGameInfo converts a game's PGN into GameInfo. Only the PGN and the final position are kept;
+// the site becomes the game's URL when it is one.
+func pgnGameInfo(pgnParser *parser.PGNParser, gameID, pgn string) (*models.GameInfo, error) {
+	parsed, err := pgnParser.ParsePGN(pgn)
+	if err != nil {
+		return nil, err
+	}
+	if err := pgnParser.ExtractPositions(parsed); err != nil {
+		return nil, err
+	}
+
+	gameInfo := pgnParser.ConvertToGameInfo(parsed)
+	gameInfo.GameID = gameID
+	gameInfo.Moves = nil
+	if !strings.HasPrefix(gameInfo.URL, "http://") && !strings.HasPrefix(gameInfo.URL, "https://") {
+		gameInfo.URL = ""
+	}
+	if gameInfo.Rules == "" {
+		gameInfo.Rules = "chess"
+	}
+	if variant := parsed.Headers["variant"]; variant != "" && !strings.EqualFold(variant, "standard") {
+		gameInfo.Rules = strings.ToLower(variant)
+	}
+	gameInfo.WhitePlayer.Rating, _ = strconv.Atoi(parsed.Headers["whiteelo"])
+	gameInfo.BlackPlayer.Rating, _ = strconv.Atoi(parsed.Headers["blackelo"])
+	gameInfo.WhitePlayer.Title = parsed.Headers["whitetitle"]
+	gameInfo.BlackPlayer.Title = parsed.Headers["blacktitle"]
+
+	gameInfo.FEN = parsed.Headers["fen"]
+	if len(parsed.Moves) > 0 {
+		gameInfo.FEN = parsed.Moves[len(parsed.Moves)-1].FEN
+	}
+	return gameInfo, nil
+}
+
+// Ingestion limits
+const (
+	MaxIngestGames = 10000 // Games one ingest stores at most
+
+	defaultIngestMax  = 1000
+	maxIngestErrors   = 10 // Errors of skipped games reported in an ingest result
+	maxRunningIngests = 2  // Ingests running at once
+	ingestTimeout     = 30 * time.Minute
+	ingestRetention   = time.Hour // Finished ingests are kept this long for clients to poll
+)
+
+// SetGameSources registers the sources games can be ingested from, replacing any registered before
+func (s *SyncService) SetGameSources(sources ...GameSource) {
+	s.sources = make(map[string]GameSource, len(sources))
+	for _, source := range sources {
+		s.sources[source.Name()] = source
+	}
+}
+
+// GameSources returns the names of the registered game sources, sorted
+func (s *SyncService) GameSources() []string {
+	names := make([]string, 0, len(s.sources))
+	for name := range s.sources {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// StartIngest validates an ingest query and starts ingesting its games in the background. It
+// fails with a capacity error while as many ingests as allowed are running.
+func (s *SyncService) StartIngest(query models.GameSourceQuery) (*models.IngestJob, error) {
+	if _, err := s.normalizeIngestQuery(&query); err != nil {
+		return nil, err
+	}
+	id, err := storage.NewID()
+	if err != nil {
+		return nil, err
+	}
+
+	s.ingestMu.Lock()
+	defer s.ingestMu.Unlock()
+	s.pruneIngests()
+	if s.ingesting >= maxRunningIngests {
+		return nil, errors.NewCapacityError("ingests running at once", maxRunningIngests)
+	}
+	s.ingesting++
+
+	job := &models.IngestJob{
+		ID:         id,
+		Source:     query.Source,
+		Player:     query.Player,
+		Collection: query.Collection,
+		Status:     models.JobStatusRunning,
+		CreatedAt:  s.now(),
+	}
+	s.ingests[id] = job
+	snapshot := *job
+
+	go s.runIngest(job, query)
+	return &snapshot, nil
+}
+
+// runIngest ingests a job's games within the ingest timeout and records the outcome
+func (s *SyncService) runIngest(job *models.IngestJob, query models.GameSourceQuery) {
+	ctx, cancel := context.WithTimeout(s.ctx, ingestTimeout)
+	defer cancel()
+	result, err := s.IngestGames(ctx, query)
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		err = errors.NewTimeoutError("ingest", err)
+	}
+
+	s.ingestMu.Lock()
+	defer s.ingestMu.Unlock()
+	s.ingesting--
+	completed := s.now()
+	job.CompletedAt = &completed
+	if err != nil {
+		job.Status = models.JobStatusFailed
+		job.Error = err.Error()
+		return
+	}
+	job.Status = models.JobStatusCompleted
+	job.Result = result
+}
+
+// GetIngest returns the state of an ingest job, with its result once it is done
+func (s *SyncService) GetIngest(id string) (*models.IngestJob, error) {
+	s.ingestMu.Lock()
+	defer s.ingestMu.Unlock()
+
+	job, ok := s.ingests[id]
+	if !ok {
+		return nil, errors.NewIngestNotFoundError(id)
+	}
+	snapshot := *job
+	return &snapshot, nil
+}
+
+// pruneIngests forgets ingests that finished longer ago than the retention period. The caller
+// must hold s.ingestMu.
+func (s *SyncService) pruneIngests() {
+	for id, job := range s.ingests {
+		if job.CompletedAt != nil && s.now().Sub(*job.CompletedAt) > ingestRetention {
+			delete(s.ingests, id)
+		}
+	}
+}
+
+// normalizeIngestQuery validates an ingest query, fills in the defaults and returns its source
+func (s *SyncService) normalizeIngestQuery(query *models.GameSourceQuery) (GameSource, error) {
+	source, ok := s.sources[query.Source]
+	if !ok {
+		return nil, errors.NewValidationError("source", fmt.Sprintf("unknown game source %q", query.Source))
+	}
+	if query.Max < 0 || query.Max > MaxIngestGames {
+		return nil, errors.NewValidationError("max", fmt.Sprintf("max must be between 1 and %d", MaxIngestGames))
+	}
+	if query.Max == 0 {
+		query.Max = defaultIngestMax
+	}
+	if !query.Since.IsZero() && !query.Until.IsZero() && !query.Since.Before(query.Until) {
+		return nil, errors.NewValidationError("until", "until must be after since")
+	}
+	return source, nil
+}
+
+// IngestGames stores the games of a source that weren't stored before and queues them for
+// automatic analysis, within the request. Games are stored under the query's player, who must
+// have played them, or under both players when the query names none.
+func (s *SyncService) IngestGames(ctx context.Context, query models.GameSourceQuery) (*models.IngestResult, error) {
+	source, err := s.normalizeIngestQuery(&query)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.IngestResult{Source: source.Name()}
+	err = source.FetchGames(ctx, query, func(game *models.GameInfo, err error) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			result.Skipped++
+			if len(result.Errors) < maxIngestErrors {
+				result.Errors = append(result.Errors, err.Error())
+			}
+			return nil
+		}
+		if !query.Since.IsZero() && game.StartTime.Before(query.Since) {
+			return nil
+		}
+		if !query.Until.IsZero() && !game.StartTime.Before(query.Until) {
+			return nil
+		}
+
+		owners := []string{game.WhitePlayer.Username, game.BlackPlayer.Username}
+		if query.Player != "" {
+			if !slices.ContainsFunc(owners, func(owner string) bool { return strings.EqualFold(owner, query.Player) }) {
+				return nil
+			}
+			owners = []string{query.Player}
+		}
+
+		if result.Fetched == query.Max {
+			result.Truncated = true
+			return client.ErrStopStream
+		}
+		result.Fetched++
+
+		added := false
+		for _, owner := range owners {
+			if owner != "" && len(s.store.SaveGames(owner, []*models.GameInfo{game})) > 0 {
+				added = true
+			}
+		}
+		if added {
+			result.Added++
+			if s.queueAnalysis(game) {
+				result.AnalysesQueued++
+			}
+		}
+		return nil
+	})
+	if err != nil && err != client.ErrStopStream {
+		return nil, err
+	}
+	return result, nil
+}
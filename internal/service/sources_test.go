@@ -0,0 +1,244 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/client"
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/internal/storage"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+func TestSyncService_IngestLichess(t *testing.T) {
+	games := []string{
+		`{"id":"abc12345","rated":true,"variant":"standard","speed":"blitz","createdAt":1700000000000,"lastMoveAt":1700000300000,"status":"mate","winner":"white",` +
+			`"players":{"white":{"user":{"name":"Alice"},"rating":1850},"black":{"aiLevel":3}},"clock":{"initial":180,"increment":2},` +
+			`"pgn":"[Event \"Rated blitz game\"]\n[White \"Alice\"]\n[Black \"lichess AI level 3\"]\n[Result \"1-0\"]\n\n1. e4 e5 2. Qh5 Nc6 3. Bc4 Nf6 4. Qxf7# 1-0\n"}`,
+		`{"id":"def67890","rated":false,"variant":"chess960","speed":"correspondence","createdAt":1690000000000,"status":"started","daysPerTurn":2,` +
+			`"players":{"white":{"user":{"name":"Bob","title":"FM"},"rating":2300},"black":{"user":{"name":"Alice"},"rating":1850}},` +
+			`"pgn":"[White \"Bob\"]\n[Black \"Alice\"]\n[Result \"*\"]\n\n1. e4 *\n"}`,
+		`{"id":"broken00","players":{"white":{"user":{"name":"Alice"}},"black":{"user":{"name":"Carol"}}},"pgn":"1. e5 *"}`,
+	}
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/games/user/alice" {
+			http.NotFound(w, r)
+			return
+		}
+		query = r.URL.RawQuery
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		fmt.Fprint(w, strings.Join(games, "\n")+"\n")
+	}))
+	defer server.Close()
+
+	api := client.NewLichessAPI()
+	api.BaseURL = server.URL
+	sync := NewSyncService(NewGameAnalyzerService(), nil, storage.NewMemoryStore(), nil, time.Minute, false)
+	sync.SetGameSources(NewLichessSource(api))
+
+	result, err := sync.IngestGames(context.Background(), models.GameSourceQuery{Source: models.GameSourceLichess, Player: "alice"})
+	if err != nil {
+		t.Fatalf("IngestGames() error = %v", err)
+	}
+	if result.Fetched != 2 || result.Added != 2 || result.Skipped != 1 || len(result.Errors) != 1 {
+		t.Errorf("Unexpected ingest result: %+v", result)
+	}
+	if !strings.Contains(query, "max=1001") {
+		t.Errorf("Expected the export to be limited to max+1 games, got query %q", query)
+	}
+
+	page, _ := sync.GetSyncedGames("alice", models.PageRequest{})
+	if len(page.Items) != 2 {
+		t.Fatalf("Expected 2 stored games, got %d", len(page.Items))
+	}
+	correspondence, blitz := page.Items[0], page.Items[1]
+	if blitz.GameID != "lichess:abc12345" || blitz.URL != server.URL+"/abc12345" || blitz.Rules != "chess" ||
+		blitz.TimeControl != "180+2" || blitz.TimeClass != "blitz" || blitz.EndTime == nil || !blitz.Rated {
+		t.Errorf("Unexpected blitz game: %+v", blitz)
+	}
+	if !blitz.BlackPlayer.IsBot || blitz.BlackPlayer.Username != "Stockfish level 3" || blitz.WhitePlayer.Rating != 1850 {
+		t.Errorf("Unexpected blitz players: %+v vs %+v", blitz.WhitePlayer, blitz.BlackPlayer)
+	}
+	if !strings.HasPrefix(blitz.FEN, "r1bqkb1r/pppp1Qpp/2n2n2/4p3/2B1P3") {
+		t.Errorf("Expected the final position, got %q", blitz.FEN)
+	}
+	if correspondence.Rules != "chess960" || correspondence.TimeControl != "1/172800" || correspondence.TimeClass != "daily" ||
+		correspondence.EndTime != nil || correspondence.WhitePlayer.Title != "FM" {
+		t.Errorf("Unexpected correspondence game: %+v", correspondence)
+	}
+
+	// Ingesting again stores nothing new
+	if result, _ = sync.IngestGames(context.Background(), models.GameSourceQuery{Source: models.GameSourceLichess, Player: "alice"}); result.Added != 0 {
+		t.Errorf("Expected no new games on a second ingest, got %+v", result)
+	}
+}
+
+func TestSyncService_IngestPGNDirectory(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "2024"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	club := `[Event "Club Championship"]
+[Site "Leiden"]
+[Date "2024.03.01"]
+[White "Alice"]
+[Black "Bob"]
+[Result "1-0"]
+[WhiteElo "1900"]
+
+1. d4 d5 2. c4 1-0
+
+[Event "Club Championship"]
+[Site "Leiden"]
+[Date "2024.03.08"]
+[White "Carol"]
+[Black "Alice"]
+[Result "0-1"]
+
+1. e4 c5 0-1
+
+[Event "Club Championship"]
+[Site "https://example.org/games/3"]
+[Date "2024.03.15"]
+[White "Bob"]
+[Black "Carol"]
+[Result "1/2-1/2"]
+
+1. Nf3 Nf6 1/2-1/2
+`
+	for name, content := range map[string]string{"2024/club.pgn": club, "notes.txt": "not a game"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sync := NewSyncService(NewGameAnalyzerService(), nil, storage.NewMemoryStore(), nil, time.Minute, false)
+	sync.SetGameSources(NewPGNDirectorySource(dir))
+
+	// Without a player every game is stored under both players
+	result, err := sync.IngestGames(context.Background(), models.GameSourceQuery{Source: models.GameSourcePGN, Max: 2})
+	if err != nil {
+		t.Fatalf("IngestGames() error = %v", err)
+	}
+	if result.Fetched != 2 || result.Added != 2 || !result.Truncated {
+		t.Errorf("Unexpected ingest result: %+v", result)
+	}
+	alice, _ := sync.GetSyncedGames("alice", models.PageRequest{})
+	if len(alice.Items) != 2 || alice.Items[0].GameID != "pgn:2024/club.pgn#1" || alice.Items[1].GameID != "pgn:2024/club.pgn#2" {
+		t.Fatalf("Unexpected games of Alice: %+v", alice.Items)
+	}
+	if game := alice.Items[0]; game.URL != "" || game.Rules != "chess" || game.WhitePlayer.Rating != 1900 || game.FEN == "" {
+		t.Errorf("Unexpected over-the-board game: %+v", game)
+	}
+
+	// A player's games within a time range; the first game is already stored
+	result, err = sync.IngestGames(context.Background(), models.GameSourceQuery{
+		Source: models.GameSourcePGN, Player: "bob", Collection: "2024",
+		Since: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("IngestGames() error = %v", err)
+	}
+	if result.Fetched != 2 || result.Added != 1 || result.Truncated {
+		t.Errorf("Unexpected ingest result for Bob: %+v", result)
+	}
+	bob, _ := sync.GetSyncedGames("bob", models.PageRequest{})
+	if len(bob.Items) != 2 || bob.Items[1].URL != "https://example.org/games/3" {
+		t.Errorf("Unexpected games of Bob: %+v", bob.Items)
+	}
+
+	if _, err := sync.IngestGames(context.Background(), models.GameSourceQuery{Source: models.GameSourcePGN, Collection: "../etc"}); err == nil {
+		t.Error("Expected a collection outside the directory to be rejected")
+	}
+	if _, err := sync.IngestGames(context.Background(), models.GameSourceQuery{Source: models.GameSourceTWIC, Collection: "1550"}); err == nil {
+		t.Error("Expected an unregistered source to be rejected")
+	}
+}
+
+func TestPGNDirectorySource_SkipsSymlinks(t *testing.T) {
+	dir, outside := t.TempDir(), t.TempDir()
+	game := "[White \"Alice\"]\n[Black \"Bob\"]\n[Result \"1-0\"]\n\n1. e4 1-0\n"
+	for _, path := range []string{filepath.Join(dir, "club.pgn"), filepath.Join(outside, "secret.pgn")} {
+		if err := os.WriteFile(path, []byte(game), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(filepath.Join(outside, "secret.pgn"), filepath.Join(dir, "linked.pgn")); err != nil {
+		t.Skipf("Symbolic links unavailable: %v", err)
+	}
+	if err := os.Symlink(outside, filepath.Join(dir, "linked")); err != nil {
+		t.Fatal(err)
+	}
+
+	source := NewPGNDirectorySource(dir)
+	var ids []string
+	err := source.FetchGames(context.Background(), models.GameSourceQuery{}, func(game *models.GameInfo, err error) error {
+		if err == nil {
+			ids = append(ids, game.GameID)
+		}
+		return nil
+	})
+	if err != nil || strings.Join(ids, ",") != "pgn:club.pgn#1" {
+		t.Errorf("Expected only the club's own file to be read, got %v (error %v)", ids, err)
+	}
+
+	for _, collection := range []string{"linked", "linked/secret.pgn", "linked.pgn"} {
+		var validation *errors.ValidationError
+		err := source.FetchGames(context.Background(), models.GameSourceQuery{Collection: collection}, func(*models.GameInfo, error) error {
+			t.Errorf("Expected nothing to be read through %s", collection)
+			return nil
+		})
+		if !errors.As(err, &validation) {
+			t.Errorf("Expected collection %s to be rejected, got %v", collection, err)
+		}
+	}
+}
+
+func TestSyncService_StartIngest(t *testing.T) {
+	dir := t.TempDir()
+	game := "[White \"Alice\"]\n[Black \"Bob\"]\n[Result \"1-0\"]\n\n1. e4 1-0\n"
+	if err := os.WriteFile(filepath.Join(dir, "club.pgn"), []byte(game+"\n"+game), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	sync := NewSyncService(NewGameAnalyzerService(), nil, storage.NewMemoryStore(), nil, time.Minute, false)
+	defer sync.Close()
+	sync.SetGameSources(NewPGNDirectorySource(dir))
+
+	var validation *errors.ValidationError
+	if _, err := sync.StartIngest(models.GameSourceQuery{Source: models.GameSourcePGN, Max: MaxIngestGames + 1}); !errors.As(err, &validation) {
+		t.Fatalf("Expected an invalid query to be refused before starting, got %v", err)
+	}
+
+	job, err := sync.StartIngest(models.GameSourceQuery{Source: models.GameSourcePGN, Player: "alice"})
+	if err != nil {
+		t.Fatalf("StartIngest() error = %v", err)
+	}
+	if job.Status != models.JobStatusRunning || job.Source != models.GameSourcePGN || job.Player != "alice" {
+		t.Errorf("Expected a running job, got %+v", job)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for job.Status == models.JobStatusRunning && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		if job, err = sync.GetIngest(job.ID); err != nil {
+			t.Fatalf("GetIngest() error = %v", err)
+		}
+	}
+	if job.Status != models.JobStatusCompleted || job.Result == nil || job.Result.Added != 2 || job.CompletedAt == nil {
+		t.Fatalf("Expected the completed ingest, got %+v", job)
+	}
+
+	var notFound *errors.IngestNotFoundError
+	if _, err := sync.GetIngest("missing"); !errors.As(err, &notFound) {
+		t.Errorf("Expected a not found error, got %v", err)
+	}
+}
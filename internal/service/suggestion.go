@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/engine"
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+// SuggestMove finds the objectively best move and the move a player of the given rating should find
+func (s *AnalysisService) SuggestMove(ctx context.Context, fen string, rating int, settings models.EngineSettings) (*models.MoveSuggestion, error) {
+	if rating <= 0 {
+		return nil, errors.NewValidationError("rating", "a positive player rating is required")
+	}
+
+	pool, err := s.poolFor(settings.Variant, "")
+	if err != nil {
+		return nil, err
+	}
+	stockfishEngine, err := pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer pool.ReturnEngine(stockfishEngine)
+
+	settings.LimitStrength = false
+	best, err := stockfishEngine.AnalyzePosition(ctx, fen, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	humanMove, elo, err := s.humanMove(ctx, stockfishEngine, fen, rating, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.MoveSuggestion{
+		FEN:            fen,
+		Rating:         rating,
+		Elo:            elo,
+		BestMove:       best.BestMove,
+		BestEvaluation: best.Evaluation,
+		BestLine:       best.PrincipalVariation,
+		HumanMove:      humanMove,
+	}, nil
+}
+
+// humanMove runs a strength-limited search around the player's rating and returns the chosen move
+func (s *AnalysisService) humanMove(ctx context.Context, stockfishEngine *engine.StockfishEngine, fen string, rating int, settings models.EngineSettings) (string, int, error) {
+	limited := settings
+	limited.LimitStrength = true
+	limited.Elo = engine.ClampElo(rating)
+	limited.MultiPV = 1
+
+	result, err := stockfishEngine.AnalyzePosition(ctx, fen, limited)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return result.BestMove, limited.Elo, nil
+}
@@ -0,0 +1,312 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/blob"
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/internal/notify"
+	"github.com/pedrampdd/ChessAnalyser/internal/parser"
+	"github.com/pedrampdd/ChessAnalyser/internal/storage"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+// Archive sync settings
+const (
+	syncBackfillMonths  = 1   // Archives fetched the first time a player is synced
+	syncAnalysisBacklog = 100 // Games waiting for automatic analysis before new ones are dropped
+	defaultSyncInterval = 30 * time.Minute
+)
+
+// SyncService periodically stores configured players' new games and optionally analyzes them
+type SyncService struct {
+	gameService     *GameAnalyzerService
+	analysisService *AnalysisService
+	store           *storage.MemoryStore
+	players         []string
+	interval        time.Duration
+	autoAnalyze     bool
+	analysisQueue   chan *models.GameInfo
+	blunderQueue    chan blunderCheck
+	rawArchives     blob.Store      // Keeps raw archive responses, nil to disable
+	rawRetention    time.Duration   // Older snapshots no game traces back to are deleted (0 = keep all)
+	rawMu           sync.Mutex      // Serializes updates of the raw archive index
+	mu              sync.Mutex      // Guards syncing
+	syncing         map[string]bool // Players being synced or repaired, who only that run updates
+	cursorMu        sync.Mutex      // Serializes polls for new games
+	pgnParser       *parser.PGNParser
+	now             func() time.Time
+	notifier        *notify.Notifier
+	sources         map[string]GameSource // Sources games can be ingested from, by name
+	ctx             context.Context       // Cancelled on Close, stopping running ingests
+	cancel          context.CancelFunc
+
+	ingestMu  sync.Mutex // Guards the fields below
+	ingests   map[string]*models.IngestJob
+	ingesting int // Ingests running
+
+	notifyMu      sync.Mutex // Guards the fields below
+	notify        NotificationOptions
+	notifications []models.PlayerNotification // Most recent last
+	published     int                         // Notifications published, including ones trimmed since
+	subscribers   map[chan models.PlayerNotification]struct{}
+}
+
+// NewSyncService creates a new archive sync service
+func NewSyncService(gameService *GameAnalyzerService, analysisService *AnalysisService, store *storage.MemoryStore,
+	players []string, interval time.Duration, autoAnalyze bool) *SyncService {
+	if interval <= 0 {
+		interval = defaultSyncInterval
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &SyncService{
+		gameService:     gameService,
+		analysisService: analysisService,
+		store:           store,
+		players:         players,
+		interval:        interval,
+		autoAnalyze:     autoAnalyze,
+		analysisQueue:   make(chan *models.GameInfo, syncAnalysisBacklog),
+		blunderQueue:    make(chan blunderCheck, blunderCheckBacklog),
+		pgnParser:       parser.NewPGNParser(),
+		now:             time.Now,
+		notifier:        notify.New(notify.Settings{}),
+		notify:          NotificationOptions{MilestoneStep: defaultMilestoneStep, StreakLength: defaultStreakLength},
+		subscribers:     make(map[chan models.PlayerNotification]struct{}),
+		syncing:         make(map[string]bool),
+		ctx:             ctx,
+		cancel:          cancel,
+		ingests:         make(map[string]*models.IngestJob),
+	}
+}
+
+// Close stops running ingests
+func (s *SyncService) Close() {
+	s.cancel()
+}
+
+// Start syncs all configured players immediately and then every interval until ctx is cancelled
+func (s *SyncService) Start(ctx context.Context) {
+	if s.autoAnalyze {
+		go s.analyzeQueued(ctx)
+	}
+	s.notifyMu.Lock()
+	blunderCheck := s.notify.BlunderCheck
+	s.notifyMu.Unlock()
+	if blunderCheck {
+		go s.checkBlunders(ctx)
+	}
+
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			s.syncAll(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// syncAll syncs every configured player, logging failures
+func (s *SyncService) syncAll(ctx context.Context) {
+	for _, username := range s.players {
+		if ctx.Err() != nil {
+			return
+		}
+		if _, err := s.SyncPlayer(ctx, username); err != nil {
+			log.Printf("Archive sync failed for %s: %v", username, err)
+		}
+	}
+}
+
+// SyncPlayer fetches archives that may contain new games and stores the games not seen before.
+// Unchanged archives are skipped using their ETags.
+func (s *SyncService) SyncPlayer(ctx context.Context, username string) (*models.SyncState, error) {
+	if username == "" {
+		return nil, errors.NewValidationError("username", "username is required")
+	}
+
+	release, err := s.claimPlayer(username)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	state := s.store.GetSyncState(username)
+	if state == nil {
+		state = &models.SyncState{Username: strings.ToLower(username), ArchiveETags: make(map[string]string)}
+	}
+
+	// Nothing is announced from the games backfilled on a player's first sync
+	baseline := state.LastArchive == ""
+
+	archives, err := s.gameService.GetPlayerArchives(ctx, username)
+	if err != nil {
+		return s.saveFailure(state, err)
+	}
+
+	for _, archive := range archivesToSync(archives, state.LastArchive) {
+		if ctx.Err() != nil {
+			return s.saveFailure(state, ctx.Err())
+		}
+
+		key := archiveKey(archive[0], archive[1])
+		games, raw, err := s.gameService.GetPlayerGamesIfModified(username, archive[0], archive[1], state.ArchiveETags[key])
+		if err != nil && !isArchiveNotFound(err) { // A month without an archive has no games to sync
+			return s.saveFailure(state, err)
+		}
+
+		if raw != nil {
+			// The raw response is kept before its games so stored games always have a source
+			if err := s.saveRawArchive(ctx, username, archive[0], archive[1], raw, games); err != nil {
+				return s.saveFailure(state, err)
+			}
+
+			added := s.store.SaveGames(username, games)
+			state.GamesSynced += len(added)
+			for i, game := range added {
+				// Games missing their PGN are repaired now; failed re-fetches are retried by RepairGames
+				if needsRepair(game) {
+					outcome, stored := s.repairGame(username, game)
+					game, added[i] = stored, stored
+					switch outcome.Status {
+					case models.RepairStatusRepaired:
+						state.GamesRepaired++
+					case models.RepairStatusUnanalyzable:
+						state.Unanalyzable++
+					}
+				}
+				// Games that can't be analyzed are neither analyzed nor checked for blunders
+				if game.UnanalyzableReason != "" {
+					continue
+				}
+				if s.queueAnalysis(game) {
+					state.AnalysesQueued++
+				}
+				s.queueBlunderCheck(username, game, baseline)
+			}
+			s.detectStatusChanges(state, username, added, baseline)
+
+			state.ArchiveETags[key] = raw.ETag
+		}
+		state.LastArchive = key
+	}
+
+	state.LastSync = time.Now()
+	state.LastError = ""
+	s.store.SaveSyncState(state)
+	return state, nil
+}
+
+// claimPlayer marks a player as being synced or repaired until the returned function is called,
+// failing when another sync or repair of the player is running. The lock is only held to check
+// and mark the player, so the network calls of one player's sync never hold up another's.
+func (s *SyncService) claimPlayer(username string) (func(), error) {
+	key := strings.ToLower(username)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.syncing[key] {
+		return nil, errors.NewCapacityError("syncs or repairs of a player at once", 1)
+	}
+	s.syncing[key] = true
+	return func() {
+		s.mu.Lock()
+		delete(s.syncing, key)
+		s.mu.Unlock()
+	}, nil
+}
+
+// saveFailure records a sync error on the player's state
+func (s *SyncService) saveFailure(state *models.SyncState, err error) (*models.SyncState, error) {
+	state.LastSync = time.Now()
+	state.LastError = err.Error()
+	s.store.SaveSyncState(state)
+	return state, err
+}
+
+// GetSyncStatus returns the sync state of every configured player
+func (s *SyncService) GetSyncStatus() []*models.SyncState {
+	states := make([]*models.SyncState, 0, len(s.players))
+	for _, username := range s.players {
+		state := s.store.GetSyncState(username)
+		if state == nil {
+			state = &models.SyncState{Username: strings.ToLower(username)}
+		}
+		states = append(states, state)
+	}
+	return states
+}
+
+// GetSyncedGames returns a page of the games stored for a player, oldest first
+func (s *SyncService) GetSyncedGames(username string, request models.PageRequest) (*models.Page[*models.GameInfo], error) {
+	return s.store.QueryGames(username, request)
+}
+
+// queueAnalysis queues a new game for automatic analysis, reporting whether it was queued
+func (s *SyncService) queueAnalysis(game *models.GameInfo) bool {
+	if !s.autoAnalyze || game.PGN == "" || game.UnanalyzableReason != "" {
+		return false
+	}
+
+	select {
+	case s.analysisQueue <- game:
+		return true
+	default:
+		log.Printf("Analysis backlog full, skipping %s", game.URL)
+		return false
+	}
+}
+
+// analyzeQueued analyzes queued games one at a time so syncing never monopolizes the engine pool
+func (s *SyncService) analyzeQueued(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case game := <-s.analysisQueue:
+			_, err := s.analysisService.AnalyzeGame(ctx, &models.AnalysisRequest{
+				PGN:      game.PGN,
+				Settings: s.analysisService.defaultSettings,
+			})
+			if err != nil {
+				log.Printf("Automatic analysis failed for %s: %v", game.URL, err)
+			}
+		}
+	}
+}
+
+// archivesToSync returns the archives that may contain unsynced games: the last synced
+// archive (it may have grown) and every newer one, or the most recent ones on a first sync
+func archivesToSync(archives [][2]int, lastArchive string) [][2]int {
+	if lastArchive == "" {
+		if len(archives) > syncBackfillMonths {
+			return archives[len(archives)-syncBackfillMonths:]
+		}
+		return archives
+	}
+
+	var pending [][2]int
+	for _, archive := range archives {
+		if archiveKey(archive[0], archive[1]) >= lastArchive {
+			pending = append(pending, archive)
+		}
+	}
+	return pending
+}
+
+// archiveKey formats an archive month as "YYYY/MM", which sorts chronologically
+func archiveKey(year, month int) string {
+	return fmt.Sprintf("%04d/%02d", year, month)
+}
@@ -0,0 +1,505 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/blob"
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/internal/storage"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+func TestSyncService_SyncPlayer(t *testing.T) {
+	games := []string{`{"url": "https://www.chess.com/game/live/1", "rules": "chess", "start_time": 1700000000}`}
+	etag := func() string { return fmt.Sprintf(`"v%d"`, len(games)) }
+	monthRequests := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/player/alice/games/archives":
+			fmt.Fprintf(w, `{"archives": ["%[1]s/player/alice/games/2023/10", "%[1]s/player/alice/games/2023/11"]}`, "https://api.chess.com/pub")
+		case "/player/alice/games/2023/11":
+			monthRequests++
+			if r.Header.Get("If-None-Match") == etag() {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", etag())
+			fmt.Fprintf(w, `{"games": [%s]}`, strings.Join(games, ","))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	gameService := NewGameAnalyzerService()
+	gameService.chessAPI.BaseURL = server.URL
+	gameService.chessAPI.CallbackURL = server.URL + "/callback"
+	sync := NewSyncService(gameService, nil, storage.NewMemoryStore(), []string{"alice"}, time.Minute, false)
+
+	state, err := sync.SyncPlayer(context.Background(), "alice")
+	if err != nil {
+		t.Fatalf("SyncPlayer() error = %v", err)
+	}
+	if state.GamesSynced != 1 || state.LastArchive != "2023/11" {
+		t.Errorf("Unexpected state after first sync: %+v", state)
+	}
+
+	// Unchanged archive: the ETag matches and nothing is stored again
+	if state, _ = sync.SyncPlayer(context.Background(), "alice"); state.GamesSynced != 1 {
+		t.Errorf("Expected no new games for an unchanged archive, got %+v", state)
+	}
+
+	// A new game in the current month is picked up once
+	games = append(games, `{"url": "https://www.chess.com/game/live/2", "rules": "chess", "start_time": 1700001000}`)
+	if state, _ = sync.SyncPlayer(context.Background(), "alice"); state.GamesSynced != 2 {
+		t.Errorf("Expected the new game to be synced, got %+v", state)
+	}
+
+	if monthRequests != 3 {
+		t.Errorf("Expected only the latest archive to be fetched, got %d requests", monthRequests)
+	}
+
+	stored, err := sync.GetSyncedGames("Alice", models.PageRequest{})
+	if err != nil {
+		t.Fatalf("GetSyncedGames() error = %v", err)
+	}
+	if len(stored.Items) != 2 || stored.Items[0].URL != "https://www.chess.com/game/live/1" || stored.TotalEstimate != 2 {
+		t.Errorf("Unexpected stored games: %+v", stored)
+	}
+}
+
+func TestSyncService_SyncPlayerConcurrently(t *testing.T) {
+	started, unblock := make(chan struct{}), make(chan struct{})
+	var once sync.Once
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/player/alice/games/archives":
+			once.Do(func() {
+				close(started)
+				<-unblock
+			})
+			fmt.Fprint(w, `{"archives": []}`)
+		case "/player/bob/games/archives":
+			fmt.Fprint(w, `{"archives": []}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	gameService := NewGameAnalyzerService()
+	gameService.chessAPI.BaseURL = server.URL
+	sync := NewSyncService(gameService, nil, storage.NewMemoryStore(), []string{"alice", "bob"}, time.Minute, false)
+
+	done := make(chan error)
+	go func() {
+		_, err := sync.SyncPlayer(context.Background(), "alice")
+		done <- err
+	}()
+	<-started
+
+	// Alice's hanging fetch holds up neither another player's sync nor the status
+	if _, err := sync.SyncPlayer(context.Background(), "bob"); err != nil {
+		t.Errorf("Expected Bob's sync to run alongside Alice's, got %v", err)
+	}
+	if statuses := sync.GetSyncStatus(); len(statuses) != 2 {
+		t.Errorf("Expected the status of both players, got %+v", statuses)
+	}
+
+	// A second sync or repair of Alice is refused until the first ends
+	var capacity *errors.CapacityError
+	if _, err := sync.SyncPlayer(context.Background(), "Alice"); !errors.As(err, &capacity) {
+		t.Errorf("Expected a second sync of Alice to be refused, got %v", err)
+	}
+	if _, err := sync.RepairGames(context.Background(), "alice"); !errors.As(err, &capacity) {
+		t.Errorf("Expected a repair during Alice's sync to be refused, got %v", err)
+	}
+
+	close(unblock)
+	if err := <-done; err != nil {
+		t.Fatalf("SyncPlayer() error = %v", err)
+	}
+	if _, err := sync.SyncPlayer(context.Background(), "alice"); err != nil {
+		t.Errorf("Expected Alice to sync again after the first sync ended, got %v", err)
+	}
+}
+
+func TestSyncService_RawArchives(t *testing.T) {
+	// Unusual spacing and key order must survive untouched
+	game := `{"url":"https://www.chess.com/game/live/7",  "rules":"chess", "start_time":1700000000, "pgn":"1. e4 *"}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/player/alice/games/archives":
+			fmt.Fprint(w, `{"archives": ["https://api.chess.com/pub/player/alice/games/2023/11"]}`)
+		case "/player/alice/games/2023/11":
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", `"v1"`)
+			fmt.Fprintf(w, `{"games": [%s]}`, game)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	blobs, err := blob.NewLocalStore(t.TempDir(), "/blobs", []byte("secret"))
+	if err != nil {
+		t.Fatalf("NewLocalStore() error = %v", err)
+	}
+
+	gameService := NewGameAnalyzerService()
+	gameService.chessAPI.BaseURL = server.URL
+	sync := NewSyncService(gameService, nil, storage.NewMemoryStore(), nil, time.Minute, false)
+	if err := sync.SetRawArchiveStore(context.Background(), blobs, 0); err != nil {
+		t.Fatalf("SetRawArchiveStore() error = %v", err)
+	}
+
+	if _, err := sync.SyncPlayer(context.Background(), "alice"); err != nil {
+		t.Fatalf("SyncPlayer() error = %v", err)
+	}
+
+	page, err := sync.ListRawArchives("alice", models.PageRequest{})
+	if err != nil {
+		t.Fatalf("ListRawArchives() error = %v", err)
+	}
+	archives := page.Items
+	if len(archives) != 1 || archives[0].ETag != `"v1"` || archives[0].Games != 1 || archives[0].Month != 11 {
+		t.Fatalf("Unexpected raw archives %+v", archives)
+	}
+
+	source, err := sync.GetGameSource(context.Background(), "alice", "https://www.chess.com/game/live/7")
+	if err != nil {
+		t.Fatalf("GetGameSource() error = %v", err)
+	}
+	if string(source.Game) != game {
+		t.Errorf("GetGameSource() = %s, want the game as served: %s", source.Game, game)
+	}
+	if source.Archive.Key != archives[0].Key {
+		t.Errorf("Expected the game's source to be the synced archive")
+	}
+
+	// Unchanged archives aren't stored again
+	if _, err := sync.SyncPlayer(context.Background(), "alice"); err != nil {
+		t.Fatalf("SyncPlayer() error = %v", err)
+	}
+	if page, _ := sync.ListRawArchives("alice", models.PageRequest{}); len(page.Items) != 1 {
+		t.Errorf("Expected one snapshot after an unchanged sync, got %d", len(page.Items))
+	}
+
+	if _, err := sync.GetGameSource(context.Background(), "alice", "https://www.chess.com/game/live/8"); err == nil {
+		t.Error("Expected an error for a game that isn't stored")
+	}
+}
+
+func TestSyncService_RawArchiveRetention(t *testing.T) {
+	// Each fetch serves a changed archive; game 1 stays in it, game 2 is removed after the first
+	version := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/player/alice/games/archives":
+			fmt.Fprint(w, `{"archives": ["https://api.chess.com/pub/player/alice/games/2023/11"]}`)
+		case "/player/alice/games/2023/11":
+			version++
+			w.Header().Set("ETag", fmt.Sprintf(`"v%d"`, version))
+			games := `{"url":"https://www.chess.com/game/live/1","rules":"chess","start_time":1700000000,"pgn":"1. e4 *"}`
+			if version == 1 {
+				games += `,{"url":"https://www.chess.com/game/live/2","rules":"chess","start_time":1700000100,"pgn":"1. d4 *"}`
+			}
+			fmt.Fprintf(w, `{"games": [%s], "version": %d}`, games, version)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	blobs, err := blob.NewLocalStore(t.TempDir(), "/blobs", []byte("secret"))
+	if err != nil {
+		t.Fatalf("NewLocalStore() error = %v", err)
+	}
+	gameService := NewGameAnalyzerService()
+	gameService.chessAPI.BaseURL = server.URL
+	newSync := func() *SyncService {
+		sync := NewSyncService(gameService, nil, storage.NewMemoryStore(), nil, time.Minute, false)
+		sync.now = func() time.Time { return time.Now().Add(48 * time.Hour) }
+		if err := sync.SetRawArchiveStore(context.Background(), blobs, 24*time.Hour); err != nil {
+			t.Fatalf("SetRawArchiveStore() error = %v", err)
+		}
+		return sync
+	}
+	keys := func(sync *SyncService) []string {
+		page, err := sync.ListRawArchives("alice", models.PageRequest{})
+		if err != nil {
+			t.Fatalf("ListRawArchives() error = %v", err)
+		}
+		keys := []string{}
+		for _, archive := range page.Items {
+			keys = append(keys, archive.Key)
+		}
+		return keys
+	}
+
+	sync := newSync()
+	for i := 0; i < 3; i++ {
+		if _, err := sync.SyncPlayer(context.Background(), "alice"); err != nil {
+			t.Fatalf("SyncPlayer() error = %v", err)
+		}
+	}
+
+	// The second snapshot outlived the retention and its game is in the third; the first is
+	// still the only source of game 2
+	kept := keys(sync)
+	if len(kept) != 2 {
+		t.Fatalf("Expected the first and latest snapshots to be kept, got %v", kept)
+	}
+	if source := sync.store.GetGameSource("https://www.chess.com/game/live/2"); source == nil || source.Key != kept[0] {
+		t.Errorf("Expected game 2 to trace back to the first snapshot, got %+v", source)
+	}
+
+	// A restart finds the same snapshots in the blob store
+	if reloaded := keys(newSync()); !reflect.DeepEqual(reloaded, kept) {
+		t.Errorf("Expected the snapshots %v after a restart, got %v", kept, reloaded)
+	}
+	if source := newSync().store.GetGameSource("https://www.chess.com/game/live/1"); source == nil || source.Key != kept[1] {
+		t.Errorf("Expected game 1 to trace back to the latest snapshot after a restart, got %+v", source)
+	}
+}
+
+func TestSyncService_Notifications(t *testing.T) {
+	game := func(id int, rating int, title, result string) string {
+		return fmt.Sprintf(`{"url": "https://www.chess.com/game/live/%d", "rules": "chess", "rated": true, "time_class": "blitz",
+			"start_time": %d, "end_time": %d, "pgn": "[Result \"%s\"]\n\n1. e4 e5 %s",
+			"white": {"username": "alice", "rating": %d, "title": "%s"}, "black": {"username": "bob", "rating": 1500}}`,
+			id, 1700000000+id*1000, 1700000500+id*1000, result, result, rating, title)
+	}
+	games := []string{game(1, 1480, "", "1-0")}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/player/alice/games/archives":
+			fmt.Fprint(w, `{"archives": ["https://api.chess.com/pub/player/alice/games/2023/11"]}`)
+		case "/player/alice/games/2023/11":
+			w.Header().Set("ETag", fmt.Sprintf(`"v%d"`, len(games)))
+			fmt.Fprintf(w, `{"games": [%s]}`, strings.Join(games, ","))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	gameService := NewGameAnalyzerService()
+	gameService.chessAPI.BaseURL = server.URL
+	gameService.chessAPI.CallbackURL = server.URL + "/callback"
+	sync := NewSyncService(gameService, nil, storage.NewMemoryStore(), []string{"alice"}, time.Minute, false)
+	if err := sync.SetNotificationOptions(NotificationOptions{MilestoneStep: 100, StreakLength: 2}); err != nil {
+		t.Fatalf("SetNotificationOptions() error = %v", err)
+	}
+	notifications, unsubscribe := sync.SubscribeNotifications()
+	defer unsubscribe()
+
+	// The first sync only records the player's status
+	state, err := sync.SyncPlayer(context.Background(), "alice")
+	if err != nil {
+		t.Fatalf("SyncPlayer() error = %v", err)
+	}
+	if state.Ratings["blitz"] != 1480 || state.Streak != 1 || len(notificationsOf(sync, "")) != 0 {
+		t.Fatalf("Expected a silent baseline sync, got %+v", state)
+	}
+
+	// A second win past 1500 under a new title
+	games = append(games, game(2, 1512, "FM", "1-0"))
+	if _, err := sync.SyncPlayer(context.Background(), "alice"); err != nil {
+		t.Fatalf("SyncPlayer() error = %v", err)
+	}
+
+	types := map[string]models.PlayerNotification{}
+	for _, notification := range notificationsOf(sync, "Alice") {
+		types[notification.Type] = notification
+	}
+	if n := types[models.NotificationRatingMilestone]; n.Milestone != 1500 || n.PreviousRating != 1480 || n.Rating != 1512 {
+		t.Errorf("Unexpected milestone notification: %+v", n)
+	}
+	if n := types[models.NotificationTitleChange]; n.Title != "FM" || n.PreviousTitle != "" {
+		t.Errorf("Unexpected title notification: %+v", n)
+	}
+	if n := types[models.NotificationWinStreak]; n.Streak != 2 || n.GameURL != "https://www.chess.com/game/live/2" {
+		t.Errorf("Unexpected streak notification: %+v", n)
+	}
+	if len(types) != 3 || len(notifications) != 3 {
+		t.Errorf("Expected 3 notifications listed and streamed, got %d and %d", len(types), len(notifications))
+	}
+
+	// A loss ends the streak and drops the rating back below 1500
+	games = append(games, game(3, 1495, "FM", "0-1"))
+	if state, _ = sync.SyncPlayer(context.Background(), "alice"); state.Streak != -1 {
+		t.Errorf("Expected the loss to reset the streak, got %d", state.Streak)
+	}
+	latest, err := sync.GetNotifications("", models.PageRequest{Limit: 1})
+	if err != nil {
+		t.Fatalf("GetNotifications() error = %v", err)
+	}
+	if len(latest.Items) != 1 || latest.Items[0].Milestone != 1500 || latest.Items[0].Rating != 1495 {
+		t.Errorf("Expected a notification for dropping below 1500, got %+v", latest.Items)
+	}
+
+	// The next page continues with older notifications
+	next, err := sync.GetNotifications("", models.PageRequest{Cursor: latest.NextCursor, Limit: 1})
+	if err != nil {
+		t.Fatalf("GetNotifications() error = %v", err)
+	}
+	if len(next.Items) != 1 || next.Items[0].ID == latest.Items[0].ID || next.PrevCursor == "" {
+		t.Errorf("Expected the next page to hold an older notification, got %+v", next.Items)
+	}
+	if _, err := sync.GetNotifications("", models.PageRequest{Limit: 501}); err == nil {
+		t.Error("Expected a limit over the maximum page size to be rejected")
+	}
+}
+
+// notificationsOf returns every recent notification of a player
+func notificationsOf(sync *SyncService, username string) []models.PlayerNotification {
+	page, _ := sync.GetNotifications(username, models.PageRequest{Limit: storage.MaxPageSize})
+	return page.Items
+}
+
+func TestCrossedMilestone(t *testing.T) {
+	tests := []struct {
+		previous, rating, step int
+		milestone              int
+		crossed                bool
+	}{
+		{1490, 1510, 100, 1500, true},
+		{1510, 1490, 100, 1500, true},
+		{1510, 1590, 100, 0, false},
+		{1390, 1610, 100, 1600, true},
+		{1490, 1510, 0, 0, false},
+	}
+	for _, tt := range tests {
+		milestone, crossed := crossedMilestone(tt.previous, tt.rating, tt.step)
+		if milestone != tt.milestone || crossed != tt.crossed {
+			t.Errorf("crossedMilestone(%d, %d, %d) = %d, %v; want %d, %v", tt.previous, tt.rating, tt.step,
+				milestone, crossed, tt.milestone, tt.crossed)
+		}
+	}
+}
+
+func TestArchivesToSync(t *testing.T) {
+	archives := [][2]int{{2023, 9}, {2023, 10}, {2023, 11}}
+
+	if got := archivesToSync(archives, ""); len(got) != 1 || got[0] != [2]int{2023, 11} {
+		t.Errorf("Expected only the latest archive on a first sync, got %v", got)
+	}
+	if got := archivesToSync(archives, "2023/10"); len(got) != 2 || got[0] != [2]int{2023, 10} {
+		t.Errorf("Expected the last synced archive and newer ones, got %v", got)
+	}
+}
+
+func TestSyncService_GetNewGames(t *testing.T) {
+	november := []string{
+		`{"url": "https://www.chess.com/game/live/1", "rules": "chess", "end_time": 1698800000}`,
+		`{"url": "https://www.chess.com/game/live/2", "rules": "chess", "end_time": 1698900000}`,
+	}
+	december := []string{}
+	monthRequests := map[string]int{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/player/alice/games/archives":
+			fmt.Fprint(w, `{"archives": ["https://api.chess.com/pub/player/alice/games/2023/10",
+				"https://api.chess.com/pub/player/alice/games/2023/11", "https://api.chess.com/pub/player/alice/games/2023/12"]}`)
+		case "/player/alice/games/2023/10":
+			monthRequests["10"]++
+			fmt.Fprint(w, `{"games": []}`)
+		case "/player/alice/games/2023/11":
+			monthRequests["11"]++
+			fmt.Fprintf(w, `{"games": [%s]}`, strings.Join(november, ","))
+		case "/player/alice/games/2023/12":
+			monthRequests["12"]++
+			fmt.Fprintf(w, `{"games": [%s]}`, strings.Join(december, ","))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	gameService := NewGameAnalyzerService()
+	gameService.SetGameCacheOptions(0, 0)
+	gameService.chessAPI.BaseURL = server.URL
+	sync := NewSyncService(gameService, nil, storage.NewMemoryStore(), nil, time.Minute, false)
+	polled := time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC)
+	sync.now = func() time.Time { return polled }
+	ctx := context.Background()
+
+	// The first poll returns the latest month, which is empty yet, so the cursor starts now
+	first, err := sync.GetNewGames(ctx, "alice", "bot", nil, 0)
+	if err != nil {
+		t.Fatalf("GetNewGames() error = %v", err)
+	}
+	if len(first.Games) != 0 || first.Cursor != polled.Unix() {
+		t.Errorf("Unexpected first poll %+v", first)
+	}
+
+	// The next poll only reads the archives from the poll's month on
+	second, err := sync.GetNewGames(ctx, "alice", "bot", nil, 0)
+	if err != nil {
+		t.Fatalf("GetNewGames() error = %v", err)
+	}
+	if len(second.Games) != 0 || second.Since != polled.Unix() || second.Cursor != polled.Unix() {
+		t.Errorf("Unexpected poll after an empty first one %+v", second)
+	}
+	if monthRequests["10"] != 0 || monthRequests["11"] != 0 {
+		t.Errorf("Archives before the cursor's month were fetched: %v", monthRequests)
+	}
+
+	// Replaying from an explicit cursor fetches the cursor's month on, a page at a time
+	since := int64(1698800000)
+	replay, err := sync.GetNewGames(ctx, "alice", "bot", &since, 1)
+	if err != nil {
+		t.Fatalf("GetNewGames() error = %v", err)
+	}
+	if len(replay.Games) != 1 || replay.Games[0].URL != "https://www.chess.com/game/live/2" || replay.Cursor != 1698900000 || replay.HasMore {
+		t.Errorf("Unexpected replay %+v", replay)
+	}
+	if monthRequests["10"] != 0 {
+		t.Errorf("Archives before the cursor's month were fetched: %v", monthRequests)
+	}
+
+	// New games, two ending in the same second, arrive; the stored cursor picks up after the replay
+	december = append(december,
+		`{"url": "https://www.chess.com/game/live/4", "rules": "chess", "end_time": 1701500000}`,
+		`{"url": "https://www.chess.com/game/live/3", "rules": "chess", "end_time": 1701400000}`,
+		`{"url": "https://www.chess.com/game/live/5", "rules": "chess", "end_time": 1701500000}`,
+	)
+	next, err := sync.GetNewGames(ctx, "alice", "bot", nil, 2)
+	if err != nil {
+		t.Fatalf("GetNewGames() error = %v", err)
+	}
+	var urls []string
+	for _, game := range next.Games {
+		urls = append(urls, strings.TrimPrefix(game.URL, "https://www.chess.com/game/live/"))
+	}
+	if strings.Join(urls, ",") != "3,4,5" || next.Since != 1698900000 || next.Cursor != 1701500000 || next.HasMore {
+		t.Errorf("Expected games 3 to 5 after the replay, got %v in %+v", urls, next)
+	}
+
+	// Nothing new: the cursor stays, and other consumers keep their own
+	if again, _ := sync.GetNewGames(ctx, "alice", "bot", nil, 0); len(again.Games) != 0 || again.Cursor != 1701500000 {
+		t.Errorf("Unexpected poll without new games %+v", again)
+	}
+	if other, _ := sync.GetNewGames(ctx, "alice", "", nil, 0); len(other.Games) != 3 {
+		t.Errorf("Expected another consumer's first poll to return the latest month, got %+v", other)
+	}
+
+	if _, err := sync.GetNewGames(ctx, "alice", "bot", nil, storage.MaxPageSize+1); err == nil {
+		t.Error("GetNewGames() accepted a limit above the maximum")
+	}
+}
@@ -0,0 +1,377 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/board"
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/internal/storage"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+// Team match planning limits
+const (
+	MaxTeamPrepGames = 100 // Recent games of each opponent scanned at most
+	MaxTeamBoards    = 50
+
+	defaultTeamActiveDays = 30
+	defaultTeamPrepGames  = 30
+	maxTeamRoster         = 100 // Members of each club looked up
+	teamPrepOpenings      = 3   // Openings summarized per color
+	teamWorkers           = 4   // Members looked up at the same time
+	maxRunningTeamPlans   = 2   // Plans prepared at once
+	teamPlanTimeout       = 10 * time.Minute
+	teamPlanRetention     = time.Hour // Finished plans are kept this long for clients to poll
+)
+
+// teamTimeClasses are the time classes clubs can be paired by
+var teamTimeClasses = map[string]bool{"daily": true, "rapid": true, "blitz": true, "bullet": true}
+
+// TeamService helps captains plan club matches. Plans take a few hundred Chess.com requests, so
+// they are prepared in the background as jobs clients poll.
+type TeamService struct {
+	gameService *GameAnalyzerService
+	analytics   *AnalyticsService
+	ctx         context.Context // Cancelled on Close, stopping running plans
+	cancel      context.CancelFunc
+	now         func() time.Time
+
+	mu      sync.Mutex
+	jobs    map[string]*models.TeamMatchJob
+	running int // Plans being prepared
+}
+
+// NewTeamService creates a new team tools service
+func NewTeamService(gameService *GameAnalyzerService, analytics *AnalyticsService) *TeamService {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &TeamService{
+		gameService: gameService,
+		analytics:   analytics,
+		ctx:         ctx,
+		cancel:      cancel,
+		now:         time.Now,
+		jobs:        make(map[string]*models.TeamMatchJob),
+	}
+}
+
+// StartMatchPlan validates a match request and starts preparing its plan in the background. It
+// fails with a capacity error while as many plans as allowed are being prepared.
+func (s *TeamService) StartMatchPlan(request models.TeamMatchRequest) (*models.TeamMatchJob, error) {
+	if err := normalizeTeamRequest(&request); err != nil {
+		return nil, err
+	}
+	id, err := storage.NewID()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pruneJobs()
+	if s.running >= maxRunningTeamPlans {
+		return nil, errors.NewCapacityError("match plans prepared at once", maxRunningTeamPlans)
+	}
+	s.running++
+
+	job := &models.TeamMatchJob{
+		ID:           id,
+		Club:         request.Club,
+		OpponentClub: request.OpponentClub,
+		Status:       models.JobStatusRunning,
+		CreatedAt:    s.now(),
+	}
+	s.jobs[id] = job
+	snapshot := *job
+
+	go s.run(job, request)
+	return &snapshot, nil
+}
+
+// run prepares a job's plan within the planning timeout and records the outcome
+func (s *TeamService) run(job *models.TeamMatchJob, request models.TeamMatchRequest) {
+	ctx, cancel := context.WithTimeout(s.ctx, teamPlanTimeout)
+	defer cancel()
+	plan, err := s.PlanMatch(ctx, &request)
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		err = errors.NewTimeoutError("match planning", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running--
+	completed := s.now()
+	job.CompletedAt = &completed
+	if err != nil {
+		job.Status = models.JobStatusFailed
+		job.Error = err.Error()
+		return
+	}
+	job.Status = models.JobStatusCompleted
+	job.Plan = plan
+}
+
+// GetMatchPlan returns the state of a match plan job, with the plan once it is ready
+func (s *TeamService) GetMatchPlan(id string) (*models.TeamMatchJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, errors.NewTeamPlanNotFoundError(id)
+	}
+	snapshot := *job
+	return &snapshot, nil
+}
+
+// pruneJobs forgets plans that finished longer ago than the retention period. The caller must hold s.mu.
+func (s *TeamService) pruneJobs() {
+	for id, job := range s.jobs {
+		if job.CompletedAt != nil && s.now().Sub(*job.CompletedAt) > teamPlanRetention {
+			delete(s.jobs, id)
+		}
+	}
+}
+
+// Close stops the plans being prepared
+func (s *TeamService) Close() {
+	s.cancel()
+}
+
+// rosterLookup is the outcome of looking up a club's members
+type rosterLookup struct {
+	eligible  []models.TeamMember // Rated in the time class, in board order
+	unrated   []string
+	failed    []string
+	truncated bool
+}
+
+// PlanMatch suggests a board pairing between two clubs within the request: both rosters are ordered with active
+// members first and by rating, and paired board by board, as Chess.com pairs club matches.
+// Each board comes with a summary of the opponent's openings.
+func (s *TeamService) PlanMatch(ctx context.Context, request *models.TeamMatchRequest) (*models.TeamMatchPlan, error) {
+	if err := normalizeTeamRequest(request); err != nil {
+		return nil, err
+	}
+
+	rosters := make([]*rosterLookup, 2)
+	for i, club := range []string{request.Club, request.OpponentClub} {
+		roster, err := s.lookupRoster(ctx, club, request)
+		if err != nil {
+			return nil, err
+		}
+		rosters[i] = roster
+	}
+	ours, theirs := rosters[0], rosters[1]
+
+	boards := min(len(ours.eligible), len(theirs.eligible))
+	if request.Boards > 0 {
+		boards = min(boards, request.Boards)
+	}
+	boards = min(boards, MaxTeamBoards)
+
+	plan := &models.TeamMatchPlan{
+		Club:         request.Club,
+		OpponentClub: request.OpponentClub,
+		TimeClass:    request.TimeClass,
+		GeneratedAt:  s.now(),
+		Boards:       make([]models.TeamBoard, boards),
+		Bench:        append([]models.TeamMember{}, ours.eligible[boards:]...),
+		Unrated:      append(append([]string{}, ours.unrated...), theirs.unrated...),
+		Failed:       append(ours.failed, theirs.failed...),
+		Truncated:    ours.truncated || theirs.truncated,
+	}
+
+	// Opponents' games are fetched concurrently; a board whose games can't be fetched says why
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, teamWorkers)
+	for i := range plan.Boards {
+		player, opponent := ours.eligible[i], theirs.eligible[i]
+		plan.Boards[i] = models.TeamBoard{
+			Board:      i + 1,
+			Player:     player,
+			Opponent:   opponent,
+			RatingDiff: player.Rating - opponent.Rating,
+		}
+
+		wg.Add(1)
+		go func(board *models.TeamBoard) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			if ctx.Err() != nil {
+				board.Preparation.Error = ctx.Err().Error()
+				return
+			}
+			board.Preparation = s.prepareBoard(ctx, board.Opponent.Username, request.PrepGames)
+		}(&plan.Boards[i])
+	}
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return plan, nil
+}
+
+// normalizeTeamRequest validates a match request and fills in defaults
+func normalizeTeamRequest(request *models.TeamMatchRequest) error {
+	request.Club = strings.TrimSpace(request.Club)
+	request.OpponentClub = strings.TrimSpace(request.OpponentClub)
+	if request.Club == "" {
+		return errors.NewValidationError("club", "club is required")
+	}
+	if request.OpponentClub == "" {
+		return errors.NewValidationError("opponent_club", "opponent club is required")
+	}
+	if strings.EqualFold(request.Club, request.OpponentClub) {
+		return errors.NewValidationError("opponent_club", "a club can't play itself")
+	}
+
+	request.TimeClass = strings.ToLower(request.TimeClass)
+	if request.TimeClass == "" {
+		request.TimeClass = "daily"
+	}
+	if !teamTimeClasses[request.TimeClass] {
+		return errors.NewValidationError("time_class", "time class must be daily, rapid, blitz or bullet")
+	}
+	if request.Boards < 0 || request.Boards > MaxTeamBoards {
+		return errors.NewValidationError("boards", fmt.Sprintf("boards must be between 1 and %d", MaxTeamBoards))
+	}
+	if request.ActiveDays < 0 {
+		return errors.NewValidationError("active_days", "active days can't be negative")
+	}
+	if request.ActiveDays == 0 {
+		request.ActiveDays = defaultTeamActiveDays
+	}
+	if request.PrepGames < 0 || request.PrepGames > MaxTeamPrepGames {
+		return errors.NewValidationError("prep_games", fmt.Sprintf("prep games must be between 1 and %d", MaxTeamPrepGames))
+	}
+	if request.PrepGames == 0 {
+		request.PrepGames = defaultTeamPrepGames
+	}
+	return nil
+}
+
+// lookupRoster fetches the rating and last game of a club's members in the time class and orders
+// the rated members for pairing
+func (s *TeamService) lookupRoster(ctx context.Context, club string, request *models.TeamMatchRequest) (*rosterLookup, error) {
+	members, err := s.gameService.GetClubMembers(club)
+	if err != nil {
+		return nil, err
+	}
+
+	roster := &rosterLookup{}
+	seen := make(map[string]bool, len(members))
+	unique := make([]string, 0, len(members))
+	for _, member := range members {
+		if key := strings.ToLower(member); !seen[key] {
+			seen[key] = true
+			unique = append(unique, member)
+		}
+	}
+	// Members are listed most recently active first, so the cut keeps the likely players
+	if len(unique) > maxTeamRoster {
+		unique, roster.truncated = unique[:maxTeamRoster], true
+	}
+
+	type lookup struct {
+		member models.TeamMember
+		rated  bool
+		err    error
+	}
+	results := make([]lookup, len(unique))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, teamWorkers)
+	for i, username := range unique {
+		wg.Add(1)
+		go func(i int, username string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			if ctx.Err() != nil {
+				results[i].err = ctx.Err()
+				return
+			}
+			results[i].member, results[i].rated, results[i].err = s.memberRating(username, request.TimeClass)
+		}(i, username)
+	}
+	wg.Wait()
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	cutoff := s.now().AddDate(0, 0, -request.ActiveDays)
+	for i, result := range results {
+		switch {
+		case result.err != nil:
+			roster.failed = append(roster.failed, unique[i])
+		case !result.rated:
+			roster.unrated = append(roster.unrated, unique[i])
+		default:
+			member := result.member
+			member.Active = member.LastGame != nil && member.LastGame.After(cutoff)
+			roster.eligible = append(roster.eligible, member)
+		}
+	}
+
+	sort.SliceStable(roster.eligible, func(i, j int) bool {
+		a, b := roster.eligible[i], roster.eligible[j]
+		if a.Active != b.Active {
+			return a.Active
+		}
+		if a.Rating != b.Rating {
+			return a.Rating > b.Rating
+		}
+		return strings.ToLower(a.Username) < strings.ToLower(b.Username)
+	})
+	return roster, nil
+}
+
+// memberRating reads a member's current rating and last rated game in a time class from their
+// stats, reporting whether they have a rating at all
+func (s *TeamService) memberRating(username, timeClass string) (models.TeamMember, bool, error) {
+	member := models.TeamMember{Username: username}
+	stats, err := s.gameService.GetPlayerStats(username)
+	if err != nil {
+		return member, false, err
+	}
+
+	category, _ := stats["chess_"+timeClass].(map[string]any)
+	last, _ := category["last"].(map[string]any)
+	member.Rating = int(getFloatValue(last, "rating"))
+	if member.Rating == 0 {
+		return member, false, nil
+	}
+	if date := int64(getFloatValue(last, "date")); date > 0 {
+		lastGame := time.Unix(date, 0)
+		member.LastGame = &lastGame
+	}
+	return member, true, nil
+}
+
+// prepareBoard summarizes the openings an opponent played with each color in their recent games
+func (s *TeamService) prepareBoard(ctx context.Context, opponent string, games int) models.BoardPreparation {
+	recent, _, err := s.gameService.GetRecentGames(ctx, opponent, games)
+	if err != nil {
+		return models.BoardPreparation{Error: err.Error()}
+	}
+
+	preparation := models.BoardPreparation{Games: len(recent)}
+	for _, color := range []board.Color{board.White, board.Black} {
+		openings := s.analytics.buildRepertoire(recent, opponent, color).Openings
+		if len(openings) > teamPrepOpenings {
+			openings = openings[:teamPrepOpenings]
+		}
+		if color == board.White {
+			preparation.AsWhite = openings
+		} else {
+			preparation.AsBlack = openings
+		}
+	}
+	return preparation
+}
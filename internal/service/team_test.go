@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+// newTeamTestServer serves the Chess.com API of two clubs, "home" and "away"
+func newTeamTestServer(now time.Time) *httptest.Server {
+	recent, stale := now.AddDate(0, 0, -3).Unix(), now.AddDate(0, 0, -90).Unix()
+	ratings := map[string][2]int64{ // Daily rating and last game
+		"alice": {1500, recent},
+		"bob":   {1700, recent},
+		"carol": {1800, stale},
+		"erin":  {1600, recent},
+		"frank": {1400, recent},
+		"gina":  {2000, stale},
+	}
+	sicilian := `[ECOUrl \"https://www.chess.com/openings/Sicilian-Defense\"]\n[Result \"0-1\"]\n\n1. e4 c5 0-1`
+	italian := `[ECOUrl \"https://www.chess.com/openings/Italian-Game\"]\n[Result \"1-0\"]\n\n1. e4 e5 2. Nf3 Nc6 3. Bc4 1-0`
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch path := r.URL.Path; {
+		case path == "/club/home/members":
+			fmt.Fprint(w, `{"weekly":[{"username":"alice"},{"username":"bob"}],"monthly":[{"username":"carol"},{"username":"Alice"}],"all_time":[{"username":"dave"}]}`)
+		case path == "/club/away/members":
+			fmt.Fprint(w, `{"weekly":[{"username":"erin"},{"username":"frank"}],"monthly":[],"all_time":[{"username":"gina"},{"username":"hank"}]}`)
+		case strings.HasSuffix(path, "/stats"):
+			username := strings.TrimSuffix(strings.TrimPrefix(path, "/player/"), "/stats")
+			if username == "hank" {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			rating, ok := ratings[strings.ToLower(username)]
+			if !ok {
+				fmt.Fprint(w, `{"chess_blitz":{"last":{"rating":1200,"date":1}}}`)
+				return
+			}
+			fmt.Fprintf(w, `{"chess_daily":{"last":{"rating":%d,"date":%d}}}`, rating[0], rating[1])
+		case path == "/player/erin/games/archives":
+			fmt.Fprint(w, `{"archives":["https://api.chess.com/pub/player/erin/games/2024/02"]}`)
+		case path == "/player/erin/games/2024/02":
+			fmt.Fprintf(w, `{"games":[`+
+				`{"url":"https://www.chess.com/game/daily/1","rules":"chess","white":{"username":"zed"},"black":{"username":"erin"},"pgn":"%s"},`+
+				`{"url":"https://www.chess.com/game/daily/2","rules":"chess","white":{"username":"zed"},"black":{"username":"erin"},"pgn":"%s"},`+
+				`{"url":"https://www.chess.com/game/daily/3","rules":"chess","white":{"username":"erin"},"black":{"username":"zed"},"pgn":"%s"}]}`,
+				sicilian, sicilian, italian)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestTeamService_PlanMatch(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	server := newTeamTestServer(now)
+	defer server.Close()
+
+	gameService := NewGameAnalyzerService()
+	gameService.chessAPI.BaseURL = server.URL
+	team := NewTeamService(gameService, NewAnalyticsService(gameService, nil))
+	team.now = func() time.Time { return now }
+
+	plan, err := team.PlanMatch(context.Background(), &models.TeamMatchRequest{Club: "home", OpponentClub: "away", Boards: 2})
+	if err != nil {
+		t.Fatalf("PlanMatch() error = %v", err)
+	}
+	if plan.TimeClass != "daily" || !plan.GeneratedAt.Equal(now) || plan.Truncated {
+		t.Errorf("Unexpected plan: %+v", plan)
+	}
+	if len(plan.Boards) != 2 {
+		t.Fatalf("Expected 2 boards, got %d", len(plan.Boards))
+	}
+
+	// Active members are paired first, strongest on board one; inactive Carol sits on the bench
+	first, second := plan.Boards[0], plan.Boards[1]
+	if first.Player.Username != "bob" || first.Opponent.Username != "erin" || first.RatingDiff != 100 || !first.Player.Active {
+		t.Errorf("Unexpected first board: %+v", first)
+	}
+	if second.Player.Username != "alice" || second.Opponent.Username != "frank" || second.RatingDiff != 100 {
+		t.Errorf("Unexpected second board: %+v", second)
+	}
+	if len(plan.Bench) != 1 || plan.Bench[0].Username != "carol" || plan.Bench[0].Active {
+		t.Errorf("Expected Carol on the bench, got %+v", plan.Bench)
+	}
+	if fmt.Sprint(plan.Unrated) != "[dave]" || fmt.Sprint(plan.Failed) != "[hank]" {
+		t.Errorf("Unrated = %v, Failed = %v", plan.Unrated, plan.Failed)
+	}
+
+	prep := first.Preparation
+	if prep.Error != "" || prep.Games != 3 {
+		t.Fatalf("Unexpected preparation for Erin: %+v", prep)
+	}
+	if len(prep.AsBlack) != 1 || prep.AsBlack[0].Name != "Sicilian Defense" || prep.AsBlack[0].Games != 2 {
+		t.Errorf("Unexpected openings as Black: %+v", prep.AsBlack)
+	}
+	if len(prep.AsWhite) != 1 || prep.AsWhite[0].Name != "Italian Game" {
+		t.Errorf("Unexpected openings as White: %+v", prep.AsWhite)
+	}
+	// Frank's games can't be fetched, which is reported on his board alone
+	if second.Preparation.Error == "" {
+		t.Errorf("Expected a preparation error for Frank, got %+v", second.Preparation)
+	}
+}
+
+func TestTeamService_PlanMatchValidation(t *testing.T) {
+	team := NewTeamService(NewGameAnalyzerService(), nil)
+
+	requests := []models.TeamMatchRequest{
+		{OpponentClub: "away"},
+		{Club: "home"},
+		{Club: "home", OpponentClub: "Home"},
+		{Club: "home", OpponentClub: "away", TimeClass: "classical"},
+		{Club: "home", OpponentClub: "away", Boards: MaxTeamBoards + 1},
+		{Club: "home", OpponentClub: "away", PrepGames: -1},
+	}
+	for _, request := range requests {
+		var validation *errors.ValidationError
+		if _, err := team.PlanMatch(context.Background(), &request); !errors.As(err, &validation) {
+			t.Errorf("Expected a validation error for %+v, got %v", request, err)
+		}
+	}
+}
+
+func TestTeamService_StartMatchPlan(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	server := newTeamTestServer(now)
+	defer server.Close()
+
+	gameService := NewGameAnalyzerService()
+	gameService.chessAPI.BaseURL = server.URL
+	team := NewTeamService(gameService, NewAnalyticsService(gameService, nil))
+	defer team.Close()
+	team.now = func() time.Time { return now }
+
+	var validation *errors.ValidationError
+	if _, err := team.StartMatchPlan(models.TeamMatchRequest{Club: "home"}); !errors.As(err, &validation) {
+		t.Fatalf("Expected an invalid request to be refused before starting, got %v", err)
+	}
+
+	job, err := team.StartMatchPlan(models.TeamMatchRequest{Club: "home", OpponentClub: "away", Boards: 2})
+	if err != nil {
+		t.Fatalf("StartMatchPlan() error = %v", err)
+	}
+	if job.Status != models.JobStatusRunning || job.Plan != nil {
+		t.Errorf("Expected a running job, got %+v", job)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for job.Status == models.JobStatusRunning && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		if job, err = team.GetMatchPlan(job.ID); err != nil {
+			t.Fatalf("GetMatchPlan() error = %v", err)
+		}
+	}
+	if job.Status != models.JobStatusCompleted || job.Plan == nil || len(job.Plan.Boards) != 2 || job.CompletedAt == nil {
+		t.Fatalf("Expected the completed plan, got %+v", job)
+	}
+
+	var notFound *errors.TeamPlanNotFoundError
+	if _, err := team.GetMatchPlan("missing"); !errors.As(err, &notFound) {
+		t.Errorf("Expected a not found error, got %v", err)
+	}
+
+	// Finished plans are forgotten after the retention period
+	team.now = func() time.Time { return now.Add(teamPlanRetention + time.Minute) }
+	team.StartMatchPlan(models.TeamMatchRequest{Club: "home", OpponentClub: "away", Boards: 1})
+	if _, err := team.GetMatchPlan(job.ID); !errors.As(err, &notFound) {
+		t.Errorf("Expected the old plan to be pruned, got %v", err)
+	}
+}
+
+func TestTeamService_StartMatchPlanCapacity(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+	defer close(release)
+
+	gameService := NewGameAnalyzerService()
+	gameService.chessAPI.BaseURL = server.URL
+	team := NewTeamService(gameService, nil)
+	defer team.Close()
+
+	for i := 0; i < maxRunningTeamPlans; i++ {
+		if _, err := team.StartMatchPlan(models.TeamMatchRequest{Club: "home", OpponentClub: "away"}); err != nil {
+			t.Fatalf("StartMatchPlan() error = %v", err)
+		}
+	}
+	var capacity *errors.CapacityError
+	if _, err := team.StartMatchPlan(models.TeamMatchRequest{Club: "home", OpponentClub: "away"}); !errors.As(err, &capacity) {
+		t.Errorf("Expected a capacity error beyond %d running plans, got %v", maxRunningTeamPlans, err)
+	}
+}
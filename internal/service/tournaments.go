@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/board"
+	"github.com/pedrampdd/ChessAnalyser/internal/client"
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+// drawResultCodes are the Chess.com result codes of both players of a drawn game
+var drawResultCodes = map[string]bool{
+	"agreed":             true,
+	"repetition":         true,
+	"stalemate":          true,
+	"insufficient":       true,
+	"50move":             true,
+	"timevsinsufficient": true,
+}
+
+// tournamentGames accumulates a player's games in one tournament
+type tournamentGames struct {
+	games          int
+	points         float64
+	wins, losses   int
+	opponentRating int
+	accuracySum    float64
+}
+
+// GetPlayerTournaments lists a player's Chess.com tournaments and summarizes how they played in
+// each: their results from their recent games, and their accuracy and errors from the games among
+// them with a stored analysis. Tournament games are matched by the tournament field of archive
+// games, and analyses by the game link in their PGN.
+func (s *AnalyticsService) GetPlayerTournaments(ctx context.Context, request *models.TournamentHistoryRequest) (*models.TournamentHistory, error) {
+	if request.Username == "" {
+		return nil, errors.NewValidationError("username", "username is required")
+	}
+	if request.Games <= 0 {
+		request.Games = defaultReportGames
+	}
+	if request.Games > maxReportGames {
+		request.Games = maxReportGames
+	}
+
+	tournaments, err := s.gameService.GetPlayerTournaments(request.Username)
+	if err != nil {
+		return nil, err
+	}
+	games, archives, err := s.gameService.GetRecentGames(ctx, request.Username, request.Games)
+	if err != nil {
+		return nil, err
+	}
+
+	history := &models.TournamentHistory{
+		Username:       request.Username,
+		GeneratedAt:    time.Now(),
+		Games:          len(games),
+		Tournaments:    []models.TournamentPerformance{},
+		FailedArchives: failedArchives(archives),
+		Archives:       archives,
+	}
+	performances := make(map[string]*models.TournamentPerformance)
+	for _, group := range []struct {
+		state   string
+		entries []client.PlayerTournament
+	}{
+		{models.TournamentInProgress, tournaments.InProgress},
+		{models.TournamentFinished, tournaments.Finished},
+		{models.TournamentRegistered, tournaments.Registered},
+	} {
+		for _, entry := range group.entries {
+			history.Tournaments = append(history.Tournaments, models.TournamentPerformance{
+				URL:           entry.URL,
+				ID:            entry.ID,
+				State:         group.state,
+				Status:        entry.Status,
+				Type:          entry.Type,
+				TimeClass:     entry.TimeClass,
+				Wins:          entry.Wins,
+				Losses:        entry.Losses,
+				Draws:         entry.Draws,
+				PointsAwarded: entry.PointsAwarded,
+				Placement:     entry.Placement,
+				TotalPlayers:  entry.TotalPlayers,
+			})
+		}
+	}
+	for i := range history.Tournaments {
+		if id := history.Tournaments[i].ID; id != "" {
+			performances[id] = &history.Tournaments[i]
+		}
+	}
+
+	analyses := s.analysisService.analysesByGameURL()
+	totals := make(map[string]*tournamentGames)
+	for _, game := range games {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		performance, ok := performances[game.Tournament]
+		if !ok {
+			continue
+		}
+		color, ok := playerColor(game, request.Username)
+		if !ok {
+			continue
+		}
+
+		total, ok := totals[game.Tournament]
+		if !ok {
+			total = &tournamentGames{}
+			totals[game.Tournament] = total
+		}
+		player, opponent := game.WhitePlayer, game.BlackPlayer
+		if color == board.Black {
+			player, opponent = opponent, player
+		}
+		total.games++
+		total.opponentRating += opponent.Rating
+		switch {
+		case player.Result == "win":
+			total.points++
+			total.wins++
+		case drawResultCodes[player.Result]:
+			total.points += 0.5
+		default:
+			total.losses++
+		}
+
+		analysis, ok := analyses[game.URL]
+		if !ok {
+			continue
+		}
+		performance.AnalyzedGames++
+		performance.AnalysisIDs = append(performance.AnalysisIDs, analysis.ID)
+		if color == board.White {
+			total.accuracySum += analysis.Accuracy.WhiteAccuracy
+		} else {
+			total.accuracySum += analysis.Accuracy.BlackAccuracy
+		}
+		for _, move := range analysis.Moves {
+			if moveColor(move.FEN, move.MoveNumber) != color.String() {
+				continue
+			}
+			switch moveClassification(move) {
+			case "blunder":
+				performance.Blunders++
+			case "mistake":
+				performance.Mistakes++
+			case "inaccuracy":
+				performance.Inaccuracies++
+			}
+		}
+		history.AnalyzedGames++
+	}
+
+	for id, total := range totals {
+		performance := performances[id]
+		performance.Games = total.games
+		performance.Score = total.points / float64(total.games) * 100
+		performance.AverageOpponentRating = total.opponentRating / total.games
+		performance.PerformanceRating = performance.AverageOpponentRating +
+			int(math.Round(400*float64(total.wins-total.losses)/float64(total.games)))
+		if performance.AnalyzedGames > 0 {
+			accuracy := total.accuracySum / float64(performance.AnalyzedGames)
+			performance.Accuracy = &accuracy
+		}
+	}
+
+	return history, nil
+}
+
+// analysesByGameURL indexes the stored analyses by the Chess.com game they analyzed, from the
+// Link tag of their PGN. A game analyzed more than once maps to its latest analysis.
+func (s *AnalysisService) analysesByGameURL() map[string]*models.GameAnalysis {
+	analyses := make(map[string]*models.GameAnalysis)
+	for _, analysis := range s.store.ListAnalyses() {
+		if link := pgnTag(analysis.PGN, "Link"); link != "" {
+			analyses[link] = analysis
+		}
+	}
+	return analyses
+}
+
+// pgnTag returns the value of a PGN tag without parsing the rest of the game
+func pgnTag(pgn, name string) string {
+	prefix := "[" + name + " \""
+	for _, line := range strings.Split(pgn, "\n") {
+		line = strings.TrimSpace(line)
+		if value, ok := strings.CutPrefix(line, prefix); ok {
+			return strings.TrimSuffix(value, "\"]")
+		}
+		if line != "" && !strings.HasPrefix(line, "[") {
+			break // Past the tags
+		}
+	}
+	return ""
+}
@@ -0,0 +1,321 @@
+package service
+
+import (
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/board"
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/internal/storage"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+// Training settings
+const (
+	maxPuzzlesPerUser    = 1000
+	defaultDuePuzzles    = 10
+	initialEaseFactor    = 2.5
+	minEaseFactor        = 1.3
+	masteredIntervalDays = 21 // Puzzles reviewed this many days apart count as mastered
+)
+
+// Recall quality of an attempt, on SM-2's 0-5 scale
+const (
+	qualityCorrect     = 4
+	qualityCorrectHint = 3
+	qualityIncorrect   = 1
+	qualityPassing     = 3 // Lowest quality that keeps a puzzle's repetition streak
+)
+
+// TrainingService turns the errors of analyzed games into puzzles and schedules their
+// review with the SM-2 spaced-repetition algorithm
+type TrainingService struct {
+	getAnalysis func(id string) (*models.GameAnalysis, error)
+	now         func() time.Time
+	mu          sync.Mutex
+	users       map[string]*trainingUser
+}
+
+// trainingUser is one user's puzzles and attempt history
+type trainingUser struct {
+	puzzles  map[string]*trainingPuzzle
+	fens     map[string]bool // Positions already stored, so re-extracting a game adds nothing
+	attempts int
+	correct  int
+	streak   int
+}
+
+// trainingPuzzle is a puzzle with its solution, which isn't shown until it's attempted
+type trainingPuzzle struct {
+	state    models.Puzzle
+	solution string // UCI notation
+}
+
+// NewTrainingService creates a new training service
+func NewTrainingService(analysisService *AnalysisService) *TrainingService {
+	return &TrainingService{
+		getAnalysis: analysisService.GetAnalysis,
+		now:         time.Now,
+		users:       make(map[string]*trainingUser),
+	}
+}
+
+// ExtractPuzzles adds a puzzle for every blunder and mistake of the user's side in a stored
+// analysis. Positions the user already has are skipped; new puzzles are due immediately.
+func (s *TrainingService) ExtractPuzzles(user string, request *models.PuzzleExtractRequest) ([]*models.Puzzle, error) {
+	if user == "" {
+		return nil, errors.NewValidationError("user", "X-API-Key header or user parameter is required")
+	}
+	request.Color = strings.ToLower(request.Color)
+	if request.Color != "" && request.Color != "white" && request.Color != "black" {
+		return nil, errors.NewValidationError("color", "must be white or black")
+	}
+	if request.AnalysisID == "" {
+		return nil, errors.NewValidationError("analysis_id", "analysis_id is required")
+	}
+
+	analysis, err := s.getAnalysis(request.AnalysisID)
+	if err != nil {
+		return nil, err
+	}
+	candidates := extractPuzzles(analysis, request.Color)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u := s.user(user)
+	now := s.now()
+	added := []*models.Puzzle{}
+	for _, candidate := range candidates {
+		if u.fens[candidate.state.FEN] {
+			continue
+		}
+		if len(u.puzzles) >= maxPuzzlesPerUser {
+			return nil, errors.NewStorageLimitError("puzzles stored", maxPuzzlesPerUser)
+		}
+
+		id, err := storage.NewID()
+		if err != nil {
+			return nil, err
+		}
+		candidate.state.ID = id
+		candidate.state.CreatedAt = now
+		candidate.state.Review = models.PuzzleReview{EaseFactor: initialEaseFactor, DueAt: now}
+
+		u.puzzles[id] = candidate
+		u.fens[candidate.state.FEN] = true
+		state := candidate.state
+		added = append(added, &state)
+	}
+	return added, nil
+}
+
+// DuePuzzles returns a page of the user's puzzles that are due for review, longest overdue first
+func (s *TrainingService) DuePuzzles(user string, request models.PageRequest) (*models.Page[*models.Puzzle], error) {
+	if user == "" {
+		return nil, errors.NewValidationError("user", "X-API-Key header or user parameter is required")
+	}
+	if request.Limit == 0 {
+		request.Limit = defaultDuePuzzles // Out-of-range limits are rejected by Paginate
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	due := []*models.Puzzle{}
+	for _, p := range s.user(user).puzzles {
+		if !p.state.Review.DueAt.After(now) {
+			state := p.state
+			due = append(due, &state)
+		}
+	}
+	return storage.Paginate(storage.ListDuePuzzles, due, func(puzzle *models.Puzzle) string {
+		return storage.TimeKey(puzzle.Review.DueAt, storage.IndexKey(puzzle.Ply)+"|"+puzzle.ID)
+	}, request)
+}
+
+// SubmitAttempt grades the user's answer to a puzzle and schedules its next review.
+// Attempts are accepted whether or not the puzzle is due.
+func (s *TrainingService) SubmitAttempt(user, puzzleID string, request *models.PuzzleAttemptRequest) (*models.PuzzleAttemptResult, error) {
+	if user == "" {
+		return nil, errors.NewValidationError("user", "X-API-Key header or user parameter is required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u := s.user(user)
+	p, ok := u.puzzles[puzzleID]
+	if !ok {
+		return nil, errors.NewPuzzleNotFoundError(puzzleID)
+	}
+
+	b, err := board.FromFEN(p.state.FEN)
+	if err != nil {
+		return nil, errors.NewAPIError("stored puzzle has an invalid position", err)
+	}
+	move, err := parseUserMove(b, strings.TrimSpace(request.Move))
+	if err != nil {
+		return nil, errors.NewValidationError("move", err.Error())
+	}
+	solution, err := b.ParseUCI(p.solution)
+	if err != nil {
+		return nil, errors.NewAPIError("stored puzzle has an illegal solution", err)
+	}
+
+	correct := move.UCI() == solution.UCI()
+	quality := qualityIncorrect
+	switch {
+	case correct && request.HintUsed:
+		quality = qualityCorrectHint
+	case correct:
+		quality = qualityCorrect
+	}
+
+	now := s.now()
+	p.state.Review = scheduleReview(p.state.Review, quality, now)
+	u.attempts++
+	if correct {
+		p.state.Review.Solved++
+		u.correct++
+		u.streak++
+	} else {
+		u.streak = 0
+	}
+
+	state := p.state
+	return &models.PuzzleAttemptResult{
+		Correct:     correct,
+		Move:        b.SAN(move),
+		Solution:    solution.UCI(),
+		SolutionSAN: b.SAN(solution),
+		Quality:     quality,
+		Puzzle:      &state,
+	}, nil
+}
+
+// Stats summarizes the user's puzzles and attempts
+func (s *TrainingService) Stats(user string) (*models.TrainingStats, error) {
+	if user == "" {
+		return nil, errors.NewValidationError("user", "X-API-Key header or user parameter is required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u := s.user(user)
+	now := s.now()
+	stats := &models.TrainingStats{
+		Puzzles:         len(u.puzzles),
+		Attempts:        u.attempts,
+		CorrectAttempts: u.correct,
+		Streak:          u.streak,
+	}
+	for _, p := range u.puzzles {
+		review := p.state.Review
+		switch {
+		case review.Attempts == 0:
+			stats.New++
+		case review.IntervalDays >= masteredIntervalDays:
+			stats.Mastered++
+		default:
+			stats.Learning++
+		}
+		if !review.DueAt.After(now) {
+			stats.Due++
+		}
+	}
+	if u.attempts > 0 {
+		stats.SolveRate = float64(u.correct) / float64(u.attempts) * 100
+	}
+	return stats, nil
+}
+
+// user returns a user's training state, creating it on first use. Callers hold s.mu.
+func (s *TrainingService) user(user string) *trainingUser {
+	u, ok := s.users[user]
+	if !ok {
+		u = &trainingUser{
+			puzzles: make(map[string]*trainingPuzzle),
+			fens:    make(map[string]bool),
+		}
+		s.users[user] = u
+	}
+	return u
+}
+
+// extractPuzzles finds the blunders and mistakes of one side (or both, for an empty color)
+// that the engine had a better move for. The puzzle is the position before the move, and the
+// solution is the best move the engine found there.
+func extractPuzzles(analysis *models.GameAnalysis, color string) []*trainingPuzzle {
+	var puzzles []*trainingPuzzle
+	for i := 1; i < len(analysis.Moves); i++ {
+		move, prev := analysis.Moves[i], analysis.Moves[i-1]
+		if !move.Blunder && !move.Mistake {
+			continue
+		}
+		mover := moveColor(move.FEN, move.MoveNumber)
+		if color != "" && mover != color {
+			continue
+		}
+		// The previous ply's evaluation and best move describe the position the move was played in
+		if prev.MoveNumber != move.MoveNumber-1 || prev.FEN == "" || prev.BestMove == "" || sameMove(move.Move, prev.BestMove) {
+			continue
+		}
+
+		b, err := board.FromFEN(prev.FEN)
+		if err != nil {
+			continue
+		}
+		if _, err := b.ParseUCI(prev.BestMove); err != nil {
+			continue
+		}
+
+		theme := models.PuzzleThemeMistake
+		if move.Blunder {
+			theme = models.PuzzleThemeBlunder
+		}
+		puzzles = append(puzzles, &trainingPuzzle{
+			state: models.Puzzle{
+				AnalysisID:     analysis.ID,
+				Ply:            move.MoveNumber,
+				Color:          mover,
+				FEN:            prev.FEN,
+				PlayedMove:     move.Move,
+				EvaluationLoss: math.Max(moverEval(prev.Evaluation, mover)-moverEval(move.Evaluation, mover), 0),
+				Theme:          theme,
+			},
+			solution: prev.BestMove,
+		})
+	}
+	return puzzles
+}
+
+// scheduleReview applies SM-2 to a review graded with a recall quality from 0 to 5: the
+// interval grows by the ease factor after each passing review and restarts at a day otherwise
+func scheduleReview(review models.PuzzleReview, quality int, now time.Time) models.PuzzleReview {
+	if quality >= qualityPassing {
+		switch review.Repetitions {
+		case 0:
+			review.IntervalDays = 1
+		case 1:
+			review.IntervalDays = 6
+		default:
+			review.IntervalDays = int(math.Round(float64(review.IntervalDays) * review.EaseFactor))
+		}
+		review.Repetitions++
+	} else {
+		review.Repetitions = 0
+		review.IntervalDays = 1
+	}
+
+	miss := float64(5 - quality)
+	review.EaseFactor = math.Max(review.EaseFactor+0.1-miss*(0.08+miss*0.02), minEaseFactor)
+	review.DueAt = now.AddDate(0, 0, review.IntervalDays)
+	review.Attempts++
+	review.LastAttemptAt = &now
+	return review
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+// newTestTrainingService creates a training service over one analysis of 1. e4 f6 2. Qh5,
+// in which Black blunders with f6 and White errs with Qh5
+func newTestTrainingService(now *time.Time) *TrainingService {
+	analysis := &models.GameAnalysis{
+		ID: "analysis-1",
+		Moves: []models.MoveAnalysis{
+			{MoveNumber: 1, Move: "e4", Evaluation: 0.3, BestMove: "e7e5",
+				FEN: "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1"},
+			{MoveNumber: 2, Move: "f6", Evaluation: 1.5, BestMove: "d2d4", Blunder: true,
+				FEN: "rnbqkbnr/ppppp1pp/5p2/8/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 0 2"},
+			{MoveNumber: 3, Move: "Qh5+", Evaluation: 0.4, BestMove: "g7g6", Mistake: true,
+				FEN: "rnbqkbnr/ppppp1pp/5p2/7Q/4P3/8/PPPP1PPP/RNB1KBNR b KQkq - 1 2"},
+		},
+	}
+
+	service := NewTrainingService(newTestAnalysisService())
+	service.getAnalysis = func(id string) (*models.GameAnalysis, error) {
+		if id != analysis.ID {
+			return nil, errors.NewAnalysisNotFoundError(id)
+		}
+		return analysis, nil
+	}
+	service.now = func() time.Time { return *now }
+	return service
+}
+
+func TestTrainingService_ExtractPuzzles(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	service := newTestTrainingService(&now)
+
+	puzzles, err := service.ExtractPuzzles("alice", &models.PuzzleExtractRequest{AnalysisID: "analysis-1", Color: "black"})
+	if err != nil {
+		t.Fatalf("ExtractPuzzles() error = %v", err)
+	}
+	if len(puzzles) != 1 {
+		t.Fatalf("Expected one puzzle for Black, got %d", len(puzzles))
+	}
+	p := puzzles[0]
+	if p.Ply != 2 || p.Color != "black" || p.PlayedMove != "f6" || p.Theme != models.PuzzleThemeBlunder ||
+		p.EvaluationLoss != 1.2 || !p.Review.DueAt.Equal(now) {
+		t.Errorf("Unexpected puzzle: %+v", p)
+	}
+
+	// Extracting again only adds White's position
+	puzzles, err = service.ExtractPuzzles("alice", &models.PuzzleExtractRequest{AnalysisID: "analysis-1"})
+	if err != nil {
+		t.Fatalf("ExtractPuzzles() error = %v", err)
+	}
+	if len(puzzles) != 1 || puzzles[0].Theme != models.PuzzleThemeMistake {
+		t.Errorf("Expected only White's mistake to be added, got %+v", puzzles)
+	}
+
+	// Users don't share puzzles
+	due, _ := service.DuePuzzles("bob", models.PageRequest{})
+	if len(due.Items) != 0 {
+		t.Errorf("Expected no puzzles for another user, got %d", len(due.Items))
+	}
+
+	var validationErr *errors.ValidationError
+	if _, err := service.ExtractPuzzles("", &models.PuzzleExtractRequest{AnalysisID: "analysis-1"}); !errors.As(err, &validationErr) {
+		t.Errorf("Expected a validation error without a user, got %v", err)
+	}
+	if _, err := service.ExtractPuzzles("alice", &models.PuzzleExtractRequest{AnalysisID: "analysis-1", Color: "red"}); !errors.As(err, &validationErr) {
+		t.Errorf("Expected a validation error for an unknown color, got %v", err)
+	}
+	var notFound *errors.AnalysisNotFoundError
+	if _, err := service.ExtractPuzzles("alice", &models.PuzzleExtractRequest{AnalysisID: "missing"}); !errors.As(err, &notFound) {
+		t.Errorf("Expected analysis not found, got %v", err)
+	}
+}
+
+func TestTrainingService_Attempts(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	service := newTestTrainingService(&now)
+
+	puzzles, err := service.ExtractPuzzles("alice", &models.PuzzleExtractRequest{AnalysisID: "analysis-1", Color: "black"})
+	if err != nil || len(puzzles) != 1 {
+		t.Fatalf("ExtractPuzzles() = %v, %v", puzzles, err)
+	}
+	id := puzzles[0].ID
+
+	result, err := service.SubmitAttempt("alice", id, &models.PuzzleAttemptRequest{Move: "d5"})
+	if err != nil {
+		t.Fatalf("SubmitAttempt() error = %v", err)
+	}
+	if result.Correct || result.Solution != "e7e5" || result.SolutionSAN != "e5" || result.Quality != qualityIncorrect {
+		t.Errorf("Expected a wrong answer revealing e5, got %+v", result)
+	}
+	if due, _ := service.DuePuzzles("alice", models.PageRequest{}); len(due.Items) != 0 {
+		t.Errorf("Expected the missed puzzle to wait a day, got %d due", len(due.Items))
+	}
+
+	now = now.Add(25 * time.Hour)
+	if due, _ := service.DuePuzzles("alice", models.PageRequest{}); len(due.Items) != 1 {
+		t.Fatalf("Expected the puzzle to be due the next day, got %d due", len(due.Items))
+	}
+	result, err = service.SubmitAttempt("alice", id, &models.PuzzleAttemptRequest{Move: "e7e5"})
+	if err != nil {
+		t.Fatalf("SubmitAttempt() error = %v", err)
+	}
+	if !result.Correct || result.Move != "e5" || result.Puzzle.Review.Solved != 1 || result.Puzzle.Review.Attempts != 2 {
+		t.Errorf("Expected a correct answer in UCI notation, got %+v", result)
+	}
+
+	var validationErr *errors.ValidationError
+	if _, err := service.SubmitAttempt("alice", id, &models.PuzzleAttemptRequest{Move: "Ke2"}); !errors.As(err, &validationErr) {
+		t.Errorf("Expected a validation error for an illegal move, got %v", err)
+	}
+	var notFound *errors.PuzzleNotFoundError
+	if _, err := service.SubmitAttempt("bob", id, &models.PuzzleAttemptRequest{Move: "e5"}); !errors.As(err, &notFound) {
+		t.Errorf("Expected another user's puzzle to be not found, got %v", err)
+	}
+
+	stats, err := service.Stats("alice")
+	if err != nil {
+		t.Fatalf("Stats() error = %v", err)
+	}
+	if stats.Puzzles != 1 || stats.Learning != 1 || stats.Due != 0 || stats.Attempts != 2 ||
+		stats.CorrectAttempts != 1 || stats.SolveRate != 50 || stats.Streak != 1 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
+
+func TestScheduleReview(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	review := models.PuzzleReview{EaseFactor: initialEaseFactor}
+
+	for _, want := range []int{1, 6, 15} {
+		review = scheduleReview(review, qualityCorrect, now)
+		if review.IntervalDays != want {
+			t.Fatalf("Expected an interval of %d days, got %d", want, review.IntervalDays)
+		}
+	}
+	if review.EaseFactor != initialEaseFactor || !review.DueAt.Equal(now.AddDate(0, 0, 15)) {
+		t.Errorf("Unexpected review after three correct answers: %+v", review)
+	}
+
+	review = scheduleReview(review, qualityIncorrect, now)
+	if review.IntervalDays != 1 || review.Repetitions != 0 || review.EaseFactor >= initialEaseFactor {
+		t.Errorf("Expected a miss to reset the schedule and lower the ease, got %+v", review)
+	}
+
+	for i := 0; i < 10; i++ {
+		review = scheduleReview(review, 0, now)
+	}
+	if review.EaseFactor != minEaseFactor {
+		t.Errorf("Expected the ease factor to bottom out at %.1f, got %.2f", minEaseFactor, review.EaseFactor)
+	}
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/internal/parser"
+)
+
+func TestVerifyMove(t *testing.T) {
+	// After 1. e4 e5 2. Qh5 Nc6 3. Bc4 Nf6?? the queen takes on f7 with mate
+	const afterBlunder = "r1bqkb1r/pppp1ppp/2n2n2/4p2Q/2B1P3/8/PPPP1PPP/RNB1K1NR w KQkq - 4 4"
+
+	tests := []struct {
+		name         string
+		fen          string
+		result       models.AnalysisResult
+		searchErr    error
+		wantSearched bool
+		wantVerified bool
+		wantBlunder  bool
+	}{
+		{"legal, blunder confirmed", afterBlunder, models.AnalysisResult{Evaluation: mateEvaluation, Depth: 18}, nil, true, true, true},
+		{"legal, reclassified", afterBlunder, models.AnalysisResult{Evaluation: 0.2, Depth: 18}, nil, true, true, false},
+		{"illegal, side not to move in check", "4k3/8/8/8/8/8/4r3/4K3 b - - 0 1", models.AnalysisResult{}, nil, false, false, true},
+		{"illegal, two white kings", "4k3/8/8/8/8/8/8/3KK3 b - - 0 1", models.AnalysisResult{}, nil, false, false, true},
+		{"malformed", "not a position", models.AnalysisResult{}, nil, false, false, true},
+		{"ambiguous, only a lower bound", afterBlunder, models.AnalysisResult{Evaluation: 0.2, Depth: 18, ScoreBound: "lower"}, nil, true, false, true},
+		{"ambiguous, only an upper bound", afterBlunder, models.AnalysisResult{Evaluation: 0.2, Depth: 18, ScoreBound: "upper"}, nil, true, false, true},
+		{"search failed", afterBlunder, models.AnalysisResult{}, fmt.Errorf("engine crashed"), true, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var searched *models.EngineSettings
+			search := func(ctx context.Context, fen string, settings models.EngineSettings) (*models.AnalysisResult, error) {
+				searched = &settings
+				result := tt.result
+				return &result, tt.searchErr
+			}
+
+			move := parser.ParsedMove{MoveNumber: 3, Move: "Nf6", Color: "black", FEN: tt.fen}
+			original := models.MoveAnalysis{Move: "Nf6", MoveNumber: 6, FEN: tt.fen, Evaluation: mateEvaluation, Blunder: true}
+			got, err := (&AnalysisService{}).verifyMove(context.Background(), search, move, original, 6, 0.3,
+				accuracyModels[models.AccuracyModelLegacy], models.EngineSettings{Depth: 12, TimeLimit: 1000}, 0,
+				models.DefaultClassificationThresholds())
+
+			if (searched != nil) != tt.wantSearched {
+				t.Fatalf("searched = %v, want %v", searched != nil, tt.wantSearched)
+			}
+			if searched != nil && (searched.Depth != 12+defaultVerifyDepthIncrease || searched.TimeLimit != 0) {
+				t.Errorf("Expected a depth %d search without a time limit, got %+v", 12+defaultVerifyDepthIncrease, *searched)
+			}
+			if (err == nil) != tt.wantVerified || got.Verified != tt.wantVerified {
+				t.Fatalf("verifyMove() = verified %v, error %v, want verified %v", got.Verified, err, tt.wantVerified)
+			}
+			if !tt.wantVerified && got.Evaluation != original.Evaluation {
+				t.Errorf("Expected the original analysis to be kept, got %+v", got)
+			}
+			if got.Blunder != tt.wantBlunder {
+				t.Errorf("Blunder = %v, want %v", got.Blunder, tt.wantBlunder)
+			}
+		})
+	}
+}
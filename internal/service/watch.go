@@ -0,0 +1,433 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/internal/notify"
+	"github.com/pedrampdd/ChessAnalyser/internal/parser"
+	"github.com/pedrampdd/ChessAnalyser/internal/storage"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+// Watch settings
+const (
+	defaultWatchPollInterval = 15 // Seconds
+	minWatchPollInterval     = 5
+	defaultSwingThreshold    = 1.5 // Pawns
+	defaultWatchDepth        = 14
+	maxActiveWatches         = 20
+	maxWatchPollFailures     = 10             // Consecutive failed polls before a watch gives up
+	maxWatchIdle             = 72 * time.Hour // Watches stop when no move is seen for this long
+	watchRetention           = time.Hour      // Ended watches are kept this long for clients to read
+	watchEvalCap             = 10.0           // Evaluations are capped so mate scores don't dominate swings
+	watchSubscriberBuffer    = 32
+	webhookTimeout           = 5 * time.Second
+)
+
+// watchURLRegex extracts the game ID from Chess.com game URLs such as /game/daily/123 or /live/game/123
+var watchURLRegex = regexp.MustCompile(`^https?://(?:www\.)?chess\.com/(?:[a-z]+/)*(\d+)/?(?:[?#].*)?$`)
+
+// WatchService polls ongoing games, analyzes each new position and streams evaluation updates
+type WatchService struct {
+	gameService  *GameAnalyzerService
+	analyze      func(ctx context.Context, fen string, settings models.EngineSettings) (*models.AnalysisResult, error)
+	httpClient   *http.Client
+	allowPrivate bool // Webhooks may target private, loopback and link-local addresses
+	now          func() time.Time
+	mu           sync.Mutex
+	watches      map[string]*gameWatch
+}
+
+// gameWatch is a watched game and its subscribers
+type gameWatch struct {
+	session     models.WatchSession
+	gameID      string
+	webhookURL  string
+	settings    models.EngineSettings
+	baseline    int // Plies already played when the watch started; they never raise alerts
+	polls       int
+	failures    int
+	endedAt     time.Time // When the watch stopped polling (zero while watching)
+	cancel      context.CancelFunc
+	subscribers map[chan models.EvalUpdate]struct{}
+}
+
+// NewWatchService creates a new game watch service
+func NewWatchService(gameService *GameAnalyzerService, analysisService *AnalysisService) *WatchService {
+	return &WatchService{
+		gameService: gameService,
+		analyze:     analysisService.AnalyzePosition,
+		httpClient:  notify.PublicClient(webhookTimeout),
+		now:         time.Now,
+		watches:     make(map[string]*gameWatch),
+	}
+}
+
+// SetAllowPrivateWebhooks lets swing alert webhooks target private, loopback and link-local
+// addresses, for deployments whose webhooks run on the internal network. By default they're
+// rejected, so users can't make the server reach internal services.
+func (s *WatchService) SetAllowPrivateWebhooks(allow bool) {
+	s.allowPrivate = allow
+	if allow {
+		s.httpClient = &http.Client{Timeout: webhookTimeout}
+	} else {
+		s.httpClient = notify.PublicClient(webhookTimeout)
+	}
+}
+
+// StartWatch starts polling a game in the background and returns the new watch session
+func (s *WatchService) StartWatch(request *models.WatchRequest) (*models.WatchSession, error) {
+	gameID, err := watchGameID(request.URL)
+	if err != nil {
+		return nil, err
+	}
+	if request.Username == "" {
+		return nil, errors.NewValidationError("username", "username of one of the players is required")
+	}
+	if request.WebhookURL != "" {
+		if err := s.checkWebhook(request.WebhookURL); err != nil {
+			return nil, errors.NewValidationError("webhook_url", err.Error())
+		}
+	}
+	if request.PollInterval == 0 {
+		request.PollInterval = defaultWatchPollInterval
+	}
+	if request.PollInterval < minWatchPollInterval {
+		return nil, errors.NewValidationError("poll_interval", fmt.Sprintf("must be at least %d seconds", minWatchPollInterval))
+	}
+	if request.SwingThreshold <= 0 {
+		request.SwingThreshold = defaultSwingThreshold
+	}
+	if request.Settings.Depth <= 0 {
+		request.Settings.Depth = defaultWatchDepth
+	}
+	request.Settings.MultiPV = 1
+
+	id, err := storage.NewID()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pruneWatches()
+	active := 0
+	for _, w := range s.watches {
+		if w.session.Status == models.WatchStatusWatching {
+			active++
+		}
+	}
+	if active >= maxActiveWatches {
+		return nil, errors.NewCapacityError("games watched at once", maxActiveWatches)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &gameWatch{
+		session: models.WatchSession{
+			ID:             id,
+			URL:            request.URL,
+			Username:       request.Username,
+			Status:         models.WatchStatusWatching,
+			SwingThreshold: request.SwingThreshold,
+			PollInterval:   request.PollInterval,
+			StartedAt:      time.Now(),
+			LastMoveAt:     time.Now(),
+		},
+		gameID:      gameID,
+		webhookURL:  request.WebhookURL,
+		settings:    request.Settings,
+		cancel:      cancel,
+		subscribers: make(map[chan models.EvalUpdate]struct{}),
+	}
+	s.watches[id] = w
+
+	go s.run(ctx, w)
+
+	return copyWatchSession(&w.session), nil
+}
+
+// run polls the watched game until it finishes, fails or the watch is stopped
+func (s *WatchService) run(ctx context.Context, w *gameWatch) {
+	ticker := time.NewTicker(time.Duration(w.session.PollInterval) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		if status := s.poll(ctx, w); status != models.WatchStatusWatching {
+			s.finish(w, status)
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll fetches the game, analyzes positions not seen before and returns the watch status
+func (s *WatchService) poll(ctx context.Context, w *gameWatch) string {
+	pgn, finished, err := s.gameService.GetWatchedGamePGN(w.session.Username, w.gameID)
+	if err == nil && pgn != "" {
+		err = s.analyzeNewMoves(ctx, w, pgn)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w.polls++
+	w.session.LastPoll = time.Now()
+	if err != nil {
+		if ctx.Err() != nil {
+			return models.WatchStatusStopped
+		}
+		w.failures++
+		w.session.LastError = err.Error()
+		if w.failures >= maxWatchPollFailures {
+			return models.WatchStatusFailed
+		}
+		return models.WatchStatusWatching
+	}
+
+	w.failures = 0
+	w.session.LastError = ""
+	switch {
+	case finished:
+		return models.WatchStatusFinished
+	case time.Since(w.session.LastMoveAt) > maxWatchIdle:
+		return models.WatchStatusStopped
+	}
+	return models.WatchStatusWatching
+}
+
+// analyzeNewMoves evaluates every position of the game that has no update yet
+func (s *WatchService) analyzeNewMoves(ctx context.Context, w *gameWatch, pgn string) error {
+	pgnParser := parser.NewPGNParser()
+	game, err := pgnParser.ParsePGN(pgn)
+	if err != nil {
+		return err
+	}
+	if err := pgnParser.ExtractPositions(game); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	if w.polls == 0 && len(w.session.Updates) == 0 {
+		// Moves already on the board when watching began are evaluated but never alert
+		w.baseline = len(game.Moves)
+	}
+	next := len(w.session.Updates)
+	s.mu.Unlock()
+
+	for i := next; i < len(game.Moves); i++ {
+		move := game.Moves[i]
+		result, err := s.analyze(ctx, move.FEN, w.settings)
+		if err != nil {
+			return err
+		}
+		s.publish(w, move, i+1, result)
+	}
+
+	return nil
+}
+
+// publish records an evaluation update, sends it to subscribers and fires the webhook on alerts
+func (s *WatchService) publish(w *gameWatch, move parser.ParsedMove, ply int, result *models.AnalysisResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	evaluation := math.Max(-watchEvalCap, math.Min(watchEvalCap, result.Evaluation))
+	previous := 0.0
+	if n := len(w.session.Updates); n > 0 {
+		previous = w.session.Updates[n-1].Evaluation
+	}
+	previous = math.Max(-watchEvalCap, math.Min(watchEvalCap, previous))
+
+	update := models.EvalUpdate{
+		Ply:        ply,
+		Move:       move.Move,
+		FEN:        move.FEN,
+		Evaluation: result.Evaluation,
+		BestMove:   result.BestMove,
+		Depth:      result.Depth,
+		Swing:      evaluation - previous,
+		Time:       time.Now(),
+	}
+	update.Alert = ply > w.baseline && len(w.session.Updates) > 0 && math.Abs(update.Swing) >= w.session.SwingThreshold
+
+	w.session.Updates = append(w.session.Updates, update)
+	w.session.LastMoveAt = update.Time
+	if update.Alert {
+		w.session.Alerts++
+		if w.webhookURL != "" {
+			go s.sendWebhook(w.webhookURL, models.WatchAlert{WatchID: w.session.ID, URL: w.session.URL, Update: update})
+		}
+	}
+
+	for ch := range w.subscribers {
+		select {
+		case ch <- update:
+		default:
+			// Slow subscribers miss updates rather than stalling the watch
+		}
+	}
+}
+
+// sendWebhook posts a swing alert, logging failures
+func (s *WatchService) sendWebhook(webhookURL string, alert models.WatchAlert) {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		log.Printf("Failed to encode swing alert: %v", err)
+		return
+	}
+
+	resp, err := s.httpClient.Post(webhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		log.Printf("Swing alert webhook failed for watch %s: %v", alert.WatchID, err)
+		return
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		log.Printf("Swing alert webhook for watch %s returned status %d", alert.WatchID, resp.StatusCode)
+	}
+}
+
+// finish sets the final status of a watch and closes its subscriptions
+func (s *WatchService) finish(w *gameWatch, status string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if w.session.Status != models.WatchStatusWatching {
+		return
+	}
+	w.session.Status = status
+	w.endedAt = s.now()
+	w.cancel()
+	for ch := range w.subscribers {
+		close(ch)
+		delete(w.subscribers, ch)
+	}
+}
+
+// checkWebhook rejects webhook URLs that aren't http or https and, unless private targets are
+// allowed, those whose host resolves to an address that isn't public
+func (s *WatchService) checkWebhook(webhookURL string) error {
+	if s.allowPrivate {
+		if u, err := url.Parse(webhookURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("must be an http or https URL")
+		}
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+	defer cancel()
+	return notify.CheckDestination(ctx, webhookURL)
+}
+
+// pruneWatches forgets watches that ended longer ago than the retention period. The caller must
+// hold s.mu.
+func (s *WatchService) pruneWatches() {
+	for id, w := range s.watches {
+		if !w.endedAt.IsZero() && s.now().Sub(w.endedAt) > watchRetention {
+			delete(s.watches, id)
+		}
+	}
+}
+
+// GetWatch returns a watch session with all updates so far
+func (s *WatchService) GetWatch(id string) (*models.WatchSession, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pruneWatches()
+	w, ok := s.watches[id]
+	if !ok {
+		return nil, errors.NewWatchNotFoundError(id)
+	}
+	return copyWatchSession(&w.session), nil
+}
+
+// StopWatch stops polling a game; its updates stay available
+func (s *WatchService) StopWatch(id string) (*models.WatchSession, error) {
+	s.mu.Lock()
+	w, ok := s.watches[id]
+	s.mu.Unlock()
+	if !ok {
+		return nil, errors.NewWatchNotFoundError(id)
+	}
+
+	s.finish(w, models.WatchStatusStopped)
+	return s.GetWatch(id)
+}
+
+// Subscribe returns the updates published so far and a channel receiving new ones.
+// The channel is closed when the watch ends; call the returned function to unsubscribe early.
+func (s *WatchService) Subscribe(id string) ([]models.EvalUpdate, <-chan models.EvalUpdate, func(), error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w, ok := s.watches[id]
+	if !ok {
+		return nil, nil, nil, errors.NewWatchNotFoundError(id)
+	}
+
+	backlog := append([]models.EvalUpdate(nil), w.session.Updates...)
+	ch := make(chan models.EvalUpdate, watchSubscriberBuffer)
+	if w.session.Status != models.WatchStatusWatching {
+		close(ch)
+		return backlog, ch, func() {}, nil
+	}
+
+	w.subscribers[ch] = struct{}{}
+	unsubscribe := func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if _, ok := w.subscribers[ch]; ok {
+			delete(w.subscribers, ch)
+			close(ch)
+		}
+	}
+	return backlog, ch, unsubscribe, nil
+}
+
+// Close stops all watches
+func (s *WatchService) Close() {
+	s.mu.Lock()
+	watches := make([]*gameWatch, 0, len(s.watches))
+	for _, w := range s.watches {
+		watches = append(watches, w)
+	}
+	s.mu.Unlock()
+
+	for _, w := range watches {
+		s.finish(w, models.WatchStatusStopped)
+	}
+}
+
+// watchGameID extracts the game ID from a Chess.com game URL
+func watchGameID(gameURL string) (string, error) {
+	matches := watchURLRegex.FindStringSubmatch(gameURL)
+	if matches == nil {
+		return "", errors.NewValidationError("url", fmt.Sprintf("not a Chess.com game URL: %s", gameURL))
+	}
+	return matches[1], nil
+}
+
+// copyWatchSession copies a session so callers can't race with the poller
+func copyWatchSession(session *models.WatchSession) *models.WatchSession {
+	copied := *session
+	copied.Updates = append([]models.EvalUpdate(nil), session.Updates...)
+	return &copied
+}
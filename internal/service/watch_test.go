@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+func TestWatchGameID(t *testing.T) {
+	tests := []struct {
+		url     string
+		want    string
+		wantErr bool
+	}{
+		{"https://www.chess.com/game/daily/123456", "123456", false},
+		{"https://www.chess.com/game/live/98765?tab=analysis", "98765", false},
+		{"https://www.chess.com/daily/game/42", "42", false},
+		{"https://lichess.org/abcdef", "", true},
+		{"123456", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := watchGameID(tt.url)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("watchGameID(%q) = %q, %v, want %q, error %v", tt.url, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestWatchService_Poll(t *testing.T) {
+	moves := "1. e4 e5"
+	ongoing := true
+	alerts := make(chan models.WatchAlert, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pgn := fmt.Sprintf(`[Event \"Let's Play!\"]\n\n%s *`, moves)
+		thisMonth := time.Now()
+		switch r.URL.Path {
+		case "/player/alice/games":
+			if ongoing {
+				fmt.Fprintf(w, `{"games": [{"url": "https://www.chess.com/game/daily/77", "pgn": "%s"}]}`, pgn)
+				return
+			}
+			fmt.Fprint(w, `{"games": []}`)
+		case fmt.Sprintf("/player/alice/games/%d/%02d", thisMonth.Year(), thisMonth.Month()):
+			fmt.Fprintf(w, `{"games": [{"url": "https://www.chess.com/game/daily/77", "pgn": "%s"}]}`, pgn)
+		case "/webhook":
+			var alert models.WatchAlert
+			json.NewDecoder(r.Body).Decode(&alert)
+			alerts <- alert
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	gameService := NewGameAnalyzerService()
+	gameService.chessAPI.BaseURL = server.URL
+	watches := NewWatchService(gameService, nil)
+	watches.SetAllowPrivateWebhooks(true)
+	watches.analyze = func(ctx context.Context, fen string, settings models.EngineSettings) (*models.AnalysisResult, error) {
+		// Black's queen hangs after 2... Qh4
+		if strings.HasPrefix(fen, "rnb1kbnr/pppp1ppp/8/4p3/4P2q/5N2") {
+			return &models.AnalysisResult{Evaluation: 3, BestMove: "f3h4"}, nil
+		}
+		return &models.AnalysisResult{Evaluation: 0.3}, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w := &gameWatch{
+		session:     models.WatchSession{ID: "w1", Username: "alice", Status: models.WatchStatusWatching, SwingThreshold: 1.5, LastMoveAt: time.Now()},
+		gameID:      "77",
+		webhookURL:  server.URL + "/webhook",
+		cancel:      cancel,
+		subscribers: make(map[chan models.EvalUpdate]struct{}),
+	}
+	watches.watches["w1"] = w
+
+	if status := watches.poll(ctx, w); status != models.WatchStatusWatching {
+		t.Fatalf("Expected the game to be watched, got status %s (%s)", status, w.session.LastError)
+	}
+	if len(w.session.Updates) != 2 || w.session.Alerts != 0 {
+		t.Fatalf("Expected two updates and no alerts for moves played before watching, got %+v", w.session)
+	}
+
+	_, updates, unsubscribe, err := watches.Subscribe("w1")
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	defer unsubscribe()
+
+	moves = "1. e4 e5 2. Nf3 Qh4"
+	watches.poll(ctx, w)
+
+	if len(w.session.Updates) != 4 || w.session.Alerts != 1 {
+		t.Fatalf("Expected four updates and one alert, got %+v", w.session)
+	}
+	<-updates
+	if update := <-updates; update.Ply != 4 || !update.Alert || update.Swing != 2.7 {
+		t.Errorf("Expected a swing alert on ply 4, got %+v", update)
+	}
+
+	select {
+	case alert := <-alerts:
+		if alert.WatchID != "w1" || alert.Update.Ply != 4 {
+			t.Errorf("Unexpected webhook payload: %+v", alert)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("Expected the swing alert webhook to be called")
+	}
+
+	// The game ended: it's found in the monthly archive and the watch finishes
+	ongoing = false
+	if status := watches.poll(ctx, w); status != models.WatchStatusFinished {
+		t.Errorf("Expected the watch to finish, got %s", status)
+	}
+}
+
+func TestWatchService_StartWatchRejectsPrivateWebhooks(t *testing.T) {
+	watches := NewWatchService(NewGameAnalyzerService(), nil)
+
+	for _, webhookURL := range []string{
+		"http://127.0.0.1:8080/hook",
+		"http://localhost/hook",
+		"http://10.0.0.5/hook",
+		"http://169.254.169.254/latest/meta-data/",
+		"http://[::1]/hook",
+		"http://[fd00:ec2::254]/hook",
+		"ftp://example.com/hook",
+	} {
+		_, err := watches.StartWatch(&models.WatchRequest{
+			URL:        "https://www.chess.com/game/daily/77",
+			Username:   "alice",
+			WebhookURL: webhookURL,
+		})
+		var validation *errors.ValidationError
+		if !errors.As(err, &validation) || validation.Field != "webhook_url" {
+			t.Errorf("StartWatch(%q) error = %v, want a webhook_url validation error", webhookURL, err)
+		}
+	}
+	if len(watches.watches) != 0 {
+		t.Errorf("Expected no watch to start, got %d", len(watches.watches))
+	}
+}
+
+func TestWatchService_PrunesEndedWatches(t *testing.T) {
+	now := time.Now()
+	watches := NewWatchService(NewGameAnalyzerService(), nil)
+	watches.now = func() time.Time { return now }
+
+	for _, id := range []string{"ended", "recent", "watching"} {
+		_, cancel := context.WithCancel(context.Background())
+		watches.watches[id] = &gameWatch{
+			session:     models.WatchSession{ID: id, Status: models.WatchStatusWatching},
+			cancel:      cancel,
+			subscribers: make(map[chan models.EvalUpdate]struct{}),
+		}
+	}
+	watches.finish(watches.watches["ended"], models.WatchStatusFinished)
+	now = now.Add(watchRetention / 2)
+	watches.finish(watches.watches["recent"], models.WatchStatusStopped)
+	now = now.Add(watchRetention)
+
+	if _, err := watches.GetWatch("ended"); err == nil {
+		t.Error("Expected a watch ended longer ago than the retention period to be forgotten")
+	}
+	for _, id := range []string{"recent", "watching"} {
+		if _, err := watches.GetWatch(id); err != nil {
+			t.Errorf("GetWatch(%q) error = %v", id, err)
+		}
+	}
+}
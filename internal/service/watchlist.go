@@ -0,0 +1,441 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/internal/notify"
+	"github.com/pedrampdd/ChessAnalyser/internal/parser"
+	"github.com/pedrampdd/ChessAnalyser/internal/storage"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+// Watchlist settings
+const (
+	maxWatchlistEntries    = 100
+	maxReportAnalyses      = 20 // Games analyzed per report; the rest are listed without analysis
+	defaultWatchlistDepth  = 12
+	watchlistCheckInterval = time.Minute
+)
+
+// WatchlistService fetches watched players' new games on a schedule, analyzes them and
+// delivers a summary report to each entry's notification channels
+type WatchlistService struct {
+	gameService *GameAnalyzerService
+	pgnParser   *parser.PGNParser
+	analyze     func(ctx context.Context, request *models.AnalysisRequest) (*models.GameAnalysis, error)
+	notifier    *notify.Notifier
+	mu          sync.Mutex
+	reportMu    sync.Mutex // Serializes reports so they never monopolize the engine pool
+	entries     map[string]*watchlistEntry
+}
+
+// watchlistEntry is a watched player with the settings used for their reports
+type watchlistEntry struct {
+	state    models.WatchlistEntry
+	settings models.EngineSettings
+	since    time.Time // Games that ended after this haven't been reported yet
+}
+
+// NewWatchlistService creates a new watchlist service
+func NewWatchlistService(gameService *GameAnalyzerService, analysisService *AnalysisService, notifier *notify.Notifier) *WatchlistService {
+	return &WatchlistService{
+		gameService: gameService,
+		pgnParser:   parser.NewPGNParser(),
+		analyze:     analysisService.analyzeGameMoves,
+		notifier:    notifier,
+		entries:     make(map[string]*watchlistEntry),
+	}
+}
+
+// AddEntry adds a player to the watchlist. The first report covers the schedule's period before it was added.
+func (s *WatchlistService) AddEntry(request *models.WatchlistRequest) (*models.WatchlistEntry, error) {
+	if request.Username == "" {
+		return nil, errors.NewValidationError("username", "username is required")
+	}
+	if request.Schedule == "" {
+		request.Schedule = models.ScheduleWeekly
+	}
+	period, ok := schedulePeriod(request.Schedule)
+	if !ok {
+		return nil, errors.NewValidationError("schedule", fmt.Sprintf("unknown schedule: %s", request.Schedule))
+	}
+
+	channels := request.Channels
+	if request.Delivery != "" || len(channels) == 0 {
+		channels = append([]models.NotificationChannel{{Type: request.Delivery, Target: request.Target}}, channels...)
+	}
+	for _, channel := range channels {
+		if err := s.notifier.Validate(channel); err != nil {
+			return nil, err
+		}
+	}
+
+	if request.Settings.Depth <= 0 {
+		request.Settings.Depth = defaultWatchlistDepth
+	}
+	request.Settings.MultiPV = 1
+
+	id, err := storage.NewID()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.entries) >= maxWatchlistEntries {
+		return nil, errors.NewStorageLimitError("players on the watchlist", maxWatchlistEntries)
+	}
+
+	now := time.Now()
+	e := &watchlistEntry{
+		state: models.WatchlistEntry{
+			ID:           id,
+			Username:     strings.ToLower(request.Username),
+			Schedule:     request.Schedule,
+			Delivery:     request.Delivery,
+			Target:       request.Target,
+			Channels:     channels,
+			CreatedAt:    now,
+			NextReportAt: now.Add(period),
+		},
+		settings: request.Settings,
+		since:    now.Add(-period),
+	}
+	s.entries[id] = e
+
+	state := e.state
+	return &state, nil
+}
+
+// ListEntries returns a page of the watchlist entries, oldest first
+func (s *WatchlistService) ListEntries(request models.PageRequest) (*models.Page[*models.WatchlistEntry], error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries := make([]*models.WatchlistEntry, 0, len(s.entries))
+	for _, e := range s.entries {
+		state := e.state
+		entries = append(entries, &state)
+	}
+	return storage.Paginate(storage.ListWatchlist, entries, func(entry *models.WatchlistEntry) string {
+		return storage.TimeKey(entry.CreatedAt, entry.ID)
+	}, request)
+}
+
+// GetEntry returns a watchlist entry with its last report
+func (s *WatchlistService) GetEntry(id string) (*models.WatchlistEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[id]
+	if !ok {
+		return nil, errors.NewWatchlistEntryNotFoundError(id)
+	}
+	state := e.state
+	return &state, nil
+}
+
+// RemoveEntry removes a player from the watchlist
+func (s *WatchlistService) RemoveEntry(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.entries[id]; !ok {
+		return errors.NewWatchlistEntryNotFoundError(id)
+	}
+	delete(s.entries, id)
+	return nil
+}
+
+// Start delivers due reports every minute until ctx is cancelled
+func (s *WatchlistService) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(watchlistCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.deliverDue(ctx)
+			}
+		}
+	}()
+}
+
+// deliverDue runs every report whose scheduled time has passed, logging failures
+func (s *WatchlistService) deliverDue(ctx context.Context) {
+	now := time.Now()
+
+	s.mu.Lock()
+	var due []*watchlistEntry
+	for _, e := range s.entries {
+		if !e.state.NextReportAt.After(now) {
+			due = append(due, e)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, e := range due {
+		if ctx.Err() != nil {
+			return
+		}
+		if _, err := s.report(ctx, e); err != nil {
+			log.Printf("Watchlist report failed for %s: %v", e.state.Username, err)
+		}
+
+		period, _ := schedulePeriod(e.state.Schedule)
+		s.mu.Lock()
+		for !e.state.NextReportAt.After(now) {
+			e.state.NextReportAt = e.state.NextReportAt.Add(period)
+		}
+		s.mu.Unlock()
+	}
+}
+
+// RunReport builds and delivers a player's report now, without changing the schedule
+func (s *WatchlistService) RunReport(ctx context.Context, id string) (*models.WatchlistReport, error) {
+	s.mu.Lock()
+	e, ok := s.entries[id]
+	s.mu.Unlock()
+
+	if !ok {
+		return nil, errors.NewWatchlistEntryNotFoundError(id)
+	}
+	return s.report(ctx, e)
+}
+
+// report builds a report of the games finished since the last delivered one and delivers it.
+// Games stay pending when delivery fails, so the next report includes them.
+func (s *WatchlistService) report(ctx context.Context, e *watchlistEntry) (*models.WatchlistReport, error) {
+	s.reportMu.Lock()
+	defer s.reportMu.Unlock()
+
+	s.mu.Lock()
+	state, settings, since := e.state, e.settings, e.since
+	s.mu.Unlock()
+
+	now := time.Now()
+	report, err := s.buildReport(ctx, &state, settings, since, now)
+	if err == nil {
+		err = s.deliver(ctx, &state, report)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err != nil {
+		e.state.LastError = err.Error()
+		return nil, err
+	}
+	e.since = now
+	e.state.LastError = ""
+	e.state.ReportsSent++
+	e.state.LastReport = report
+	return report, nil
+}
+
+// buildReport fetches the player's games that ended in (from, to] and analyzes the most recent ones
+func (s *WatchlistService) buildReport(ctx context.Context, entry *models.WatchlistEntry, settings models.EngineSettings,
+	from, to time.Time) (*models.WatchlistReport, error) {
+	games, err := s.gamesBetween(ctx, entry.Username, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.WatchlistReport{
+		EntryID:       entry.ID,
+		Username:      entry.Username,
+		Schedule:      entry.Schedule,
+		From:          from,
+		To:            to,
+		GameSummaries: []models.WatchlistGame{},
+		GeneratedAt:   time.Now(),
+	}
+
+	accuracySum := 0.0
+	for _, game := range games {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		color, ok := playerColor(game, entry.Username)
+		if !ok {
+			continue
+		}
+
+		summary := models.WatchlistGame{
+			URL:       game.URL,
+			Opponent:  game.BlackPlayer.Username,
+			Color:     color.String(),
+			TimeClass: game.TimeClass,
+			EndTime:   *game.EndTime,
+		}
+		if summary.Color == "black" {
+			summary.Opponent = game.WhitePlayer.Username
+		}
+
+		if parsed, err := s.pgnParser.ParsePGN(game.PGN); err == nil {
+			if score, decided := playerScore(parsed.Result, color); decided {
+				switch score {
+				case 1:
+					summary.Result = "win"
+					report.Wins++
+				case 0.5:
+					summary.Result = "draw"
+					report.Draws++
+				default:
+					summary.Result = "loss"
+					report.Losses++
+				}
+			}
+		}
+
+		if report.AnalyzedGames < maxReportAnalyses && game.Rules == "chess" && game.PGN != "" {
+			analysis, err := s.analyze(ctx, &models.AnalysisRequest{
+				PGN:      game.PGN,
+				Settings: settings,
+				Mode:     models.AnalysisModeScan,
+			})
+			if err != nil {
+				log.Printf("Watchlist analysis failed for %s: %v", game.URL, err)
+			} else {
+				summarizePlayerMoves(&summary, analysis)
+				report.AnalyzedGames++
+				accuracySum += summary.Accuracy
+				report.Blunders += summary.Blunders
+				report.Mistakes += summary.Mistakes
+				report.Inaccuracies += summary.Inaccuracies
+			}
+		}
+
+		report.Games++
+		report.GameSummaries = append(report.GameSummaries, summary)
+	}
+
+	if report.AnalyzedGames > 0 {
+		report.AverageAccuracy = accuracySum / float64(report.AnalyzedGames)
+	}
+	return report, nil
+}
+
+// summarizePlayerMoves copies the reported player's accuracy and errors from an analysis
+func summarizePlayerMoves(summary *models.WatchlistGame, analysis *models.GameAnalysis) {
+	summary.AnalysisID = analysis.ID
+	summary.Accuracy = analysis.Accuracy.WhiteAccuracy
+	if summary.Color == "black" {
+		summary.Accuracy = analysis.Accuracy.BlackAccuracy
+	}
+
+	for _, move := range analysis.Moves {
+		if moveColor(move.FEN, move.MoveNumber) != summary.Color {
+			continue
+		}
+		switch {
+		case move.Blunder:
+			summary.Blunders++
+		case move.Mistake:
+			summary.Mistakes++
+		case move.Inaccuracy:
+			summary.Inaccuracies++
+		}
+	}
+}
+
+// gamesBetween returns the player's games that ended in (from, to], most recent first.
+// Only archives that can contain such games are fetched.
+func (s *WatchlistService) gamesBetween(ctx context.Context, username string, from, to time.Time) ([]*models.GameInfo, error) {
+	archives, err := s.gameService.GetPlayerArchives(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	first := archiveKey(from.UTC().Year(), int(from.UTC().Month()))
+	var games []*models.GameInfo
+	for i := len(archives) - 1; i >= 0; i-- {
+		if archiveKey(archives[i][0], archives[i][1]) < first {
+			break
+		}
+
+		monthly, err := s.gameService.GetPlayerGames(username, archives[i][0], archives[i][1], models.GameFilter{})
+		if isArchiveNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for j := len(monthly) - 1; j >= 0; j-- {
+			game := monthly[j]
+			if game.EndTime != nil && game.EndTime.After(from) && !game.EndTime.After(to) {
+				games = append(games, game)
+			}
+		}
+	}
+
+	sort.SliceStable(games, func(i, j int) bool {
+		return games[i].EndTime.After(*games[j].EndTime)
+	})
+	return games, nil
+}
+
+// deliver sends a report to each of the entry's channels
+func (s *WatchlistService) deliver(ctx context.Context, entry *models.WatchlistEntry, report *models.WatchlistReport) error {
+	err := s.notifier.Send(ctx, entry.Channels, notify.Message{
+		Subject: fmt.Sprintf("Chess report for %s: %d games", report.Username, report.Games),
+		Text:    formatWatchlistReport(report),
+		Payload: report,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to deliver report: %w", err)
+	}
+	return nil
+}
+
+// formatWatchlistReport renders a report as plain text for email and chat delivery
+func formatWatchlistReport(report *models.WatchlistReport) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s report for %s\n", strings.ToUpper(report.Schedule[:1])+report.Schedule[1:], report.Username)
+	fmt.Fprintf(&b, "%s to %s\n\n", report.From.Format("2006-01-02 15:04"), report.To.Format("2006-01-02 15:04"))
+
+	if report.Games == 0 {
+		b.WriteString("No games finished in this period.\n")
+		return b.String()
+	}
+
+	fmt.Fprintf(&b, "Games: %d (+%d =%d -%d)\n", report.Games, report.Wins, report.Draws, report.Losses)
+	if report.AnalyzedGames > 0 {
+		fmt.Fprintf(&b, "Accuracy: %.1f%% over %d analyzed games\n", report.AverageAccuracy, report.AnalyzedGames)
+		fmt.Fprintf(&b, "Blunders: %d, mistakes: %d, inaccuracies: %d\n", report.Blunders, report.Mistakes, report.Inaccuracies)
+	}
+
+	b.WriteString("\n")
+	for _, game := range report.GameSummaries {
+		fmt.Fprintf(&b, "- %s vs %s (%s, %s)", game.Result, game.Opponent, game.Color, game.TimeClass)
+		if game.AnalysisID != "" {
+			fmt.Fprintf(&b, ": %.1f%% accuracy, %d blunders", game.Accuracy, game.Blunders)
+		}
+		fmt.Fprintf(&b, "\n  %s\n", game.URL)
+	}
+	return b.String()
+}
+
+// schedulePeriod returns the time between reports on a schedule
+func schedulePeriod(schedule string) (time.Duration, bool) {
+	switch schedule {
+	case models.ScheduleDaily:
+		return 24 * time.Hour, true
+	case models.ScheduleWeekly:
+		return 7 * 24 * time.Hour, true
+	}
+	return 0, false
+}
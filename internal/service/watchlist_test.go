@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/internal/notify"
+)
+
+func TestWatchlistService_AddEntry(t *testing.T) {
+	watchlist := NewWatchlistService(NewGameAnalyzerService(), nil, notify.New(notify.Settings{}))
+
+	tests := []struct {
+		name    string
+		request models.WatchlistRequest
+		wantErr bool
+	}{
+		{"webhook", models.WatchlistRequest{Username: "Alice", Delivery: models.DeliveryWebhook, Target: "https://example.com/hook"}, false},
+		{"missing username", models.WatchlistRequest{Delivery: models.DeliveryWebhook, Target: "https://example.com/hook"}, true},
+		{"unknown schedule", models.WatchlistRequest{Username: "alice", Schedule: "monthly", Delivery: models.DeliveryWebhook, Target: "https://example.com/hook"}, true},
+		{"invalid webhook", models.WatchlistRequest{Username: "alice", Delivery: models.DeliveryWebhook, Target: "ftp://example.com"}, true},
+		{"loopback webhook", models.WatchlistRequest{Username: "alice", Delivery: models.DeliveryWebhook, Target: "http://127.0.0.1:8080/hook"}, true},
+		{"metadata webhook", models.WatchlistRequest{Username: "alice", Delivery: models.DeliveryWebhook, Target: "http://169.254.169.254/latest/meta-data/"}, true},
+		{"email not configured", models.WatchlistRequest{Username: "alice", Delivery: models.DeliveryEmail, Target: "alice@example.com"}, true},
+		{"unknown delivery", models.WatchlistRequest{Username: "alice", Delivery: "sms", Target: "555"}, true},
+		{"slack channel only", models.WatchlistRequest{Username: "alice", Channels: []models.NotificationChannel{{Type: models.DeliverySlack, Target: "https://hooks.slack.com/services/T/B/x"}}}, false},
+		{"telegram not configured", models.WatchlistRequest{Username: "alice", Delivery: models.DeliveryWebhook, Target: "https://example.com/hook",
+			Channels: []models.NotificationChannel{{Type: models.DeliveryTelegram, Target: "12345"}}}, true},
+	}
+
+	for _, tt := range tests {
+		entry, err := watchlist.AddEntry(&tt.request)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: AddEntry() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err == nil && (entry.Username != "alice" || entry.Schedule != models.ScheduleWeekly) {
+			t.Errorf("%s: unexpected entry %+v", tt.name, entry)
+		}
+	}
+}
+
+func TestWatchlistService_RunReport(t *testing.T) {
+	now := time.Now()
+	reports := make(chan models.WatchlistReport, 2)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/player/alice/games/archives":
+			fmt.Fprintf(w, `{"archives": ["https://api.chess.com/pub/player/alice/games/%d/%02d"]}`, now.Year(), now.Month())
+		case fmt.Sprintf("/player/alice/games/%d/%02d", now.Year(), now.Month()):
+			fmt.Fprintf(w, `{"games": [
+				{"url": "https://www.chess.com/game/live/1", "rules": "chess", "time_class": "blitz", "end_time": %d,
+				 "pgn": "[Result \"1-0\"]\n\n1. e4 e5 1-0", "white": {"username": "Bob"}, "black": {"username": "Alice"}},
+				{"url": "https://www.chess.com/game/live/2", "rules": "chess", "time_class": "rapid", "end_time": %d,
+				 "pgn": "[Result \"1-0\"]\n\n1. e4 e5 1-0", "white": {"username": "Alice"}, "black": {"username": "Bob"}}
+			]}`, now.Add(-30*24*time.Hour).Unix(), now.Add(-time.Hour).Unix())
+		case "/webhook":
+			var report models.WatchlistReport
+			json.NewDecoder(r.Body).Decode(&report)
+			reports <- report
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	gameService := NewGameAnalyzerService()
+	gameService.chessAPI.BaseURL = server.URL
+	// The test server listens on loopback
+	watchlist := NewWatchlistService(gameService, nil, notify.New(notify.Settings{AllowPrivateTargets: true}))
+	watchlist.analyze = func(ctx context.Context, request *models.AnalysisRequest) (*models.GameAnalysis, error) {
+		return &models.GameAnalysis{
+			ID:       "a1",
+			Accuracy: models.GameAccuracy{WhiteAccuracy: 91, BlackAccuracy: 70},
+			Moves:    []models.MoveAnalysis{{MoveNumber: 1}, {MoveNumber: 2, Blunder: true}},
+		}, nil
+	}
+
+	entry, err := watchlist.AddEntry(&models.WatchlistRequest{Username: "alice", Delivery: models.DeliveryWebhook, Target: server.URL + "/webhook"})
+	if err != nil {
+		t.Fatalf("AddEntry() error = %v", err)
+	}
+
+	report, err := watchlist.RunReport(context.Background(), entry.ID)
+	if err != nil {
+		t.Fatalf("RunReport() error = %v", err)
+	}
+	if report.Games != 1 || report.Wins != 1 || report.AnalyzedGames != 1 || report.AverageAccuracy != 91 || report.Blunders != 0 {
+		t.Errorf("Expected only last hour's win to be reported, got %+v", report)
+	}
+	if got := report.GameSummaries[0]; got.Opponent != "Bob" || got.Color != "white" || got.AnalysisID != "a1" {
+		t.Errorf("Unexpected game summary: %+v", got)
+	}
+
+	select {
+	case delivered := <-reports:
+		if delivered.EntryID != entry.ID || delivered.Games != 1 {
+			t.Errorf("Unexpected webhook payload: %+v", delivered)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the report to be posted to the webhook")
+	}
+
+	// Reported games aren't included again
+	if report, _ = watchlist.RunReport(context.Background(), entry.ID); report.Games != 0 {
+		t.Errorf("Expected no new games in the second report, got %+v", report)
+	}
+	if entry, _ = watchlist.GetEntry(entry.ID); entry.ReportsSent != 2 || entry.LastReport == nil {
+		t.Errorf("Expected two delivered reports, got %+v", entry)
+	}
+}
+
+func TestFormatWatchlistReport(t *testing.T) {
+	report := &models.WatchlistReport{
+		Username:        "alice",
+		Schedule:        models.ScheduleDaily,
+		Games:           1,
+		Wins:            1,
+		AnalyzedGames:   1,
+		AverageAccuracy: 91,
+		GameSummaries: []models.WatchlistGame{
+			{URL: "https://www.chess.com/game/live/2", Opponent: "Bob", Color: "white", Result: "win", TimeClass: "rapid", AnalysisID: "a1", Accuracy: 91},
+		},
+	}
+
+	text := formatWatchlistReport(report)
+	for _, want := range []string{"Daily report for alice", "Games: 1 (+1 =0 -0)", "Accuracy: 91.0%", "win vs Bob (white, rapid)"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected report text to contain %q, got:\n%s", want, text)
+		}
+	}
+}
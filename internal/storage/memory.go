@@ -0,0 +1,542 @@
+package storage
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+// MemoryStore keeps completed analyses and user data in memory
+type MemoryStore struct {
+	analyses    map[string]*models.GameAnalysis
+	moveRows    map[string][]models.MoveAnalysis   // Moves of saved streamed analyses, by analysis ID
+	moveWindows map[string][][]models.MoveAnalysis // Windows of moves streamed for analyses not saved yet
+	preferences map[string]*models.UserPreferences
+	shareLinks  map[string]*models.ShareLink
+	playerGames map[string]map[string]*models.GameInfo // Synced games by player, keyed by game URL
+	syncStates  map[string]*models.SyncState
+	gameCursors map[string]int64 // End time of the last new game returned, by player and consumer
+	artifacts   map[string]*models.Artifact
+	rawArchives map[string][]*models.RawArchive // Raw archive snapshots by player, in fetch order
+	gameSources map[string]*models.RawArchive   // Latest snapshot containing each game, keyed by game URL
+	audit       []*models.AuditEntry            // Audit log of analysis requests, oldest first
+	mu          sync.RWMutex
+}
+
+// NewMemoryStore creates a new in-memory analysis store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		analyses:    make(map[string]*models.GameAnalysis),
+		moveRows:    make(map[string][]models.MoveAnalysis),
+		moveWindows: make(map[string][][]models.MoveAnalysis),
+		preferences: make(map[string]*models.UserPreferences),
+		shareLinks:  make(map[string]*models.ShareLink),
+		playerGames: make(map[string]map[string]*models.GameInfo),
+		syncStates:  make(map[string]*models.SyncState),
+		gameCursors: make(map[string]int64),
+		artifacts:   make(map[string]*models.Artifact),
+		rawArchives: make(map[string][]*models.RawArchive),
+		gameSources: make(map[string]*models.RawArchive),
+	}
+}
+
+// SaveAnalysis stores an analysis, assigning it an ID if it doesn't have one yet
+func (s *MemoryStore) SaveAnalysis(analysis *models.GameAnalysis) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if analysis.ID == "" {
+		id, err := NewID()
+		if err != nil {
+			return "", err
+		}
+		analysis.ID = id
+	}
+
+	// The windows of a streamed analysis are joined once it's saved and released
+	if windows, ok := s.moveWindows[analysis.ID]; ok {
+		rows := make([]models.MoveAnalysis, 0, analysis.StreamedMoves)
+		for _, window := range windows {
+			rows = append(rows, window...)
+		}
+		s.moveRows[analysis.ID] = rows[:len(rows):len(rows)]
+		delete(s.moveWindows, analysis.ID)
+	}
+
+	s.analyses[analysis.ID] = analysis
+	return analysis.ID, nil
+}
+
+// GetAnalysis retrieves a stored analysis by ID
+func (s *MemoryStore) GetAnalysis(id string) (*models.GameAnalysis, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	analysis, ok := s.analyses[id]
+	if !ok {
+		return nil, errors.NewAnalysisNotFoundError(id)
+	}
+	return s.withMoveRows(analysis), nil
+}
+
+// AppendAnalysisMoves stores moves of a streamed analysis as they complete, before the analysis
+// itself is saved. Each window is copied once into a slice of its own size, so the caller can
+// reuse its buffer and earlier windows are never copied again while the analysis runs.
+func (s *MemoryStore) AppendAnalysisMoves(id string, moves []models.MoveAnalysis) {
+	if len(moves) == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.moveWindows[id] = append(s.moveWindows[id], append([]models.MoveAnalysis(nil), moves...))
+}
+
+// DeleteAnalysisMoves drops the moves streamed for an analysis that never completed or wasn't saved
+func (s *MemoryStore) DeleteAnalysisMoves(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.moveWindows, id)
+}
+
+// withMoveRows returns a streamed analysis with its stored moves attached, and other analyses as
+// they are. The moves are shared, so callers must not modify them.
+func (s *MemoryStore) withMoveRows(analysis *models.GameAnalysis) *models.GameAnalysis {
+	if analysis.StreamedMoves == 0 {
+		return analysis
+	}
+	attached := *analysis
+	rows := s.moveRows[analysis.ID]
+	attached.Moves = rows[:len(rows):len(rows)]
+	return &attached
+}
+
+// ListAnalyses returns every stored analysis, oldest first
+func (s *MemoryStore) ListAnalyses() []*models.GameAnalysis {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	analyses := make([]*models.GameAnalysis, 0, len(s.analyses))
+	for _, analysis := range s.analyses {
+		analyses = append(analyses, s.withMoveRows(analysis))
+	}
+	sort.Slice(analyses, func(i, j int) bool {
+		if !analyses[i].AnalysisTime.Equal(analyses[j].AnalysisTime) {
+			return analyses[i].AnalysisTime.Before(analyses[j].AnalysisTime)
+		}
+		return analyses[i].ID < analyses[j].ID
+	})
+	return analyses
+}
+
+// QueryAnalyses returns a page of the stored analyses, oldest first
+func (s *MemoryStore) QueryAnalyses(request models.PageRequest) (*models.Page[*models.GameAnalysis], error) {
+	return Paginate(ListAnalyses, s.ListAnalyses(), func(analysis *models.GameAnalysis) string {
+		return TimeKey(analysis.AnalysisTime, analysis.ID)
+	}, request)
+}
+
+// UpdateAnalysis applies an update to a copy of a stored analysis and replaces it,
+// so readers holding the previous version never observe a partial edit
+func (s *MemoryStore) UpdateAnalysis(id string, update func(*models.GameAnalysis) error) (*models.GameAnalysis, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, ok := s.analyses[id]
+	if !ok {
+		return nil, errors.NewAnalysisNotFoundError(id)
+	}
+
+	updated := *s.withMoveRows(current)
+	updated.Moves = append([]models.MoveAnalysis(nil), updated.Moves...)
+	if err := update(&updated); err != nil {
+		return nil, err
+	}
+
+	// A streamed analysis keeps its moves apart from the record
+	if updated.StreamedMoves > 0 {
+		record := updated
+		record.Moves = nil
+		s.moveRows[id] = updated.Moves
+		s.analyses[id] = &record
+		return &updated, nil
+	}
+	s.analyses[id] = &updated
+	return &updated, nil
+}
+
+// SavePreferences stores preferences for the user they belong to
+func (s *MemoryStore) SavePreferences(prefs *models.UserPreferences) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.preferences[prefs.User] = prefs
+}
+
+// GetPreferences retrieves a user's preferences, or nil if none are saved
+func (s *MemoryStore) GetPreferences(user string) *models.UserPreferences {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.preferences[user]
+}
+
+// DeletePreferences removes a user's preferences
+func (s *MemoryStore) DeletePreferences(user string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.preferences, user)
+}
+
+// SaveShareLink stores a share link by token
+func (s *MemoryStore) SaveShareLink(link *models.ShareLink) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.shareLinks[link.Token] = link
+}
+
+// GetShareLink retrieves a share link by token
+func (s *MemoryStore) GetShareLink(token string) (*models.ShareLink, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	link, ok := s.shareLinks[token]
+	if !ok {
+		return nil, errors.NewShareLinkNotFoundError(token)
+	}
+	return link, nil
+}
+
+// DeleteShareLink removes a share link
+func (s *MemoryStore) DeleteShareLink(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.shareLinks, token)
+}
+
+// SaveGames stores a player's games and returns the ones that weren't stored yet
+func (s *MemoryStore) SaveGames(username string, games []*models.GameInfo) []*models.GameInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := strings.ToLower(username)
+	stored, ok := s.playerGames[key]
+	if !ok {
+		stored = make(map[string]*models.GameInfo)
+		s.playerGames[key] = stored
+	}
+
+	var added []*models.GameInfo
+	for _, game := range games {
+		if _, exists := stored[gameKey(game)]; exists {
+			continue
+		}
+		stored[gameKey(game)] = game
+		added = append(added, game)
+	}
+	return added
+}
+
+// ReplaceGame replaces a stored game of a player, matched by URL
+func (s *MemoryStore) ReplaceGame(username string, game *models.GameInfo) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if stored, ok := s.playerGames[strings.ToLower(username)]; ok {
+		if _, exists := stored[gameKey(game)]; exists {
+			stored[gameKey(game)] = game
+		}
+	}
+}
+
+// gameKey identifies a stored game by its URL, or by its source ID for over-the-board games
+// that were never published online
+func gameKey(game *models.GameInfo) string {
+	if game.URL != "" {
+		return game.URL
+	}
+	return game.GameID
+}
+
+// GetGames returns a player's stored games, oldest first
+func (s *MemoryStore) GetGames(username string) []*models.GameInfo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stored := s.playerGames[strings.ToLower(username)]
+	games := make([]*models.GameInfo, 0, len(stored))
+	for _, game := range stored {
+		games = append(games, game)
+	}
+
+	sort.Slice(games, func(i, j int) bool {
+		return games[i].StartTime.Before(games[j].StartTime)
+	})
+	return games
+}
+
+// QueryGames returns a page of a player's stored games, oldest first
+func (s *MemoryStore) QueryGames(username string, request models.PageRequest) (*models.Page[*models.GameInfo], error) {
+	return Paginate(ListSyncedGames, s.GetGames(username), func(game *models.GameInfo) string {
+		return TimeKey(game.StartTime, gameKey(game))
+	}, request)
+}
+
+// FindGame returns a synced game of any player by URL, or by source ID for games without one
+func (s *MemoryStore) FindGame(gameURL string) (*models.GameInfo, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, stored := range s.playerGames {
+		if game, ok := stored[gameURL]; ok {
+			return game, true
+		}
+	}
+	return nil, false
+}
+
+// SaveSyncState stores a copy of a player's sync state
+func (s *MemoryStore) SaveSyncState(state *models.SyncState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.syncStates[strings.ToLower(state.Username)] = copySyncState(state)
+}
+
+// GetSyncState returns a copy of a player's sync state, or nil if the player was never synced
+func (s *MemoryStore) GetSyncState(username string) *models.SyncState {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	state, ok := s.syncStates[strings.ToLower(username)]
+	if !ok {
+		return nil
+	}
+	return copySyncState(state)
+}
+
+// SaveGameCursor stores the end time of the last new game a consumer was given for a player
+func (s *MemoryStore) SaveGameCursor(username, consumer string, cursor int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.gameCursors[gameCursorKey(username, consumer)] = cursor
+}
+
+// GetGameCursor returns the cursor stored for a player and consumer, and whether there is one
+func (s *MemoryStore) GetGameCursor(username, consumer string) (int64, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	cursor, ok := s.gameCursors[gameCursorKey(username, consumer)]
+	return cursor, ok
+}
+
+// gameCursorKey identifies a consumer's cursor for a player
+func gameCursorKey(username, consumer string) string {
+	return strings.ToLower(username) + "\x00" + consumer
+}
+
+// SaveArtifact stores an artifact's metadata
+func (s *MemoryStore) SaveArtifact(artifact *models.Artifact) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.artifacts[artifact.ID] = artifact
+}
+
+// GetArtifact retrieves an artifact's metadata by ID
+func (s *MemoryStore) GetArtifact(id string) (*models.Artifact, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	artifact, ok := s.artifacts[id]
+	if !ok {
+		return nil, errors.NewArtifactNotFoundError(id)
+	}
+	return artifact, nil
+}
+
+// ListArtifacts returns the artifacts of an analysis, oldest first
+func (s *MemoryStore) ListArtifacts(analysisID string) []*models.Artifact {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	artifacts := []*models.Artifact{}
+	for _, artifact := range s.artifacts {
+		if artifact.AnalysisID == analysisID {
+			artifacts = append(artifacts, artifact)
+		}
+	}
+
+	sort.Slice(artifacts, func(i, j int) bool {
+		return artifacts[i].CreatedAt.Before(artifacts[j].CreatedAt)
+	})
+	return artifacts
+}
+
+// DeleteArtifact removes an artifact's metadata
+func (s *MemoryStore) DeleteArtifact(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.artifacts, id)
+}
+
+// SaveAuditEntry appends an entry to the audit log, dropping the oldest entries beyond maxEntries
+// (0 = no limit)
+func (s *MemoryStore) SaveAuditEntry(entry *models.AuditEntry, maxEntries int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.audit = append(s.audit, entry)
+	if maxEntries > 0 && len(s.audit) > maxEntries {
+		s.audit = append([]*models.AuditEntry(nil), s.audit[len(s.audit)-maxEntries:]...)
+	}
+}
+
+// AuditEntries returns the whole audit log, oldest first
+func (s *MemoryStore) AuditEntries() []*models.AuditEntry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return append([]*models.AuditEntry(nil), s.audit...)
+}
+
+// QueryAuditEntries returns a page of the audit log entries matching a query, oldest first
+func (s *MemoryStore) QueryAuditEntries(query models.AuditQuery) (*models.Page[*models.AuditEntry], error) {
+	s.mu.RLock()
+	entries := []*models.AuditEntry{}
+	for _, entry := range s.audit {
+		if auditEntryMatches(entry, query) {
+			entries = append(entries, entry)
+		}
+	}
+	s.mu.RUnlock()
+
+	return Paginate(ListAuditEntries, entries, func(entry *models.AuditEntry) string {
+		return TimeKey(entry.Time, entry.ID)
+	}, query.PageRequest)
+}
+
+// auditEntryMatches reports whether an audit log entry passes a query's filters
+func auditEntryMatches(entry *models.AuditEntry, query models.AuditQuery) bool {
+	switch {
+	case query.KeyFingerprint != "" && entry.KeyFingerprint != query.KeyFingerprint:
+		return false
+	case query.IP != "" && entry.IP != query.IP:
+		return false
+	case query.GameID != "" && entry.GameID != query.GameID:
+		return false
+	case !query.Since.IsZero() && entry.Time.Before(query.Since):
+		return false
+	case !query.Until.IsZero() && !entry.Time.Before(query.Until):
+		return false
+	}
+	return true
+}
+
+// SaveRawArchive stores a raw archive snapshot's metadata and makes it the source of its games
+func (s *MemoryStore) SaveRawArchive(archive *models.RawArchive) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := strings.ToLower(archive.Username)
+	s.rawArchives[key] = append(s.rawArchives[key], archive)
+	for _, url := range archive.GameURLs {
+		s.gameSources[url] = archive
+	}
+}
+
+// ListRawArchives returns a player's raw archive snapshots by month, oldest first
+func (s *MemoryStore) ListRawArchives(username string) []*models.RawArchive {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	archives := append([]*models.RawArchive{}, s.rawArchives[strings.ToLower(username)]...)
+	sort.SliceStable(archives, func(i, j int) bool {
+		if archives[i].Year != archives[j].Year {
+			return archives[i].Year < archives[j].Year
+		}
+		if archives[i].Month != archives[j].Month {
+			return archives[i].Month < archives[j].Month
+		}
+		return archives[i].FetchedAt.Before(archives[j].FetchedAt)
+	})
+	return archives
+}
+
+// GetGameSource returns the latest raw archive snapshot that contained a game, or nil if none did
+func (s *MemoryStore) GetGameSource(gameURL string) *models.RawArchive {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.gameSources[gameURL]
+}
+
+// AllRawArchives returns every player's raw archive snapshots in fetch order
+func (s *MemoryStore) AllRawArchives() []*models.RawArchive {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	archives := []*models.RawArchive{}
+	for _, snapshots := range s.rawArchives {
+		archives = append(archives, snapshots...)
+	}
+	sort.SliceStable(archives, func(i, j int) bool {
+		return archives[i].FetchedAt.Before(archives[j].FetchedAt)
+	})
+	return archives
+}
+
+// PruneRawArchives removes a player's snapshots fetched before a time that are no longer the
+// source of any game, and returns them
+func (s *MemoryStore) PruneRawArchives(username string, before time.Time) []*models.RawArchive {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := strings.ToLower(username)
+	var kept, pruned []*models.RawArchive
+	for _, archive := range s.rawArchives[key] {
+		if archive.FetchedAt.Before(before) && !s.isGameSource(archive) {
+			pruned = append(pruned, archive)
+			continue
+		}
+		kept = append(kept, archive)
+	}
+	s.rawArchives[key] = kept
+	return pruned
+}
+
+// isGameSource reports whether a snapshot is the latest one containing any of its games
+func (s *MemoryStore) isGameSource(archive *models.RawArchive) bool {
+	for _, url := range archive.GameURLs {
+		if s.gameSources[url] == archive {
+			return true
+		}
+	}
+	return false
+}
+
+// copySyncState copies a sync state including its ETag map
+func copySyncState(state *models.SyncState) *models.SyncState {
+	copied := *state
+	copied.ArchiveETags = make(map[string]string, len(state.ArchiveETags))
+	for archive, etag := range state.ArchiveETags {
+		copied.ArchiveETags[archive] = etag
+	}
+	if state.Ratings != nil {
+		copied.Ratings = make(map[string]int, len(state.Ratings))
+		for timeClass, rating := range state.Ratings {
+			copied.Ratings[timeClass] = rating
+		}
+	}
+	return &copied
+}
+
+// NewID generates a random identifier for stored records
+func NewID() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+)
+
+func TestMemoryStore_StreamedMovesRetainedSize(t *testing.T) {
+	store := NewMemoryStore()
+	id := "streamed"
+
+	// The analysis reuses one window buffer, as long games do
+	window := make([]models.MoveAnalysis, 0, 4)
+	for ply := 1; ply <= 10; ply++ {
+		window = append(window, models.MoveAnalysis{MoveNumber: ply})
+		if len(window) == cap(window) || ply == 10 {
+			store.AppendAnalysisMoves(id, window)
+			window = window[:0]
+		}
+	}
+	if windows := store.moveWindows[id]; len(windows) != 3 || cap(windows[2]) != 2 {
+		t.Fatalf("Stored %d windows, want 3 sized to their moves", len(windows))
+	}
+
+	if _, err := store.SaveAnalysis(&models.GameAnalysis{ID: id, StreamedMoves: 10}); err != nil {
+		t.Fatalf("SaveAnalysis() error = %v", err)
+	}
+	if len(store.moveWindows) != 0 {
+		t.Errorf("%d window lists kept after the analysis was saved, want 0", len(store.moveWindows))
+	}
+	if rows := store.moveRows[id]; len(rows) != 10 || cap(rows) != 10 {
+		t.Errorf("Retained %d moves with capacity %d, want 10 and 10", len(rows), cap(rows))
+	}
+
+	analysis, err := store.GetAnalysis(id)
+	if err != nil {
+		t.Fatalf("GetAnalysis() error = %v", err)
+	}
+	for i, move := range analysis.Moves {
+		if move.MoveNumber != i+1 {
+			t.Fatalf("Move %d has number %d, want %d", i, move.MoveNumber, i+1)
+		}
+	}
+
+	// An analysis that never completes leaves nothing behind
+	store.AppendAnalysisMoves("abandoned", []models.MoveAnalysis{{MoveNumber: 1}})
+	store.DeleteAnalysisMoves("abandoned")
+	if len(store.moveWindows) != 0 || len(store.moveRows) != 1 {
+		t.Errorf("Abandoned moves kept: %d window lists, %d saved analyses", len(store.moveWindows), len(store.moveRows))
+	}
+}
@@ -0,0 +1,136 @@
+package storage
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/pkg/errors"
+)
+
+// Page sizes of list queries
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 500
+)
+
+// Lists that issue cursors. A cursor names its list, so one list's cursor can't page another.
+const (
+	ListAnalyses      = "analyses"
+	ListAnalysisJobs  = "analysis_jobs"
+	ListMonthlyGames  = "monthly_games"
+	ListSyncedGames   = "synced_games"
+	ListImportedGames = "imported_games"
+	ListDuePuzzles    = "due_puzzles"
+	ListAuditEntries  = "audit_entries"
+	ListWatchlist     = "watchlist"
+	ListCollections   = "collections"
+	ListRawArchives   = "raw_archives"
+	ListArtifacts     = "artifacts"
+	ListNotifications = "notifications"
+)
+
+// cursor is the content of a page cursor: its list, and the sort key the page it leads to starts
+// after or ends before
+type cursor struct {
+	List   string `json:"l"`
+	After  string `json:"a,omitempty"`
+	Before string `json:"b,omitempty"`
+}
+
+// PageQuery is a validated page request: the page holds up to Limit items with keys after After,
+// or the last Limit items with keys before Before
+type PageQuery struct {
+	After  string
+	Before string
+	Limit  int
+}
+
+// ParsePageRequest validates a page request for a list, decoding its cursor
+func ParsePageRequest(list string, request models.PageRequest) (PageQuery, error) {
+	query := PageQuery{Limit: request.Limit}
+	if query.Limit == 0 {
+		query.Limit = DefaultPageSize
+	}
+	if query.Limit < 0 || query.Limit > MaxPageSize {
+		return query, errors.NewValidationError("limit", fmt.Sprintf("must be between 1 and %d", MaxPageSize))
+	}
+	if request.Cursor == "" {
+		return query, nil
+	}
+
+	var decoded cursor
+	data, err := base64.RawURLEncoding.DecodeString(request.Cursor)
+	if err == nil {
+		err = json.Unmarshal(data, &decoded)
+	}
+	if err != nil || (decoded.After == "") == (decoded.Before == "") {
+		return query, errors.NewValidationError("cursor", "malformed cursor")
+	}
+	if decoded.List != list {
+		return query, errors.NewValidationError("cursor", fmt.Sprintf("cursor of the %s list, not %s", decoded.List, list))
+	}
+	query.After, query.Before = decoded.After, decoded.Before
+	return query, nil
+}
+
+// NextCursor returns the cursor of the page after the item with the given key
+func NextCursor(list, key string) string {
+	return encodeCursor(cursor{List: list, After: key})
+}
+
+// PrevCursor returns the cursor of the page before the item with the given key
+func PrevCursor(list, key string) string {
+	return encodeCursor(cursor{List: list, Before: key})
+}
+
+// encodeCursor makes a cursor opaque to clients
+func encodeCursor(c cursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// Paginate returns the page of items a page request selects. Keys must be unique; items are
+// ordered by key.
+func Paginate[T any](list string, items []T, key func(T) string, request models.PageRequest) (*models.Page[T], error) {
+	query, err := ParsePageRequest(list, request)
+	if err != nil {
+		return nil, err
+	}
+
+	sorted := make([]T, len(items))
+	copy(sorted, items)
+	sort.SliceStable(sorted, func(i, j int) bool { return key(sorted[i]) < key(sorted[j]) })
+
+	start, end := 0, min(query.Limit, len(sorted))
+	switch {
+	case query.After != "":
+		start = sort.Search(len(sorted), func(i int) bool { return key(sorted[i]) > query.After })
+		end = min(start+query.Limit, len(sorted))
+	case query.Before != "":
+		end = sort.Search(len(sorted), func(i int) bool { return key(sorted[i]) >= query.Before })
+		start = max(end-query.Limit, 0)
+	}
+
+	page := &models.Page[T]{Items: sorted[start:end:end], TotalEstimate: len(sorted)}
+	if start < end && end < len(sorted) {
+		page.NextCursor = NextCursor(list, key(sorted[end-1]))
+	}
+	if start < end && start > 0 {
+		page.PrevCursor = PrevCursor(list, key(sorted[start]))
+	}
+	return page, nil
+}
+
+// TimeKey is a sort key ordering items by time, then ID
+func TimeKey(t time.Time, id string) string {
+	return t.UTC().Format("20060102150405.000000000") + "|" + id
+}
+
+// IndexKey is a sort key ordering items by position
+func IndexKey(index int) string {
+	return fmt.Sprintf("%010d", index)
+}
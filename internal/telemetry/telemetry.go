@@ -0,0 +1,43 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// SetupTracing installs a global tracer provider exporting spans over OTLP/HTTP. The collector
+// endpoint and headers come from the standard OTEL_EXPORTER_OTLP_* environment variables.
+// The returned function flushes pending spans and shuts the exporter down.
+func SetupTracing(ctx context.Context, serviceName string, samplePercent int) (func(context.Context) error, error) {
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace exporter: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(attribute.String("service.name", serviceName)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe service: %w", err)
+	}
+
+	if samplePercent < 0 {
+		samplePercent = 0
+	}
+	if samplePercent > 100 {
+		samplePercent = 100
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(float64(samplePercent)/100))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
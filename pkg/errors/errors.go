@@ -1,6 +1,11 @@
 package errors
 
-import "fmt"
+import (
+	stderrors "errors"
+	"fmt"
+	"strings"
+	"time"
+)
 
 // GameNotFoundError represents an error when a game is not found
 type GameNotFoundError struct {
@@ -19,6 +24,133 @@ func (e *GameNotFoundError) Unwrap() error {
 	return e.Err
 }
 
+// AnalysisNotFoundError represents an error when a stored analysis is not found
+type AnalysisNotFoundError struct {
+	AnalysisID string
+}
+
+func (e *AnalysisNotFoundError) Error() string {
+	return fmt.Sprintf("analysis with ID %s not found", e.AnalysisID)
+}
+
+// ShareLinkNotFoundError represents an error when a share link does not exist or has expired
+type ShareLinkNotFoundError struct {
+	Token string
+}
+
+func (e *ShareLinkNotFoundError) Error() string {
+	return fmt.Sprintf("share link %s not found or expired", e.Token)
+}
+
+// WatchNotFoundError represents an error when a game watch does not exist
+type WatchNotFoundError struct {
+	WatchID string
+}
+
+func (e *WatchNotFoundError) Error() string {
+	return fmt.Sprintf("watch with ID %s not found", e.WatchID)
+}
+
+// BroadcastNotFoundError represents an error when a followed broadcast does not exist
+type BroadcastNotFoundError struct {
+	BroadcastID string
+}
+
+func (e *BroadcastNotFoundError) Error() string {
+	return fmt.Sprintf("broadcast with ID %s not found", e.BroadcastID)
+}
+
+// AnalysisJobNotFoundError represents an error when a background analysis job does not exist
+type AnalysisJobNotFoundError struct {
+	JobID string
+}
+
+func (e *AnalysisJobNotFoundError) Error() string {
+	return fmt.Sprintf("analysis job with ID %s not found", e.JobID)
+}
+
+// TeamPlanNotFoundError represents an error when a team match plan job does not exist
+type TeamPlanNotFoundError struct {
+	PlanID string
+}
+
+func (e *TeamPlanNotFoundError) Error() string {
+	return fmt.Sprintf("match plan with ID %s not found", e.PlanID)
+}
+
+// IngestNotFoundError represents an error when a game ingest job does not exist
+type IngestNotFoundError struct {
+	IngestID string
+}
+
+func (e *IngestNotFoundError) Error() string {
+	return fmt.Sprintf("ingest with ID %s not found", e.IngestID)
+}
+
+// ImportNotFoundError represents an error when a PGN import does not exist
+type ImportNotFoundError struct {
+	ImportID string
+}
+
+func (e *ImportNotFoundError) Error() string {
+	return fmt.Sprintf("import with ID %s not found", e.ImportID)
+}
+
+// WatchlistEntryNotFoundError represents an error when a watchlist entry does not exist
+type WatchlistEntryNotFoundError struct {
+	EntryID string
+}
+
+func (e *WatchlistEntryNotFoundError) Error() string {
+	return fmt.Sprintf("watchlist entry with ID %s not found", e.EntryID)
+}
+
+// ArtifactNotFoundError represents an error when a stored artifact does not exist
+type ArtifactNotFoundError struct {
+	ArtifactID string
+}
+
+func (e *ArtifactNotFoundError) Error() string {
+	return fmt.Sprintf("artifact with ID %s not found", e.ArtifactID)
+}
+
+// PlaySessionNotFoundError represents an error when a game against the engine does not exist
+type PlaySessionNotFoundError struct {
+	SessionID string
+}
+
+func (e *PlaySessionNotFoundError) Error() string {
+	return fmt.Sprintf("play session with ID %s not found", e.SessionID)
+}
+
+// PuzzleNotFoundError represents an error when a training puzzle does not exist
+type PuzzleNotFoundError struct {
+	PuzzleID string
+}
+
+func (e *PuzzleNotFoundError) Error() string {
+	return fmt.Sprintf("puzzle with ID %s not found", e.PuzzleID)
+}
+
+// CollectionNotFoundError represents an error when a game collection does not exist
+type CollectionNotFoundError struct {
+	CollectionID string
+}
+
+func (e *CollectionNotFoundError) Error() string {
+	return fmt.Sprintf("collection with ID %s not found", e.CollectionID)
+}
+
+// UploadOffsetError represents a chunk that doesn't start where the upload left off
+type UploadOffsetError struct {
+	Expected int64
+	Got      int64
+}
+
+func (e *UploadOffsetError) Error() string {
+	return fmt.Sprintf("upload offset mismatch: expected %d, got %d", e.Expected, e.Got)
+}
+
 // APIError represents an error with the Chess.com API
 type APIError struct {
 	Message string
@@ -36,6 +168,118 @@ func (e *APIError) Unwrap() error {
 	return e.Err
 }
 
+// RateLimitedError represents a request rejected because a rate limit was hit,
+// either by the Chess.com API or by this service
+type RateLimitedError struct {
+	RetryAfter time.Duration // How long to wait before retrying (0 if unknown)
+	Err        error
+}
+
+func (e *RateLimitedError) Error() string {
+	msg := "rate limited"
+	if e.RetryAfter > 0 {
+		msg = fmt.Sprintf("rate limited, retry after %s", e.RetryAfter)
+	}
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", msg, e.Err)
+	}
+	return msg
+}
+
+func (e *RateLimitedError) Unwrap() error {
+	return e.Err
+}
+
+// CapacityError represents a request refused because a limit on what the service runs or keeps at
+// once was reached. It clears once something finishes or is deleted, so it isn't the request's fault.
+type CapacityError struct {
+	Resource string // What the limit counts, e.g. "games played at once"
+	Limit    int
+	Stored   bool // Whether the limit is on stored data rather than running work
+}
+
+func (e *CapacityError) Error() string {
+	return fmt.Sprintf("limit reached: at most %d %s", e.Limit, e.Resource)
+}
+
+// EngineUnavailableError represents an engine or engine pool that can't serve requests
+type EngineUnavailableError struct {
+	Engine string
+	Err    error
+}
+
+func (e *EngineUnavailableError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("engine %s is unavailable: %v", e.Engine, e.Err)
+	}
+	return fmt.Sprintf("engine %s is unavailable", e.Engine)
+}
+
+func (e *EngineUnavailableError) Unwrap() error {
+	return e.Err
+}
+
+// TimeoutError represents an operation that didn't complete in time
+type TimeoutError struct {
+	Operation string
+	Err       error
+}
+
+func (e *TimeoutError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s timed out: %v", e.Operation, e.Err)
+	}
+	return fmt.Sprintf("%s timed out", e.Operation)
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// UnsupportedVariantError represents a chess variant no configured engine can analyze
+type UnsupportedVariantError struct {
+	Variant string
+}
+
+func (e *UnsupportedVariantError) Error() string {
+	return fmt.Sprintf("variant %s is not supported by any configured engine pool", e.Variant)
+}
+
+// UnanalyzableGameError represents a game that can't be analyzed, e.g. one aborted before any move
+type UnanalyzableGameError struct {
+	URL    string
+	Reason string
+	Err    error
+}
+
+func (e *UnanalyzableGameError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("game %s can't be analyzed (%s): %v", e.URL, e.Reason, e.Err)
+	}
+	return fmt.Sprintf("game %s can't be analyzed (%s)", e.URL, e.Reason)
+}
+
+func (e *UnanalyzableGameError) Unwrap() error {
+	return e.Err
+}
+
+// StorageError represents a failure to read or write stored data
+type StorageError struct {
+	Operation string
+	Err       error
+}
+
+func (e *StorageError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("storage error: failed to %s: %v", e.Operation, e.Err)
+	}
+	return fmt.Sprintf("storage error: failed to %s", e.Operation)
+}
+
+func (e *StorageError) Unwrap() error {
+	return e.Err
+}
+
 // ValidationError represents a validation error
 type ValidationError struct {
 	Field   string
@@ -46,6 +290,19 @@ func (e *ValidationError) Error() string {
 	return fmt.Sprintf("validation error for field %s: %s", e.Field, e.Message)
 }
 
+// ValidationErrors represents the validation errors of every invalid field of a request
+type ValidationErrors struct {
+	Errors []*ValidationError
+}
+
+func (e *ValidationErrors) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		messages[i] = fmt.Sprintf("%s: %s", err.Field, err.Message)
+	}
+	return "validation failed: " + strings.Join(messages, "; ")
+}
+
 // NewGameNotFoundError creates a new GameNotFoundError
 func NewGameNotFoundError(gameID string, err error) *GameNotFoundError {
 	return &GameNotFoundError{
@@ -54,6 +311,105 @@ func NewGameNotFoundError(gameID string, err error) *GameNotFoundError {
 	}
 }
 
+// NewAnalysisNotFoundError creates a new AnalysisNotFoundError
+func NewAnalysisNotFoundError(analysisID string) *AnalysisNotFoundError {
+	return &AnalysisNotFoundError{
+		AnalysisID: analysisID,
+	}
+}
+
+// NewShareLinkNotFoundError creates a new ShareLinkNotFoundError
+func NewShareLinkNotFoundError(token string) *ShareLinkNotFoundError {
+	return &ShareLinkNotFoundError{
+		Token: token,
+	}
+}
+
+// NewWatchNotFoundError creates a new WatchNotFoundError
+func NewWatchNotFoundError(watchID string) *WatchNotFoundError {
+	return &WatchNotFoundError{
+		WatchID: watchID,
+	}
+}
+
+// NewBroadcastNotFoundError creates a new BroadcastNotFoundError
+func NewBroadcastNotFoundError(broadcastID string) *BroadcastNotFoundError {
+	return &BroadcastNotFoundError{
+		BroadcastID: broadcastID,
+	}
+}
+
+// NewAnalysisJobNotFoundError creates a new AnalysisJobNotFoundError
+func NewAnalysisJobNotFoundError(jobID string) *AnalysisJobNotFoundError {
+	return &AnalysisJobNotFoundError{
+		JobID: jobID,
+	}
+}
+
+// NewTeamPlanNotFoundError creates a new TeamPlanNotFoundError
+func NewTeamPlanNotFoundError(planID string) *TeamPlanNotFoundError {
+	return &TeamPlanNotFoundError{
+		PlanID: planID,
+	}
+}
+
+// NewIngestNotFoundError creates a new IngestNotFoundError
+func NewIngestNotFoundError(ingestID string) *IngestNotFoundError {
+	return &IngestNotFoundError{
+		IngestID: ingestID,
+	}
+}
+
+// NewImportNotFoundError creates a new ImportNotFoundError
+func NewImportNotFoundError(importID string) *ImportNotFoundError {
+	return &ImportNotFoundError{
+		ImportID: importID,
+	}
+}
+
+// NewWatchlistEntryNotFoundError creates a new WatchlistEntryNotFoundError
+func NewWatchlistEntryNotFoundError(entryID string) *WatchlistEntryNotFoundError {
+	return &WatchlistEntryNotFoundError{
+		EntryID: entryID,
+	}
+}
+
+// NewArtifactNotFoundError creates a new ArtifactNotFoundError
+func NewArtifactNotFoundError(artifactID string) *ArtifactNotFoundError {
+	return &ArtifactNotFoundError{
+		ArtifactID: artifactID,
+	}
+}
+
+// NewPlaySessionNotFoundError creates a new PlaySessionNotFoundError
+func NewPlaySessionNotFoundError(sessionID string) *PlaySessionNotFoundError {
+	return &PlaySessionNotFoundError{
+		SessionID: sessionID,
+	}
+}
+
+// NewPuzzleNotFoundError creates a new PuzzleNotFoundError
+func NewPuzzleNotFoundError(puzzleID string) *PuzzleNotFoundError {
+	return &PuzzleNotFoundError{
+		PuzzleID: puzzleID,
+	}
+}
+
+// NewCollectionNotFoundError creates a new CollectionNotFoundError
+func NewCollectionNotFoundError(collectionID string) *CollectionNotFoundError {
+	return &CollectionNotFoundError{
+		CollectionID: collectionID,
+	}
+}
+
+// NewUploadOffsetError creates a new UploadOffsetError
+func NewUploadOffsetError(expected, got int64) *UploadOffsetError {
+	return &UploadOffsetError{
+		Expected: expected,
+		Got:      got,
+	}
+}
+
 // NewAPIError creates a new APIError
 func NewAPIError(message string, err error) *APIError {
 	return &APIError{
@@ -62,6 +418,71 @@ func NewAPIError(message string, err error) *APIError {
 	}
 }
 
+// NewRateLimitedError creates a new RateLimitedError
+func NewRateLimitedError(retryAfter time.Duration, err error) *RateLimitedError {
+	return &RateLimitedError{
+		RetryAfter: retryAfter,
+		Err:        err,
+	}
+}
+
+// NewCapacityError creates a CapacityError for a limit on running work
+func NewCapacityError(resource string, limit int) *CapacityError {
+	return &CapacityError{
+		Resource: resource,
+		Limit:    limit,
+	}
+}
+
+// NewStorageLimitError creates a CapacityError for a limit on stored data
+func NewStorageLimitError(resource string, limit int) *CapacityError {
+	return &CapacityError{
+		Resource: resource,
+		Limit:    limit,
+		Stored:   true,
+	}
+}
+
+// NewEngineUnavailableError creates a new EngineUnavailableError
+func NewEngineUnavailableError(engine string, err error) *EngineUnavailableError {
+	return &EngineUnavailableError{
+		Engine: engine,
+		Err:    err,
+	}
+}
+
+// NewTimeoutError creates a new TimeoutError
+func NewTimeoutError(operation string, err error) *TimeoutError {
+	return &TimeoutError{
+		Operation: operation,
+		Err:       err,
+	}
+}
+
+// NewUnsupportedVariantError creates a new UnsupportedVariantError
+func NewUnsupportedVariantError(variant string) *UnsupportedVariantError {
+	return &UnsupportedVariantError{
+		Variant: variant,
+	}
+}
+
+// NewUnanalyzableGameError creates a new UnanalyzableGameError
+func NewUnanalyzableGameError(url, reason string, err error) *UnanalyzableGameError {
+	return &UnanalyzableGameError{
+		URL:    url,
+		Reason: reason,
+		Err:    err,
+	}
+}
+
+// NewStorageError creates a new StorageError
+func NewStorageError(operation string, err error) *StorageError {
+	return &StorageError{
+		Operation: operation,
+		Err:       err,
+	}
+}
+
 // NewValidationError creates a new ValidationError
 func NewValidationError(field, message string) *ValidationError {
 	return &ValidationError{
@@ -69,3 +490,20 @@ func NewValidationError(field, message string) *ValidationError {
 		Message: message,
 	}
 }
+
+// NewValidationErrors creates a new ValidationErrors
+func NewValidationErrors(errs []*ValidationError) *ValidationErrors {
+	return &ValidationErrors{
+		Errors: errs,
+	}
+}
+
+// Is reports whether any error in err's chain matches target, like the standard library's errors.Is
+func Is(err, target error) bool {
+	return stderrors.Is(err, target)
+}
+
+// As finds the first error in err's chain that matches target, like the standard library's errors.As
+func As(err error, target interface{}) bool {
+	return stderrors.As(err, target)
+}
@@ -46,3 +46,28 @@ func TestValidationError(t *testing.T) {
 		t.Errorf("Error() = %v, want %v", err.Error(), expectedMsg)
 	}
 }
+
+func TestValidationErrors(t *testing.T) {
+	err := NewValidationErrors([]*ValidationError{
+		NewValidationError("depth", "too deep"),
+		NewValidationError("fen", "is required"),
+	})
+
+	expectedMsg := "validation failed: depth: too deep; fen: is required"
+	if err.Error() != expectedMsg {
+		t.Errorf("Error() = %v, want %v", err.Error(), expectedMsg)
+	}
+}
+
+func TestAnalysisNotFoundError(t *testing.T) {
+	err := NewAnalysisNotFoundError("abc123")
+
+	if err.AnalysisID != "abc123" {
+		t.Errorf("AnalysisID = %v, want abc123", err.AnalysisID)
+	}
+
+	expectedMsg := "analysis with ID abc123 not found"
+	if err.Error() != expectedMsg {
+		t.Errorf("Error() = %v, want %v", err.Error(), expectedMsg)
+	}
+}
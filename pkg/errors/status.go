@@ -0,0 +1,75 @@
+package errors
+
+import (
+	"context"
+	"net/http"
+)
+
+// HTTPStatus maps an error to the HTTP status it should be reported with. Errors are matched
+// anywhere in the chain, and transient conditions win over the error that wraps them, so a rate
+// limit hit while looking up a game is reported as 429 rather than 404.
+func HTTPStatus(err error) int {
+	var (
+		rateLimited  *RateLimitedError
+		capacity     *CapacityError
+		timeout      *TimeoutError
+		unavailable  *EngineUnavailableError
+		storage      *StorageError
+		validation   *ValidationError
+		validations  *ValidationErrors
+		variant      *UnsupportedVariantError
+		uploadOffset *UploadOffsetError
+		unanalyzable *UnanalyzableGameError
+	)
+
+	switch {
+	case err == nil:
+		return http.StatusOK
+	case As(err, &rateLimited):
+		return http.StatusTooManyRequests
+	case As(err, &capacity):
+		if capacity.Stored {
+			return http.StatusInsufficientStorage
+		}
+		return http.StatusTooManyRequests
+	case As(err, &timeout), Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	case As(err, &unavailable):
+		return http.StatusServiceUnavailable
+	case As(err, &storage):
+		return http.StatusInternalServerError
+	case As(err, &validation), As(err, &validations), As(err, &variant):
+		return http.StatusBadRequest
+	case isNotFound(err):
+		return http.StatusNotFound
+	case As(err, &uploadOffset):
+		return http.StatusConflict
+	case As(err, &unanalyzable):
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusInternalServerError
+}
+
+// isNotFound reports whether err is one of the not-found errors
+func isNotFound(err error) bool {
+	var (
+		game     *GameNotFoundError
+		analysis *AnalysisNotFoundError
+		share    *ShareLinkNotFoundError
+		watch    *WatchNotFoundError
+		relay    *BroadcastNotFoundError
+		job      *AnalysisJobNotFoundError
+		plan     *TeamPlanNotFoundError
+		ingest   *IngestNotFoundError
+		imp      *ImportNotFoundError
+		entry    *WatchlistEntryNotFoundError
+		artifact *ArtifactNotFoundError
+		play     *PlaySessionNotFoundError
+		puzzle   *PuzzleNotFoundError
+		coll     *CollectionNotFoundError
+	)
+	return As(err, &game) || As(err, &analysis) || As(err, &share) || As(err, &watch) || As(err, &imp) ||
+		As(err, &entry) || As(err, &artifact) || As(err, &play) || As(err, &puzzle) ||
+		As(err, &coll) || As(err, &relay) || As(err, &job) || As(err, &plan) ||
+		As(err, &ingest)
+}
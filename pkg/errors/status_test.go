@@ -0,0 +1,60 @@
+package errors
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestHTTPStatus(t *testing.T) {
+	rateLimited := NewRateLimitedError(30*time.Second, fmt.Errorf("API request failed with status: 429"))
+
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"validation", NewValidationError("pgn", "invalid"), http.StatusBadRequest},
+		{"field validation", NewValidationErrors([]*ValidationError{NewValidationError("depth", "invalid")}), http.StatusBadRequest},
+		{"unsupported variant", NewUnsupportedVariantError("horde"), http.StatusBadRequest},
+		{"unanalyzable game", NewUnanalyzableGameError("https://www.chess.com/game/live/1", "no_moves", nil), http.StatusUnprocessableEntity},
+		{"not found", NewAnalysisNotFoundError("abc"), http.StatusNotFound},
+		{"collection not found", NewCollectionNotFoundError("abc"), http.StatusNotFound},
+		{"wrapped rate limit", NewAPIError("failed to retrieve games", rateLimited), http.StatusTooManyRequests},
+		{"rate limit inside not found", NewGameNotFoundError("123", rateLimited), http.StatusTooManyRequests},
+		{"capacity", NewCapacityError("games played at once", 200), http.StatusTooManyRequests},
+		{"storage limit", NewStorageLimitError("puzzles stored", 1000), http.StatusInsufficientStorage},
+		{"timeout", NewTimeoutError("analysis", nil), http.StatusGatewayTimeout},
+		{"deadline", fmt.Errorf("analysis: %w", context.DeadlineExceeded), http.StatusGatewayTimeout},
+		{"engine unavailable", NewEngineUnavailableError("fairy", fmt.Errorf("not found")), http.StatusServiceUnavailable},
+		{"storage", NewStorageError("store analysis", fmt.Errorf("disk full")), http.StatusInternalServerError},
+		{"upload offset", NewUploadOffsetError(10, 0), http.StatusConflict},
+		{"unknown", fmt.Errorf("boom"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		if got := HTTPStatus(tt.err); got != tt.want {
+			t.Errorf("%s: HTTPStatus() = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAsUnwrapsChain(t *testing.T) {
+	cause := fmt.Errorf("disk full")
+	err := NewAPIError("analysis failed", NewStorageError("store analysis", cause))
+
+	var storage *StorageError
+	if !As(err, &storage) || storage.Operation != "store analysis" {
+		t.Errorf("As() did not find the storage error in %v", err)
+	}
+	if !Is(err, cause) {
+		t.Errorf("Is() did not find the cause in %v", err)
+	}
+
+	expectedMsg := "rate limited, retry after 30s"
+	if msg := NewRateLimitedError(30*time.Second, nil).Error(); msg != expectedMsg {
+		t.Errorf("Error() = %v, want %v", msg, expectedMsg)
+	}
+}
@@ -0,0 +1,204 @@
+package position
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/board"
+	"github.com/pedrampdd/ChessAnalyser/internal/parser"
+)
+
+// Board types shared with the analyzer
+type (
+	Color     = board.Color
+	PieceType = board.PieceType
+	Piece     = board.Piece
+	Square    = board.Square
+	Move      = board.Move
+)
+
+// Piece colors
+const (
+	White = board.White
+	Black = board.Black
+)
+
+// Piece types
+const (
+	NoPieceType = board.NoPieceType
+	Pawn        = board.Pawn
+	Knight      = board.Knight
+	Bishop      = board.Bishop
+	Rook        = board.Rook
+	Queen       = board.Queen
+	King        = board.King
+)
+
+// StartFEN is the standard starting position
+const StartFEN = board.StartFEN
+
+// pieceValues are the conventional piece values in pawns, indexed by piece type
+var pieceValues = [...]int{0, 1, 3, 3, 5, 9, 0}
+
+// Position is a chess position that moves can be played on. The zero value is not usable;
+// create positions with New or FromFEN.
+type Position struct {
+	board board.Board
+}
+
+// Material is the material each side has on the board, in pawns
+type Material struct {
+	White int `json:"white"`
+	Black int `json:"black"`
+}
+
+// Balance returns White's material advantage in pawns
+func (m Material) Balance() int {
+	return m.White - m.Black
+}
+
+// New returns the standard starting position
+func New() *Position {
+	return &Position{board: *board.NewBoard()}
+}
+
+// FromFEN parses a position from FEN, rejecting positions that can't arise in a game
+func FromFEN(fen string) (*Position, error) {
+	b, err := board.FromFEN(fen)
+	if err != nil {
+		return nil, err
+	}
+	if err := b.Validate(); err != nil {
+		return nil, err
+	}
+	return &Position{board: *b}, nil
+}
+
+// ParseSquare parses a square name such as "e4"
+func ParseSquare(name string) (Square, error) {
+	return board.ParseSquare(name)
+}
+
+// Copy returns an independent copy of the position
+func (p *Position) Copy() *Position {
+	c := *p
+	return &c
+}
+
+// FEN returns the position in FEN
+func (p *Position) FEN() string {
+	return p.board.FEN()
+}
+
+// Turn returns the side to move
+func (p *Position) Turn() Color {
+	return p.board.Turn()
+}
+
+// PieceAt returns the piece on a square; the piece is empty for an empty square
+func (p *Position) PieceAt(s Square) Piece {
+	return p.board.PieceAt(s)
+}
+
+// LegalMoves returns every legal move of the side to move
+func (p *Position) LegalMoves() []Move {
+	return p.board.LegalMoves()
+}
+
+// ParseMove parses a legal move in SAN ("Nf3") or UCI ("g1f3") notation without playing it
+func (p *Position) ParseMove(move string) (Move, error) {
+	move = strings.TrimSpace(move)
+	if m, err := p.board.ParseSAN(move); err == nil {
+		return m, nil
+	}
+	if m, err := p.board.ParseUCI(move); err == nil {
+		return m, nil
+	}
+	return Move{}, fmt.Errorf("illegal move %q", move)
+}
+
+// MakeMove plays a move in SAN or UCI notation and returns it
+func (p *Position) MakeMove(move string) (Move, error) {
+	m, err := p.ParseMove(move)
+	if err != nil {
+		return Move{}, err
+	}
+	p.board.Apply(m)
+	return m, nil
+}
+
+// SAN returns a legal move of this position in SAN, with check and mate suffixes
+func (p *Position) SAN(m Move) string {
+	return p.board.SAN(m)
+}
+
+// IsCheck reports whether the side to move is in check
+func (p *Position) IsCheck() bool {
+	return p.board.InCheck()
+}
+
+// IsCheckmate reports whether the side to move is checkmated
+func (p *Position) IsCheckmate() bool {
+	return p.board.InCheck() && len(p.board.LegalMoves()) == 0
+}
+
+// IsStalemate reports whether the side to move has no legal move but isn't in check
+func (p *Position) IsStalemate() bool {
+	return !p.board.InCheck() && len(p.board.LegalMoves()) == 0
+}
+
+// IsInsufficientMaterial reports whether neither side can possibly checkmate
+func (p *Position) IsInsufficientMaterial() bool {
+	return p.board.InsufficientMaterial()
+}
+
+// Material counts both sides' pieces with the conventional values: pawn 1, knight and bishop 3,
+// rook 5, queen 9
+func (p *Position) Material() Material {
+	var material Material
+	for s := Square(0); s < 64; s++ {
+		piece := p.board.PieceAt(s)
+		if piece.Color == White {
+			material.White += pieceValues[piece.Type]
+		} else {
+			material.Black += pieceValues[piece.Type]
+		}
+	}
+	return material
+}
+
+// Ply is one move of a game with the positions before and after it
+type Ply struct {
+	Number int    // 1 for White's first move
+	SAN    string // As written in the PGN
+	Move   Move
+	Before *Position
+	After  *Position
+}
+
+// ParseGame replays the main line of a PGN, from its FEN tag when it has one, and returns
+// every ply in order
+func ParseGame(pgn string) ([]Ply, error) {
+	game, err := parser.NewPGNParser().ParsePGN(pgn)
+	if err != nil {
+		return nil, err
+	}
+
+	p := New()
+	if fen := game.Headers["fen"]; fen != "" {
+		if p, err = FromFEN(fen); err != nil {
+			return nil, fmt.Errorf("invalid FEN tag: %w", err)
+		}
+	}
+
+	plies := make([]Ply, 0, len(game.Moves))
+	for i, move := range game.Moves {
+		before := p.Copy()
+		m, err := p.MakeMove(move.Move)
+		if err != nil {
+			return nil, fmt.Errorf("ply %d (%s): %w", i+1, move.Move, err)
+		}
+		plies = append(plies, Ply{Number: i + 1, SAN: move.Move, Move: m, Before: before, After: p.Copy()})
+	}
+	return plies, nil
+}
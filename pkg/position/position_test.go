@@ -0,0 +1,128 @@
+package position
+
+import "testing"
+
+func TestPosition_MakeMove(t *testing.T) {
+	p := New()
+	for _, move := range []string{"e4", "e7e5", "Nf3"} {
+		if _, err := p.MakeMove(move); err != nil {
+			t.Fatalf("MakeMove(%q) error = %v", move, err)
+		}
+	}
+
+	want := "rnbqkbnr/pppp1ppp/8/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R b KQkq - 1 2"
+	if p.FEN() != want {
+		t.Errorf("FEN() = %q, want %q", p.FEN(), want)
+	}
+	if p.Turn() != Black {
+		t.Errorf("Turn() = %v, want black", p.Turn())
+	}
+	if _, err := p.MakeMove("Nf3"); err == nil {
+		t.Error("Expected an illegal move to be rejected")
+	}
+	if len(New().LegalMoves()) != 20 {
+		t.Errorf("Expected 20 legal moves in the starting position")
+	}
+}
+
+func TestPosition_CheckAndMate(t *testing.T) {
+	p := New()
+	for _, move := range []string{"f3", "e5", "g4"} {
+		if _, err := p.MakeMove(move); err != nil {
+			t.Fatalf("MakeMove(%q) error = %v", move, err)
+		}
+	}
+	if p.IsCheck() || p.IsCheckmate() {
+		t.Error("Expected no check before Qh4")
+	}
+
+	m, err := p.MakeMove("d8h4")
+	if err != nil {
+		t.Fatalf("MakeMove() error = %v", err)
+	}
+	if m.From.String() != "d8" || m.To.String() != "h4" {
+		t.Errorf("MakeMove() = %s, want d8h4", m.UCI())
+	}
+	if !p.IsCheck() || !p.IsCheckmate() || p.IsStalemate() {
+		t.Error("Expected fool's mate")
+	}
+
+	stalemate, err := FromFEN("7k/5Q2/6K1/8/8/8/8/8 b - - 0 1")
+	if err != nil {
+		t.Fatalf("FromFEN() error = %v", err)
+	}
+	if !stalemate.IsStalemate() || stalemate.IsCheckmate() {
+		t.Error("Expected stalemate")
+	}
+}
+
+func TestPosition_Material(t *testing.T) {
+	if m := New().Material(); m.White != 39 || m.Black != 39 || m.Balance() != 0 {
+		t.Errorf("Material() = %+v, want 39 each", m)
+	}
+
+	p, err := FromFEN("4k3/8/8/8/8/8/4P3/R3K2R w KQ - 0 1")
+	if err != nil {
+		t.Fatalf("FromFEN() error = %v", err)
+	}
+	if m := p.Material(); m.White != 11 || m.Black != 0 || m.Balance() != 11 {
+		t.Errorf("Material() = %+v, want 11 to 0", m)
+	}
+
+	if _, err := FromFEN("8/8/8/8/8/8/8/8 w - - 0 1"); err == nil {
+		t.Error("Expected a position without kings to be rejected")
+	}
+}
+
+func TestParseGame(t *testing.T) {
+	pgn := `[Event "Test"]
+[White "A"]
+[Black "B"]
+[Result "1-0"]
+
+1. e4 e5 2. Bc4 Nc6 3. Qh5 Nf6 4. Qxf7# 1-0`
+
+	plies, err := ParseGame(pgn)
+	if err != nil {
+		t.Fatalf("ParseGame() error = %v", err)
+	}
+	if len(plies) != 7 {
+		t.Fatalf("Expected 7 plies, got %d", len(plies))
+	}
+
+	last := plies[6]
+	if last.Number != 7 || last.SAN != "Qxf7#" || !last.Move.IsCapture() {
+		t.Errorf("Unexpected last ply %+v", last)
+	}
+	if !last.After.IsCheckmate() || last.Before.IsCheckmate() {
+		t.Error("Expected the last ply to deliver mate")
+	}
+	if last.Before.Material().Black != 39 || last.After.Material().Black != 38 {
+		t.Errorf("Expected the capture to cost Black a pawn")
+	}
+	if plies[0].Before.FEN() != StartFEN {
+		t.Errorf("Expected the game to start from the initial position")
+	}
+	if plies[1].Before.FEN() != plies[0].After.FEN() {
+		t.Error("Expected each ply to start where the previous one ended")
+	}
+}
+
+func TestParseGame_FromFEN(t *testing.T) {
+	pgn := `[FEN "4k3/8/4K3/8/8/8/8/R7 w - - 0 1"]
+[SetUp "1"]
+
+1. Ra8# *`
+
+	plies, err := ParseGame(pgn)
+	if err != nil {
+		t.Fatalf("ParseGame() error = %v", err)
+	}
+	if len(plies) != 1 || !plies[0].After.IsCheckmate() {
+		t.Errorf("Expected a single mating ply, got %+v", plies)
+	}
+
+	if _, err := ParseGame("1. e4 e5 2. Ke3 Ke6 3. Qh8 *"); err == nil {
+		t.Error("Expected an illegal move to fail the game")
+	}
+}
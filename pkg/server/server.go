@@ -0,0 +1,462 @@
+// Package server embeds the Chess Analyzer API in other programs. It builds the same services as
+// the server binary from a configuration and serves them with a gin engine that accepts extra
+// middleware, routes and services.
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/api"
+	"github.com/pedrampdd/ChessAnalyser/internal/blob"
+	"github.com/pedrampdd/ChessAnalyser/internal/client"
+	"github.com/pedrampdd/ChessAnalyser/internal/config"
+	"github.com/pedrampdd/ChessAnalyser/internal/engine"
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+	"github.com/pedrampdd/ChessAnalyser/internal/notify"
+	"github.com/pedrampdd/ChessAnalyser/internal/service"
+	"github.com/pedrampdd/ChessAnalyser/internal/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Server building blocks shared with the API
+type (
+	Server            = api.Server
+	Option            = api.Option
+	Services          = api.Services
+	RouterOptions     = api.RouterOptions
+	CompressionConfig = api.CompressionConfig
+	Config            = config.Config
+)
+
+// LoadConfig reads the configuration from environment variables, with defaults
+func LoadConfig() *Config {
+	return config.LoadConfig()
+}
+
+// NewServer creates a server configured by the given options
+func NewServer(opts ...Option) *Server {
+	return api.NewServer(opts...)
+}
+
+// WithServices sets the services behind the built-in routes
+func WithServices(services Services) Option {
+	return api.WithServices(services)
+}
+
+// WithRouterOptions sets how responses are compressed and streamed
+func WithRouterOptions(options RouterOptions) Option {
+	return api.WithRouterOptions(options)
+}
+
+// WithMiddleware adds middleware run on every request after the built-in middleware
+func WithMiddleware(middleware ...gin.HandlerFunc) Option {
+	return api.WithMiddleware(middleware...)
+}
+
+// WithRoutes registers extra routes on the /api group
+func WithRoutes(register func(api *gin.RouterGroup)) Option {
+	return api.WithRoutes(register)
+}
+
+// WithService makes a custom service available to every handler under name
+func WithService(name string, svc any) Option {
+	return api.WithService(name, svc)
+}
+
+// GetService returns the custom service registered under name, or false if there is none or it
+// isn't a T
+func GetService[T any](c *gin.Context, name string) (T, bool) {
+	return api.GetService[T](c, name)
+}
+
+// RouterOptionsFromConfig returns the response delivery options of a configuration
+func RouterOptionsFromConfig(cfg *Config) RouterOptions {
+	return RouterOptions{
+		Compression: CompressionConfig{
+			Enabled:   cfg.Server.Compression,
+			Encodings: cfg.Server.CompressionEncodings,
+			Level:     cfg.Server.CompressionLevel,
+			MinSize:   cfg.Server.CompressionMinSize,
+		},
+		StreamThreshold: cfg.Server.StreamThreshold,
+		LegacySunset:    cfg.Server.LegacyAPISunset,
+		AdminKey:        cfg.Server.AdminAPIKey,
+		TrustedProxies:  cfg.Server.TrustedProxies,
+		PublicURL:       cfg.Server.PublicURL,
+	}
+}
+
+// NewServices creates the built-in services from a configuration and starts their background
+// work (archive sync and scheduled watchlist reports). The returned function stops the background
+// work and releases the engines; call it once the server is done.
+func NewServices(cfg *Config) (Services, func(), error) {
+	var closers []func()
+	closeAll := func() {
+		for i := len(closers) - 1; i >= 0; i-- {
+			closers[i]()
+		}
+	}
+	fail := func(err error) (Services, func(), error) {
+		closeAll()
+		return Services{}, nil, err
+	}
+
+	// Initialize the game analyzer service
+	gameService := service.NewGameAnalyzerService()
+
+	// Reach Chess.com through the configured proxy, CA bundle and connection pool
+	chessAPI, err := client.NewChessComAPIWithOptions(client.ClientOptions{
+		BaseURL:             cfg.ChessAPI.BaseURL,
+		UserAgent:           cfg.ChessAPI.UserAgent,
+		Timeout:             time.Duration(cfg.ChessAPI.Timeout) * time.Second,
+		ProxyURL:            cfg.ChessAPI.ProxyURL,
+		CABundle:            cfg.ChessAPI.CABundle,
+		MaxIdleConns:        cfg.ChessAPI.MaxIdleConns,
+		MaxIdleConnsPerHost: cfg.ChessAPI.MaxIdleConnsPerHost,
+		MaxConnsPerHost:     cfg.ChessAPI.MaxConnsPerHost,
+		IdleConnTimeout:     time.Duration(cfg.ChessAPI.IdleConnTimeout) * time.Second,
+	})
+	if err != nil {
+		return fail(fmt.Errorf("invalid Chess.com client configuration: %w", err))
+	}
+	gameService.SetChessAPI(chessAPI)
+	gameService.SetGameCacheOptions(cfg.ChessAPI.GameCacheSize, time.Duration(cfg.ChessAPI.GameCacheTTL)*time.Minute)
+	gameService.SetArchiveFetchOptions(service.ArchiveFetchOptions{
+		Workers:           cfg.ChessAPI.ArchiveWorkers,
+		RequestsPerSecond: cfg.ChessAPI.RequestsPerSecond,
+		WalkTimeout:       time.Duration(cfg.ChessAPI.ArchiveWalkTimeout) * time.Second,
+	})
+
+	// Size the default engine pool for the host when auto-tuning; configured values are kept
+	cpus, err := engine.ParseCPUList(cfg.Stockfish.CPUs)
+	if err != nil {
+		return fail(fmt.Errorf("invalid STOCKFISH_CPUS: %w", err))
+	}
+	var tuning *models.EngineTuning
+	if cfg.Stockfish.AutoTune {
+		tuned := autoTuneEngines(cfg, cpus)
+		tuning = &tuned
+		cfg.Stockfish.MaxEngines, cfg.Stockfish.DefaultThreads, cfg.Stockfish.DefaultHashSize = tuned.MaxEngines, tuned.Threads, tuned.HashSize
+		log.Printf("Engine auto-tuning: %d CPUs and %d MB available, using %d engines with %d threads and %d MB hash each (configured: %v)",
+			tuned.CPUs, tuned.MemoryMB, tuned.MaxEngines, tuned.Threads, tuned.HashSize, tuned.Overridden)
+	}
+
+	// Initialize the analysis service
+	defaultSettings := models.EngineSettings{
+		Depth:      cfg.Stockfish.DefaultDepth,
+		TimeLimit:  cfg.Stockfish.DefaultTimeLimit,
+		Threads:    cfg.Stockfish.DefaultThreads,
+		HashSize:   cfg.Stockfish.DefaultHashSize,
+		SkillLevel: cfg.Stockfish.DefaultSkillLevel,
+		Contempt:   &cfg.Stockfish.DefaultContempt,
+		MultiPV:    1,
+		EvalFile:   cfg.Stockfish.EvalFile,
+	}
+
+	// Without a usable Stockfish the server still starts: engine analysis answers 503 and the
+	// health check reports the analysis capability as missing
+	sandbox := engineSandbox(cfg)
+	analysisService, err := newAnalysisService(cfg, defaultSettings, sandbox)
+	if err != nil {
+		log.Println("Engine analysis unavailable:", err)
+		analysisService = service.NewUnavailableAnalysisService(defaultSettings, err)
+		// Pools added below still start sandboxed, and lazily in lazy mode
+		if err := analysisService.SetEngineSandbox(sandbox); err != nil {
+			return fail(fmt.Errorf("invalid engine sandbox: %w", err))
+		}
+		if cfg.Stockfish.Lazy {
+			analysisService.SetLazyEngines(time.Duration(cfg.Stockfish.IdleTimeout) * time.Second)
+		}
+	}
+	closers = append(closers, func() { analysisService.Close() })
+	// Every service keeps its data in one store, so analyses are seen by collections and synced games
+	store := storage.NewMemoryStore()
+	analysisService.SetStore(store)
+	if tuning != nil {
+		analysisService.SetEngineTuning(*tuning)
+	}
+	cacheSize, evalCacheSize := cfg.Analysis.MaxCacheSize, cfg.Analysis.EvalCacheSize
+	if !cfg.Analysis.EnableCaching {
+		cacheSize, evalCacheSize = 0, 0
+	}
+	analysisService.SetQueueLimit(cfg.Stockfish.MaxQueue)
+	analysisService.SetLoadShedding(models.LoadShedding{
+		QueueLength: cfg.Stockfish.ShedQueueLength,
+		MaxMultiPV:  cfg.Stockfish.ShedMultiPV,
+		MaxDepth:    cfg.Stockfish.ShedDepth,
+	})
+
+	// Keep engines from starving the API server on shared hosts; limits also apply to the pools added below
+	if err := analysisService.SetEngineLimits(models.EngineLimits{
+		Nice:        cfg.Stockfish.Nice,
+		CPUs:        cpus,
+		MaxMemoryMB: cfg.Stockfish.MaxMemoryMB,
+		Cgroup:      cfg.Stockfish.Cgroup,
+		CPUQuota:    cfg.Stockfish.CPUQuota,
+	}); err != nil {
+		return fail(fmt.Errorf("failed to apply engine resource limits: %w", err))
+	}
+	analysisService.SetCacheOptions(cacheSize, time.Duration(cfg.Analysis.CacheExpiration)*time.Minute)
+	analysisService.SetEvalCacheSize(evalCacheSize)
+	analysisService.SetBatchLimit(cfg.Analysis.BatchMaxItems)
+	analysisService.SetStreamingOptions(cfg.Analysis.StreamMinPlies, cfg.Analysis.StreamWindow)
+	if err := analysisService.SetAuditLog(cfg.Analysis.AuditMaxEntries, cfg.Analysis.AuditFile); err != nil {
+		return fail(fmt.Errorf("failed to load the audit log: %w", err))
+	}
+	if err := analysisService.SetAccuracyModel(cfg.Analysis.AccuracyModel); err != nil {
+		return fail(fmt.Errorf("invalid accuracy model: %w", err))
+	}
+
+	// Serve the first plies of games from the opening cache, warming it with popular lines when asked
+	if err := analysisService.SetOpeningCacheOptions(cfg.Analysis.OpeningCachePlies,
+		cfg.Analysis.OpeningCacheMaxPositions, cfg.Analysis.OpeningCacheFile); err != nil {
+		return fail(fmt.Errorf("failed to load opening cache: %w", err))
+	}
+	if err := analysisService.SetBenchmarkFile(cfg.Analysis.BenchmarkFile); err != nil {
+		return fail(fmt.Errorf("failed to load benchmark baseline: %w", err))
+	}
+	if cfg.Analysis.OpeningWarmupLines > 0 && analysisService.EngineAvailable() {
+		warmup := &models.OpeningWarmupRequest{Lines: cfg.Analysis.OpeningWarmupLines}
+		analysisService.ApplyDefaultSettings(&warmup.Settings)
+		if _, err := analysisService.WarmOpeningCache(warmup); err != nil {
+			log.Println("Opening cache warmup unavailable:", err)
+		}
+	}
+
+	// Start additional engine pools; a pool that fails to start is reported unhealthy and the rest keep working
+	for _, pool := range cfg.EnginePools {
+		if err := analysisService.AddEnginePool(engine.PoolConfig{
+			Name:           pool.Name,
+			ExecutablePath: pool.ExecutablePath,
+			Size:           pool.Size,
+			Variants:       pool.Variants,
+			Profiles:       pool.Profiles,
+		}); err != nil {
+			log.Printf("Engine pool %s unavailable: %v", pool.Name, err)
+		}
+	}
+
+	// Plug in the Maia human move model when configured; analysis works without it
+	if cfg.Maia.Enabled {
+		maiaSandbox := sandbox
+		maiaSandbox.ReadPaths = append(slices.Clip(sandbox.ReadPaths), cfg.Maia.WeightsDir)
+		maiaPool, err := engine.NewMaiaPool(cfg.Maia.Engines, cfg.Maia.ExecutablePath, cfg.Maia.WeightsDir, maiaSandbox)
+		if err != nil {
+			log.Println("Maia human move model unavailable:", err)
+		} else {
+			analysisService.SetHumanModel(maiaPool)
+		}
+	}
+
+	// Keep exported artifacts in the configured blob store
+	blobStore, err := newBlobStore(cfg.Blob)
+	if err != nil {
+		return fail(fmt.Errorf("failed to initialize blob store: %w", err))
+	}
+	analysisService.SetBlobStore(blobStore, time.Duration(cfg.Blob.URLExpiry)*time.Minute)
+
+	// Initialize the preferences service
+	preferencesService := service.NewPreferencesService(store)
+
+	// Initialize the analytics service
+	analyticsService := service.NewAnalyticsService(gameService, analysisService)
+
+	// Initialize the notifier delivering reports and notifications to email, chat and webhook channels
+	notifier := notify.New(notify.Settings{
+		Mail: notify.MailSettings{
+			Host:     cfg.Mail.Host,
+			Port:     cfg.Mail.Port,
+			Username: cfg.Mail.Username,
+			Password: cfg.Mail.Password,
+			From:     cfg.Mail.From,
+		},
+		TelegramToken:       cfg.Telegram.BotToken,
+		TelegramURL:         cfg.Telegram.APIURL,
+		AllowPrivateTargets: cfg.Server.AllowPrivateWebhooks,
+	})
+
+	// Initialize the archive sync service and keep configured players up to date in the background
+	syncService := service.NewSyncService(gameService, analysisService, store,
+		cfg.Sync.Players, time.Duration(cfg.Sync.Interval)*time.Minute, cfg.Sync.AutoAnalyze)
+	closers = append(closers, syncService.Close)
+	if cfg.Sync.RawArchives {
+		retention := time.Duration(cfg.Sync.RawArchiveRetention) * 24 * time.Hour
+		if err := syncService.SetRawArchiveStore(context.Background(), blobStore, retention); err != nil {
+			return fail(fmt.Errorf("failed to load raw archives: %w", err))
+		}
+	}
+	notifyChannels, err := notify.ParseChannels(cfg.Sync.NotifyChannels)
+	if err != nil {
+		return fail(fmt.Errorf("invalid sync notification channels: %w", err))
+	}
+	syncService.SetNotifier(notifier)
+	if err := syncService.SetNotificationOptions(service.NotificationOptions{
+		WebhookURL:    cfg.Sync.NotifyWebhook,
+		Channels:      notifyChannels,
+		MilestoneStep: cfg.Sync.RatingMilestone,
+		StreakLength:  cfg.Sync.StreakLength,
+		BlunderCheck:  cfg.Sync.BlunderCheck,
+	}); err != nil {
+		return fail(fmt.Errorf("invalid sync notification settings: %w", err))
+	}
+	// Register the sources games can be ingested from, reached like Chess.com
+	sourceTransport, err := client.NewTransport(client.ClientOptions{
+		ProxyURL:            cfg.ChessAPI.ProxyURL,
+		CABundle:            cfg.ChessAPI.CABundle,
+		MaxIdleConns:        cfg.ChessAPI.MaxIdleConns,
+		MaxIdleConnsPerHost: cfg.ChessAPI.MaxIdleConnsPerHost,
+		MaxConnsPerHost:     cfg.ChessAPI.MaxConnsPerHost,
+		IdleConnTimeout:     time.Duration(cfg.ChessAPI.IdleConnTimeout) * time.Second,
+	})
+	if err != nil {
+		return fail(fmt.Errorf("invalid game source client configuration: %w", err))
+	}
+	lichessAPI := client.NewLichessAPI()
+	lichessAPI.BaseURL = cfg.Sources.LichessURL
+	lichessAPI.ExplorerURL = cfg.Sources.ExplorerURL
+	lichessAPI.Token = cfg.Sources.LichessToken
+	lichessAPI.UserAgent = cfg.ChessAPI.UserAgent
+	lichessAPI.HTTPClient.Transport = sourceTransport
+	twicAPI := client.NewTWICClient()
+	twicAPI.BaseURL = cfg.Sources.TWICURL
+	twicAPI.UserAgent = cfg.ChessAPI.UserAgent
+	twicAPI.HTTPClient.Transport = sourceTransport
+	gameSources := []service.GameSource{
+		service.NewChessComSource(gameService),
+		service.NewLichessSource(lichessAPI),
+		service.NewTWICSource(twicAPI),
+	}
+	if cfg.Sources.PGNDir != "" {
+		gameSources = append(gameSources, service.NewPGNDirectorySource(cfg.Sources.PGNDir))
+	}
+	syncService.SetGameSources(gameSources...)
+	analyticsService.AddOpeningReference(service.NewLichessMastersReference(lichessAPI))
+
+	if len(cfg.Sync.Players) > 0 {
+		syncCtx, stopSync := context.WithCancel(context.Background())
+		closers = append(closers, stopSync)
+		syncService.Start(syncCtx)
+		log.Printf("Syncing archives of %d players every %d minutes", len(cfg.Sync.Players), cfg.Sync.Interval)
+	}
+
+	// Initialize the live game watch service
+	watchService := service.NewWatchService(gameService, analysisService)
+	watchService.SetAllowPrivateWebhooks(cfg.Server.AllowPrivateWebhooks)
+	closers = append(closers, watchService.Close)
+
+	// Initialize the broadcast relay service
+	broadcastService := service.NewBroadcastService(analysisService)
+	closers = append(closers, broadcastService.Close)
+
+	// Initialize the analysis job service for one-call fetch and analyze requests
+	jobService := service.NewAnalysisJobService(gameService, analysisService)
+	jobService.SetSyncPlies(cfg.Analysis.SyncMaxPlies)
+	jobService.SetMaxRunning(cfg.Analysis.MaxRunningJobs)
+	closers = append(closers, jobService.Close)
+	teamService := service.NewTeamService(gameService, analyticsService)
+	closers = append(closers, teamService.Close)
+
+	// Initialize the resumable PGN import service
+	importService := service.NewImportService(cfg.Import.Dir, cfg.Import.MaxSize)
+	importService.SetGameService(gameService)
+	closers = append(closers, importService.Close)
+
+	// Initialize the watchlist service and deliver scheduled reports in the background
+	watchlistService := service.NewWatchlistService(gameService, analysisService, notifier)
+	watchlistCtx, stopWatchlist := context.WithCancel(context.Background())
+	closers = append(closers, stopWatchlist)
+	watchlistService.Start(watchlistCtx)
+
+	services := Services{
+		Game:        gameService,
+		Analysis:    analysisService,
+		Preferences: preferencesService,
+		Analytics:   analyticsService,
+		Sync:        syncService,
+		Watch:       watchService,
+		Broadcast:   broadcastService,
+		Import:      importService,
+		Watchlist:   watchlistService,
+		Play:        service.NewPlayService(analysisService),
+		Training:    service.NewTrainingService(analysisService),
+		Collections: service.NewCollectionService(analysisService, store),
+		Jobs:        jobService,
+		Team:        teamService,
+	}
+	return services, closeAll, nil
+}
+
+// newAnalysisService validates (or downloads) the NNUE network and starts the default engine pool,
+// whose engines start on first use in lazy mode
+func newAnalysisService(cfg *Config, defaultSettings models.EngineSettings, sandbox models.EngineSandbox) (*service.AnalysisService, error) {
+	if err := engine.PrepareEvalFile(cfg.Stockfish.ExecutablePath, cfg.Stockfish.EvalFile, cfg.Stockfish.DownloadEvalFile, sandbox); err != nil {
+		return nil, fmt.Errorf("invalid NNUE evaluation file: %w", err)
+	}
+	if cfg.Stockfish.Lazy {
+		idleTimeout := time.Duration(cfg.Stockfish.IdleTimeout) * time.Second
+		return service.NewLazyAnalysisService(cfg.Stockfish.ExecutablePath, cfg.Stockfish.MaxEngines, defaultSettings, sandbox, idleTimeout)
+	}
+	return service.NewSandboxedAnalysisService(cfg.Stockfish.ExecutablePath, cfg.Stockfish.MaxEngines, defaultSettings, sandbox)
+}
+
+// engineSandbox returns the restrictions engines run under. Read-only engines may also read the
+// configured NNUE network.
+func engineSandbox(cfg *Config) models.EngineSandbox {
+	sandbox := models.EngineSandbox{
+		User:      cfg.Stockfish.SandboxUser,
+		NoNetwork: cfg.Stockfish.SandboxNoNetwork,
+		ReadOnly:  cfg.Stockfish.SandboxReadOnly,
+		ReadPaths: cfg.Stockfish.SandboxReadPaths,
+		Seccomp:   cfg.Stockfish.SandboxSeccomp,
+		AppArmor:  cfg.Stockfish.SandboxAppArmor,
+	}
+	if sandbox.ReadOnly && cfg.Stockfish.EvalFile != "" {
+		sandbox.ReadPaths = append(slices.Clip(sandbox.ReadPaths), cfg.Stockfish.EvalFile)
+	}
+	return sandbox
+}
+
+// autoTuneEngines derives the default pool's size, threads and hash from the CPUs and memory
+// engines may use, keeping the values set in the configuration
+func autoTuneEngines(cfg *Config, cpus []int) models.EngineTuning {
+	host := engine.DetectHostResources()
+	if len(cpus) > 0 && len(cpus) < host.CPUs {
+		host.CPUs = len(cpus)
+	}
+	if quota := cfg.Stockfish.CPUQuota / 100; cfg.Stockfish.CPUQuota > 0 && quota < host.CPUs {
+		host.CPUs = max(quota, 1)
+	}
+	return engine.AutoTune(host, models.EngineTuning{
+		MaxEngines: cfg.Stockfish.MaxEngines,
+		Threads:    cfg.Stockfish.DefaultThreads,
+		HashSize:   cfg.Stockfish.DefaultHashSize,
+	})
+}
+
+// newBlobStore creates the blob store selected by the configuration
+func newBlobStore(cfg config.BlobConfig) (blob.Store, error) {
+	switch cfg.Backend {
+	case "local":
+		if cfg.Dir == "" {
+			return nil, fmt.Errorf("BLOB_DIR must be set for the local blob backend")
+		}
+		return blob.NewLocalStore(cfg.Dir, "/blobs", []byte(cfg.SigningKey))
+	case "s3":
+		return blob.NewS3Store(blob.S3Config{
+			Endpoint:  cfg.Endpoint,
+			Region:    cfg.Region,
+			Bucket:    cfg.Bucket,
+			AccessKey: cfg.AccessKey,
+			SecretKey: cfg.SecretKey,
+			PathStyle: cfg.PathStyle,
+		})
+	case "gcs":
+		return blob.NewGCSStore(cfg.Bucket, cfg.AccessKey, cfg.SecretKey)
+	}
+	return nil, fmt.Errorf("unknown blob backend %q", cfg.Backend)
+}
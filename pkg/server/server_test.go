@@ -0,0 +1,125 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pedrampdd/ChessAnalyser/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type greeter struct{ greeting string }
+
+func TestServer_Extensions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var order []string
+	srv := NewServer(
+		WithMiddleware(func(c *gin.Context) {
+			order = append(order, "first")
+			c.Next()
+		}, func(c *gin.Context) {
+			order = append(order, "second")
+			c.Header("X-Embedded", "yes")
+			c.Next()
+		}),
+		WithService("greeter", &greeter{greeting: "hello"}),
+		WithRoutes(func(api *gin.RouterGroup) {
+			api.GET("/greeting", func(c *gin.Context) {
+				g, ok := GetService[*greeter](c, "greeter")
+				if !ok {
+					c.Status(http.StatusInternalServerError)
+					return
+				}
+				if _, ok := GetService[string](c, "greeter"); ok {
+					t.Error("Expected a service of another type not to be returned")
+				}
+				if _, ok := GetService[*greeter](c, "missing"); ok {
+					t.Error("Expected an unregistered service not to be returned")
+				}
+				c.String(http.StatusOK, g.greeting)
+			})
+		}),
+	)
+
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/greeting", nil))
+
+	if w.Code != http.StatusOK || w.Body.String() != "hello" {
+		t.Fatalf("GET /api/greeting = %d %q, want 200 \"hello\"", w.Code, w.Body.String())
+	}
+	if w.Header().Get("X-Embedded") != "yes" {
+		t.Error("Expected the custom middleware to run")
+	}
+	if len(order) != 2 || order[0] != "first" || order[1] != "second" {
+		t.Errorf("Middleware ran in order %v, want [first second]", order)
+	}
+	if w.Header().Get("Access-Control-Allow-Origin") != "*" {
+		t.Error("Expected the built-in middleware to keep running")
+	}
+}
+
+func TestServer_Versions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	srv := NewServer(
+		WithRouterOptions(RouterOptions{LegacySunset: time.Date(2027, 6, 30, 0, 0, 0, 0, time.UTC)}),
+		WithRoutes(func(api *gin.RouterGroup) {
+			api.GET("/moves", func(c *gin.Context) {
+				c.JSON(http.StatusOK, models.APIResponse{Success: true, Data: []models.MoveAnalysis{
+					{Move: "e4", MoveNumber: 1, Inaccuracy: true},
+					{Move: "e5", MoveNumber: 2},
+				}})
+			})
+		}),
+	)
+	handler := srv.Handler()
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s = %d, want 200", path, w.Code)
+		}
+		return w
+	}
+
+	// v1 is served as the handlers build it
+	v1 := get("/api/v1/moves")
+	if !strings.Contains(v1.Body.String(), `"inaccuracy":true`) || strings.Contains(v1.Body.String(), "classification") {
+		t.Errorf("Unexpected v1 body: %s", v1.Body.String())
+	}
+	if v1.Header().Get("Deprecation") != "" {
+		t.Error("Expected v1 not to be deprecated")
+	}
+
+	// v2 replaces the classification booleans with an enum
+	var v2 struct {
+		Data []map[string]any `json:"data"`
+	}
+	body := get("/api/v2/moves").Body.Bytes()
+	if err := json.Unmarshal(body, &v2); err != nil {
+		t.Fatalf("Invalid v2 body %s: %v", body, err)
+	}
+	if len(v2.Data) != 2 || v2.Data[0]["classification"] != "inaccuracy" || v2.Data[1]["classification"] != "good" {
+		t.Errorf("Unexpected v2 classifications: %s", body)
+	}
+	if _, ok := v2.Data[0]["inaccuracy"]; ok {
+		t.Errorf("Expected v2 to drop the classification booleans: %s", body)
+	}
+
+	// The unversioned routes are a deprecated alias of v1
+	legacy := get("/api/moves")
+	if legacy.Body.String() != v1.Body.String() {
+		t.Errorf("Expected /api to serve v1, got %s", legacy.Body.String())
+	}
+	if legacy.Header().Get("Deprecation") != "true" || legacy.Header().Get("Sunset") != "Wed, 30 Jun 2027 00:00:00 GMT" ||
+		legacy.Header().Get("Link") != `</api/v1/moves>; rel="successor-version"` {
+		t.Errorf("Unexpected deprecation headers: %v", legacy.Header())
+	}
+}